@@ -11,9 +11,11 @@ import (
 	// Uncomment if you want to run locally against remote GKE cluster.
 	// _ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"knative.dev/eventing/pkg/adapter/v2"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/injection/clients/dynamicclient"
 	"knative.dev/pkg/injection/sharedmain"
 	"knative.dev/pkg/signals"
 
@@ -22,7 +24,9 @@ import (
 
 func main() {
 	ctx := signals.NewContext()
-	kc := kubernetes.NewForConfigOrDie(sharedmain.ParseAndGetConfigOrDie())
-	ctx = context.WithValue(ctx, kubeclient.Key{}, kc)
+	cfg := sharedmain.ParseAndGetConfigOrDie()
+	ctx = context.WithValue(ctx, kubeclient.Key{}, kubernetes.NewForConfigOrDie(cfg))
+	// the adapter reads the logging level of its source
+	ctx = context.WithValue(ctx, dynamicclient.Key{}, dynamic.NewForConfigOrDie(cfg))
 	adapter.MainWithContext(ctx, "vspheresource", vsphere.NewEnvConfig, vsphere.NewAdapter)
 }
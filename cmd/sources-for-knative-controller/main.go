@@ -10,6 +10,9 @@ import (
 	"os"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	cminformer "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap"
+	"knative.dev/pkg/client/injection/kube/informers/core/v1/secret"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/injection"
@@ -25,8 +28,12 @@ import (
 	"knative.dev/pkg/webhook/resourcesemantics/defaulting"
 	"knative.dev/pkg/webhook/resourcesemantics/validation"
 
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/config"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	templateinformer "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/informers/sources/v1alpha1/vspheresourcetemplate"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/scope"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspherebinding"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vsphereinventorysource"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource"
 )
 
@@ -34,9 +41,28 @@ var types = map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
 	// List the types to validate.
 	v1alpha1.SchemeGroupVersion.WithKind("VSphereSource"):  &v1alpha1.VSphereSource{},
 	v1alpha1.SchemeGroupVersion.WithKind("VSphereBinding"): &v1alpha1.VSphereBinding{},
+
+	v1alpha1.SchemeGroupVersion.WithKind("VSphereInventorySource"): &v1alpha1.VSphereInventorySource{},
+	v1alpha1.SchemeGroupVersion.WithKind("VSphereSourceTemplate"):  &v1alpha1.VSphereSourceTemplate{},
+}
+
+// templates returns the getter of the VSphereSourceTemplates referenced by
+// VSphereSources, which reads them from the informer cache.
+func templates(ctx context.Context) v1alpha1.TemplateGetter {
+	lister := templateinformer.Get(ctx).Lister()
+	return func(_ context.Context, name string) (*v1alpha1.VSphereSourceTemplate, error) {
+		return lister.Get(name)
+	}
 }
 
 func NewDefaultingAdmissionController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	// The defaults of the VSphereSources come from the config-vsphere
+	// ConfigMaps of the cluster and of their namespace, and their templates.
+	store := config.NewStore(logging.FromContext(ctx).Named("config-store"))
+	store.WatchConfigs(cmw)
+	cmLister := cminformer.Get(ctx).Lister()
+	getTemplate := templates(ctx)
+
 	return defaulting.NewAdmissionController(ctx,
 
 		// Name of the resource webhook.
@@ -50,9 +76,7 @@ func NewDefaultingAdmissionController(ctx context.Context, cmw configmap.Watcher
 
 		// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
 		func(ctx context.Context) context.Context {
-			// Here is where you would infuse the context with state
-			// (e.g. attach a store with configmap data)
-			return ctx
+			return v1alpha1.WithTemplates(config.WithConfigMapLister(store.ToContext(ctx), cmLister), getTemplate)
 		},
 
 		// Whether to disallow unknown fields.
@@ -61,6 +85,11 @@ func NewDefaultingAdmissionController(ctx context.Context, cmw configmap.Watcher
 }
 
 func NewValidationAdmissionController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	// The secrets and templates referenced by the resources are looked up to
+	// reject missing credentials before the adapters fail to log in.
+	secrets := kubeclient.Get(ctx).CoreV1()
+	getTemplate := templates(ctx)
+
 	return validation.NewAdmissionController(ctx,
 
 		// Name of the resource webhook.
@@ -74,9 +103,7 @@ func NewValidationAdmissionController(ctx context.Context, cmw configmap.Watcher
 
 		// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
 		func(ctx context.Context) context.Context {
-			// Here is where you would infuse the context with state
-			// (e.g. attach a store with configmap data)
-			return ctx
+			return v1alpha1.WithTemplates(v1alpha1.WithSecrets(ctx, secrets), getTemplate)
 		},
 
 		// Whether to disallow unknown fields.
@@ -95,8 +122,9 @@ func NewConfigValidationController(ctx context.Context, cmw configmap.Watcher) *
 
 		// The configmaps to validate.
 		configmap.Constructors{
-			logging.ConfigMapName(): logging.NewConfigFromConfigMap,
-			metrics.ConfigMapName(): metrics.NewObservabilityConfigFromConfigMap,
+			logging.ConfigMapName():   logging.NewConfigFromConfigMap,
+			metrics.ConfigMapName():   metrics.NewObservabilityConfigFromConfigMap,
+			config.DefaultsConfigName: config.NewDefaultsFromConfigMap,
 		},
 	)
 }
@@ -113,17 +141,22 @@ func NewVSphereBindingWebhook(opts ...psbinding.ReconcilerOption) injection.Cont
 			// How to get all the Bindables for configuring the mutating webhook.
 			vspherebinding.ListAll,
 
-			// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
-			func(ctx context.Context, _ psbinding.Bindable) (context.Context, error) {
-				// Here is where you would infuse the context with state
-				// (e.g. attach a store with configmap data)
-				return ctx, nil
-			},
+			// A function that infuses the context passed to Do/Undo with the
+			// version of the credentials of the binding.
+			vspherebinding.WithCredentialsVersion(secret.Get(ctx).Lister()),
 			opts...,
 		)
 	}
 }
 
+// scoped restricts the given controller to the resources of the given
+// namespaces, or all namespaces if none are given.
+func scoped(namespaces []string, ctor injection.ControllerConstructor) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		return ctor(scope.WithNamespaces(ctx, namespaces), cmw)
+	}
+}
+
 func main() {
 	ctx := webhook.WithOptions(signals.NewContext(), webhook.Options{
 		ServiceName: "webhook",
@@ -136,6 +169,10 @@ func main() {
 		vsbSelector = psbinding.WithSelector(psbinding.InclusionSelector)
 	}
 
+	// Restrict the controllers and the binding webhook to these namespaces in
+	// multi-tenant clusters, see hack/namespaced-rbac.sh.
+	namespaces := scope.ParseNamespaces(os.Getenv("VSPHERE_NAMESPACES"))
+
 	sharedmain.WebhookMainWithConfig(ctx, "webhook", sharedmain.ParseAndGetConfigOrDie(),
 		certificates.NewController,
 		NewDefaultingAdmissionController,
@@ -143,9 +180,11 @@ func main() {
 		NewConfigValidationController,
 
 		// For each binding we have a controller and a binding webhook.
-		vspherebinding.NewController, NewVSphereBindingWebhook(vsbSelector),
+		scoped(namespaces, vspherebinding.NewController),
+		scoped(namespaces, NewVSphereBindingWebhook(vsbSelector)),
 
-		// Also run our source controller here.
-		vspheresource.NewController,
+		// Also run our source controllers here.
+		scoped(namespaces, vspheresource.NewController),
+		scoped(namespaces, vsphereinventorysource.NewController),
 	)
 }
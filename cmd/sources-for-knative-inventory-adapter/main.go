@@ -0,0 +1,18 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/signals"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+)
+
+func main() {
+	ctx := signals.NewContext()
+	adapter.MainWithContext(ctx, "vsphereinventorysource", vsphere.NewInventoryEnvConfig, vsphere.NewInventoryAdapter)
+}
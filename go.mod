@@ -3,24 +3,33 @@ module github.com/vmware-tanzu/sources-for-knative
 go 1.15
 
 require (
+	github.com/Shopify/sarama v1.25.0
 	github.com/braintree/manners v0.0.0-20160418043613-82a8879fc5fd // indirect
+	github.com/cloudevents/sdk-go/protocol/kafka_sarama/v2 v2.4.1
 	github.com/cloudevents/sdk-go/v2 v2.4.1
 	github.com/codegangsta/cli v0.0.0-00010101000000-000000000000 // indirect
 	github.com/davecgh/go-spew v1.1.1
 	github.com/elazarl/go-bindata-assetfs v1.0.0 // indirect
 	github.com/fatih/structs v1.1.0 // indirect
+	github.com/google/cel-go v0.7.3
 	github.com/google/go-cmp v0.5.5
+	github.com/google/uuid v1.2.0
 	github.com/hashicorp/go-multierror v1.1.0 // indirect
 	github.com/jpillora/backoff v1.0.0
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/kr/pty v1.1.8 // indirect
 	github.com/spf13/cobra v1.1.3
 	github.com/vmware/govmomi v0.24.1-0.20210127152625-854ba4efe87e
+	github.com/xdg-go/scram v1.0.2
 	github.com/yudai/gotty v1.0.1
 	github.com/yudai/hcl v0.0.0-20151013225006-5fa2393b3552 // indirect
 	github.com/yudai/umutex v0.0.0-20150817080136-18216d265c6b // indirect
+	go.opencensus.io v0.23.0
 	go.uber.org/zap v1.16.0
 	golang.org/x/crypto v0.0.0-20210415154028-4f45737414dc
+	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
+	golang.org/x/time v0.0.0-20210220033141-f8bda1e9f3ba
+	google.golang.org/protobuf v1.26.0
 	gotest.tools v2.2.0+incompatible
 	k8s.io/api v0.19.7
 	k8s.io/apimachinery v0.19.7
@@ -30,6 +39,7 @@ require (
 	knative.dev/eventing v0.22.1-0.20210423044837-a0a33025aee0
 	knative.dev/hack v0.0.0-20210325223819-b6ab329907d3
 	knative.dev/pkg v0.0.0-20210422210038-0c5259d6504d
+	sigs.k8s.io/yaml v1.2.0
 )
 
 replace (
@@ -0,0 +1,146 @@
+#!/usr/bin/env bash
+
+# Copyright 2020 VMware, Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+# Prints the RBAC manifests which restrict the write access of the controller
+# to the given namespaces and its system namespace. Apply them after the
+# manifests in config/ and set VSPHERE_NAMESPACES of the webhook deployment to
+# the same namespaces, e.g.
+#
+#   ko apply -f config
+#   hack/namespaced-rbac.sh team-a team-b | kubectl apply -f -
+#   kubectl -n vmware-sources set env deployment/webhook VSPHERE_NAMESPACES=team-a,team-b
+#
+# The controller keeps read access to all namespaces, because its informers
+# watch the whole cluster.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+SYSTEM_NAMESPACE=${SYSTEM_NAMESPACE:-vmware-sources}
+
+if [[ $# -eq 0 ]]; then
+  echo "usage: $0 NAMESPACE..." >&2
+  exit 1
+fi
+
+cat <<YAML
+# Generated by hack/namespaced-rbac.sh for namespaces: $*
+
+# Replaces the rules of config/200-clusterrole.yaml with read-only access to
+# namespaced resources.
+kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: vmware-sources-core
+  labels:
+    sources.tanzu.vmware.com/release: devel
+    sources.tanzu.vmware.com/controller: "true"
+rules:
+  - apiGroups: [""]
+    resources: ["configmaps", "services", "secrets", "events", "serviceaccounts"]
+    verbs: ["get", "list", "watch"]
+  - apiGroups: [""]
+    resources: ["namespaces"]
+    verbs: ["get", "list", "update", "patch", "watch"]
+  - apiGroups: ["apps"]
+    resources: ["deployments"]
+    verbs: ["get", "list", "watch"]
+  - apiGroups: ["admissionregistration.k8s.io"]
+    resources: ["mutatingwebhookconfigurations", "validatingwebhookconfigurations"]
+    verbs: ["get", "list", "create", "update", "delete", "patch", "watch"]
+  - apiGroups: ["apiextensions.k8s.io"]
+    resources: ["customresourcedefinitions"]
+    verbs: ["get", "list", "create", "update", "delete", "patch", "watch"]
+  - apiGroups: ["coordination.k8s.io"]
+    resources: ["leases"]
+    verbs: ["get", "list", "watch"]
+  - apiGroups: ["rbac.authorization.k8s.io"]
+    resources: ["rolebindings"]
+    verbs: ["get", "list", "watch"]
+  - apiGroups: ["sources.tanzu.vmware.com"]
+    resources: ["*"]
+    verbs: ["get", "list", "watch"]
+  - apiGroups: ["eventing.knative.dev"]
+    resources: ["eventtypes"]
+    verbs: ["get", "list", "watch"]
+---
+# Replaces the rules of config/200-podspecable-binding-clusterrole.yaml, the
+# subjects of bindings are patched with vmware-sources-namespaced instead.
+kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: builtin-podspecable-binding
+  labels:
+    eventing.knative.dev/release: devel
+    duck.knative.dev/podspecable: "true"
+rules:
+  - apiGroups: ["apps"]
+    resources: ["deployments", "daemonsets", "statefulsets", "replicasets"]
+    verbs: ["list", "watch"]
+  - apiGroups: ["batch"]
+    resources: ["jobs"]
+    verbs: ["list", "watch"]
+---
+# The write access of the controller, granted per namespace.
+kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: vmware-sources-namespaced
+  labels:
+    sources.tanzu.vmware.com/release: devel
+rules:
+  - apiGroups: [""]
+    resources: ["configmaps", "services", "secrets", "events", "serviceaccounts"]
+    verbs: ["get", "list", "create", "update", "delete", "patch", "watch"]
+  - apiGroups: [""]
+    resources: ["serviceaccounts/token"]
+    verbs: ["create"]
+  - apiGroups: ["apps"]
+    resources: ["deployments", "deployments/finalizers"]
+    verbs: ["get", "list", "create", "update", "delete", "patch", "watch"]
+  - apiGroups: ["apps"]
+    resources: ["daemonsets", "statefulsets", "replicasets"]
+    verbs: ["list", "watch", "patch"]
+  - apiGroups: ["policy"]
+    resources: ["poddisruptionbudgets"]
+    verbs: ["get", "list", "create", "update", "delete", "patch", "watch"]
+  - apiGroups: ["batch"]
+    resources: ["jobs"]
+    verbs: ["list", "watch", "patch"]
+  - apiGroups: ["coordination.k8s.io"]
+    resources: ["leases"]
+    verbs: ["get", "list", "create", "update", "delete", "patch", "watch"]
+  - apiGroups: ["rbac.authorization.k8s.io"]
+    resources: ["rolebindings"]
+    verbs: ["get", "list", "create", "update", "delete", "patch", "watch"]
+  - apiGroups: ["sources.tanzu.vmware.com"]
+    resources: ["*"]
+    verbs: ["get", "list", "create", "update", "delete", "deletecollection", "patch", "watch"]
+  - apiGroups: ["eventing.knative.dev"]
+    resources: ["eventtypes"]
+    verbs: ["get", "list", "create", "update", "delete", "patch", "watch"]
+YAML
+
+for ns in "${SYSTEM_NAMESPACE}" "$@"; do
+  cat <<YAML
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: vmware-sources-controller-namespaced
+  namespace: ${ns}
+  labels:
+    sources.tanzu.vmware.com/release: devel
+subjects:
+  - kind: ServiceAccount
+    name: controller
+    namespace: ${SYSTEM_NAMESPACE}
+roleRef:
+  kind: ClusterRole
+  name: vmware-sources-namespaced
+  apiGroup: rbac.authorization.k8s.io
+YAML
+done
@@ -0,0 +1,47 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// schemagen writes the JSON schemas and protobuf definitions of the event
+// payloads emitted by the adapters into the given directory.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s <output directory>\n", os.Args[0])
+		os.Exit(2)
+	}
+	dir := os.Args[1]
+
+	for name, v := range vsphere.DataSchemas {
+		b, err := vsphere.JSONSchema(name, v)
+		if err != nil {
+			log.Fatalf("generate schema %q: %v", name, err)
+		}
+
+		file := filepath.Join(dir, name+".json")
+		if err = ioutil.WriteFile(file, append(b, '\n'), 0644); err != nil {
+			log.Fatalf("write schema %q: %v", file, err)
+		}
+
+		if b, err = vsphere.ProtoSchema(name, v); err != nil {
+			log.Fatalf("generate protobuf definition %q: %v", name, err)
+		}
+
+		file = filepath.Join(dir, name+".proto")
+		if err = ioutil.WriteFile(file, b, 0644); err != nil {
+			log.Fatalf("write protobuf definition %q: %v", file, err)
+		}
+	}
+}
@@ -35,6 +35,12 @@ ${KNATIVE_CODEGEN_PKG}/hack/generate-knative.sh "injection" \
   "sources:v1alpha1" \
   --go-header-file ${REPO_ROOT_DIR}/hack/boilerplate/boilerplate.go.txt
 
+group "Payload Schemas"
+
+# Schemas and protobuf definitions of the event payloads, referenced by the
+# dataschema attribute
+go run ${REPO_ROOT_DIR}/hack/schemagen ${REPO_ROOT_DIR}/schemas
+
 group "Update deps post-codegen"
 
 # Make sure our dependencies are up-to-date
@@ -0,0 +1,142 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	cm "knative.dev/pkg/configmap"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+)
+
+const (
+	// DefaultsConfigName is the name of the ConfigMap with the defaults of
+	// the VSphereSources, either in the system namespace for the whole
+	// cluster or in the namespace of the sources to override them.
+	DefaultsConfigName = "config-vsphere"
+
+	// DefaultCheckpointPeriod is the checkpoint period of sources which do not
+	// configure checkpointing, unless overridden.
+	DefaultCheckpointPeriod = 10 * time.Second
+)
+
+// Defaults holds the defaults of the VSphereSources.
+type Defaults struct {
+	// AdapterImage is the image of the receive adapter. If empty, the image
+	// configured with the controller is used.
+	AdapterImage string
+
+	// The resources of the receive adapter container, unset if nil.
+	AdapterCPURequest    *resource.Quantity
+	AdapterMemoryRequest *resource.Quantity
+	AdapterCPULimit      *resource.Quantity
+	AdapterMemoryLimit   *resource.Quantity
+
+	// CheckpointMaxAge and CheckpointPeriod are the checkpoint settings of
+	// sources which do not configure checkpointing when they are created.
+	CheckpointMaxAge time.Duration
+	CheckpointPeriod time.Duration
+
+	// TLSMinVersion and TLSCipherSuites restrict the TLS connections of the
+	// receive adapters to vCenter and the sink, the Go defaults if empty.
+	TLSMinVersion   string
+	TLSCipherSuites string
+}
+
+// NewDefaultsFromMap creates Defaults from the supplied map, using the
+// built-in defaults for missing keys.
+func NewDefaultsFromMap(data map[string]string) (*Defaults, error) {
+	d := &Defaults{
+		CheckpointPeriod: DefaultCheckpointPeriod,
+	}
+	return d.WithOverrides(data)
+}
+
+// NewDefaultsFromConfigMap creates Defaults from the supplied ConfigMap.
+func NewDefaultsFromConfigMap(config *corev1.ConfigMap) (*Defaults, error) {
+	return NewDefaultsFromMap(config.Data)
+}
+
+// WithOverrides returns a copy of the defaults with the values of the
+// supplied map, e.g. the data of the ConfigMap in the namespace of a source.
+func (d *Defaults) WithOverrides(data map[string]string) (*Defaults, error) {
+	nd := *d
+
+	if err := cm.Parse(data,
+		cm.AsString("adapter-image", &nd.AdapterImage),
+		cm.AsQuantity("adapter-cpu-request", &nd.AdapterCPURequest),
+		cm.AsQuantity("adapter-memory-request", &nd.AdapterMemoryRequest),
+		cm.AsQuantity("adapter-cpu-limit", &nd.AdapterCPULimit),
+		cm.AsQuantity("adapter-memory-limit", &nd.AdapterMemoryLimit),
+		cm.AsDuration("checkpoint-max-age", &nd.CheckpointMaxAge),
+		cm.AsDuration("checkpoint-period", &nd.CheckpointPeriod),
+		cm.AsString("tls-min-version", &nd.TLSMinVersion),
+		cm.AsString("tls-cipher-suites", &nd.TLSCipherSuites),
+	); err != nil {
+		return nil, fmt.Errorf("failed to parse data: %w", err)
+	}
+
+	if nd.CheckpointMaxAge < 0 {
+		return nil, fmt.Errorf("checkpoint-max-age must not be negative, was %v", nd.CheckpointMaxAge)
+	}
+	if nd.CheckpointPeriod < time.Second {
+		return nil, fmt.Errorf("checkpoint-period must be at least 1s, was %v", nd.CheckpointPeriod)
+	}
+	if nd.CheckpointMaxAge > 0 && nd.CheckpointPeriod > nd.CheckpointMaxAge {
+		return nil, fmt.Errorf("checkpoint-period must not be larger than checkpoint-max-age %v, was %v",
+			nd.CheckpointMaxAge, nd.CheckpointPeriod)
+	}
+	if err := nd.TLSConfig().Validate(); err != nil {
+		return nil, fmt.Errorf("invalid tls-min-version or tls-cipher-suites: %w", err)
+	}
+
+	return &nd, nil
+}
+
+// DeepCopy returns a copy of the defaults.
+func (d *Defaults) DeepCopy() *Defaults {
+	nd := *d
+	for _, q := range []**resource.Quantity{&nd.AdapterCPURequest, &nd.AdapterMemoryRequest, &nd.AdapterCPULimit, &nd.AdapterMemoryLimit} {
+		if *q != nil {
+			c := (*q).DeepCopy()
+			*q = &c
+		}
+	}
+	return &nd
+}
+
+// AdapterResources returns the resource requirements of the receive adapter
+// container.
+func (d *Defaults) AdapterResources() corev1.ResourceRequirements {
+	var r corev1.ResourceRequirements
+	add := func(l *corev1.ResourceList, name corev1.ResourceName, q *resource.Quantity) {
+		if q == nil {
+			return
+		}
+		if *l == nil {
+			*l = corev1.ResourceList{}
+		}
+		(*l)[name] = *q
+	}
+	add(&r.Requests, corev1.ResourceCPU, d.AdapterCPURequest)
+	add(&r.Requests, corev1.ResourceMemory, d.AdapterMemoryRequest)
+	add(&r.Limits, corev1.ResourceCPU, d.AdapterCPULimit)
+	add(&r.Limits, corev1.ResourceMemory, d.AdapterMemoryLimit)
+	return r
+}
+
+// TLSConfig returns the TLS configuration of the connections of the receive
+// adapters to vCenter and the sink.
+func (d *Defaults) TLSConfig() vsphere.TLSConfig {
+	return vsphere.TLSConfig{
+		MinVersion:   d.TLSMinVersion,
+		CipherSuites: d.TLSCipherSuites,
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDefaultsConfigurationFromFile(t *testing.T) {
+	b, err := ioutil.ReadFile("../../../config/" + DefaultsConfigName + ".yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cm corev1.ConfigMap
+	if err := yaml.Unmarshal(b, &cm); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewDefaultsFromConfigMap(&cm); err != nil {
+		t.Errorf("NewDefaultsFromConfigMap(actual) = %v", err)
+	}
+
+	// The example block is a valid configuration, too.
+	var example map[string]string
+	if err := yaml.Unmarshal([]byte(cm.Data["_example"]), &example); err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDefaultsFromMap(example)
+	if err != nil {
+		t.Fatalf("NewDefaultsFromMap(example) = %v", err)
+	}
+	if got, want := d.CheckpointPeriod, DefaultCheckpointPeriod; got != want {
+		t.Errorf("example checkpoint period = %v, want %v", got, want)
+	}
+}
+
+func TestNewDefaultsFromMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		want    *Defaults
+		wantErr bool
+	}{{
+		name: "empty",
+		data: map[string]string{},
+		want: &Defaults{CheckpointPeriod: DefaultCheckpointPeriod},
+	}, {
+		name: "all set",
+		data: map[string]string{
+			"adapter-image":        "example.com/adapter",
+			"adapter-cpu-request":  "25m",
+			"adapter-memory-limit": "250Mi",
+			"checkpoint-max-age":   "5m",
+			"checkpoint-period":    "30s",
+			"tls-min-version":      "1.2",
+			"tls-cipher-suites":    "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+		},
+		want: &Defaults{
+			AdapterImage:       "example.com/adapter",
+			AdapterCPURequest:  quantity("25m"),
+			AdapterMemoryLimit: quantity("250Mi"),
+			CheckpointMaxAge:   5 * time.Minute,
+			CheckpointPeriod:   30 * time.Second,
+			TLSMinVersion:      "1.2",
+			TLSCipherSuites:    "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+		},
+	}, {
+		name:    "invalid quantity",
+		data:    map[string]string{"adapter-cpu-limit": "lots"},
+		wantErr: true,
+	}, {
+		name:    "period too short",
+		data:    map[string]string{"checkpoint-period": "100ms"},
+		wantErr: true,
+	}, {
+		name:    "period larger than max age",
+		data:    map[string]string{"checkpoint-max-age": "10s", "checkpoint-period": "1m"},
+		wantErr: true,
+	}, {
+		name:    "unsupported tls version",
+		data:    map[string]string{"tls-min-version": "1.4"},
+		wantErr: true,
+	}, {
+		name:    "unsupported cipher suite",
+		data:    map[string]string{"tls-cipher-suites": "TLS_RSA_WITH_NULL"},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDefaultsFromMap(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDefaultsFromMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("NewDefaultsFromMap() (-want, +got) = %s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestAdapterResources(t *testing.T) {
+	d := &Defaults{
+		AdapterCPURequest:  quantity("25m"),
+		AdapterMemoryLimit: quantity("250Mi"),
+	}
+	want := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("25m")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("250Mi")},
+	}
+	if got := d.AdapterResources(); !cmp.Equal(got, want) {
+		t.Errorf("AdapterResources() (-want, +got) = %s", cmp.Diff(want, got))
+	}
+
+	if got := (&Defaults{}).AdapterResources(); !cmp.Equal(got, corev1.ResourceRequirements{}) {
+		t.Errorf("AdapterResources() = %v, want no resources", got)
+	}
+}
+
+func TestDefaultsForNamespace(t *testing.T) {
+	cluster, err := NewDefaultsFromMap(map[string]string{"adapter-image": "example.com/cluster"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := ToContext(context.Background(), &Config{Defaults: cluster})
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := indexer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultsConfigName, Namespace: "team-a"},
+		Data:       map[string]string{"checkpoint-period": "1m"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	lister := corev1listers.NewConfigMapLister(indexer)
+
+	d, err := DefaultsForNamespace(ctx, lister, "team-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.AdapterImage != "example.com/cluster" || d.CheckpointPeriod != time.Minute {
+		t.Errorf("DefaultsForNamespace(team-a) = %+v, want the cluster image and the namespace period", d)
+	}
+
+	d, err = DefaultsForNamespace(ctx, lister, "team-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(d, cluster) {
+		t.Errorf("DefaultsForNamespace(team-b) = %+v, want the cluster defaults", d)
+	}
+}
+
+func quantity(s string) *resource.Quantity {
+	q := resource.MustParse(s)
+	return &q
+}
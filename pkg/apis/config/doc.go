@@ -0,0 +1,9 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package config holds the configuration of the VSphereSources which
+// operators can change through ConfigMaps without redeploying the
+// controller.
+package config
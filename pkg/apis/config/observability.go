@@ -0,0 +1,35 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/metrics"
+)
+
+// Observability holds the data of the config-observability ConfigMap, which
+// configures the metrics backend and the profiling server of the adapters.
+type Observability struct {
+	Data map[string]string
+}
+
+// NewObservabilityFromConfigMap creates an Observability from the supplied
+// ConfigMap after validating it like the Knative components do.
+func NewObservabilityFromConfigMap(config *corev1.ConfigMap) (*Observability, error) {
+	if _, err := metrics.NewObservabilityConfigFromConfigMap(config); err != nil {
+		return nil, err
+	}
+	return &Observability{Data: config.Data}, nil
+}
+
+// DeepCopy returns a copy of the observability config.
+func (o *Observability) DeepCopy() *Observability {
+	data := make(map[string]string, len(o.Data))
+	for k, v := range o.Data {
+		data[k] = v
+	}
+	return &Observability{Data: data}
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewObservabilityFromConfigMap(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config-observability", Namespace: "vmware-sources"},
+		Data: map[string]string{
+			"metrics.backend-destination": "prometheus",
+			"profiling.enable":            "true",
+		},
+	}
+	o, err := NewObservabilityFromConfigMap(cm)
+	if err != nil {
+		t.Fatalf("NewObservabilityFromConfigMap() = %v", err)
+	}
+	if !cmp.Equal(o.Data, cm.Data) {
+		t.Errorf("NewObservabilityFromConfigMap() data = %v, want %v", o.Data, cm.Data)
+	}
+
+	cm.Data["profiling.enable"] = "maybe"
+	if _, err := NewObservabilityFromConfigMap(cm); err == nil {
+		t.Error("NewObservabilityFromConfigMap() = nil, want error for invalid profiling flag")
+	}
+}
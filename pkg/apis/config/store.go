@@ -0,0 +1,124 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"context"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+type cfgKey struct{}
+
+type listerKey struct{}
+
+// Config holds the collection of configurations that we attach to contexts.
+type Config struct {
+	Defaults      *Defaults
+	Logging       *logging.Config
+	Observability *Observability
+}
+
+// FromContext extracts a Config from the provided context.
+func FromContext(ctx context.Context) *Config {
+	x, ok := ctx.Value(cfgKey{}).(*Config)
+	if ok {
+		return x
+	}
+	return nil
+}
+
+// FromContextOrDefaults is like FromContext, but when no Config is attached
+// it returns a Config populated with the built-in defaults.
+func FromContextOrDefaults(ctx context.Context) *Config {
+	if cfg := FromContext(ctx); cfg != nil {
+		return cfg
+	}
+	defaults, _ := NewDefaultsFromMap(map[string]string{})
+	loggingConfig, _ := logging.NewConfigFromMap(map[string]string{})
+	return &Config{
+		Defaults:      defaults,
+		Logging:       loggingConfig,
+		Observability: &Observability{},
+	}
+}
+
+// ToContext attaches the provided Config to the provided context, returning
+// the new context with the Config attached.
+func ToContext(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, cfgKey{}, c)
+}
+
+// WithConfigMapLister attaches the lister with which DefaultsFromContext
+// looks up the ConfigMaps overriding the defaults per namespace.
+func WithConfigMapLister(ctx context.Context, lister corev1listers.ConfigMapLister) context.Context {
+	return context.WithValue(ctx, listerKey{}, lister)
+}
+
+// DefaultsFromContext returns the defaults of the given namespace, using the
+// ConfigMap lister attached with WithConfigMapLister, if any.
+func DefaultsFromContext(ctx context.Context, namespace string) (*Defaults, error) {
+	lister, _ := ctx.Value(listerKey{}).(corev1listers.ConfigMapLister)
+	return DefaultsForNamespace(ctx, lister, namespace)
+}
+
+// DefaultsForNamespace returns the defaults of the given context overridden
+// by the ConfigMap in the given namespace, if it exists.
+func DefaultsForNamespace(ctx context.Context, lister corev1listers.ConfigMapLister, namespace string) (*Defaults, error) {
+	defaults := FromContextOrDefaults(ctx).Defaults
+	if lister == nil {
+		return defaults, nil
+	}
+
+	cm, err := lister.ConfigMaps(namespace).Get(DefaultsConfigName)
+	if apierrs.IsNotFound(err) {
+		return defaults, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return defaults.WithOverrides(cm.Data)
+}
+
+// Store is a typed wrapper around configmap.UntypedStore to handle our configmaps.
+type Store struct {
+	*configmap.UntypedStore
+}
+
+// NewStore creates a new store of Configs and optionally calls functions when ConfigMaps are updated.
+func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *Store {
+	store := &Store{
+		UntypedStore: configmap.NewUntypedStore(
+			"vsphere",
+			logger,
+			configmap.Constructors{
+				DefaultsConfigName:      NewDefaultsFromConfigMap,
+				logging.ConfigMapName(): logging.NewConfigFromConfigMap,
+				metrics.ConfigMapName(): NewObservabilityFromConfigMap,
+			},
+			onAfterStore...,
+		),
+	}
+
+	return store
+}
+
+// ToContext attaches the current Config state to the provided context.
+func (s *Store) ToContext(ctx context.Context) context.Context {
+	return ToContext(ctx, s.Load())
+}
+
+// Load creates a Config from the current config state of the Store.
+func (s *Store) Load() *Config {
+	return &Config{
+		Defaults:      s.UntypedLoad(DefaultsConfigName).(*Defaults).DeepCopy(),
+		Logging:       s.UntypedLoad(logging.ConfigMapName()).(*logging.Config).DeepCopy(),
+		Observability: s.UntypedLoad(metrics.ConfigMapName()).(*Observability).DeepCopy(),
+	}
+}
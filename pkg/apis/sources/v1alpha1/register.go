@@ -38,6 +38,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&VSphereSourceList{},
 		&VSphereBinding{},
 		&VSphereBindingList{},
+		&VSphereInventorySource{},
+		&VSphereInventorySourceList{},
+		&VSphereSourceTemplate{},
+		&VSphereSourceTemplateList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -15,4 +15,6 @@ func (vsb *VSphereBinding) SetDefaults(ctx context.Context) {
 		// Default the subject's namespace to our namespace.
 		vsb.Spec.Subject.Namespace = vsb.Namespace
 	}
+
+	vsb.Spec.VAuthSpec.SetDefaults(ctx)
 }
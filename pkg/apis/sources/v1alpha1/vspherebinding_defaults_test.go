@@ -37,7 +37,7 @@ func TestVSphereBindingDefaulting(t *testing.T) {
 			},
 			Spec: VSphereBindingSpec{
 				BindingSpec: validBindingSpec,
-				VAuthSpec:   validVAuthSpec,
+				VAuthSpec:   defaultedVAuthSpec,
 			},
 		},
 	}, {
@@ -72,7 +72,7 @@ func TestVSphereBindingDefaulting(t *testing.T) {
 						Namespace:  "with-namespace",
 					},
 				},
-				VAuthSpec: validVAuthSpec,
+				VAuthSpec: defaultedVAuthSpec,
 			},
 		},
 	}}
@@ -8,6 +8,7 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -21,6 +22,32 @@ import (
 
 var vsbCondSet = apis.NewLivingConditionSet()
 
+const (
+	// CredentialsVersionAnnotation is the pod template annotation with the
+	// version of the credentials in the secret of the binding, which rolls the
+	// subject when the credentials change.
+	CredentialsVersionAnnotation = "vspherebindings.sources.tanzu.vmware.com/credentials-version"
+
+	// CredentialsReloadAnnotation on a pod template set to "true" opts the
+	// subject out of rolling when the credentials change, because it reloads
+	// them from the mounted secret itself.
+	CredentialsReloadAnnotation = "vspherebindings.sources.tanzu.vmware.com/credentials-reload"
+)
+
+type credentialsVersionKey struct{}
+
+// WithCredentialsVersion returns a copy of the context with the version of the
+// credentials in the secret of the binding, which Do records in the pod
+// templates of the subjects.
+func WithCredentialsVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, credentialsVersionKey{}, version)
+}
+
+func credentialsVersionFrom(ctx context.Context) string {
+	v, _ := ctx.Value(credentialsVersionKey{}).(string)
+	return v
+}
+
 // GetGroupVersionKind returns the GroupVersionKind.
 func (vsb *VSphereBinding) GetGroupVersionKind() schema.GroupVersionKind {
 	return SchemeGroupVersion.WithKind("VSphereBinding")
@@ -53,9 +80,37 @@ func (sbs *VSphereBindingStatus) InitializeConditions() {
 }
 
 // MarkBindingUnavailable marks the VSphereBinding's Ready condition to False with
-// the provided reason and message.
+// the provided reason and message. The binding is only unavailable if its
+// subjects cannot be resolved, so the resolved subjects are reset as well.
 func (sbs *VSphereBindingStatus) MarkBindingUnavailable(reason, message string) {
 	vsbCondSet.Manage(sbs).MarkFalse(VSphereBindingConditionReady, reason, message)
+	vsbCondSet.Manage(sbs).MarkFalse(VSphereBindingConditionSubjectsResolved, reason, "%s", message)
+	sbs.Subjects = nil
+	sbs.BoundSubjects = 0
+	sbs.PendingSubjects = 0
+}
+
+// MarkSubjects records the given resolved subjects of the binding and sets the
+// SubjectsResolved condition to False if there are none.
+func (sbs *VSphereBindingStatus) MarkSubjects(subjects []VBindingSubjectStatus) {
+	sbs.Subjects = subjects
+	sbs.BoundSubjects = 0
+	sbs.PendingSubjects = 0
+	for _, s := range subjects {
+		if s.Bound {
+			sbs.BoundSubjects++
+		} else {
+			sbs.PendingSubjects++
+		}
+	}
+
+	if len(subjects) == 0 {
+		vsbCondSet.Manage(sbs).MarkFalse(VSphereBindingConditionSubjectsResolved, "NoSubjects",
+			"No subjects match the subject of the binding")
+		return
+	}
+	vsbCondSet.Manage(sbs).MarkTrueWithReason(VSphereBindingConditionSubjectsResolved, "Resolved",
+		"%d of %d subject(s) bound", sbs.BoundSubjects, len(subjects))
 }
 
 // MarkBindingAvailable marks the VSphereBinding's Ready condition to True.
@@ -68,6 +123,9 @@ func (vsb *VSphereBinding) Do(ctx context.Context, ps *duckv1.WithPod) {
 	// First undo so that we can just unconditionally append below.
 	vsb.Undo(ctx, ps)
 
+	// The secret is only mounted without an external credential provider
+	mount := vsb.Spec.CredentialProvider == nil
+
 	// Make sure the PodSpec has a Volume like this:
 	volume := corev1.Volume{
 		Name: vsphere.VolumeName,
@@ -77,7 +135,9 @@ func (vsb *VSphereBinding) Do(ctx context.Context, ps *duckv1.WithPod) {
 			},
 		},
 	}
-	ps.Spec.Template.Spec.Volumes = append(ps.Spec.Template.Spec.Volumes, volume)
+	if mount {
+		ps.Spec.Template.Spec.Volumes = append(ps.Spec.Template.Spec.Volumes, volume)
+	}
 
 	// Make sure that each [init]container in the PodSpec has a VolumeMount like this:
 	volumeMount := corev1.VolumeMount{
@@ -86,85 +146,181 @@ func (vsb *VSphereBinding) Do(ctx context.Context, ps *duckv1.WithPod) {
 		MountPath: vsphere.DefaultMountPath,
 	}
 
-	spec := ps.Spec.Template.Spec
-	for i := range spec.InitContainers {
-		spec.InitContainers[i].VolumeMounts = append(spec.InitContainers[i].VolumeMounts, volumeMount)
-		spec.InitContainers[i].Env = append(spec.InitContainers[i].Env, corev1.EnvVar{
-			Name:  "VC_URL",
-			Value: vsb.Spec.Address.String(),
-		}, corev1.EnvVar{
-			Name:  "VC_INSECURE",
-			Value: fmt.Sprintf("%v", vsb.Spec.SkipTLSVerify),
-		}, corev1.EnvVar{
-			Name: "VC_USERNAME",
-			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: vsb.Spec.SecretRef.Name,
-					},
-					Key: corev1.BasicAuthUsernameKey,
-				},
-			},
-		}, corev1.EnvVar{
-			Name: "VC_PASSWORD",
-			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: vsb.Spec.SecretRef.Name,
-					},
-					Key: corev1.BasicAuthPasswordKey,
+	// Mount the configmap with the CA certificates of the vSphere API, if any
+	var caVolumeMount *corev1.VolumeMount
+	if ref := vsb.Spec.CACertsConfigMapRef; ref != nil {
+		ps.Spec.Template.Spec.Volumes = append(ps.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name: vsphere.CACertsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: *ref,
 				},
 			},
 		})
+		caVolumeMount = &corev1.VolumeMount{
+			Name:      vsphere.CACertsVolumeName,
+			ReadOnly:  true,
+			MountPath: vsphere.CACertsMountPath,
+		}
+	}
+
+	// Roll the subject when the credentials change, unless it reloads them
+	if v := credentialsVersionFrom(ctx); v != "" && ps.Spec.Template.Annotations[CredentialsReloadAnnotation] != "true" {
+		if ps.Spec.Template.Annotations == nil {
+			ps.Spec.Template.Annotations = make(map[string]string, 1)
+		}
+		ps.Spec.Template.Annotations[CredentialsVersionAnnotation] = v
+	}
+
+	spec := ps.Spec.Template.Spec
+	for i := range spec.InitContainers {
+		if mount {
+			spec.InitContainers[i].VolumeMounts = append(spec.InitContainers[i].VolumeMounts, volumeMount)
+		}
+		if caVolumeMount != nil {
+			spec.InitContainers[i].VolumeMounts = append(spec.InitContainers[i].VolumeMounts, *caVolumeMount)
+		}
+		spec.InitContainers[i].Env = append(spec.InitContainers[i].Env, vsb.env()...)
 	}
 	for i := range spec.Containers {
-		spec.Containers[i].VolumeMounts = append(spec.Containers[i].VolumeMounts, volumeMount)
-		spec.Containers[i].Env = append(spec.Containers[i].Env, corev1.EnvVar{
-			Name:  "VC_URL",
+		if mount {
+			spec.Containers[i].VolumeMounts = append(spec.Containers[i].VolumeMounts, volumeMount)
+		}
+		if caVolumeMount != nil {
+			spec.Containers[i].VolumeMounts = append(spec.Containers[i].VolumeMounts, *caVolumeMount)
+		}
+		spec.Containers[i].Env = append(spec.Containers[i].Env, vsb.env()...)
+	}
+}
+
+// env returns the environment variables with the vSphere API address and its
+// CA certificates, the credential provider and, for basic authentication with the secret, the
+// credentials, optionally also in the variables of the govc CLI. Other auth
+// methods read the credentials from the mounted secret.
+func (vsb *VSphereBinding) env() []corev1.EnvVar {
+	env := []corev1.EnvVar{{
+		Name:  "VC_URL",
+		Value: vsb.Spec.Address.String(),
+	}, {
+		Name:  "VC_INSECURE",
+		Value: fmt.Sprintf("%v", vsb.Spec.SkipTLSVerify),
+	}}
+
+	if vsb.Spec.GovcEnv {
+		env = append(env, corev1.EnvVar{
+			Name:  "GOVC_URL",
 			Value: vsb.Spec.Address.String(),
 		}, corev1.EnvVar{
-			Name:  "VC_INSECURE",
+			Name:  "GOVC_INSECURE",
 			Value: fmt.Sprintf("%v", vsb.Spec.SkipTLSVerify),
+		})
+	}
+
+	if vsb.Spec.CACertsConfigMapRef != nil {
+		caCert := filepath.Join(vsphere.CACertsMountPath, vsphere.CACertsKey)
+		env = append(env, corev1.EnvVar{
+			Name:  "VC_CA_CERT",
+			Value: caCert,
+		})
+		if vsb.Spec.GovcEnv {
+			env = append(env, corev1.EnvVar{
+				Name:  "GOVC_TLS_CA_CERTS",
+				Value: caCert,
+			})
+		}
+	}
+
+	basic := true
+	if m := vsb.Spec.AuthMethod; m != "" && m != vsphere.AuthMethodBasic {
+		basic = false
+		env = append(env, corev1.EnvVar{
+			Name:  "VC_AUTH_METHOD",
+			Value: m,
+		})
+	}
+
+	if cp := vsb.Spec.CredentialProvider; cp != nil && cp.Vault != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "VC_CREDENTIAL_PROVIDER",
+			Value: vsphere.CredentialProviderVault,
 		}, corev1.EnvVar{
-			Name: "VC_USERNAME",
-			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: vsb.Spec.SecretRef.Name,
-					},
-					Key: corev1.BasicAuthUsernameKey,
-				},
-			},
+			Name:  "VC_VAULT_ADDRESS",
+			Value: cp.Vault.Address.String(),
+		}, corev1.EnvVar{
+			Name:  "VC_VAULT_PATH",
+			Value: cp.Vault.Path,
 		}, corev1.EnvVar{
-			Name: "VC_PASSWORD",
-			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: vsb.Spec.SecretRef.Name,
-					},
-					Key: corev1.BasicAuthPasswordKey,
+			Name:  "VC_VAULT_ROLE",
+			Value: cp.Vault.Role,
+		})
+		if cp.Vault.AuthPath != "" {
+			env = append(env, corev1.EnvVar{
+				Name:  "VC_VAULT_AUTH_PATH",
+				Value: cp.Vault.AuthPath,
+			})
+		}
+		return env
+	}
+
+	if !basic {
+		return env
+	}
+
+	env = append(env, vsb.secretKeyEnv("VC_USERNAME", corev1.BasicAuthUsernameKey),
+		vsb.secretKeyEnv("VC_PASSWORD", corev1.BasicAuthPasswordKey))
+	if vsb.Spec.GovcEnv {
+		env = append(env, vsb.secretKeyEnv("GOVC_USERNAME", corev1.BasicAuthUsernameKey),
+			vsb.secretKeyEnv("GOVC_PASSWORD", corev1.BasicAuthPasswordKey))
+	}
+	return env
+}
+
+// secretKeyEnv returns an environment variable with the given key of the
+// secret of the binding
+func (vsb *VSphereBinding) secretKeyEnv(name, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: vsb.Spec.SecretRef.Name,
 				},
+				Key: key,
 			},
-		})
+		},
 	}
 }
 
 func (vsb *VSphereBinding) Undo(ctx context.Context, ps *duckv1.WithPod) {
+	delete(ps.Spec.Template.Annotations, CredentialsVersionAnnotation)
+
 	spec := ps.Spec.Template.Spec
 
-	for i, v := range spec.Volumes {
-		if v.Name == vsphere.VolumeName {
-			ps.Spec.Template.Spec.Volumes = append(spec.Volumes[:i], spec.Volumes[i+1:]...)
-			break
+	if len(spec.Volumes) != 0 {
+		volumes := make([]corev1.Volume, 0, len(spec.Volumes))
+		for _, v := range spec.Volumes {
+			switch v.Name {
+			case vsphere.VolumeName, vsphere.CACertsVolumeName:
+				continue
+			default:
+				volumes = append(volumes, v)
+			}
 		}
+		ps.Spec.Template.Spec.Volumes = volumes
 	}
 
 	for i, c := range spec.InitContainers {
-		for j, vm := range c.VolumeMounts {
-			if vm.Name == vsphere.VolumeName {
-				spec.InitContainers[i].VolumeMounts = append(c.VolumeMounts[:j], c.VolumeMounts[j+1:]...)
-				break
+		if len(c.VolumeMounts) != 0 {
+			mounts := make([]corev1.VolumeMount, 0, len(c.VolumeMounts))
+			for _, vm := range c.VolumeMounts {
+				switch vm.Name {
+				case vsphere.VolumeName, vsphere.CACertsVolumeName:
+					continue
+				default:
+					mounts = append(mounts, vm)
+				}
 			}
+			spec.InitContainers[i].VolumeMounts = mounts
 		}
 
 		if len(c.Env) == 0 {
@@ -173,7 +329,9 @@ func (vsb *VSphereBinding) Undo(ctx context.Context, ps *duckv1.WithPod) {
 		env := make([]corev1.EnvVar, 0, len(spec.InitContainers[i].Env))
 		for j, ev := range c.Env {
 			switch ev.Name {
-			case "VC_URL", "VC_INSECURE", "VC_USERNAME", "VC_PASSWORD":
+			case "VC_URL", "VC_INSECURE", "VC_USERNAME", "VC_PASSWORD", "VC_AUTH_METHOD",
+				"VC_CREDENTIAL_PROVIDER", "VC_VAULT_ADDRESS", "VC_VAULT_PATH", "VC_VAULT_ROLE", "VC_VAULT_AUTH_PATH",
+				"VC_CA_CERT", "GOVC_URL", "GOVC_INSECURE", "GOVC_USERNAME", "GOVC_PASSWORD", "GOVC_TLS_CA_CERTS":
 				continue
 			default:
 				env = append(env, spec.InitContainers[i].Env[j])
@@ -182,11 +340,17 @@ func (vsb *VSphereBinding) Undo(ctx context.Context, ps *duckv1.WithPod) {
 		spec.InitContainers[i].Env = env
 	}
 	for i, c := range spec.Containers {
-		for j, vm := range c.VolumeMounts {
-			if vm.Name == vsphere.VolumeName {
-				spec.Containers[i].VolumeMounts = append(c.VolumeMounts[:j], c.VolumeMounts[j+1:]...)
-				break
+		if len(c.VolumeMounts) != 0 {
+			mounts := make([]corev1.VolumeMount, 0, len(c.VolumeMounts))
+			for _, vm := range c.VolumeMounts {
+				switch vm.Name {
+				case vsphere.VolumeName, vsphere.CACertsVolumeName:
+					continue
+				default:
+					mounts = append(mounts, vm)
+				}
 			}
+			spec.Containers[i].VolumeMounts = mounts
 		}
 
 		if len(c.Env) == 0 {
@@ -195,7 +359,9 @@ func (vsb *VSphereBinding) Undo(ctx context.Context, ps *duckv1.WithPod) {
 		env := make([]corev1.EnvVar, 0, len(spec.Containers[i].Env))
 		for j, ev := range c.Env {
 			switch ev.Name {
-			case "VC_URL", "VC_INSECURE", "VC_USERNAME", "VC_PASSWORD":
+			case "VC_URL", "VC_INSECURE", "VC_USERNAME", "VC_PASSWORD", "VC_AUTH_METHOD",
+				"VC_CREDENTIAL_PROVIDER", "VC_VAULT_ADDRESS", "VC_VAULT_PATH", "VC_VAULT_ROLE", "VC_VAULT_AUTH_PATH",
+				"VC_CA_CERT", "GOVC_URL", "GOVC_INSECURE", "GOVC_USERNAME", "GOVC_PASSWORD", "GOVC_TLS_CA_CERTS":
 				continue
 			default:
 				env = append(env, spec.Containers[i].Env[j])
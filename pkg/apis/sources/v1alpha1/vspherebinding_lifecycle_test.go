@@ -10,7 +10,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"knative.dev/pkg/apis"
 	"knative.dev/pkg/apis/duck"
@@ -627,6 +629,400 @@ func TestVSphereBindingDo(t *testing.T) {
 	}
 }
 
+func TestVSphereBindingDoAuthMethod(t *testing.T) {
+	url := apis.URL{
+		Scheme: "https",
+		Host:   "vmware.com",
+	}
+	secretName := "ssssshhhh-dont-tell"
+	vsb := &VSphereBinding{
+		Spec: VSphereBindingSpec{
+			VAuthSpec: VAuthSpec{
+				Address: url,
+				SecretRef: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+				AuthMethod: vsphere.AuthMethodSAML,
+			},
+		},
+	}
+
+	got := &duckv1.WithPod{
+		Spec: duckv1.WithPodSpec{
+			Template: duckv1.PodSpecable{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "blah",
+						Image: "busybox",
+						Env: []corev1.EnvVar{{
+							Name:  "VC_USERNAME",
+							Value: "stale",
+						}},
+					}},
+				},
+			},
+		},
+	}
+
+	vsb.Do(context.Background(), got)
+
+	want := []corev1.EnvVar{{
+		Name:  "VC_URL",
+		Value: url.String(),
+	}, {
+		Name:  "VC_INSECURE",
+		Value: "false",
+	}, {
+		Name:  "VC_AUTH_METHOD",
+		Value: vsphere.AuthMethodSAML,
+	}}
+	if env := got.Spec.Template.Spec.Containers[0].Env; !cmp.Equal(env, want) {
+		t.Errorf("Do (-want, +got): %s", cmp.Diff(want, env))
+	}
+
+	vsb.Undo(context.Background(), got)
+	if env := got.Spec.Template.Spec.Containers[0].Env; len(env) != 0 {
+		t.Errorf("Undo() env = %v, want empty", env)
+	}
+}
+
+func TestVSphereBindingDoGovcEnv(t *testing.T) {
+	url := apis.URL{
+		Scheme: "https",
+		Host:   "vmware.com",
+	}
+	secretName := "ssssshhhh-dont-tell"
+	vsb := &VSphereBinding{
+		Spec: VSphereBindingSpec{
+			VAuthSpec: VAuthSpec{
+				Address:       url,
+				SkipTLSVerify: true,
+				SecretRef: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+			},
+			GovcEnv: true,
+		},
+	}
+
+	got := &duckv1.WithPod{
+		Spec: duckv1.WithPodSpec{
+			Template: duckv1.PodSpecable{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "govc",
+						Image: "vmware/govc",
+					}},
+				},
+			},
+		},
+	}
+
+	vsb.Do(context.Background(), got)
+
+	secretKeyRef := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+				Key: key,
+			},
+		}
+	}
+	want := []corev1.EnvVar{{
+		Name:  "VC_URL",
+		Value: url.String(),
+	}, {
+		Name:  "VC_INSECURE",
+		Value: "true",
+	}, {
+		Name:  "GOVC_URL",
+		Value: url.String(),
+	}, {
+		Name:  "GOVC_INSECURE",
+		Value: "true",
+	}, {
+		Name:      "VC_USERNAME",
+		ValueFrom: secretKeyRef(corev1.BasicAuthUsernameKey),
+	}, {
+		Name:      "VC_PASSWORD",
+		ValueFrom: secretKeyRef(corev1.BasicAuthPasswordKey),
+	}, {
+		Name:      "GOVC_USERNAME",
+		ValueFrom: secretKeyRef(corev1.BasicAuthUsernameKey),
+	}, {
+		Name:      "GOVC_PASSWORD",
+		ValueFrom: secretKeyRef(corev1.BasicAuthPasswordKey),
+	}}
+	if env := got.Spec.Template.Spec.Containers[0].Env; !cmp.Equal(env, want) {
+		t.Errorf("Do (-want, +got): %s", cmp.Diff(want, env))
+	}
+
+	vsb.Undo(context.Background(), got)
+	if env := got.Spec.Template.Spec.Containers[0].Env; len(env) != 0 {
+		t.Errorf("Undo() env = %v, want empty", env)
+	}
+}
+
+func TestVSphereBindingDoCACerts(t *testing.T) {
+	url := apis.URL{
+		Scheme: "https",
+		Host:   "vmware.com",
+	}
+	vsb := &VSphereBinding{
+		Spec: VSphereBindingSpec{
+			VAuthSpec: VAuthSpec{
+				Address: url,
+				CACertsConfigMapRef: &corev1.LocalObjectReference{
+					Name: "vcenter-ca",
+				},
+				SecretRef: corev1.LocalObjectReference{
+					Name: "vsphere-credentials",
+				},
+				AuthMethod: vsphere.AuthMethodSAML,
+			},
+			GovcEnv: true,
+		},
+	}
+
+	got := &duckv1.WithPod{
+		Spec: duckv1.WithPodSpec{
+			Template: duckv1.PodSpecable{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{{
+						Name: "config",
+					}},
+					Containers: []corev1.Container{{
+						Name:  "govc",
+						Image: "vmware/govc",
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "config",
+							MountPath: "/config",
+						}},
+					}},
+				},
+			},
+		},
+	}
+
+	vsb.Do(context.Background(), got)
+
+	want := &duckv1.WithPod{
+		Spec: duckv1.WithPodSpec{
+			Template: duckv1.PodSpecable{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{{
+						Name: "config",
+					}, {
+						Name: vsphere.VolumeName,
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{
+								SecretName: "vsphere-credentials",
+							},
+						},
+					}, {
+						Name: vsphere.CACertsVolumeName,
+						VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{
+									Name: "vcenter-ca",
+								},
+							},
+						},
+					}},
+					Containers: []corev1.Container{{
+						Name:  "govc",
+						Image: "vmware/govc",
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "config",
+							MountPath: "/config",
+						}, {
+							Name:      vsphere.VolumeName,
+							ReadOnly:  true,
+							MountPath: vsphere.DefaultMountPath,
+						}, {
+							Name:      vsphere.CACertsVolumeName,
+							ReadOnly:  true,
+							MountPath: vsphere.CACertsMountPath,
+						}},
+						Env: []corev1.EnvVar{{
+							Name:  "VC_URL",
+							Value: url.String(),
+						}, {
+							Name:  "VC_INSECURE",
+							Value: "false",
+						}, {
+							Name:  "GOVC_URL",
+							Value: url.String(),
+						}, {
+							Name:  "GOVC_INSECURE",
+							Value: "false",
+						}, {
+							Name:  "VC_CA_CERT",
+							Value: "/var/bindings/vsphere-ca-certs/ca.crt",
+						}, {
+							Name:  "GOVC_TLS_CA_CERTS",
+							Value: "/var/bindings/vsphere-ca-certs/ca.crt",
+						}, {
+							Name:  "VC_AUTH_METHOD",
+							Value: vsphere.AuthMethodSAML,
+						}},
+					}},
+				},
+			},
+		},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Do (-want, +got): %s", cmp.Diff(want, got))
+	}
+
+	vsb.Undo(context.Background(), got)
+
+	want.Spec.Template.Spec.Volumes = want.Spec.Template.Spec.Volumes[:1]
+	want.Spec.Template.Spec.Containers[0].VolumeMounts = want.Spec.Template.Spec.Containers[0].VolumeMounts[:1]
+	want.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Undo (-want, +got): %s", cmp.Diff(want, got))
+	}
+}
+
+func TestVSphereBindingDoCredentialProvider(t *testing.T) {
+	url := apis.URL{
+		Scheme: "https",
+		Host:   "vmware.com",
+	}
+	vsb := &VSphereBinding{
+		Spec: VSphereBindingSpec{
+			VAuthSpec: VAuthSpec{
+				Address: url,
+				CredentialProvider: &VCredentialProviderSpec{
+					Vault: &VVaultSpec{
+						Address:  apis.URL{Scheme: "https", Host: "vault.example.com"},
+						Path:     "secret/data/vcenter",
+						Role:     "vsphere",
+						AuthPath: "k8s",
+					},
+				},
+			},
+		},
+	}
+
+	got := &duckv1.WithPod{
+		Spec: duckv1.WithPodSpec{
+			Template: duckv1.PodSpecable{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "blah",
+						Image: "busybox",
+					}},
+				},
+			},
+		},
+	}
+
+	vsb.Do(context.Background(), got)
+
+	want := []corev1.EnvVar{{
+		Name:  "VC_URL",
+		Value: url.String(),
+	}, {
+		Name:  "VC_INSECURE",
+		Value: "false",
+	}, {
+		Name:  "VC_CREDENTIAL_PROVIDER",
+		Value: vsphere.CredentialProviderVault,
+	}, {
+		Name:  "VC_VAULT_ADDRESS",
+		Value: "https://vault.example.com",
+	}, {
+		Name:  "VC_VAULT_PATH",
+		Value: "secret/data/vcenter",
+	}, {
+		Name:  "VC_VAULT_ROLE",
+		Value: "vsphere",
+	}, {
+		Name:  "VC_VAULT_AUTH_PATH",
+		Value: "k8s",
+	}}
+	spec := got.Spec.Template.Spec
+	if env := spec.Containers[0].Env; !cmp.Equal(env, want) {
+		t.Errorf("Do (-want, +got): %s", cmp.Diff(want, env))
+	}
+	if len(spec.Volumes) != 0 || len(spec.Containers[0].VolumeMounts) != 0 {
+		t.Errorf("Do() mounted secret without secretRef: %v, %v", spec.Volumes, spec.Containers[0].VolumeMounts)
+	}
+
+	vsb.Undo(context.Background(), got)
+	if env := got.Spec.Template.Spec.Containers[0].Env; len(env) != 0 {
+		t.Errorf("Undo() env = %v, want empty", env)
+	}
+}
+
+func TestVSphereBindingDoCredentialsVersion(t *testing.T) {
+	vsb := &VSphereBinding{
+		Spec: VSphereBindingSpec{
+			VAuthSpec: VAuthSpec{
+				SecretRef: corev1.LocalObjectReference{
+					Name: "ssssshhhh-dont-tell",
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		version     string
+		annotations map[string]string
+		want        map[string]string
+	}{{
+		name: "no version",
+	}, {
+		name:    "new version",
+		version: "v1",
+		want:    map[string]string{CredentialsVersionAnnotation: "v1"},
+	}, {
+		name:        "changed version",
+		version:     "v2",
+		annotations: map[string]string{CredentialsVersionAnnotation: "v1", "foo": "bar"},
+		want:        map[string]string{CredentialsVersionAnnotation: "v2", "foo": "bar"},
+	}, {
+		name:        "reloads credentials",
+		version:     "v2",
+		annotations: map[string]string{CredentialsReloadAnnotation: "true"},
+		want:        map[string]string{CredentialsReloadAnnotation: "true"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := &duckv1.WithPod{
+				Spec: duckv1.WithPodSpec{
+					Template: duckv1.PodSpecable{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: test.annotations,
+						},
+					},
+				},
+			}
+
+			ctx := context.Background()
+			if test.version != "" {
+				ctx = WithCredentialsVersion(ctx, test.version)
+			}
+			vsb.Do(ctx, got)
+
+			if !cmp.Equal(got.Spec.Template.Annotations, test.want, cmpopts.EquateEmpty()) {
+				t.Errorf("Do (-want, +got): %s", cmp.Diff(test.want, got.Spec.Template.Annotations))
+			}
+
+			vsb.Undo(ctx, got)
+			if _, ok := got.Spec.Template.Annotations[CredentialsVersionAnnotation]; ok {
+				t.Errorf("Undo() did not remove %s", CredentialsVersionAnnotation)
+			}
+		})
+	}
+}
+
 func TestTypicalBindingFlow(t *testing.T) {
 	r := &VSphereBindingStatus{}
 	r.InitializeConditions()
@@ -639,3 +1035,44 @@ func TestTypicalBindingFlow(t *testing.T) {
 	// After all of that, we're finally ready!
 	apistest.CheckConditionSucceeded(r, VSphereBindingConditionReady, t)
 }
+
+func TestBindingSubjectsFlow(t *testing.T) {
+	r := &VSphereBindingStatus{}
+	r.InitializeConditions()
+	r.MarkBindingAvailable()
+
+	r.MarkSubjects(nil)
+	apistest.CheckConditionFailed(r, VSphereBindingConditionSubjectsResolved, t)
+	apistest.CheckConditionSucceeded(r, VSphereBindingConditionReady, t)
+	if got := r.GetCondition(VSphereBindingConditionSubjectsResolved).Severity; got != apis.ConditionSeverityInfo {
+		t.Errorf("subjects condition severity = %q, want %q", got, apis.ConditionSeverityInfo)
+	}
+
+	subjects := []VBindingSubjectStatus{{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "bar",
+		Bound:      true,
+	}, {
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "foo",
+	}}
+	r.MarkSubjects(subjects)
+	apistest.CheckConditionSucceeded(r, VSphereBindingConditionSubjectsResolved, t)
+	if r.BoundSubjects != 1 || r.PendingSubjects != 1 {
+		t.Errorf("BoundSubjects, PendingSubjects = %d, %d, want 1, 1", r.BoundSubjects, r.PendingSubjects)
+	}
+	want := "1 of 2 subject(s) bound"
+	if got := r.GetCondition(VSphereBindingConditionSubjectsResolved).Message; got != want {
+		t.Errorf("subjects condition message = %q, want %q", got, want)
+	}
+
+	r.MarkBindingUnavailable("SubjectMissing", "deployments.apps \"foo\" not found")
+	apistest.CheckConditionFailed(r, VSphereBindingConditionSubjectsResolved, t)
+	apistest.CheckConditionFailed(r, VSphereBindingConditionReady, t)
+	if len(r.Subjects) != 0 || r.BoundSubjects != 0 || r.PendingSubjects != 0 {
+		t.Errorf("MarkBindingUnavailable() subjects = %v, %d bound, %d pending, want none",
+			r.Subjects, r.BoundSubjects, r.PendingSubjects)
+	}
+}
@@ -43,6 +43,12 @@ type VSphereBindingSpec struct {
 	duckv1alpha1.BindingSpec `json:",inline"`
 
 	VAuthSpec `json:",inline"`
+
+	// GovcEnv additionally injects the GOVC_URL, GOVC_INSECURE, GOVC_USERNAME
+	// and GOVC_PASSWORD environment variables of the govc CLI into the subject.
+	// The credentials are only injected for basic authentication with a secret.
+	// +optional
+	GovcEnv bool `json:"govcEnv,omitempty"`
 }
 
 // VAuthSpec is the information used to authenticate with a vSphere API
@@ -54,21 +60,104 @@ type VAuthSpec struct {
 	// talking to the vsphere address.
 	SkipTLSVerify bool `json:"skipTLSVerify,omitempty"`
 
+	// CACertsConfigMapRef is a reference to a Kubernetes configmap with the
+	// PEM-encoded CA certificates of the vSphere API in the "ca.crt" key, which
+	// are used instead of the system roots to verify the vsphere address.
+	// +optional
+	CACertsConfigMapRef *corev1.LocalObjectReference `json:"caCertsConfigMapRef,omitempty"`
+
 	// SecretRef is a reference to a Kubernetes secret of type kubernetes.io/basic-auth
 	// which contains keys for "username" and "password", which will be used to authenticate
-	//  with the vSphere API at "address".
+	//  with the vSphere API at "address". It is not required with a CredentialProvider.
 	SecretRef corev1.LocalObjectReference `json:"secretRef"`
+
+	// CredentialProvider is an external provider of the credentials, which
+	// serves the same keys as the secret of SecretRef.
+	// +optional
+	CredentialProvider *VCredentialProviderSpec `json:"credentialProvider,omitempty"`
+
+	// AuthMethod is the method to authenticate with the vSphere API:
+	// "basic" (default) with the "username" and "password" keys of the secret,
+	// "saml" with a SAML token of the vSphere SSO STS in the "token" key
+	// and/or a signing certificate in the "tls.crt" and "tls.key" keys,
+	// "certificate" as the solution user with the extension key in the
+	// "extensionKey" key and its client certificate in the "tls.crt" and
+	// "tls.key" keys, or "csp" with a VMware Cloud Services API token in the
+	// "apiToken" key to retrieve the vCenter credentials of the VMware Cloud on
+	// AWS SDDC in the "orgId" and "sddcId" keys.
+	// +optional
+	AuthMethod string `json:"authMethod,omitempty"`
+}
+
+// VCredentialProviderSpec configures an external provider of the credentials
+// of the vSphere API
+type VCredentialProviderSpec struct {
+	// Vault reads the credentials from a HashiCorp Vault secret.
+	// +optional
+	Vault *VVaultSpec `json:"vault,omitempty"`
+}
+
+// VVaultSpec configures reading the credentials from a HashiCorp Vault secret.
+// Pods log in to Vault with the token of their Kubernetes service account.
+type VVaultSpec struct {
+	// Address is the URL of the Vault server.
+	Address apis.URL `json:"address"`
+
+	// Path is the path of the secret with the credentials, e.g.
+	// "secret/data/vcenter" for version 2 of the KV secrets engine.
+	Path string `json:"path"`
+
+	// Role is the role of the Kubernetes auth method to log in with.
+	Role string `json:"role"`
+
+	// AuthPath is the mount path of the Kubernetes auth method, defaults to
+	// "kubernetes".
+	// +optional
+	AuthPath string `json:"authPath,omitempty"`
 }
 
 const (
 	// VSphereBindingConditionReady is configured to indicate whether the Binding
 	// has been configured for resources subject to its runtime contract.
 	VSphereBindingConditionReady = apis.ConditionReady
+
+	// VSphereBindingConditionSubjectsResolved is set to reflect whether the
+	// subjects of the binding could be resolved. It does not affect the
+	// readiness of the VSphereBinding.
+	VSphereBindingConditionSubjectsResolved = "SubjectsResolved"
 )
 
 // VSphereBindingStatus communicates the observed state of the VSphereBinding (from the controller).
 type VSphereBindingStatus struct {
 	duckv1.Status `json:",inline"`
+
+	// Subjects are the resolved subjects of the binding.
+	// +optional
+	Subjects []VBindingSubjectStatus `json:"subjects,omitempty"`
+
+	// BoundSubjects is the number of subjects which received the binding.
+	// +optional
+	BoundSubjects int32 `json:"boundSubjects,omitempty"`
+
+	// PendingSubjects is the number of subjects which did not receive the
+	// binding yet.
+	// +optional
+	PendingSubjects int32 `json:"pendingSubjects,omitempty"`
+}
+
+// VBindingSubjectStatus is a resolved subject of the binding in its namespace.
+type VBindingSubjectStatus struct {
+	// APIVersion of the subject.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the subject.
+	Kind string `json:"kind"`
+
+	// Name of the subject.
+	Name string `json:"name"`
+
+	// Bound is true if the pod template of the subject carries the binding.
+	Bound bool `json:"bound"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -7,13 +7,31 @@ package v1alpha1
 
 import (
 	"context"
+	"fmt"
 
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
 )
 
+// secretsKey is the context key of the client with which the secrets of
+// VAuthSpecs are validated
+type secretsKey struct{}
+
+// WithSecrets attaches the client with which Validate looks up the secret
+// referenced by a VAuthSpec, see validateSecret. Secrets are not looked up
+// without a client, e.g. outside of the webhook.
+func WithSecrets(ctx context.Context, secrets corev1client.SecretsGetter) context.Context {
+	return context.WithValue(ctx, secretsKey{}, secrets)
+}
+
 // Validate implements apis.Validatable
 func (vsb *VSphereBinding) Validate(ctx context.Context) *apis.FieldError {
-	err := vsb.Spec.Validate(ctx).ViaField("spec")
+	err := vsb.Spec.Validate(ctx).Also(vsb.Spec.VAuthSpec.validateSecret(ctx, vsb.Namespace)).ViaField("spec")
 	if vsb.Spec.Subject.Namespace != "" && vsb.Namespace != vsb.Spec.Subject.Namespace {
 		err = err.Also(apis.ErrInvalidValue(vsb.Spec.Subject.Namespace, "spec.subject.namespace"))
 	}
@@ -30,8 +48,93 @@ func (vas *VAuthSpec) Validate(ctx context.Context) (err *apis.FieldError) {
 	if vas.Address.Host == "" {
 		err = err.Also(apis.ErrMissingField("address.host"))
 	}
-	if vas.SecretRef.Name == "" {
+	if vas.CACertsConfigMapRef != nil && vas.CACertsConfigMapRef.Name == "" {
+		err = err.Also(apis.ErrMissingField("caCertsConfigMapRef.name"))
+	}
+	if vas.CredentialProvider != nil {
+		err = err.Also(vas.CredentialProvider.Validate(ctx).ViaField("credentialProvider"))
+	} else if vas.SecretRef.Name == "" {
 		err = err.Also(apis.ErrMissingField("secretRef.name"))
 	}
+	switch vas.AuthMethod {
+	case "", vsphere.AuthMethodBasic, vsphere.AuthMethodSAML,
+		vsphere.AuthMethodCertificate, vsphere.AuthMethodCSP:
+	default:
+		err = err.Also(apis.ErrInvalidValue(vas.AuthMethod, "authMethod"))
+	}
+	return err
+}
+
+// validateSecret validates that the secret referenced in the given namespace
+// exists and has the keys of the auth method, so that a resource is rejected
+// instead of its adapter failing to log in later. The secret is only looked up
+// if a client is attached with WithSecrets, which is permitted to get it, and
+// on updates only if the secret or the auth method changed.
+func (vas *VAuthSpec) validateSecret(ctx context.Context, namespace string) *apis.FieldError {
+	secrets, _ := ctx.Value(secretsKey{}).(corev1client.SecretsGetter)
+	if secrets == nil || vas.CredentialProvider != nil || vas.SecretRef.Name == "" {
+		return nil
+	}
+	if apis.IsInUpdate(ctx) {
+		if old := authSpecOf(apis.GetBaseline(ctx)); old != nil &&
+			old.SecretRef.Name == vas.SecretRef.Name && old.AuthMethod == vas.AuthMethod {
+			return nil
+		}
+	}
+
+	name := vas.SecretRef.Name
+	secret, err := secrets.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrs.IsNotFound(err):
+		fe := apis.ErrInvalidValue(name, "secretRef.name")
+		fe.Details = fmt.Sprintf("secret %s/%s not found, create it first, e.g. with kn vsphere login", namespace, name)
+		return fe
+	case err != nil:
+		// e.g. the webhook is not permitted to get secrets in the namespace,
+		// the adapter reports a missing secret instead
+		logging.FromContext(ctx).Warnw("failed to validate secret", "namespace", namespace, "name", name, "error", err)
+		return nil
+	}
+
+	if err := vsphere.ValidateSecretKeys(vas.AuthMethod, secret.Data); err != nil {
+		fe := apis.ErrInvalidValue(name, "secretRef.name")
+		fe.Details = err.Error()
+		return fe
+	}
+	return nil
+}
+
+// authSpecOf returns the VAuthSpec of the given resource, nil if it has none
+func authSpecOf(obj interface{}) *VAuthSpec {
+	switch r := obj.(type) {
+	case *VSphereSource:
+		return &r.Spec.VAuthSpec
+	case *VSphereBinding:
+		return &r.Spec.VAuthSpec
+	case *VSphereInventorySource:
+		return &r.Spec.VAuthSpec
+	}
+	return nil
+}
+
+// Validate implements apis.Validatable
+func (cps *VCredentialProviderSpec) Validate(ctx context.Context) *apis.FieldError {
+	if cps.Vault == nil {
+		return apis.ErrMissingOneOf("vault")
+	}
+	return cps.Vault.Validate(ctx).ViaField("vault")
+}
+
+// Validate implements apis.Validatable
+func (vs *VVaultSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vs.Address.Host == "" {
+		err = err.Also(apis.ErrMissingField("address.host"))
+	}
+	if vs.Path == "" {
+		err = err.Also(apis.ErrMissingField("path"))
+	}
+	if vs.Role == "" {
+		err = err.Also(apis.ErrMissingField("role"))
+	}
 	return err
 }
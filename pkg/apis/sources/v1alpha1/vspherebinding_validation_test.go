@@ -7,6 +7,7 @@ package v1alpha1
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"knative.dev/pkg/apis"
@@ -15,7 +16,13 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
 )
 
 var (
@@ -115,6 +122,98 @@ func TestVSphereBindingValidation(t *testing.T) {
 			},
 		},
 		want: apis.ErrMissingField("spec.address.host"),
+	}, {
+		name: "invalid auth method",
+		c: &VSphereBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "valid",
+				Namespace: validBindingSpec.Subject.Namespace,
+			},
+			Spec: VSphereBindingSpec{
+				BindingSpec: validBindingSpec,
+				VAuthSpec: VAuthSpec{
+					Address:    validVAuthSpec.Address,
+					SecretRef:  validVAuthSpec.SecretRef,
+					AuthMethod: "kerberos",
+				},
+			},
+		},
+		want: apis.ErrInvalidValue("kerberos", "spec.authMethod"),
+	}, {
+		name: "vault without secret",
+		c: &VSphereBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "valid",
+				Namespace: validBindingSpec.Subject.Namespace,
+			},
+			Spec: VSphereBindingSpec{
+				BindingSpec: validBindingSpec,
+				VAuthSpec: VAuthSpec{
+					Address: validVAuthSpec.Address,
+					CredentialProvider: &VCredentialProviderSpec{
+						Vault: &VVaultSpec{
+							Address: apis.URL{Scheme: "https", Host: "vault.example.com"},
+							Path:    "secret/data/vcenter",
+							Role:    "vsphere",
+						},
+					},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "incomplete vault",
+		c: &VSphereBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "valid",
+				Namespace: validBindingSpec.Subject.Namespace,
+			},
+			Spec: VSphereBindingSpec{
+				BindingSpec: validBindingSpec,
+				VAuthSpec: VAuthSpec{
+					Address: validVAuthSpec.Address,
+					CredentialProvider: &VCredentialProviderSpec{
+						Vault: &VVaultSpec{
+							Address: apis.URL{Scheme: "https", Host: "vault.example.com"},
+						},
+					},
+				},
+			},
+		},
+		want: apis.ErrMissingField("spec.credentialProvider.vault.path", "spec.credentialProvider.vault.role"),
+	}, {
+		name: "empty credential provider",
+		c: &VSphereBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "valid",
+				Namespace: validBindingSpec.Subject.Namespace,
+			},
+			Spec: VSphereBindingSpec{
+				BindingSpec: validBindingSpec,
+				VAuthSpec: VAuthSpec{
+					Address:            validVAuthSpec.Address,
+					CredentialProvider: &VCredentialProviderSpec{},
+				},
+			},
+		},
+		want: apis.ErrMissingOneOf("spec.credentialProvider.vault"),
+	}, {
+		name: "missing CA certificates configmap name",
+		c: &VSphereBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "valid",
+				Namespace: validBindingSpec.Subject.Namespace,
+			},
+			Spec: VSphereBindingSpec{
+				BindingSpec: validBindingSpec,
+				VAuthSpec: VAuthSpec{
+					Address:             validVAuthSpec.Address,
+					SecretRef:           validVAuthSpec.SecretRef,
+					CACertsConfigMapRef: &corev1.LocalObjectReference{},
+				},
+			},
+		},
+		want: apis.ErrMissingField("spec.caCertsConfigMapRef.name"),
 	}}
 
 	for _, test := range tests {
@@ -127,3 +226,98 @@ func TestVSphereBindingValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestVAuthSpecValidateSecret(t *testing.T) {
+	basic := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "basic"},
+		Data:       map[string][]byte{corev1.BasicAuthUsernameKey: []byte("user"), corev1.BasicAuthPasswordKey: []byte("pass")},
+	}
+	token := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "token"},
+		Data:       map[string][]byte{vsphere.SAMLTokenKey: []byte("<saml/>")},
+	}
+	source := func(secret, authMethod string) *VSphereSource {
+		vas := validVAuthSpec
+		vas.SecretRef.Name = secret
+		vas.AuthMethod = authMethod
+		return &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "source"},
+			Spec:       VSphereSourceSpec{SourceSpec: validSourceSpec, VAuthSpec: vas},
+		}
+	}
+	forbidden := func(secrets *k8sfake.Clientset) {
+		secrets.PrependReactor("get", "secrets", func(clientgotesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrs.NewForbidden(corev1.Resource("secrets"), "basic", errors.New("no access"))
+		})
+	}
+
+	tests := []struct {
+		name    string
+		ctx     func(ctx context.Context) context.Context
+		secrets func(*k8sfake.Clientset)
+		c       *VSphereSource
+		want    *apis.FieldError
+	}{{
+		name: "secret with the keys of the auth method",
+		c:    source("basic", vsphere.AuthMethodBasic),
+	}, {
+		name: "secret with a SAML token",
+		c:    source("token", vsphere.AuthMethodSAML),
+	}, {
+		name: "missing secret",
+		c:    source("missing", vsphere.AuthMethodBasic),
+		want: &apis.FieldError{
+			Message: "invalid value: missing",
+			Paths:   []string{"spec.secretRef.name"},
+			Details: "secret ns/missing not found, create it first, e.g. with kn vsphere login",
+		},
+	}, {
+		name: "secret without the keys of the auth method",
+		c:    source("token", vsphere.AuthMethodBasic),
+		want: &apis.FieldError{
+			Message: "invalid value: token",
+			Paths:   []string{"spec.secretRef.name"},
+			Details: "secret lacks the keys required by the basic auth method: username, password",
+		},
+	}, {
+		name:    "not permitted to get the secret",
+		secrets: forbidden,
+		c:       source("missing", vsphere.AuthMethodBasic),
+	}, {
+		name: "update with the same secret",
+		ctx: func(ctx context.Context) context.Context {
+			return apis.WithinUpdate(ctx, source("token", vsphere.AuthMethodBasic))
+		},
+		c: source("token", vsphere.AuthMethodBasic),
+	}, {
+		name: "update with another auth method",
+		ctx: func(ctx context.Context) context.Context {
+			return apis.WithinUpdate(ctx, source("token", vsphere.AuthMethodSAML))
+		},
+		c: source("token", vsphere.AuthMethodCSP),
+		want: &apis.FieldError{
+			Message: "invalid value: token",
+			Paths:   []string{"spec.secretRef.name"},
+			Details: "secret lacks the keys required by the csp auth method: apiToken, orgId, sddcId",
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secrets := k8sfake.NewSimpleClientset(basic, token)
+			if test.secrets != nil {
+				test.secrets(secrets)
+			}
+			ctx := WithSecrets(context.Background(), secrets.CoreV1())
+			if test.ctx != nil {
+				ctx = test.ctx(ctx)
+			}
+
+			got := test.c.Validate(ctx)
+			if !cmp.Equal(test.want.Error(), got.Error()) {
+				t.Errorf("Validate (-want, +got) = %v",
+					cmp.Diff(test.want.Error(), got.Error()))
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	"knative.dev/pkg/apis"
+)
+
+// SetDefaults implements apis.Defaultable
+func (vis *VSphereInventorySource) SetDefaults(ctx context.Context) {
+	withNS := apis.WithinParent(ctx, vis.ObjectMeta)
+	vis.Spec.Sink.SetDefaults(withNS)
+}
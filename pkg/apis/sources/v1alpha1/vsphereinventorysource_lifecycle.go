@@ -0,0 +1,67 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package v1alpha1
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+var inventoryCondSet = apis.NewLivingConditionSet(
+	VSphereInventorySourceConditionAuthReady,
+	VSphereInventorySourceConditionAdapterReady,
+)
+
+// GetConditionSet retrieves the condition set for this resource.
+// Implements the KRShaped interface.
+func (*VSphereInventorySource) GetConditionSet() apis.ConditionSet {
+	return inventoryCondSet
+}
+
+// GetGroupVersionKind implements kmeta.OwnerRefable
+func (vis *VSphereInventorySource) GetGroupVersionKind() schema.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("VSphereInventorySource")
+}
+
+func (viss *VSphereInventorySourceStatus) InitializeConditions() {
+	inventoryCondSet.Manage(viss).InitializeConditions()
+}
+
+func (viss *VSphereInventorySourceStatus) PropagateAuthStatus(status duckv1.Status) {
+	cond := status.GetCondition(apis.ConditionReady)
+	switch {
+	case cond == nil:
+		inventoryCondSet.Manage(viss).MarkUnknown(VSphereInventorySourceConditionAuthReady, "", "")
+	case cond.Status == corev1.ConditionUnknown:
+		inventoryCondSet.Manage(viss).MarkUnknown(VSphereInventorySourceConditionAuthReady, cond.Reason, cond.Message)
+	case cond.Status == corev1.ConditionFalse:
+		inventoryCondSet.Manage(viss).MarkFalse(VSphereInventorySourceConditionAuthReady, cond.Reason, cond.Message)
+	case cond.Status == corev1.ConditionTrue:
+		inventoryCondSet.Manage(viss).MarkTrue(VSphereInventorySourceConditionAuthReady)
+	}
+}
+
+func (viss *VSphereInventorySourceStatus) PropagateAdapterStatus(d appsv1.DeploymentStatus) {
+	// Check if the Deployment is available.
+	for _, cond := range d.Conditions {
+		if cond.Type == appsv1.DeploymentAvailable {
+			switch {
+			case cond.Status == corev1.ConditionUnknown:
+				inventoryCondSet.Manage(viss).MarkUnknown(VSphereInventorySourceConditionAdapterReady, cond.Reason, cond.Message)
+			case cond.Status == corev1.ConditionFalse:
+				inventoryCondSet.Manage(viss).MarkFalse(VSphereInventorySourceConditionAdapterReady, cond.Reason, cond.Message)
+			case cond.Status == corev1.ConditionTrue:
+				inventoryCondSet.Manage(viss).MarkTrue(VSphereInventorySourceConditionAdapterReady)
+			}
+			return
+		}
+	}
+
+	inventoryCondSet.Manage(viss).MarkUnknown(VSphereInventorySourceConditionAdapterReady, "", "")
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/apis/duck"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	apistest "knative.dev/pkg/apis/testing"
+)
+
+func TestVSphereInventorySourceDuckTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		t    duck.Implementable
+	}{{
+		name: "conditions",
+		t:    &duckv1.Conditions{},
+	}, {
+		name: "source",
+		t:    &duckv1.Source{},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := duck.VerifyType(&VSphereInventorySource{}, test.t)
+			if err != nil {
+				t.Errorf("VerifyType(VSphereInventorySource, %T) = %v", test.t, err)
+			}
+		})
+	}
+}
+
+func TestTypicalInventorySourceFlow(t *testing.T) {
+	r := &VSphereInventorySourceStatus{}
+	r.InitializeConditions()
+	apistest.CheckConditionOngoing(r, VSphereInventorySourceConditionReady, t)
+
+	r.PropagateAuthStatus(duckv1.Status{})
+	apistest.CheckConditionOngoing(r, VSphereInventorySourceConditionAuthReady, t)
+	r.PropagateAuthStatus(duckv1.Status{
+		Conditions: []apis.Condition{{
+			Type:   apis.ConditionReady,
+			Status: corev1.ConditionFalse,
+		}},
+	})
+	apistest.CheckConditionFailed(r, VSphereInventorySourceConditionAuthReady, t)
+	apistest.CheckConditionFailed(r, VSphereInventorySourceConditionReady, t)
+	r.PropagateAuthStatus(duckv1.Status{
+		Conditions: []apis.Condition{{
+			Type:   apis.ConditionReady,
+			Status: corev1.ConditionTrue,
+		}},
+	})
+	apistest.CheckConditionSucceeded(r, VSphereInventorySourceConditionAuthReady, t)
+	apistest.CheckConditionOngoing(r, VSphereInventorySourceConditionReady, t)
+
+	r.PropagateAdapterStatus(appsv1.DeploymentStatus{})
+	apistest.CheckConditionOngoing(r, VSphereInventorySourceConditionAdapterReady, t)
+	r.PropagateAdapterStatus(appsv1.DeploymentStatus{
+		Conditions: []appsv1.DeploymentCondition{{
+			Type:   appsv1.DeploymentAvailable,
+			Status: corev1.ConditionTrue,
+		}},
+	})
+	apistest.CheckConditionSucceeded(r, VSphereInventorySourceConditionAdapterReady, t)
+
+	apistest.CheckConditionSucceeded(r, VSphereInventorySourceConditionReady, t)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/kmeta"
+)
+
+// +genclient
+// +genreconciler
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VSphereInventorySource is a Knative abstraction that sends CloudEvents when
+// properties of vSphere inventory objects change, e.g. the power state of a
+// virtual machine, including changes for which vCenter does not raise an event.
+type VSphereInventorySource struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the desired state of the VSphereInventorySource (from the client).
+	// +optional
+	Spec VSphereInventorySourceSpec `json:"spec,omitempty"`
+
+	// Status communicates the observed state of the VSphereInventorySource (from the controller).
+	// +optional
+	Status VSphereInventorySourceStatus `json:"status,omitempty"`
+}
+
+// Check that VSphereInventorySource can be validated and defaulted.
+var _ apis.Validatable = (*VSphereInventorySource)(nil)
+var _ apis.Defaultable = (*VSphereInventorySource)(nil)
+var _ kmeta.OwnerRefable = (*VSphereInventorySource)(nil)
+
+// VSphereInventorySourceSpec holds the desired state of the VSphereInventorySource (from the client).
+type VSphereInventorySourceSpec struct {
+	duckv1.SourceSpec `json:",inline"`
+
+	VAuthSpec `json:",inline"`
+
+	// Watches are the managed object types and their properties to watch for
+	// changes.
+	Watches []VPropertyWatch `json:"watches"`
+}
+
+// VPropertyWatch selects the properties to watch for a managed object type.
+type VPropertyWatch struct {
+	// Type is the managed object type, e.g. VirtualMachine or HostSystem.
+	Type string `json:"type"`
+
+	// Properties are the property paths to watch, e.g. runtime.powerState.
+	Properties []string `json:"properties"`
+}
+
+const (
+	// VSphereInventorySourceConditionReady is set to reflect the overall state of the resource.
+	VSphereInventorySourceConditionReady = apis.ConditionReady
+
+	// VSphereInventorySourceConditionAuthReady is set to reflect the state of the auth part of the VSphereInventorySource.
+	VSphereInventorySourceConditionAuthReady = "AuthReady"
+
+	// VSphereInventorySourceConditionAdapterReady is set to reflect the state of the adapter part of the VSphereInventorySource.
+	VSphereInventorySourceConditionAdapterReady = "AdapterReady"
+)
+
+// VSphereInventorySourceStatus communicates the observed state of the VSphereInventorySource (from the controller).
+type VSphereInventorySourceStatus struct {
+	duckv1.SourceStatus `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VSphereInventorySourceList is a list of VSphereInventorySource resources
+type VSphereInventorySourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []VSphereInventorySource `json:"items"`
+}
+
+// GetStatus retrieves the status of the VSphereInventorySource. Implements the KRShaped interface.
+func (vis *VSphereInventorySource) GetStatus() *duckv1.Status {
+	return &vis.Status.Status
+}
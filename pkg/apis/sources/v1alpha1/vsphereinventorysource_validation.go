@@ -0,0 +1,48 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	"knative.dev/pkg/apis"
+)
+
+// Validate implements apis.Validatable
+func (vis *VSphereInventorySource) Validate(ctx context.Context) *apis.FieldError {
+	return vis.Spec.Validate(ctx).Also(vis.Spec.VAuthSpec.validateSecret(ctx, vis.Namespace)).ViaField("spec")
+}
+
+// Validate implements apis.Validatable
+func (viss *VSphereInventorySourceSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	err = viss.Sink.Validate(ctx).ViaField("sink").Also(viss.VAuthSpec.Validate(ctx))
+
+	if len(viss.Watches) == 0 {
+		return err.Also(apis.ErrMissingField("watches"))
+	}
+
+	for i, w := range viss.Watches {
+		err = err.Also(w.Validate(ctx).ViaFieldIndex("watches", i))
+	}
+	return err
+}
+
+func (vpw VPropertyWatch) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vpw.Type == "" {
+		err = err.Also(apis.ErrMissingField("type"))
+	}
+
+	if len(vpw.Properties) == 0 {
+		err = err.Also(apis.ErrMissingField("properties"))
+	}
+
+	for i, p := range vpw.Properties {
+		if p == "" {
+			err = err.Also(apis.ErrInvalidArrayValue(p, "properties", i))
+		}
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+func TestVSphereInventorySourceValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		c    *VSphereInventorySource
+		want *apis.FieldError
+	}{{
+		name: "valid",
+		c: &VSphereInventorySource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereInventorySourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Watches: []VPropertyWatch{{
+					Type:       "VirtualMachine",
+					Properties: []string{"runtime.powerState"},
+				}},
+			},
+		},
+		want: nil,
+	}, {
+		name: "missing Watches",
+		c: &VSphereInventorySource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereInventorySourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+			},
+		},
+		want: apis.ErrMissingField("spec.watches"),
+	}, {
+		name: "invalid Watches",
+		c: &VSphereInventorySource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereInventorySourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Watches: []VPropertyWatch{{
+					Type: "VirtualMachine",
+				}, {
+					Properties: []string{"runtime.connectionState", ""},
+				}},
+			},
+		},
+		want: apis.ErrMissingField("spec.watches[0].properties", "spec.watches[1].type").
+			Also(apis.ErrInvalidArrayValue("", "spec.watches[1].properties", 1)),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.c.Validate(context.Background())
+			if !cmp.Equal(test.want.Error(), got.Error()) {
+				t.Errorf("Validate (-want, +got) = %v",
+					cmp.Diff(test.want.Error(), got.Error()))
+			}
+		})
+	}
+}
@@ -7,20 +7,87 @@ package v1alpha1
 
 import (
 	"context"
+	"time"
 
+	"go.uber.org/zap"
 	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
 
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/config"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
 )
 
 // SetDefaults implements apis.Defaultable
 func (vs *VSphereSource) SetDefaults(ctx context.Context) {
+	// The settings of the template override those of the source, and are
+	// defaulted with them.
+	if get := templatesFromContext(ctx); get != nil && vs.Spec.TemplateRef != "" {
+		if t, err := get(ctx, vs.Spec.TemplateRef); err != nil {
+			// rejected by Validate if the template does not exist
+			logging.FromContext(ctx).Warnw("Failed to get the template of the source", zap.Error(err))
+		} else {
+			vs.Spec.ApplyTemplate(&t.Spec)
+		}
+	}
+
 	withNS := apis.WithinParent(ctx, vs.ObjectMeta)
 	vs.Spec.Sink.SetDefaults(withNS)
+	for i := range vs.Spec.Sinks {
+		vs.Spec.Sinks[i].SetDefaults(withNS)
+	}
 
 	// only checking period, setting maxAge to 0 will disable event replay
 	// to get at-most-once semantics
-	if vs.Spec.CheckpointConfig.PeriodSeconds == 0 {
-		vs.Spec.CheckpointConfig.PeriodSeconds = int64(vsphere.CheckpointDefaultPeriod.Seconds())
+	if cc := &vs.Spec.CheckpointConfig; cc.PeriodSeconds == 0 {
+		d, err := config.DefaultsFromContext(ctx, vs.Namespace)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Failed to get the defaults of the namespace", zap.Error(err))
+			d = config.FromContextOrDefaults(ctx).Defaults
+		}
+
+		cc.PeriodSeconds = int64(d.CheckpointPeriod / time.Second)
+		if cc.MaxAgeSeconds == 0 {
+			// the source does not configure checkpointing at all
+			cc.MaxAgeSeconds = int64(d.CheckpointMaxAge / time.Second)
+		}
+		if cc.MaxAgeSeconds > 0 && cc.PeriodSeconds > cc.MaxAgeSeconds {
+			cc.PeriodSeconds = cc.MaxAgeSeconds
+		}
+	}
+
+	if vs.Spec.ExtensionAttributes == nil {
+		vs.Spec.ExtensionAttributes = append([]string(nil), vsphere.StandardExtensions...)
+	}
+
+	vs.Spec.VAuthSpec.SetDefaults(ctx)
+
+	if vs.Spec.OutputFormat == "" {
+		vs.Spec.OutputFormat = vsphere.OutputFormatCloudEvents
+	}
+
+	if vs.Spec.Delivery == nil {
+		vs.Spec.Delivery = &VDeliverySpec{}
+	}
+	if vs.Spec.Delivery.ContentMode == "" {
+		vs.Spec.Delivery.ContentMode = vsphere.ContentModeBinary
+	}
+
+	if k := vs.Spec.Kafka; k != nil && k.SASL != nil && k.SASL.Mechanism == "" {
+		k.SASL.Mechanism = vsphere.KafkaSASLPlain
+	}
+
+	if rd := vs.Spec.Redaction; rd != nil && rd.Mode == "" {
+		rd.Mode = vsphere.RedactionMask
+	}
+
+	if rl := vs.Spec.RateLimit; rl != nil && rl.Burst == 0 {
+		rl.Burst = rl.EventsPerSecond
+	}
+}
+
+// SetDefaults implements apis.Defaultable
+func (vas *VAuthSpec) SetDefaults(ctx context.Context) {
+	if vas.AuthMethod == "" {
+		vas.AuthMethod = vsphere.AuthMethodBasic
 	}
 }
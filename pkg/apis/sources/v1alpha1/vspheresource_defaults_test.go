@@ -13,9 +13,24 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/config"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
 )
 
+var (
+	defaultedVAuthSpec = func() VAuthSpec {
+		spec := validVAuthSpec
+		spec.AuthMethod = vsphere.AuthMethodBasic
+		return spec
+	}()
+
+	samlVAuthSpec = func() VAuthSpec {
+		spec := validVAuthSpec
+		spec.AuthMethod = vsphere.AuthMethodSAML
+		return spec
+	}()
+)
+
 func TestVSphereSourceDefaulting(t *testing.T) {
 	tests := []struct {
 		name string
@@ -38,11 +53,14 @@ func TestVSphereSourceDefaulting(t *testing.T) {
 			},
 			Spec: VSphereSourceSpec{
 				SourceSpec: validSourceSpec,
-				VAuthSpec:  validVAuthSpec,
+				VAuthSpec:  defaultedVAuthSpec,
 				CheckpointConfig: VCheckpointSpec{
 					MaxAgeSeconds: 0,
 					PeriodSeconds: int64(vsphere.CheckpointDefaultPeriod.Seconds()),
 				},
+				ExtensionAttributes: vsphere.StandardExtensions,
+				OutputFormat:        vsphere.OutputFormatCloudEvents,
+				Delivery:            &VDeliverySpec{ContentMode: vsphere.ContentModeBinary},
 			},
 		},
 	}, {
@@ -81,11 +99,14 @@ func TestVSphereSourceDefaulting(t *testing.T) {
 						},
 					},
 				},
-				VAuthSpec: validVAuthSpec,
+				VAuthSpec: defaultedVAuthSpec,
 				CheckpointConfig: VCheckpointSpec{
 					MaxAgeSeconds: 0,
 					PeriodSeconds: int64(vsphere.CheckpointDefaultPeriod.Seconds()),
 				},
+				ExtensionAttributes: vsphere.StandardExtensions,
+				OutputFormat:        vsphere.OutputFormatCloudEvents,
+				Delivery:            &VDeliverySpec{ContentMode: vsphere.ContentModeBinary},
 			},
 		},
 	}, {
@@ -109,11 +130,116 @@ func TestVSphereSourceDefaulting(t *testing.T) {
 			},
 			Spec: VSphereSourceSpec{
 				SourceSpec: validSourceSpec,
-				VAuthSpec:  validVAuthSpec,
+				VAuthSpec:  defaultedVAuthSpec,
 				CheckpointConfig: VCheckpointSpec{
 					MaxAgeSeconds: 3600,
 					PeriodSeconds: 60,
 				},
+				ExtensionAttributes: vsphere.StandardExtensions,
+				OutputFormat:        vsphere.OutputFormatCloudEvents,
+				Delivery:            &VDeliverySpec{ContentMode: vsphere.ContentModeBinary},
+			},
+		},
+	}, {
+		name: "no extension attributes",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				CheckpointConfig: VCheckpointSpec{
+					PeriodSeconds: 60,
+				},
+				ExtensionAttributes: []string{},
+			},
+		},
+		want: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  defaultedVAuthSpec,
+				CheckpointConfig: VCheckpointSpec{
+					PeriodSeconds: 60,
+				},
+				ExtensionAttributes: []string{},
+				OutputFormat:        vsphere.OutputFormatCloudEvents,
+				Delivery:            &VDeliverySpec{ContentMode: vsphere.ContentModeBinary},
+			},
+		},
+	}, {
+		name: "custom delivery and output format",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  samlVAuthSpec,
+				CheckpointConfig: VCheckpointSpec{
+					PeriodSeconds: 60,
+				},
+				ExtensionAttributes: []string{},
+				OutputFormat:        vsphere.OutputFormatCDEvents,
+				Delivery: &VDeliverySpec{
+					Path:        "/events",
+					ContentMode: vsphere.ContentModeStructured,
+				},
+			},
+		},
+		want: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  samlVAuthSpec,
+				CheckpointConfig: VCheckpointSpec{
+					PeriodSeconds: 60,
+				},
+				ExtensionAttributes: []string{},
+				OutputFormat:        vsphere.OutputFormatCDEvents,
+				Delivery: &VDeliverySpec{
+					Path:        "/events",
+					ContentMode: vsphere.ContentModeStructured,
+				},
+			},
+		},
+	}, {
+		name: "rate limit without burst and redaction without mode",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				CheckpointConfig: VCheckpointSpec{
+					PeriodSeconds: 60,
+				},
+				ExtensionAttributes: []string{},
+				RateLimit:           &VRateLimitSpec{EventsPerSecond: 10},
+				Redaction:           &VRedactionSpec{Fields: []string{"userName"}},
+			},
+		},
+		want: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  defaultedVAuthSpec,
+				CheckpointConfig: VCheckpointSpec{
+					PeriodSeconds: 60,
+				},
+				ExtensionAttributes: []string{},
+				OutputFormat:        vsphere.OutputFormatCloudEvents,
+				Delivery:            &VDeliverySpec{ContentMode: vsphere.ContentModeBinary},
+				RateLimit:           &VRateLimitSpec{EventsPerSecond: 10, Burst: 10},
+				Redaction:           &VRedactionSpec{Fields: []string{"userName"}, Mode: vsphere.RedactionMask},
 			},
 		},
 	}}
@@ -128,3 +254,47 @@ func TestVSphereSourceDefaulting(t *testing.T) {
 		})
 	}
 }
+
+func TestVSphereSourceCheckpointDefaulting(t *testing.T) {
+	defaults, err := config.NewDefaultsFromMap(map[string]string{
+		"checkpoint-max-age": "5m",
+		"checkpoint-period":  "30s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := config.ToContext(context.Background(), &config.Config{Defaults: defaults})
+
+	tests := []struct {
+		name string
+		c    VCheckpointSpec
+		want VCheckpointSpec
+	}{{
+		name: "not configured",
+		want: VCheckpointSpec{MaxAgeSeconds: 300, PeriodSeconds: 30},
+	}, {
+		name: "max age configured",
+		c:    VCheckpointSpec{MaxAgeSeconds: 20},
+		want: VCheckpointSpec{MaxAgeSeconds: 20, PeriodSeconds: 20},
+	}, {
+		name: "period configured",
+		c:    VCheckpointSpec{PeriodSeconds: 60},
+		want: VCheckpointSpec{PeriodSeconds: 60},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vs := &VSphereSource{
+				Spec: VSphereSourceSpec{
+					SourceSpec:       validSourceSpec,
+					VAuthSpec:        validVAuthSpec,
+					CheckpointConfig: test.c,
+				},
+			}
+			vs.SetDefaults(ctx)
+			if got := vs.Spec.CheckpointConfig; !cmp.Equal(test.want, got) {
+				t.Errorf("SetDefaults (-want, +got) = %v", cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
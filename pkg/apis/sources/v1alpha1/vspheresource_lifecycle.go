@@ -6,16 +6,26 @@ SPDX-License-Identifier: Apache-2.0
 package v1alpha1
 
 import (
+	"time"
+
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
 )
 
+// MaxConditionHistory is the maximum number of condition transitions kept in
+// the status of a VSphereSource.
+const MaxConditionHistory = 10
+
 var condSet = apis.NewLivingConditionSet(
 	VSphereSourceConditionAuthReady,
 	VSphereSourceConditionAdapterReady,
+	VSphereSourceConditionSinkProvided,
 )
 
 // GetConditionSet retrieves the condition set for this resource.
@@ -47,6 +57,20 @@ func (vss *VSphereSourceStatus) PropagateAuthStatus(status duckv1.Status) {
 	}
 }
 
+// MarkAuthSharedAdapter sets the auth condition for sources served by the
+// shared adapter, which reads the credentials itself instead of a
+// VSphereBinding.
+func (vss *VSphereSourceStatus) MarkAuthSharedAdapter() {
+	condSet.Manage(vss).MarkTrueWithReason(VSphereSourceConditionAuthReady, "SharedAdapter",
+		"Credentials are read by the shared adapter")
+}
+
+// MarkSharedAdapterUnsupported sets the adapter condition for sources which use
+// a feature not supported by the shared adapter, which does not serve them.
+func (vss *VSphereSourceStatus) MarkSharedAdapterUnsupported(messageFormat string, messageA ...interface{}) {
+	condSet.Manage(vss).MarkFalse(VSphereSourceConditionAdapterReady, "SharedAdapterUnsupported", messageFormat, messageA...)
+}
+
 func (vss *VSphereSourceStatus) PropagateAdapterStatus(d appsv1.DeploymentStatus) {
 	// Check if the Deployment is available.
 	for _, cond := range d.Conditions {
@@ -65,3 +89,157 @@ func (vss *VSphereSourceStatus) PropagateAdapterStatus(d appsv1.DeploymentStatus
 
 	condSet.Manage(vss).MarkUnknown(VSphereSourceConditionAdapterReady, "", "")
 }
+
+// MarkSink sets the sink condition to reflect resolved sinks.
+func (vss *VSphereSourceStatus) MarkSink() {
+	condSet.Manage(vss).MarkTrue(VSphereSourceConditionSinkProvided)
+}
+
+// MarkNoSink sets the sink condition to reflect sinks which could not be
+// resolved, with the given reason, e.g. SinkTLSRequired.
+func (vss *VSphereSourceStatus) MarkNoSink(reason, messageFormat string, messageA ...interface{}) {
+	condSet.Manage(vss).MarkFalse(VSphereSourceConditionSinkProvided, reason, messageFormat, messageA...)
+}
+
+// MarkAdapterPaused sets the adapter condition to reflect a paused source,
+// whose adapter is scaled to zero.
+func (vss *VSphereSourceStatus) MarkAdapterPaused() {
+	condSet.Manage(vss).MarkTrueWithReason(VSphereSourceConditionAdapterReady, "Paused",
+		"The adapter is scaled to zero until the source is resumed")
+}
+
+// MarkSessionRelogin sets the session condition to reflect successful logins of
+// the adapter after its vCenter session expired.
+func (vss *VSphereSourceStatus) MarkSessionRelogin(relogins int64, last time.Time) {
+	condSet.Manage(vss).MarkTrueWithReason(VSphereSourceConditionSessionReady, "Relogin",
+		"Logged in to vCenter %d time(s) after the session expired, last at %s", relogins, last.UTC().Format(time.RFC3339))
+}
+
+// MarkSessionLoginFailed sets the session condition to reflect a failed login
+// of the adapter after its vCenter session expired.
+func (vss *VSphereSourceStatus) MarkSessionLoginFailed(message string) {
+	condSet.Manage(vss).MarkFalse(VSphereSourceConditionSessionReady, "LoginFailed", "%s", message)
+}
+
+// MarkSourceConnected sets the connection condition to reflect a successful
+// login of the adapter to vCenter.
+func (vss *VSphereSourceStatus) MarkSourceConnected(last time.Time) {
+	condSet.Manage(vss).MarkTrueWithReason(VSphereSourceConditionSourceConnected, "Connected",
+		"Logged in to vCenter at %s", last.UTC().Format(time.RFC3339))
+}
+
+// MarkSourceConnectedFallback sets the connection condition to reflect a
+// successful login of the adapter to the given fallback address of vCenter
+// because the address of the source was unreachable.
+func (vss *VSphereSourceStatus) MarkSourceConnectedFallback(last time.Time, address string) {
+	condSet.Manage(vss).MarkTrueWithReason(VSphereSourceConditionSourceConnected, "FailedOver",
+		"Logged in to the fallback vCenter %s at %s", address, last.UTC().Format(time.RFC3339))
+}
+
+// MarkSourceConnectionFailed sets the connection condition to reflect a failed
+// connection of the adapter to vCenter with the given reason, e.g.
+// AuthenticationFailed.
+func (vss *VSphereSourceStatus) MarkSourceConnectionFailed(reason, message string) {
+	condSet.Manage(vss).MarkFalse(VSphereSourceConditionSourceConnected, reason, "%s", message)
+}
+
+// PropagateDeliveryStatus sets the delivery fields of the status from the
+// delivery status recorded by the adapter. Zero times are not set.
+func (vss *VSphereSourceStatus) PropagateDeliveryStatus(lastDelivered, checkpoint time.Time, eventsPerMinute int64) {
+	vss.LastDeliveredTime = volatileTime(lastDelivered)
+	vss.CheckpointTime = volatileTime(checkpoint)
+	vss.EventsPerMinute = eventsPerMinute
+}
+
+// PropagateMirrorStatus sets the mirror status from the mirror status recorded
+// by the adapter, or clears it if the adapter has no mirror sink.
+func (vss *VSphereSourceStatus) PropagateMirrorStatus(status *vsphere.MirrorStatus) {
+	if status == nil {
+		vss.Mirror = nil
+		return
+	}
+	vss.Mirror = &VMirrorStatus{
+		LastDeliveredTime: volatileTime(status.LastDeliveredTime),
+		EventsPerMinute:   status.EventsPerMinute,
+		MirroredEvents:    status.MirroredEvents,
+		DivergentEvents:   status.DivergentEvents,
+		LastError:         status.LastError,
+	}
+}
+
+// MarkProgressing sets the progressing condition to reflect an adapter which
+// keeps up with the vCenter events.
+func (vss *VSphereSourceStatus) MarkProgressing(lag time.Duration) {
+	vss.LagSeconds = int64(lag / time.Second)
+	condSet.Manage(vss).MarkTrueWithReason(VSphereSourceConditionProgressing, "CaughtUp",
+		"The adapter is %s behind the newest vCenter event", lag)
+}
+
+// MarkLagging sets the progressing condition to reflect an adapter which is
+// more than the given threshold behind the newest vCenter event.
+func (vss *VSphereSourceStatus) MarkLagging(lag, threshold time.Duration) {
+	vss.LagSeconds = int64(lag / time.Second)
+	condSet.Manage(vss).MarkFalse(VSphereSourceConditionProgressing, "Lagging",
+		"The adapter is %s behind the newest vCenter event, more than %s", lag, threshold)
+}
+
+// MarkDeliveryFailed sets the progressing condition to reflect an adapter which
+// failed to send events with the given reason, e.g. SinkRejected.
+func (vss *VSphereSourceStatus) MarkDeliveryFailed(reason, message string) {
+	condSet.Manage(vss).MarkFalse(VSphereSourceConditionProgressing, reason, "%s", message)
+}
+
+// IsLagging returns true if the progressing condition reflects a lagging
+// adapter.
+func (vss *VSphereSourceStatus) IsLagging() bool {
+	cond := vss.GetCondition(VSphereSourceConditionProgressing)
+	return cond != nil && cond.Status == corev1.ConditionFalse
+}
+
+func volatileTime(t time.Time) *apis.VolatileTime {
+	if t.IsZero() {
+		return nil
+	}
+	return &apis.VolatileTime{Inner: metav1.NewTime(t)}
+}
+
+// RecordConditionTransitions appends every given condition whose status
+// differs from the last transition recorded for its type to the condition
+// history, dropping the oldest entries beyond MaxConditionHistory. The given
+// conditions are the ones written by the previous reconciliation, whose
+// transition times are final, unlike the ones of a running reconciliation.
+func (vss *VSphereSourceStatus) RecordConditionTransitions(conditions duckv1.Conditions) {
+	for _, cond := range conditions {
+		if last := vss.lastTransition(cond.Type); last != nil {
+			if last.Status == cond.Status {
+				continue
+			}
+		} else if len(vss.ConditionHistory) >= MaxConditionHistory &&
+			cond.LastTransitionTime.Inner.Before(&vss.ConditionHistory[0].LastTransitionTime.Inner) {
+			// its transition was dropped from the full history already
+			continue
+		}
+
+		vss.ConditionHistory = append(vss.ConditionHistory, VConditionTransition{
+			Type:               cond.Type,
+			Status:             cond.Status,
+			Reason:             cond.Reason,
+			LastTransitionTime: cond.LastTransitionTime,
+		})
+	}
+
+	if n := len(vss.ConditionHistory); n > MaxConditionHistory {
+		vss.ConditionHistory = vss.ConditionHistory[n-MaxConditionHistory:]
+	}
+}
+
+// lastTransition returns the last recorded transition of the condition of the
+// given type, or nil if there is none.
+func (vss *VSphereSourceStatus) lastTransition(t apis.ConditionType) *VConditionTransition {
+	for i := len(vss.ConditionHistory) - 1; i >= 0; i-- {
+		if vss.ConditionHistory[i].Type == t {
+			return &vss.ConditionHistory[i]
+		}
+	}
+	return nil
+}
@@ -7,9 +7,12 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"knative.dev/pkg/apis"
 	"knative.dev/pkg/apis/duck"
@@ -108,7 +111,187 @@ func TestTypicalSourceFlow(t *testing.T) {
 		}},
 	})
 	apistest.CheckConditionSucceeded(r, VSphereSourceConditionAdapterReady, t)
+	apistest.CheckConditionOngoing(r, VSphereSourceConditionReady, t)
+
+	// Check the progression of the SinkProvided condition.
+	r.MarkNoSink("SinkTLSRequired", "sink has no https address")
+	apistest.CheckConditionFailed(r, VSphereSourceConditionSinkProvided, t)
+	apistest.CheckConditionFailed(r, VSphereSourceConditionReady, t)
+	r.MarkSink()
+	apistest.CheckConditionSucceeded(r, VSphereSourceConditionSinkProvided, t)
 
 	// After all of that, we're finally ready!
 	apistest.CheckConditionSucceeded(r, VSphereSourceConditionReady, t)
 }
+
+func TestSharedAdapterSourceFlow(t *testing.T) {
+	r := &VSphereSourceStatus{}
+	r.InitializeConditions()
+	r.MarkSink()
+
+	r.MarkAuthSharedAdapter()
+	apistest.CheckConditionSucceeded(r, VSphereSourceConditionAuthReady, t)
+	apistest.CheckConditionOngoing(r, VSphereSourceConditionReady, t)
+	if got := r.GetCondition(VSphereSourceConditionAuthReady).Reason; got != "SharedAdapter" {
+		t.Errorf("auth condition reason = %q, want %q", got, "SharedAdapter")
+	}
+
+	r.PropagateAdapterStatus(appsv1.DeploymentStatus{
+		Conditions: []appsv1.DeploymentCondition{{
+			Type:   appsv1.DeploymentAvailable,
+			Status: corev1.ConditionTrue,
+		}},
+	})
+	apistest.CheckConditionSucceeded(r, VSphereSourceConditionReady, t)
+
+	r.MarkSharedAdapterUnsupported("%s is not supported by the shared adapter", "kafka")
+	apistest.CheckConditionFailed(r, VSphereSourceConditionAdapterReady, t)
+	apistest.CheckConditionFailed(r, VSphereSourceConditionReady, t)
+	if got := r.GetCondition(VSphereSourceConditionAdapterReady).Reason; got != "SharedAdapterUnsupported" {
+		t.Errorf("adapter condition reason = %q, want %q", got, "SharedAdapterUnsupported")
+	}
+}
+
+func TestPausedSourceFlow(t *testing.T) {
+	r := &VSphereSourceStatus{}
+	r.InitializeConditions()
+	r.MarkSink()
+
+	r.PropagateAuthStatus(duckv1.Status{
+		Conditions: []apis.Condition{{
+			Type:   apis.ConditionReady,
+			Status: corev1.ConditionTrue,
+		}},
+	})
+	r.MarkAdapterPaused()
+	apistest.CheckConditionSucceeded(r, VSphereSourceConditionAdapterReady, t)
+	apistest.CheckConditionSucceeded(r, VSphereSourceConditionReady, t)
+	if got := r.GetCondition(VSphereSourceConditionAdapterReady).Reason; got != "Paused" {
+		t.Errorf("adapter condition reason = %q, want %q", got, "Paused")
+	}
+}
+
+func TestSessionConditionDoesNotAffectReady(t *testing.T) {
+	r := &VSphereSourceStatus{}
+	r.InitializeConditions()
+	r.MarkSink()
+	r.PropagateAuthStatus(duckv1.Status{
+		Conditions: []apis.Condition{{
+			Type:   apis.ConditionReady,
+			Status: corev1.ConditionTrue,
+		}},
+	})
+	r.PropagateAdapterStatus(appsv1.DeploymentStatus{
+		Conditions: []appsv1.DeploymentCondition{{
+			Type:   appsv1.DeploymentAvailable,
+			Status: corev1.ConditionTrue,
+		}},
+	})
+
+	r.MarkSessionLoginFailed("invalid login")
+	apistest.CheckConditionFailed(r, VSphereSourceConditionSessionReady, t)
+	apistest.CheckConditionSucceeded(r, VSphereSourceConditionReady, t)
+	if got := r.GetCondition(VSphereSourceConditionSessionReady).Severity; got != apis.ConditionSeverityInfo {
+		t.Errorf("session condition severity = %q, want %q", got, apis.ConditionSeverityInfo)
+	}
+
+	r.MarkSessionRelogin(2, time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC))
+	apistest.CheckConditionSucceeded(r, VSphereSourceConditionSessionReady, t)
+	apistest.CheckConditionSucceeded(r, VSphereSourceConditionReady, t)
+	want := "Logged in to vCenter 2 time(s) after the session expired, last at 2021-04-01T12:00:00Z"
+	if got := r.GetCondition(VSphereSourceConditionSessionReady).Message; got != want {
+		t.Errorf("session condition message = %q, want %q", got, want)
+	}
+}
+
+func TestProgressingConditionDoesNotAffectReady(t *testing.T) {
+	r := &VSphereSourceStatus{}
+	r.InitializeConditions()
+	r.MarkSink()
+	r.MarkAuthSharedAdapter()
+	r.PropagateAdapterStatus(appsv1.DeploymentStatus{
+		Conditions: []appsv1.DeploymentCondition{{
+			Type:   appsv1.DeploymentAvailable,
+			Status: corev1.ConditionTrue,
+		}},
+	})
+
+	r.MarkLagging(12*time.Minute, 5*time.Minute)
+	apistest.CheckConditionFailed(r, VSphereSourceConditionProgressing, t)
+	apistest.CheckConditionSucceeded(r, VSphereSourceConditionReady, t)
+	want := "The adapter is 12m0s behind the newest vCenter event, more than 5m0s"
+	if got := r.GetCondition(VSphereSourceConditionProgressing).Message; got != want {
+		t.Errorf("progressing condition message = %q, want %q", got, want)
+	}
+	if !r.IsLagging() || r.LagSeconds != 720 {
+		t.Errorf("IsLagging() = %v, lagSeconds = %d, want true, 720", r.IsLagging(), r.LagSeconds)
+	}
+
+	r.MarkProgressing(3 * time.Second)
+	apistest.CheckConditionSucceeded(r, VSphereSourceConditionProgressing, t)
+	if r.IsLagging() || r.LagSeconds != 3 {
+		t.Errorf("IsLagging() = %v, lagSeconds = %d, want false, 3", r.IsLagging(), r.LagSeconds)
+	}
+}
+
+func TestRecordConditionTransitions(t *testing.T) {
+	r := &VSphereSourceStatus{}
+	r.InitializeConditions()
+
+	// initial conditions are recorded
+	r.RecordConditionTransitions(r.Conditions)
+	if got, want := len(r.ConditionHistory), len(r.Conditions); got != want {
+		t.Fatalf("len(ConditionHistory) = %d, want %d", got, want)
+	}
+
+	// unchanged conditions are not recorded
+	r.PropagateAuthStatus(duckv1.Status{})
+	r.RecordConditionTransitions(r.Conditions)
+	if got, want := len(r.ConditionHistory), len(r.Conditions); got != want {
+		t.Fatalf("len(ConditionHistory) = %d, want %d", got, want)
+	}
+
+	// flapping is recorded up to MaxConditionHistory entries
+	for i := 0; i < MaxConditionHistory; i++ {
+		status := corev1.ConditionFalse
+		if i%2 == 1 {
+			status = corev1.ConditionTrue
+		}
+
+		r.PropagateAuthStatus(duckv1.Status{
+			Conditions: []apis.Condition{{
+				Type:   apis.ConditionReady,
+				Status: status,
+				Reason: "Flapping",
+			}},
+		})
+		r.RecordConditionTransitions(r.Conditions)
+	}
+
+	if got := len(r.ConditionHistory); got != MaxConditionHistory {
+		t.Fatalf("len(ConditionHistory) = %d, want %d", got, MaxConditionHistory)
+	}
+
+	var last VConditionTransition
+	for _, tr := range r.ConditionHistory {
+		if tr.Type == VSphereSourceConditionAuthReady {
+			last = tr
+		}
+	}
+	if last.Status != corev1.ConditionTrue || last.Reason != "" {
+		t.Errorf("last %s transition = %s (%q), want %s", VSphereSourceConditionAuthReady, last.Status,
+			last.Reason, corev1.ConditionTrue)
+	}
+
+	// transitions dropped from the full history are not recorded again
+	history := append([]VConditionTransition(nil), r.ConditionHistory...)
+	dropped := apis.Condition{
+		Type:               "Dropped",
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: apis.VolatileTime{Inner: metav1.NewTime(history[0].LastTransitionTime.Inner.Add(-time.Minute))},
+	}
+	r.RecordConditionTransitions(append(r.Conditions, dropped))
+	if diff := cmp.Diff(history, r.ConditionHistory); diff != "" {
+		t.Errorf("ConditionHistory (-want, +got) = %v", diff)
+	}
+}
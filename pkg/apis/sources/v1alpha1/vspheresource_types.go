@@ -6,7 +6,9 @@ SPDX-License-Identifier: Apache-2.0
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/kmeta"
@@ -43,11 +45,645 @@ type VSphereSourceSpec struct {
 
 	VAuthSpec        `json:",inline"`
 	CheckpointConfig VCheckpointSpec `json:"checkpointConfig"`
+
+	// TemplateRef is the name of the VSphereSourceTemplate whose settings
+	// apply to the source, overriding those of the source. The template is
+	// applied again when it changes.
+	// +optional
+	TemplateRef string `json:"templateRef,omitempty"`
+
+	// EventCollector configures how events are polled from vCenter, trading
+	// latency for vCenter API load in large environments.
+	// +optional
+	EventCollector *VEventCollectorSpec `json:"eventCollector,omitempty"`
+
+	// VCenterAPI configures the timeout and the retries of the vCenter API
+	// calls of the adapter, e.g. to keep sources against slow or distant
+	// vCenters from flapping. Calls are neither bounded nor retried if
+	// omitted.
+	// +optional
+	VCenterAPI *VAPISpec `json:"vcenterAPI,omitempty"`
+
+	// Scope restricts the events and tasks of the source to the entities in
+	// the subtree of a vSphere inventory object, e.g. the cluster of a team
+	// in a shared vCenter. The whole inventory if omitted.
+	// +optional
+	Scope *VScopeSpec `json:"scope,omitempty"`
+
+	// AllowInsecureAddress allows an address without TLS, e.g. http://, which
+	// sends the vSphere credentials in clear text. Addresses must use https by
+	// default.
+	// +optional
+	AllowInsecureAddress bool `json:"allowInsecureAddress,omitempty"`
+
+	// Addresses are additional vCenters whose events are merged into the
+	// event stream of the source. The vcenterid extension attribute of the
+	// events identifies their vCenter.
+	// +optional
+	Addresses []VAddressSpec `json:"addresses,omitempty"`
+
+	// FallbackAddress is the address of the vCenter to connect to with the
+	// credentials of the source if the address is unreachable, e.g. the
+	// passive node of a vCenter HA cluster or a linked replica. The adapter
+	// reconnects to the address when it restarts.
+	// +optional
+	FallbackAddress *apis.URL `json:"fallbackAddress,omitempty"`
+
+	// Locale is the locale of the vCenter session of the adapter, e.g. de or
+	// ja_JP, in which vCenter formats the fullFormattedMessage of events and
+	// the messages of tasks. Defaults to en_US, regardless of the default
+	// locale of vCenter.
+	// +optional
+	Locale string `json:"locale,omitempty"`
+
+	// IncludeTasks enables sending CloudEvents for vSphere task lifecycle
+	// changes (queued, running, success, error) in addition to vSphere events.
+	// +optional
+	IncludeTasks bool `json:"includeTasks,omitempty"`
+
+	// IncludeContentLibrary enables sending CloudEvents for content library
+	// and library item changes, which are not part of the vSphere event stream.
+	// +optional
+	IncludeContentLibrary bool `json:"includeContentLibrary,omitempty"`
+
+	// IncludeTags enables sending CloudEvents when tags are attached to or
+	// detached from vSphere objects, which is not part of the vSphere event
+	// stream.
+	// +optional
+	IncludeTags bool `json:"includeTags,omitempty"`
+
+	// NormalizeAlarms enables sending alarm events with a type per alarm event
+	// class, e.g. com.vmware.vsphere.alarm.statuschanged, and a normalized
+	// JSON payload with the alarm, the entity and the from and to status.
+	// Alarm events are sent like all other vSphere events otherwise, e.g. as
+	// com.vmware.vsphere.AlarmStatusChangedEvent with an XML payload.
+	// +optional
+	NormalizeAlarms bool `json:"normalizeAlarms,omitempty"`
+
+	// GroupVSANEvents enables sending vSAN health and performance events with
+	// the common type prefix com.vmware.vsphere.vsan., e.g.
+	// com.vmware.vsan.perfsvc.statsdisabled as
+	// com.vmware.vsphere.vsan.perfsvc.statsdisabled, so that they can be
+	// selected with the filter com.vmware.vsphere.vsan.*. vSAN events keep
+	// the type of their event type id otherwise.
+	// +optional
+	GroupVSANEvents bool `json:"groupVsanEvents,omitempty"`
+
+	// ExtensionAttributes are the CloudEvents extension attributes describing
+	// the vSphere context of an event, which are set on every event if known.
+	// Supported are vsphereeventclass, vmmoref, hostmoref, datacenter and
+	// vcenterid. Defaults to all supported attributes, an empty list disables
+	// them.
+	// +optional
+	ExtensionAttributes []string `json:"extensionAttributes,omitempty"`
+
+	// Delivery customizes the HTTP requests used to deliver events to the sink.
+	// +optional
+	Delivery *VDeliverySpec `json:"delivery,omitempty"`
+
+	// Filter selects the events sent to the sink. All events are sent if
+	// omitted.
+	// +optional
+	Filter *VFilterSpec `json:"filter,omitempty"`
+
+	// Sinks are additional destinations the events are sent to, each with an
+	// optional filter, e.g. to feed an audit log service and a metrics
+	// pipeline from the same vCenter as the sink. The filter of the source
+	// applies to all sinks. Events are checkpointed once all sinks acknowledged
+	// them.
+	// +optional
+	Sinks []VSinkSpec `json:"sinks,omitempty"`
+
+	// Mirror temporarily sends every event to a candidate new sink in
+	// addition to the sink, e.g. to migrate the consumers to the new sink
+	// without a gap before it replaces the sink. Failures of the mirror sink
+	// are counted independently and do not affect the delivery to the sink,
+	// and the status reports the events acknowledged by only one of them.
+	// +optional
+	Mirror *VMirrorSpec `json:"mirror,omitempty"`
+
+	// Kafka delivers the events directly to a Kafka topic instead of the
+	// sink, skipping the HTTP hop for users with Kafka as their event
+	// backbone. The sink, the additional sinks and the HTTP options of the
+	// delivery must be omitted.
+	// +optional
+	Kafka *VKafkaSpec `json:"kafka,omitempty"`
+
+	// LogOnly logs the events in the adapter instead of sending them, e.g. to
+	// validate the filter and transform of a source against vCenter before
+	// pointing it at production consumers. The sink, the additional sinks and
+	// the Kafka delivery must be omitted.
+	// +optional
+	LogOnly bool `json:"logOnly,omitempty"`
+
+	// Paused scales the adapter to zero, e.g. during a maintenance window of
+	// the sink. The checkpoint is kept, and the adapter replays the events
+	// since the checkpoint when the source is resumed, subject to the maximum
+	// age of the checkpoint config.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// Transform reshapes the payloads of the events sent to the sink, e.g. to
+	// pick and rename fields of the vSphere API objects. Payloads are sent
+	// as is if omitted.
+	// +optional
+	Transform *VTransformSpec `json:"transform,omitempty"`
+
+	// Redaction masks or removes sensitive fields of the event payloads, e.g.
+	// the user names of vSphere events, before they are sent to the sink.
+	// +optional
+	Redaction *VRedactionSpec `json:"redaction,omitempty"`
+
+	// Enrichment adds information about the affected virtual machine or host
+	// of an event, which is looked up in vCenter, as extension attributes.
+	// +optional
+	Enrichment *VEnrichmentSpec `json:"enrichment,omitempty"`
+
+	// OutputFormat is the format of the events sent to the sink, either
+	// cloudevents (default) or cdevents. With cdevents, only vSphere events
+	// and tasks with a CDEvents mapping are sent.
+	// +optional
+	OutputFormat string `json:"outputFormat,omitempty"`
+
+	// PayloadEncoding is the encoding of the event payloads with a data
+	// schema, either json (default) or protobuf. With protobuf, the payloads
+	// are encoded as the messages of the published .proto definitions, which
+	// the dataschema attribute points to. Only normalized alarm, tag and
+	// content library events have a data schema, so protobuf requires at
+	// least one of normalizeAlarms, includeTags or includeContentLibrary.
+	// vSphere events and tasks are always sent as XML. Cannot be combined
+	// with transform.
+	// +optional
+	PayloadEncoding string `json:"payloadEncoding,omitempty"`
+
+	// AttributeMapping overrides how the CloudEvents type and source
+	// attributes of events are constructed, e.g. for a naming convention
+	// shared by multiple vCenters.
+	// +optional
+	AttributeMapping *VAttributeMappingSpec `json:"attributeMapping,omitempty"`
+
+	// RateLimit limits the rate of events sent to the sink, e.g. to protect a
+	// downstream function from a burst of vCenter events. Events exceeding
+	// the limit are delayed, not dropped. Unlimited if omitted.
+	// +optional
+	RateLimit *VRateLimitSpec `json:"rateLimit,omitempty"`
+
+	// Sampling coalesces bursts of events, e.g. on mass power operations,
+	// so that a downstream function is not invoked for thousands of near
+	// duplicates. Events of the same type about the same entity exceeding
+	// the threshold of a window are sent as a single summary event with
+	// their count. All events are sent if omitted.
+	// +optional
+	Sampling *VSamplingSpec `json:"sampling,omitempty"`
+
+	// Heartbeat enables heartbeat events of type com.vmware.vsphere.heartbeat
+	// with the connection and polling state of the adapter, so that a dead
+	// source can be told apart from an idle vCenter. Disabled if omitted.
+	// +optional
+	Heartbeat *VHeartbeatSpec `json:"heartbeat,omitempty"`
+
+	// LifecycleEvents enables events of type com.vmware.vsphere.source.* when
+	// the adapter connects to vCenter, loses the connection, logs in again or
+	// starts replaying events, so that the health of the source can be
+	// monitored through the sink.
+	// +optional
+	LifecycleEvents bool `json:"lifecycleEvents,omitempty"`
+
+	// Audit records the metadata of every event dispatched by the adapter,
+	// e.g. to prove to compliance which vSphere events were forwarded where.
+	// Disabled if omitted.
+	// +optional
+	Audit *VAuditSpec `json:"audit,omitempty"`
+
+	// Deployment configures how the dedicated receive adapter of the source
+	// is updated and disrupted. It does not apply to the shared adapter.
+	// +optional
+	Deployment *VDeploymentSpec `json:"deployment,omitempty"`
+
+	// AdapterOverrides injects site-specific settings into the dedicated
+	// receive adapter of the source, e.g. proxy variables or a custom CA
+	// bundle. It does not apply to the shared adapter.
+	// +optional
+	AdapterOverrides *VAdapterOverridesSpec `json:"adapterOverrides,omitempty"`
+}
+
+// VAdapterOverridesSpec are passed through to the receive adapter of a source.
+type VAdapterOverridesSpec struct {
+	// Env are environment variables of the adapter container. A variable
+	// replaces the variable of the same name set by the controller, e.g. to
+	// enable a feature flag of the adapter.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Volumes are volumes of the adapter pod, e.g. with a CA bundle. Their
+	// names must not collide with the volumes set by the controller, e.g.
+	// sink-headers.
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// VolumeMounts mount the volumes into the adapter container.
+	// +optional
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+}
+
+// VRateLimitSpec limits the rate of events sent to the sink.
+type VRateLimitSpec struct {
+	// EventsPerSecond is the sustained rate of events sent to the sink.
+	EventsPerSecond int32 `json:"eventsPerSecond"`
+
+	// Burst is the number of events which may be sent at once above the
+	// sustained rate. Defaults to eventsPerSecond.
+	// +optional
+	Burst int32 `json:"burst,omitempty"`
+}
+
+// VSamplingSpec configures the sampling of bursts of events. The first event
+// of a type about an entity, e.g. a virtual machine, opens a window. The first
+// threshold events of the window are sent as usual, the others are coalesced
+// into a summary event sent when the window closes. The summary is the last of
+// these events with the sampledcount extension attribute set to their count
+// and the sampledsince extension attribute set to the creation time of the
+// first event of the window.
+type VSamplingSpec struct {
+	// WindowSeconds is the duration of a window.
+	WindowSeconds int64 `json:"windowSeconds"`
+
+	// Threshold is the number of events of a window sent individually.
+	// Defaults to 1.
+	// +optional
+	Threshold int32 `json:"threshold,omitempty"`
+}
+
+// VHeartbeatSpec configures the heartbeat events of a source.
+type VHeartbeatSpec struct {
+	// IntervalSeconds is the interval between heartbeat events. Defaults to
+	// 60 seconds.
+	// +optional
+	IntervalSeconds int64 `json:"intervalSeconds,omitempty"`
+}
+
+// VAuditSpec configures the audit log of a source. Every attempt to send an
+// event to a sink, and every event given up on as a dead letter, is recorded
+// with the id, type and source of the event, the affected virtual machine or
+// host, the sink, the result and the latency. At least one of sink and path
+// must be set.
+type VAuditSpec struct {
+	// Sink receives the audit records as events of type
+	// com.vmware.vsphere.audit with a JSON payload.
+	// +optional
+	Sink *duckv1.Destination `json:"sink,omitempty"`
+
+	// Path is the absolute path of a file in the adapter container the audit
+	// records are appended to as JSON lines, e.g. on a persistent volume added
+	// with adapterOverrides. It is not supported by the shared adapter.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// VDeploymentSpec configures the deployment of the receive adapter of a
+// source.
+type VDeploymentSpec struct {
+	// Strategy is the update strategy of the adapter, either Recreate, which
+	// stops the old adapter before starting the new one so that vCenter is
+	// never polled twice, or RollingUpdate, which starts the new adapter first
+	// to keep the gap in the event stream short. Defaults to RollingUpdate.
+	// +optional
+	Strategy string `json:"strategy,omitempty"`
+
+	// MaxSurge is the number or percentage of adapters started above the
+	// single adapter during a RollingUpdate. Defaults to 25%, i.e. 1.
+	// +optional
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+
+	// MaxUnavailable is the number or percentage of adapters which may be
+	// unavailable during a RollingUpdate. Defaults to 25%, i.e. 0.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// PodDisruptionBudget creates a PodDisruptionBudget for the adapter, so
+	// that voluntary disruptions like node drains respect it. No budget is
+	// created if omitted.
+	// +optional
+	PodDisruptionBudget *VPodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+
+	// PriorityClassName is the PriorityClass of the adapter pod, e.g. to
+	// protect it from preemption and eviction ahead of batch workloads on a
+	// congested cluster. The default priority of the cluster if omitted.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// RuntimeClassName is the RuntimeClass the adapter pod is run with, e.g.
+	// a sandboxed container runtime. The default runtime if omitted.
+	// +optional
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+}
+
+// VPodDisruptionBudgetSpec configures the PodDisruptionBudget of the receive
+// adapter of a source. At most one of minAvailable and maxUnavailable may be
+// set.
+type VPodDisruptionBudgetSpec struct {
+	// MinAvailable is the number or percentage of adapters which must remain
+	// available during voluntary disruptions. Defaults to 1 unless
+	// maxUnavailable is set, which blocks the eviction of the adapter until
+	// it is restarted, e.g. with kn vsphere source restart.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// MaxUnavailable is the number or percentage of adapters which may be
+	// unavailable during voluntary disruptions.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// VSinkSpec is an additional sink of a source. The delivery options of the
+// source except the path and the OIDC authentication apply to it.
+type VSinkSpec struct {
+	duckv1.Destination `json:",inline"`
+
+	// Filter selects the events sent to this sink in addition to the filter
+	// of the source. All events are sent if omitted.
+	// +optional
+	Filter *VFilterSpec `json:"filter,omitempty"`
+}
+
+// VMirrorSpec is the candidate sink of a sink cutover. The delivery options of
+// the source except the path, the headers and the OIDC authentication apply to
+// it.
+type VMirrorSpec struct {
+	// Sink is the candidate new sink, which receives a copy of every event
+	// once the sink acknowledged it or it was given up on. Events it fails to
+	// acknowledge are not retried.
+	Sink duckv1.Destination `json:"sink"`
+}
+
+// VKafkaSpec configures the delivery of the events of a source to a Kafka
+// topic. The CloudEvents are written in the content mode of the delivery, keyed
+// by their partitionkey extension attribute if set.
+type VKafkaSpec struct {
+	// BootstrapServers are the host:port addresses of the Kafka brokers to
+	// connect to.
+	BootstrapServers []string `json:"bootstrapServers"`
+
+	// Topic is the Kafka topic the events are written to.
+	Topic string `json:"topic"`
+
+	// SASL authenticates the adapter with the brokers. The connections are
+	// unauthenticated if omitted.
+	// +optional
+	SASL *VKafkaSASLSpec `json:"sasl,omitempty"`
+
+	// TLS encrypts the connections to the brokers. The connections are plain
+	// text if omitted.
+	// +optional
+	TLS *VKafkaTLSSpec `json:"tls,omitempty"`
+}
+
+// VKafkaSASLSpec configures the SASL authentication with the Kafka brokers.
+type VKafkaSASLSpec struct {
+	// Mechanism is the SASL mechanism, either PLAIN (default), SCRAM-SHA-256
+	// or SCRAM-SHA-512.
+	// +optional
+	Mechanism string `json:"mechanism,omitempty"`
+
+	// SecretRef is a reference to a Kubernetes secret of type
+	// kubernetes.io/basic-auth with the "username" and "password" keys to
+	// authenticate with.
+	SecretRef corev1.LocalObjectReference `json:"secretRef"`
+}
+
+// VKafkaTLSSpec configures the TLS connections to the Kafka brokers.
+type VKafkaTLSSpec struct {
+	// CACertsConfigMapRef is a reference to a Kubernetes configmap with
+	// PEM-encoded CA certificates, which are trusted in addition to the system
+	// roots to verify the brokers.
+	// +optional
+	CACertsConfigMapRef *corev1.LocalObjectReference `json:"caCertsConfigMapRef,omitempty"`
+}
+
+// VFilterSpec selects the CloudEvents sent to the sink.
+type VFilterSpec struct {
+	// EventTypes are glob patterns matched against the CloudEvent type, e.g.
+	// com.vmware.vsphere.Alarm* to only send alarm events. An event is sent
+	// if it matches any of the patterns.
+	// +optional
+	EventTypes []string `json:"eventTypes,omitempty"`
+
+	// CEL is a CEL expression evaluated against the CloudEvent attributes and
+	// extensions in ce, e.g. ce.type, and the JSON payload in data, e.g.
+	// data.Vm.Name.startsWith("prod-"). An event is only sent if it matches
+	// the event types and the expression returns true.
+	// +optional
+	CEL string `json:"cel,omitempty"`
+}
+
+// VTransformSpec reshapes the payloads of the CloudEvents sent to the sink.
+type VTransformSpec struct {
+	// Template is a Go text/template producing the JSON payload of an event.
+	// It is executed with the payload decoded as JSON, e.g. {{ .Vm.Name }} for
+	// vSphere events, and the json function encodes a value as JSON, e.g.
+	// {"vm": {{ json .Vm.Name }}}. Events for which the template fails are
+	// not sent.
+	Template string `json:"template"`
+}
+
+// VRedactionSpec selects the payload fields which are masked or removed.
+type VRedactionSpec struct {
+	// Fields are the payload fields to redact, either names matched at any
+	// depth, e.g. userName or ipAddress, or dot-separated paths from the root
+	// of the payload, e.g. $.vm.name. Names are matched case-insensitively
+	// against the keys of JSON payloads and the elements of XML payloads,
+	// below the top-level element.
+	Fields []string `json:"fields"`
+
+	// Mode is either mask (default), replacing the values of the fields with
+	// REDACTED, or remove, dropping the fields.
+	// +optional
+	Mode string `json:"mode,omitempty"`
+}
+
+// VEnrichmentSpec selects the information added to events about the affected
+// virtual machine or host. Lookups are cached for a few minutes.
+type VEnrichmentSpec struct {
+	// Tags adds the names of the attached tags as vspheretags extension.
+	// +optional
+	Tags bool `json:"tags,omitempty"`
+
+	// FolderPath adds the inventory path of the folder of the object as
+	// vspherefolderpath extension.
+	// +optional
+	FolderPath bool `json:"folderPath,omitempty"`
+
+	// CustomAttributes adds the custom attributes of the object as
+	// JSON-encoded vsphereattributes extension.
+	// +optional
+	CustomAttributes bool `json:"customAttributes,omitempty"`
+}
+
+// VAttributeMappingSpec overrides the CloudEvents type and source attributes.
+// Types are only mapped if they have the default com.vmware.vsphere. prefix.
+type VAttributeMappingSpec struct {
+	// Source is a static source URI replacing the vCenter host.
+	// +optional
+	Source string `json:"source,omitempty"`
+
+	// TypePrefix replaces the com.vmware.vsphere. prefix of types.
+	// +optional
+	TypePrefix string `json:"typePrefix,omitempty"`
+
+	// TypeTemplate is a Go template producing the type. The fields .Class
+	// (e.g. event or task), .Name (the type without the com.vmware.vsphere.
+	// prefix, e.g. VmPoweredOnEvent) and .Type (the default type) are
+	// available. Mutually exclusive with typePrefix.
+	// +optional
+	TypeTemplate string `json:"typeTemplate,omitempty"`
+}
+
+// VAddressSpec is an additional vCenter of a VSphereSource, whose events are
+// read with the same configuration as of the vCenter of the source.
+type VAddressSpec struct {
+	// Address contains the URL of the vSphere API.
+	Address apis.URL `json:"address"`
+
+	// SkipTLSVerify specifies whether the client should skip TLS verification when
+	// talking to the vsphere address.
+	// +optional
+	SkipTLSVerify bool `json:"skipTLSVerify,omitempty"`
+
+	// SecretRef is a reference to a Kubernetes secret with the keys of the
+	// auth method of the source, e.g. "username" and "password", which will be
+	// used to authenticate with the vSphere API at "address".
+	SecretRef corev1.LocalObjectReference `json:"secretRef"`
+}
+
+// VDeliverySpec customizes the HTTP requests sent to the sink, e.g. for
+// third-party webhook receivers which require an API key in a header.
+type VDeliverySpec struct {
+	// Path is appended to the path of the resolved sink URI.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Headers are static HTTP headers added to every request sent to the sink.
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// HeadersSecretRef is a reference to a Kubernetes secret whose keys and
+	// values are added as HTTP headers to every request sent to the sink.
+	// Headers from the secret take precedence over Headers.
+	// +optional
+	HeadersSecretRef *corev1.LocalObjectReference `json:"headersSecretRef,omitempty"`
+
+	// CACertsConfigMapRef is a reference to a Kubernetes configmap with
+	// PEM-encoded CA certificates, which are trusted in addition to the system
+	// roots when sending events to an HTTPS sink.
+	// +optional
+	CACertsConfigMapRef *corev1.LocalObjectReference `json:"caCertsConfigMapRef,omitempty"`
+
+	// RequireTLS only delivers events to https addresses. The https address
+	// advertised by an Addressable with Knative eventing transport encryption
+	// is preferred regardless, but without RequireTLS its http address is
+	// used if it has no https address. It is not supported by the shared
+	// adapter.
+	// +optional
+	RequireTLS bool `json:"requireTLS,omitempty"`
+
+	// ContentMode is the CloudEvents HTTP content mode, either binary
+	// (default) or structured. Some sinks only accept structured mode.
+	// +optional
+	ContentMode string `json:"contentMode,omitempty"`
+
+	// Compression is the compression of the HTTP request bodies, either none
+	// (default), gzip to always send gzip-compressed bodies, or auto to send
+	// gzip-compressed bodies until the sink rejects one with 415 Unsupported
+	// Media Type, e.g. to reduce egress of large enriched events.
+	// +optional
+	Compression string `json:"compression,omitempty"`
+
+	// MaxAttempts is the number of attempts to send an event before the
+	// adapter gives up on it as a poison event, e.g. with a payload rejected
+	// by the sink, records it as a dead letter and advances past it. Failed
+	// events are not retried and never skipped if omitted.
+	// +optional
+	MaxAttempts int32 `json:"maxAttempts,omitempty"`
+
+	// DeadLetterSink receives the events given up on after MaxAttempts. If
+	// omitted, the most recent ones are recorded in the checkpoint configmap
+	// of the source instead.
+	// +optional
+	DeadLetterSink *duckv1.Destination `json:"deadLetterSink,omitempty"`
+}
+
+// VEventCollectorSpec configures the polling of the vCenter event history
+// collector.
+type VEventCollectorSpec struct {
+	// PollIntervalSeconds is the maximum delay between polls without new
+	// events. Polls back off from one second up to this interval while vCenter
+	// has no new events. Defaults to 5 seconds.
+	// +optional
+	PollIntervalSeconds int64 `json:"pollIntervalSeconds,omitempty"`
+
+	// PageSize is the maximum number of events read per poll, at most 1000.
+	// Defaults to 100.
+	// +optional
+	PageSize int32 `json:"pageSize,omitempty"`
+
+	// Mode is how new events are awaited, either poll (default) to poll the
+	// event history collector, or stream to have vCenter push changes of the
+	// collector and read new events as soon as they are available. Stream
+	// falls back to polling if vCenter does not support waiting for updates.
+	// +optional
+	Mode string `json:"mode,omitempty"`
+}
+
+// VAPISpec configures the timeout and the retries of the vCenter SOAP API
+// calls of an adapter, e.g. logins, reads of events and property lookups.
+type VAPISpec struct {
+	// TimeoutSeconds is the maximum duration of an attempt of a call. The
+	// wait time of long polls in stream mode is added. Unbounded if 0.
+	// +optional
+	TimeoutSeconds int64 `json:"timeoutSeconds,omitempty"`
+
+	// Retries is the number of retries of a call failing with a network error
+	// or a timeout, at most 10. Calls failing with a vCenter fault, e.g.
+	// invalid credentials, are not retried. Defaults to 0.
+	// +optional
+	Retries int32 `json:"retries,omitempty"`
+
+	// RetryBackoffSeconds is the delay before the first retry, doubled with
+	// each retry. It also paces the logins after the vCenter session expired.
+	// Defaults to 1 second.
+	// +optional
+	RetryBackoffSeconds int64 `json:"retryBackoffSeconds,omitempty"`
+
+	// MaxRetryBackoffSeconds is the maximum delay between retries and
+	// logins. Defaults to 60 seconds.
+	// +optional
+	MaxRetryBackoffSeconds int64 `json:"maxRetryBackoffSeconds,omitempty"`
+}
+
+// VScopeSpec identifies the datacenter, cluster, folder or resource pool
+// whose subtree the events of a source are collected from. Exactly one of
+// path and id is set.
+type VScopeSpec struct {
+	// Path is the inventory path of the object, e.g. /dc1/host/cluster1 or
+	// /dc1/vm/team-a. It is looked up when the adapter connects to vCenter.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// ID is the managed object reference of the object as type:value, e.g.
+	// ClusterComputeResource:domain-c7, which is stable across renames.
+	// +optional
+	ID string `json:"id,omitempty"`
 }
 
 type VCheckpointSpec struct {
 	MaxAgeSeconds int64 `json:"maxAgeSeconds"`
 	PeriodSeconds int64 `json:"periodSeconds"`
+
+	// ReplayFrom is the point in time from which to start replaying events
+	// when no checkpoint exists yet, e.g. to backfill a newly created source.
+	// Once a checkpoint is created, it takes precedence over this setting.
+	// +optional
+	ReplayFrom *metav1.Time `json:"replayFrom,omitempty"`
 }
 
 const (
@@ -62,11 +698,137 @@ const (
 
 	// VSphereSourceConditionAdapterReady is set to reflect the state of the adapter part of the VSphereSource.
 	VSphereSourceConditionAdapterReady = "AdapterReady"
+
+	// VSphereSourceConditionSinkProvided is set to reflect whether the sinks
+	// of the VSphereSource could be resolved, e.g. to an https address if TLS
+	// is required.
+	VSphereSourceConditionSinkProvided = "SinkProvided"
+
+	// VSphereSourceConditionSessionReady is set to reflect the logins of the
+	// adapter after its vCenter session expired. It does not affect the
+	// readiness of the VSphereSource.
+	VSphereSourceConditionSessionReady = "SessionReady"
+
+	// VSphereSourceConditionSourceConnected is set to reflect whether the
+	// adapter could connect and log in to vCenter, with the reason of a failed
+	// connection. It does not affect the readiness of the VSphereSource.
+	VSphereSourceConditionSourceConnected = "SourceConnected"
+
+	// VSphereSourceConditionProgressing is set to reflect whether the adapter
+	// keeps up with the vCenter events, with the lag of a lagging adapter. It
+	// does not affect the readiness of the VSphereSource.
+	VSphereSourceConditionProgressing = "Progressing"
 )
 
 // VSphereSourceStatus communicates the observed state of the VSphereSource (from the controller).
 type VSphereSourceStatus struct {
 	duckv1.SourceStatus `json:",inline"`
+
+	// ConditionHistory is a bounded list of the most recent condition status
+	// changes, oldest first, so that intermittent failures remain visible after
+	// a condition recovered.
+	// +optional
+	ConditionHistory []VConditionTransition `json:"conditionHistory,omitempty"`
+
+	// SinkAudience is the OIDC audience advertised in the address of the sink.
+	// If set, events are sent with a token for this audience minted from the
+	// service account of the adapter.
+	// +optional
+	SinkAudience *string `json:"sinkAudience,omitempty"`
+
+	// SinkURIs are the resolved URIs of the additional sinks of the source, in
+	// the order of spec.sinks.
+	// +optional
+	SinkURIs []apis.URL `json:"sinkURIs,omitempty"`
+
+	// DeadLetterSinkURI is the resolved URI of the dead letter sink of the
+	// delivery.
+	// +optional
+	DeadLetterSinkURI *apis.URL `json:"deadLetterSinkUri,omitempty"`
+
+	// AuditSinkURI is the resolved URI of the audit sink.
+	// +optional
+	AuditSinkURI *apis.URL `json:"auditSinkUri,omitempty"`
+
+	// MirrorSinkURI is the resolved URI of the mirror sink.
+	// +optional
+	MirrorSinkURI *apis.URL `json:"mirrorSinkUri,omitempty"`
+
+	// SinkCACerts are the PEM-encoded CA certificates advertised by the https
+	// addresses the sinks of the source resolved to, which are trusted by the
+	// adapter.
+	// +optional
+	SinkCACerts *string `json:"sinkCACerts,omitempty"`
+
+	// LastDeliveredTime is the time the adapter last delivered an event to
+	// the sink.
+	// +optional
+	LastDeliveredTime *apis.VolatileTime `json:"lastDeliveredTime,omitempty"`
+
+	// CheckpointTime is the vCenter time of the last event in the checkpoint
+	// of the adapter.
+	// +optional
+	CheckpointTime *apis.VolatileTime `json:"checkpointTime,omitempty"`
+
+	// EventsPerMinute is the number of events the adapter delivered to the
+	// sink in the last minute.
+	// +optional
+	EventsPerMinute int64 `json:"eventsPerMinute,omitempty"`
+
+	// LagSeconds is how far the last event processed by the adapter is behind
+	// the newest vCenter event, in seconds.
+	// +optional
+	LagSeconds int64 `json:"lagSeconds,omitempty"`
+
+	// Mirror reports the delivery of the events to the mirror sink, compared
+	// to the sink.
+	// +optional
+	Mirror *VMirrorStatus `json:"mirror,omitempty"`
+}
+
+// VMirrorStatus reports the events the adapter sent to the mirror sink since
+// it started.
+type VMirrorStatus struct {
+	// LastDeliveredTime is the time the adapter last delivered an event to
+	// the mirror sink.
+	// +optional
+	LastDeliveredTime *apis.VolatileTime `json:"lastDeliveredTime,omitempty"`
+
+	// EventsPerMinute is the number of events the adapter delivered to the
+	// mirror sink in the last minute.
+	// +optional
+	EventsPerMinute int64 `json:"eventsPerMinute,omitempty"`
+
+	// MirroredEvents is the number of events sent to the mirror sink.
+	// +optional
+	MirroredEvents int64 `json:"mirroredEvents,omitempty"`
+
+	// DivergentEvents is the number of mirrored events acknowledged by only
+	// one of the sink and the mirror sink. The mirror sink can replace the
+	// sink without a gap while it stays zero.
+	// +optional
+	DivergentEvents int64 `json:"divergentEvents,omitempty"`
+
+	// LastError is the last error sending an event to the mirror sink in the
+	// last minute.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// VConditionTransition records a status change of a condition.
+type VConditionTransition struct {
+	// Type of the condition, e.g. AuthReady.
+	Type apis.ConditionType `json:"type"`
+
+	// Status of the condition after the transition.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// Reason for the transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// LastTransitionTime is the time of the transition.
+	LastTransitionTime apis.VolatileTime `json:"lastTransitionTime"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
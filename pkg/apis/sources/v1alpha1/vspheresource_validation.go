@@ -7,28 +7,883 @@ package v1alpha1
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
 )
 
+// kafkaTopicPattern matches the valid names of Kafka topics
+var kafkaTopicPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,249}$`)
+
+// localePattern matches the locales of vCenter sessions, e.g. de or zh_CN
+var localePattern = regexp.MustCompile(`^[a-z]{2}(_[A-Z]{2})?$`)
+
 // Validate implements apis.Validatable
 func (vs *VSphereSource) Validate(ctx context.Context) *apis.FieldError {
-	return vs.Spec.Validate(ctx).ViaField("spec")
+	return vs.Spec.Validate(ctx).Also(vs.Spec.VAuthSpec.validateSecret(ctx, vs.Namespace)).ViaField("spec").Also(validateLoggingLevel(vs.Annotations).
+		Also(validateTimeAnnotation(vs.Annotations, vsphere.RestartedAtAnnotation)).
+		Also(validateTimeAnnotation(vs.Annotations, vsphere.CheckpointResetAnnotation)).
+		Also(validateCheckpointImport(vs.Annotations)).
+		Also(validateWireTrace(vs.Annotations)).
+		ViaField("metadata.annotations"))
+}
+
+// validateLoggingLevel validates the logging level annotation of a source.
+func validateLoggingLevel(annotations map[string]string) *apis.FieldError {
+	l, ok := annotations[vsphere.LoggingLevelAnnotation]
+	if !ok {
+		return nil
+	}
+	if err := vsphere.ValidLoggingLevel(l); err != nil {
+		fe := apis.ErrInvalidValue(l, vsphere.LoggingLevelAnnotation)
+		fe.Details = err.Error()
+		return fe
+	}
+	return nil
+}
+
+// validateWireTrace validates the wire trace annotation of a source.
+func validateWireTrace(annotations map[string]string) *apis.FieldError {
+	mode, ok := annotations[vsphere.WireTraceAnnotation]
+	if !ok {
+		return nil
+	}
+	if err := vsphere.ValidWireTrace(mode); err != nil {
+		fe := apis.ErrInvalidValue(mode, vsphere.WireTraceAnnotation)
+		fe.Details = err.Error()
+		return fe
+	}
+	return nil
+}
+
+// validateCheckpointImport validates the checkpoint import annotation of a
+// source.
+func validateCheckpointImport(annotations map[string]string) *apis.FieldError {
+	cp, ok := annotations[vsphere.CheckpointImportAnnotation]
+	if !ok {
+		return nil
+	}
+	if err := vsphere.ValidateCheckpointImport(cp); err != nil {
+		fe := apis.ErrInvalidValue(cp, vsphere.CheckpointImportAnnotation)
+		fe.Details = err.Error()
+		return fe
+	}
+	return nil
+}
+
+// validateTimeAnnotation validates the RFC3339 time of the given annotation of
+// a source.
+func validateTimeAnnotation(annotations map[string]string, key string) *apis.FieldError {
+	s, ok := annotations[key]
+	if !ok {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, s); err != nil {
+		fe := apis.ErrInvalidValue(s, key)
+		fe.Details = "expected an RFC3339 time, e.g. 2021-02-15T19:00:00Z"
+		return fe
+	}
+	return nil
 }
 
 // Validate implements apis.Validatable
 func (vsss *VSphereSourceSpec) Validate(ctx context.Context) *apis.FieldError {
-	return vsss.Sink.Validate(ctx).ViaField("sink").Also(vsss.VAuthSpec.Validate(ctx)).Also(vsss.CheckpointConfig.
-		Validate(ctx))
+	return vsss.validateDestination(ctx).
+		Also(vsss.VAuthSpec.Validate(ctx)).
+		Also(validateAddressScheme(vsss.Address, "address", vsss.AllowInsecureAddress)).
+		Also(validateAddresses(vsss.Address, vsss.Addresses, vsss.AllowInsecureAddress)).
+		Also(validateFallbackAddress(vsss.Address, vsss.FallbackAddress, vsss.AllowInsecureAddress)).
+		Also(vsss.CheckpointConfig.Validate(ctx)).
+		Also(vsss.Delivery.Validate(ctx).ViaField("delivery")).
+		Also(vsss.Filter.Validate(ctx).ViaField("filter")).
+		Also(validateSinks(ctx, vsss.Sinks)).
+		Also(vsss.Transform.Validate(ctx).ViaField("transform")).
+		Also(validateExtensionAttributes(vsss.ExtensionAttributes)).
+		Also(validateOutputFormat(vsss.OutputFormat)).
+		Also(vsss.validatePayloadEncoding()).
+		Also(validateLocale(vsss.Locale)).
+		Also(vsss.AttributeMapping.Validate(ctx).ViaField("attributeMapping")).
+		Also(vsss.RateLimit.Validate(ctx).ViaField("rateLimit")).
+		Also(vsss.Sampling.Validate(ctx).ViaField("sampling")).
+		Also(vsss.EventCollector.Validate(ctx).ViaField("eventCollector")).
+		Also(vsss.VCenterAPI.Validate(ctx).ViaField("vcenterAPI")).
+		Also(vsss.validateTemplateRef(ctx)).
+		Also(vsss.validateScope(ctx)).
+		Also(vsss.Redaction.Validate(ctx).ViaField("redaction")).
+		Also(vsss.Heartbeat.Validate(ctx).ViaField("heartbeat")).
+		Also(vsss.Audit.Validate(ctx).ViaField("audit")).
+		Also(vsss.Deployment.Validate(ctx).ViaField("deployment")).
+		Also(vsss.AdapterOverrides.Validate(ctx).ViaField("adapterOverrides"))
+}
+
+// validateDestination validates the sink of a source, or its Kafka delivery,
+// which replaces the sink, the additional sinks and the HTTP options of the
+// delivery. A source logging its events has no destination.
+func (vsss *VSphereSourceSpec) validateDestination(ctx context.Context) *apis.FieldError {
+	if vsss.LogOnly {
+		var err *apis.FieldError
+		if vsss.Sink.Ref != nil || vsss.Sink.URI != nil {
+			err = err.Also(apis.ErrMultipleOneOf("sink", "logOnly"))
+		}
+		if len(vsss.Sinks) > 0 {
+			err = err.Also(apis.ErrMultipleOneOf("sinks", "logOnly"))
+		}
+		if vsss.Kafka != nil {
+			err = err.Also(apis.ErrMultipleOneOf("kafka", "logOnly"))
+		}
+		if vsss.Mirror != nil {
+			err = err.Also(apis.ErrMultipleOneOf("mirror", "logOnly"))
+		}
+		return err
+	}
+	if vsss.Kafka == nil {
+		return validateSink(ctx, vsss.Sink).ViaField("sink").Also(vsss.validateMirror(ctx))
+	}
+
+	err := vsss.Kafka.Validate(ctx).ViaField("kafka")
+	if vsss.Sink.Ref != nil || vsss.Sink.URI != nil {
+		err = err.Also(apis.ErrMultipleOneOf("sink", "kafka"))
+	}
+	if len(vsss.Sinks) > 0 {
+		err = err.Also(apis.ErrMultipleOneOf("sinks", "kafka"))
+	}
+	if vsss.Mirror != nil {
+		err = err.Also(apis.ErrMultipleOneOf("mirror", "kafka"))
+	}
+	if d := vsss.Delivery; d != nil {
+		var fields []string
+		if d.Path != "" {
+			fields = append(fields, "delivery.path")
+		}
+		if len(d.Headers) > 0 {
+			fields = append(fields, "delivery.headers")
+		}
+		if d.HeadersSecretRef != nil {
+			fields = append(fields, "delivery.headersSecretRef")
+		}
+		if d.CACertsConfigMapRef != nil {
+			fields = append(fields, "delivery.caCertsConfigMapRef")
+		}
+		if d.Compression != "" && d.Compression != vsphere.CompressionNone {
+			fields = append(fields, "delivery.compression")
+		}
+		if d.DeadLetterSink != nil {
+			fields = append(fields, "delivery.deadLetterSink")
+		}
+		if d.RequireTLS {
+			fields = append(fields, "delivery.requireTLS")
+		}
+		if len(fields) > 0 {
+			fe := apis.ErrDisallowedFields(fields...)
+			fe.Details = "the HTTP options of the delivery do not apply to kafka"
+			err = err.Also(fe)
+		}
+	}
+	return err
+}
+
+// validateSink validates the sink like duckv1.Destination and additionally
+// validates a reference combined with a relative URI and its API version.
+func validateSink(ctx context.Context, dest duckv1.Destination) (err *apis.FieldError) {
+	err = dest.Validate(ctx)
+
+	ref := dest.Ref
+	if ref == nil {
+		return err
+	}
+	if dest.URI != nil {
+		// duckv1.Destination only validates references without URI
+		err = err.Also(ref.Validate(ctx).ViaField("ref"))
+	}
+	if ref.APIVersion != "" {
+		if _, gvErr := schema.ParseGroupVersion(ref.APIVersion); gvErr != nil {
+			fe := apis.ErrInvalidValue(ref.APIVersion, "ref.apiVersion")
+			fe.Details = gvErr.Error()
+			err = err.Also(fe)
+		}
+	}
+	return err
+}
+
+// validateMirror validates the mirror sink of a source, which must differ from
+// the sink it is compared to.
+func (vsss *VSphereSourceSpec) validateMirror(ctx context.Context) *apis.FieldError {
+	if vsss.Mirror == nil {
+		return nil
+	}
+	err := validateSink(ctx, vsss.Mirror.Sink).ViaField("sink")
+	if err == nil && equality.Semantic.DeepEqual(vsss.Mirror.Sink, vsss.Sink) {
+		err = &apis.FieldError{
+			Message: "the mirror sink must differ from the sink",
+			Paths:   []string{"sink"},
+		}
+	}
+	return err.ViaField("mirror")
+}
+
+// validateSinks validates the additional sinks of a source and their filters.
+func validateSinks(ctx context.Context, sinks []VSinkSpec) (err *apis.FieldError) {
+	for i, s := range sinks {
+		err = err.Also(validateSink(ctx, s.Destination).Also(s.Filter.Validate(ctx).ViaField("filter")).
+			ViaFieldIndex("sinks", i))
+	}
+	return err
+}
+
+// validateAddressScheme requires an https address in the given field unless
+// insecure addresses are allowed.
+func validateAddressScheme(address apis.URL, field string, allowInsecure bool) *apis.FieldError {
+	if address.Host == "" {
+		// reported as missing by VAuthSpec
+		return nil
+	}
+	switch address.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if allowInsecure {
+			return nil
+		}
+		return &apis.FieldError{
+			Message: fmt.Sprintf("invalid value: %s", address.String()),
+			Paths:   []string{field},
+			Details: "the vSphere address must use https, set allowInsecureAddress to connect without TLS",
+		}
+	default:
+		return &apis.FieldError{
+			Message: fmt.Sprintf("invalid value: %s", address.String()),
+			Paths:   []string{field},
+			Details: fmt.Sprintf("unsupported scheme %q, the vSphere address must use https", address.Scheme),
+		}
+	}
+}
+
+// validateAddresses validates the additional vCenters of a source, whose hosts
+// must differ from each other and from the vCenter of the source.
+func validateAddresses(address apis.URL, addresses []VAddressSpec, allowInsecure bool) (err *apis.FieldError) {
+	hosts := map[string]bool{address.Host: true}
+	for i, a := range addresses {
+		var fe *apis.FieldError
+		switch {
+		case a.Address.Host == "":
+			fe = fe.Also(apis.ErrMissingField("address.host"))
+		case hosts[a.Address.Host]:
+			dup := apis.ErrInvalidValue(a.Address.String(), "address")
+			dup.Details = "every vCenter of the source must have a different host"
+			fe = fe.Also(dup)
+		}
+		hosts[a.Address.Host] = true
+
+		fe = fe.Also(validateAddressScheme(a.Address, "address", allowInsecure))
+		if a.SecretRef.Name == "" {
+			fe = fe.Also(apis.ErrMissingField("secretRef.name"))
+		}
+		err = err.Also(fe.ViaFieldIndex("addresses", i))
+	}
+	return err
 }
 
+// validateFallbackAddress validates the optional fallback address of the
+// vCenter of a source, whose host must differ from the address of the source.
+func validateFallbackAddress(address apis.URL, fallback *apis.URL, allowInsecure bool) *apis.FieldError {
+	if fallback == nil {
+		return nil
+	}
+	switch fallback.Host {
+	case "":
+		return apis.ErrMissingField("fallbackAddress.host")
+	case address.Host:
+		fe := apis.ErrInvalidValue(fallback.String(), "fallbackAddress")
+		fe.Details = "the fallback address must have a different host than the address"
+		return fe
+	}
+	return validateAddressScheme(*fallback, "fallbackAddress", allowInsecure)
+}
+
+func (vams *VAttributeMappingSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vams == nil {
+		return nil
+	}
+
+	if vams.Source != "" {
+		if u, parseErr := url.Parse(vams.Source); parseErr != nil || u.String() == "" {
+			err = err.Also(apis.ErrInvalidValue(vams.Source, "source"))
+		}
+	}
+
+	if vams.TypePrefix != "" && vams.TypeTemplate != "" {
+		err = err.Also(apis.ErrMultipleOneOf("typePrefix", "typeTemplate"))
+	}
+
+	if vams.TypeTemplate != "" {
+		if _, tmplErr := vsphere.NewTypeTemplate(vams.TypeTemplate); tmplErr != nil {
+			fe := apis.ErrInvalidValue(vams.TypeTemplate, "typeTemplate")
+			fe.Details = tmplErr.Error()
+			err = err.Also(fe)
+		}
+	}
+
+	return err
+}
+
+func (vrls *VRateLimitSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vrls == nil {
+		return nil
+	}
+
+	if vrls.EventsPerSecond < 1 {
+		err = err.Also(apis.ErrOutOfBoundsValue(vrls.EventsPerSecond, 1, math.MaxInt32, "eventsPerSecond"))
+	}
+	if vrls.Burst < 0 {
+		err = err.Also(apis.ErrOutOfBoundsValue(vrls.Burst, 0, math.MaxInt32, "burst"))
+	}
+	return err
+}
+
+func (vss *VSamplingSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vss == nil {
+		return nil
+	}
+
+	if vss.WindowSeconds < 1 {
+		err = err.Also(apis.ErrOutOfBoundsValue(vss.WindowSeconds, 1, math.MaxInt64, "windowSeconds"))
+	}
+	if vss.Threshold < 0 {
+		err = err.Also(apis.ErrOutOfBoundsValue(vss.Threshold, 0, math.MaxInt32, "threshold"))
+	}
+	return err
+}
+
+func (vhs *VHeartbeatSpec) Validate(ctx context.Context) *apis.FieldError {
+	if vhs == nil {
+		return nil
+	}
+
+	if vhs.IntervalSeconds < 0 {
+		return apis.ErrOutOfBoundsValue(vhs.IntervalSeconds, 0, math.MaxInt64, "intervalSeconds")
+	}
+	return nil
+}
+
+func (vas *VAuditSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vas == nil {
+		return nil
+	}
+
+	if vas.Sink == nil && vas.Path == "" {
+		return apis.ErrMissingOneOf("sink", "path")
+	}
+	if vas.Sink != nil {
+		err = err.Also(validateSink(ctx, *vas.Sink).ViaField("sink"))
+	}
+	if vas.Path != "" && !path.IsAbs(vas.Path) {
+		fe := apis.ErrInvalidValue(vas.Path, "path")
+		fe.Details = "the path must be absolute"
+		err = err.Also(fe)
+	}
+	return err
+}
+
+func (vds *VDeploymentSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vds == nil {
+		return nil
+	}
+
+	switch appsv1.DeploymentStrategyType(vds.Strategy) {
+	case "", appsv1.RollingUpdateDeploymentStrategyType:
+		err = err.Also(validateIntOrPercent(vds.MaxSurge, "maxSurge")).
+			Also(validateIntOrPercent(vds.MaxUnavailable, "maxUnavailable"))
+		if isZeroIntOrPercent(vds.MaxSurge) && isZeroIntOrPercent(vds.MaxUnavailable) {
+			fe := apis.ErrInvalidValue(vds.MaxUnavailable.String(), "maxUnavailable")
+			fe.Details = "maxUnavailable must not be 0 when maxSurge is 0"
+			err = err.Also(fe)
+		}
+	case appsv1.RecreateDeploymentStrategyType:
+		if vds.MaxSurge != nil {
+			err = err.Also(apis.ErrDisallowedFields("maxSurge"))
+		}
+		if vds.MaxUnavailable != nil {
+			err = err.Also(apis.ErrDisallowedFields("maxUnavailable"))
+		}
+	default:
+		fe := apis.ErrInvalidValue(vds.Strategy, "strategy")
+		fe.Details = fmt.Sprintf("expected %s or %s", appsv1.RecreateDeploymentStrategyType,
+			appsv1.RollingUpdateDeploymentStrategyType)
+		err = err.Also(fe)
+	}
+	if vds.PriorityClassName != "" {
+		err = err.Also(validateObjectName(vds.PriorityClassName, "priorityClassName"))
+	}
+	if vds.RuntimeClassName != nil {
+		err = err.Also(validateObjectName(*vds.RuntimeClassName, "runtimeClassName"))
+	}
+	return err.Also(vds.PodDisruptionBudget.Validate(ctx).ViaField("podDisruptionBudget"))
+}
+
+// Validate validates the overrides of the adapter of a source. The volumes must
+// not collide with the volumes set by the controller, and the volume mounts
+// must mount one of the volumes of the overrides.
+func (vaos *VAdapterOverridesSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vaos == nil {
+		return nil
+	}
+
+	envNames := make(map[string]bool, len(vaos.Env))
+	for i, e := range vaos.Env {
+		switch {
+		case e.Name == "":
+			err = err.Also(apis.ErrMissingField("name").ViaFieldIndex("env", i))
+		case envNames[e.Name]:
+			fe := apis.ErrInvalidValue(e.Name, "name").ViaFieldIndex("env", i)
+			fe.Details = "duplicate environment variable"
+			err = err.Also(fe)
+		}
+		envNames[e.Name] = true
+	}
+
+	volumeNames := make(map[string]bool, len(vaos.Volumes))
+	for i, v := range vaos.Volumes {
+		switch {
+		case v.Name == "":
+			err = err.Also(apis.ErrMissingField("name").ViaFieldIndex("volumes", i))
+		case volumeNames[v.Name]:
+			fe := apis.ErrInvalidValue(v.Name, "name").ViaFieldIndex("volumes", i)
+			fe.Details = "duplicate volume"
+			err = err.Also(fe)
+		case isAdapterVolume(v.Name):
+			fe := apis.ErrInvalidValue(v.Name, "name").ViaFieldIndex("volumes", i)
+			fe.Details = "the name is reserved for the volumes set by the controller"
+			err = err.Also(fe)
+		}
+		volumeNames[v.Name] = true
+	}
+
+	for i, m := range vaos.VolumeMounts {
+		if !volumeNames[m.Name] {
+			fe := apis.ErrInvalidValue(m.Name, "name").ViaFieldIndex("volumeMounts", i)
+			fe.Details = "expected the name of one of the volumes"
+			err = err.Also(fe)
+		}
+		if !path.IsAbs(m.MountPath) {
+			fe := apis.ErrInvalidValue(m.MountPath, "mountPath").ViaFieldIndex("volumeMounts", i)
+			fe.Details = "expected an absolute path"
+			err = err.Also(fe)
+		}
+	}
+	return err
+}
+
+// isAdapterVolume returns true if the given name is the name of a volume the
+// controller sets on the adapter.
+func isAdapterVolume(name string) bool {
+	switch name {
+	case vsphere.VolumeName, vsphere.CACertsVolumeName, vsphere.SinkHeadersVolumeName, vsphere.SinkCACertsVolumeName,
+		vsphere.KafkaSecretVolumeName, vsphere.KafkaCACertsVolumeName, vsphere.WireTraceVolumeName:
+		return true
+	}
+	return strings.HasPrefix(name, vsphere.AddressSecretVolumePrefix)
+}
+
+// Validate validates the Kafka delivery of a source.
+func (vks *VKafkaSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vks == nil {
+		return nil
+	}
+
+	if len(vks.BootstrapServers) == 0 {
+		err = err.Also(apis.ErrMissingField("bootstrapServers"))
+	}
+	for i, server := range vks.BootstrapServers {
+		if _, port, splitErr := net.SplitHostPort(server); splitErr != nil || port == "" {
+			fe := apis.ErrInvalidArrayValue(server, "bootstrapServers", i)
+			fe.Details = "expected host:port"
+			err = err.Also(fe)
+		}
+	}
+
+	if vks.Topic == "" {
+		err = err.Also(apis.ErrMissingField("topic"))
+	} else if !kafkaTopicPattern.MatchString(vks.Topic) {
+		fe := apis.ErrInvalidValue(vks.Topic, "topic")
+		fe.Details = "expected at most 249 letters, digits, '.', '_' or '-'"
+		err = err.Also(fe)
+	}
+
+	if sasl := vks.SASL; sasl != nil {
+		switch sasl.Mechanism {
+		case "", vsphere.KafkaSASLPlain, vsphere.KafkaSASLSCRAMSHA256, vsphere.KafkaSASLSCRAMSHA512:
+		default:
+			fe := apis.ErrInvalidValue(sasl.Mechanism, "sasl.mechanism")
+			fe.Details = fmt.Sprintf("expected %s, %s or %s", vsphere.KafkaSASLPlain,
+				vsphere.KafkaSASLSCRAMSHA256, vsphere.KafkaSASLSCRAMSHA512)
+			err = err.Also(fe)
+		}
+		if sasl.SecretRef.Name == "" {
+			err = err.Also(apis.ErrMissingField("sasl.secretRef.name"))
+		}
+	}
+
+	if t := vks.TLS; t != nil && t.CACertsConfigMapRef != nil && t.CACertsConfigMapRef.Name == "" {
+		err = err.Also(apis.ErrMissingField("tls.caCertsConfigMapRef.name"))
+	}
+	return err
+}
+
+// validateObjectName validates the name of a cluster object referenced in the
+// given field, which must be a DNS subdomain.
+func validateObjectName(name, field string) *apis.FieldError {
+	if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+		fe := apis.ErrInvalidValue(name, field)
+		fe.Details = strings.Join(msgs, ", ")
+		return fe
+	}
+	return nil
+}
+
+func (vpdbs *VPodDisruptionBudgetSpec) Validate(ctx context.Context) *apis.FieldError {
+	if vpdbs == nil {
+		return nil
+	}
+
+	if vpdbs.MinAvailable != nil && vpdbs.MaxUnavailable != nil {
+		return apis.ErrMultipleOneOf("minAvailable", "maxUnavailable")
+	}
+	return validateIntOrPercent(vpdbs.MinAvailable, "minAvailable").
+		Also(validateIntOrPercent(vpdbs.MaxUnavailable, "maxUnavailable"))
+}
+
+// validateIntOrPercent validates the optional non-negative number or
+// percentage in the given field.
+func validateIntOrPercent(v *intstr.IntOrString, field string) *apis.FieldError {
+	if v == nil {
+		return nil
+	}
+	if v.Type == intstr.Int {
+		if v.IntVal < 0 {
+			return apis.ErrOutOfBoundsValue(v.IntVal, 0, math.MaxInt32, field)
+		}
+		return nil
+	}
+	if p, err := intstr.GetValueFromIntOrPercent(v, 100, false); err != nil || p < 0 || p > 100 {
+		fe := apis.ErrInvalidValue(v.StrVal, field)
+		fe.Details = "expected a number or a percentage, e.g. 1 or 25%"
+		return fe
+	}
+	return nil
+}
+
+// isZeroIntOrPercent returns true if the given number or percentage is set to
+// 0 or 0%.
+func isZeroIntOrPercent(v *intstr.IntOrString) bool {
+	if v == nil {
+		return false
+	}
+	p, err := intstr.GetValueFromIntOrPercent(v, 100, false)
+	return err == nil && p == 0
+}
+
+func (vecs *VEventCollectorSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vecs == nil {
+		return nil
+	}
+
+	if vecs.PollIntervalSeconds < 0 {
+		err = err.Also(apis.ErrOutOfBoundsValue(vecs.PollIntervalSeconds, 0, math.MaxInt64, "pollIntervalSeconds"))
+	}
+	if vecs.PageSize < 0 || vecs.PageSize > vsphere.CollectorMaxPageSize {
+		err = err.Also(apis.ErrOutOfBoundsValue(vecs.PageSize, 0, vsphere.CollectorMaxPageSize, "pageSize"))
+	}
+	switch vecs.Mode {
+	case "", vsphere.CollectorModePoll, vsphere.CollectorModeStream:
+	default:
+		err = err.Also(apis.ErrInvalidValue(vecs.Mode, "mode"))
+	}
+	return err
+}
+
+func (vas *VAPISpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vas == nil {
+		return nil
+	}
+
+	if vas.TimeoutSeconds < 0 {
+		err = err.Also(apis.ErrOutOfBoundsValue(vas.TimeoutSeconds, 0, math.MaxInt64, "timeoutSeconds"))
+	}
+	if vas.Retries < 0 || vas.Retries > vsphere.APIMaxRetries {
+		err = err.Also(apis.ErrOutOfBoundsValue(vas.Retries, 0, vsphere.APIMaxRetries, "retries"))
+	}
+	if vas.RetryBackoffSeconds < 0 {
+		err = err.Also(apis.ErrOutOfBoundsValue(vas.RetryBackoffSeconds, 0, math.MaxInt64, "retryBackoffSeconds"))
+	}
+	if vas.MaxRetryBackoffSeconds < 0 {
+		err = err.Also(apis.ErrOutOfBoundsValue(vas.MaxRetryBackoffSeconds, 0, math.MaxInt64, "maxRetryBackoffSeconds"))
+	}
+	if vas.RetryBackoffSeconds > 0 && vas.MaxRetryBackoffSeconds > 0 && vas.MaxRetryBackoffSeconds < vas.RetryBackoffSeconds {
+		err = err.Also(&apis.FieldError{
+			Message: fmt.Sprintf("invalid value: %d", vas.MaxRetryBackoffSeconds),
+			Paths:   []string{"maxRetryBackoffSeconds"},
+			Details: "the maximum retry backoff must not be less than retryBackoffSeconds",
+		})
+	}
+	return err
+}
+
+// validateScope validates the scope of a source, which does not apply to the
+// content library and tag events, and whose managed object reference only
+// identifies an object in one vCenter.
+func (vsss *VSphereSourceSpec) validateScope(ctx context.Context) *apis.FieldError {
+	if vsss.Scope == nil {
+		return nil
+	}
+	err := vsss.Scope.Validate(ctx).ViaField("scope")
+	if vsss.IncludeContentLibrary {
+		err = err.Also(apis.ErrMultipleOneOf("scope", "includeContentLibrary"))
+	}
+	if vsss.IncludeTags {
+		err = err.Also(apis.ErrMultipleOneOf("scope", "includeTags"))
+	}
+	if vsss.Scope.ID != "" && len(vsss.Addresses) > 0 {
+		err = err.Also(apis.ErrMultipleOneOf("scope.id", "addresses"))
+	}
+	return err
+}
+
+func (vss *VScopeSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vss == nil {
+		return nil
+	}
+
+	switch {
+	case vss.Path == "" && vss.ID == "":
+		return apis.ErrMissingOneOf("path", "id")
+	case vss.Path != "" && vss.ID != "":
+		return apis.ErrMultipleOneOf("path", "id")
+	case vss.Path != "" && !strings.HasPrefix(vss.Path, "/"):
+		fe := apis.ErrInvalidValue(vss.Path, "path")
+		fe.Details = "the inventory path must be absolute, e.g. /dc1/host/cluster1"
+		return fe
+	case vss.ID != "":
+		if _, parseErr := vsphere.ParseScopeID(vss.ID); parseErr != nil {
+			fe := apis.ErrInvalidValue(vss.ID, "id")
+			fe.Details = parseErr.Error()
+			return fe
+		}
+	}
+	return nil
+}
+
+func (vts *VTransformSpec) Validate(ctx context.Context) *apis.FieldError {
+	if vts == nil {
+		return nil
+	}
+
+	if vts.Template == "" {
+		return apis.ErrMissingField("template")
+	}
+	if _, err := vsphere.NewTransformTemplate(vts.Template); err != nil {
+		fe := apis.ErrInvalidValue(vts.Template, "template")
+		fe.Details = err.Error()
+		return fe
+	}
+	return nil
+}
+
+func (vrs *VRedactionSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vrs == nil {
+		return nil
+	}
+
+	if len(vrs.Fields) == 0 {
+		err = err.Also(apis.ErrMissingField("fields"))
+	}
+	for i, f := range vrs.Fields {
+		if _, parseErr := vsphere.ParseRedactionField(f); parseErr != nil {
+			fe := apis.ErrInvalidArrayValue(f, "fields", i)
+			fe.Details = parseErr.Error()
+			err = err.Also(fe)
+		}
+	}
+
+	switch vrs.Mode {
+	case "", vsphere.RedactionMask, vsphere.RedactionRemove:
+	default:
+		err = err.Also(apis.ErrInvalidValue(vrs.Mode, "mode"))
+	}
+	return err
+}
+
+func validateOutputFormat(format string) *apis.FieldError {
+	switch format {
+	case "", vsphere.OutputFormatCloudEvents, vsphere.OutputFormatCDEvents:
+		return nil
+	default:
+		return apis.ErrInvalidValue(format, "outputFormat")
+	}
+}
+
+func (vsss *VSphereSourceSpec) validatePayloadEncoding() *apis.FieldError {
+	switch vsss.PayloadEncoding {
+	case "", vsphere.PayloadEncodingJSON:
+		return nil
+	case vsphere.PayloadEncodingProtobuf:
+		if vsss.Transform != nil {
+			// transformed payloads have no data schema
+			return apis.ErrMultipleOneOf("payloadEncoding", "transform")
+		}
+		if !vsss.NormalizeAlarms && !vsss.IncludeTags && !vsss.IncludeContentLibrary {
+			// vSphere events and tasks have no protobuf definition
+			fe := apis.ErrInvalidValue(vsss.PayloadEncoding, "payloadEncoding")
+			fe.Details = "only normalized alarm, tag and content library events are encoded as protobuf, enable at least one of normalizeAlarms, includeTags or includeContentLibrary"
+			return fe
+		}
+		return nil
+	default:
+		return apis.ErrInvalidValue(vsss.PayloadEncoding, "payloadEncoding")
+	}
+}
+
+func validateLocale(locale string) *apis.FieldError {
+	if locale == "" || localePattern.MatchString(locale) {
+		return nil
+	}
+	fe := apis.ErrInvalidValue(locale, "locale")
+	fe.Details = "expected a language code with an optional country code, e.g. de or zh_CN"
+	return fe
+}
+
+func validateExtensionAttributes(names []string) (err *apis.FieldError) {
+	for i, n := range names {
+		if !isExtensionAttribute(n) {
+			err = err.Also(apis.ErrInvalidArrayValue(n, "extensionAttributes", i))
+		}
+	}
+	return err
+}
+
+func isExtensionAttribute(name string) bool {
+	for _, n := range vsphere.StandardExtensions {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (vfs *VFilterSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vfs == nil {
+		return nil
+	}
+
+	for i, p := range vfs.EventTypes {
+		if p == "" {
+			fe := apis.ErrInvalidArrayValue(p, "eventTypes", i)
+			fe.Details = "event type patterns must not be empty"
+			err = err.Also(fe)
+		} else if _, matchErr := path.Match(p, ""); matchErr != nil {
+			fe := apis.ErrInvalidArrayValue(p, "eventTypes", i)
+			fe.Details = fmt.Sprintf("malformed glob pattern: %v", matchErr)
+			err = err.Also(fe)
+		}
+	}
+
+	if vfs.CEL != "" {
+		if _, celErr := vsphere.CompileCELFilter(vfs.CEL); celErr != nil {
+			fe := apis.ErrInvalidValue(vfs.CEL, "cel")
+			fe.Details = celErr.Error()
+			err = err.Also(fe)
+		}
+	}
+
+	return err
+}
+
+func (vds *VDeliverySpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	if vds == nil {
+		return nil
+	}
+
+	if strings.ContainsAny(vds.Path, "?#") {
+		err = err.Also(apis.ErrInvalidValue(vds.Path, "path"))
+	}
+
+	for name := range vds.Headers {
+		if msgs := validation.IsHTTPHeaderName(name); len(msgs) > 0 {
+			err = err.Also(apis.ErrInvalidKeyName(name, "headers", msgs...))
+		}
+	}
+
+	if vds.HeadersSecretRef != nil && vds.HeadersSecretRef.Name == "" {
+		err = err.Also(apis.ErrMissingField("headersSecretRef.name"))
+	}
+
+	if vds.CACertsConfigMapRef != nil && vds.CACertsConfigMapRef.Name == "" {
+		err = err.Also(apis.ErrMissingField("caCertsConfigMapRef.name"))
+	}
+
+	switch vds.ContentMode {
+	case "", vsphere.ContentModeBinary, vsphere.ContentModeStructured:
+	default:
+		err = err.Also(apis.ErrInvalidValue(vds.ContentMode, "contentMode"))
+	}
+
+	switch vds.Compression {
+	case "", vsphere.CompressionNone, vsphere.CompressionGzip, vsphere.CompressionAuto:
+	default:
+		err = err.Also(apis.ErrInvalidValue(vds.Compression, "compression"))
+	}
+
+	if vds.MaxAttempts < 0 {
+		fe := apis.ErrInvalidValue(vds.MaxAttempts, "maxAttempts")
+		fe.Details = "the number of attempts must be positive"
+		err = err.Also(fe)
+	}
+
+	if vds.DeadLetterSink != nil {
+		err = err.Also(validateSink(ctx, *vds.DeadLetterSink).ViaField("deadLetterSink"))
+		if vds.MaxAttempts == 0 {
+			fe := apis.ErrMissingField("maxAttempts")
+			fe.Details = "events are only sent to the dead letter sink after the maximum number of attempts"
+			err = err.Also(fe)
+		}
+	}
+
+	return err
+}
+
+// Validate implements apis.Validatable. A zero period is replaced by the
+// default period and a zero max age disables event replay.
 func (vcs VCheckpointSpec) Validate(ctx context.Context) (err *apis.FieldError) {
 	if vcs.PeriodSeconds < 0 {
-		err = err.Also(apis.ErrInvalidValue(vcs.PeriodSeconds, "checkpointConfig.periodSeconds"))
+		fe := apis.ErrInvalidValue(vcs.PeriodSeconds, "checkpointConfig.periodSeconds")
+		fe.Details = "the checkpoint period must be positive"
+		err = err.Also(fe)
 	}
 
 	if vcs.MaxAgeSeconds < 0 {
-		err = err.Also(apis.ErrInvalidValue(vcs.MaxAgeSeconds, "checkpointConfig.maxAgeSeconds"))
+		fe := apis.ErrInvalidValue(vcs.MaxAgeSeconds, "checkpointConfig.maxAgeSeconds")
+		fe.Details = "the max age must be positive, or zero to disable event replay"
+		err = err.Also(fe)
+	}
+
+	if vcs.MaxAgeSeconds > 0 && vcs.PeriodSeconds > vcs.MaxAgeSeconds {
+		fe := apis.ErrInvalidValue(vcs.PeriodSeconds, "checkpointConfig.periodSeconds")
+		fe.Details = fmt.Sprintf("the checkpoint period must not be larger than the max age of %d seconds", vcs.MaxAgeSeconds)
+		err = err.Also(fe)
 	}
 
 	return err
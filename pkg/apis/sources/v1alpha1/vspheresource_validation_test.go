@@ -7,13 +7,20 @@ package v1alpha1
 
 import (
 	"context"
+	"math"
 	"testing"
 
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/ptr"
 
 	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
 )
 
 var (
@@ -83,17 +90,1483 @@ func TestVSphereSourceValidation(t *testing.T) {
 				},
 			},
 		},
-		want: apis.ErrInvalidValue("-10", "spec.checkpointConfig.maxAgeSeconds").Also(apis.ErrInvalidValue("-5",
-			"spec.checkpointConfig.periodSeconds")),
-	}}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			got := test.c.Validate(context.Background())
-			if !cmp.Equal(test.want.Error(), got.Error()) {
-				t.Errorf("Validate (-want, +got) = %v",
-					cmp.Diff(test.want.Error(), got.Error()))
-			}
-		})
-	}
+		want: withDetails(apis.ErrInvalidValue("-10", "spec.checkpointConfig.maxAgeSeconds"),
+			"the max age must be positive, or zero to disable event replay").
+			Also(withDetails(apis.ErrInvalidValue("-5", "spec.checkpointConfig.periodSeconds"),
+				"the checkpoint period must be positive")),
+	}, {
+		name: "checkpoint period larger than max age",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				CheckpointConfig: VCheckpointSpec{
+					MaxAgeSeconds: 60,
+					PeriodSeconds: 300,
+				},
+			},
+		},
+		want: withDetails(apis.ErrInvalidValue("300", "spec.checkpointConfig.periodSeconds"),
+			"the checkpoint period must not be larger than the max age of 60 seconds"),
+	}, {
+		name: "checkpoint period without event replay",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				CheckpointConfig: VCheckpointSpec{
+					MaxAgeSeconds: 0,
+					PeriodSeconds: 300,
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "insecure address",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  insecureVAuthSpec("http"),
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: http://tekton.dev/sdk",
+			Paths:   []string{"spec.address"},
+			Details: "the vSphere address must use https, set allowInsecureAddress to connect without TLS",
+		},
+	}, {
+		name: "allowed insecure address",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:           validSourceSpec,
+				VAuthSpec:            insecureVAuthSpec("http"),
+				AllowInsecureAddress: true,
+			},
+		},
+		want: nil,
+	}, {
+		name: "unsupported address scheme",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:           validSourceSpec,
+				VAuthSpec:            insecureVAuthSpec("ftp"),
+				AllowInsecureAddress: true,
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: ftp://tekton.dev/sdk",
+			Paths:   []string{"spec.address"},
+			Details: `unsupported scheme "ftp", the vSphere address must use https`,
+		},
+	}, {
+		name: "additional addresses",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Addresses: []VAddressSpec{{
+					Address:   apis.URL{Scheme: "https", Host: "vcenter-2.example.com", Path: "/sdk"},
+					SecretRef: corev1.LocalObjectReference{Name: "vcenter-2"},
+				}, {
+					Address:       apis.URL{Scheme: "https", Host: "vcenter-3.example.com", Path: "/sdk"},
+					SkipTLSVerify: true,
+					SecretRef:     corev1.LocalObjectReference{Name: "vcenter-3"},
+				}},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid additional addresses",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Addresses: []VAddressSpec{{
+					Address: apis.URL{Scheme: "http", Host: "vcenter-2.example.com"},
+				}, {
+					Address:   apis.URL{Scheme: "https", Host: "tekton.dev"},
+					SecretRef: corev1.LocalObjectReference{Name: "vcenter-3"},
+				}, {
+					SecretRef: corev1.LocalObjectReference{Name: "vcenter-4"},
+				}},
+			},
+		},
+		want: (&apis.FieldError{
+			Message: "invalid value: http://vcenter-2.example.com",
+			Paths:   []string{"spec.addresses[0].address"},
+			Details: "the vSphere address must use https, set allowInsecureAddress to connect without TLS",
+		}).Also(apis.ErrMissingField("spec.addresses[0].secretRef.name")).Also(&apis.FieldError{
+			Message: "invalid value: https://tekton.dev",
+			Paths:   []string{"spec.addresses[1].address"},
+			Details: "every vCenter of the source must have a different host",
+		}).Also(apis.ErrMissingField("spec.addresses[2].address.host")),
+	}, {
+		name: "fallback address",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				FallbackAddress: &apis.URL{Scheme: "https", Host: "vcenter-2.example.com", Path: "/sdk"},
+			},
+		},
+		want: nil,
+	}, {
+		name: "fallback address with same host",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				FallbackAddress: &apis.URL{Scheme: "https", Host: "tekton.dev"},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: https://tekton.dev",
+			Paths:   []string{"spec.fallbackAddress"},
+			Details: "the fallback address must have a different host than the address",
+		},
+	}, {
+		name: "insecure fallback address",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				FallbackAddress: &apis.URL{Scheme: "http", Host: "vcenter-2.example.com"},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: http://vcenter-2.example.com",
+			Paths:   []string{"spec.fallbackAddress"},
+			Details: "the vSphere address must use https, set allowInsecureAddress to connect without TLS",
+		},
+	}, {
+		name: "fallback address without host",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				FallbackAddress: &apis.URL{Scheme: "https"},
+			},
+		},
+		want: apis.ErrMissingField("spec.fallbackAddress.host"),
+	}, {
+		name: "sink ref with relative URI",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{
+						Ref: &duckv1.KReference{Name: "default"},
+						URI: &apis.URL{Path: "/events"},
+					},
+				},
+				VAuthSpec: validVAuthSpec,
+			},
+		},
+		want: apis.ErrMissingField("spec.sink.ref.apiVersion", "spec.sink.ref.kind"),
+	}, {
+		name: "sink ref with invalid API version",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{
+						Ref: &duckv1.KReference{APIVersion: "eventing.knative.dev/v1/broker", Kind: "Broker", Name: "default"},
+					},
+				},
+				VAuthSpec: validVAuthSpec,
+			},
+		},
+		want: withDetails(apis.ErrInvalidValue("eventing.knative.dev/v1/broker", "spec.sink.ref.apiVersion"),
+			"unexpected GroupVersion string: eventing.knative.dev/v1/broker"),
+	}, {
+		name: "valid Delivery",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Delivery: &VDeliverySpec{
+					Path:                "/api/v1/webhook",
+					Headers:             map[string]string{"X-Api-Key": "s3cr3t"},
+					HeadersSecretRef:    &corev1.LocalObjectReference{Name: "sink-headers"},
+					CACertsConfigMapRef: &corev1.LocalObjectReference{Name: "sink-ca-certs"},
+					ContentMode:         "structured",
+					Compression:         "gzip",
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid Delivery",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Delivery: &VDeliverySpec{
+					Path:                "/webhook?key=value",
+					Headers:             map[string]string{"X Api Key": "s3cr3t"},
+					HeadersSecretRef:    &corev1.LocalObjectReference{},
+					CACertsConfigMapRef: &corev1.LocalObjectReference{},
+					ContentMode:         "json",
+					Compression:         "brotli",
+				},
+			},
+		},
+		want: apis.ErrInvalidValue("/webhook?key=value", "spec.delivery.path").
+			Also(apis.ErrInvalidKeyName("X Api Key", "spec.delivery.headers",
+				"a valid HTTP header must consist of alphanumeric characters or '-' (e.g. 'X-Header-Name', regex used for validation is '[-A-Za-z0-9]+')")).
+			Also(apis.ErrMissingField("spec.delivery.headersSecretRef.name")).
+			Also(apis.ErrMissingField("spec.delivery.caCertsConfigMapRef.name")).
+			Also(apis.ErrInvalidValue("json", "spec.delivery.contentMode")).
+			Also(apis.ErrInvalidValue("brotli", "spec.delivery.compression")),
+	}, {
+		name: "valid Delivery with dead letter sink",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Delivery: &VDeliverySpec{
+					MaxAttempts:    3,
+					DeadLetterSink: &duckv1.Destination{URI: apis.HTTP("dead-letters.example.com")},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid Delivery dead letters",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Delivery: &VDeliverySpec{
+					MaxAttempts:    -1,
+					DeadLetterSink: &duckv1.Destination{},
+				},
+			},
+		},
+		want: withDetails(apis.ErrInvalidValue(-1, "spec.delivery.maxAttempts"), "the number of attempts must be positive").
+			Also(apis.ErrGeneric("expected at least one, got none", "spec.delivery.deadLetterSink.ref", "spec.delivery.deadLetterSink.uri")),
+	}, {
+		name: "dead letter sink without max attempts",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Delivery: &VDeliverySpec{
+					DeadLetterSink: &duckv1.Destination{URI: apis.HTTP("dead-letters.example.com")},
+				},
+			},
+		},
+		want: withDetails(apis.ErrMissingField("spec.delivery.maxAttempts"),
+			"events are only sent to the dead letter sink after the maximum number of attempts"),
+	}, {
+		name: "valid Filter",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Filter: &VFilterSpec{
+					EventTypes: []string{"com.vmware.vsphere.alarm.*", "com.vmware.vsphere.VmPoweredOnEvent"},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid Filter",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Filter: &VFilterSpec{
+					EventTypes: []string{"com.vmware.vsphere.alarm.*", "", "com.vmware.vsphere.[alarm"},
+				},
+			},
+		},
+		want: withDetails(apis.ErrInvalidArrayValue("", "spec.filter.eventTypes", 1),
+			"event type patterns must not be empty").
+			Also(withDetails(apis.ErrInvalidArrayValue("com.vmware.vsphere.[alarm", "spec.filter.eventTypes", 2),
+				"malformed glob pattern: syntax error in pattern")),
+	}, {
+		name: "valid CEL Filter",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Filter: &VFilterSpec{
+					CEL: `ce.type == "com.vmware.vsphere.VmPoweredOnEvent" && data.Vm.Name.startsWith("prod-")`,
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "CEL Filter not returning a bool",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Filter: &VFilterSpec{
+					CEL: `ce.type`,
+				},
+			},
+		},
+		want: withDetails(apis.ErrInvalidValue("ce.type", "spec.filter.cel"),
+			"invalid CEL expression: must return a bool, not string"),
+	}, {
+		name: "valid Sinks",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Sinks: []VSinkSpec{{
+					Destination: validSourceSpec.Sink,
+				}, {
+					Destination: duckv1.Destination{URI: apis.HTTP("audit.example.com")},
+					Filter: &VFilterSpec{
+						EventTypes: []string{"com.vmware.vsphere.UserLoginSessionEvent"},
+					},
+				}},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid Sinks",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Sinks: []VSinkSpec{{
+					Destination: validSourceSpec.Sink,
+				}, {
+					Filter: &VFilterSpec{
+						CEL: `ce.type`,
+					},
+				}},
+			},
+		},
+		want: apis.ErrGeneric("expected at least one, got none", "spec.sinks[1].ref", "spec.sinks[1].uri").
+			Also(withDetails(apis.ErrInvalidValue("ce.type", "spec.sinks[1].filter.cel"),
+				"invalid CEL expression: must return a bool, not string")),
+	}, {
+		name: "valid Kafka",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				VAuthSpec: validVAuthSpec,
+				Delivery: &VDeliverySpec{
+					ContentMode: vsphere.ContentModeStructured,
+				},
+				Kafka: &VKafkaSpec{
+					BootstrapServers: []string{"kafka-0.example.com:9093", "kafka-1.example.com:9093"},
+					Topic:            "vsphere.events",
+					SASL: &VKafkaSASLSpec{
+						Mechanism: vsphere.KafkaSASLSCRAMSHA512,
+						SecretRef: corev1.LocalObjectReference{Name: "kafka-creds"},
+					},
+					TLS: &VKafkaTLSSpec{},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "Kafka with sinks",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Sinks: []VSinkSpec{{
+					Destination: validSourceSpec.Sink,
+				}},
+				Kafka: &VKafkaSpec{
+					BootstrapServers: []string{"kafka.example.com:9092"},
+					Topic:            "vsphere.events",
+				},
+			},
+		},
+		want: apis.ErrMultipleOneOf("spec.sink", "spec.kafka").
+			Also(apis.ErrMultipleOneOf("spec.sinks", "spec.kafka")),
+	}, {
+		name: "valid Mirror",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Mirror: &VMirrorSpec{
+					Sink: duckv1.Destination{URI: apis.HTTP("candidate.example.com")},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "Mirror of the sink",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Mirror:     &VMirrorSpec{Sink: validSourceSpec.Sink},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "the mirror sink must differ from the sink",
+			Paths:   []string{"spec.mirror.sink"},
+		},
+	}, {
+		name: "Mirror without sink",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Mirror:     &VMirrorSpec{},
+			},
+		},
+		want: apis.ErrGeneric("expected at least one, got none", "spec.mirror.sink.ref", "spec.mirror.sink.uri"),
+	}, {
+		name: "Kafka with Mirror",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				VAuthSpec: validVAuthSpec,
+				Mirror: &VMirrorSpec{
+					Sink: duckv1.Destination{URI: apis.HTTP("candidate.example.com")},
+				},
+				Kafka: &VKafkaSpec{
+					BootstrapServers: []string{"kafka.example.com:9092"},
+					Topic:            "vsphere.events",
+				},
+			},
+		},
+		want: apis.ErrMultipleOneOf("spec.mirror", "spec.kafka"),
+	}, {
+		name: "invalid Kafka",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				VAuthSpec: validVAuthSpec,
+				Kafka: &VKafkaSpec{
+					BootstrapServers: []string{"kafka.example.com"},
+					Topic:            "vsphere/events",
+					SASL: &VKafkaSASLSpec{
+						Mechanism: "GSSAPI",
+					},
+				},
+			},
+		},
+		want: withDetails(apis.ErrInvalidArrayValue("kafka.example.com", "spec.kafka.bootstrapServers", 0),
+			"expected host:port").
+			Also(withDetails(apis.ErrInvalidValue("vsphere/events", "spec.kafka.topic"),
+				"expected at most 249 letters, digits, '.', '_' or '-'")).
+			Also(withDetails(apis.ErrInvalidValue("GSSAPI", "spec.kafka.sasl.mechanism"),
+				"expected PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512")).
+			Also(apis.ErrMissingField("spec.kafka.sasl.secretRef.name")),
+	}, {
+		name: "Kafka with HTTP delivery options",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				VAuthSpec: validVAuthSpec,
+				Delivery: &VDeliverySpec{
+					Headers:        map[string]string{"X-Team": "infra"},
+					MaxAttempts:    3,
+					DeadLetterSink: &duckv1.Destination{URI: apis.HTTP("dead-letters.example.com")},
+				},
+				Kafka: &VKafkaSpec{
+					BootstrapServers: []string{"kafka.example.com:9092"},
+					Topic:            "vsphere.events",
+				},
+			},
+		},
+		want: withDetails(apis.ErrDisallowedFields("spec.delivery.headers", "spec.delivery.deadLetterSink"),
+			"the HTTP options of the delivery do not apply to kafka"),
+	}, {
+		name: "valid LogOnly",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				VAuthSpec: validVAuthSpec,
+				LogOnly:   true,
+			},
+		},
+		want: nil,
+	}, {
+		name: "LogOnly with sink and Kafka",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Kafka: &VKafkaSpec{
+					BootstrapServers: []string{"kafka.example.com:9092"},
+					Topic:            "vsphere.events",
+				},
+				LogOnly: true,
+			},
+		},
+		want: apis.ErrMultipleOneOf("spec.sink", "spec.logOnly").
+			Also(apis.ErrMultipleOneOf("spec.kafka", "spec.logOnly")),
+	}, {
+		name: "valid Transform",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Transform: &VTransformSpec{
+					Template: `{"vm": {{ json .Vm.Name }}}`,
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "Transform without template",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Transform:  &VTransformSpec{},
+			},
+		},
+		want: apis.ErrMissingField("spec.transform.template"),
+	}, {
+		name: "invalid Transform template",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Transform: &VTransformSpec{
+					Template: `{"vm": {{ toJSON .Vm.Name }}}`,
+				},
+			},
+		},
+		want: withDetails(apis.ErrInvalidValue(`{"vm": {{ toJSON .Vm.Name }}}`, "spec.transform.template"),
+			`template: transform:1: function "toJSON" not defined`),
+	}, {
+		name: "valid ExtensionAttributes",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:          validSourceSpec,
+				VAuthSpec:           validVAuthSpec,
+				ExtensionAttributes: []string{"vmmoref", "vcenterid"},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid ExtensionAttributes",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:          validSourceSpec,
+				VAuthSpec:           validVAuthSpec,
+				ExtensionAttributes: []string{"vmmoref", "VMMoref"},
+			},
+		},
+		want: apis.ErrInvalidArrayValue("VMMoref", "spec.extensionAttributes", 1),
+	}, {
+		name: "valid OutputFormat",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:   validSourceSpec,
+				VAuthSpec:    validVAuthSpec,
+				OutputFormat: "cdevents",
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid OutputFormat",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:   validSourceSpec,
+				VAuthSpec:    validVAuthSpec,
+				OutputFormat: "xml",
+			},
+		},
+		want: apis.ErrInvalidValue("xml", "spec.outputFormat"),
+	}, {
+		name: "valid PayloadEncoding",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				PayloadEncoding: "protobuf",
+				IncludeTags:     true,
+			},
+		},
+		want: nil,
+	}, {
+		name: "PayloadEncoding protobuf without payloads with a schema",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				PayloadEncoding: "protobuf",
+			},
+		},
+		want: func() *apis.FieldError {
+			fe := apis.ErrInvalidValue("protobuf", "spec.payloadEncoding")
+			fe.Details = "only normalized alarm, tag and content library events are encoded as protobuf, enable at least one of normalizeAlarms, includeTags or includeContentLibrary"
+			return fe
+		}(),
+	}, {
+		name: "invalid PayloadEncoding",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				PayloadEncoding: "avro",
+			},
+		},
+		want: apis.ErrInvalidValue("avro", "spec.payloadEncoding"),
+	}, {
+		name: "PayloadEncoding protobuf with Transform",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:      validSourceSpec,
+				VAuthSpec:       validVAuthSpec,
+				PayloadEncoding: "protobuf",
+				Transform: &VTransformSpec{
+					Template: `{"vm": {{ json .Vm.Name }}}`,
+				},
+			},
+		},
+		want: apis.ErrMultipleOneOf("spec.payloadEncoding", "spec.transform"),
+	}, {
+		name: "valid logging level",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "valid",
+				Annotations: map[string]string{vsphere.LoggingLevelAnnotation: "debug"},
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid logging level",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "valid",
+				Annotations: map[string]string{vsphere.LoggingLevelAnnotation: "chatty"},
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+			},
+		},
+		want: func() *apis.FieldError {
+			fe := apis.ErrInvalidValue("chatty", vsphere.LoggingLevelAnnotation)
+			fe.Details = `unrecognized level: "chatty"`
+			return fe.ViaField("metadata.annotations")
+		}(),
+	}, {
+		name: "valid restart annotations",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+				Annotations: map[string]string{
+					vsphere.RestartedAtAnnotation:     "2021-03-01T08:00:00Z",
+					vsphere.CheckpointResetAnnotation: "2021-03-01T08:00:00Z",
+				},
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid checkpoint reset annotation",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "valid",
+				Annotations: map[string]string{vsphere.CheckpointResetAnnotation: "now"},
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+			},
+		},
+		want: func() *apis.FieldError {
+			fe := apis.ErrInvalidValue("now", vsphere.CheckpointResetAnnotation)
+			fe.Details = "expected an RFC3339 time, e.g. 2021-02-15T19:00:00Z"
+			return fe.ViaField("metadata.annotations")
+		}(),
+	}, {
+		name: "valid checkpoint import annotation",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+				Annotations: map[string]string{
+					vsphere.CheckpointImportAnnotation: `{"lastEventKey":42,"lastEventKeyTimestamp":"2021-03-01T08:00:00Z"}`,
+				},
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+			},
+		},
+		want: nil,
+	}, {
+		name: "checkpoint import annotation without last event timestamp",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "valid",
+				Annotations: map[string]string{vsphere.CheckpointImportAnnotation: `{"lastEventKey":42}`},
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+			},
+		},
+		want: func() *apis.FieldError {
+			fe := apis.ErrInvalidValue(`{"lastEventKey":42}`, vsphere.CheckpointImportAnnotation)
+			fe.Details = "checkpoint has no last event timestamp"
+			return fe.ViaField("metadata.annotations")
+		}(),
+	}, {
+		name: "valid wire trace annotation",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "valid",
+				Annotations: map[string]string{vsphere.WireTraceAnnotation: "volume"},
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid wire trace annotation",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "valid",
+				Annotations: map[string]string{vsphere.WireTraceAnnotation: "true"},
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+			},
+		},
+		want: func() *apis.FieldError {
+			fe := apis.ErrInvalidValue("true", vsphere.WireTraceAnnotation)
+			fe.Details = `unsupported wire trace "true", must be logs or volume`
+			return fe.ViaField("metadata.annotations")
+		}(),
+	}, {
+		name: "valid AttributeMapping",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				AttributeMapping: &VAttributeMappingSpec{
+					Source:       "https://vcenter.prod.example.com",
+					TypeTemplate: "com.example.{{ .Class }}.{{ .Name }}",
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid AttributeMapping",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				AttributeMapping: &VAttributeMappingSpec{
+					Source:       "://vcenter",
+					TypePrefix:   "com.example.",
+					TypeTemplate: "{{ .Unknown }}",
+				},
+			},
+		},
+		want: apis.ErrInvalidValue("://vcenter", "spec.attributeMapping.source").
+			Also(apis.ErrMultipleOneOf("spec.attributeMapping.typePrefix", "spec.attributeMapping.typeTemplate")).
+			Also(&apis.FieldError{
+				Message: "invalid value: {{ .Unknown }}",
+				Paths:   []string{"spec.attributeMapping.typeTemplate"},
+				Details: `template: type:1:3: executing "type" at <.Unknown>: can't evaluate field Unknown in type vsphere.typeTemplateData`,
+			}),
+	}, {
+		name: "valid RateLimit",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				RateLimit:  &VRateLimitSpec{EventsPerSecond: 10, Burst: 50},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid RateLimit",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				RateLimit:  &VRateLimitSpec{EventsPerSecond: 0, Burst: -1},
+			},
+		},
+		want: apis.ErrOutOfBoundsValue(int32(0), 1, math.MaxInt32, "spec.rateLimit.eventsPerSecond").
+			Also(apis.ErrOutOfBoundsValue(int32(-1), 0, math.MaxInt32, "spec.rateLimit.burst")),
+	}, {
+		name: "valid Sampling",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Sampling:   &VSamplingSpec{WindowSeconds: 60, Threshold: 3},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid Sampling",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Sampling:   &VSamplingSpec{Threshold: -1},
+			},
+		},
+		want: apis.ErrOutOfBoundsValue(int64(0), 1, math.MaxInt64, "spec.sampling.windowSeconds").
+			Also(apis.ErrOutOfBoundsValue(int32(-1), 0, math.MaxInt32, "spec.sampling.threshold")),
+	}, {
+		name: "valid VCenterAPI",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				VCenterAPI: &VAPISpec{TimeoutSeconds: 30, Retries: 3, RetryBackoffSeconds: 2, MaxRetryBackoffSeconds: 20},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid VCenterAPI",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				VCenterAPI: &VAPISpec{TimeoutSeconds: -1, Retries: 11, RetryBackoffSeconds: 30, MaxRetryBackoffSeconds: 10},
+			},
+		},
+		want: apis.ErrOutOfBoundsValue(int64(-1), 0, math.MaxInt64, "spec.vcenterAPI.timeoutSeconds").
+			Also(apis.ErrOutOfBoundsValue(int32(11), 0, 10, "spec.vcenterAPI.retries")).
+			Also(&apis.FieldError{
+				Message: "invalid value: 10",
+				Paths:   []string{"spec.vcenterAPI.maxRetryBackoffSeconds"},
+				Details: "the maximum retry backoff must not be less than retryBackoffSeconds",
+			}),
+	}, {
+		name: "valid Heartbeat",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Heartbeat:  &VHeartbeatSpec{IntervalSeconds: 30},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid Heartbeat",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Heartbeat:  &VHeartbeatSpec{IntervalSeconds: -1},
+			},
+		},
+		want: apis.ErrOutOfBoundsValue(int64(-1), 0, math.MaxInt64, "spec.heartbeat.intervalSeconds"),
+	}, {
+		name: "valid Deployment",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Deployment: &VDeploymentSpec{
+					Strategy:            "RollingUpdate",
+					MaxSurge:            intOrStringPtr(intstr.FromInt(0)),
+					MaxUnavailable:      intOrStringPtr(intstr.FromString("100%")),
+					PodDisruptionBudget: &VPodDisruptionBudgetSpec{MinAvailable: intOrStringPtr(intstr.FromInt(1))},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid Deployment",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Deployment: &VDeploymentSpec{
+					Strategy: "Recreate",
+					MaxSurge: intOrStringPtr(intstr.FromInt(1)),
+					PodDisruptionBudget: &VPodDisruptionBudgetSpec{
+						MinAvailable:   intOrStringPtr(intstr.FromInt(1)),
+						MaxUnavailable: intOrStringPtr(intstr.FromInt(0)),
+					},
+				},
+			},
+		},
+		want: apis.ErrDisallowedFields("spec.deployment.maxSurge").
+			Also(apis.ErrMultipleOneOf("spec.deployment.podDisruptionBudget.minAvailable",
+				"spec.deployment.podDisruptionBudget.maxUnavailable")),
+	}, {
+		name: "invalid Deployment rolling update",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Deployment: &VDeploymentSpec{
+					MaxSurge:       intOrStringPtr(intstr.FromString("0%")),
+					MaxUnavailable: intOrStringPtr(intstr.FromInt(0)),
+				},
+			},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: 0",
+			Paths:   []string{"spec.deployment.maxUnavailable"},
+			Details: "maxUnavailable must not be 0 when maxSurge is 0",
+		},
+	}, {
+		name: "invalid Deployment strategy",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Deployment: &VDeploymentSpec{
+					Strategy:            "BlueGreen",
+					PodDisruptionBudget: &VPodDisruptionBudgetSpec{MaxUnavailable: intOrStringPtr(intstr.FromString("one"))},
+				},
+			},
+		},
+		want: (&apis.FieldError{
+			Message: "invalid value: BlueGreen",
+			Paths:   []string{"spec.deployment.strategy"},
+			Details: "expected Recreate or RollingUpdate",
+		}).Also(&apis.FieldError{
+			Message: "invalid value: one",
+			Paths:   []string{"spec.deployment.podDisruptionBudget.maxUnavailable"},
+			Details: "expected a number or a percentage, e.g. 1 or 25%",
+		}),
+	}, {
+		name: "valid Deployment pod classes",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Deployment: &VDeploymentSpec{
+					PriorityClassName: "event-sources",
+					RuntimeClassName:  ptr.String("gvisor"),
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid Deployment pod classes",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Deployment: &VDeploymentSpec{
+					PriorityClassName: "Event_Sources",
+					RuntimeClassName:  ptr.String(""),
+				},
+			},
+		},
+		want: withDetails(apis.ErrInvalidValue("Event_Sources", "spec.deployment.priorityClassName"),
+			validation.IsDNS1123Subdomain("Event_Sources")[0]).
+			Also(withDetails(apis.ErrInvalidValue("", "spec.deployment.runtimeClassName"),
+				validation.IsDNS1123Subdomain("")[0])),
+	}, {
+		name: "valid EventCollector",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:     validSourceSpec,
+				VAuthSpec:      validVAuthSpec,
+				EventCollector: &VEventCollectorSpec{PollIntervalSeconds: 30, PageSize: 1000, Mode: "stream"},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid EventCollector",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:     validSourceSpec,
+				VAuthSpec:      validVAuthSpec,
+				EventCollector: &VEventCollectorSpec{PollIntervalSeconds: -1, PageSize: 1001, Mode: "push"},
+			},
+		},
+		want: apis.ErrOutOfBoundsValue(int64(-1), 0, math.MaxInt64, "spec.eventCollector.pollIntervalSeconds").
+			Also(apis.ErrOutOfBoundsValue(int32(1001), 0, vsphere.CollectorMaxPageSize, "spec.eventCollector.pageSize")).
+			Also(apis.ErrInvalidValue("push", "spec.eventCollector.mode")),
+	}, {
+		name: "valid Redaction",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Redaction: &VRedactionSpec{
+					Fields: []string{"userName", "$.vm.name"},
+					Mode:   vsphere.RedactionRemove,
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid Redaction",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Redaction: &VRedactionSpec{
+					Fields: []string{"userName", "$.hosts[*].name"},
+					Mode:   "hash",
+				},
+			},
+		},
+		want: (&apis.FieldError{
+			Message: "invalid value: $.hosts[*].name",
+			Paths:   []string{"spec.redaction.fields[1]"},
+			Details: `unsupported field name "hosts[*]" in "$.hosts[*].name", only names and dot-separated paths are supported`,
+		}).Also(apis.ErrInvalidValue("hash", "spec.redaction.mode")),
+	}, {
+		name: "Redaction without fields",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Redaction:  &VRedactionSpec{},
+			},
+		},
+		want: apis.ErrMissingField("spec.redaction.fields"),
+	}, {
+		name: "valid Locale",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Locale:     "zh_CN",
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid Locale",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Locale:     "de-DE",
+			},
+		},
+		want: withDetails(apis.ErrInvalidValue("de-DE", "spec.locale"),
+			"expected a language code with an optional country code, e.g. de or zh_CN"),
+	}, {
+		name: "valid AdapterOverrides",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				AdapterOverrides: &VAdapterOverridesSpec{
+					Env: []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy.example.com:3128"}},
+					Volumes: []corev1.Volume{{
+						Name:         "ca-bundle",
+						VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "ca-bundle"}}},
+					}},
+					VolumeMounts: []corev1.VolumeMount{{Name: "ca-bundle", MountPath: "/etc/ssl/certs/site", ReadOnly: true}},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid AdapterOverrides",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				AdapterOverrides: &VAdapterOverridesSpec{
+					Env:          []corev1.EnvVar{{Name: "HTTPS_PROXY"}, {Name: ""}, {Name: "HTTPS_PROXY"}},
+					Volumes:      []corev1.Volume{{Name: vsphere.SinkHeadersVolumeName}, {Name: vsphere.AddressSecretVolumeName(3)}, {Name: "ca-bundle"}, {Name: "ca-bundle"}},
+					VolumeMounts: []corev1.VolumeMount{{Name: "ca-bundle", MountPath: "certs"}, {Name: "missing", MountPath: "/missing"}},
+				},
+			},
+		},
+		want: apis.ErrMissingField("spec.adapterOverrides.env[1].name").
+			Also(withDetails(apis.ErrInvalidValue("HTTPS_PROXY", "spec.adapterOverrides.env[2].name"), "duplicate environment variable")).
+			Also(withDetails(apis.ErrInvalidValue(vsphere.SinkHeadersVolumeName, "spec.adapterOverrides.volumes[0].name"),
+				"the name is reserved for the volumes set by the controller")).
+			Also(withDetails(apis.ErrInvalidValue("vsphere-address-3", "spec.adapterOverrides.volumes[1].name"),
+				"the name is reserved for the volumes set by the controller")).
+			Also(withDetails(apis.ErrInvalidValue("ca-bundle", "spec.adapterOverrides.volumes[3].name"), "duplicate volume")).
+			Also(withDetails(apis.ErrInvalidValue("certs", "spec.adapterOverrides.volumeMounts[0].mountPath"), "expected an absolute path")).
+			Also(withDetails(apis.ErrInvalidValue("missing", "spec.adapterOverrides.volumeMounts[1].name"),
+				"expected the name of one of the volumes")),
+	}, {
+		name: "valid Audit",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Audit: &VAuditSpec{
+					Sink: &duckv1.Destination{URI: apis.HTTP("audit.example.com")},
+					Path: "/var/audit/events.log",
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "empty Audit",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Audit:      &VAuditSpec{},
+			},
+		},
+		want: apis.ErrMissingOneOf("spec.audit.sink", "spec.audit.path"),
+	}, {
+		name: "invalid Audit",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Audit: &VAuditSpec{
+					Sink: &duckv1.Destination{},
+					Path: "events.log",
+				},
+			},
+		},
+		want: apis.ErrGeneric("expected at least one, got none", "spec.audit.sink.ref", "spec.audit.sink.uri").
+			Also(withDetails(apis.ErrInvalidValue("events.log", "spec.audit.path"), "the path must be absolute")),
+	}, {
+		name: "valid Scope path",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Scope:      &VScopeSpec{Path: "/dc1/host/cluster1"},
+			},
+		},
+		want: nil,
+	}, {
+		name: "valid Scope id",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Scope:      &VScopeSpec{ID: "ResourcePool:resgroup-42"},
+			},
+		},
+		want: nil,
+	}, {
+		name: "empty Scope",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Scope:      &VScopeSpec{},
+			},
+		},
+		want: apis.ErrMissingOneOf("spec.scope.path", "spec.scope.id"),
+	}, {
+		name: "Scope with path and id",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Scope:      &VScopeSpec{Path: "/dc1", ID: "Datacenter:datacenter-2"},
+			},
+		},
+		want: apis.ErrMultipleOneOf("spec.scope.path", "spec.scope.id"),
+	}, {
+		name: "relative Scope path",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Scope:      &VScopeSpec{Path: "dc1/host/cluster1"},
+			},
+		},
+		want: withDetails(apis.ErrInvalidValue("dc1/host/cluster1", "spec.scope.path"),
+			"the inventory path must be absolute, e.g. /dc1/host/cluster1"),
+	}, {
+		name: "Scope id of a virtual machine",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec: validSourceSpec,
+				VAuthSpec:  validVAuthSpec,
+				Scope:      &VScopeSpec{ID: "VirtualMachine:vm-42"},
+			},
+		},
+		want: withDetails(apis.ErrInvalidValue("VirtualMachine:vm-42", "spec.scope.id"),
+			`unsupported scope type "VirtualMachine", must be one of Datacenter, Folder, ClusterComputeResource, ComputeResource, ResourcePool, VirtualApp`),
+	}, {
+		name: "Scope with content library, tags and additional vCenters",
+		c: &VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: VSphereSourceSpec{
+				SourceSpec:            validSourceSpec,
+				VAuthSpec:             validVAuthSpec,
+				IncludeContentLibrary: true,
+				IncludeTags:           true,
+				Addresses: []VAddressSpec{{
+					Address:   apis.URL{Scheme: "https", Host: "vcenter-2.example.com"},
+					SecretRef: corev1.LocalObjectReference{Name: "vcenter-2"},
+				}},
+				Scope: &VScopeSpec{ID: "ClusterComputeResource:domain-c7"},
+			},
+		},
+		want: apis.ErrMultipleOneOf("spec.scope", "spec.includeContentLibrary").
+			Also(apis.ErrMultipleOneOf("spec.scope", "spec.includeTags")).
+			Also(apis.ErrMultipleOneOf("spec.scope.id", "spec.addresses")),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.c.Validate(context.Background())
+			if !cmp.Equal(test.want.Error(), got.Error()) {
+				t.Errorf("Validate (-want, +got) = %v",
+					cmp.Diff(test.want.Error(), got.Error()))
+			}
+		})
+	}
+}
+
+func insecureVAuthSpec(scheme string) VAuthSpec {
+	spec := validVAuthSpec
+	spec.Address.Scheme = scheme
+	return spec
+}
+
+func withDetails(fe *apis.FieldError, details string) *apis.FieldError {
+	fe.Details = details
+	return fe
+}
+
+func intOrStringPtr(v intstr.IntOrString) *intstr.IntOrString {
+	return &v
 }
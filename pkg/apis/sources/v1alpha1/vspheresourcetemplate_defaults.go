@@ -0,0 +1,77 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package v1alpha1
+
+import (
+	"context"
+)
+
+// TemplateGetter returns the VSphereSourceTemplate of the given name.
+type TemplateGetter func(ctx context.Context, name string) (*VSphereSourceTemplate, error)
+
+// templatesKey is the context key of the TemplateGetter with which the
+// templates of VSphereSources are applied and validated
+type templatesKey struct{}
+
+// WithTemplates attaches the getter with which SetDefaults applies the
+// template referenced by a VSphereSource and Validate checks that it exists.
+// Templates are neither applied nor checked without a getter, e.g. outside of
+// the webhook.
+func WithTemplates(ctx context.Context, get TemplateGetter) context.Context {
+	return context.WithValue(ctx, templatesKey{}, get)
+}
+
+// templatesFromContext returns the TemplateGetter attached with
+// WithTemplates, nil if there is none
+func templatesFromContext(ctx context.Context) TemplateGetter {
+	get, _ := ctx.Value(templatesKey{}).(TemplateGetter)
+	return get
+}
+
+// SetDefaults implements apis.Defaultable. The settings of a template are
+// defaulted with the sources they are applied to.
+func (vsst *VSphereSourceTemplate) SetDefaults(ctx context.Context) {}
+
+// ApplyTemplate overrides the settings of the source with those set in the
+// given template, which are copied.
+func (vsss *VSphereSourceSpec) ApplyTemplate(t *VSphereSourceTemplateSpec) {
+	if t.Address != nil {
+		vsss.Address = *t.Address.DeepCopy()
+	}
+	if t.SkipTLSVerify != nil {
+		vsss.SkipTLSVerify = *t.SkipTLSVerify
+	}
+	if t.AllowInsecureAddress != nil {
+		vsss.AllowInsecureAddress = *t.AllowInsecureAddress
+	}
+	if t.CACertsConfigMapRef != nil {
+		vsss.CACertsConfigMapRef = t.CACertsConfigMapRef.DeepCopy()
+	}
+	if t.SecretRef != nil {
+		vsss.SecretRef = *t.SecretRef
+	}
+	if t.AuthMethod != "" {
+		vsss.AuthMethod = t.AuthMethod
+	}
+	if t.CredentialProvider != nil {
+		vsss.CredentialProvider = t.CredentialProvider.DeepCopy()
+	}
+	if t.FallbackAddress != nil {
+		vsss.FallbackAddress = t.FallbackAddress.DeepCopy()
+	}
+	if t.VCenterAPI != nil {
+		vsss.VCenterAPI = t.VCenterAPI.DeepCopy()
+	}
+	if t.CheckpointConfig != nil {
+		vsss.CheckpointConfig = *t.CheckpointConfig
+	}
+	if t.Delivery != nil {
+		vsss.Delivery = t.Delivery.DeepCopy()
+	}
+	if t.RateLimit != nil {
+		vsss.RateLimit = t.RateLimit.DeepCopy()
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/ptr"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+)
+
+// templateGetter returns a TemplateGetter of the given templates
+func templateGetter(templates ...*VSphereSourceTemplate) TemplateGetter {
+	return func(_ context.Context, name string) (*VSphereSourceTemplate, error) {
+		for _, t := range templates {
+			if t.Name == name {
+				return t, nil
+			}
+		}
+		return nil, apierrs.NewNotFound(Resource("vspheresourcetemplates"), name)
+	}
+}
+
+func TestVSphereSourceTemplateDefaulting(t *testing.T) {
+	template := &VSphereSourceTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+		Spec: VSphereSourceTemplateSpec{
+			Address:             &apis.URL{Scheme: "https", Host: "vcenter.prod"},
+			SkipTLSVerify:       ptr.Bool(false),
+			SecretRef:           &corev1.LocalObjectReference{Name: "prod-credentials"},
+			CACertsConfigMapRef: &corev1.LocalObjectReference{Name: "prod-ca"},
+			CheckpointConfig:    &VCheckpointSpec{MaxAgeSeconds: 600, PeriodSeconds: 30},
+			Delivery:            &VDeliverySpec{MaxAttempts: 5},
+			RateLimit:           &VRateLimitSpec{EventsPerSecond: 10},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		ref      string
+		get      TemplateGetter
+		wantAuth VAuthSpec
+		want     func(*testing.T, *VSphereSourceSpec)
+	}{{
+		name:     "no template",
+		get:      templateGetter(template),
+		wantAuth: defaultedVAuthSpec,
+	}, {
+		name:     "no getter",
+		ref:      "prod",
+		wantAuth: defaultedVAuthSpec,
+	}, {
+		name:     "template not found",
+		ref:      "dev",
+		get:      templateGetter(template),
+		wantAuth: defaultedVAuthSpec,
+	}, {
+		name: "getter fails",
+		ref:  "prod",
+		get: func(context.Context, string) (*VSphereSourceTemplate, error) {
+			return nil, errors.New("boom")
+		},
+		wantAuth: defaultedVAuthSpec,
+	}, {
+		name: "template applied",
+		ref:  "prod",
+		get:  templateGetter(template),
+		wantAuth: VAuthSpec{
+			Address:             apis.URL{Scheme: "https", Host: "vcenter.prod"},
+			SecretRef:           corev1.LocalObjectReference{Name: "prod-credentials"},
+			CACertsConfigMapRef: &corev1.LocalObjectReference{Name: "prod-ca"},
+			AuthMethod:          vsphere.AuthMethodBasic,
+		},
+		want: func(t *testing.T, spec *VSphereSourceSpec) {
+			if want := (VCheckpointSpec{MaxAgeSeconds: 600, PeriodSeconds: 30}); spec.CheckpointConfig != want {
+				t.Errorf("CheckpointConfig = %+v, want %+v", spec.CheckpointConfig, want)
+			}
+			if want := (&VDeliverySpec{MaxAttempts: 5, ContentMode: vsphere.ContentModeBinary}); !cmp.Equal(spec.Delivery, want) {
+				t.Errorf("Delivery (-want, +got) = %v", cmp.Diff(want, spec.Delivery))
+			}
+			if want := (&VRateLimitSpec{EventsPerSecond: 10, Burst: 10}); !cmp.Equal(spec.RateLimit, want) {
+				t.Errorf("RateLimit (-want, +got) = %v", cmp.Diff(want, spec.RateLimit))
+			}
+			if template.Spec.Delivery.ContentMode != "" || template.Spec.RateLimit.Burst != 0 {
+				t.Error("SetDefaults defaulted the template, want its settings copied")
+			}
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vs := &VSphereSource{
+				ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"},
+				Spec: VSphereSourceSpec{
+					SourceSpec:  validSourceSpec,
+					VAuthSpec:   validVAuthSpec,
+					TemplateRef: test.ref,
+					Delivery:    &VDeliverySpec{Path: "/events"},
+				},
+			}
+			ctx := context.Background()
+			if test.get != nil {
+				ctx = WithTemplates(ctx, test.get)
+			}
+			vs.SetDefaults(ctx)
+			if !cmp.Equal(test.wantAuth, vs.Spec.VAuthSpec) {
+				t.Errorf("VAuthSpec (-want, +got) = %v", cmp.Diff(test.wantAuth, vs.Spec.VAuthSpec))
+			}
+			if test.want != nil {
+				test.want(t, &vs.Spec)
+			}
+		})
+	}
+}
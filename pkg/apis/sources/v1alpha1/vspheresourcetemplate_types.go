@@ -0,0 +1,104 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VSphereSourceTemplate is a cluster-scoped set of common vCenter, TLS,
+// checkpoint and delivery settings of VSphereSources. The settings of a
+// template override those of the sources referencing it with templateRef, so
+// platform teams can enforce standards while users only specify the name,
+// sink and filter of their sources.
+type VSphereSourceTemplate struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the settings of the VSphereSourceTemplate.
+	// +optional
+	Spec VSphereSourceTemplateSpec `json:"spec,omitempty"`
+}
+
+// Check that VSphereSourceTemplate can be validated and defaulted.
+var _ apis.Validatable = (*VSphereSourceTemplate)(nil)
+var _ apis.Defaultable = (*VSphereSourceTemplate)(nil)
+
+// VSphereSourceTemplateSpec holds the settings applied to the VSphereSources
+// referencing the template. Omitted settings are left to the sources.
+type VSphereSourceTemplateSpec struct {
+	// Address is the URL of the vCenter of the sources.
+	// +optional
+	Address *apis.URL `json:"address,omitempty"`
+
+	// SkipTLSVerify disables certificate verification of the vCenter.
+	// +optional
+	SkipTLSVerify *bool `json:"skipTLSVerify,omitempty"`
+
+	// AllowInsecureAddress allows an address without TLS.
+	// +optional
+	AllowInsecureAddress *bool `json:"allowInsecureAddress,omitempty"`
+
+	// CACertsConfigMapRef is the configmap with the CA certificates of the
+	// vCenter, which must exist in the namespace of every source.
+	// +optional
+	CACertsConfigMapRef *corev1.LocalObjectReference `json:"caCertsConfigMapRef,omitempty"`
+
+	// SecretRef is the secret with the vCenter credentials, which must exist
+	// in the namespace of every source.
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// AuthMethod is the method to authenticate with vCenter.
+	// +optional
+	AuthMethod string `json:"authMethod,omitempty"`
+
+	// CredentialProvider reads the vCenter credentials from an external
+	// secret store instead of the secret.
+	// +optional
+	CredentialProvider *VCredentialProviderSpec `json:"credentialProvider,omitempty"`
+
+	// FallbackAddress is the address of the vCenter to connect to if the
+	// address is unreachable.
+	// +optional
+	FallbackAddress *apis.URL `json:"fallbackAddress,omitempty"`
+
+	// VCenterAPI configures the timeout and the retries of the vCenter API
+	// calls.
+	// +optional
+	VCenterAPI *VAPISpec `json:"vcenterAPI,omitempty"`
+
+	// CheckpointConfig configures the checkpoints and the event replay.
+	// +optional
+	CheckpointConfig *VCheckpointSpec `json:"checkpointConfig,omitempty"`
+
+	// Delivery configures the delivery of the events to the sinks, replacing
+	// the delivery settings of the sources.
+	// +optional
+	Delivery *VDeliverySpec `json:"delivery,omitempty"`
+
+	// RateLimit limits the rate of the events sent to the sinks.
+	// +optional
+	RateLimit *VRateLimitSpec `json:"rateLimit,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VSphereSourceTemplateList is a list of VSphereSourceTemplate resources
+type VSphereSourceTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []VSphereSourceTemplate `json:"items"`
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+)
+
+// Validate implements apis.Validatable
+func (vsst *VSphereSourceTemplate) Validate(ctx context.Context) *apis.FieldError {
+	return vsst.Spec.Validate(ctx).ViaField("spec")
+}
+
+// Validate implements apis.Validatable. Only the settings set in the template
+// are validated, the sources are validated with the template applied.
+func (vssts *VSphereSourceTemplateSpec) Validate(ctx context.Context) (err *apis.FieldError) {
+	allowInsecure := vssts.AllowInsecureAddress != nil && *vssts.AllowInsecureAddress
+
+	var address apis.URL
+	if vssts.Address != nil {
+		address = *vssts.Address
+		if address.Host == "" {
+			err = err.Also(apis.ErrMissingField("address.host"))
+		}
+		err = err.Also(validateAddressScheme(address, "address", allowInsecure))
+	}
+	err = err.Also(validateFallbackAddress(address, vssts.FallbackAddress, allowInsecure))
+
+	if vssts.CACertsConfigMapRef != nil && vssts.CACertsConfigMapRef.Name == "" {
+		err = err.Also(apis.ErrMissingField("caCertsConfigMapRef.name"))
+	}
+	if vssts.SecretRef != nil && vssts.SecretRef.Name == "" {
+		err = err.Also(apis.ErrMissingField("secretRef.name"))
+	}
+	if vssts.CredentialProvider != nil {
+		err = err.Also(vssts.CredentialProvider.Validate(ctx).ViaField("credentialProvider"))
+	}
+	switch vssts.AuthMethod {
+	case "", vsphere.AuthMethodBasic, vsphere.AuthMethodSAML,
+		vsphere.AuthMethodCertificate, vsphere.AuthMethodCSP:
+	default:
+		err = err.Also(apis.ErrInvalidValue(vssts.AuthMethod, "authMethod"))
+	}
+
+	if vssts.CheckpointConfig != nil {
+		err = err.Also(vssts.CheckpointConfig.Validate(ctx))
+	}
+	return err.Also(vssts.VCenterAPI.Validate(ctx).ViaField("vcenterAPI")).
+		Also(vssts.Delivery.Validate(ctx).ViaField("delivery")).
+		Also(vssts.RateLimit.Validate(ctx).ViaField("rateLimit"))
+}
+
+// validateTemplateRef validates that the template referenced by a source
+// exists, so that a source is rejected instead of being created without the
+// settings of its template. The template is only looked up if a getter is
+// attached with WithTemplates.
+func (vsss *VSphereSourceSpec) validateTemplateRef(ctx context.Context) *apis.FieldError {
+	if vsss.TemplateRef == "" {
+		return nil
+	}
+	if fe := validateObjectName(vsss.TemplateRef, "templateRef"); fe != nil {
+		return fe
+	}
+	get := templatesFromContext(ctx)
+	if get == nil {
+		return nil
+	}
+
+	_, err := get(ctx, vsss.TemplateRef)
+	switch {
+	case apierrs.IsNotFound(err):
+		fe := apis.ErrInvalidValue(vsss.TemplateRef, "templateRef")
+		fe.Details = fmt.Sprintf("VSphereSourceTemplate %s not found", vsss.TemplateRef)
+		return fe
+	case err != nil:
+		logging.FromContext(ctx).Warnw("failed to validate template", "name", vsss.TemplateRef, "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/ptr"
+)
+
+func TestVSphereSourceTemplateValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		c    VSphereSourceTemplateSpec
+		want *apis.FieldError
+	}{{
+		name: "empty",
+	}, {
+		name: "valid",
+		c: VSphereSourceTemplateSpec{
+			Address:          &apis.URL{Scheme: "https", Host: "vcenter.prod"},
+			FallbackAddress:  &apis.URL{Scheme: "https", Host: "vcenter.dr"},
+			SecretRef:        &corev1.LocalObjectReference{Name: "prod-credentials"},
+			CheckpointConfig: &VCheckpointSpec{MaxAgeSeconds: 600, PeriodSeconds: 30},
+			VCenterAPI:       &VAPISpec{TimeoutSeconds: 30, Retries: 3},
+		},
+	}, {
+		name: "insecure address",
+		c: VSphereSourceTemplateSpec{
+			Address: &apis.URL{Scheme: "http", Host: "vcenter.prod"},
+		},
+		want: &apis.FieldError{
+			Message: "invalid value: http://vcenter.prod",
+			Paths:   []string{"spec.address"},
+			Details: "the vSphere address must use https, set allowInsecureAddress to connect without TLS",
+		},
+	}, {
+		name: "insecure address allowed",
+		c: VSphereSourceTemplateSpec{
+			Address:              &apis.URL{Scheme: "http", Host: "vcenter.prod"},
+			AllowInsecureAddress: ptr.Bool(true),
+		},
+	}, {
+		name: "missing names",
+		c: VSphereSourceTemplateSpec{
+			Address:             &apis.URL{},
+			SecretRef:           &corev1.LocalObjectReference{},
+			CACertsConfigMapRef: &corev1.LocalObjectReference{},
+		},
+		want: apis.ErrMissingField("spec.address.host", "spec.caCertsConfigMapRef.name", "spec.secretRef.name"),
+	}, {
+		name: "invalid settings",
+		c: VSphereSourceTemplateSpec{
+			AuthMethod:       "kerberos",
+			CheckpointConfig: &VCheckpointSpec{PeriodSeconds: -1},
+			VCenterAPI:       &VAPISpec{Retries: 100},
+		},
+		want: apis.ErrInvalidValue("kerberos", "spec.authMethod").
+			Also(&apis.FieldError{
+				Message: "invalid value: -1",
+				Paths:   []string{"spec.checkpointConfig.periodSeconds"},
+				Details: "the checkpoint period must be positive",
+			}).
+			Also(apis.ErrOutOfBoundsValue(100, 0, 10, "spec.vcenterAPI.retries")),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vsst := &VSphereSourceTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "template"},
+				Spec:       test.c,
+			}
+			got := vsst.Validate(context.Background())
+			if !cmp.Equal(test.want.Error(), got.Error()) {
+				t.Errorf("Validate (-want, +got) = %v",
+					cmp.Diff(test.want.Error(), got.Error()))
+			}
+		})
+	}
+}
+
+func TestVSphereSourceValidateTemplateRef(t *testing.T) {
+	template := &VSphereSourceTemplate{ObjectMeta: metav1.ObjectMeta{Name: "prod"}}
+
+	tests := []struct {
+		name string
+		ref  string
+		get  TemplateGetter
+		want *apis.FieldError
+	}{{
+		name: "template exists",
+		ref:  "prod",
+		get:  templateGetter(template),
+	}, {
+		name: "template not looked up without getter",
+		ref:  "dev",
+	}, {
+		name: "template not found",
+		ref:  "dev",
+		get:  templateGetter(template),
+		want: &apis.FieldError{
+			Message: "invalid value: dev",
+			Paths:   []string{"spec.templateRef"},
+			Details: "VSphereSourceTemplate dev not found",
+		},
+	}, {
+		name: "getter fails",
+		ref:  "prod",
+		get: func(context.Context, string) (*VSphereSourceTemplate, error) {
+			return nil, errors.New("boom")
+		},
+	}, {
+		name: "invalid name",
+		ref:  "Prod",
+		get:  templateGetter(template),
+		want: &apis.FieldError{
+			Message: "invalid value: Prod",
+			Paths:   []string{"spec.templateRef"},
+			Details: strings.Join(validation.IsDNS1123Subdomain("Prod"), ", "),
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vs := &VSphereSource{
+				ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"},
+				Spec: VSphereSourceSpec{
+					SourceSpec:  validSourceSpec,
+					VAuthSpec:   validVAuthSpec,
+					TemplateRef: test.ref,
+				},
+			}
+			ctx := context.Background()
+			if test.get != nil {
+				ctx = WithTemplates(ctx, test.get)
+			}
+			got := vs.Validate(ctx)
+			if !cmp.Equal(test.want.Error(), got.Error()) {
+				t.Errorf("Validate (-want, +got) = %v",
+					cmp.Diff(test.want.Error(), got.Error()))
+			}
+		})
+	}
+}
@@ -10,39 +10,607 @@ SPDX-License-Identifier: Apache-2.0
 package v1alpha1
 
 import (
+	v1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	intstr "k8s.io/apimachinery/pkg/util/intstr"
+	apis "knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VAPISpec) DeepCopyInto(out *VAPISpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VAPISpec.
+func (in *VAPISpec) DeepCopy() *VAPISpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VAPISpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VAdapterOverridesSpec) DeepCopyInto(out *VAdapterOverridesSpec) {
+	*out = *in
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]v1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]v1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VAdapterOverridesSpec.
+func (in *VAdapterOverridesSpec) DeepCopy() *VAdapterOverridesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VAdapterOverridesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VAddressSpec) DeepCopyInto(out *VAddressSpec) {
+	*out = *in
+	in.Address.DeepCopyInto(&out.Address)
+	out.SecretRef = in.SecretRef
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VAddressSpec.
+func (in *VAddressSpec) DeepCopy() *VAddressSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VAddressSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VAttributeMappingSpec) DeepCopyInto(out *VAttributeMappingSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VAttributeMappingSpec.
+func (in *VAttributeMappingSpec) DeepCopy() *VAttributeMappingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VAttributeMappingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VAuditSpec) DeepCopyInto(out *VAuditSpec) {
+	*out = *in
+	if in.Sink != nil {
+		in, out := &in.Sink, &out.Sink
+		*out = new(duckv1.Destination)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VAuditSpec.
+func (in *VAuditSpec) DeepCopy() *VAuditSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VAuditSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VAuthSpec) DeepCopyInto(out *VAuthSpec) {
 	*out = *in
-	in.Address.DeepCopyInto(&out.Address)
-	out.SecretRef = in.SecretRef
+	in.Address.DeepCopyInto(&out.Address)
+	if in.CACertsConfigMapRef != nil {
+		in, out := &in.CACertsConfigMapRef, &out.CACertsConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	out.SecretRef = in.SecretRef
+	if in.CredentialProvider != nil {
+		in, out := &in.CredentialProvider, &out.CredentialProvider
+		*out = new(VCredentialProviderSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VAuthSpec.
+func (in *VAuthSpec) DeepCopy() *VAuthSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VAuthSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VBindingSubjectStatus) DeepCopyInto(out *VBindingSubjectStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VBindingSubjectStatus.
+func (in *VBindingSubjectStatus) DeepCopy() *VBindingSubjectStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VBindingSubjectStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VCheckpointSpec) DeepCopyInto(out *VCheckpointSpec) {
+	*out = *in
+	if in.ReplayFrom != nil {
+		in, out := &in.ReplayFrom, &out.ReplayFrom
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VCheckpointSpec.
+func (in *VCheckpointSpec) DeepCopy() *VCheckpointSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VCheckpointSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VConditionTransition) DeepCopyInto(out *VConditionTransition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VConditionTransition.
+func (in *VConditionTransition) DeepCopy() *VConditionTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(VConditionTransition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VCredentialProviderSpec) DeepCopyInto(out *VCredentialProviderSpec) {
+	*out = *in
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VVaultSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VCredentialProviderSpec.
+func (in *VCredentialProviderSpec) DeepCopy() *VCredentialProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VCredentialProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VDeliverySpec) DeepCopyInto(out *VDeliverySpec) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.HeadersSecretRef != nil {
+		in, out := &in.HeadersSecretRef, &out.HeadersSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.CACertsConfigMapRef != nil {
+		in, out := &in.CACertsConfigMapRef, &out.CACertsConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.DeadLetterSink != nil {
+		in, out := &in.DeadLetterSink, &out.DeadLetterSink
+		*out = new(duckv1.Destination)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VDeliverySpec.
+func (in *VDeliverySpec) DeepCopy() *VDeliverySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VDeliverySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VDeploymentSpec) DeepCopyInto(out *VDeploymentSpec) {
+	*out = *in
+	if in.MaxSurge != nil {
+		in, out := &in.MaxSurge, &out.MaxSurge
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(VPodDisruptionBudgetSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VDeploymentSpec.
+func (in *VDeploymentSpec) DeepCopy() *VDeploymentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VDeploymentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VEnrichmentSpec) DeepCopyInto(out *VEnrichmentSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VEnrichmentSpec.
+func (in *VEnrichmentSpec) DeepCopy() *VEnrichmentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VEnrichmentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VEventCollectorSpec) DeepCopyInto(out *VEventCollectorSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VEventCollectorSpec.
+func (in *VEventCollectorSpec) DeepCopy() *VEventCollectorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VEventCollectorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VFilterSpec) DeepCopyInto(out *VFilterSpec) {
+	*out = *in
+	if in.EventTypes != nil {
+		in, out := &in.EventTypes, &out.EventTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VFilterSpec.
+func (in *VFilterSpec) DeepCopy() *VFilterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VFilterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VHeartbeatSpec) DeepCopyInto(out *VHeartbeatSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VHeartbeatSpec.
+func (in *VHeartbeatSpec) DeepCopy() *VHeartbeatSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VHeartbeatSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VKafkaSASLSpec) DeepCopyInto(out *VKafkaSASLSpec) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VKafkaSASLSpec.
+func (in *VKafkaSASLSpec) DeepCopy() *VKafkaSASLSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VKafkaSASLSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VKafkaSpec) DeepCopyInto(out *VKafkaSpec) {
+	*out = *in
+	if in.BootstrapServers != nil {
+		in, out := &in.BootstrapServers, &out.BootstrapServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SASL != nil {
+		in, out := &in.SASL, &out.SASL
+		*out = new(VKafkaSASLSpec)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(VKafkaTLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VKafkaSpec.
+func (in *VKafkaSpec) DeepCopy() *VKafkaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VKafkaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VKafkaTLSSpec) DeepCopyInto(out *VKafkaTLSSpec) {
+	*out = *in
+	if in.CACertsConfigMapRef != nil {
+		in, out := &in.CACertsConfigMapRef, &out.CACertsConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VKafkaTLSSpec.
+func (in *VKafkaTLSSpec) DeepCopy() *VKafkaTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VKafkaTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMirrorSpec) DeepCopyInto(out *VMirrorSpec) {
+	*out = *in
+	in.Sink.DeepCopyInto(&out.Sink)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMirrorSpec.
+func (in *VMirrorSpec) DeepCopy() *VMirrorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VMirrorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMirrorStatus) DeepCopyInto(out *VMirrorStatus) {
+	*out = *in
+	if in.LastDeliveredTime != nil {
+		in, out := &in.LastDeliveredTime, &out.LastDeliveredTime
+		*out = new(apis.VolatileTime)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMirrorStatus.
+func (in *VMirrorStatus) DeepCopy() *VMirrorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VMirrorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPodDisruptionBudgetSpec) DeepCopyInto(out *VPodDisruptionBudgetSpec) {
+	*out = *in
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPodDisruptionBudgetSpec.
+func (in *VPodDisruptionBudgetSpec) DeepCopy() *VPodDisruptionBudgetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VPodDisruptionBudgetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VPropertyWatch) DeepCopyInto(out *VPropertyWatch) {
+	*out = *in
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VPropertyWatch.
+func (in *VPropertyWatch) DeepCopy() *VPropertyWatch {
+	if in == nil {
+		return nil
+	}
+	out := new(VPropertyWatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VRateLimitSpec) DeepCopyInto(out *VRateLimitSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VRateLimitSpec.
+func (in *VRateLimitSpec) DeepCopy() *VRateLimitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VRateLimitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VRedactionSpec) DeepCopyInto(out *VRedactionSpec) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VRedactionSpec.
+func (in *VRedactionSpec) DeepCopy() *VRedactionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VRedactionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSamplingSpec) DeepCopyInto(out *VSamplingSpec) {
+	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VAuthSpec.
-func (in *VAuthSpec) DeepCopy() *VAuthSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSamplingSpec.
+func (in *VSamplingSpec) DeepCopy() *VSamplingSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VAuthSpec)
+	out := new(VSamplingSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VCheckpointSpec) DeepCopyInto(out *VCheckpointSpec) {
+func (in *VScopeSpec) DeepCopyInto(out *VScopeSpec) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VCheckpointSpec.
-func (in *VCheckpointSpec) DeepCopy() *VCheckpointSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VScopeSpec.
+func (in *VScopeSpec) DeepCopy() *VScopeSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(VCheckpointSpec)
+	out := new(VScopeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSinkSpec) DeepCopyInto(out *VSinkSpec) {
+	*out = *in
+	in.Destination.DeepCopyInto(&out.Destination)
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = new(VFilterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSinkSpec.
+func (in *VSinkSpec) DeepCopy() *VSinkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VSinkSpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -130,6 +698,11 @@ func (in *VSphereBindingSpec) DeepCopy() *VSphereBindingSpec {
 func (in *VSphereBindingStatus) DeepCopyInto(out *VSphereBindingStatus) {
 	*out = *in
 	in.Status.DeepCopyInto(&out.Status)
+	if in.Subjects != nil {
+		in, out := &in.Subjects, &out.Subjects
+		*out = make([]VBindingSubjectStatus, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -143,6 +716,109 @@ func (in *VSphereBindingStatus) DeepCopy() *VSphereBindingStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereInventorySource) DeepCopyInto(out *VSphereInventorySource) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereInventorySource.
+func (in *VSphereInventorySource) DeepCopy() *VSphereInventorySource {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereInventorySource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VSphereInventorySource) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereInventorySourceList) DeepCopyInto(out *VSphereInventorySourceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VSphereInventorySource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereInventorySourceList.
+func (in *VSphereInventorySourceList) DeepCopy() *VSphereInventorySourceList {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereInventorySourceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VSphereInventorySourceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereInventorySourceSpec) DeepCopyInto(out *VSphereInventorySourceSpec) {
+	*out = *in
+	in.SourceSpec.DeepCopyInto(&out.SourceSpec)
+	in.VAuthSpec.DeepCopyInto(&out.VAuthSpec)
+	if in.Watches != nil {
+		in, out := &in.Watches, &out.Watches
+		*out = make([]VPropertyWatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereInventorySourceSpec.
+func (in *VSphereInventorySourceSpec) DeepCopy() *VSphereInventorySourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereInventorySourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereInventorySourceStatus) DeepCopyInto(out *VSphereInventorySourceStatus) {
+	*out = *in
+	in.SourceStatus.DeepCopyInto(&out.SourceStatus)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereInventorySourceStatus.
+func (in *VSphereInventorySourceStatus) DeepCopy() *VSphereInventorySourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereInventorySourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VSphereSource) DeepCopyInto(out *VSphereSource) {
 	*out = *in
@@ -209,7 +885,116 @@ func (in *VSphereSourceSpec) DeepCopyInto(out *VSphereSourceSpec) {
 	*out = *in
 	in.SourceSpec.DeepCopyInto(&out.SourceSpec)
 	in.VAuthSpec.DeepCopyInto(&out.VAuthSpec)
-	out.CheckpointConfig = in.CheckpointConfig
+	in.CheckpointConfig.DeepCopyInto(&out.CheckpointConfig)
+	if in.EventCollector != nil {
+		in, out := &in.EventCollector, &out.EventCollector
+		*out = new(VEventCollectorSpec)
+		**out = **in
+	}
+	if in.VCenterAPI != nil {
+		in, out := &in.VCenterAPI, &out.VCenterAPI
+		*out = new(VAPISpec)
+		**out = **in
+	}
+	if in.Scope != nil {
+		in, out := &in.Scope, &out.Scope
+		*out = new(VScopeSpec)
+		**out = **in
+	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]VAddressSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FallbackAddress != nil {
+		in, out := &in.FallbackAddress, &out.FallbackAddress
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtensionAttributes != nil {
+		in, out := &in.ExtensionAttributes, &out.ExtensionAttributes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Delivery != nil {
+		in, out := &in.Delivery, &out.Delivery
+		*out = new(VDeliverySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = new(VFilterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sinks != nil {
+		in, out := &in.Sinks, &out.Sinks
+		*out = make([]VSinkSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		*out = new(VMirrorSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Kafka != nil {
+		in, out := &in.Kafka, &out.Kafka
+		*out = new(VKafkaSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Transform != nil {
+		in, out := &in.Transform, &out.Transform
+		*out = new(VTransformSpec)
+		**out = **in
+	}
+	if in.Redaction != nil {
+		in, out := &in.Redaction, &out.Redaction
+		*out = new(VRedactionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Enrichment != nil {
+		in, out := &in.Enrichment, &out.Enrichment
+		*out = new(VEnrichmentSpec)
+		**out = **in
+	}
+	if in.AttributeMapping != nil {
+		in, out := &in.AttributeMapping, &out.AttributeMapping
+		*out = new(VAttributeMappingSpec)
+		**out = **in
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(VRateLimitSpec)
+		**out = **in
+	}
+	if in.Sampling != nil {
+		in, out := &in.Sampling, &out.Sampling
+		*out = new(VSamplingSpec)
+		**out = **in
+	}
+	if in.Heartbeat != nil {
+		in, out := &in.Heartbeat, &out.Heartbeat
+		*out = new(VHeartbeatSpec)
+		**out = **in
+	}
+	if in.Audit != nil {
+		in, out := &in.Audit, &out.Audit
+		*out = new(VAuditSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Deployment != nil {
+		in, out := &in.Deployment, &out.Deployment
+		*out = new(VDeploymentSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdapterOverrides != nil {
+		in, out := &in.AdapterOverrides, &out.AdapterOverrides
+		*out = new(VAdapterOverridesSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -227,6 +1012,60 @@ func (in *VSphereSourceSpec) DeepCopy() *VSphereSourceSpec {
 func (in *VSphereSourceStatus) DeepCopyInto(out *VSphereSourceStatus) {
 	*out = *in
 	in.SourceStatus.DeepCopyInto(&out.SourceStatus)
+	if in.ConditionHistory != nil {
+		in, out := &in.ConditionHistory, &out.ConditionHistory
+		*out = make([]VConditionTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SinkAudience != nil {
+		in, out := &in.SinkAudience, &out.SinkAudience
+		*out = new(string)
+		**out = **in
+	}
+	if in.SinkURIs != nil {
+		in, out := &in.SinkURIs, &out.SinkURIs
+		*out = make([]apis.URL, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DeadLetterSinkURI != nil {
+		in, out := &in.DeadLetterSinkURI, &out.DeadLetterSinkURI
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuditSinkURI != nil {
+		in, out := &in.AuditSinkURI, &out.AuditSinkURI
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MirrorSinkURI != nil {
+		in, out := &in.MirrorSinkURI, &out.MirrorSinkURI
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SinkCACerts != nil {
+		in, out := &in.SinkCACerts, &out.SinkCACerts
+		*out = new(string)
+		**out = **in
+	}
+	if in.LastDeliveredTime != nil {
+		in, out := &in.LastDeliveredTime, &out.LastDeliveredTime
+		*out = new(apis.VolatileTime)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CheckpointTime != nil {
+		in, out := &in.CheckpointTime, &out.CheckpointTime
+		*out = new(apis.VolatileTime)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		*out = new(VMirrorStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -239,3 +1078,167 @@ func (in *VSphereSourceStatus) DeepCopy() *VSphereSourceStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereSourceTemplate) DeepCopyInto(out *VSphereSourceTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereSourceTemplate.
+func (in *VSphereSourceTemplate) DeepCopy() *VSphereSourceTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereSourceTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VSphereSourceTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereSourceTemplateList) DeepCopyInto(out *VSphereSourceTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VSphereSourceTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereSourceTemplateList.
+func (in *VSphereSourceTemplateList) DeepCopy() *VSphereSourceTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereSourceTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VSphereSourceTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSphereSourceTemplateSpec) DeepCopyInto(out *VSphereSourceTemplateSpec) {
+	*out = *in
+	if in.Address != nil {
+		in, out := &in.Address, &out.Address
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SkipTLSVerify != nil {
+		in, out := &in.SkipTLSVerify, &out.SkipTLSVerify
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AllowInsecureAddress != nil {
+		in, out := &in.AllowInsecureAddress, &out.AllowInsecureAddress
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CACertsConfigMapRef != nil {
+		in, out := &in.CACertsConfigMapRef, &out.CACertsConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.CredentialProvider != nil {
+		in, out := &in.CredentialProvider, &out.CredentialProvider
+		*out = new(VCredentialProviderSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FallbackAddress != nil {
+		in, out := &in.FallbackAddress, &out.FallbackAddress
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VCenterAPI != nil {
+		in, out := &in.VCenterAPI, &out.VCenterAPI
+		*out = new(VAPISpec)
+		**out = **in
+	}
+	if in.CheckpointConfig != nil {
+		in, out := &in.CheckpointConfig, &out.CheckpointConfig
+		*out = new(VCheckpointSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Delivery != nil {
+		in, out := &in.Delivery, &out.Delivery
+		*out = new(VDeliverySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(VRateLimitSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSphereSourceTemplateSpec.
+func (in *VSphereSourceTemplateSpec) DeepCopy() *VSphereSourceTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VSphereSourceTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VTransformSpec) DeepCopyInto(out *VTransformSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VTransformSpec.
+func (in *VTransformSpec) DeepCopy() *VTransformSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VTransformSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VVaultSpec) DeepCopyInto(out *VVaultSpec) {
+	*out = *in
+	in.Address.DeepCopyInto(&out.Address)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VVaultSpec.
+func (in *VVaultSpec) DeepCopy() *VVaultSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VVaultSpec)
+	in.DeepCopyInto(out)
+	return out
+}
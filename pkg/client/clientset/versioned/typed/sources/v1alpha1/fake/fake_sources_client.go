@@ -21,10 +21,18 @@ func (c *FakeSourcesV1alpha1) VSphereBindings(namespace string) v1alpha1.VSphere
 	return &FakeVSphereBindings{c, namespace}
 }
 
+func (c *FakeSourcesV1alpha1) VSphereInventorySources(namespace string) v1alpha1.VSphereInventorySourceInterface {
+	return &FakeVSphereInventorySources{c, namespace}
+}
+
 func (c *FakeSourcesV1alpha1) VSphereSources(namespace string) v1alpha1.VSphereSourceInterface {
 	return &FakeVSphereSources{c, namespace}
 }
 
+func (c *FakeSourcesV1alpha1) VSphereSourceTemplates() v1alpha1.VSphereSourceTemplateInterface {
+	return &FakeVSphereSourceTemplates{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeSourcesV1alpha1) RESTClient() rest.Interface {
@@ -9,4 +9,8 @@ package v1alpha1
 
 type VSphereBindingExpansion interface{}
 
+type VSphereInventorySourceExpansion interface{}
+
 type VSphereSourceExpansion interface{}
+
+type VSphereSourceTemplateExpansion interface{}
@@ -16,7 +16,9 @@ import (
 type SourcesV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	VSphereBindingsGetter
+	VSphereInventorySourcesGetter
 	VSphereSourcesGetter
+	VSphereSourceTemplatesGetter
 }
 
 // SourcesV1alpha1Client is used to interact with features provided by the sources.tanzu.vmware.com group.
@@ -28,10 +30,18 @@ func (c *SourcesV1alpha1Client) VSphereBindings(namespace string) VSphereBinding
 	return newVSphereBindings(c, namespace)
 }
 
+func (c *SourcesV1alpha1Client) VSphereInventorySources(namespace string) VSphereInventorySourceInterface {
+	return newVSphereInventorySources(c, namespace)
+}
+
 func (c *SourcesV1alpha1Client) VSphereSources(namespace string) VSphereSourceInterface {
 	return newVSphereSources(c, namespace)
 }
 
+func (c *SourcesV1alpha1Client) VSphereSourceTemplates() VSphereSourceTemplateInterface {
+	return newVSphereSourceTemplates(c)
+}
+
 // NewForConfig creates a new SourcesV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*SourcesV1alpha1Client, error) {
 	config := *c
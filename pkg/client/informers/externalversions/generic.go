@@ -44,8 +44,12 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=sources.tanzu.vmware.com, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("vspherebindings"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Sources().V1alpha1().VSphereBindings().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("vsphereinventorysources"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Sources().V1alpha1().VSphereInventorySources().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("vspheresources"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Sources().V1alpha1().VSphereSources().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("vspheresourcetemplates"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Sources().V1alpha1().VSphereSourceTemplates().Informer()}, nil
 
 	}
 
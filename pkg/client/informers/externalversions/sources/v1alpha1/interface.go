@@ -15,8 +15,12 @@ import (
 type Interface interface {
 	// VSphereBindings returns a VSphereBindingInformer.
 	VSphereBindings() VSphereBindingInformer
+	// VSphereInventorySources returns a VSphereInventorySourceInformer.
+	VSphereInventorySources() VSphereInventorySourceInformer
 	// VSphereSources returns a VSphereSourceInformer.
 	VSphereSources() VSphereSourceInformer
+	// VSphereSourceTemplates returns a VSphereSourceTemplateInformer.
+	VSphereSourceTemplates() VSphereSourceTemplateInformer
 }
 
 type version struct {
@@ -35,7 +39,17 @@ func (v *version) VSphereBindings() VSphereBindingInformer {
 	return &vSphereBindingInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// VSphereInventorySources returns a VSphereInventorySourceInformer.
+func (v *version) VSphereInventorySources() VSphereInventorySourceInformer {
+	return &vSphereInventorySourceInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // VSphereSources returns a VSphereSourceInformer.
 func (v *version) VSphereSources() VSphereSourceInformer {
 	return &vSphereSourceInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// VSphereSourceTemplates returns a VSphereSourceTemplateInformer.
+func (v *version) VSphereSourceTemplates() VSphereSourceTemplateInformer {
+	return &vSphereSourceTemplateInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
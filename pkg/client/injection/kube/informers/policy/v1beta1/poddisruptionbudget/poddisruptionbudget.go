@@ -0,0 +1,42 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package poddisruptionbudget injects the informer of the policy/v1beta1
+// PodDisruptionBudgets, which knative.dev/pkg does not provide. It follows the
+// shape of the informers generated by injection-gen.
+package poddisruptionbudget
+
+import (
+	context "context"
+
+	v1beta1 "k8s.io/client-go/informers/policy/v1beta1"
+	factory "knative.dev/pkg/client/injection/kube/informers/factory"
+	controller "knative.dev/pkg/controller"
+	injection "knative.dev/pkg/injection"
+	logging "knative.dev/pkg/logging"
+)
+
+func init() {
+	injection.Default.RegisterInformer(withInformer)
+}
+
+// Key is used for associating the Informer inside the context.Context.
+type Key struct{}
+
+func withInformer(ctx context.Context) (context.Context, controller.Informer) {
+	f := factory.Get(ctx)
+	inf := f.Policy().V1beta1().PodDisruptionBudgets()
+	return context.WithValue(ctx, Key{}, inf), inf.Informer()
+}
+
+// Get extracts the typed informer from the context.
+func Get(ctx context.Context) v1beta1.PodDisruptionBudgetInformer {
+	untyped := ctx.Value(Key{})
+	if untyped == nil {
+		logging.FromContext(ctx).Panic(
+			"Unable to fetch k8s.io/client-go/informers/policy/v1beta1.PodDisruptionBudgetInformer from context.")
+	}
+	return untyped.(v1beta1.PodDisruptionBudgetInformer)
+}
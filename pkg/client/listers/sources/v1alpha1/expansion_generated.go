@@ -15,6 +15,14 @@ type VSphereBindingListerExpansion interface{}
 // VSphereBindingNamespaceLister.
 type VSphereBindingNamespaceListerExpansion interface{}
 
+// VSphereInventorySourceListerExpansion allows custom methods to be added to
+// VSphereInventorySourceLister.
+type VSphereInventorySourceListerExpansion interface{}
+
+// VSphereInventorySourceNamespaceListerExpansion allows custom methods to be added to
+// VSphereInventorySourceNamespaceLister.
+type VSphereInventorySourceNamespaceListerExpansion interface{}
+
 // VSphereSourceListerExpansion allows custom methods to be added to
 // VSphereSourceLister.
 type VSphereSourceListerExpansion interface{}
@@ -22,3 +30,7 @@ type VSphereSourceListerExpansion interface{}
 // VSphereSourceNamespaceListerExpansion allows custom methods to be added to
 // VSphereSourceNamespaceLister.
 type VSphereSourceNamespaceListerExpansion interface{}
+
+// VSphereSourceTemplateListerExpansion allows custom methods to be added to
+// VSphereSourceTemplateLister.
+type VSphereSourceTemplateListerExpansion interface{}
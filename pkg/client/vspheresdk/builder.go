@@ -0,0 +1,176 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresdk
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+)
+
+// SourceBuilder builds a VSphereSource fluently, e.g.
+//    source, err := vspheresdk.NewSource("ns", "source").
+//        Address("https://vcenter.example.com").
+//        SecretRef("vsphere-credentials").
+//        SinkRef("eventing.knative.dev/v1", "Broker", "default").
+//        Build()
+// The first error of the builder is returned by Build.
+type SourceBuilder struct {
+	source *v1alpha1.VSphereSource
+	err    error
+}
+
+// NewSource returns a builder of the source with the given namespace and name
+func NewSource(namespace, name string) *SourceBuilder {
+	return &SourceBuilder{source: &v1alpha1.VSphereSource{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "VSphereSource",
+		},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+	}}
+}
+
+// Address sets the URL of the vCenter of the source
+func (b *SourceBuilder) Address(address string) *SourceBuilder {
+	u, err := url.Parse(address)
+	if err != nil {
+		b.fail(fmt.Errorf("parse address: %w", err))
+		return b
+	}
+	b.source.Spec.Address = apis.URL(*u)
+	return b
+}
+
+// SkipTLSVerify disables the verification of the certificate of vCenter
+func (b *SourceBuilder) SkipTLSVerify() *SourceBuilder {
+	b.source.Spec.SkipTLSVerify = true
+	return b
+}
+
+// SecretRef sets the name of the secret with the vCenter credentials
+func (b *SourceBuilder) SecretRef(name string) *SourceBuilder {
+	b.source.Spec.SecretRef = corev1.LocalObjectReference{Name: name}
+	return b
+}
+
+// SinkURI sets the URI the events are sent to
+func (b *SourceBuilder) SinkURI(uri string) *SourceBuilder {
+	u, err := apis.ParseURL(uri)
+	if err != nil {
+		b.fail(fmt.Errorf("parse sink URI: %w", err))
+		return b
+	}
+	b.source.Spec.Sink.URI = u
+	return b
+}
+
+// SinkRef sets the addressable the events are sent to, which is in the
+// namespace of the source
+func (b *SourceBuilder) SinkRef(apiVersion, kind, name string) *SourceBuilder {
+	b.source.Spec.Sink.Ref = &duckv1.KReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  b.source.Namespace,
+		Name:       name,
+	}
+	return b
+}
+
+// LogOnly logs the events in the adapter instead of sending them
+func (b *SourceBuilder) LogOnly() *SourceBuilder {
+	b.source.Spec.LogOnly = true
+	return b
+}
+
+// Checkpoint sets the maximum age of the events replayed from the checkpoint
+// and the period between checkpoints
+func (b *SourceBuilder) Checkpoint(maxAge, period time.Duration) *SourceBuilder {
+	b.source.Spec.CheckpointConfig.MaxAgeSeconds = int64(maxAge.Seconds())
+	b.source.Spec.CheckpointConfig.PeriodSeconds = int64(period.Seconds())
+	return b
+}
+
+// IncludeTasks also sends events for the lifecycle of vSphere tasks
+func (b *SourceBuilder) IncludeTasks() *SourceBuilder {
+	b.source.Spec.IncludeTasks = true
+	return b
+}
+
+// EventTypes only sends the events with a type matching one of the given glob
+// patterns
+func (b *SourceBuilder) EventTypes(patterns ...string) *SourceBuilder {
+	b.filter().EventTypes = append(b.filter().EventTypes, patterns...)
+	return b
+}
+
+// CELFilter only sends the events for which the given CEL expression returns
+// true
+func (b *SourceBuilder) CELFilter(expression string) *SourceBuilder {
+	b.filter().CEL = expression
+	return b
+}
+
+// Label sets a label of the source
+func (b *SourceBuilder) Label(key, value string) *SourceBuilder {
+	if b.source.Labels == nil {
+		b.source.Labels = map[string]string{}
+	}
+	b.source.Labels[key] = value
+	return b
+}
+
+// Annotation sets an annotation of the source
+func (b *SourceBuilder) Annotation(key, value string) *SourceBuilder {
+	if b.source.Annotations == nil {
+		b.source.Annotations = map[string]string{}
+	}
+	b.source.Annotations[key] = value
+	return b
+}
+
+// Spec applies the given function to the spec of the source, for the fields
+// without a method of the builder
+func (b *SourceBuilder) Spec(f func(*v1alpha1.VSphereSourceSpec)) *SourceBuilder {
+	f(&b.source.Spec)
+	return b
+}
+
+// Build returns the source with the defaults of the webhook, or the first
+// error of the builder or the validation of the webhook
+func (b *SourceBuilder) Build() (*v1alpha1.VSphereSource, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	source := b.source.DeepCopy()
+	ctx := context.Background()
+	source.SetDefaults(ctx)
+	if err := source.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("invalid source: %w", err)
+	}
+	return source, nil
+}
+
+func (b *SourceBuilder) filter() *v1alpha1.VFilterSpec {
+	if b.source.Spec.Filter == nil {
+		b.source.Spec.Filter = &v1alpha1.VFilterSpec{}
+	}
+	return b.source.Spec.Filter
+}
+
+func (b *SourceBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
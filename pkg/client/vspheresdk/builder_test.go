@@ -0,0 +1,81 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresdk
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+)
+
+func TestSourceBuilder(t *testing.T) {
+	source, err := NewSource("ns", "source").
+		Address("https://vcenter.example.com").
+		SecretRef("vsphere-credentials").
+		SinkRef("eventing.knative.dev/v1", "Broker", "default").
+		Checkpoint(time.Hour, 30*time.Second).
+		IncludeTasks().
+		EventTypes("com.vmware.vsphere.alarm.*").
+		Label("team", "infra").
+		Spec(func(spec *v1alpha1.VSphereSourceSpec) {
+			spec.Locale = "de"
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if source.Namespace != "ns" || source.Name != "source" || source.Kind != "VSphereSource" || source.Labels["team"] != "infra" {
+		t.Errorf("Build() metadata = %+v %+v", source.TypeMeta, source.ObjectMeta)
+	}
+	spec := source.Spec
+	if spec.Address != (apis.URL{Scheme: "https", Host: "vcenter.example.com"}) || spec.SecretRef.Name != "vsphere-credentials" {
+		t.Errorf("Build() vCenter = %s with %s", spec.Address.String(), spec.SecretRef.Name)
+	}
+	wantSink := duckv1.Destination{Ref: &duckv1.KReference{APIVersion: "eventing.knative.dev/v1", Kind: "Broker", Namespace: "ns", Name: "default"}}
+	if diff := cmp.Diff(wantSink, spec.Sink); diff != "" {
+		t.Errorf("Build() sink (-want, +got) = %s", diff)
+	}
+	if spec.CheckpointConfig.MaxAgeSeconds != 3600 || spec.CheckpointConfig.PeriodSeconds != 30 || !spec.IncludeTasks || spec.Locale != "de" {
+		t.Errorf("Build() spec = %+v", spec)
+	}
+	if !cmp.Equal(spec.Filter.EventTypes, []string{"com.vmware.vsphere.alarm.*"}) {
+		t.Errorf("Build() event types = %v", spec.Filter.EventTypes)
+	}
+}
+
+func TestSourceBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *SourceBuilder
+		wantErr string
+	}{{
+		name:    "invalid address",
+		builder: NewSource("ns", "source").Address("https://vcenter example.com").SecretRef("creds").SinkURI("http://sink.example.com"),
+		wantErr: "parse address",
+	}, {
+		name:    "invalid sink",
+		builder: NewSource("ns", "source").Address("https://vcenter.example.com").SecretRef("creds").SinkURI("://sink"),
+		wantErr: "parse sink URI",
+	}, {
+		name:    "invalid source",
+		builder: NewSource("ns", "source").Address("https://vcenter.example.com").SinkURI("http://sink.example.com"),
+		wantErr: "invalid source",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.builder.Build(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Build() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
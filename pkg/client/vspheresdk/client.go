@@ -0,0 +1,245 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package vspheresdk manages VSphereSources programmatically on top of the
+// generated clientset, for platform controllers and test suites embedding
+// this project:
+//    client, err := vspheresdk.NewForConfig(restConfig)
+//    source, err := vspheresdk.NewSource("ns", "source").Address(address).
+//        SecretRef("vsphere-credentials").SinkURI(sink).Build()
+//    applied, err := client.Apply(ctx, source)
+//    ready, err := client.WaitForReady(ctx, applied.Namespace, applied.Name, nil)
+package vspheresdk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+	"knative.dev/pkg/apis"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned"
+)
+
+// PollInterval is the interval between the polls of WaitForReady
+var PollInterval = time.Second
+
+// Client manages VSphereSources and reads the Kubernetes events about them
+type Client struct {
+	kube    kubernetes.Interface
+	vsphere versioned.Interface
+}
+
+// New returns a client using the given clientsets
+func New(kube kubernetes.Interface, vsphere versioned.Interface) *Client {
+	return &Client{kube: kube, vsphere: vsphere}
+}
+
+// NewForConfig returns a client for the cluster of the given config
+func NewForConfig(config *rest.Config) (*Client, error) {
+	kube, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	vsphere, err := versioned.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return New(kube, vsphere), nil
+}
+
+// Create creates the given source
+func (c *Client) Create(ctx context.Context, source *v1alpha1.VSphereSource) (*v1alpha1.VSphereSource, error) {
+	created, err := c.vsphere.SourcesV1alpha1().VSphereSources(source.Namespace).Create(ctx, source, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("create source %s/%s: %w", source.Namespace, source.Name, err)
+	}
+	return created, nil
+}
+
+// Apply creates the given source, or updates the spec, labels and annotations
+// of the existing source of the same name, retrying on conflicts. The labels
+// and annotations of the given source are merged into the existing ones.
+func (c *Client) Apply(ctx context.Context, source *v1alpha1.VSphereSource) (*v1alpha1.VSphereSource, error) {
+	sources := c.vsphere.SourcesV1alpha1().VSphereSources(source.Namespace)
+
+	var applied *v1alpha1.VSphereSource
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing, err := sources.Get(ctx, source.Name, metav1.GetOptions{})
+		if apierrs.IsNotFound(err) {
+			applied, err = sources.Create(ctx, source, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		existing.Spec = *source.Spec.DeepCopy()
+		existing.Labels = merge(existing.Labels, source.Labels)
+		existing.Annotations = merge(existing.Annotations, source.Annotations)
+		applied, err = sources.Update(ctx, existing, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("apply source %s/%s: %w", source.Namespace, source.Name, err)
+	}
+	return applied, nil
+}
+
+// Delete deletes the source of the given name, which is not an error if it
+// does not exist
+func (c *Client) Delete(ctx context.Context, namespace, name string) error {
+	err := c.vsphere.SourcesV1alpha1().VSphereSources(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("delete source %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
+
+// ConditionFunc is called by WaitForReady with each new or changed condition
+// of the source
+type ConditionFunc func(source *v1alpha1.VSphereSource, condition apis.Condition)
+
+// WaitForReady polls the source of the given name until the controller
+// reconciled its current generation and it is ready, or ctx is done, and
+// returns the last polled source. onCondition is optional.
+func (c *Client) WaitForReady(ctx context.Context, namespace, name string, onCondition ConditionFunc) (*v1alpha1.VSphereSource, error) {
+	sources := c.vsphere.SourcesV1alpha1().VSphereSources(namespace)
+	seen := map[apis.ConditionType]apis.Condition{}
+
+	var source *v1alpha1.VSphereSource
+	err := wait.PollImmediateUntil(PollInterval, func() (bool, error) {
+		polled, err := sources.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("get source %s/%s: %w", namespace, name, err)
+		}
+		source = polled
+
+		for _, cond := range source.Status.Conditions {
+			if previous, ok := seen[cond.Type]; ok && previous.Status == cond.Status &&
+				previous.Reason == cond.Reason && previous.Message == cond.Message {
+				continue
+			}
+			seen[cond.Type] = cond
+			if onCondition != nil {
+				onCondition(source, cond)
+			}
+		}
+		return source.Status.ObservedGeneration >= source.Generation && source.Status.IsReady(), nil
+	}, ctx.Done())
+
+	if err == wait.ErrWaitTimeout {
+		err = fmt.Errorf("source %s/%s is not ready: %w", namespace, name, ctx.Err())
+		if source != nil {
+			if ready := source.Status.GetCondition(apis.ConditionReady); ready != nil && ready.Message != "" {
+				err = fmt.Errorf("%w: %s", err, ready.Message)
+			}
+		}
+	}
+	return source, err
+}
+
+// EventFunc is called by TailEvents with each Kubernetes event about the
+// source
+type EventFunc func(event *corev1.Event)
+
+// TailEvents calls onEvent with the Kubernetes events about the source of the
+// given name, the existing ones first, least recent first, and then the new
+// and updated ones as they are emitted, until ctx is done. Events about a
+// deleted source of the same name are left out.
+func (c *Client) TailEvents(ctx context.Context, namespace, name string, onEvent EventFunc) error {
+	source, err := c.vsphere.SourcesV1alpha1().VSphereSources(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get source %s/%s: %w", namespace, name, err)
+	}
+	related := func(e *corev1.Event) bool {
+		o := e.InvolvedObject
+		return o.Kind == "VSphereSource" && o.Name == name && (o.UID == "" || o.UID == source.UID)
+	}
+
+	events := c.kube.CoreV1().Events(namespace)
+	opts := metav1.ListOptions{FieldSelector: fields.Set{
+		"involvedObject.kind": "VSphereSource",
+		"involvedObject.name": name,
+	}.AsSelector().String()}
+	list, err := events.List(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("list events: %w", err)
+	}
+	existing := make([]corev1.Event, 0, len(list.Items))
+	for _, e := range list.Items {
+		if related(&e) {
+			existing = append(existing, e)
+		}
+	}
+	sort.SliceStable(existing, func(i, j int) bool {
+		return eventTime(&existing[i]).Before(eventTime(&existing[j]))
+	})
+	for i := range existing {
+		onEvent(&existing[i])
+	}
+
+	opts.ResourceVersion = list.ResourceVersion
+	w, err := events.Watch(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("watch events: %w", err)
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case we, ok := <-w.ResultChan():
+			if !ok {
+				return fmt.Errorf("watch of the events about source %s/%s closed", namespace, name)
+			}
+			switch we.Type {
+			case watch.Added, watch.Modified:
+				if e, ok := we.Object.(*corev1.Event); ok && related(e) {
+					onEvent(e)
+				}
+			case watch.Error:
+				return fmt.Errorf("watch events: %w", apierrs.FromObject(we.Object))
+			}
+		}
+	}
+}
+
+// eventTime returns the time the given event was last seen
+func eventTime(e *corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
+
+// merge returns the given labels or annotations with the given overrides
+func merge(m, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return m
+	}
+	if m == nil {
+		m = make(map[string]string, len(overrides))
+	}
+	for k, v := range overrides {
+		m[k] = v
+	}
+	return m
+}
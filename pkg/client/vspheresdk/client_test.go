@@ -0,0 +1,176 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresdk
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	vspherefake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+)
+
+func newTestSource(t *testing.T, sink string) *v1alpha1.VSphereSource {
+	source, err := NewSource("ns", "source").
+		Address("https://vcenter.example.com").
+		SecretRef("vsphere-credentials").
+		SinkURI(sink).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source
+}
+
+func TestClientApply(t *testing.T) {
+	vsphere := vspherefake.NewSimpleClientset()
+	c := New(k8sfake.NewSimpleClientset(), vsphere)
+	ctx := context.Background()
+
+	created, err := c.Apply(ctx, newTestSource(t, "http://sink.example.com"))
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	created.Labels = map[string]string{"team": "infra"}
+	if _, err = vsphere.SourcesV1alpha1().VSphereSources("ns").Update(ctx, created, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	updated := newTestSource(t, "http://other-sink.example.com")
+	updated.Labels = map[string]string{"env": "prod"}
+	applied, err := c.Apply(ctx, updated)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := applied.Spec.Sink.URI.String(); got != "http://other-sink.example.com" {
+		t.Errorf("Apply() sink = %s, want http://other-sink.example.com", got)
+	}
+	if want := map[string]string{"team": "infra", "env": "prod"}; !cmp.Equal(applied.Labels, want) {
+		t.Errorf("Apply() labels = %v, want %v", applied.Labels, want)
+	}
+
+	if err = c.Delete(ctx, "ns", "source"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err = c.Delete(ctx, "ns", "source"); err != nil {
+		t.Errorf("Delete() of a missing source error = %v", err)
+	}
+}
+
+func TestClientWaitForReady(t *testing.T) {
+	defaultInterval := PollInterval
+	defer func() { PollInterval = defaultInterval }()
+	PollInterval = time.Millisecond
+
+	source := newTestSource(t, "http://sink.example.com")
+	source.Generation = 2
+	withReady := func(status corev1.ConditionStatus, reason, message string, observed int64) *v1alpha1.VSphereSource {
+		s := source.DeepCopy()
+		s.Status.ObservedGeneration = observed
+		s.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionReady, Status: status, Reason: reason, Message: message}}
+		return s
+	}
+
+	t.Run("ready", func(t *testing.T) {
+		polls := []*v1alpha1.VSphereSource{
+			withReady(corev1.ConditionTrue, "", "", 1),
+			withReady(corev1.ConditionFalse, "SecretNotFound", "secret not found", 2),
+			withReady(corev1.ConditionFalse, "SecretNotFound", "secret not found", 2),
+			withReady(corev1.ConditionTrue, "", "", 2),
+		}
+		vsphere := vspherefake.NewSimpleClientset()
+		vsphere.PrependReactor("get", "vspheresources", func(clientgotesting.Action) (bool, runtime.Object, error) {
+			polled := polls[0]
+			if len(polls) > 1 {
+				polls = polls[1:]
+			}
+			return true, polled, nil
+		})
+
+		var reasons []string
+		ready, err := New(k8sfake.NewSimpleClientset(), vsphere).WaitForReady(context.Background(), "ns", "source",
+			func(_ *v1alpha1.VSphereSource, cond apis.Condition) {
+				reasons = append(reasons, string(cond.Status)+"/"+cond.Reason)
+			})
+		if err != nil {
+			t.Fatalf("WaitForReady() error = %v", err)
+		}
+		if !ready.Status.IsReady() {
+			t.Errorf("WaitForReady() = %+v, want ready", ready.Status)
+		}
+		if want := []string{"True/", "False/SecretNotFound", "True/"}; !cmp.Equal(reasons, want) {
+			t.Errorf("WaitForReady() conditions = %v, want %v", reasons, want)
+		}
+	})
+
+	t.Run("not ready", func(t *testing.T) {
+		vsphere := vspherefake.NewSimpleClientset(withReady(corev1.ConditionFalse, "SecretNotFound", "secret not found", 2))
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := New(k8sfake.NewSimpleClientset(), vsphere).WaitForReady(ctx, "ns", "source", nil)
+		if err == nil || !strings.HasSuffix(err.Error(), "secret not found") {
+			t.Errorf("WaitForReady() error = %v, want the message of the ready condition", err)
+		}
+	})
+}
+
+func TestClientTailEvents(t *testing.T) {
+	source := newTestSource(t, "http://sink.example.com")
+	source.UID = "source-uid"
+	event := func(name, uid string, ago time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "ns", Name: name},
+			InvolvedObject: corev1.ObjectReference{Kind: "VSphereSource", Namespace: "ns", Name: "source", UID: types.UID(uid)},
+			LastTimestamp:  metav1.NewTime(time.Now().Add(-ago)),
+		}
+	}
+	kube := k8sfake.NewSimpleClientset(
+		event("recent", "source-uid", time.Minute),
+		event("old", "source-uid", time.Hour),
+		// about a deleted source of the same name
+		event("deleted", "old-uid", time.Minute),
+	)
+	// the fake watch of the clientset does not replay the events created
+	// between the list and the watch
+	w := watch.NewFake()
+	kube.PrependWatchReactor("events", func(clientgotesting.Action) (bool, watch.Interface, error) {
+		return true, w, nil
+	})
+	go func() {
+		w.Add(event("deleted-new", "old-uid", 0))
+		w.Add(event("new", "source-uid", 0))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var names []string
+	err := New(kube, vspherefake.NewSimpleClientset(source)).TailEvents(ctx, "ns", "source", func(e *corev1.Event) {
+		names = append(names, e.Name)
+		if e.Name == "new" {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("TailEvents() error = %v", err)
+	}
+	if want := []string{"old", "recent", "new"}; !cmp.Equal(names, want) {
+		t.Errorf("TailEvents() events = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package scope restricts the controllers and webhooks to the resources of
+// a list of namespaces, e.g. to run an installation per team in multi-tenant
+// clusters.
+package scope
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	informers "github.com/vmware-tanzu/sources-for-knative/pkg/client/informers/externalversions/sources/v1alpha1"
+	vspherebindinginformer "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/informers/sources/v1alpha1/vspherebinding"
+	inventoryinformer "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/informers/sources/v1alpha1/vsphereinventorysource"
+	vsphereinformer "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/informers/sources/v1alpha1/vspheresource"
+	listers "github.com/vmware-tanzu/sources-for-knative/pkg/client/listers/sources/v1alpha1"
+)
+
+type namespacesKey struct{}
+
+// ParseNamespaces parses a comma-separated list of namespaces, as configured
+// with the VSPHERE_NAMESPACES environment variable. An empty list means all
+// namespaces.
+func ParseNamespaces(s string) []string {
+	var namespaces []string
+	for _, ns := range strings.Split(s, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+// WithNamespaces returns a context in which the informers of our resources
+// only list the resources in the given namespaces. Resources in other
+// namespaces look as if they did not exist, so that the reconcilers and the
+// binding webhook ignore them. If no namespaces are given, the context is
+// returned unchanged.
+func WithNamespaces(ctx context.Context, namespaces []string) context.Context {
+	if len(namespaces) == 0 {
+		return ctx
+	}
+	s := sets.NewString(namespaces...)
+
+	ctx = context.WithValue(ctx, namespacesKey{}, s)
+	ctx = context.WithValue(ctx, vsphereinformer.Key{}, &vsphereSourceInformer{
+		VSphereSourceInformer: vsphereinformer.Get(ctx),
+		namespaces:            s,
+	})
+	ctx = context.WithValue(ctx, vspherebindinginformer.Key{}, &vsphereBindingInformer{
+		VSphereBindingInformer: vspherebindinginformer.Get(ctx),
+		namespaces:             s,
+	})
+	ctx = context.WithValue(ctx, inventoryinformer.Key{}, &vsphereInventorySourceInformer{
+		VSphereInventorySourceInformer: inventoryinformer.Get(ctx),
+		namespaces:                     s,
+	})
+	return ctx
+}
+
+// Contains returns whether resources in the given namespace are in the scope
+// of the given context.
+func Contains(ctx context.Context, namespace string) bool {
+	s, ok := ctx.Value(namespacesKey{}).(sets.String)
+	return !ok || s.Has(namespace)
+}
+
+// empty is the indexer of the listers returned for namespaces out of scope
+var empty = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+type vsphereSourceInformer struct {
+	informers.VSphereSourceInformer
+	namespaces sets.String
+}
+
+func (i *vsphereSourceInformer) Lister() listers.VSphereSourceLister {
+	return &vsphereSourceLister{VSphereSourceLister: i.VSphereSourceInformer.Lister(), namespaces: i.namespaces}
+}
+
+type vsphereSourceLister struct {
+	listers.VSphereSourceLister
+	namespaces sets.String
+}
+
+func (l *vsphereSourceLister) List(selector labels.Selector) ([]*v1alpha1.VSphereSource, error) {
+	all, err := l.VSphereSourceLister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	var ret []*v1alpha1.VSphereSource
+	for _, elt := range all {
+		if l.namespaces.Has(elt.Namespace) {
+			ret = append(ret, elt)
+		}
+	}
+	return ret, nil
+}
+
+func (l *vsphereSourceLister) VSphereSources(namespace string) listers.VSphereSourceNamespaceLister {
+	if !l.namespaces.Has(namespace) {
+		return listers.NewVSphereSourceLister(empty).VSphereSources(namespace)
+	}
+	return l.VSphereSourceLister.VSphereSources(namespace)
+}
+
+type vsphereBindingInformer struct {
+	informers.VSphereBindingInformer
+	namespaces sets.String
+}
+
+func (i *vsphereBindingInformer) Lister() listers.VSphereBindingLister {
+	return &vsphereBindingLister{VSphereBindingLister: i.VSphereBindingInformer.Lister(), namespaces: i.namespaces}
+}
+
+type vsphereBindingLister struct {
+	listers.VSphereBindingLister
+	namespaces sets.String
+}
+
+func (l *vsphereBindingLister) List(selector labels.Selector) ([]*v1alpha1.VSphereBinding, error) {
+	all, err := l.VSphereBindingLister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	var ret []*v1alpha1.VSphereBinding
+	for _, elt := range all {
+		if l.namespaces.Has(elt.Namespace) {
+			ret = append(ret, elt)
+		}
+	}
+	return ret, nil
+}
+
+func (l *vsphereBindingLister) VSphereBindings(namespace string) listers.VSphereBindingNamespaceLister {
+	if !l.namespaces.Has(namespace) {
+		return listers.NewVSphereBindingLister(empty).VSphereBindings(namespace)
+	}
+	return l.VSphereBindingLister.VSphereBindings(namespace)
+}
+
+type vsphereInventorySourceInformer struct {
+	informers.VSphereInventorySourceInformer
+	namespaces sets.String
+}
+
+func (i *vsphereInventorySourceInformer) Lister() listers.VSphereInventorySourceLister {
+	return &vsphereInventorySourceLister{VSphereInventorySourceLister: i.VSphereInventorySourceInformer.Lister(), namespaces: i.namespaces}
+}
+
+type vsphereInventorySourceLister struct {
+	listers.VSphereInventorySourceLister
+	namespaces sets.String
+}
+
+func (l *vsphereInventorySourceLister) List(selector labels.Selector) ([]*v1alpha1.VSphereInventorySource, error) {
+	all, err := l.VSphereInventorySourceLister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	var ret []*v1alpha1.VSphereInventorySource
+	for _, elt := range all {
+		if l.namespaces.Has(elt.Namespace) {
+			ret = append(ret, elt)
+		}
+	}
+	return ret, nil
+}
+
+func (l *vsphereInventorySourceLister) VSphereInventorySources(namespace string) listers.VSphereInventorySourceNamespaceLister {
+	if !l.namespaces.Has(namespace) {
+		return listers.NewVSphereInventorySourceLister(empty).VSphereInventorySources(namespace)
+	}
+	return l.VSphereInventorySourceLister.VSphereInventorySources(namespace)
+}
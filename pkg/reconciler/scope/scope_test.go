@@ -0,0 +1,80 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package scope
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	listers "github.com/vmware-tanzu/sources-for-knative/pkg/client/listers/sources/v1alpha1"
+)
+
+func TestParseNamespaces(t *testing.T) {
+	tests := map[string][]string{
+		"":               nil,
+		"team-a":         {"team-a"},
+		"team-a, team-b": {"team-a", "team-b"},
+		"team-a,,":       {"team-a"},
+	}
+	for in, want := range tests {
+		if got := ParseNamespaces(in); !cmp.Equal(got, want) {
+			t.Errorf("ParseNamespaces(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	ctx := context.Background()
+	if !Contains(ctx, "any") {
+		t.Error("Contains() = false without a scope, want true")
+	}
+
+	ctx = context.WithValue(ctx, namespacesKey{}, sets.NewString("team-a"))
+	if !Contains(ctx, "team-a") {
+		t.Error("Contains(team-a) = false, want true")
+	}
+	if Contains(ctx, "team-b") {
+		t.Error("Contains(team-b) = true, want false")
+	}
+}
+
+func TestVSphereSourceLister(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, ns := range []string{"team-a", "team-b"} {
+		if err := indexer.Add(&v1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: ns},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l := &vsphereSourceLister{
+		VSphereSourceLister: listers.NewVSphereSourceLister(indexer),
+		namespaces:          sets.NewString("team-a"),
+	}
+
+	all, err := l.List(labels.Everything())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].Namespace != "team-a" {
+		t.Errorf("List() = %v, want only the source in team-a", all)
+	}
+
+	if _, err := l.VSphereSources("team-a").Get("src"); err != nil {
+		t.Errorf("Get(team-a/src) = %v, want the source", err)
+	}
+	if _, err := l.VSphereSources("team-b").Get("src"); !apierrs.IsNotFound(err) {
+		t.Errorf("Get(team-b/src) = %v, want NotFound", err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	vsbinformer "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/informers/sources/v1alpha1/vspherebinding"
 	"knative.dev/pkg/client/injection/ducks/duck/v1/podspecable"
 	"knative.dev/pkg/client/injection/kube/informers/core/v1/namespace"
+	"knative.dev/pkg/client/injection/kube/informers/core/v1/secret"
 	"knative.dev/pkg/reconciler"
 
 	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
@@ -44,6 +45,7 @@ func NewController(
 	dc := dynamicclient.Get(ctx)
 	psInformerFactory := podspecable.Get(ctx)
 	namespaceInformer := namespace.Get(ctx)
+	secretInformer := secret.Get(ctx)
 
 	c := &psbinding.BaseReconciler{
 		LeaderAwareFuncs: reconciler.LeaderAwareFuncs{
@@ -69,6 +71,7 @@ func NewController(
 		Recorder: record.NewBroadcaster().NewRecorder(
 			scheme.Scheme, corev1.EventSource{Component: controllerAgentName}),
 		NamespaceLister: namespaceInformer.Lister(),
+		WithContext:     WithCredentialsVersion(secretInformer.Lister()),
 	}
 	impl := controller.NewImpl(c, logger, "VSphereBindings")
 
@@ -76,6 +79,10 @@ func NewController(
 
 	vsbInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
 
+	// Roll the subjects of bindings when the credentials in their secret change
+	secretInformer.Informer().AddEventHandler(controller.HandleAll(
+		enqueueBindingsOfSecret(vsbInformer.Lister(), impl.EnqueueKey)))
+
 	c.Tracker = tracker.New(impl.EnqueueKey, controller.GetTrackerLease(ctx))
 	c.Factory = &duck.CachedInformerFactory{
 		Delegate: &duck.EnqueueInformerFactory{
@@ -83,6 +90,10 @@ func NewController(
 			EventHandler: controller.HandleAll(c.Tracker.OnChanged),
 		},
 	}
+	c.SubResourcesReconciler = &subjectsReconciler{
+		factory:     c.Factory,
+		withContext: c.WithContext,
+	}
 
 	return impl
 }
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspherebinding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/webhook/psbinding"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	listers "github.com/vmware-tanzu/sources-for-knative/pkg/client/listers/sources/v1alpha1"
+)
+
+// WithCredentialsVersion returns a context decorator which infuses the context
+// of Do with the version of the credentials in the secret of the binding, so
+// that its subjects are rolled when the credentials change.
+func WithCredentialsVersion(secretLister corev1listers.SecretLister) psbinding.BindableContext {
+	return func(ctx context.Context, b psbinding.Bindable) (context.Context, error) {
+		vsb, ok := b.(*v1alpha1.VSphereBinding)
+		if !ok {
+			return ctx, nil
+		}
+
+		secret, err := secretLister.Secrets(vsb.Namespace).Get(vsb.Spec.SecretRef.Name)
+		if apierrs.IsNotFound(err) {
+			return ctx, nil
+		}
+		if err != nil {
+			return ctx, fmt.Errorf("get secret %q: %w", vsb.Spec.SecretRef.Name, err)
+		}
+		return v1alpha1.WithCredentialsVersion(ctx, secretVersion(secret)), nil
+	}
+}
+
+// secretVersion returns a digest of the data of the given secret, which only
+// changes with the credentials in contrast to its resource version.
+func secretVersion(secret *corev1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for k := range secret.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(h, "%s:%d:", k, len(secret.Data[k]))
+		_, _ = h.Write(secret.Data[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// enqueueBindingsOfSecret returns an event handler which enqueues the bindings
+// referencing the changed secret, including a deleted secret whose final state
+// is unknown.
+func enqueueBindingsOfSecret(lister listers.VSphereBindingLister, enqueue func(types.NamespacedName)) func(interface{}) {
+	return func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return
+		}
+
+		bindings, err := lister.VSphereBindings(secret.Namespace).List(labels.Everything())
+		if err != nil {
+			return
+		}
+		for _, vsb := range bindings {
+			if vsb.Spec.SecretRef.Name == secret.Name {
+				enqueue(types.NamespacedName{Namespace: vsb.Namespace, Name: vsb.Name})
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspherebinding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	listers "github.com/vmware-tanzu/sources-for-knative/pkg/client/listers/sources/v1alpha1"
+)
+
+func Test_secretVersion(t *testing.T) {
+	secret := func(data map[string]string) *corev1.Secret {
+		s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", ResourceVersion: "1"}, Data: map[string][]byte{}}
+		for k, v := range data {
+			s.Data[k] = []byte(v)
+		}
+		return s
+	}
+
+	v1 := secretVersion(secret(map[string]string{"username": "user", "password": "secret"}))
+	relabeled := secret(map[string]string{"username": "user", "password": "secret"})
+	relabeled.ResourceVersion = "2"
+	relabeled.Labels = map[string]string{"team": "infra"}
+	if got := secretVersion(relabeled); got != v1 {
+		t.Errorf("secretVersion() of unchanged credentials = %s, want %s", got, v1)
+	}
+	if got := secretVersion(secret(map[string]string{"username": "user", "password": "rotated"})); got == v1 {
+		t.Errorf("secretVersion() of rotated credentials = %s, want a new version", got)
+	}
+	// the length of the values is part of the digest
+	if secretVersion(secret(map[string]string{"a": "bc"})) == secretVersion(secret(map[string]string{"a": "b", "c": ""})) {
+		t.Error("secretVersion() of different data is equal")
+	}
+}
+
+func TestWithCredentialsVersion(t *testing.T) {
+	creds := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vsphere-credentials"},
+		Data:       map[string][]byte{corev1.BasicAuthUsernameKey: []byte("user"), corev1.BasicAuthPasswordKey: []byte("secret")},
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(creds); err != nil {
+		t.Fatal(err)
+	}
+	withContext := WithCredentialsVersion(corev1listers.NewSecretLister(indexer))
+
+	subject := func(ctx context.Context, secretName string) map[string]string {
+		vsb := &v1alpha1.VSphereBinding{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "binding"}}
+		vsb.Spec.SecretRef.Name = secretName
+		ctx, err := withContext(ctx, vsb)
+		if err != nil {
+			t.Fatalf("WithCredentialsVersion() error = %v", err)
+		}
+		ps := &duckv1.WithPod{}
+		vsb.Do(ctx, ps)
+		return ps.Spec.Template.Annotations
+	}
+
+	want := map[string]string{v1alpha1.CredentialsVersionAnnotation: secretVersion(creds)}
+	if got := subject(context.Background(), creds.Name); !cmp.Equal(got, want) {
+		t.Errorf("pod template annotations = %v, want %v", got, want)
+	}
+	// subjects of a missing secret are bound without a version
+	if got := subject(context.Background(), "missing"); len(got) != 0 {
+		t.Errorf("pod template annotations = %v, want none", got)
+	}
+}
+
+func Test_enqueueBindingsOfSecret(t *testing.T) {
+	binding := func(namespace, name, secretName string) *v1alpha1.VSphereBinding {
+		vsb := &v1alpha1.VSphereBinding{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+		vsb.Spec.SecretRef.Name = secretName
+		return vsb
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, vsb := range []*v1alpha1.VSphereBinding{
+		binding("default", "a", "vsphere-credentials"),
+		binding("default", "b", "other-credentials"),
+		binding("default", "c", "vsphere-credentials"),
+		binding("other", "d", "vsphere-credentials"),
+	} {
+		if err := indexer.Add(vsb); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []types.NamespacedName
+	handler := enqueueBindingsOfSecret(listers.NewVSphereBindingLister(indexer), func(key types.NamespacedName) {
+		got = append(got, key)
+	})
+
+	handler(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vsphere-credentials"}})
+	handler(cache.DeletedFinalStateUnknown{
+		Key: "other/vsphere-credentials",
+		Obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "vsphere-credentials"}},
+	})
+	// other objects are ignored
+	handler(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vsphere-credentials"}})
+
+	want := []types.NamespacedName{{Namespace: "default", Name: "a"}, {Namespace: "default", Name: "c"}, {Namespace: "other", Name: "d"}}
+	if diff := cmp.Diff(want, got, cmpopts.SortSlices(func(a, b types.NamespacedName) bool { return a.Name < b.Name })); diff != "" {
+		t.Errorf("enqueued bindings (-want, +got) = %s", diff)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspherebinding
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/apis/duck"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/tracker"
+	"knative.dev/pkg/webhook/psbinding"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+)
+
+// subjectsReconciler records the resolved subjects of a binding in its status
+// after the base reconciler bound them.
+type subjectsReconciler struct {
+	factory     duck.InformerFactory
+	withContext psbinding.BindableContext
+}
+
+var _ psbinding.SubResourcesReconcilerInterface = (*subjectsReconciler)(nil)
+
+// Reconcile implements psbinding.SubResourcesReconcilerInterface
+func (r *subjectsReconciler) Reconcile(ctx context.Context, b psbinding.Bindable) error {
+	vsb, ok := b.(*v1alpha1.VSphereBinding)
+	if !ok {
+		return nil
+	}
+
+	subject := vsb.GetSubject()
+	referents, err := r.resolve(ctx, subject)
+	if err != nil {
+		return err
+	}
+
+	if r.withContext != nil {
+		if ctx, err = r.withContext(ctx, vsb); err != nil {
+			return err
+		}
+	}
+	vsb.Status.MarkSubjects(subjectStatuses(ctx, vsb, subject, referents))
+	return nil
+}
+
+// ReconcileDeletion implements psbinding.SubResourcesReconcilerInterface
+func (r *subjectsReconciler) ReconcileDeletion(context.Context, psbinding.Bindable) error {
+	return nil
+}
+
+// resolve returns the PodSpecable resources matching the given subject.
+func (r *subjectsReconciler) resolve(ctx context.Context, subject tracker.Reference) ([]*duckv1.WithPod, error) {
+	gv, err := schema.ParseGroupVersion(subject.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject apiVersion %q: %w", subject.APIVersion, err)
+	}
+
+	_, lister, err := r.factory.Get(ctx, apis.KindToResource(gv.WithKind(subject.Kind)))
+	if err != nil {
+		return nil, fmt.Errorf("get subject lister: %w", err)
+	}
+
+	if subject.Name != "" {
+		obj, err := lister.ByNamespace(subject.Namespace).Get(subject.Name)
+		if err != nil {
+			return nil, fmt.Errorf("get subject %q: %w", subject.Name, err)
+		}
+		return []*duckv1.WithPod{obj.(*duckv1.WithPod)}, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(subject.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject selector: %w", err)
+	}
+	objs, err := lister.ByNamespace(subject.Namespace).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("list subjects: %w", err)
+	}
+
+	result := make([]*duckv1.WithPod, 0, len(objs))
+	for _, obj := range objs {
+		result = append(result, obj.(*duckv1.WithPod))
+	}
+	return result, nil
+}
+
+// subjectStatuses returns the status of the given subjects sorted by name. A
+// subject is bound if binding it again does not change its pod template.
+func subjectStatuses(ctx context.Context, vsb *v1alpha1.VSphereBinding, subject tracker.Reference, referents []*duckv1.WithPod) []v1alpha1.VBindingSubjectStatus {
+	result := make([]v1alpha1.VBindingSubjectStatus, 0, len(referents))
+	for _, ps := range referents {
+		bound := ps.DeepCopy()
+		vsb.Do(ctx, bound)
+
+		result = append(result, v1alpha1.VBindingSubjectStatus{
+			APIVersion: subject.APIVersion,
+			Kind:       subject.Kind,
+			Name:       ps.Name,
+			Bound:      equality.Semantic.DeepEqual(ps.Spec.Template, bound.Spec.Template),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspherebinding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	duckv1alpha1 "knative.dev/pkg/apis/duck/v1alpha1"
+	"knative.dev/pkg/tracker"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+)
+
+func Test_subjectStatuses(t *testing.T) {
+	subject := tracker.Reference{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Namespace:  "default",
+		Selector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "vsphere"},
+		},
+	}
+	vsb := &v1alpha1.VSphereBinding{
+		Spec: v1alpha1.VSphereBindingSpec{
+			BindingSpec: duckv1alpha1.BindingSpec{Subject: subject},
+			VAuthSpec: v1alpha1.VAuthSpec{
+				Address:   apis.URL{Scheme: "https", Host: "vcenter.example.com"},
+				SecretRef: corev1.LocalObjectReference{Name: "vsphere-credentials"},
+			},
+		},
+	}
+
+	newPodSpecable := func(name string) *duckv1.WithPod {
+		return &duckv1.WithPod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: duckv1.WithPodSpec{
+				Template: duckv1.PodSpecable{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "app", Image: "example.com/app"}},
+					},
+				},
+			},
+		}
+	}
+
+	ctx := context.Background()
+	bound := newPodSpecable("bound")
+	vsb.Do(ctx, bound)
+	pending := newPodSpecable("pending")
+
+	got := subjectStatuses(ctx, vsb, subject, []*duckv1.WithPod{pending, bound})
+	want := []v1alpha1.VBindingSubjectStatus{{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "bound",
+		Bound:      true,
+	}, {
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "pending",
+	}}
+	if !cmp.Equal(got, want) {
+		t.Errorf("subjectStatuses (-want, +got): %s", cmp.Diff(want, got))
+	}
+
+	// bound subjects are pending again when the credentials change
+	got = subjectStatuses(v1alpha1.WithCredentialsVersion(ctx, "v2"), vsb, subject, []*duckv1.WithPod{bound})
+	if len(got) != 1 || got[0].Bound {
+		t.Errorf("subjectStatuses() with new credentials = %v, want pending subject", got)
+	}
+}
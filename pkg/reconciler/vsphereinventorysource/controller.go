@@ -0,0 +1,73 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphereinventorysource
+
+import (
+	"context"
+
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/resolver"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/client"
+	vspherebindinginformer "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/informers/sources/v1alpha1/vspherebinding"
+	inventoryinformer "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/informers/sources/v1alpha1/vsphereinventorysource"
+	inventoryreconciler "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/reconciler/sources/v1alpha1/vsphereinventorysource"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	deploymentinformer "knative.dev/pkg/client/injection/kube/informers/apps/v1/deployment"
+)
+
+type envConfig struct {
+	InventoryAdapter string `envconfig:"VSPHERE_INVENTORY_ADAPTER" required:"true"`
+}
+
+// NewController creates a Reconciler and returns the result of NewImpl.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+
+	inventoryInformer := inventoryinformer.Get(ctx)
+	deploymentInformer := deploymentinformer.Get(ctx)
+	vspherebindingInformer := vspherebindinginformer.Get(ctx)
+
+	var env envConfig
+	if err := envconfig.Process("", &env); err != nil {
+		logger.Fatalf("Unable to read environment config: %v", err)
+	}
+
+	r := &Reconciler{
+		adapterImage:         env.InventoryAdapter,
+		kubeclient:           kubeclient.Get(ctx),
+		client:               client.Get(ctx),
+		deploymentLister:     deploymentInformer.Lister(),
+		vspherebindingLister: vspherebindingInformer.Lister(),
+	}
+	impl := inventoryreconciler.NewImpl(ctx, r)
+
+	logger.Info("Setting up event handlers.")
+
+	inventoryInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	deploymentInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGK(v1alpha1.Kind("VSphereInventorySource")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	vspherebindingInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGK(v1alpha1.Kind("VSphereInventorySource")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	r.resolver = resolver.NewURIResolver(ctx, impl.EnqueueKey)
+
+	return impl
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphereinventorysource
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vsphereinventorysource/resources"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+)
+
+func TestMakeDeployment(t *testing.T) {
+	vis := newInventorySource()
+	vis.Spec.CloudEventOverrides = &duckv1.CloudEventOverrides{Extensions: map[string]string{"cluster": "prod"}}
+
+	d, err := resources.MakeDeployment(context.Background(), vis, "adapter")
+	if err != nil {
+		t.Fatalf("MakeDeployment() error = %v", err)
+	}
+
+	if d.Name != "inv-inventory-deployment" || d.Namespace != "ns" {
+		t.Errorf("MakeDeployment() name = %s/%s, want ns/inv-inventory-deployment", d.Namespace, d.Name)
+	}
+	if len(d.OwnerReferences) != 1 || d.OwnerReferences[0].UID != "uid" {
+		t.Errorf("MakeDeployment() owners = %+v, want the source", d.OwnerReferences)
+	}
+	labels := map[string]string{"vsphereinventorysources.sources.tanzu.vmware.com/name": "inv"}
+	if diff := cmp.Diff(labels, d.Spec.Selector.MatchLabels); diff != "" {
+		t.Errorf("MakeDeployment() selector (-want, +got) = %v", diff)
+	}
+	if diff := cmp.Diff(labels, d.Spec.Template.Labels); diff != "" {
+		t.Errorf("MakeDeployment() pod labels (-want, +got) = %v", diff)
+	}
+	if got := d.Spec.Template.Spec.Containers[0].Image; got != "adapter" {
+		t.Errorf("MakeDeployment() image = %q, want adapter", got)
+	}
+
+	var watches []vsphere.PropertyWatch
+	if err := json.Unmarshal([]byte(envValue(d, "VSPHERE_INVENTORY_WATCHES")), &watches); err != nil {
+		t.Fatalf("invalid VSPHERE_INVENTORY_WATCHES: %v", err)
+	}
+	want := []vsphere.PropertyWatch{{Type: "VirtualMachine", Properties: []string{"runtime.powerState"}}}
+	if diff := cmp.Diff(want, watches); diff != "" {
+		t.Errorf("VSPHERE_INVENTORY_WATCHES (-want, +got) = %v", diff)
+	}
+	if got, want := envValue(d, "K_CE_OVERRIDES"), `{"extensions":{"cluster":"prod"}}`; got != want {
+		t.Errorf("K_CE_OVERRIDES = %s, want %s", got, want)
+	}
+	if got := envValue(d, "K_SINK"); got != "http://sink.example.com" {
+		t.Errorf("K_SINK = %q, want http://sink.example.com", got)
+	}
+}
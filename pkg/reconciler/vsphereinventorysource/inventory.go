@@ -0,0 +1,122 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphereinventorysource
+
+import (
+	"context"
+	"fmt"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	clientset "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned"
+	inventoryreconciler "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/reconciler/sources/v1alpha1/vsphereinventorysource"
+	v1alpha1lister "github.com/vmware-tanzu/sources-for-knative/pkg/client/listers/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vsphereinventorysource/resources"
+	resourcenames "github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vsphereinventorysource/resources/names"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/reconciler"
+	"knative.dev/pkg/resolver"
+)
+
+// Reconciler implements inventoryreconciler.Interface for
+// VSphereInventorySource resources.
+type Reconciler struct {
+	adapterImage string
+
+	resolver *resolver.URIResolver
+
+	kubeclient kubernetes.Interface
+	client     clientset.Interface
+
+	deploymentLister     appsv1listers.DeploymentLister
+	vspherebindingLister v1alpha1lister.VSphereBindingLister
+}
+
+// Check that our Reconciler implements Interface
+var _ inventoryreconciler.Interface = (*Reconciler)(nil)
+
+// ReconcileKind implements Interface.ReconcileKind.
+func (r *Reconciler) ReconcileKind(ctx context.Context, vis *sourcesv1alpha1.VSphereInventorySource) reconciler.Event {
+	if err := r.reconcileVSphereBinding(ctx, vis); err != nil {
+		return err
+	}
+
+	uri, err := r.resolver.URIFromDestinationV1(ctx, vis.Spec.Sink, vis)
+	if err != nil {
+		return err
+	}
+	vis.Status.SinkURI = uri
+
+	return r.reconcileDeployment(ctx, vis)
+}
+
+func (r *Reconciler) reconcileVSphereBinding(ctx context.Context, vis *sourcesv1alpha1.VSphereInventorySource) error {
+	ns := vis.Namespace
+	vspherebindingName := resourcenames.VSphereBinding(vis)
+
+	vspherebinding, err := r.vspherebindingLister.VSphereBindings(ns).Get(vspherebindingName)
+	if apierrs.IsNotFound(err) {
+		vspherebinding = resources.MakeVSphereBinding(ctx, vis)
+		vspherebinding, err = r.client.SourcesV1alpha1().VSphereBindings(ns).Create(ctx, vspherebinding, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create vspherebinding %q: %w", vspherebindingName, err)
+		}
+		logging.FromContext(ctx).Infof("Created vspherebinding %q", vspherebindingName)
+	} else if err != nil {
+		return fmt.Errorf("failed to get vspherebinding %q: %w", vspherebindingName, err)
+	} else {
+		// The vspherebinding exists, but make sure that it has the shape that we expect.
+		desiredVSphereBinding := resources.MakeVSphereBinding(ctx, vis)
+		vspherebinding = vspherebinding.DeepCopy()
+		vspherebinding.Spec = desiredVSphereBinding.Spec
+		vspherebinding, err = r.client.SourcesV1alpha1().VSphereBindings(ns).Update(ctx, vspherebinding, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update vspherebinding %q: %w", vspherebindingName, err)
+		}
+	}
+
+	// Reflect the state of the VSphereBinding in the VSphereInventorySource
+	vis.Status.PropagateAuthStatus(vspherebinding.Status.Status)
+
+	return nil
+}
+
+func (r *Reconciler) reconcileDeployment(ctx context.Context, vis *sourcesv1alpha1.VSphereInventorySource) error {
+	ns := vis.Namespace
+	deploymentName := resourcenames.Deployment(vis)
+
+	desiredDeployment, err := resources.MakeDeployment(ctx, vis, r.adapterImage)
+	if err != nil {
+		return fmt.Errorf("failed to make deployment %q: %w", deploymentName, err)
+	}
+
+	deployment, err := r.deploymentLister.Deployments(ns).Get(deploymentName)
+	if apierrs.IsNotFound(err) {
+		deployment, err = r.kubeclient.AppsV1().Deployments(ns).Create(ctx, desiredDeployment, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create deployment %q: %w", deploymentName, err)
+		}
+		logging.FromContext(ctx).Infof("Created deployment %q", deploymentName)
+	} else if err != nil {
+		return fmt.Errorf("failed to get deployment %q: %w", deploymentName, err)
+	} else {
+		// The deployment exists, but make sure that it has the shape that we expect.
+		deployment = deployment.DeepCopy()
+		deployment.Spec = desiredDeployment.Spec
+		deployment, err = r.kubeclient.AppsV1().Deployments(ns).Update(ctx, deployment, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update deployment %q: %w", deploymentName, err)
+		}
+	}
+
+	// Reflect the state of the Adapter Deployment in the VSphereInventorySource
+	vis.Status.PropagateAdapterStatus(deployment.Status)
+
+	return nil
+}
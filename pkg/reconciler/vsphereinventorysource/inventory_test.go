@@ -0,0 +1,163 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphereinventorysource
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/client/informers/externalversions"
+)
+
+func newInventorySource() *sourcesv1alpha1.VSphereInventorySource {
+	vis := &sourcesv1alpha1.VSphereInventorySource{
+		ObjectMeta: metav1.ObjectMeta{Name: "inv", Namespace: "ns", UID: "uid"},
+	}
+	vis.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vis.Spec.SecretRef = corev1.LocalObjectReference{Name: "vsphere-credentials"}
+	vis.Spec.Watches = []sourcesv1alpha1.VPropertyWatch{{Type: "VirtualMachine", Properties: []string{"runtime.powerState"}}}
+	vis.Status.SinkURI = apis.HTTP("sink.example.com")
+	vis.Status.InitializeConditions()
+	return vis
+}
+
+// syncIndexer replaces the objects of the given indexer with the items of the
+// given list, like the informer syncing with the client
+func syncIndexer(t *testing.T, indexer cache.Indexer, list runtime.Object) {
+	t.Helper()
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		objs = append(objs, item)
+	}
+	if err := indexer.Replace(objs, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReconcileVSphereBinding(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	informer := externalversions.NewSharedInformerFactory(client, 0).Sources().V1alpha1().VSphereBindings()
+	r := &Reconciler{client: client, vspherebindingLister: informer.Lister()}
+	bindings := client.SourcesV1alpha1().VSphereBindings("ns")
+
+	reconcile := func(vis *sourcesv1alpha1.VSphereInventorySource) {
+		t.Helper()
+		list, err := bindings.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		syncIndexer(t, informer.Informer().GetIndexer(), list)
+		if err := r.reconcileVSphereBinding(ctx, vis); err != nil {
+			t.Fatalf("reconcileVSphereBinding() error = %v", err)
+		}
+	}
+
+	vis := newInventorySource()
+	reconcile(vis)
+	binding, err := bindings.Get(ctx, "inv-inventory-vspherebinding", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got := binding.Spec.Subject; got.Kind != "Deployment" || got.Name != "inv-inventory-deployment" {
+		t.Errorf("created vspherebinding subject = %+v, want the adapter deployment", got)
+	}
+	if got := binding.Spec.SecretRef.Name; got != "vsphere-credentials" {
+		t.Errorf("created vspherebinding secret = %q, want vsphere-credentials", got)
+	}
+
+	// the status of the binding is propagated
+	binding.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionTrue}}
+	if _, err = bindings.UpdateStatus(ctx, binding, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	vis.Spec.SecretRef.Name = "rotated"
+	reconcile(vis)
+	if binding, err = bindings.Get(ctx, "inv-inventory-vspherebinding", metav1.GetOptions{}); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got := binding.Spec.SecretRef.Name; got != "rotated" {
+		t.Errorf("updated vspherebinding secret = %q, want rotated", got)
+	}
+	if cond := vis.Status.GetCondition(sourcesv1alpha1.VSphereInventorySourceConditionAuthReady); cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("auth condition = %+v, want true", cond)
+	}
+}
+
+func TestReconcileDeployment(t *testing.T) {
+	ctx := context.Background()
+	kubeclient := kubefake.NewSimpleClientset()
+	informer := kubeinformers.NewSharedInformerFactory(kubeclient, 0).Apps().V1().Deployments()
+	r := &Reconciler{adapterImage: "adapter", kubeclient: kubeclient, deploymentLister: informer.Lister()}
+	deployments := kubeclient.AppsV1().Deployments("ns")
+
+	reconcile := func(vis *sourcesv1alpha1.VSphereInventorySource) {
+		t.Helper()
+		list, err := deployments.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		syncIndexer(t, informer.Informer().GetIndexer(), list)
+		if err := r.reconcileDeployment(ctx, vis); err != nil {
+			t.Fatalf("reconcileDeployment() error = %v", err)
+		}
+	}
+
+	vis := newInventorySource()
+	reconcile(vis)
+	d, err := deployments.Get(ctx, "inv-inventory-deployment", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got := envValue(d, "K_SINK"); got != "http://sink.example.com" {
+		t.Errorf("created deployment K_SINK = %q, want http://sink.example.com", got)
+	}
+
+	// the deployment follows the sink and its status is propagated
+	d.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}}
+	if _, err = deployments.UpdateStatus(ctx, d, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	vis.Status.SinkURI = apis.HTTP("other.example.com")
+	reconcile(vis)
+	if d, err = deployments.Get(ctx, "inv-inventory-deployment", metav1.GetOptions{}); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got := envValue(d, "K_SINK"); got != "http://other.example.com" {
+		t.Errorf("updated deployment K_SINK = %q, want http://other.example.com", got)
+	}
+	if cond := vis.Status.GetCondition(sourcesv1alpha1.VSphereInventorySourceConditionAdapterReady); cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("adapter condition = %+v, want true", cond)
+	}
+}
+
+// envValue returns the value of the given environment variable of the adapter
+// container of the given deployment
+func envValue(d *appsv1.Deployment, name string) string {
+	for _, e := range d.Spec.Template.Spec.Containers[0].Env {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
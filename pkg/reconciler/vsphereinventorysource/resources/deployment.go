@@ -0,0 +1,103 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/ptr"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vsphereinventorysource/resources/names"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+)
+
+func MakeDeployment(ctx context.Context, vis *v1alpha1.VSphereInventorySource, adapterImage string) (*appsv1.Deployment, error) {
+	labels := map[string]string{
+		"vsphereinventorysources.sources.tanzu.vmware.com/name": vis.Name,
+	}
+
+	var ceOverrides string
+	if vis.Spec.CloudEventOverrides != nil {
+		if co, err := json.Marshal(vis.Spec.SourceSpec.CloudEventOverrides); err != nil {
+			logging.FromContext(ctx).Errorf(
+				"Failed to marshal CloudEventOverrides into JSON for %+v, %v", vis, err)
+		} else if len(co) > 0 {
+			ceOverrides = string(co)
+		}
+	}
+
+	watches := make([]vsphere.PropertyWatch, 0, len(vis.Spec.Watches))
+	for _, w := range vis.Spec.Watches {
+		watches = append(watches, vsphere.PropertyWatch{Type: w.Type, Properties: w.Properties})
+	}
+	jsonBytes, err := json.Marshal(watches)
+	if err != nil {
+		return nil, fmt.Errorf("marshal property watches: %w", err)
+	}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            names.Deployment(vis),
+			Namespace:       vis.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(vis)},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.Int32(1),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "adapter",
+						Image: adapterImage,
+						Env: []corev1.EnvVar{{
+							Name: "NAMESPACE",
+							ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{
+									FieldPath: "metadata.namespace",
+								},
+							},
+						}, {
+							Name: "NAME",
+							ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{
+									FieldPath: "metadata.name",
+								},
+							},
+						}, {
+							Name:  "K_METRICS_CONFIG",
+							Value: `{"Domain":"tanzu.vmware.com/sources","Component":"inventorysource"}`,
+						}, {
+							Name:  "K_LOGGING_CONFIG",
+							Value: "{}",
+						}, {
+							Name:  "VSPHERE_INVENTORY_WATCHES",
+							Value: string(jsonBytes),
+						}, {
+							Name:  "K_CE_OVERRIDES",
+							Value: ceOverrides,
+						}, {
+							Name:  "K_SINK",
+							Value: vis.Status.SinkURI.String(),
+						}},
+					}},
+				},
+			},
+		},
+	}, nil
+}
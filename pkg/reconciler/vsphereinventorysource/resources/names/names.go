@@ -0,0 +1,23 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package names
+
+import (
+	"knative.dev/pkg/kmeta"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+)
+
+// The suffixes differ from the VSphereSource ones so that both kinds of
+// sources can share a name in the same namespace.
+
+func Deployment(vis *v1alpha1.VSphereInventorySource) string {
+	return kmeta.ChildName(vis.Name, "-inventory-deployment")
+}
+
+func VSphereBinding(vis *v1alpha1.VSphereInventorySource) string {
+	return kmeta.ChildName(vis.Name, "-inventory-vspherebinding")
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package names
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		name string
+		vis  *v1alpha1.VSphereInventorySource
+		f    func(*v1alpha1.VSphereInventorySource) string
+		want string
+	}{{
+		name: "Deployment too long",
+		vis: &v1alpha1.VSphereInventorySource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: strings.Repeat("f", 63),
+			},
+		},
+		f:    Deployment,
+		want: "ffffffffff105d7597f637e83cc711605ac3ea4957-inventory-deployment",
+	}, {
+		name: "Deployment",
+		vis: &v1alpha1.VSphereInventorySource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo",
+			},
+		},
+		f:    Deployment,
+		want: "foo-inventory-deployment",
+	}, {
+		name: "vspherebinding",
+		vis: &v1alpha1.VSphereInventorySource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo",
+			},
+		},
+		f:    VSphereBinding,
+		want: "foo-inventory-vspherebinding",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.f(test.vis)
+			if got != test.want {
+				t.Errorf("%s() = %v, wanted %v", test.name, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resources
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	duckv1alpha1 "knative.dev/pkg/apis/duck/v1alpha1"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/tracker"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vsphereinventorysource/resources/names"
+)
+
+func MakeVSphereBinding(ctx context.Context, vis *v1alpha1.VSphereInventorySource) *v1alpha1.VSphereBinding {
+	return &v1alpha1.VSphereBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            names.VSphereBinding(vis),
+			Namespace:       vis.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(vis)},
+		},
+		Spec: v1alpha1.VSphereBindingSpec{
+			// Copy the VAuthSpec wholesale.
+			VAuthSpec: vis.Spec.VAuthSpec,
+			// Bind to the Deployment for the receive adapter.
+			BindingSpec: duckv1alpha1.BindingSpec{
+				Subject: tracker.Reference{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Namespace:  vis.Namespace,
+					Name:       names.Deployment(vis),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/apis/duck"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+// addressables reads the Addressables referenced by the destinations of the
+// sources from informers, which are started for each resource on first use.
+// The informers keep the Addressables unstructured, since the duck type of the
+// resolver lacks the https addresses and the audience of their status. The
+// resolver tracks the references, so that changes of the Addressables requeue
+// the sources.
+type addressables struct {
+	informers duck.InformerFactory
+}
+
+func newAddressables(ctx context.Context, client dynamic.Interface, resyncPeriod time.Duration) *addressables {
+	return &addressables{
+		informers: &duck.CachedInformerFactory{
+			Delegate: &unstructuredInformerFactory{
+				ctx:          ctx,
+				client:       client,
+				resyncPeriod: resyncPeriod,
+			},
+		},
+	}
+}
+
+// get returns the Addressable referenced by the given destination of a source
+// in the given namespace, or nil if the destination is a URI.
+func (a *addressables) get(ctx context.Context, dest duckv1.Destination, namespace string) (*unstructured.Unstructured, error) {
+	if dest.Ref == nil {
+		return nil, nil
+	}
+
+	gv, err := schema.ParseGroupVersion(dest.Ref.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sink apiVersion %q: %w", dest.Ref.APIVersion, err)
+	}
+
+	ns := dest.Ref.Namespace
+	if ns == "" {
+		ns = namespace
+	}
+
+	gvr := apis.KindToResource(gv.WithKind(dest.Ref.Kind))
+	_, lister, err := a.informers.Get(ctx, gvr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lister for %v: %w", gvr, err)
+	}
+	obj, err := lister.ByNamespace(ns).Get(dest.Ref.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sink %q: %w", dest.Ref.Name, err)
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("sink %q is a %T, not unstructured", dest.Ref.Name, obj)
+	}
+	return u, nil
+}
+
+// unstructuredInformerFactory implements duck.InformerFactory like
+// duck.TypedInformerFactory, but keeps the objects unstructured. Its
+// informers run until the given context is done.
+type unstructuredInformerFactory struct {
+	ctx          context.Context
+	client       dynamic.Interface
+	resyncPeriod time.Duration
+}
+
+// Check that unstructuredInformerFactory implements duck.InformerFactory.
+var _ duck.InformerFactory = (*unstructuredInformerFactory)(nil)
+
+// Get implements duck.InformerFactory.
+func (f *unstructuredInformerFactory) Get(ctx context.Context, gvr schema.GroupVersionResource) (cache.SharedIndexInformer, cache.GenericLister, error) {
+	resource := f.client.Resource(gvr)
+	// Fail early if the resource does not exist or we may not list it,
+	// since the informer would never sync.
+	if _, err := resource.List(ctx, metav1.ListOptions{Limit: 1}); err != nil {
+		return nil, nil, err
+	}
+
+	lw := &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			return resource.List(f.ctx, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return resource.Watch(f.ctx, opts)
+		},
+	}
+	inf := cache.NewSharedIndexInformer(lw, &unstructured.Unstructured{}, f.resyncPeriod, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+	})
+	lister := cache.NewGenericLister(inf.GetIndexer(), gvr.GroupResource())
+
+	go inf.Run(f.ctx.Done())
+
+	if !cache.WaitForCacheSync(f.ctx.Done(), inf.HasSynced) {
+		return nil, nil, fmt.Errorf("failed starting informer for %v", gvr)
+	}
+	return inf, lister, nil
+}
@@ -7,7 +7,9 @@ package vspheresource
 
 import (
 	"context"
+	"time"
 
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
@@ -15,21 +17,51 @@ import (
 	"knative.dev/pkg/resolver"
 
 	"github.com/kelseyhightower/envconfig"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/config"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/client"
 	vspherebindinginformer "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/informers/sources/v1alpha1/vspherebinding"
 	vsphereinformer "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/informers/sources/v1alpha1/vspheresource"
+	templateinformer "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/informers/sources/v1alpha1/vspheresourcetemplate"
+	pdbinformer "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/kube/informers/policy/v1beta1/poddisruptionbudget"
 	vspherereconciler "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/reconciler/sources/v1alpha1/vspheresource"
+	resourcenames "github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
 	eventingclient "knative.dev/eventing/pkg/client/injection/client"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	deploymentinformer "knative.dev/pkg/client/injection/kube/informers/apps/v1/deployment"
 	cminformer "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap"
 	sainformer "knative.dev/pkg/client/injection/kube/informers/core/v1/serviceaccount"
 	rbacinformer "knative.dev/pkg/client/injection/kube/informers/rbac/v1/rolebinding"
+	"knative.dev/pkg/injection/clients/dynamicclient"
 )
 
 type envConfig struct {
 	VSphereAdapter string `envconfig:"VSPHERE_ADAPTER" required:"true"`
+
+	// AdapterMode is "dedicated" to run a receive adapter per source, or
+	// "shared" to run one receive adapter per namespace serving all sources.
+	AdapterMode string `envconfig:"VSPHERE_ADAPTER_MODE" default:"dedicated"`
+
+	// SharedAdapter is the image of the shared receive adapter, required in
+	// the shared adapter mode.
+	SharedAdapter string `envconfig:"VSPHERE_SHARED_ADAPTER"`
+
+	// SinkKinds is a comma-separated list of custom Addressable kinds, in the
+	// form "<kind>.<version>.<group>", which are accepted as sink references
+	// in addition to the built-in kinds. If empty, any Addressable is accepted.
+	SinkKinds []string `envconfig:"VSPHERE_SINK_KINDS"`
+
+	// GCInterval is the interval at which checkpoint configmaps of sources
+	// which no longer exist are collected.
+	GCInterval time.Duration `envconfig:"VSPHERE_GC_INTERVAL" default:"10m"`
+
+	// MetricsInterval is the interval at which the aggregate states of the
+	// sources are recorded in metrics.
+	MetricsInterval time.Duration `envconfig:"VSPHERE_METRICS_INTERVAL" default:"30s"`
+
+	// LagThreshold is how far the adapter of a source may fall behind the
+	// newest vCenter event before the source is marked as lagging.
+	LagThreshold time.Duration `envconfig:"VSPHERE_LAG_THRESHOLD" default:"5m"`
 }
 
 // NewController creates a Reconciler and returns the result of NewImpl.
@@ -41,28 +73,59 @@ func NewController(
 
 	vsphereInformer := vsphereinformer.Get(ctx)
 	deploymentInformer := deploymentinformer.Get(ctx)
+	pdbInformer := pdbinformer.Get(ctx)
 	rbacInformer := rbacinformer.Get(ctx)
 	cmInformer := cminformer.Get(ctx)
 	vspherebindingInformer := vspherebindinginformer.Get(ctx)
 	saInformer := sainformer.Get(ctx)
+	templateInformer := templateinformer.Get(ctx)
 
 	var env envConfig
 	if err := envconfig.Process("", &env); err != nil {
 		logger.Fatalf("Unable to read environment config: %v", err)
 	}
 
+	switch env.AdapterMode {
+	case adapterModeDedicated:
+	case adapterModeShared:
+		if env.SharedAdapter == "" {
+			logger.Fatal("VSPHERE_SHARED_ADAPTER is required in the shared adapter mode")
+		}
+	default:
+		logger.Fatalf("Unsupported adapter mode %q", env.AdapterMode)
+	}
+
+	sinkKinds, err := parseSinkKinds(env.SinkKinds)
+	if err != nil {
+		logger.Fatalf("Unable to parse sink kinds: %v", err)
+	}
+
 	r := &Reconciler{
 		adapterImage:         env.VSphereAdapter,
+		adapterMode:          env.AdapterMode,
+		sharedAdapterImage:   env.SharedAdapter,
+		sinkKinds:            sinkKinds,
+		lagThreshold:         env.LagThreshold,
 		kubeclient:           kubeclient.Get(ctx),
 		eventingclient:       eventingclient.Get(ctx),
 		client:               client.Get(ctx),
+		vsphereLister:        vsphereInformer.Lister(),
 		deploymentLister:     deploymentInformer.Lister(),
+		pdbLister:            pdbInformer.Lister(),
 		vspherebindingLister: vspherebindingInformer.Lister(),
 		cmLister:             cmInformer.Lister(),
 		rbacLister:           rbacInformer.Lister(),
 		saLister:             saInformer.Lister(),
+		templateLister:       templateInformer.Lister(),
 	}
-	impl := vspherereconciler.NewImpl(ctx, r)
+	impl := vspherereconciler.NewImpl(ctx, r, func(impl *controller.Impl) controller.Options {
+		// Roll the adapters of all sources when the cluster defaults change.
+		configStore := config.NewStore(logger.Named("config-store"), func(string, interface{}) {
+			impl.GlobalResync(vsphereInformer.Informer())
+		})
+		configStore.WatchConfigs(cmw)
+		return controller.Options{ConfigStore: configStore}
+	})
 
 	logger.Info("Setting up event handlers.")
 
@@ -73,6 +136,18 @@ func NewController(
 		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
 	})
 
+	// The shared adapter is not controlled by any source, so enqueue all
+	// sources of its namespace to reflect its status.
+	deploymentInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterWithName(resourcenames.SharedAdapter),
+		Handler:    controller.HandleAll(r.enqueueNamespace(impl.EnqueueKey)),
+	})
+
+	pdbInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGK(v1alpha1.Kind("VSphereSource")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
 	saInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: controller.FilterControllerGK(v1alpha1.Kind("VSphereSource")),
 		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
@@ -83,15 +158,61 @@ func NewController(
 		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
 	})
 
-	// Don't trigger off of CM updates because we don't care about the content
-	// and it is high churn.
+	// Roll the adapters of the sources of a namespace when its defaults change.
+	cmInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterWithName(config.DefaultsConfigName),
+		Handler:    controller.HandleAll(r.enqueueNamespace(impl.EnqueueKey)),
+	})
+
+	// Only trigger off of CM updates of the status recorded by the adapter because the
+	// checkpoints are high churn.
+	cmInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGK(v1alpha1.Kind("VSphereSource")),
+		Handler: cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				if adapterStatusChanged(oldObj, newObj) {
+					impl.EnqueueControllerOf(newObj)
+				}
+			},
+		},
+	})
 
 	vspherebindingInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: controller.FilterControllerGK(v1alpha1.Kind("VSphereSource")),
 		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
 	})
 
+	// Reapply the templates to the sources referencing them when they change.
+	templateInformer.Informer().AddEventHandler(controller.HandleAll(r.enqueueTemplateSources(impl.EnqueueKey)))
+
 	r.resolver = resolver.NewURIResolver(ctx, impl.EnqueueKey)
+	r.addressables = newAddressables(ctx, dynamicclient.Get(ctx), controller.GetResyncPeriod(ctx))
+	r.eventTypes = newEventTypeInformer(ctx, r.eventingclient, controller.GetResyncPeriod(ctx), cache.FilteringResourceEventHandler{
+		FilterFunc: controller.FilterControllerGK(v1alpha1.Kind("VSphereSource")),
+		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	})
+
+	// Sweep up the configmaps left behind by sources deleted without running
+	// our finalizer, but only once the informers know all current sources.
+	go func() {
+		if !cache.WaitForCacheSync(ctx.Done(), vsphereInformer.Informer().HasSynced, cmInformer.Informer().HasSynced) {
+			return
+		}
+		wait.UntilWithContext(ctx, func(ctx context.Context) {
+			r.collectOrphans(ctx, impl.EnqueueKey)
+		}, env.GCInterval)
+	}()
+
+	// Record the states of all sources for a dashboard of the source fleet.
+	go func() {
+		if !cache.WaitForCacheSync(ctx.Done(), vsphereInformer.Informer().HasSynced, deploymentInformer.Informer().HasSynced) {
+			return
+		}
+		seen := map[readyState]bool{}
+		wait.UntilWithContext(ctx, func(ctx context.Context) {
+			r.recordFleetMetrics(ctx, seen)
+		}, env.MetricsInterval)
+	}()
 
 	return impl
 }
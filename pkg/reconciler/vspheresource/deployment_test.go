@@ -0,0 +1,316 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"knative.dev/pkg/apis"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+)
+
+func newDeploymentSource(d *sourcesv1alpha1.VDeploymentSpec) *sourcesv1alpha1.VSphereSource {
+	vms := &sourcesv1alpha1.VSphereSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns", UID: "uid"},
+	}
+	vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vms.Spec.Deployment = d
+	return vms
+}
+
+func TestMakeDeploymentStrategy(t *testing.T) {
+	one, quarter := intstr.FromInt(1), intstr.FromString("25%")
+
+	tests := []struct {
+		name       string
+		deployment *sourcesv1alpha1.VDeploymentSpec
+		want       appsv1.DeploymentStrategy
+	}{
+		{name: "default"},
+		{name: "default strategy", deployment: &sourcesv1alpha1.VDeploymentSpec{}},
+		{
+			name:       "recreate",
+			deployment: &sourcesv1alpha1.VDeploymentSpec{Strategy: "Recreate"},
+			want:       appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+		},
+		{
+			name:       "rolling update",
+			deployment: &sourcesv1alpha1.VDeploymentSpec{MaxSurge: &one, MaxUnavailable: &quarter},
+			want: appsv1.DeploymentStrategy{
+				Type:          appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: &one, MaxUnavailable: &quarter},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := resources.MakeDeployment(context.Background(), newDeploymentSource(tt.deployment), "image",
+				corev1.ResourceRequirements{}, vsphere.TLSConfig{})
+			if err != nil {
+				t.Fatalf("MakeDeployment() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, d.Spec.Strategy); diff != "" {
+				t.Errorf("MakeDeployment() strategy (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestMakeDeploymentPodClasses(t *testing.T) {
+	d, err := resources.MakeDeployment(context.Background(), newDeploymentSource(nil), "image",
+		corev1.ResourceRequirements{}, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeDeployment() error = %v", err)
+	}
+	if spec := d.Spec.Template.Spec; spec.PriorityClassName != "" || spec.RuntimeClassName != nil {
+		t.Errorf("MakeDeployment() priorityClassName = %q, runtimeClassName = %v, want the defaults",
+			spec.PriorityClassName, spec.RuntimeClassName)
+	}
+
+	runtimeClass := "gvisor"
+	d, err = resources.MakeDeployment(context.Background(), newDeploymentSource(&sourcesv1alpha1.VDeploymentSpec{
+		PriorityClassName: "event-sources",
+		RuntimeClassName:  &runtimeClass,
+	}), "image", corev1.ResourceRequirements{}, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeDeployment() error = %v", err)
+	}
+	if spec := d.Spec.Template.Spec; spec.PriorityClassName != "event-sources" ||
+		spec.RuntimeClassName == nil || *spec.RuntimeClassName != runtimeClass {
+		t.Errorf("MakeDeployment() priorityClassName = %q, runtimeClassName = %v, want event-sources and gvisor",
+			spec.PriorityClassName, spec.RuntimeClassName)
+	}
+}
+
+func TestReconcilePodDisruptionBudget(t *testing.T) {
+	ctx := context.Background()
+	zero := intstr.FromInt(0)
+	kubeclient := kubefake.NewSimpleClientset()
+	pdbInformer := kubeinformers.NewSharedInformerFactory(kubeclient, 0).Policy().V1beta1().PodDisruptionBudgets()
+	r := &Reconciler{kubeclient: kubeclient, pdbLister: pdbInformer.Lister()}
+	pdbs := kubeclient.PolicyV1beta1().PodDisruptionBudgets("ns")
+
+	// reconcile reads the poddisruptionbudgets from the lister, which is
+	// synced with the client before like by the informer
+	reconcile := func(vms *sourcesv1alpha1.VSphereSource) error {
+		list, err := pdbs.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		var objs []interface{}
+		for i := range list.Items {
+			objs = append(objs, &list.Items[i])
+		}
+		if err = pdbInformer.Informer().GetIndexer().Replace(objs, ""); err != nil {
+			return err
+		}
+		return r.reconcilePodDisruptionBudget(ctx, vms)
+	}
+
+	vms := newDeploymentSource(&sourcesv1alpha1.VDeploymentSpec{
+		PodDisruptionBudget: &sourcesv1alpha1.VPodDisruptionBudgetSpec{},
+	})
+	if err := reconcile(vms); err != nil {
+		t.Fatalf("reconcilePodDisruptionBudget() error = %v", err)
+	}
+	pdb, err := pdbs.Get(ctx, "src-pdb", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got := pdb.Spec.MinAvailable; got == nil || got.IntValue() != 1 || pdb.Spec.MaxUnavailable != nil {
+		t.Errorf("created poddisruptionbudget spec = %+v, want minAvailable 1", pdb.Spec)
+	}
+	if got := pdb.Spec.Selector.MatchLabels["vspheresources.sources.tanzu.vmware.com/name"]; got != "src" {
+		t.Errorf("created poddisruptionbudget selects source %q, want src", got)
+	}
+
+	vms.Spec.Deployment.PodDisruptionBudget.MaxUnavailable = &zero
+	if err := reconcile(vms); err != nil {
+		t.Fatalf("reconcilePodDisruptionBudget() error = %v", err)
+	}
+	if pdb, err = pdbs.Get(ctx, "src-pdb", metav1.GetOptions{}); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got := pdb.Spec.MaxUnavailable; got == nil || got.IntValue() != 0 || pdb.Spec.MinAvailable != nil {
+		t.Errorf("updated poddisruptionbudget spec = %+v, want maxUnavailable 0", pdb.Spec)
+	}
+
+	vms.Spec.Deployment = nil
+	if err := reconcile(vms); err != nil {
+		t.Fatalf("reconcilePodDisruptionBudget() error = %v", err)
+	}
+	if _, err = pdbs.Get(ctx, "src-pdb", metav1.GetOptions{}); !apierrs.IsNotFound(err) {
+		t.Errorf("Get() error = %v, want the poddisruptionbudget deleted", err)
+	}
+}
+
+func TestMakeDeploymentWireTrace(t *testing.T) {
+	vms := newDeploymentSource(nil)
+	vms.Annotations = map[string]string{vsphere.WireTraceAnnotation: vsphere.WireTraceVolume}
+	d, err := resources.MakeDeployment(context.Background(), vms, "image", corev1.ResourceRequirements{}, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeDeployment() error = %v", err)
+	}
+
+	spec := d.Spec.Template.Spec
+	var volume *corev1.Volume
+	for i := range spec.Volumes {
+		if spec.Volumes[i].Name == vsphere.WireTraceVolumeName {
+			volume = &spec.Volumes[i]
+		}
+	}
+	if volume == nil || volume.EmptyDir == nil || volume.EmptyDir.SizeLimit == nil {
+		t.Fatalf("MakeDeployment() volumes = %+v, want a size-limited wire trace volume", spec.Volumes)
+	}
+
+	env := map[string]string{}
+	for _, e := range spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	if env["VSPHERE_WIRE_TRACE"] != vsphere.WireTraceVolume || env["VSPHERE_WIRE_TRACE_PATH"] != vsphere.WireTraceMountPath {
+		t.Errorf("MakeDeployment() wire trace env = %q at %q, want %q at %q", env["VSPHERE_WIRE_TRACE"],
+			env["VSPHERE_WIRE_TRACE_PATH"], vsphere.WireTraceVolume, vsphere.WireTraceMountPath)
+	}
+}
+
+func TestMakeDeploymentKafka(t *testing.T) {
+	vms := newDeploymentSource(nil)
+	vms.Spec.Kafka = &sourcesv1alpha1.VKafkaSpec{
+		BootstrapServers: []string{"kafka-0.kafka:9093", "kafka-1.kafka:9093"},
+		Topic:            "vsphere-events",
+		SASL: &sourcesv1alpha1.VKafkaSASLSpec{
+			Mechanism: vsphere.KafkaSASLSCRAMSHA512,
+			SecretRef: corev1.LocalObjectReference{Name: "kafka-creds"},
+		},
+		TLS: &sourcesv1alpha1.VKafkaTLSSpec{
+			CACertsConfigMapRef: &corev1.LocalObjectReference{Name: "kafka-ca"},
+		},
+	}
+	d, err := resources.MakeDeployment(context.Background(), vms, "image", corev1.ResourceRequirements{}, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeDeployment() error = %v", err)
+	}
+
+	spec := d.Spec.Template.Spec
+	volumes := map[string]corev1.VolumeSource{}
+	for _, v := range spec.Volumes {
+		volumes[v.Name] = v.VolumeSource
+	}
+	if s := volumes[vsphere.KafkaSecretVolumeName].Secret; s == nil || s.SecretName != "kafka-creds" {
+		t.Errorf("MakeDeployment() kafka credentials volume = %+v, want secret kafka-creds", s)
+	}
+	if c := volumes[vsphere.KafkaCACertsVolumeName].ConfigMap; c == nil || c.Name != "kafka-ca" {
+		t.Errorf("MakeDeployment() kafka CA certs volume = %+v, want configmap kafka-ca", c)
+	}
+
+	env := map[string]string{}
+	for _, e := range spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	want := `{"bootstrapServers":["kafka-0.kafka:9093","kafka-1.kafka:9093"],"topic":"vsphere-events",` +
+		`"saslMechanism":"SCRAM-SHA-512","secretPath":"/var/bindings/kafka","tls":true,"caCertsPath":"/var/run/vsphere/kafka-ca-certs"}`
+	if env["VSPHERE_KAFKA"] != want {
+		t.Errorf("MakeDeployment() VSPHERE_KAFKA = %s, want %s", env["VSPHERE_KAFKA"], want)
+	}
+	if env["K_SINK"] != "" {
+		t.Errorf("MakeDeployment() K_SINK = %q, want none", env["K_SINK"])
+	}
+}
+
+func TestMakeDeploymentLogOnly(t *testing.T) {
+	vms := newDeploymentSource(nil)
+	vms.Spec.LogOnly = true
+	d, err := resources.MakeDeployment(context.Background(), vms, "image", corev1.ResourceRequirements{}, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeDeployment() error = %v", err)
+	}
+
+	env := map[string]string{}
+	for _, e := range d.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	if env["VSPHERE_LOG_ONLY"] != "true" {
+		t.Errorf("MakeDeployment() VSPHERE_LOG_ONLY = %q, want true", env["VSPHERE_LOG_ONLY"])
+	}
+	if env["K_SINK"] != "" {
+		t.Errorf("MakeDeployment() K_SINK = %q, want none", env["K_SINK"])
+	}
+}
+
+func TestMakeDeploymentPaused(t *testing.T) {
+	vms := newDeploymentSource(nil)
+	for _, paused := range []bool{false, true} {
+		vms.Spec.Paused = paused
+		d, err := resources.MakeDeployment(context.Background(), vms, "image", corev1.ResourceRequirements{}, vsphere.TLSConfig{})
+		if err != nil {
+			t.Fatalf("MakeDeployment() error = %v", err)
+		}
+		want := int32(1)
+		if paused {
+			want = 0
+		}
+		if got := *d.Spec.Replicas; got != want {
+			t.Errorf("MakeDeployment() of paused %v source replicas = %d, want %d", paused, got, want)
+		}
+	}
+}
+
+func TestMakeDeploymentAdapterOverrides(t *testing.T) {
+	vms := newDeploymentSource(nil)
+	vms.Spec.Delivery = &sourcesv1alpha1.VDeliverySpec{CACertsConfigMapRef: &corev1.LocalObjectReference{Name: "sink-ca"}}
+	vms.Spec.AdapterOverrides = &sourcesv1alpha1.VAdapterOverridesSpec{
+		Env: []corev1.EnvVar{
+			{Name: "VSPHERE_PAGE_SIZE", Value: "50"},
+			{Name: "HTTPS_PROXY", Value: "http://proxy.example.com:3128"},
+		},
+		Volumes: []corev1.Volume{{
+			Name:         "ca-bundle",
+			VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "ca-bundle"}}},
+		}},
+		VolumeMounts: []corev1.VolumeMount{{Name: "ca-bundle", MountPath: "/etc/ssl/certs/site", ReadOnly: true}},
+	}
+	d, err := resources.MakeDeployment(context.Background(), vms, "image", corev1.ResourceRequirements{}, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeDeployment() error = %v", err)
+	}
+
+	spec := d.Spec.Template.Spec
+	if got := spec.Volumes[len(spec.Volumes)-1].Name; len(spec.Volumes) != 2 || got != "ca-bundle" {
+		t.Errorf("MakeDeployment() volumes = %+v, want the sink CA certs and ca-bundle", spec.Volumes)
+	}
+	adapter := spec.Containers[0]
+	if got := adapter.VolumeMounts[len(adapter.VolumeMounts)-1]; got.Name != "ca-bundle" || got.MountPath != "/etc/ssl/certs/site" {
+		t.Errorf("MakeDeployment() volume mounts = %+v, want ca-bundle at /etc/ssl/certs/site", adapter.VolumeMounts)
+	}
+
+	env := map[string]string{}
+	names := map[string]int{}
+	for _, e := range adapter.Env {
+		env[e.Name] = e.Value
+		names[e.Name]++
+	}
+	if env["VSPHERE_PAGE_SIZE"] != "50" || names["VSPHERE_PAGE_SIZE"] != 1 {
+		t.Errorf("MakeDeployment() VSPHERE_PAGE_SIZE = %q (%d times), want 50 once", env["VSPHERE_PAGE_SIZE"], names["VSPHERE_PAGE_SIZE"])
+	}
+	if got := adapter.Env[len(adapter.Env)-1]; got.Name != "HTTPS_PROXY" || got.Value != "http://proxy.example.com:3128" {
+		t.Errorf("MakeDeployment() last env = %+v, want HTTPS_PROXY", got)
+	}
+	if env["VSPHERE_SINK_CA_CERTS_PATH"] != vsphere.SinkCACertsMountPath {
+		t.Errorf("MakeDeployment() VSPHERE_SINK_CA_CERTS_PATH = %q, want %q", env["VSPHERE_SINK_CA_CERTS_PATH"], vsphere.SinkCACertsMountPath)
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	eventingclientset "knative.dev/eventing/pkg/client/clientset/versioned"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+)
+
+// reconcileEventTypes makes sure that the EventTypes of the source match the
+// event types it sends to its Broker sink.
+func (r *Reconciler) reconcileEventTypes(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) error {
+	ns := vms.Namespace
+	logger := logging.FromContext(ctx)
+
+	desired, err := resources.MakeEventTypes(vms)
+	if err != nil {
+		return controller.NewPermanentError(fmt.Errorf("failed to make eventtypes: %w", err))
+	}
+
+	lister, err := r.eventTypes.lister(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start eventtype informer: %w", err)
+	}
+	if lister == nil {
+		logger.Debug("Not registering eventtypes, Knative Eventing does not serve them")
+		return nil
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{resources.EventTypeSourceLabel: vms.Name})
+	list, err := lister.ByNamespace(ns).List(selector)
+	if err != nil {
+		return fmt.Errorf("failed to list eventtypes: %w", err)
+	}
+
+	existing := make([]*eventingv1beta1.EventType, 0, len(list))
+	for _, obj := range list {
+		if et, ok := obj.(*eventingv1beta1.EventType); ok {
+			existing = append(existing, et)
+		}
+	}
+
+	create, update, remove := diffEventTypes(existing, desired)
+	for _, et := range create {
+		if _, err := r.eventingclient.EventingV1beta1().EventTypes(ns).Create(ctx, et, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create eventtype %q: %w", et.Name, err)
+		}
+		logger.Infof("Created eventtype %q for type %q", et.Name, et.Spec.Type)
+	}
+	for _, et := range update {
+		if _, err := r.eventingclient.EventingV1beta1().EventTypes(ns).Update(ctx, et, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update eventtype %q: %w", et.Name, err)
+		}
+	}
+	for _, et := range remove {
+		err := r.eventingclient.EventingV1beta1().EventTypes(ns).Delete(ctx, et.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("failed to delete eventtype %q: %w", et.Name, err)
+		}
+		logger.Infof("Deleted eventtype %q for type %q", et.Name, et.Spec.Type)
+	}
+
+	return nil
+}
+
+// eventTypeInformer starts the informer of the EventTypes registered for the
+// sources on first use, unlike the injected informers, which would never sync
+// in clusters where Knative Eventing does not serve EventTypes.
+type eventTypeInformer struct {
+	ctx          context.Context
+	client       eventingclientset.Interface
+	resyncPeriod time.Duration
+	handler      cache.ResourceEventHandler
+
+	mu     sync.Mutex
+	cached cache.GenericLister
+}
+
+func newEventTypeInformer(ctx context.Context, client eventingclientset.Interface, resyncPeriod time.Duration, handler cache.ResourceEventHandler) *eventTypeInformer {
+	return &eventTypeInformer{
+		ctx:          ctx,
+		client:       client,
+		resyncPeriod: resyncPeriod,
+		handler:      handler,
+	}
+}
+
+// lister returns the lister of the EventTypes of the sources, or nil if
+// Knative Eventing does not serve EventTypes in this cluster.
+func (i *eventTypeInformer) lister(ctx context.Context) (cache.GenericLister, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.cached != nil {
+		return i.cached, nil
+	}
+
+	eventTypes := i.client.EventingV1beta1().EventTypes(metav1.NamespaceAll)
+	if _, err := eventTypes.List(ctx, metav1.ListOptions{Limit: 1}); apierrs.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// only the EventTypes registered for sources
+	selector := resources.EventTypeSourceLabel
+	lw := &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			opts.LabelSelector = selector
+			return eventTypes.List(i.ctx, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			opts.LabelSelector = selector
+			return eventTypes.Watch(i.ctx, opts)
+		},
+	}
+	inf := cache.NewSharedIndexInformer(lw, &eventingv1beta1.EventType{}, i.resyncPeriod, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+	})
+	if i.handler != nil {
+		inf.AddEventHandler(i.handler)
+	}
+
+	go inf.Run(i.ctx.Done())
+
+	if !cache.WaitForCacheSync(i.ctx.Done(), inf.HasSynced) {
+		return nil, errors.New("failed to sync eventtype informer")
+	}
+	i.cached = cache.NewGenericLister(inf.GetIndexer(), eventingv1beta1.Resource("eventtypes"))
+	return i.cached, nil
+}
+
+// diffEventTypes returns the desired EventTypes which do not exist yet, the
+// existing EventTypes updated with changed desired specs, and the existing
+// EventTypes which are no longer desired.
+func diffEventTypes(existing, desired []*eventingv1beta1.EventType) (create, update, remove []*eventingv1beta1.EventType) {
+	byName := make(map[string]*eventingv1beta1.EventType, len(existing))
+	for _, et := range existing {
+		byName[et.Name] = et
+	}
+
+	for _, want := range desired {
+		got, ok := byName[want.Name]
+		if !ok {
+			create = append(create, want)
+			continue
+		}
+		delete(byName, want.Name)
+
+		// The webhook defaults the spec, so only compare the fields we set.
+		if got.Spec.Type != want.Spec.Type ||
+			!equality.Semantic.DeepEqual(got.Spec.Source, want.Spec.Source) ||
+			got.Spec.Broker != want.Spec.Broker ||
+			got.Spec.Description != want.Spec.Description {
+			got = got.DeepCopy()
+			got.Spec = want.Spec
+			update = append(update, got)
+		}
+	}
+
+	for _, et := range existing {
+		if _, ok := byName[et.Name]; ok {
+			remove = append(remove, et)
+		}
+	}
+	return create, update, remove
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources"
+)
+
+func TestMakeEventTypes(t *testing.T) {
+	source := func(sink duckv1.KReference) *sourcesv1alpha1.VSphereSource {
+		vms := &sourcesv1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"},
+		}
+		vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+		vms.Spec.Sink.Ref = &sink
+		vms.Spec.Filter = &sourcesv1alpha1.VFilterSpec{EventTypes: []string{"com.vmware.vsphere.alarm.cleared"}}
+		return vms
+	}
+
+	tests := []struct {
+		name       string
+		sink       duckv1.KReference
+		wantBroker string
+	}{{
+		name:       "broker",
+		sink:       duckv1.KReference{APIVersion: "eventing.knative.dev/v1", Kind: "Broker", Name: "default"},
+		wantBroker: "default",
+	}, {
+		name:       "broker in the same namespace",
+		sink:       duckv1.KReference{APIVersion: "eventing.knative.dev/v1", Kind: "Broker", Name: "b", Namespace: "ns"},
+		wantBroker: "b",
+	}, {
+		name: "broker in another namespace",
+		sink: duckv1.KReference{APIVersion: "eventing.knative.dev/v1", Kind: "Broker", Name: "b", Namespace: "other"},
+	}, {
+		name: "knative service",
+		sink: duckv1.KReference{APIVersion: "serving.knative.dev/v1", Kind: "Service", Name: "svc"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resources.MakeEventTypes(source(tt.sink))
+			if err != nil {
+				t.Fatalf("MakeEventTypes() error = %v", err)
+			}
+			if tt.wantBroker == "" {
+				if len(got) != 0 {
+					t.Errorf("MakeEventTypes() = %v, want none", got)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("MakeEventTypes() got %d eventtypes, want 1", len(got))
+			}
+			et := got[0]
+			if et.Spec.Broker != tt.wantBroker || et.Spec.Type != "com.vmware.vsphere.alarm.cleared" ||
+				et.Spec.Source.String() != "vcenter.example.com" {
+				t.Errorf("MakeEventTypes() spec = %+v", et.Spec)
+			}
+			if et.Labels[resources.EventTypeSourceLabel] != "src" || len(et.OwnerReferences) != 1 {
+				t.Errorf("MakeEventTypes() metadata = %+v", et.ObjectMeta)
+			}
+		})
+	}
+}
+
+func TestDiffEventTypes(t *testing.T) {
+	eventType := func(name, typ string) *eventingv1beta1.EventType {
+		return &eventingv1beta1.EventType{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       eventingv1beta1.EventTypeSpec{Type: typ, Broker: "default"},
+		}
+	}
+	names := func(ets []*eventingv1beta1.EventType) []string {
+		result := []string{}
+		for _, et := range ets {
+			result = append(result, et.Name)
+		}
+		return result
+	}
+
+	existing := []*eventingv1beta1.EventType{
+		eventType("unchanged", "a"),
+		eventType("changed", "b"),
+		eventType("stale", "c"),
+	}
+	desired := []*eventingv1beta1.EventType{
+		eventType("unchanged", "a"),
+		eventType("changed", "b2"),
+		eventType("new", "d"),
+	}
+
+	create, update, remove := diffEventTypes(existing, desired)
+	if diff := cmp.Diff([]string{"new"}, names(create)); diff != "" {
+		t.Errorf("create (-want, +got) = %s", diff)
+	}
+	if diff := cmp.Diff([]string{"changed"}, names(update)); diff != "" {
+		t.Errorf("update (-want, +got) = %s", diff)
+	}
+	if update[0].Spec.Type != "b2" || existing[1].Spec.Type != "b" {
+		t.Errorf("update = %+v, want the updated spec on a copy", update[0].Spec)
+	}
+	if diff := cmp.Diff([]string{"stale"}, names(remove)); diff != "" {
+		t.Errorf("remove (-want, +got) = %s", diff)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"fmt"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	vspherereconciler "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/reconciler/sources/v1alpha1/vspheresource"
+	v1alpha1lister "github.com/vmware-tanzu/sources-for-knative/pkg/client/listers/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/scope"
+	resourcenames "github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/reconciler"
+)
+
+// Check that our Reconciler implements Finalizer
+var _ vspherereconciler.Finalizer = (*Reconciler)(nil)
+
+// FinalizeKind implements Finalizer.FinalizeKind. It deletes the checkpoint
+// configmap of the adapter so that its state does not outlive the source,
+// even if the garbage collection of owned objects is disabled or orphaning,
+// and removes the source from the shared adapter.
+func (r *Reconciler) FinalizeKind(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) reconciler.Event {
+	ns := vms.Namespace
+
+	if err := r.removeTenant(ctx, vms); err != nil {
+		return err
+	}
+	name := resourcenames.ConfigMap(vms)
+
+	cm, err := r.cmLister.ConfigMaps(ns).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get configmap %q: %w", name, err)
+	}
+
+	// Leave configmaps alone which happen to have the name but are not ours.
+	if !metav1.IsControlledBy(cm, vms) {
+		return nil
+	}
+
+	return r.deleteConfigMap(ctx, cm)
+}
+
+// collectOrphans deletes the checkpoint configmaps of sources which no longer
+// exist, e.g. because a reconcile crashed while the source was deleted or
+// the source was recreated with the same name. Sources which were recreated
+// are enqueued so that they get a fresh configmap.
+func (r *Reconciler) collectOrphans(ctx context.Context, enqueue func(types.NamespacedName)) {
+	logger := logging.FromContext(ctx)
+
+	cms, err := r.cmLister.List(labels.Everything())
+	if err != nil {
+		logger.Errorw("Failed to list configmaps", zap.Error(err))
+		return
+	}
+
+	// Sources out of the scope of the controller look deleted, so leave
+	// their configmaps alone.
+	var inScope []*corev1.ConfigMap
+	for _, cm := range cms {
+		if scope.Contains(ctx, cm.Namespace) {
+			inScope = append(inScope, cm)
+		}
+	}
+
+	for _, cm := range orphanedConfigMaps(inScope, r.vsphereLister) {
+		if err := r.deleteConfigMap(ctx, cm); err != nil {
+			logger.Errorw("Failed to collect orphaned configmap", zap.Error(err))
+			continue
+		}
+		enqueue(types.NamespacedName{Namespace: cm.Namespace, Name: metav1.GetControllerOf(cm).Name})
+	}
+}
+
+// orphanedConfigMaps returns the configmaps controlled by a VSphereSource
+// which does not exist in the given lister anymore.
+func orphanedConfigMaps(cms []*corev1.ConfigMap, lister v1alpha1lister.VSphereSourceLister) []*corev1.ConfigMap {
+	gk := sourcesv1alpha1.Kind("VSphereSource")
+
+	var orphans []*corev1.ConfigMap
+	for _, cm := range cms {
+		owner := metav1.GetControllerOf(cm)
+		if owner == nil || owner.Kind != gk.Kind {
+			continue
+		}
+		if gv, err := schema.ParseGroupVersion(owner.APIVersion); err != nil || gv.Group != gk.Group {
+			continue
+		}
+
+		vms, err := lister.VSphereSources(cm.Namespace).Get(owner.Name)
+		switch {
+		case apierrs.IsNotFound(err):
+			orphans = append(orphans, cm)
+		case err != nil:
+			continue
+		case vms.UID != owner.UID:
+			orphans = append(orphans, cm)
+		}
+	}
+	return orphans
+}
+
+func (r *Reconciler) deleteConfigMap(ctx context.Context, cm *corev1.ConfigMap) error {
+	err := r.kubeclient.CoreV1().ConfigMaps(cm.Namespace).Delete(ctx, cm.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &cm.UID},
+	})
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete configmap %q: %w", cm.Name, err)
+	}
+	logging.FromContext(ctx).Infof("Deleted configmap %q", cm.Name)
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/ptr"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	v1alpha1lister "github.com/vmware-tanzu/sources-for-knative/pkg/client/listers/sources/v1alpha1"
+)
+
+func TestOrphanedConfigMaps(t *testing.T) {
+	source := func(name string, uid types.UID) *sourcesv1alpha1.VSphereSource {
+		return &sourcesv1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: uid},
+		}
+	}
+	configMap := func(name string, owner *sourcesv1alpha1.VSphereSource) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		}
+		if owner != nil {
+			cm.OwnerReferences = []metav1.OwnerReference{*kmeta.NewControllerRef(owner)}
+		}
+		return cm
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(source("live", "uid-1")); err != nil {
+		t.Fatal(err)
+	}
+	lister := v1alpha1lister.NewVSphereSourceLister(indexer)
+
+	unowned := configMap("unowned", nil)
+	foreign := configMap("foreign", nil)
+	foreign.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "apps/v1", Kind: "Deployment", Name: "gone", UID: "uid-3", Controller: ptr.Bool(true),
+	}}
+	live := configMap("live-configmap", source("live", "uid-1"))
+	deleted := configMap("gone-configmap", source("gone", "uid-2"))
+	recreated := configMap("live-configmap-old", source("live", "uid-0"))
+
+	got := orphanedConfigMaps([]*corev1.ConfigMap{unowned, foreign, live, deleted, recreated}, lister)
+	want := []*corev1.ConfigMap{deleted, recreated}
+	if !cmp.Equal(got, want) {
+		t.Errorf("orphanedConfigMaps() = %v, want %v", names(got), names(want))
+	}
+}
+
+func names(cms []*corev1.ConfigMap) []string {
+	var names []string
+	for _, cm := range cms {
+		names = append(names, cm.Name)
+	}
+	return names
+}
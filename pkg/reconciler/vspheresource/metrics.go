@@ -0,0 +1,168 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"errors"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+	"knative.dev/pkg/reconciler"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/scope"
+	resourcenames "github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+)
+
+var (
+	sourceCountM = stats.Int64(
+		"vspheresource_count",
+		"Number of VSphereSources by the status and reason of their Ready condition",
+		stats.UnitDimensionless,
+	)
+	outOfSyncCountM = stats.Int64(
+		"vspheresource_adapter_out_of_sync_count",
+		"Number of VSphereSources whose adapter does not run their current spec yet",
+		stats.UnitDimensionless,
+	)
+	reconcileErrorCountM = stats.Int64(
+		"vspheresource_reconcile_error_count",
+		"Number of failed reconciliations of VSphereSources by reason",
+		stats.UnitDimensionless,
+	)
+	readyKey  = tag.MustNewKey("ready")
+	reasonKey = tag.MustNewKey("reason")
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: sourceCountM.Description(),
+		Measure:     sourceCountM,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{readyKey, reasonKey},
+	}, &view.View{
+		Description: outOfSyncCountM.Description(),
+		Measure:     outOfSyncCountM,
+		Aggregation: view.LastValue(),
+	}, &view.View{
+		Description: reconcileErrorCountM.Description(),
+		Measure:     reconcileErrorCountM,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{reasonKey},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// readyState is the status and reason of the Ready condition of a source
+type readyState struct {
+	status corev1.ConditionStatus
+	reason string
+}
+
+// fleetStats are the aggregate states of the sources of the controller
+type fleetStats struct {
+	ready     map[readyState]int64
+	outOfSync int64
+}
+
+// collectFleetStats aggregates the states of the given sources. The adapter
+// of a source is out of sync while the controller has not observed its latest
+// generation or, with dedicated adapters, while its deployment is missing or
+// not rolled out.
+func collectFleetStats(sources []*sourcesv1alpha1.VSphereSource, deploymentLister appsv1listers.DeploymentLister, dedicated bool) fleetStats {
+	fleet := fleetStats{ready: map[readyState]int64{}}
+	for _, vms := range sources {
+		state := readyState{status: corev1.ConditionUnknown}
+		if cond := vms.Status.GetCondition(apis.ConditionReady); cond != nil {
+			state = readyState{status: cond.Status, reason: cond.Reason}
+		}
+		fleet.ready[state]++
+
+		if vms.Status.ObservedGeneration != vms.Generation {
+			fleet.outOfSync++
+			continue
+		}
+		if !dedicated {
+			continue
+		}
+		deployment, err := deploymentLister.Deployments(vms.Namespace).Get(resourcenames.Deployment(vms))
+		if err != nil {
+			fleet.outOfSync++
+			continue
+		}
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		if deployment.Status.ObservedGeneration < deployment.Generation || deployment.Status.UpdatedReplicas < replicas {
+			fleet.outOfSync++
+		}
+	}
+	return fleet
+}
+
+// recordFleetMetrics records the aggregate states of the sources in the scope
+// of the controller as gauges. The Ready states which were seen before but
+// which no sources are in any more are recorded as zero.
+func (r *Reconciler) recordFleetMetrics(ctx context.Context, seen map[readyState]bool) {
+	sources, err := r.vsphereLister.List(labels.Everything())
+	if err != nil {
+		logging.FromContext(ctx).Errorw("Failed to list sources", zap.Error(err))
+		return
+	}
+	var inScope []*sourcesv1alpha1.VSphereSource
+	for _, vms := range sources {
+		if scope.Contains(ctx, vms.Namespace) {
+			inScope = append(inScope, vms)
+		}
+	}
+
+	fleet := collectFleetStats(inScope, r.deploymentLister, r.adapterMode == adapterModeDedicated)
+	for state := range fleet.ready {
+		seen[state] = true
+	}
+	for state := range seen {
+		metrics.Record(ctx, sourceCountM.M(fleet.ready[state]), stats.WithTags(
+			tag.Insert(readyKey, string(state.status)),
+			tag.Insert(reasonKey, state.reason)))
+	}
+	metrics.Record(ctx, outOfSyncCountM.M(fleet.outOfSync))
+}
+
+// reconcileErrorReason returns the reason of the given reconcile error: the
+// reason of a reconciler event, the reason of a Kubernetes API error, e.g.
+// Conflict, or else InternalError like the events of failed reconciliations.
+func reconcileErrorReason(err error) string {
+	var event *reconciler.ReconcilerEvent
+	if errors.As(err, &event) {
+		return event.Reason
+	}
+	if reason := apierrs.ReasonForError(err); reason != "" {
+		return string(reason)
+	}
+	return "InternalError"
+}
+
+// recordReconcileError counts the given reconcile error by reason. Normal
+// reconciler events are not errors.
+func recordReconcileError(ctx context.Context, err error) {
+	var event *reconciler.ReconcilerEvent
+	if err == nil || errors.As(err, &event) && event.EventType == corev1.EventTypeNormal {
+		return
+	}
+	metrics.Record(ctx, reconcileErrorCountM.M(1), stats.WithTags(tag.Insert(reasonKey, reconcileErrorReason(err))))
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/ptr"
+	"knative.dev/pkg/reconciler"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	resourcenames "github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+)
+
+func TestCollectFleetStats(t *testing.T) {
+	source := func(name string, generation, observed int64, ready *apis.Condition) *sourcesv1alpha1.VSphereSource {
+		vms := &sourcesv1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", Generation: generation},
+		}
+		vms.Status.ObservedGeneration = observed
+		if ready != nil {
+			vms.Status.Conditions = duckv1.Conditions{*ready}
+		}
+		return vms
+	}
+	deployment := func(vms *sourcesv1alpha1.VSphereSource, generation, observed int64, updated int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: resourcenames.Deployment(vms), Namespace: vms.Namespace, Generation: generation},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.Int32(1)},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: observed, UpdatedReplicas: updated},
+		}
+	}
+	ready := &apis.Condition{Type: apis.ConditionReady, Status: corev1.ConditionTrue}
+	notReady := &apis.Condition{Type: apis.ConditionReady, Status: corev1.ConditionFalse, Reason: "DeploymentUnavailable"}
+
+	synced := source("synced", 1, 1, ready)
+	rolling := source("rolling", 2, 2, ready)
+	failing := source("failing", 1, 1, notReady)
+	unobserved := source("unobserved", 3, 2, notReady)
+	pending := source("pending", 1, 0, nil)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, d := range []*appsv1.Deployment{
+		deployment(synced, 1, 1, 1),
+		deployment(rolling, 2, 2, 0),
+		deployment(unobserved, 1, 1, 1),
+	} {
+		if err := indexer.Add(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lister := appsv1listers.NewDeploymentLister(indexer)
+	sources := []*sourcesv1alpha1.VSphereSource{synced, rolling, failing, unobserved, pending}
+
+	tests := []struct {
+		name      string
+		dedicated bool
+		want      fleetStats
+	}{{
+		name:      "dedicated adapters",
+		dedicated: true,
+		want: fleetStats{
+			ready: map[readyState]int64{
+				{status: corev1.ConditionTrue}:                                   2,
+				{status: corev1.ConditionFalse, reason: "DeploymentUnavailable"}: 2,
+				{status: corev1.ConditionUnknown}:                                1,
+			},
+			// rolling, the missing deployment of failing, unobserved and pending
+			outOfSync: 4,
+		},
+	}, {
+		name: "shared adapter",
+		want: fleetStats{
+			ready: map[readyState]int64{
+				{status: corev1.ConditionTrue}:                                   2,
+				{status: corev1.ConditionFalse, reason: "DeploymentUnavailable"}: 2,
+				{status: corev1.ConditionUnknown}:                                1,
+			},
+			outOfSync: 2,
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := collectFleetStats(sources, lister, tt.dedicated)
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(fleetStats{}, readyState{})); diff != "" {
+				t.Errorf("collectFleetStats() (-want, +got) = %s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcileErrorReason(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{{
+		name: "reconciler event",
+		err:  reconciler.NewEvent(corev1.EventTypeWarning, "SinkNotFound", "sink not found"),
+		want: "SinkNotFound",
+	}, {
+		name: "wrapped api error",
+		err:  fmt.Errorf("failed to create deployment %q: %w", "src-deployment", apierrs.NewConflict(schema.GroupResource{Resource: "deployments"}, "src-deployment", errors.New("changed"))),
+		want: "Conflict",
+	}, {
+		name: "other error",
+		err:  errors.New("boom"),
+		want: "InternalError",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reconcileErrorReason(tt.err); got != tt.want {
+				t.Errorf("reconcileErrorReason() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,65 +9,323 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"time"
+	"strconv"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"knative.dev/pkg/kmeta"
-	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
 	"knative.dev/pkg/ptr"
 
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/config"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
 )
 
-func MakeDeployment(ctx context.Context, vms *v1alpha1.VSphereSource, adapterImage string) (*appsv1.Deployment, error) {
-	labels := map[string]string{
+// wireTraceSizeLimit limits the ephemeral volume of the wire trace of an
+// adapter, which is evicted when the trace exceeds it
+var wireTraceSizeLimit = resource.MustParse("256Mi")
+
+// makeMetricsConfig returns the JSON-encoded metrics exporter options of the
+// receive adapters, which include the config-observability ConfigMap to
+// select the metrics backend and enable the profiling server.
+func makeMetricsConfig(ctx context.Context) (string, error) {
+	s, err := metrics.OptionsToJSON(&metrics.ExporterOptions{
+		Domain:    "tanzu.vmware.com/sources",
+		Component: "source",
+		ConfigMap: config.FromContextOrDefaults(ctx).Observability.Data,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal metrics config: %w", err)
+	}
+	return s, nil
+}
+
+// makeAddresses returns the JSON-encoded configurations of the additional
+// vCenters of the given source, which read the credentials from the mounted
+// secrets with the auth method of the source. It is empty without additional
+// vCenters.
+func makeAddresses(vms *v1alpha1.VSphereSource, tlsConfig vsphere.TLSConfig) (string, error) {
+	if len(vms.Spec.Addresses) == 0 {
+		return "", nil
+	}
+
+	authMethod := vms.Spec.AuthMethod
+	if authMethod == "" {
+		authMethod = vsphere.AuthMethodBasic
+	}
+
+	addresses := make([]vsphere.EnvConfig, 0, len(vms.Spec.Addresses))
+	for i, a := range vms.Spec.Addresses {
+		addresses = append(addresses, vsphere.EnvConfig{
+			Address:            a.Address.String(),
+			Insecure:           a.SkipTLSVerify,
+			SecretPath:         vsphere.AddressSecretMountPath(i),
+			AuthMethod:         authMethod,
+			CredentialProvider: vsphere.CredentialProviderSecret,
+			TLSMinVersion:      tlsConfig.MinVersion,
+			TLSCipherSuites:    tlsConfig.CipherSuites,
+			Locale:             vms.Spec.Locale,
+			API:                makeAPIConfig(vms),
+		})
+	}
+
+	b, err := json.Marshal(addresses)
+	if err != nil {
+		return "", fmt.Errorf("marshal addresses: %w", err)
+	}
+	return string(b), nil
+}
+
+// adapterLabels returns the labels of the receive adapter pods of the given
+// source
+func adapterLabels(vms *v1alpha1.VSphereSource) map[string]string {
+	return map[string]string{
 		"vspheresources.sources.tanzu.vmware.com/name": vms.Name,
 	}
+}
+
+// makeDeploymentStrategy returns the update strategy of the receive adapter of
+// the given source, the Kubernetes default if not configured.
+func makeDeploymentStrategy(vms *v1alpha1.VSphereSource) appsv1.DeploymentStrategy {
+	d := vms.Spec.Deployment
+	if d == nil {
+		return appsv1.DeploymentStrategy{}
+	}
+	if appsv1.DeploymentStrategyType(d.Strategy) == appsv1.RecreateDeploymentStrategyType {
+		return appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	}
+	if d.Strategy == "" && d.MaxSurge == nil && d.MaxUnavailable == nil {
+		return appsv1.DeploymentStrategy{}
+	}
+	return appsv1.DeploymentStrategy{
+		Type: appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{
+			MaxSurge:       d.MaxSurge,
+			MaxUnavailable: d.MaxUnavailable,
+		},
+	}
+}
+
+func MakeDeployment(ctx context.Context, vms *v1alpha1.VSphereSource, adapterImage string, adapterResources corev1.ResourceRequirements, tlsConfig vsphere.TLSConfig) (*appsv1.Deployment, error) {
+	labels := adapterLabels(vms)
+
+	cfg, err := MakeSourceConfig(ctx, vms, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	metricsConfig, err := makeMetricsConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var volumes []corev1.Volume
+	var volumeMounts []corev1.VolumeMount
+	if d := vms.Spec.Delivery; d != nil {
+		if d.HeadersSecretRef != nil {
+			volumes = append(volumes, corev1.Volume{
+				Name: vsphere.SinkHeadersVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: d.HeadersSecretRef.Name,
+					},
+				},
+			})
+			volumeMounts = append(volumeMounts, corev1.VolumeMount{
+				Name:      vsphere.SinkHeadersVolumeName,
+				ReadOnly:  true,
+				MountPath: vsphere.SinkHeadersMountPath,
+			})
+		}
 
-	var ceOverrides string
-	if vms.Spec.CloudEventOverrides != nil {
-		if co, err := json.Marshal(vms.Spec.SourceSpec.CloudEventOverrides); err != nil {
-			logging.FromContext(ctx).Errorf(
-				"Failed to marshal CloudEventOverrides into JSON for %+v, %v", vms, err)
-		} else if len(co) > 0 {
-			ceOverrides = string(co)
+		if d.CACertsConfigMapRef != nil {
+			volumes = append(volumes, corev1.Volume{
+				Name: vsphere.SinkCACertsVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: *d.CACertsConfigMapRef,
+					},
+				},
+			})
+			volumeMounts = append(volumeMounts, corev1.VolumeMount{
+				Name:      vsphere.SinkCACertsVolumeName,
+				ReadOnly:  true,
+				MountPath: vsphere.SinkCACertsMountPath,
+			})
 		}
 	}
 
-	cpconf := vsphere.CheckpointConfig{
-		MaxAge: time.Second * time.Duration(vms.Spec.CheckpointConfig.MaxAgeSeconds),
-		Period: time.Second * time.Duration(vms.Spec.CheckpointConfig.PeriodSeconds),
+	if k := vms.Spec.Kafka; k != nil {
+		if k.SASL != nil {
+			volumes = append(volumes, corev1.Volume{
+				Name: vsphere.KafkaSecretVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: k.SASL.SecretRef.Name,
+					},
+				},
+			})
+			volumeMounts = append(volumeMounts, corev1.VolumeMount{
+				Name:      vsphere.KafkaSecretVolumeName,
+				ReadOnly:  true,
+				MountPath: vsphere.KafkaSecretMountPath,
+			})
+		}
+
+		if k.TLS != nil && k.TLS.CACertsConfigMapRef != nil {
+			volumes = append(volumes, corev1.Volume{
+				Name: vsphere.KafkaCACertsVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: *k.TLS.CACertsConfigMapRef,
+					},
+				},
+			})
+			volumeMounts = append(volumeMounts, corev1.VolumeMount{
+				Name:      vsphere.KafkaCACertsVolumeName,
+				ReadOnly:  true,
+				MountPath: vsphere.KafkaCACertsMountPath,
+			})
+		}
 	}
 
-	jsonBytes, err := json.Marshal(&cpconf)
+	for i, a := range vms.Spec.Addresses {
+		volumes = append(volumes, corev1.Volume{
+			Name: vsphere.AddressSecretVolumeName(i),
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: a.SecretRef.Name,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      vsphere.AddressSecretVolumeName(i),
+			ReadOnly:  true,
+			MountPath: vsphere.AddressSecretMountPath(i),
+		})
+	}
+	addresses, err := makeAddresses(vms, tlsConfig)
 	if err != nil {
-		return nil, fmt.Errorf("marshal checkpoint config: %w", err)
+		return nil, err
+	}
+
+	// the wire trace is written to the logs or an ephemeral volume
+	wireTrace := vms.Annotations[vsphere.WireTraceAnnotation]
+	if wireTrace == vsphere.WireTraceVolume {
+		volumes = append(volumes, corev1.Volume{
+			Name: vsphere.WireTraceVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{
+					SizeLimit: &wireTraceSizeLimit,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      vsphere.WireTraceVolumeName,
+			MountPath: vsphere.WireTraceMountPath,
+		})
 	}
 
-	return &appsv1.Deployment{
+	var sinkHeadersPath, sinkCACertsPath, wireTracePath string
+	for _, m := range volumeMounts {
+		switch m.Name {
+		case vsphere.SinkHeadersVolumeName:
+			sinkHeadersPath = m.MountPath
+		case vsphere.SinkCACertsVolumeName:
+			sinkCACertsPath = m.MountPath
+		case vsphere.WireTraceVolumeName:
+			wireTracePath = m.MountPath
+		}
+	}
+
+	// the volumes of the overrides are added last, so that their mounts are
+	// not taken for the paths of the controller
+	overrides := vms.Spec.AdapterOverrides
+	if overrides != nil {
+		volumes = append(volumes, overrides.Volumes...)
+		volumeMounts = append(volumeMounts, overrides.VolumeMounts...)
+	}
+
+	// the adapter logs in again when the credentials change
+	podAnnotations := map[string]string{
+		v1alpha1.CredentialsReloadAnnotation: "true",
+	}
+	if cfg.RestartedAt != "" {
+		// rolls out the adapter again when changed
+		podAnnotations[vsphere.RestartedAtAnnotation] = cfg.RestartedAt
+	}
+
+	var (
+		priorityClassName string
+		runtimeClassName  *string
+	)
+	if d := vms.Spec.Deployment; d != nil {
+		priorityClassName, runtimeClassName = d.PriorityClassName, d.RuntimeClassName
+	}
+
+	// a paused source keeps its adapter deployment and checkpoint
+	replicas := int32(1)
+	if vms.Spec.Paused {
+		replicas = 0
+	}
+
+	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            names.Deployment(vms),
 			Namespace:       vms.Namespace,
 			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(vms)},
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: ptr.Int32(1),
+			Replicas: ptr.Int32(replicas),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
+			Strategy: makeDeploymentStrategy(vms),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      labels,
+					Annotations: podAnnotations,
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: names.ServiceAccount(vms),
+					PriorityClassName:  priorityClassName,
+					RuntimeClassName:   runtimeClassName,
+					Volumes:            volumes,
 					Containers: []corev1.Container{{
-						Name:  "adapter",
-						Image: adapterImage,
+						Name:         "adapter",
+						Image:        adapterImage,
+						Resources:    adapterResources,
+						VolumeMounts: volumeMounts,
+						Ports: []corev1.ContainerPort{{
+							Name:          "health",
+							ContainerPort: vsphere.HealthPort,
+						}},
+						// not ready without a vCenter session and event polls,
+						// restarted if wedged
+						ReadinessProbe: &corev1.Probe{
+							Handler: corev1.Handler{
+								HTTPGet: &corev1.HTTPGetAction{
+									Path: vsphere.ReadinessPath,
+									Port: intstr.FromString("health"),
+								},
+							},
+							PeriodSeconds: 10,
+						},
+						LivenessProbe: &corev1.Probe{
+							Handler: corev1.Handler{
+								HTTPGet: &corev1.HTTPGetAction{
+									Path: vsphere.LivenessPath,
+									Port: intstr.FromString("health"),
+								},
+							},
+							PeriodSeconds:    30,
+							FailureThreshold: 3,
+						},
 						Env: []corev1.EnvVar{{
 							Name: "NAMESPACE",
 							ValueFrom: &corev1.EnvVarSource{
@@ -84,26 +342,207 @@ func MakeDeployment(ctx context.Context, vms *v1alpha1.VSphereSource, adapterIma
 							},
 						}, {
 							Name:  "K_METRICS_CONFIG",
-							Value: `{"Domain":"tanzu.vmware.com/sources","Component":"source"}`,
+							Value: metricsConfig,
 						}, {
 							Name:  "K_LOGGING_CONFIG",
-							Value: "{}",
+							Value: cfg.LoggingConfig,
+						}, {
+							Name:  "VSPHERE_SOURCE_NAME",
+							Value: vms.Name,
+						}, {
+							Name:  "VSPHERE_HEALTH_PORT",
+							Value: strconv.Itoa(vsphere.HealthPort),
+						}, {
+							Name: "VSPHERE_SERVICE_ACCOUNT",
+							ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{
+									FieldPath: "spec.serviceAccountName",
+								},
+							},
 						}, {
 							Name:  "VSPHERE_KVSTORE_CONFIGMAP",
-							Value: names.ConfigMap(vms),
+							Value: cfg.KVConfigMap,
 						}, {
 							Name:  "VSPHERE_CHECKPOINT_CONFIG",
-							Value: string(jsonBytes),
+							Value: cfg.CheckpointConfig,
+						}, {
+							Name:  "VSPHERE_POLL_INTERVAL",
+							Value: cfg.PollInterval.String(),
+						}, {
+							Name:  "VSPHERE_PAGE_SIZE",
+							Value: strconv.Itoa(cfg.PageSize),
+						}, {
+							Name:  "VSPHERE_COLLECTOR_MODE",
+							Value: cfg.CollectorMode,
+						}, {
+							Name:  "VSPHERE_SCOPE",
+							Value: cfg.Scope,
+						}, {
+							Name:  "VSPHERE_INCLUDE_TASKS",
+							Value: strconv.FormatBool(cfg.IncludeTasks),
+						}, {
+							Name:  "VSPHERE_INCLUDE_CONTENT_LIBRARY",
+							Value: strconv.FormatBool(cfg.IncludeContentLibrary),
+						}, {
+							Name:  "VSPHERE_INCLUDE_TAGS",
+							Value: strconv.FormatBool(cfg.IncludeTags),
+						}, {
+							Name:  "VSPHERE_NORMALIZE_ALARMS",
+							Value: strconv.FormatBool(cfg.NormalizeAlarms),
+						}, {
+							Name:  "VSPHERE_GROUP_VSAN_EVENTS",
+							Value: strconv.FormatBool(cfg.GroupVSANEvents),
+						}, {
+							Name:  "VSPHERE_EXTENSIONS",
+							Value: strings.Join(cfg.Extensions, ","),
+						}, {
+							Name:  "VSPHERE_ENRICHMENT",
+							Value: cfg.Enrichment,
+						}, {
+							Name:  "VSPHERE_ATTRIBUTE_MAPPING",
+							Value: cfg.AttributeMapping,
+						}, {
+							Name:  "VSPHERE_OUTPUT_FORMAT",
+							Value: cfg.OutputFormat,
+						}, {
+							Name:  "VSPHERE_REDACTION",
+							Value: cfg.Redaction,
+						}, {
+							Name:  "VSPHERE_PAYLOAD_ENCODING",
+							Value: cfg.PayloadEncoding,
+						}, {
+							Name:  "VSPHERE_EVENT_FILTER",
+							Value: cfg.EventFilter,
+						}, {
+							Name:  "VSPHERE_SINKS",
+							Value: cfg.Sinks,
+						}, {
+							Name:  "VSPHERE_MIRROR_SINK",
+							Value: cfg.MirrorSink,
+						}, {
+							Name:  "VSPHERE_KAFKA",
+							Value: cfg.Kafka,
+						}, {
+							Name:  "VSPHERE_LOG_ONLY",
+							Value: strconv.FormatBool(cfg.LogOnly),
+						}, {
+							Name:  "VSPHERE_TRANSFORM",
+							Value: cfg.Transform,
+						}, {
+							Name:  "VSPHERE_SINK_CONTENT_MODE",
+							Value: cfg.SinkContentMode,
+						}, {
+							Name:  "VSPHERE_SINK_COMPRESSION",
+							Value: cfg.SinkCompression,
+						}, {
+							Name:  "VSPHERE_SINK_HEADERS",
+							Value: cfg.SinkHeaders,
+						}, {
+							Name:  "VSPHERE_RATE_LIMIT",
+							Value: cfg.RateLimit,
+						}, {
+							Name:  "VSPHERE_SAMPLING",
+							Value: cfg.Sampling,
+						}, {
+							Name:  "VSPHERE_SINK_MAX_ATTEMPTS",
+							Value: strconv.Itoa(cfg.SinkMaxAttempts),
+						}, {
+							Name:  "VSPHERE_DEAD_LETTER_SINK",
+							Value: cfg.DeadLetterSink,
+						}, {
+							Name:  "VSPHERE_AUDIT",
+							Value: cfg.Audit,
+						}, {
+							Name:  "VSPHERE_SINK_CA_CERTS",
+							Value: cfg.SinkCACerts,
+						}, {
+							Name:  "VSPHERE_HEARTBEAT_INTERVAL",
+							Value: cfg.HeartbeatInterval.String(),
+						}, {
+							Name:  "VSPHERE_LIFECYCLE_EVENTS",
+							Value: strconv.FormatBool(cfg.LifecycleEvents),
+						}, {
+							Name:  "VSPHERE_SINK_HEADERS_PATH",
+							Value: sinkHeadersPath,
+						}, {
+							Name:  "VSPHERE_SINK_CA_CERTS_PATH",
+							Value: sinkCACertsPath,
+						}, {
+							Name:  "VSPHERE_SINK_AUDIENCE",
+							Value: cfg.SinkAudience,
+						}, {
+							Name:  "VSPHERE_SINK_TLS_MIN_VERSION",
+							Value: tlsConfig.MinVersion,
+						}, {
+							Name:  "VSPHERE_SINK_TLS_CIPHER_SUITES",
+							Value: tlsConfig.CipherSuites,
+						}, {
+							Name:  "VSPHERE_WIRE_TRACE",
+							Value: wireTrace,
+						}, {
+							Name:  "VSPHERE_WIRE_TRACE_PATH",
+							Value: wireTracePath,
+						}, {
+							Name:  "VC_FALLBACK_URL",
+							Value: cfg.VCenter.FallbackAddress,
+						}, {
+							Name:  "VC_LOCALE",
+							Value: cfg.VCenter.Locale,
+						}, {
+							Name:  "VC_TLS_MIN_VERSION",
+							Value: cfg.VCenter.TLSMinVersion,
+						}, {
+							Name:  "VC_TLS_CIPHER_SUITES",
+							Value: cfg.VCenter.TLSCipherSuites,
+						}, {
+							Name:  "VC_API_TIMEOUT",
+							Value: cfg.VCenter.API.Timeout.String(),
+						}, {
+							Name:  "VC_API_RETRIES",
+							Value: strconv.Itoa(cfg.VCenter.API.Retries),
+						}, {
+							Name:  "VC_API_RETRY_BACKOFF",
+							Value: cfg.VCenter.API.RetryBackoff.String(),
+						}, {
+							Name:  "VC_API_MAX_RETRY_BACKOFF",
+							Value: cfg.VCenter.API.MaxRetryBackoff.String(),
+						}, {
+							Name:  "VSPHERE_ADDRESSES",
+							Value: addresses,
 						}, {
 							Name:  "K_CE_OVERRIDES",
-							Value: ceOverrides,
+							Value: cfg.CEOverrides,
 						}, {
 							Name:  "K_SINK",
-							Value: vms.Status.SinkURI.String(),
+							Value: cfg.Sink,
 						}},
 					}},
 				},
 			},
 		},
-	}, nil
+	}
+	if overrides != nil {
+		adapter := &deployment.Spec.Template.Spec.Containers[0]
+		adapter.Env = overrideEnv(adapter.Env, overrides.Env)
+	}
+	return deployment, nil
+}
+
+// overrideEnv returns the given environment variables with the given
+// overrides, which replace the variables of the same name in place and are
+// appended otherwise.
+func overrideEnv(env, overrides []corev1.EnvVar) []corev1.EnvVar {
+	index := make(map[string]int, len(env))
+	for i, e := range env {
+		index[e.Name] = i
+	}
+	for _, o := range overrides {
+		if i, ok := index[o.Name]; ok {
+			env[i] = o
+			continue
+		}
+		index[o.Name] = len(env)
+		env = append(env, o)
+	}
+	return env
 }
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resources
+
+import (
+	"fmt"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	eventingv1beta1 "knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/kmeta"
+)
+
+// EventTypeSourceLabel is the label with the name of the source on the
+// EventTypes created for it
+const EventTypeSourceLabel = "vspheresources.sources.tanzu.vmware.com/name"
+
+// MakeEventTypes creates the EventType objects of the event types the source
+// sends to its sink. EventTypes are only created if the sink is a Broker in the
+// namespace of the source.
+func MakeEventTypes(vms *v1alpha1.VSphereSource) ([]*eventingv1beta1.EventType, error) {
+	broker := sinkBroker(vms)
+	if broker == "" {
+		return nil, nil
+	}
+
+	opts := vsphere.EventTypeOptions{
+		IncludeTasks:          vms.Spec.IncludeTasks,
+		IncludeTags:           vms.Spec.IncludeTags,
+		IncludeContentLibrary: vms.Spec.IncludeContentLibrary,
+		NormalizeAlarms:       vms.Spec.NormalizeAlarms,
+		OutputFormat:          vms.Spec.OutputFormat,
+	}
+	if f := vms.Spec.Filter; f != nil {
+		opts.Filter = vsphere.EventFilter{EventTypes: f.EventTypes}
+	}
+	if m := vms.Spec.AttributeMapping; m != nil {
+		opts.Mapping = vsphere.AttributeMapping{
+			Source:       m.Source,
+			TypePrefix:   m.TypePrefix,
+			TypeTemplate: m.TypeTemplate,
+		}
+	}
+
+	eventTypes, err := vsphere.EventTypes(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	source := vms.Spec.Address.Host
+	if opts.Mapping.Source != "" {
+		source = opts.Mapping.Source
+	}
+	sourceURL, err := apis.ParseURL(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event source %q: %w", source, err)
+	}
+
+	result := make([]*eventingv1beta1.EventType, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		result = append(result, &eventingv1beta1.EventType{
+			ObjectMeta: metav1.ObjectMeta{
+				OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(vms)},
+				Name:            names.EventType(vms, t),
+				Namespace:       vms.Namespace,
+				Labels: map[string]string{
+					EventTypeSourceLabel: vms.Name,
+				},
+			},
+			Spec: eventingv1beta1.EventTypeSpec{
+				Type:        t,
+				Source:      sourceURL,
+				Broker:      broker,
+				Description: fmt.Sprintf("Event type %s sent by the VSphereSource %s", t, vms.Name),
+			},
+		})
+	}
+	return result, nil
+}
+
+// sinkBroker returns the name of the Broker the source sends its events to, or
+// an empty string if the sink is not a Broker in its namespace.
+func sinkBroker(vms *v1alpha1.VSphereSource) string {
+	ref := vms.Spec.Sink.Ref
+	if ref == nil || ref.Kind != "Broker" {
+		return ""
+	}
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil || gv.Group != eventingv1beta1.SchemeGroupVersion.Group {
+		return ""
+	}
+	if ref.Namespace != "" && ref.Namespace != vms.Namespace {
+		return ""
+	}
+	return ref.Name
+}
@@ -6,6 +6,9 @@ SPDX-License-Identifier: Apache-2.0
 package names
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+
 	"knative.dev/pkg/kmeta"
 
 	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
@@ -30,3 +33,18 @@ func RoleBinding(vms *v1alpha1.VSphereSource) string {
 func ServiceAccount(vms *v1alpha1.VSphereSource) string {
 	return kmeta.ChildName(vms.Name, "-serviceaccount")
 }
+
+func PodDisruptionBudget(vms *v1alpha1.VSphereSource) string {
+	return kmeta.ChildName(vms.Name, "-pdb")
+}
+
+// EventType returns the name of the EventType of the given CloudEvent type,
+// which is unique among the types of the source
+func EventType(vms *v1alpha1.VSphereSource, eventType string) string {
+	h := sha256.Sum256([]byte(eventType))
+	return kmeta.ChildName(vms.Name, "-"+hex.EncodeToString(h[:])[:10])
+}
+
+// SharedAdapter is the name of the deployment, tenant configmap, service
+// account and rolebinding of the shared adapter of a namespace
+const SharedAdapter = "vsphere-shared-adapter"
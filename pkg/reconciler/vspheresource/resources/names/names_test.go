@@ -82,6 +82,26 @@ func TestNames(t *testing.T) {
 		},
 		f:    ServiceAccount,
 		want: "baz-serviceaccount",
+	}, {
+		name: "poddisruptionbudget",
+		vss: &v1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "baz",
+			},
+		},
+		f:    PodDisruptionBudget,
+		want: "baz-pdb",
+	}, {
+		name: "eventtype",
+		vss: &v1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "baz",
+			},
+		},
+		f: func(vss *v1alpha1.VSphereSource) string {
+			return EventType(vss, "com.vmware.vsphere.alarm.cleared")
+		},
+		want: "baz-eb2148fc72",
 	}}
 
 	for _, test := range tests {
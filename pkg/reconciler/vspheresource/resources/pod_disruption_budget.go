@@ -0,0 +1,49 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resources
+
+import (
+	"context"
+
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"knative.dev/pkg/kmeta"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+)
+
+// MakePodDisruptionBudget creates the PodDisruptionBudget of the receive adapter
+// of the given source, or returns nil if the source does not configure one. The
+// budget keeps one adapter available by default.
+func MakePodDisruptionBudget(ctx context.Context, vms *v1alpha1.VSphereSource) *policyv1beta1.PodDisruptionBudget {
+	d := vms.Spec.Deployment
+	if d == nil || d.PodDisruptionBudget == nil {
+		return nil
+	}
+
+	spec := policyv1beta1.PodDisruptionBudgetSpec{
+		Selector: &metav1.LabelSelector{
+			MatchLabels: adapterLabels(vms),
+		},
+		MinAvailable:   d.PodDisruptionBudget.MinAvailable,
+		MaxUnavailable: d.PodDisruptionBudget.MaxUnavailable,
+	}
+	if spec.MinAvailable == nil && spec.MaxUnavailable == nil {
+		one := intstr.FromInt(1)
+		spec.MinAvailable = &one
+	}
+
+	return &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(vms)},
+			Namespace:       vms.Namespace,
+			Name:            names.PodDisruptionBudget(vms),
+		},
+		Spec: spec,
+	}
+}
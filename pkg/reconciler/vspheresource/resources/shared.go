@@ -0,0 +1,508 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/ptr"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/config"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+)
+
+// SharedAdapterLabel is the label of the objects of the shared adapter of a
+// namespace
+const SharedAdapterLabel = "vspheresources.sources.tanzu.vmware.com/shared-adapter"
+
+// MakeSourceConfig returns the adapter configuration of the given source. It
+// configures the receive adapter of the source, either through the environment
+// of its dedicated deployment or the tenant configmap of the shared adapter.
+// The given TLS configuration applies to the connection to vCenter.
+func MakeSourceConfig(ctx context.Context, vms *v1alpha1.VSphereSource, tlsConfig vsphere.TLSConfig) (*vsphere.SourceConfig, error) {
+	cfg := &vsphere.SourceConfig{
+		VCenter:               makeVCenterConfig(vms, tlsConfig),
+		KVConfigMap:           names.ConfigMap(vms),
+		Sink:                  vms.Status.SinkURI.String(),
+		IncludeTasks:          vms.Spec.IncludeTasks,
+		IncludeContentLibrary: vms.Spec.IncludeContentLibrary,
+		IncludeTags:           vms.Spec.IncludeTags,
+		NormalizeAlarms:       vms.Spec.NormalizeAlarms,
+		GroupVSANEvents:       vms.Spec.GroupVSANEvents,
+		LifecycleEvents:       vms.Spec.LifecycleEvents,
+		LogOnly:               vms.Spec.LogOnly,
+		Extensions:            vms.Spec.ExtensionAttributes,
+		OutputFormat:          vms.Spec.OutputFormat,
+		PayloadEncoding:       vms.Spec.PayloadEncoding,
+		SinkContentMode:       vsphere.ContentModeBinary,
+		SinkCompression:       vsphere.CompressionNone,
+		PollInterval:          vsphere.CollectorDefaultPollInterval,
+		PageSize:              vsphere.CollectorDefaultPageSize,
+		CollectorMode:         vsphere.CollectorModePoll,
+	}
+
+	if ec := vms.Spec.EventCollector; ec != nil {
+		if ec.PollIntervalSeconds > 0 {
+			cfg.PollInterval = time.Second * time.Duration(ec.PollIntervalSeconds)
+		}
+		if ec.PageSize > 0 {
+			cfg.PageSize = int(ec.PageSize)
+		}
+		if ec.Mode != "" {
+			cfg.CollectorMode = ec.Mode
+		}
+	}
+
+	if sc := vms.Spec.Scope; sc != nil {
+		b, err := json.Marshal(vsphere.ScopeConfig{Path: sc.Path, ID: sc.ID})
+		if err != nil {
+			return nil, fmt.Errorf("marshal scope: %w", err)
+		}
+		cfg.Scope = string(b)
+	}
+
+	if vms.Spec.CloudEventOverrides != nil {
+		if co, err := json.Marshal(vms.Spec.SourceSpec.CloudEventOverrides); err != nil {
+			logging.FromContext(ctx).Errorf(
+				"Failed to marshal CloudEventOverrides into JSON for %+v, %v", vms, err)
+		} else if len(co) > 0 {
+			cfg.CEOverrides = string(co)
+		}
+	}
+
+	cpconf := vsphere.CheckpointConfig{
+		MaxAge: time.Second * time.Duration(vms.Spec.CheckpointConfig.MaxAgeSeconds),
+		Period: time.Second * time.Duration(vms.Spec.CheckpointConfig.PeriodSeconds),
+	}
+	if rf := vms.Spec.CheckpointConfig.ReplayFrom; rf != nil {
+		cpconf.ReplayFrom = &rf.Time
+	}
+	if s, ok := vms.Annotations[vsphere.CheckpointResetAnnotation]; ok {
+		resetAt, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("parse checkpoint reset annotation: %w", err)
+		}
+		cpconf.ResetAt = &resetAt
+	}
+	cpconf.Import = vms.Annotations[vsphere.CheckpointImportAnnotation]
+	cfg.RestartedAt = vms.Annotations[vsphere.RestartedAtAnnotation]
+
+	jsonBytes, err := json.Marshal(&cpconf)
+	if err != nil {
+		return nil, fmt.Errorf("marshal checkpoint config: %w", err)
+	}
+	cfg.CheckpointConfig = string(jsonBytes)
+
+	if f := vms.Spec.Filter; f != nil {
+		b, err := json.Marshal(vsphere.EventFilter{EventTypes: f.EventTypes, CEL: f.CEL})
+		if err != nil {
+			return nil, fmt.Errorf("marshal event filter: %w", err)
+		}
+		cfg.EventFilter = string(b)
+	}
+
+	if len(vms.Status.SinkURIs) > 0 {
+		sinks := make([]vsphere.Sink, 0, len(vms.Status.SinkURIs))
+		for i, uri := range vms.Status.SinkURIs {
+			sink := vsphere.Sink{URI: uri.String()}
+			if i < len(vms.Spec.Sinks) {
+				if f := vms.Spec.Sinks[i].Filter; f != nil {
+					sink.Filter = &vsphere.EventFilter{EventTypes: f.EventTypes, CEL: f.CEL}
+				}
+			}
+			sinks = append(sinks, sink)
+		}
+		b, err := json.Marshal(sinks)
+		if err != nil {
+			return nil, fmt.Errorf("marshal sinks: %w", err)
+		}
+		cfg.Sinks = string(b)
+	}
+
+	if k := vms.Spec.Kafka; k != nil {
+		kafka := vsphere.KafkaConfig{
+			BootstrapServers: k.BootstrapServers,
+			Topic:            k.Topic,
+		}
+		if k.SASL != nil {
+			kafka.SASLMechanism = k.SASL.Mechanism
+			if kafka.SASLMechanism == "" {
+				kafka.SASLMechanism = vsphere.KafkaSASLPlain
+			}
+			kafka.SecretPath = vsphere.KafkaSecretMountPath
+		}
+		if k.TLS != nil {
+			kafka.TLS = true
+			if k.TLS.CACertsConfigMapRef != nil {
+				kafka.CACertsPath = vsphere.KafkaCACertsMountPath
+			}
+		}
+		b, err := json.Marshal(kafka)
+		if err != nil {
+			return nil, fmt.Errorf("marshal kafka config: %w", err)
+		}
+		cfg.Kafka = string(b)
+	}
+
+	if cfg.OutputFormat == "" {
+		cfg.OutputFormat = vsphere.OutputFormatCloudEvents
+	}
+	if cfg.PayloadEncoding == "" {
+		cfg.PayloadEncoding = vsphere.PayloadEncodingJSON
+	}
+
+	if m := vms.Spec.AttributeMapping; m != nil {
+		b, err := json.Marshal(vsphere.AttributeMapping{
+			Source:       m.Source,
+			TypePrefix:   m.TypePrefix,
+			TypeTemplate: m.TypeTemplate,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("marshal attribute mapping: %w", err)
+		}
+		cfg.AttributeMapping = string(b)
+	}
+
+	if hb := vms.Spec.Heartbeat; hb != nil {
+		cfg.HeartbeatInterval = vsphere.HeartbeatDefaultInterval
+		if hb.IntervalSeconds > 0 {
+			cfg.HeartbeatInterval = time.Second * time.Duration(hb.IntervalSeconds)
+		}
+	}
+
+	if rl := vms.Spec.RateLimit; rl != nil {
+		b, err := json.Marshal(vsphere.RateLimit{
+			EventsPerSecond: rl.EventsPerSecond,
+			Burst:           rl.Burst,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("marshal rate limit: %w", err)
+		}
+		cfg.RateLimit = string(b)
+	}
+
+	if sm := vms.Spec.Sampling; sm != nil {
+		b, err := json.Marshal(vsphere.Sampling{
+			Window:    time.Second * time.Duration(sm.WindowSeconds),
+			Threshold: sm.Threshold,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("marshal sampling: %w", err)
+		}
+		cfg.Sampling = string(b)
+	}
+
+	if t := vms.Spec.Transform; t != nil {
+		b, err := json.Marshal(vsphere.Transform{Template: t.Template})
+		if err != nil {
+			return nil, fmt.Errorf("marshal transform: %w", err)
+		}
+		cfg.Transform = string(b)
+	}
+
+	if rd := vms.Spec.Redaction; rd != nil {
+		b, err := json.Marshal(vsphere.Redaction{
+			Fields: rd.Fields,
+			Mode:   rd.Mode,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("marshal redaction: %w", err)
+		}
+		cfg.Redaction = string(b)
+	}
+
+	if e := vms.Spec.Enrichment; e != nil {
+		b, err := json.Marshal(vsphere.Enrichment{
+			Tags:             e.Tags,
+			FolderPath:       e.FolderPath,
+			CustomAttributes: e.CustomAttributes,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("marshal enrichment: %w", err)
+		}
+		cfg.Enrichment = string(b)
+	}
+
+	if d := vms.Spec.Delivery; d != nil {
+		if len(d.Headers) > 0 {
+			b, err := json.Marshal(d.Headers)
+			if err != nil {
+				return nil, fmt.Errorf("marshal sink headers: %w", err)
+			}
+			cfg.SinkHeaders = string(b)
+		}
+
+		if d.ContentMode != "" {
+			cfg.SinkContentMode = d.ContentMode
+		}
+
+		if d.Compression != "" {
+			cfg.SinkCompression = d.Compression
+		}
+
+		cfg.SinkMaxAttempts = int(d.MaxAttempts)
+	}
+
+	if vms.Status.DeadLetterSinkURI != nil {
+		cfg.DeadLetterSink = vms.Status.DeadLetterSinkURI.String()
+	}
+
+	if vms.Status.MirrorSinkURI != nil {
+		cfg.MirrorSink = vms.Status.MirrorSinkURI.String()
+	}
+
+	if vms.Status.SinkAudience != nil {
+		cfg.SinkAudience = *vms.Status.SinkAudience
+	}
+
+	if vms.Status.SinkCACerts != nil {
+		cfg.SinkCACerts = *vms.Status.SinkCACerts
+	}
+
+	if a := vms.Spec.Audit; a != nil {
+		ac := vsphere.AuditConfig{Path: a.Path}
+		if vms.Status.AuditSinkURI != nil {
+			ac.Sink = vms.Status.AuditSinkURI.String()
+		}
+		b, err := json.Marshal(ac)
+		if err != nil {
+			return nil, fmt.Errorf("marshal audit config: %w", err)
+		}
+		cfg.Audit = string(b)
+	}
+
+	// the adapter logs like the controller, the logging level annotation of
+	// the source is applied by the adapter itself
+	lc, err := logging.ConfigToJSON(config.FromContextOrDefaults(ctx).Logging)
+	if err != nil {
+		return nil, fmt.Errorf("marshal logging config: %w", err)
+	}
+	cfg.LoggingConfig = lc
+
+	return cfg, nil
+}
+
+// makeVCenterConfig returns the vCenter configuration of the given source for
+// the shared adapter, which reads the credentials through the Kubernetes API
+// instead of the secret mounted by the VSphereBinding.
+func makeVCenterConfig(vms *v1alpha1.VSphereSource, tlsConfig vsphere.TLSConfig) vsphere.EnvConfig {
+	vc := vsphere.EnvConfig{
+		Address:            vms.Spec.Address.String(),
+		FallbackAddress:    vms.Spec.FallbackAddress.String(),
+		Locale:             vms.Spec.Locale,
+		Insecure:           vms.Spec.SkipTLSVerify,
+		TLSMinVersion:      tlsConfig.MinVersion,
+		TLSCipherSuites:    tlsConfig.CipherSuites,
+		AuthMethod:         vms.Spec.AuthMethod,
+		CredentialProvider: vsphere.CredentialProviderKubernetes,
+		SecretName:         vms.Spec.SecretRef.Name,
+		SecretNamespace:    vms.Namespace,
+		API:                makeAPIConfig(vms),
+	}
+	if vc.AuthMethod == "" {
+		vc.AuthMethod = vsphere.AuthMethodBasic
+	}
+
+	if p := vms.Spec.CredentialProvider; p != nil && p.Vault != nil {
+		vc.CredentialProvider = vsphere.CredentialProviderVault
+		vc.SecretName, vc.SecretNamespace = "", ""
+		vc.Vault = vsphere.VaultConfig{
+			Address:  p.Vault.Address.String(),
+			Path:     p.Vault.Path,
+			Role:     p.Vault.Role,
+			AuthPath: p.Vault.AuthPath,
+		}
+		if vc.Vault.AuthPath == "" {
+			vc.Vault.AuthPath = "kubernetes"
+		}
+	}
+	return vc
+}
+
+// CheckSharedAdapter returns an error if the given source uses a feature which
+// requires mounting objects into the adapter, which is not supported by the
+// shared adapter.
+func CheckSharedAdapter(vms *v1alpha1.VSphereSource) error {
+	if vms.Spec.CACertsConfigMapRef != nil {
+		return errors.New("caCertsConfigMapRef is not supported by the shared adapter")
+	}
+	if len(vms.Spec.Addresses) > 0 {
+		return errors.New("addresses is not supported by the shared adapter")
+	}
+	if vms.Spec.Kafka != nil {
+		return errors.New("kafka is not supported by the shared adapter")
+	}
+	if d := vms.Spec.Delivery; d != nil {
+		if d.HeadersSecretRef != nil {
+			return errors.New("delivery.headersSecretRef is not supported by the shared adapter")
+		}
+		if d.CACertsConfigMapRef != nil {
+			return errors.New("delivery.caCertsConfigMapRef is not supported by the shared adapter")
+		}
+		if d.RequireTLS {
+			return errors.New("delivery.requireTLS is not supported by the shared adapter")
+		}
+	}
+	if vms.Spec.AdapterOverrides != nil {
+		return errors.New("adapterOverrides is not supported by the shared adapter")
+	}
+	if a := vms.Spec.Audit; a != nil && a.Path != "" {
+		return errors.New("audit.path is not supported by the shared adapter")
+	}
+	return nil
+}
+
+func sharedAdapterMeta(ns string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      names.SharedAdapter,
+		Namespace: ns,
+		Labels: map[string]string{
+			SharedAdapterLabel: "true",
+		},
+	}
+}
+
+// MakeSharedAdapterConfigMap creates the tenant ConfigMap of the shared
+// adapter in the given namespace, holding the configurations of its sources
+// keyed by source name.
+func MakeSharedAdapterConfigMap(ns string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: sharedAdapterMeta(ns),
+		Data:       map[string]string{},
+	}
+}
+
+// MakeSharedAdapterServiceAccount creates the ServiceAccount of the shared
+// adapter in the given namespace.
+func MakeSharedAdapterServiceAccount(ns string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: sharedAdapterMeta(ns),
+	}
+}
+
+// MakeSharedAdapterRoleBinding creates a RoleBinding for the service account
+// of the shared adapter in the given namespace, which allows it to read the
+// tenant configmap and the vCenter credentials, and to store the state of
+// its sources in configmaps.
+func MakeSharedAdapterRoleBinding(ns string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: sharedAdapterMeta(ns),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     "shared-receive-adapter",
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      "ServiceAccount",
+			Namespace: ns,
+			Name:      names.SharedAdapter,
+		}},
+	}
+}
+
+// MakeSharedAdapterDeployment creates the Deployment of the shared adapter in
+// the given namespace, which serves all sources in the tenant configmap. The
+// given TLS configuration applies to the connections to the sinks.
+func MakeSharedAdapterDeployment(ctx context.Context, ns, adapterImage string, adapterResources corev1.ResourceRequirements, tlsConfig vsphere.TLSConfig) (*appsv1.Deployment, error) {
+	labels := map[string]string{
+		SharedAdapterLabel: "true",
+	}
+
+	metricsConfig, err := makeMetricsConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loggingConfig, err := logging.ConfigToJSON(config.FromContextOrDefaults(ctx).Logging)
+	if err != nil {
+		return nil, fmt.Errorf("marshal logging config: %w", err)
+	}
+
+	return &appsv1.Deployment{
+		ObjectMeta: sharedAdapterMeta(ns),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.Int32(1),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: names.SharedAdapter,
+					Containers: []corev1.Container{{
+						Name:      "adapter",
+						Image:     adapterImage,
+						Resources: adapterResources,
+						Env: []corev1.EnvVar{{
+							Name: "NAMESPACE",
+							ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{
+									FieldPath: "metadata.namespace",
+								},
+							},
+						}, {
+							Name: "NAME",
+							ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{
+									FieldPath: "metadata.name",
+								},
+							},
+						}, {
+							Name:  "K_METRICS_CONFIG",
+							Value: metricsConfig,
+						}, {
+							Name:  "K_LOGGING_CONFIG",
+							Value: loggingConfig,
+						}, {
+							Name: "VSPHERE_SERVICE_ACCOUNT",
+							ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{
+									FieldPath: "spec.serviceAccountName",
+								},
+							},
+						}, {
+							Name:  "VSPHERE_SHARED_ADAPTER_CONFIGMAP",
+							Value: names.SharedAdapter,
+						}, {
+							Name:  "VSPHERE_SINK_TLS_MIN_VERSION",
+							Value: tlsConfig.MinVersion,
+						}, {
+							Name:  "VSPHERE_SINK_TLS_CIPHER_SUITES",
+							Value: tlsConfig.CipherSuites,
+						}},
+					}},
+				},
+			},
+		},
+	}, nil
+}
+
+// makeAPIConfig returns the timeout and retries of the vCenter API calls of the
+// given source, the zero value if not configured
+func makeAPIConfig(vms *v1alpha1.VSphereSource) vsphere.APIConfig {
+	api := vms.Spec.VCenterAPI
+	if api == nil {
+		return vsphere.APIConfig{}
+	}
+	return vsphere.APIConfig{
+		Timeout:         time.Second * time.Duration(api.TimeoutSeconds),
+		Retries:         int(api.Retries),
+		RetryBackoff:    time.Second * time.Duration(api.RetryBackoffSeconds),
+		MaxRetryBackoff: time.Second * time.Duration(api.MaxRetryBackoffSeconds),
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/config"
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources"
+	resourcenames "github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// adapterModeDedicated runs a receive adapter deployment per source
+	adapterModeDedicated = "dedicated"
+	// adapterModeShared runs one receive adapter deployment per namespace,
+	// serving all sources of the namespace
+	adapterModeShared = "shared"
+)
+
+// reconcileSharedAdapter makes sure that the given source is served by the
+// shared adapter of its namespace instead of a dedicated adapter.
+func (r *Reconciler) reconcileSharedAdapter(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) error {
+	ns := vms.Namespace
+
+	if err := resources.CheckSharedAdapter(vms); err != nil {
+		vms.Status.MarkSharedAdapterUnsupported("%v", err)
+		return controller.NewPermanentError(err)
+	}
+
+	// Stop the dedicated adapter of the source, e.g. after switching modes,
+	// so that events are not sent twice.
+	if err := r.deleteDedicatedAdapter(ctx, vms); err != nil {
+		return err
+	}
+
+	// The checkpoint of a paused source is kept in its own configmap.
+	if vms.Spec.Paused {
+		if err := r.removeTenant(ctx, vms); err != nil {
+			return err
+		}
+		vms.Status.MarkAuthSharedAdapter()
+		vms.Status.MarkAdapterPaused()
+		return nil
+	}
+
+	defaults, err := config.DefaultsForNamespace(ctx, r.cmLister, ns)
+	if err != nil {
+		return fmt.Errorf("failed to get defaults of namespace %q: %w", ns, err)
+	}
+
+	cfg, err := resources.MakeSourceConfig(ctx, vms, defaults.TLSConfig())
+	if err != nil {
+		return fmt.Errorf("failed to make source config: %w", err)
+	}
+	if err := r.reconcileTenant(ctx, vms, cfg); err != nil {
+		return err
+	}
+
+	if _, err := r.saLister.ServiceAccounts(ns).Get(resourcenames.SharedAdapter); apierrs.IsNotFound(err) {
+		if _, err := r.kubeclient.CoreV1().ServiceAccounts(ns).Create(ctx, resources.MakeSharedAdapterServiceAccount(ns), metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create serviceaccount %q: %w", resourcenames.SharedAdapter, err)
+		}
+		logging.FromContext(ctx).Infof("Created serviceaccount %q", resourcenames.SharedAdapter)
+	} else if err != nil {
+		return fmt.Errorf("failed to get serviceaccount %q: %w", resourcenames.SharedAdapter, err)
+	}
+
+	if _, err := r.rbacLister.RoleBindings(ns).Get(resourcenames.SharedAdapter); apierrs.IsNotFound(err) {
+		if _, err := r.kubeclient.RbacV1().RoleBindings(ns).Create(ctx, resources.MakeSharedAdapterRoleBinding(ns), metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create rolebinding %q: %w", resourcenames.SharedAdapter, err)
+		}
+		logging.FromContext(ctx).Infof("Created rolebinding %q", resourcenames.SharedAdapter)
+	} else if err != nil {
+		return fmt.Errorf("failed to get rolebinding %q: %w", resourcenames.SharedAdapter, err)
+	}
+
+	desired, err := resources.MakeSharedAdapterDeployment(ctx, ns, r.sharedAdapterImage, defaults.AdapterResources(), defaults.TLSConfig())
+	if err != nil {
+		return fmt.Errorf("failed to make deployment %q: %w", resourcenames.SharedAdapter, err)
+	}
+
+	deployment, err := r.deploymentLister.Deployments(ns).Get(resourcenames.SharedAdapter)
+	if apierrs.IsNotFound(err) {
+		deployment, err = r.kubeclient.AppsV1().Deployments(ns).Create(ctx, desired, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create deployment %q: %w", resourcenames.SharedAdapter, err)
+		}
+		logging.FromContext(ctx).Infof("Created deployment %q", resourcenames.SharedAdapter)
+	} else if err != nil {
+		return fmt.Errorf("failed to get deployment %q: %w", resourcenames.SharedAdapter, err)
+	} else {
+		// The deployment exists, but make sure that it has the shape that we expect.
+		deployment = deployment.DeepCopy()
+		deployment.Spec = desired.Spec
+		deployment, err = r.kubeclient.AppsV1().Deployments(ns).Update(ctx, deployment, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update deployment %q: %w", resourcenames.SharedAdapter, err)
+		}
+	}
+
+	// The shared adapter reads the credentials itself instead of a VSphereBinding
+	vms.Status.MarkAuthSharedAdapter()
+	vms.Status.PropagateAdapterStatus(deployment.Status)
+
+	return nil
+}
+
+// reconcileTenant makes sure that the tenant configmap of the shared adapter
+// holds the given configuration of the source.
+func (r *Reconciler) reconcileTenant(ctx context.Context, vms *sourcesv1alpha1.VSphereSource, cfg *vsphere.SourceConfig) error {
+	ns := vms.Namespace
+	name := resourcenames.SharedAdapter
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal source config: %w", err)
+	}
+
+	cm, err := r.cmLister.ConfigMaps(ns).Get(name)
+	if apierrs.IsNotFound(err) {
+		cm = resources.MakeSharedAdapterConfigMap(ns)
+		cm.Data[vms.Name] = string(b)
+		if _, err := r.kubeclient.CoreV1().ConfigMaps(ns).Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create configmap %q: %w", name, err)
+		}
+		logging.FromContext(ctx).Infof("Created configmap %q", name)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get configmap %q: %w", name, err)
+	}
+
+	if cm.Data[vms.Name] == string(b) {
+		return nil
+	}
+	cm = cm.DeepCopy()
+	if cm.Data == nil {
+		cm.Data = make(map[string]string, 1)
+	}
+	cm.Data[vms.Name] = string(b)
+	if _, err := r.kubeclient.CoreV1().ConfigMaps(ns).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update configmap %q: %w", name, err)
+	}
+	return nil
+}
+
+// removeTenant removes the given source from the tenant configmap of the
+// shared adapter. The shared adapter is deleted with its last source.
+func (r *Reconciler) removeTenant(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) error {
+	ns := vms.Namespace
+	name := resourcenames.SharedAdapter
+
+	cm, err := r.cmLister.ConfigMaps(ns).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get configmap %q: %w", name, err)
+	}
+	if _, ok := cm.Data[vms.Name]; !ok {
+		return nil
+	}
+
+	if len(cm.Data) == 1 {
+		return r.deleteSharedAdapter(ctx, ns)
+	}
+
+	cm = cm.DeepCopy()
+	delete(cm.Data, vms.Name)
+	if _, err := r.kubeclient.CoreV1().ConfigMaps(ns).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update configmap %q: %w", name, err)
+	}
+	return nil
+}
+
+// deleteSharedAdapter deletes the deployment, tenant configmap, rolebinding
+// and service account of the shared adapter in the given namespace.
+func (r *Reconciler) deleteSharedAdapter(ctx context.Context, ns string) error {
+	name := resourcenames.SharedAdapter
+
+	err := r.kubeclient.AppsV1().Deployments(ns).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete deployment %q: %w", name, err)
+	}
+	err = r.kubeclient.RbacV1().RoleBindings(ns).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete rolebinding %q: %w", name, err)
+	}
+	err = r.kubeclient.CoreV1().ServiceAccounts(ns).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete serviceaccount %q: %w", name, err)
+	}
+	// The tenant configmap goes last, so that a failed deletion is retried.
+	err = r.kubeclient.CoreV1().ConfigMaps(ns).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete configmap %q: %w", name, err)
+	}
+
+	logging.FromContext(ctx).Infof("Deleted shared adapter %q", name)
+	return nil
+}
+
+// deleteDedicatedAdapter deletes the dedicated adapter deployment of the given
+// source if it exists.
+func (r *Reconciler) deleteDedicatedAdapter(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) error {
+	name := resourcenames.Deployment(vms)
+
+	deployment, err := r.deploymentLister.Deployments(vms.Namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get deployment %q: %w", name, err)
+	}
+	if !metav1.IsControlledBy(deployment, vms) {
+		return nil
+	}
+
+	err = r.kubeclient.AppsV1().Deployments(vms.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete deployment %q: %w", name, err)
+	}
+	logging.FromContext(ctx).Infof("Deleted deployment %q", name)
+	return nil
+}
+
+// enqueueNamespace returns a handler which enqueues all sources in the
+// namespace of the given object, e.g. after the shared adapter changed.
+func (r *Reconciler) enqueueNamespace(enqueue func(types.NamespacedName)) func(obj interface{}) {
+	return func(obj interface{}) {
+		acc, err := kmeta.DeletionHandlingAccessor(obj)
+		if err != nil {
+			return
+		}
+		sources, err := r.vsphereLister.VSphereSources(acc.GetNamespace()).List(labels.Everything())
+		if err != nil {
+			return
+		}
+		for _, vms := range sources {
+			enqueue(types.NamespacedName{Namespace: vms.Namespace, Name: vms.Name})
+		}
+	}
+}
@@ -0,0 +1,436 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+)
+
+func TestMakeSourceConfigVCenter(t *testing.T) {
+	source := func(p *sourcesv1alpha1.VCredentialProviderSpec, fallback *apis.URL, locale string) *sourcesv1alpha1.VSphereSource {
+		vms := &sourcesv1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"},
+		}
+		vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+		vms.Spec.SkipTLSVerify = true
+		vms.Spec.SecretRef = corev1.LocalObjectReference{Name: "vsphere-credentials"}
+		vms.Spec.CredentialProvider = p
+		vms.Spec.FallbackAddress = fallback
+		vms.Spec.Locale = locale
+		vms.Status.SinkURI = &apis.URL{Scheme: "http", Host: "sink.example.com"}
+		return vms
+	}
+
+	tests := []struct {
+		name     string
+		provider *sourcesv1alpha1.VCredentialProviderSpec
+		fallback *apis.URL
+		locale   string
+		tls      vsphere.TLSConfig
+		want     vsphere.EnvConfig
+	}{{
+		name: "secret",
+		want: vsphere.EnvConfig{
+			Address:            "https://vcenter.example.com",
+			Insecure:           true,
+			AuthMethod:         vsphere.AuthMethodBasic,
+			CredentialProvider: vsphere.CredentialProviderKubernetes,
+			SecretName:         "vsphere-credentials",
+			SecretNamespace:    "ns",
+		},
+	}, {
+		name: "tls restrictions",
+		tls:  vsphere.TLSConfig{MinVersion: "1.2", CipherSuites: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+		want: vsphere.EnvConfig{
+			Address:            "https://vcenter.example.com",
+			Insecure:           true,
+			AuthMethod:         vsphere.AuthMethodBasic,
+			CredentialProvider: vsphere.CredentialProviderKubernetes,
+			SecretName:         "vsphere-credentials",
+			SecretNamespace:    "ns",
+			TLSMinVersion:      "1.2",
+			TLSCipherSuites:    "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+		},
+	}, {
+		name:     "fallback address",
+		fallback: &apis.URL{Scheme: "https", Host: "vcenter-2.example.com"},
+		want: vsphere.EnvConfig{
+			Address:            "https://vcenter.example.com",
+			FallbackAddress:    "https://vcenter-2.example.com",
+			Insecure:           true,
+			AuthMethod:         vsphere.AuthMethodBasic,
+			CredentialProvider: vsphere.CredentialProviderKubernetes,
+			SecretName:         "vsphere-credentials",
+			SecretNamespace:    "ns",
+		},
+	}, {
+		name:   "locale",
+		locale: "de",
+		want: vsphere.EnvConfig{
+			Address:            "https://vcenter.example.com",
+			Insecure:           true,
+			AuthMethod:         vsphere.AuthMethodBasic,
+			CredentialProvider: vsphere.CredentialProviderKubernetes,
+			SecretName:         "vsphere-credentials",
+			SecretNamespace:    "ns",
+			Locale:             "de",
+		},
+	}, {
+		name: "vault",
+		provider: &sourcesv1alpha1.VCredentialProviderSpec{Vault: &sourcesv1alpha1.VVaultSpec{
+			Address: apis.URL{Scheme: "https", Host: "vault.example.com"},
+			Path:    "secret/data/vcenter",
+			Role:    "vsphere",
+		}},
+		want: vsphere.EnvConfig{
+			Address:            "https://vcenter.example.com",
+			Insecure:           true,
+			AuthMethod:         vsphere.AuthMethodBasic,
+			CredentialProvider: vsphere.CredentialProviderVault,
+			Vault: vsphere.VaultConfig{
+				Address:  "https://vault.example.com",
+				Path:     "secret/data/vcenter",
+				Role:     "vsphere",
+				AuthPath: "kubernetes",
+			},
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := resources.MakeSourceConfig(context.Background(), source(tt.provider, tt.fallback, tt.locale), tt.tls)
+			if err != nil {
+				t.Fatalf("MakeSourceConfig() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, cfg.VCenter); diff != "" {
+				t.Errorf("MakeSourceConfig() vcenter (-want, +got) = %s", diff)
+			}
+			if cfg.KVConfigMap != "src-configmap" || cfg.Sink != "http://sink.example.com" {
+				t.Errorf("MakeSourceConfig() = %+v, want kvstore src-configmap and sink http://sink.example.com", cfg)
+			}
+		})
+	}
+}
+
+func TestMakeSourceConfigRestart(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns", Annotations: map[string]string{
+			vsphere.RestartedAtAnnotation:     "2021-03-01T08:00:00Z",
+			vsphere.CheckpointResetAnnotation: "2021-03-01T08:00:00Z",
+		}},
+	}
+	vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vms.Status.SinkURI = &apis.URL{Scheme: "http", Host: "sink.example.com"}
+
+	cfg, err := resources.MakeSourceConfig(context.Background(), vms, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeSourceConfig() error = %v", err)
+	}
+	if cfg.RestartedAt != "2021-03-01T08:00:00Z" {
+		t.Errorf("MakeSourceConfig() restartedAt = %q, want the restart annotation", cfg.RestartedAt)
+	}
+	if want := `{"maxAge":"0s","period":"0s","resetAt":"2021-03-01T08:00:00Z"}`; cfg.CheckpointConfig != want {
+		t.Errorf("MakeSourceConfig() checkpointConfig = %s, want %s", cfg.CheckpointConfig, want)
+	}
+
+	d, err := resources.MakeDeployment(context.Background(), vms, "adapter", corev1.ResourceRequirements{}, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeDeployment() error = %v", err)
+	}
+	if got := d.Spec.Template.Annotations[vsphere.RestartedAtAnnotation]; got != "2021-03-01T08:00:00Z" {
+		t.Errorf("MakeDeployment() pod annotation %s = %q, want the restart annotation", vsphere.RestartedAtAnnotation, got)
+	}
+
+	vms.Annotations[vsphere.CheckpointResetAnnotation] = "now"
+	if _, err := resources.MakeSourceConfig(context.Background(), vms, vsphere.TLSConfig{}); err == nil {
+		t.Error("MakeSourceConfig() with invalid checkpoint reset annotation did not fail")
+	}
+}
+
+func TestMakeSourceConfigCheckpointImport(t *testing.T) {
+	const imported = `{"vCenter":"vcenter.example.com","lastEventKey":42,"lastEventKeyTimestamp":"2021-03-01T08:00:00Z","createdTimestamp":"2021-03-02T08:00:00Z"}`
+	vms := &sourcesv1alpha1.VSphereSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns", Annotations: map[string]string{
+			vsphere.CheckpointImportAnnotation: imported,
+		}},
+	}
+	vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vms.Status.SinkURI = &apis.URL{Scheme: "http", Host: "sink.example.com"}
+
+	cfg, err := resources.MakeSourceConfig(context.Background(), vms, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeSourceConfig() error = %v", err)
+	}
+	var cpconf vsphere.CheckpointConfig
+	if err := json.Unmarshal([]byte(cfg.CheckpointConfig), &cpconf); err != nil {
+		t.Fatalf("unmarshal checkpoint config %s: %v", cfg.CheckpointConfig, err)
+	}
+	if cpconf.Import != imported {
+		t.Errorf("MakeSourceConfig() checkpoint import = %s, want %s", cpconf.Import, imported)
+	}
+}
+
+func TestMakeSourceConfigScope(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"},
+	}
+	vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vms.Spec.Scope = &sourcesv1alpha1.VScopeSpec{Path: "/dc1/host/cluster1"}
+	vms.Status.SinkURI = &apis.URL{Scheme: "http", Host: "sink.example.com"}
+
+	cfg, err := resources.MakeSourceConfig(context.Background(), vms, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeSourceConfig() error = %v", err)
+	}
+	if want := `{"path":"/dc1/host/cluster1"}`; cfg.Scope != want {
+		t.Errorf("MakeSourceConfig() scope = %s, want %s", cfg.Scope, want)
+	}
+}
+
+func TestMakeSourceConfigSampling(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"}}
+	vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vms.Spec.Sampling = &sourcesv1alpha1.VSamplingSpec{WindowSeconds: 30, Threshold: 2}
+
+	cfg, err := resources.MakeSourceConfig(context.Background(), vms, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeSourceConfig() error = %v", err)
+	}
+	if want := `{"window":30000000000,"threshold":2}`; cfg.Sampling != want {
+		t.Errorf("MakeSourceConfig() sampling = %s, want %s", cfg.Sampling, want)
+	}
+}
+
+func TestMakeSourceConfigVCenterAPI(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"}}
+	vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vms.Spec.VCenterAPI = &sourcesv1alpha1.VAPISpec{TimeoutSeconds: 30, Retries: 3, RetryBackoffSeconds: 2}
+
+	cfg, err := resources.MakeSourceConfig(context.Background(), vms, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeSourceConfig() error = %v", err)
+	}
+	want := vsphere.APIConfig{Timeout: 30 * time.Second, Retries: 3, RetryBackoff: 2 * time.Second}
+	if cfg.VCenter.API != want {
+		t.Errorf("MakeSourceConfig() vcenter api = %+v, want %+v", cfg.VCenter.API, want)
+	}
+}
+
+func TestMakeSourceConfigHeartbeat(t *testing.T) {
+	tests := []struct {
+		name      string
+		heartbeat *sourcesv1alpha1.VHeartbeatSpec
+		want      time.Duration
+	}{
+		{name: "disabled"},
+		{name: "default interval", heartbeat: &sourcesv1alpha1.VHeartbeatSpec{}, want: vsphere.HeartbeatDefaultInterval},
+		{name: "interval", heartbeat: &sourcesv1alpha1.VHeartbeatSpec{IntervalSeconds: 15}, want: 15 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vms := &sourcesv1alpha1.VSphereSource{ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"}}
+			vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+			vms.Spec.Heartbeat = tt.heartbeat
+
+			cfg, err := resources.MakeSourceConfig(context.Background(), vms, vsphere.TLSConfig{})
+			if err != nil {
+				t.Fatalf("MakeSourceConfig() error = %v", err)
+			}
+			if cfg.HeartbeatInterval != tt.want {
+				t.Errorf("MakeSourceConfig() heartbeatInterval = %v, want %v", cfg.HeartbeatInterval, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeSourceConfigSinks(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"}}
+	vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vms.Spec.Sinks = []sourcesv1alpha1.VSinkSpec{{
+		Destination: duckv1.Destination{URI: apis.HTTP("audit.example.com")},
+	}, {
+		Destination: duckv1.Destination{URI: apis.HTTP("metrics.example.com")},
+		Filter:      &sourcesv1alpha1.VFilterSpec{EventTypes: []string{"com.vmware.vsphere.alarm.*"}},
+	}}
+	vms.Status.SinkURIs = []apis.URL{*apis.HTTP("audit.example.com"), *apis.HTTP("metrics.example.com")}
+
+	cfg, err := resources.MakeSourceConfig(context.Background(), vms, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeSourceConfig() error = %v", err)
+	}
+	want := `[{"uri":"http://audit.example.com"},{"uri":"http://metrics.example.com","filter":{"eventTypes":["com.vmware.vsphere.alarm.*"]}}]`
+	if cfg.Sinks != want {
+		t.Errorf("MakeSourceConfig() sinks = %s, want %s", cfg.Sinks, want)
+	}
+}
+
+func TestMakeSourceConfigMirror(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"}}
+	vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vms.Spec.Mirror = &sourcesv1alpha1.VMirrorSpec{
+		Sink: duckv1.Destination{URI: apis.HTTP("candidate.example.com")},
+	}
+	vms.Status.MirrorSinkURI = apis.HTTP("candidate.example.com")
+
+	cfg, err := resources.MakeSourceConfig(context.Background(), vms, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeSourceConfig() error = %v", err)
+	}
+	if want := "http://candidate.example.com"; cfg.MirrorSink != want {
+		t.Errorf("MakeSourceConfig() mirrorSink = %s, want %s", cfg.MirrorSink, want)
+	}
+}
+
+func TestMakeSourceConfigDeadLetterSink(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"}}
+	vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vms.Spec.Delivery = &sourcesv1alpha1.VDeliverySpec{
+		MaxAttempts:    5,
+		DeadLetterSink: &duckv1.Destination{URI: apis.HTTP("dead-letters.example.com")},
+	}
+	vms.Status.DeadLetterSinkURI = apis.HTTP("dead-letters.example.com")
+
+	cfg, err := resources.MakeSourceConfig(context.Background(), vms, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeSourceConfig() error = %v", err)
+	}
+	if cfg.SinkMaxAttempts != 5 {
+		t.Errorf("MakeSourceConfig() sinkMaxAttempts = %d, want 5", cfg.SinkMaxAttempts)
+	}
+	if want := "http://dead-letters.example.com"; cfg.DeadLetterSink != want {
+		t.Errorf("MakeSourceConfig() deadLetterSink = %s, want %s", cfg.DeadLetterSink, want)
+	}
+}
+
+func TestMakeSourceConfigAudit(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"}}
+	vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vms.Spec.Audit = &sourcesv1alpha1.VAuditSpec{
+		Sink: &duckv1.Destination{URI: apis.HTTP("audit.example.com")},
+		Path: "/var/audit/events.log",
+	}
+	vms.Status.AuditSinkURI = apis.HTTP("audit.example.com")
+
+	cfg, err := resources.MakeSourceConfig(context.Background(), vms, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeSourceConfig() error = %v", err)
+	}
+	if want := `{"sink":"http://audit.example.com","path":"/var/audit/events.log"}`; cfg.Audit != want {
+		t.Errorf("MakeSourceConfig() audit = %s, want %s", cfg.Audit, want)
+	}
+}
+
+func TestMakeSourceConfigKafka(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"}}
+	vms.Spec.Address = apis.URL{Scheme: "https", Host: "vcenter.example.com"}
+	vms.Spec.Kafka = &sourcesv1alpha1.VKafkaSpec{
+		BootstrapServers: []string{"kafka:9092"},
+		Topic:            "vsphere-events",
+		SASL:             &sourcesv1alpha1.VKafkaSASLSpec{SecretRef: corev1.LocalObjectReference{Name: "kafka-creds"}},
+	}
+
+	cfg, err := resources.MakeSourceConfig(context.Background(), vms, vsphere.TLSConfig{})
+	if err != nil {
+		t.Fatalf("MakeSourceConfig() error = %v", err)
+	}
+	want := `{"bootstrapServers":["kafka:9092"],"topic":"vsphere-events","saslMechanism":"PLAIN","secretPath":"/var/bindings/kafka"}`
+	if cfg.Kafka != want {
+		t.Errorf("MakeSourceConfig() kafka = %s, want %s", cfg.Kafka, want)
+	}
+	if cfg.Sink != "" {
+		t.Errorf("MakeSourceConfig() sink = %q, want none", cfg.Sink)
+	}
+}
+
+func TestCheckSharedAdapter(t *testing.T) {
+	ref := &corev1.LocalObjectReference{Name: "ref"}
+
+	tests := []struct {
+		name    string
+		spec    sourcesv1alpha1.VSphereSourceSpec
+		wantErr bool
+	}{{
+		name: "supported",
+		spec: sourcesv1alpha1.VSphereSourceSpec{
+			Delivery: &sourcesv1alpha1.VDeliverySpec{Headers: map[string]string{"X-Tenant": "a"}},
+		},
+	}, {
+		name: "vcenter ca certs",
+		spec: sourcesv1alpha1.VSphereSourceSpec{
+			VAuthSpec: sourcesv1alpha1.VAuthSpec{CACertsConfigMapRef: ref},
+		},
+		wantErr: true,
+	}, {
+		name: "sink headers secret",
+		spec: sourcesv1alpha1.VSphereSourceSpec{
+			Delivery: &sourcesv1alpha1.VDeliverySpec{HeadersSecretRef: ref},
+		},
+		wantErr: true,
+	}, {
+		name: "sink ca certs",
+		spec: sourcesv1alpha1.VSphereSourceSpec{
+			Delivery: &sourcesv1alpha1.VDeliverySpec{CACertsConfigMapRef: ref},
+		},
+		wantErr: true,
+	}, {
+		name: "require tls",
+		spec: sourcesv1alpha1.VSphereSourceSpec{
+			Delivery: &sourcesv1alpha1.VDeliverySpec{RequireTLS: true},
+		},
+		wantErr: true,
+	}, {
+		name: "additional vcenters",
+		spec: sourcesv1alpha1.VSphereSourceSpec{
+			Addresses: []sourcesv1alpha1.VAddressSpec{{SecretRef: *ref}},
+		},
+		wantErr: true,
+	}, {
+		name: "kafka",
+		spec: sourcesv1alpha1.VSphereSourceSpec{
+			Kafka: &sourcesv1alpha1.VKafkaSpec{BootstrapServers: []string{"kafka:9092"}, Topic: "events"},
+		},
+		wantErr: true,
+	}, {
+		name: "adapter overrides",
+		spec: sourcesv1alpha1.VSphereSourceSpec{
+			AdapterOverrides: &sourcesv1alpha1.VAdapterOverridesSpec{Env: []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}}},
+		},
+		wantErr: true,
+	}, {
+		name: "audit sink",
+		spec: sourcesv1alpha1.VSphereSourceSpec{
+			Audit: &sourcesv1alpha1.VAuditSpec{Sink: &duckv1.Destination{URI: apis.HTTP("audit.example.com")}},
+		},
+	}, {
+		name: "audit file",
+		spec: sourcesv1alpha1.VSphereSourceSpec{
+			Audit: &sourcesv1alpha1.VAuditSpec{Path: "/var/audit/events.log"},
+		},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := resources.CheckSharedAdapter(&sourcesv1alpha1.VSphereSource{Spec: tt.spec})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckSharedAdapter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/controller"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+)
+
+// builtinSinkKinds are the Addressable kinds which are always accepted as a
+// sink reference, regardless of their API version.
+var builtinSinkKinds = []schema.GroupKind{
+	{Group: "", Kind: "Service"},
+	{Group: "serving.knative.dev", Kind: "Service"},
+	{Group: "eventing.knative.dev", Kind: "Broker"},
+	{Group: "messaging.knative.dev", Kind: "Channel"},
+	{Group: "messaging.knative.dev", Kind: "InMemoryChannel"},
+}
+
+// sinkKinds is the set of custom Addressable kinds a VSphereSource may use as
+// its sink in addition to the built-in kinds. An empty set does not restrict
+// the sink kind, i.e. every Addressable is resolved by the generic resolver.
+type sinkKinds []schema.GroupVersionKind
+
+// parseSinkKinds parses a list of kinds in the form "<kind>.<version>.<group>",
+// e.g. "Gateway.v1alpha1.gateways.example.com". Kinds of the core API group
+// are specified as "<kind>.<version>".
+func parseSinkKinds(kinds []string) (sinkKinds, error) {
+	result := make(sinkKinds, 0, len(kinds))
+	for _, k := range kinds {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+
+		s := strings.SplitN(k, ".", 3)
+		if len(s) < 2 || s[0] == "" || s[1] == "" {
+			return nil, fmt.Errorf("invalid sink kind %q: expected <kind>.<version>.<group>", k)
+		}
+
+		gvk := schema.GroupVersionKind{Kind: s[0], Version: s[1]}
+		if len(s) == 3 {
+			gvk.Group = s[2]
+		}
+		result = append(result, gvk)
+	}
+	return result, nil
+}
+
+// Allowed returns an error if the sink reference of the given destination is
+// neither a built-in Addressable kind nor part of the configured set of custom
+// kinds. Destinations without a reference (URI only) are always allowed.
+func (sk sinkKinds) Allowed(dest duckv1.Destination) error {
+	if dest.Ref == nil || len(sk) == 0 {
+		return nil
+	}
+
+	gv, err := schema.ParseGroupVersion(dest.Ref.APIVersion)
+	if err != nil {
+		return fmt.Errorf("invalid sink apiVersion %q: %w", dest.Ref.APIVersion, err)
+	}
+	gvk := gv.WithKind(dest.Ref.Kind)
+
+	for _, gk := range builtinSinkKinds {
+		if gk == gvk.GroupKind() {
+			return nil
+		}
+	}
+
+	for _, k := range sk {
+		if k == gvk {
+			return nil
+		}
+	}
+
+	return &sinkKindNotAllowedError{kind: gvk}
+}
+
+// sinkKindNotAllowedError is the error of a sink reference whose kind is not
+// part of the configured set of custom kinds
+type sinkKindNotAllowedError struct {
+	kind schema.GroupVersionKind
+}
+
+func (e *sinkKindNotAllowedError) Error() string {
+	k := e.kind.Kind + "." + e.kind.Version
+	if e.kind.Group != "" {
+		k += "." + e.kind.Group
+	}
+	return fmt.Sprintf("sink kind %q is not allowed, add it to VSPHERE_SINK_KINDS of the webhook deployment to use it", k)
+}
+
+// resolveSink resolves the sink, its audience, the additional sinks, the mirror
+// sink, the dead letter sink and the audit sink of the given source into its
+// status. They are cleared if the source delivers its events to Kafka instead,
+// or only logs them, except for the audit sink. The dedicated adapter sends
+// events to the https addresses of the sinks if available, see sinkTLS.
+func (r *Reconciler) resolveSink(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) error {
+	// the shared adapter does not trust the CA certificates of its sources
+	tls := &sinkTLS{}
+	if r.adapterMode == adapterModeShared {
+		tls = nil
+	} else if d := vms.Spec.Delivery; d != nil {
+		tls.require = d.RequireTLS
+	}
+
+	auditURI, err := r.resolveAuditSink(ctx, vms, tls)
+	if err != nil {
+		return err
+	}
+	vms.Status.AuditSinkURI = auditURI
+
+	if vms.Spec.Kafka != nil || vms.Spec.LogOnly {
+		vms.Status.SinkURI = nil
+		vms.Status.SinkAudience = nil
+		vms.Status.SinkURIs = nil
+		vms.Status.MirrorSinkURI = nil
+		vms.Status.DeadLetterSinkURI = nil
+		vms.Status.SinkCACerts = tls.bundle()
+		return nil
+	}
+
+	if err := r.sinkKinds.Allowed(vms.Spec.Sink); err != nil {
+		return controller.NewPermanentError(err)
+	}
+
+	uri, err := r.resolver.URIFromDestinationV1(ctx, vms.Spec.Sink, vms)
+	if err != nil {
+		return err
+	}
+	// the sink is read once for both its https address and its audience
+	addressable, err := r.addressables.get(ctx, vms.Spec.Sink, vms.Namespace)
+	if err != nil {
+		return err
+	}
+	if uri, err = tls.resolve("sink", vms.Spec.Sink, addressable, uri); err != nil {
+		return err
+	}
+	if d := vms.Spec.Delivery; d != nil && d.Path != "" {
+		uri = appendSinkPath(uri, d.Path)
+	}
+	vms.Status.SinkURI = uri
+
+	audience, err := addressAudience(addressable)
+	if err != nil {
+		return err
+	}
+	vms.Status.SinkAudience = audience
+
+	uris, err := r.resolveSinks(ctx, vms, tls)
+	if err != nil {
+		return err
+	}
+	vms.Status.SinkURIs = uris
+
+	mirrorURI, err := r.resolveMirrorSink(ctx, vms, tls)
+	if err != nil {
+		return err
+	}
+	vms.Status.MirrorSinkURI = mirrorURI
+
+	deadLetterURI, err := r.resolveDeadLetterSink(ctx, vms, tls)
+	if err != nil {
+		return err
+	}
+	vms.Status.DeadLetterSinkURI = deadLetterURI
+	vms.Status.SinkCACerts = tls.bundle()
+	return nil
+}
+
+// resolveDeadLetterSink returns the URI of the dead letter sink of the given
+// source, or nil if it has none.
+func (r *Reconciler) resolveDeadLetterSink(ctx context.Context, vms *sourcesv1alpha1.VSphereSource, tls *sinkTLS) (*apis.URL, error) {
+	d := vms.Spec.Delivery
+	if d == nil || d.DeadLetterSink == nil {
+		return nil, nil
+	}
+
+	if err := r.sinkKinds.Allowed(*d.DeadLetterSink); err != nil {
+		return nil, controller.NewPermanentError(fmt.Errorf("delivery.deadLetterSink: %w", err))
+	}
+	uri, err := r.resolver.URIFromDestinationV1(ctx, *d.DeadLetterSink, vms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve delivery.deadLetterSink: %w", err)
+	}
+	addressable, err := r.addressables.get(ctx, *d.DeadLetterSink, vms.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return tls.resolve("delivery.deadLetterSink", *d.DeadLetterSink, addressable, uri)
+}
+
+// resolveAuditSink returns the URI of the audit sink of the given source, or
+// nil if it has none.
+func (r *Reconciler) resolveAuditSink(ctx context.Context, vms *sourcesv1alpha1.VSphereSource, tls *sinkTLS) (*apis.URL, error) {
+	a := vms.Spec.Audit
+	if a == nil || a.Sink == nil {
+		return nil, nil
+	}
+
+	if err := r.sinkKinds.Allowed(*a.Sink); err != nil {
+		return nil, controller.NewPermanentError(fmt.Errorf("audit.sink: %w", err))
+	}
+	uri, err := r.resolver.URIFromDestinationV1(ctx, *a.Sink, vms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve audit.sink: %w", err)
+	}
+	addressable, err := r.addressables.get(ctx, *a.Sink, vms.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return tls.resolve("audit.sink", *a.Sink, addressable, uri)
+}
+
+// resolveMirrorSink returns the URI of the mirror sink of the given source, or
+// nil if it has none. The path of the delivery is appended like to the sink.
+func (r *Reconciler) resolveMirrorSink(ctx context.Context, vms *sourcesv1alpha1.VSphereSource, tls *sinkTLS) (*apis.URL, error) {
+	m := vms.Spec.Mirror
+	if m == nil {
+		return nil, nil
+	}
+
+	if err := r.sinkKinds.Allowed(m.Sink); err != nil {
+		return nil, controller.NewPermanentError(fmt.Errorf("mirror.sink: %w", err))
+	}
+	uri, err := r.resolver.URIFromDestinationV1(ctx, m.Sink, vms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve mirror.sink: %w", err)
+	}
+	addressable, err := r.addressables.get(ctx, m.Sink, vms.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if uri, err = tls.resolve("mirror.sink", m.Sink, addressable, uri); err != nil {
+		return nil, err
+	}
+	if d := vms.Spec.Delivery; d != nil && d.Path != "" {
+		uri = appendSinkPath(uri, d.Path)
+	}
+	return uri, nil
+}
+
+// resolveSinks returns the URIs of the additional sinks of the given source, in
+// the order of its spec.
+func (r *Reconciler) resolveSinks(ctx context.Context, vms *sourcesv1alpha1.VSphereSource, tls *sinkTLS) ([]apis.URL, error) {
+	if len(vms.Spec.Sinks) == 0 {
+		return nil, nil
+	}
+
+	uris := make([]apis.URL, 0, len(vms.Spec.Sinks))
+	for i, s := range vms.Spec.Sinks {
+		if err := r.sinkKinds.Allowed(s.Destination); err != nil {
+			return nil, controller.NewPermanentError(fmt.Errorf("sinks[%d]: %w", i, err))
+		}
+		uri, err := r.resolver.URIFromDestinationV1(ctx, s.Destination, vms)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve sinks[%d]: %w", i, err)
+		}
+		addressable, err := r.addressables.get(ctx, s.Destination, vms.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if uri, err = tls.resolve(fmt.Sprintf("sinks[%d]", i), s.Destination, addressable, uri); err != nil {
+			return nil, err
+		}
+		uris = append(uris, *uri)
+	}
+	return uris, nil
+}
+
+// appendSinkPath returns a copy of the given sink URI with p appended to its
+// path.
+func appendSinkPath(uri *apis.URL, p string) *apis.URL {
+	result := *uri
+	result.Path = strings.TrimSuffix(uri.Path, "/") + "/" + strings.TrimPrefix(p, "/")
+	result.RawPath = ""
+	return &result
+}
+
+// addressAudience returns the OIDC audience in the status address of the
+// given Addressable, or nil if it does not advertise one or is nil, i.e. the
+// sink is a URI.
+func addressAudience(obj *unstructured.Unstructured) (*string, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	audience, found, err := unstructured.NestedString(obj.Object, "status", "address", "audience")
+	if err != nil {
+		return nil, fmt.Errorf("invalid sink address audience: %w", err)
+	}
+	if !found || audience == "" {
+		return nil, nil
+	}
+	return &audience, nil
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/ptr"
+)
+
+func TestParseSinkKinds(t *testing.T) {
+	tests := []struct {
+		name    string
+		kinds   []string
+		want    sinkKinds
+		wantErr bool
+	}{{
+		name:  "empty",
+		kinds: nil,
+		want:  sinkKinds{},
+	}, {
+		name:  "core and custom group",
+		kinds: []string{"Endpoints.v1", " Gateway.v1alpha1.gateways.example.com ", ""},
+		want: sinkKinds{
+			{Version: "v1", Kind: "Endpoints"},
+			{Group: "gateways.example.com", Version: "v1alpha1", Kind: "Gateway"},
+		},
+	}, {
+		name:    "missing version",
+		kinds:   []string{"Gateway"},
+		wantErr: true,
+	}, {
+		name:    "empty kind",
+		kinds:   []string{".v1.gateways.example.com"},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseSinkKinds(test.kinds)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseSinkKinds() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("parseSinkKinds (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
+
+func TestSinkKindsAllowed(t *testing.T) {
+	custom := sinkKinds{
+		schema.GroupVersionKind{Group: "gateways.example.com", Version: "v1alpha1", Kind: "Gateway"},
+	}
+
+	tests := []struct {
+		name    string
+		kinds   sinkKinds
+		dest    duckv1.Destination
+		wantErr bool
+		// wantNotAllowed is the message of the error of a disallowed kind
+		wantNotAllowed string
+	}{{
+		name:  "uri only",
+		kinds: custom,
+		dest: duckv1.Destination{
+			URI: &apis.URL{Scheme: "http", Host: "example.com"},
+		},
+	}, {
+		name: "no restrictions",
+		dest: duckv1.Destination{
+			Ref: &duckv1.KReference{APIVersion: "foo.example.com/v1", Kind: "Foo", Name: "foo"},
+		},
+	}, {
+		name:  "built-in kind",
+		kinds: custom,
+		dest: duckv1.Destination{
+			Ref: &duckv1.KReference{APIVersion: "eventing.knative.dev/v1beta1", Kind: "Broker", Name: "default"},
+		},
+	}, {
+		name:  "core service",
+		kinds: custom,
+		dest: duckv1.Destination{
+			Ref: &duckv1.KReference{APIVersion: "v1", Kind: "Service", Name: "svc"},
+		},
+	}, {
+		name:  "allowed custom kind",
+		kinds: custom,
+		dest: duckv1.Destination{
+			Ref: &duckv1.KReference{APIVersion: "gateways.example.com/v1alpha1", Kind: "Gateway", Name: "gw"},
+		},
+	}, {
+		name:  "custom kind with different version",
+		kinds: custom,
+		dest: duckv1.Destination{
+			Ref: &duckv1.KReference{APIVersion: "gateways.example.com/v1", Kind: "Gateway", Name: "gw"},
+		},
+		wantErr:        true,
+		wantNotAllowed: `sink kind "Gateway.v1.gateways.example.com" is not allowed, add it to VSPHERE_SINK_KINDS of the webhook deployment to use it`,
+	}, {
+		name:  "unknown custom kind",
+		kinds: custom,
+		dest: duckv1.Destination{
+			Ref: &duckv1.KReference{APIVersion: "foo.example.com/v1", Kind: "Foo", Name: "foo"},
+		},
+		wantErr:        true,
+		wantNotAllowed: `sink kind "Foo.v1.foo.example.com" is not allowed, add it to VSPHERE_SINK_KINDS of the webhook deployment to use it`,
+	}, {
+		name:  "invalid api version",
+		kinds: custom,
+		dest: duckv1.Destination{
+			Ref: &duckv1.KReference{APIVersion: "a/b/c", Kind: "Foo", Name: "foo"},
+		},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.kinds.Allowed(test.dest)
+			if (err != nil) != test.wantErr {
+				t.Errorf("Allowed() error = %v, wantErr %v", err, test.wantErr)
+			}
+			var kindErr *sinkKindNotAllowedError
+			if errors.As(err, &kindErr) != (test.wantNotAllowed != "") {
+				t.Errorf("Allowed() error = %v, want not allowed error %q", err, test.wantNotAllowed)
+			} else if kindErr != nil && kindErr.Error() != test.wantNotAllowed {
+				t.Errorf("Allowed() error = %q, want %q", kindErr, test.wantNotAllowed)
+			}
+		})
+	}
+}
+
+func TestAppendSinkPath(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		path string
+		want string
+	}{{
+		name: "no path",
+		uri:  "http://broker-ingress.knative-eventing.svc.cluster.local",
+		path: "webhook",
+		want: "http://broker-ingress.knative-eventing.svc.cluster.local/webhook",
+	}, {
+		name: "existing path",
+		uri:  "http://broker-ingress.knative-eventing.svc.cluster.local/ns/default/",
+		path: "/api/v1/events",
+		want: "http://broker-ingress.knative-eventing.svc.cluster.local/ns/default/api/v1/events",
+	}, {
+		name: "query is preserved",
+		uri:  "https://example.com/hooks?tenant=foo",
+		path: "vsphere",
+		want: "https://example.com/hooks/vsphere?tenant=foo",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			uri, err := apis.ParseURL(test.uri)
+			if err != nil {
+				t.Fatalf("ParseURL() = %v", err)
+			}
+			if got := appendSinkPath(uri, test.path).String(); got != test.want {
+				t.Errorf("appendSinkPath() = %s, want %s", got, test.want)
+			}
+			if uri.String() != test.uri {
+				t.Errorf("appendSinkPath() modified the given URI: %s", uri)
+			}
+		})
+	}
+}
+
+func TestAddressAudience(t *testing.T) {
+	tests := []struct {
+		name    string
+		obj     map[string]interface{}
+		want    *string
+		wantErr bool
+	}{{
+		name: "no status",
+		obj:  map[string]interface{}{},
+	}, {
+		name: "no audience",
+		obj: map[string]interface{}{
+			"status": map[string]interface{}{
+				"address": map[string]interface{}{"url": "http://broker.example.com"},
+			},
+		},
+	}, {
+		name: "audience",
+		obj: map[string]interface{}{
+			"status": map[string]interface{}{
+				"address": map[string]interface{}{
+					"url":      "https://broker.example.com",
+					"audience": "eventing.knative.dev/broker/default/default",
+				},
+			},
+		},
+		want: ptr.String("eventing.knative.dev/broker/default/default"),
+	}, {
+		name: "invalid audience",
+		obj: map[string]interface{}{
+			"status": map[string]interface{}{
+				"address": map[string]interface{}{"audience": int64(1)},
+			},
+		},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addressAudience(&unstructured.Unstructured{Object: tt.obj})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("addressAudience() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("addressAudience() (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/controller"
+)
+
+// sinkTLSRequiredError is the error of a destination without https address
+// of a source requiring TLS
+type sinkTLSRequiredError struct {
+	field string
+}
+
+func (e *sinkTLSRequiredError) Error() string {
+	return fmt.Sprintf("%s has no https address, but delivery.requireTLS is set", e.field)
+}
+
+// sinkTLS prefers the https addresses advertised by the Addressables of
+// Knative eventing transport encryption over their http addresses, and
+// collects the CA certificates of the resolved https addresses.
+type sinkTLS struct {
+	// require fails destinations without https address
+	require bool
+
+	caCerts []string
+}
+
+// resolve returns the https address of the given Addressable referenced by
+// the given destination in the given field, or else the given resolved URI of
+// the destination, which is returned as is if t is nil. The Addressable is nil
+// if the destination is a URI.
+func (t *sinkTLS) resolve(field string, dest duckv1.Destination, addressable *unstructured.Unstructured, uri *apis.URL) (*apis.URL, error) {
+	if t == nil {
+		return uri, nil
+	}
+	if addressable != nil {
+		https, caCerts, err := httpsAddress(addressable)
+		if err != nil {
+			return nil, err
+		}
+		if https != nil {
+			uri = https
+			if dest.URI != nil {
+				uri = https.ResolveReference(dest.URI)
+			}
+			t.addCACerts(caCerts)
+		}
+	}
+
+	if t.require && uri.Scheme != "https" {
+		return nil, controller.NewPermanentError(&sinkTLSRequiredError{field: field})
+	}
+	return uri, nil
+}
+
+func (t *sinkTLS) addCACerts(caCerts string) {
+	if caCerts == "" {
+		return
+	}
+	for _, c := range t.caCerts {
+		if c == caCerts {
+			return
+		}
+	}
+	t.caCerts = append(t.caCerts, caCerts)
+}
+
+// bundle returns the CA certificates of the resolved https addresses, or nil
+// if there are none.
+func (t *sinkTLS) bundle() *string {
+	if t == nil || len(t.caCerts) == 0 {
+		return nil
+	}
+	b := strings.Join(t.caCerts, "\n")
+	return &b
+}
+
+// httpsAddress returns the first https URL in the status addresses of the
+// given Addressable, or else its status address if it is an https URL, with
+// its CA certificates. It returns nil if the Addressable has no https
+// address.
+func httpsAddress(obj *unstructured.Unstructured) (*apis.URL, string, error) {
+	addresses, _, err := unstructured.NestedSlice(obj.Object, "status", "addresses")
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid sink addresses: %w", err)
+	}
+	if address, found, err := unstructured.NestedMap(obj.Object, "status", "address"); err != nil {
+		return nil, "", fmt.Errorf("invalid sink address: %w", err)
+	} else if found {
+		addresses = append(addresses, address)
+	}
+
+	for _, a := range addresses {
+		address, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		u, _, err := unstructured.NestedString(address, "url")
+		if err != nil || !strings.HasPrefix(u, "https://") {
+			continue
+		}
+		url, err := apis.ParseURL(u)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid sink address %q: %w", u, err)
+		}
+		caCerts, _, _ := unstructured.NestedString(address, "CACerts")
+		return url, caCerts, nil
+	}
+	return nil, "", nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/ptr"
+)
+
+func TestHTTPSAddress(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      map[string]interface{}
+		want        string
+		wantCACerts string
+		wantErr     bool
+	}{{
+		name: "no status",
+	}, {
+		name: "http address",
+		status: map[string]interface{}{
+			"address": map[string]interface{}{"url": "http://broker.example.com"},
+		},
+	}, {
+		name: "https address",
+		status: map[string]interface{}{
+			"address": map[string]interface{}{"url": "https://broker.example.com", "CACerts": "ca"},
+		},
+		want:        "https://broker.example.com",
+		wantCACerts: "ca",
+	}, {
+		name: "https in addresses",
+		status: map[string]interface{}{
+			"address": map[string]interface{}{"url": "http://broker.example.com"},
+			"addresses": []interface{}{
+				map[string]interface{}{"name": "http", "url": "http://broker.example.com"},
+				map[string]interface{}{"name": "https", "url": "https://broker.example.com", "CACerts": "ca"},
+			},
+		},
+		want:        "https://broker.example.com",
+		wantCACerts: "ca",
+	}, {
+		name: "invalid addresses",
+		status: map[string]interface{}{
+			"addresses": "https://broker.example.com",
+		},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if tt.status != nil {
+				obj.Object["status"] = tt.status
+			}
+			got, caCerts, err := httpsAddress(obj)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("httpsAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var gotURL string
+			if got != nil {
+				gotURL = got.String()
+			}
+			if gotURL != tt.want || caCerts != tt.wantCACerts {
+				t.Errorf("httpsAddress() = %q, %q, want %q, %q", gotURL, caCerts, tt.want, tt.wantCACerts)
+			}
+		})
+	}
+}
+
+func TestSinkTLSResolve(t *testing.T) {
+	broker := func(name string, status map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "eventing.knative.dev/v1",
+			"kind":       "Broker",
+			"metadata":   map[string]interface{}{"namespace": "ns", "name": name},
+			"status":     status,
+		}}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		broker("tls", map[string]interface{}{
+			"address": map[string]interface{}{"url": "http://broker.example.com/ns/tls"},
+			"addresses": []interface{}{
+				map[string]interface{}{"name": "https", "url": "https://broker.example.com/ns/tls", "CACerts": "ca"},
+			},
+		}),
+		broker("plain", map[string]interface{}{
+			"address": map[string]interface{}{"url": "http://broker.example.com/ns/plain"},
+		}),
+	)
+	addressables := newAddressables(ctx, client, 0)
+	ref := func(name string) duckv1.Destination {
+		return duckv1.Destination{Ref: &duckv1.KReference{APIVersion: "eventing.knative.dev/v1", Kind: "Broker", Name: name}}
+	}
+
+	tests := []struct {
+		name        string
+		dest        duckv1.Destination
+		uri         *apis.URL
+		require     bool
+		want        string
+		wantCACerts *string
+		wantTLSErr  bool
+	}{{
+		name:        "prefers https address",
+		dest:        ref("tls"),
+		uri:         &apis.URL{Scheme: "http", Host: "broker.example.com", Path: "/ns/tls"},
+		want:        "https://broker.example.com/ns/tls",
+		wantCACerts: ptr.String("ca"),
+	}, {
+		name: "http address",
+		dest: ref("plain"),
+		uri:  &apis.URL{Scheme: "http", Host: "broker.example.com", Path: "/ns/plain"},
+		want: "http://broker.example.com/ns/plain",
+	}, {
+		name:       "http address with TLS required",
+		dest:       ref("plain"),
+		uri:        &apis.URL{Scheme: "http", Host: "broker.example.com", Path: "/ns/plain"},
+		require:    true,
+		wantTLSErr: true,
+	}, {
+		name:    "https URI with TLS required",
+		dest:    duckv1.Destination{URI: &apis.URL{Scheme: "https", Host: "sink.example.com"}},
+		uri:     &apis.URL{Scheme: "https", Host: "sink.example.com"},
+		require: true,
+		want:    "https://sink.example.com",
+	}, {
+		name:       "http URI with TLS required",
+		dest:       duckv1.Destination{URI: apis.HTTP("sink.example.com")},
+		uri:        apis.HTTP("sink.example.com"),
+		require:    true,
+		wantTLSErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addressable, err := addressables.get(ctx, tt.dest, "ns")
+			if err != nil {
+				t.Fatalf("get() error = %v", err)
+			}
+			tls := &sinkTLS{require: tt.require}
+			got, err := tls.resolve("sink", tt.dest, addressable, tt.uri)
+			var tlsErr *sinkTLSRequiredError
+			if errors.As(err, &tlsErr) != tt.wantTLSErr {
+				t.Fatalf("resolve() error = %v, want TLS error %v", err, tt.wantTLSErr)
+			}
+			if tt.wantTLSErr {
+				if !controller.IsPermanentError(err) {
+					t.Errorf("resolve() error = %v, want permanent error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolve() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("resolve() = %s, want %s", got, tt.want)
+			}
+			if diff := cmp.Diff(tt.wantCACerts, tls.bundle()); diff != "" {
+				t.Errorf("bundle() (-want, +got) = %v", diff)
+			}
+		})
+	}
+
+	// the shared adapter resolves the given URIs as is
+	var shared *sinkTLS
+	addressable, err := addressables.get(ctx, ref("tls"), "ns")
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if got, err := shared.resolve("sink", ref("tls"), addressable, &apis.URL{Scheme: "http", Host: "broker.example.com", Path: "/ns/tls"}); err != nil || got.Scheme != "http" {
+		t.Errorf("resolve() of shared adapter = %v, %v, want the http URI", got, err)
+	}
+}
+
+func TestAddressablesGetMissing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	addressables := newAddressables(ctx, fake.NewSimpleDynamicClient(runtime.NewScheme()), 0)
+
+	dest := duckv1.Destination{Ref: &duckv1.KReference{APIVersion: "eventing.knative.dev/v1", Kind: "Broker", Name: "missing"}}
+	if got, err := addressables.get(ctx, dest, "ns"); err == nil {
+		t.Errorf("get() = %v, want error of missing sink", got)
+	}
+}
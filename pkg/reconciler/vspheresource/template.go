@@ -0,0 +1,81 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/config"
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+)
+
+// reconcileTemplate reapplies the template referenced by the given source,
+// e.g. after the template changed, by updating the source with the settings
+// of the template. It returns true if the source was updated, which is then
+// reconciled again with the template applied. A missing template is reported
+// with an event, the source keeps the settings last applied.
+func (r *Reconciler) reconcileTemplate(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) (bool, error) {
+	name := vms.Spec.TemplateRef
+	if name == "" {
+		return false, nil
+	}
+	if _, err := r.templateLister.Get(name); apierrs.IsNotFound(err) {
+		if recorder := controller.GetEventRecorder(ctx); recorder != nil {
+			recorder.Eventf(vms, corev1.EventTypeWarning, "TemplateNotFound",
+				"VSphereSourceTemplate %q not found, keeping the settings last applied", name)
+		}
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	// Default the source like the webhook does, which applies the template.
+	want := vms.DeepCopy()
+	want.SetDefaults(sourcesv1alpha1.WithTemplates(config.WithConfigMapLister(ctx, r.cmLister),
+		func(_ context.Context, name string) (*sourcesv1alpha1.VSphereSourceTemplate, error) {
+			return r.templateLister.Get(name)
+		}))
+	if equality.Semantic.DeepEqual(want.Spec, vms.Spec) {
+		return false, nil
+	}
+
+	logging.FromContext(ctx).Infow("Applying the template to the source", "template", name)
+	if _, err := r.client.SourcesV1alpha1().VSphereSources(vms.Namespace).Update(ctx, want, metav1.UpdateOptions{}); err != nil {
+		return false, fmt.Errorf("failed to apply template %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// enqueueTemplateSources returns a handler of VSphereSourceTemplates which
+// enqueues all sources referencing the template.
+func (r *Reconciler) enqueueTemplateSources(enqueue func(types.NamespacedName)) func(obj interface{}) {
+	return func(obj interface{}) {
+		acc, err := kmeta.DeletionHandlingAccessor(obj)
+		if err != nil {
+			return
+		}
+		sources, err := r.vsphereLister.List(labels.Everything())
+		if err != nil {
+			return
+		}
+		for _, vms := range sources {
+			if vms.Spec.TemplateRef == acc.GetName() {
+				enqueue(types.NamespacedName{Namespace: vms.Namespace, Name: vms.Name})
+			}
+		}
+	}
+}
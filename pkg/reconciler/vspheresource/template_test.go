@@ -0,0 +1,102 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	v1alpha1lister "github.com/vmware-tanzu/sources-for-knative/pkg/client/listers/sources/v1alpha1"
+)
+
+func TestReconcileTemplate(t *testing.T) {
+	ctx := context.Background()
+	template := &sourcesv1alpha1.VSphereSourceTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+		Spec: sourcesv1alpha1.VSphereSourceTemplateSpec{
+			Address:   &apis.URL{Scheme: "https", Host: "vcenter.prod"},
+			SecretRef: &corev1.LocalObjectReference{Name: "prod-credentials"},
+		},
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(template); err != nil {
+		t.Fatal(err)
+	}
+
+	vms := &sourcesv1alpha1.VSphereSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "src", Namespace: "ns"},
+		Spec: sourcesv1alpha1.VSphereSourceSpec{
+			VAuthSpec: sourcesv1alpha1.VAuthSpec{
+				Address:   apis.URL{Scheme: "https", Host: "vcenter.dev"},
+				SecretRef: corev1.LocalObjectReference{Name: "dev-credentials"},
+			},
+			TemplateRef: "prod",
+		},
+	}
+	vms.SetDefaults(ctx)
+	client := fake.NewSimpleClientset(vms)
+	r := &Reconciler{client: client, templateLister: v1alpha1lister.NewVSphereSourceTemplateLister(indexer)}
+
+	updated, err := r.reconcileTemplate(ctx, vms)
+	if err != nil || !updated {
+		t.Fatalf("reconcileTemplate() = %v, %v, want the source updated", updated, err)
+	}
+	got, err := client.SourcesV1alpha1().VSphereSources("ns").Get(ctx, "src", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Spec.Address.Host != "vcenter.prod" || got.Spec.SecretRef.Name != "prod-credentials" {
+		t.Errorf("updated source spec = %+v, want the settings of the template", got.Spec.VAuthSpec)
+	}
+
+	// the source is up to date with the template
+	if updated, err := r.reconcileTemplate(ctx, got); err != nil || updated {
+		t.Errorf("reconcileTemplate() = %v, %v, want the source unchanged", updated, err)
+	}
+
+	// the source keeps its settings without its template
+	got.Spec.TemplateRef = "dev"
+	if updated, err := r.reconcileTemplate(ctx, got); err != nil || updated {
+		t.Errorf("reconcileTemplate() = %v, %v, want the source unchanged", updated, err)
+	}
+}
+
+func TestEnqueueTemplateSources(t *testing.T) {
+	source := func(namespace, name, template string) *sourcesv1alpha1.VSphereSource {
+		return &sourcesv1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       sourcesv1alpha1.VSphereSourceSpec{TemplateRef: template},
+		}
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, vms := range []*sourcesv1alpha1.VSphereSource{
+		source("a", "src", "prod"), source("b", "src", "prod"), source("a", "other", "dev"), source("a", "plain", ""),
+	} {
+		if err := indexer.Add(vms); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &Reconciler{vsphereLister: v1alpha1lister.NewVSphereSourceLister(indexer)}
+
+	enqueued := map[types.NamespacedName]bool{}
+	r.enqueueTemplateSources(func(key types.NamespacedName) {
+		enqueued[key] = true
+	})(&sourcesv1alpha1.VSphereSourceTemplate{ObjectMeta: metav1.ObjectMeta{Name: "prod"}})
+
+	want := map[types.NamespacedName]bool{{Namespace: "a", Name: "src"}: true, {Namespace: "b", Name: "src"}: true}
+	if !cmp.Equal(enqueued, want) {
+		t.Errorf("enqueued %v, want %v", enqueued, want)
+	}
+}
@@ -7,21 +7,31 @@ package vspheresource
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/config"
 	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
 	clientset "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned"
 	vspherereconciler "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/reconciler/sources/v1alpha1/vspheresource"
 	v1alpha1lister "github.com/vmware-tanzu/sources-for-knative/pkg/client/listers/sources/v1alpha1"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources"
 	resourcenames "github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	appsv1listers "k8s.io/client-go/listers/apps/v1"
 	corev1Listers "k8s.io/client-go/listers/core/v1"
+	policyv1beta1listers "k8s.io/client-go/listers/policy/v1beta1"
 	rbacv1listers "k8s.io/client-go/listers/rbac/v1"
 	eventingclientset "knative.dev/eventing/pkg/client/clientset/versioned"
+	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/reconciler"
 	"knative.dev/pkg/resolver"
@@ -32,50 +42,107 @@ import (
 type Reconciler struct {
 	adapterImage string
 
-	resolver *resolver.URIResolver
+	// adapterMode is either adapterModeDedicated or adapterModeShared
+	adapterMode        string
+	sharedAdapterImage string
+
+	resolver     *resolver.URIResolver
+	addressables *addressables
+	eventTypes   *eventTypeInformer
+	sinkKinds    sinkKinds
+
+	// lagThreshold is how far the adapter of a source may fall behind the
+	// newest vCenter event before the source is marked as lagging
+	lagThreshold time.Duration
 
 	kubeclient     kubernetes.Interface
 	eventingclient eventingclientset.Interface
 	client         clientset.Interface
 
+	vsphereLister        v1alpha1lister.VSphereSourceLister
 	deploymentLister     appsv1listers.DeploymentLister
+	pdbLister            policyv1beta1listers.PodDisruptionBudgetLister
 	vspherebindingLister v1alpha1lister.VSphereBindingLister
 	rbacLister           rbacv1listers.RoleBindingLister
 	cmLister             corev1Listers.ConfigMapLister
 	saLister             corev1Listers.ServiceAccountLister
+	templateLister       v1alpha1lister.VSphereSourceTemplateLister
 }
 
 // Check that our Reconciler implements Interface
 var _ vspherereconciler.Interface = (*Reconciler)(nil)
 
 // ReconcileKind implements Interface.ReconcileKind.
-func (r *Reconciler) ReconcileKind(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) reconciler.Event {
-	if err := r.reconcileVSphereBinding(ctx, vms); err != nil {
+func (r *Reconciler) ReconcileKind(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) (event reconciler.Event) {
+	// Track the condition changes of the status written by the previous
+	// reconciliation, including failed ones. The status update requeues the
+	// source, so they are recorded right after it.
+	if original, err := r.vsphereLister.VSphereSources(vms.Namespace).Get(vms.Name); err == nil {
+		vms.Status.RecordConditionTransitions(original.Status.Conditions)
+	}
+	defer func() { recordReconcileError(ctx, event) }()
+
+	if updated, err := r.reconcileTemplate(ctx, vms); err != nil || updated {
 		return err
 	}
 
+	// The shared adapter reads the credentials of all its sources itself and
+	// runs with its own service account.
+	shared := r.adapterMode == adapterModeShared
+
+	if !shared {
+		if err := r.reconcileVSphereBinding(ctx, vms); err != nil {
+			return err
+		}
+	}
+
 	// Make sure the ConfigMap for storing state exists before we
 	// create the deployment so that it gets created as owned
 	// by the source and hence won't be leaked.
 	if err := r.reconcileConfigMap(ctx, vms); err != nil {
 		return err
 	}
-	if err := r.reconcileServiceAccount(ctx, vms); err != nil {
-		return err
+	if !shared {
+		if err := r.reconcileServiceAccount(ctx, vms); err != nil {
+			return err
+		}
+		if err := r.reconcileRoleBinding(ctx, vms); err != nil {
+			return err
+		}
 	}
-	if err := r.reconcileRoleBinding(ctx, vms); err != nil {
+
+	if err := r.resolveSink(ctx, vms); err != nil {
+		var tlsErr *sinkTLSRequiredError
+		var kindErr *sinkKindNotAllowedError
+		if errors.As(err, &tlsErr) {
+			vms.Status.MarkNoSink("SinkTLSRequired", "%v", tlsErr)
+		} else if errors.As(err, &kindErr) {
+			vms.Status.MarkNoSink("SinkKindNotAllowed", "%v", err)
+		} else {
+			vms.Status.MarkNoSink("NotFound", "%v", err)
+		}
 		return err
 	}
+	vms.Status.MarkSink()
 
-	uri, err := r.resolver.URIFromDestinationV1(ctx, vms.Spec.Sink, vms)
-	if err != nil {
+	if err := r.reconcileEventTypes(ctx, vms); err != nil {
 		return err
 	}
-	vms.Status.SinkURI = uri
 
+	if shared {
+		return r.reconcileSharedAdapter(ctx, vms)
+	}
+
+	// Stop serving the source by the shared adapter, e.g. after switching modes.
+	if err := r.removeTenant(ctx, vms); err != nil {
+		return err
+	}
 	if err := r.reconcileDeployment(ctx, vms); err != nil {
 		return err
 	}
+	if err := r.reconcilePodDisruptionBudget(ctx, vms); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -115,7 +182,7 @@ func (r *Reconciler) reconcileConfigMap(ctx context.Context, vms *sourcesv1alpha
 	ns := vms.Namespace
 	name := resourcenames.ConfigMap(vms)
 
-	_, err := r.cmLister.ConfigMaps(ns).Get(name)
+	cm, err := r.cmLister.ConfigMaps(ns).Get(name)
 	// Note that we only create the configmap if it does not exist so that we get the
 	// OwnerRefs set up properly so it gets Garbage Collected.
 	if apierrs.IsNotFound(err) {
@@ -127,11 +194,110 @@ func (r *Reconciler) reconcileConfigMap(ctx context.Context, vms *sourcesv1alpha
 		logging.FromContext(ctx).Infof("Created configmap %q", name)
 	} else if err != nil {
 		return fmt.Errorf("failed to get configmap %q: %w", name, err)
+	} else {
+		// Reflect the vCenter connection, session and delivery status recorded by the adapter
+		propagateConnectionStatus(ctx, vms, cm)
+		propagateSessionStatus(ctx, vms, cm)
+		propagateDeliveryStatus(ctx, vms, cm, r.lagThreshold)
 	}
 
 	return nil
 }
 
+// propagateConnectionStatus sets the connection condition of the given source
+// from the connection status in the kvstore configmap of its adapter. The
+// condition is not set until the adapter tried to connect to vCenter.
+func propagateConnectionStatus(ctx context.Context, vms *sourcesv1alpha1.VSphereSource, cm *corev1.ConfigMap) {
+	data, ok := cm.Data[vsphere.ConnectionStatusKey]
+	if !ok {
+		return
+	}
+
+	var status vsphere.ConnectionStatus
+	if err := json.Unmarshal([]byte(data), &status); err != nil {
+		logging.FromContext(ctx).Warnw("Failed to parse connection status", zap.Error(err))
+		return
+	}
+
+	switch {
+	case status.Connected && status.FallbackAddress != "":
+		vms.Status.MarkSourceConnectedFallback(status.LastAttempt, status.FallbackAddress)
+	case status.Connected:
+		vms.Status.MarkSourceConnected(status.LastAttempt)
+	default:
+		vms.Status.MarkSourceConnectionFailed(status.Reason, status.Message)
+	}
+}
+
+// propagateDeliveryStatus sets the delivery fields of the status of the given
+// source from the delivery status in the kvstore configmap of its adapter. The
+// source is marked as failing with the reason of the error category while the
+// adapter fails to send events, and otherwise as lagging while the adapter is
+// more than lagThreshold behind the newest vCenter event. A Warning event is
+// emitted when the source starts lagging.
+func propagateDeliveryStatus(ctx context.Context, vms *sourcesv1alpha1.VSphereSource, cm *corev1.ConfigMap, lagThreshold time.Duration) {
+	data, ok := cm.Data[vsphere.DeliveryStatusKey]
+	if !ok {
+		return
+	}
+
+	var status vsphere.DeliveryStatus
+	if err := json.Unmarshal([]byte(data), &status); err != nil {
+		logging.FromContext(ctx).Warnw("Failed to parse delivery status", zap.Error(err))
+		return
+	}
+
+	vms.Status.PropagateDeliveryStatus(status.LastDeliveredTime, status.CheckpointTime, status.EventsPerMinute)
+	if vms.Spec.Mirror != nil {
+		vms.Status.PropagateMirrorStatus(status.Mirror)
+	} else {
+		// the status of an adapter which still mirrors the events
+		vms.Status.PropagateMirrorStatus(nil)
+	}
+
+	lag := time.Duration(status.LagSeconds) * time.Second
+	if status.LastErrorCategory != "" {
+		vms.Status.LagSeconds = int64(lag / time.Second)
+		vms.Status.MarkDeliveryFailed(status.LastErrorCategory.Reason(),
+			fmt.Sprintf("Failed to send events (%s): %s", status.LastErrorCategory, status.LastError))
+		return
+	}
+	if lag <= lagThreshold {
+		vms.Status.MarkProgressing(lag)
+		return
+	}
+	if !vms.Status.IsLagging() {
+		if recorder := controller.GetEventRecorder(ctx); recorder != nil {
+			recorder.Eventf(vms, corev1.EventTypeWarning, "Lagging",
+				"The adapter is %s behind the newest vCenter event, more than %s", lag, lagThreshold)
+		}
+	}
+	vms.Status.MarkLagging(lag, lagThreshold)
+}
+
+// propagateSessionStatus sets the session condition of the given source from
+// the session status in the kvstore configmap of its adapter. The condition is
+// not set until the vCenter session of the adapter expired once.
+func propagateSessionStatus(ctx context.Context, vms *sourcesv1alpha1.VSphereSource, cm *corev1.ConfigMap) {
+	data, ok := cm.Data[vsphere.SessionStatusKey]
+	if !ok {
+		return
+	}
+
+	var status vsphere.SessionStatus
+	if err := json.Unmarshal([]byte(data), &status); err != nil {
+		logging.FromContext(ctx).Warnw("Failed to parse session status", zap.Error(err))
+		return
+	}
+
+	switch {
+	case status.LastError != "":
+		vms.Status.MarkSessionLoginFailed(status.LastError)
+	case status.Relogins > 0:
+		vms.Status.MarkSessionRelogin(status.Relogins, status.LastRelogin)
+	}
+}
+
 func (r *Reconciler) reconcileServiceAccount(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) error {
 	ns := vms.Namespace
 	name := resourcenames.ServiceAccount(vms)
@@ -173,9 +339,18 @@ func (r *Reconciler) reconcileDeployment(ctx context.Context, vms *sourcesv1alph
 	ns := vms.Namespace
 	deploymentName := resourcenames.Deployment(vms)
 
+	defaults, err := config.DefaultsForNamespace(ctx, r.cmLister, ns)
+	if err != nil {
+		return fmt.Errorf("failed to get defaults of namespace %q: %w", ns, err)
+	}
+	adapterImage := r.adapterImage
+	if defaults.AdapterImage != "" {
+		adapterImage = defaults.AdapterImage
+	}
+
 	deployment, err := r.deploymentLister.Deployments(ns).Get(deploymentName)
 	if apierrs.IsNotFound(err) {
-		deployment, err = resources.MakeDeployment(ctx, vms, r.adapterImage)
+		deployment, err = resources.MakeDeployment(ctx, vms, adapterImage, defaults.AdapterResources(), defaults.TLSConfig())
 		if err != nil {
 			return fmt.Errorf("failed to create deployment %q: %w", deploymentName, err)
 		}
@@ -189,7 +364,7 @@ func (r *Reconciler) reconcileDeployment(ctx context.Context, vms *sourcesv1alph
 		return fmt.Errorf("failed to get deployment %q: %w", deploymentName, err)
 	} else {
 		// The deployment exists, but make sure that it has the shape that we expect.
-		desiredDeployment, err := resources.MakeDeployment(ctx, vms, r.adapterImage)
+		desiredDeployment, err := resources.MakeDeployment(ctx, vms, adapterImage, defaults.AdapterResources(), defaults.TLSConfig())
 		if err != nil {
 			return fmt.Errorf("failed to create deployment %q: %w", deploymentName, err)
 		}
@@ -203,7 +378,66 @@ func (r *Reconciler) reconcileDeployment(ctx context.Context, vms *sourcesv1alph
 	}
 
 	// Reflect the state of the Adapter Deployment in the VSphereSource
-	vms.Status.PropagateAdapterStatus(deployment.Status)
+	if vms.Spec.Paused {
+		vms.Status.MarkAdapterPaused()
+	} else {
+		vms.Status.PropagateAdapterStatus(deployment.Status)
+	}
 
 	return nil
 }
+
+// reconcilePodDisruptionBudget makes sure that the PodDisruptionBudget of the
+// adapter exists as configured by the source, and is deleted otherwise. It
+// uses policy/v1beta1, since policy/v1 is newer than the vendored client-go.
+func (r *Reconciler) reconcilePodDisruptionBudget(ctx context.Context, vms *sourcesv1alpha1.VSphereSource) error {
+	ns := vms.Namespace
+	name := resourcenames.PodDisruptionBudget(vms)
+	pdbs := r.kubeclient.PolicyV1beta1().PodDisruptionBudgets(ns)
+
+	desired := resources.MakePodDisruptionBudget(ctx, vms)
+	pdb, err := r.pdbLister.PodDisruptionBudgets(ns).Get(name)
+	switch {
+	case apierrs.IsNotFound(err):
+		if desired == nil {
+			return nil
+		}
+		if _, err = pdbs.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create poddisruptionbudget %q: %w", name, err)
+		}
+		logging.FromContext(ctx).Infof("Created poddisruptionbudget %q", name)
+	case err != nil:
+		return fmt.Errorf("failed to get poddisruptionbudget %q: %w", name, err)
+	case !metav1.IsControlledBy(pdb, vms):
+		return fmt.Errorf("poddisruptionbudget %q is not owned by the source", name)
+	case desired == nil:
+		err = pdbs.Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("failed to delete poddisruptionbudget %q: %w", name, err)
+		}
+		logging.FromContext(ctx).Infof("Deleted poddisruptionbudget %q", name)
+	case !equality.Semantic.DeepEqual(pdb.Spec, desired.Spec):
+		pdb = pdb.DeepCopy()
+		pdb.Spec = desired.Spec
+		if _, err = pdbs.Update(ctx, pdb, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update poddisruptionbudget %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// adapterStatusChanged returns true if the connection, session or delivery
+// status in the given versions of a kvstore configmap differs.
+func adapterStatusChanged(oldObj, newObj interface{}) bool {
+	oldCM, ok := oldObj.(*corev1.ConfigMap)
+	if !ok {
+		return false
+	}
+	newCM, ok := newObj.(*corev1.ConfigMap)
+	if !ok {
+		return false
+	}
+	return oldCM.Data[vsphere.ConnectionStatusKey] != newCM.Data[vsphere.ConnectionStatusKey] ||
+		oldCM.Data[vsphere.SessionStatusKey] != newCM.Data[vsphere.SessionStatusKey] ||
+		oldCM.Data[vsphere.DeliveryStatusKey] != newCM.Data[vsphere.DeliveryStatusKey]
+}
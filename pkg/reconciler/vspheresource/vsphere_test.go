@@ -0,0 +1,291 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheresource
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+
+	sourcesv1alpha1 "github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+)
+
+func TestPropagateSessionStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       map[string]string
+		wantStatus corev1.ConditionStatus
+		wantReason string
+	}{{
+		name: "no session status",
+		data: map[string]string{"checkpoint": "{}"},
+	}, {
+		name: "invalid session status",
+		data: map[string]string{vsphere.SessionStatusKey: "{"},
+	}, {
+		name:       "relogin",
+		data:       map[string]string{vsphere.SessionStatusKey: `{"relogins":1,"lastRelogin":"2021-04-01T12:00:00Z"}`},
+		wantStatus: corev1.ConditionTrue,
+		wantReason: "Relogin",
+	}, {
+		name:       "login failed",
+		data:       map[string]string{vsphere.SessionStatusKey: `{"relogins":1,"lastError":"invalid login"}`},
+		wantStatus: corev1.ConditionFalse,
+		wantReason: "LoginFailed",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vms := &sourcesv1alpha1.VSphereSource{}
+			vms.Status.InitializeConditions()
+
+			propagateSessionStatus(context.Background(), vms, &corev1.ConfigMap{Data: tt.data})
+
+			cond := vms.Status.GetCondition(sourcesv1alpha1.VSphereSourceConditionSessionReady)
+			if tt.wantStatus == "" {
+				if cond != nil {
+					t.Errorf("session condition = %+v, want none", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tt.wantStatus || cond.Reason != tt.wantReason {
+				t.Errorf("session condition = %+v, want status %s with reason %s", cond, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestPropagateConnectionStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       map[string]string
+		wantStatus corev1.ConditionStatus
+		wantReason string
+	}{{
+		name: "no connection status",
+		data: map[string]string{"checkpoint": "{}"},
+	}, {
+		name: "invalid connection status",
+		data: map[string]string{vsphere.ConnectionStatusKey: "{"},
+	}, {
+		name:       "connected",
+		data:       map[string]string{vsphere.ConnectionStatusKey: `{"connected":true,"lastAttempt":"2021-04-01T12:00:00Z"}`},
+		wantStatus: corev1.ConditionTrue,
+		wantReason: "Connected",
+	}, {
+		name:       "connected to fallback address",
+		data:       map[string]string{vsphere.ConnectionStatusKey: `{"connected":true,"lastAttempt":"2021-04-01T12:00:00Z","fallbackAddress":"https://vcenter-2.example.com/sdk"}`},
+		wantStatus: corev1.ConditionTrue,
+		wantReason: "FailedOver",
+	}, {
+		name:       "authentication failed",
+		data:       map[string]string{vsphere.ConnectionStatusKey: `{"connected":false,"reason":"AuthenticationFailed","message":"invalid login"}`},
+		wantStatus: corev1.ConditionFalse,
+		wantReason: vsphere.ConnectionReasonAuthenticationFailed,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vms := &sourcesv1alpha1.VSphereSource{}
+			vms.Status.InitializeConditions()
+
+			propagateConnectionStatus(context.Background(), vms, &corev1.ConfigMap{Data: tt.data})
+
+			cond := vms.Status.GetCondition(sourcesv1alpha1.VSphereSourceConditionSourceConnected)
+			if tt.wantStatus == "" {
+				if cond != nil {
+					t.Errorf("connection condition = %+v, want none", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tt.wantStatus || cond.Reason != tt.wantReason {
+				t.Errorf("connection condition = %+v, want status %s with reason %s", cond, tt.wantStatus, tt.wantReason)
+			}
+			if ready := vms.Status.GetCondition(sourcesv1alpha1.VSphereSourceConditionReady); ready.Status != corev1.ConditionUnknown {
+				t.Errorf("ready condition = %+v, want it unaffected", ready)
+			}
+		})
+	}
+}
+
+func TestPropagateDeliveryStatus(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{}
+	vms.Status.InitializeConditions()
+
+	propagateDeliveryStatus(context.Background(), vms, &corev1.ConfigMap{Data: map[string]string{
+		vsphere.DeliveryStatusKey: `{"lastDeliveredTime":"2021-04-01T12:00:00Z","checkpointTime":"2021-04-01T11:59:50Z","eventsPerMinute":42}`,
+	}}, 5*time.Minute)
+
+	if got := vms.Status.LastDeliveredTime; got == nil || !got.Inner.Equal(&metav1.Time{Time: time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)}) {
+		t.Errorf("lastDeliveredTime = %v", got)
+	}
+	if got := vms.Status.CheckpointTime; got == nil || !got.Inner.Equal(&metav1.Time{Time: time.Date(2021, 4, 1, 11, 59, 50, 0, time.UTC)}) {
+		t.Errorf("checkpointTime = %v", got)
+	}
+	if vms.Status.EventsPerMinute != 42 {
+		t.Errorf("eventsPerMinute = %d, want 42", vms.Status.EventsPerMinute)
+	}
+
+	// sources which did not deliver events yet have no times
+	propagateDeliveryStatus(context.Background(), vms, &corev1.ConfigMap{Data: map[string]string{
+		vsphere.DeliveryStatusKey: `{"eventsPerMinute":0}`,
+	}}, 5*time.Minute)
+	if vms.Status.LastDeliveredTime != nil || vms.Status.CheckpointTime != nil || vms.Status.EventsPerMinute != 0 {
+		t.Errorf("status = %+v, want no delivery fields", vms.Status)
+	}
+}
+
+func TestPropagateDeliveryStatusMirror(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{}
+	vms.Spec.Mirror = &sourcesv1alpha1.VMirrorSpec{}
+	vms.Status.InitializeConditions()
+	cm := &corev1.ConfigMap{Data: map[string]string{
+		vsphere.DeliveryStatusKey: `{"eventsPerMinute":42,"mirror":{"lastDeliveredTime":"2021-04-01T12:00:00Z",` +
+			`"eventsPerMinute":40,"mirroredEvents":100,"divergentEvents":2,"lastErrorCategory":"Sink5xx","lastError":"500"}}`,
+	}}
+
+	propagateDeliveryStatus(context.Background(), vms, cm, 5*time.Minute)
+	want := &sourcesv1alpha1.VMirrorStatus{
+		LastDeliveredTime: &apis.VolatileTime{Inner: metav1.NewTime(time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC))},
+		EventsPerMinute:   40,
+		MirroredEvents:    100,
+		DivergentEvents:   2,
+		LastError:         "500",
+	}
+	if diff := cmp.Diff(want, vms.Status.Mirror); diff != "" {
+		t.Errorf("mirror (-want, +got) = %v", diff)
+	}
+	// failures of the mirror sink do not affect the source
+	if vms.Status.IsLagging() {
+		t.Errorf("source is failing, want the mirror failure ignored")
+	}
+
+	// the status of the adapter is outdated once the mirror is removed
+	vms.Spec.Mirror = nil
+	propagateDeliveryStatus(context.Background(), vms, cm, 5*time.Minute)
+	if vms.Status.Mirror != nil {
+		t.Errorf("mirror = %+v, want nil", vms.Status.Mirror)
+	}
+}
+
+func TestPropagateDeliveryStatusLag(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{}
+	vms.Status.InitializeConditions()
+	recorder := record.NewFakeRecorder(10)
+	ctx := controller.WithEventRecorder(context.Background(), recorder)
+	withLag := func(lag string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{Data: map[string]string{
+			vsphere.DeliveryStatusKey: `{"eventsPerMinute":10,"lagSeconds":` + lag + `}`,
+		}}
+	}
+
+	propagateDeliveryStatus(ctx, vms, withLag("60"), 5*time.Minute)
+	if cond := vms.Status.GetCondition(sourcesv1alpha1.VSphereSourceConditionProgressing); cond.Status != corev1.ConditionTrue || vms.Status.LagSeconds != 60 {
+		t.Errorf("progressing condition = %+v, lagSeconds = %d, want caught up 60", cond, vms.Status.LagSeconds)
+	}
+
+	// the event is only emitted when the source starts lagging
+	propagateDeliveryStatus(ctx, vms, withLag("600"), 5*time.Minute)
+	propagateDeliveryStatus(ctx, vms, withLag("900"), 5*time.Minute)
+	cond := vms.Status.GetCondition(sourcesv1alpha1.VSphereSourceConditionProgressing)
+	if cond.Status != corev1.ConditionFalse || cond.Reason != "Lagging" || vms.Status.LagSeconds != 900 {
+		t.Errorf("progressing condition = %+v, lagSeconds = %d, want lagging 900", cond, vms.Status.LagSeconds)
+	}
+	if ready := vms.Status.GetCondition(sourcesv1alpha1.VSphereSourceConditionReady); ready.Status != corev1.ConditionUnknown {
+		t.Errorf("ready condition = %+v, want it unaffected", ready)
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("events = %d, want 1", len(recorder.Events))
+	}
+	if got, want := <-recorder.Events, "Warning Lagging The adapter is 10m0s behind the newest vCenter event, more than 5m0s"; got != want {
+		t.Errorf("event = %q, want %q", got, want)
+	}
+
+	propagateDeliveryStatus(ctx, vms, withLag("0"), 5*time.Minute)
+	if vms.Status.IsLagging() || vms.Status.LagSeconds != 0 {
+		t.Errorf("IsLagging() = true, lagSeconds = %d, want caught up", vms.Status.LagSeconds)
+	}
+}
+
+func TestPropagateDeliveryStatusError(t *testing.T) {
+	vms := &sourcesv1alpha1.VSphereSource{}
+	vms.Status.InitializeConditions()
+
+	propagateDeliveryStatus(context.Background(), vms, &corev1.ConfigMap{Data: map[string]string{
+		vsphere.DeliveryStatusKey: `{"eventsPerMinute":0,"lastErrorCategory":"sink-4xx","lastError":"400: bad request"}`,
+	}}, 5*time.Minute)
+	cond := vms.Status.GetCondition(sourcesv1alpha1.VSphereSourceConditionProgressing)
+	if cond.Status != corev1.ConditionFalse || cond.Reason != "SinkRejected" {
+		t.Errorf("progressing condition = %+v, want SinkRejected", cond)
+	}
+	if want := "Failed to send events (sink-4xx): 400: bad request"; cond.Message != want {
+		t.Errorf("progressing message = %q, want %q", cond.Message, want)
+	}
+
+	// the condition recovers with the next status without error
+	propagateDeliveryStatus(context.Background(), vms, &corev1.ConfigMap{Data: map[string]string{
+		vsphere.DeliveryStatusKey: `{"eventsPerMinute":10,"lagSeconds":0}`,
+	}}, 5*time.Minute)
+	if cond := vms.Status.GetCondition(sourcesv1alpha1.VSphereSourceConditionProgressing); cond.Status != corev1.ConditionTrue {
+		t.Errorf("progressing condition = %+v, want recovered", cond)
+	}
+}
+
+func TestAdapterStatusChanged(t *testing.T) {
+	cm := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{Data: data}
+	}
+
+	tests := []struct {
+		name     string
+		old, new interface{}
+		want     bool
+	}{{
+		name: "checkpoint changed",
+		old:  cm(map[string]string{"checkpoint": "1"}),
+		new:  cm(map[string]string{"checkpoint": "2"}),
+	}, {
+		name: "session status added",
+		old:  cm(map[string]string{"checkpoint": "1"}),
+		new:  cm(map[string]string{"checkpoint": "1", vsphere.SessionStatusKey: `{"relogins":1}`}),
+		want: true,
+	}, {
+		name: "session status changed",
+		old:  cm(map[string]string{vsphere.SessionStatusKey: `{"relogins":1}`}),
+		new:  cm(map[string]string{vsphere.SessionStatusKey: `{"relogins":2}`}),
+		want: true,
+	}, {
+		name: "connection status changed",
+		old:  cm(map[string]string{vsphere.ConnectionStatusKey: `{"connected":false}`}),
+		new:  cm(map[string]string{vsphere.ConnectionStatusKey: `{"connected":true}`}),
+		want: true,
+	}, {
+		name: "delivery status changed",
+		old:  cm(map[string]string{vsphere.DeliveryStatusKey: `{"eventsPerMinute":1}`}),
+		new:  cm(map[string]string{vsphere.DeliveryStatusKey: `{"eventsPerMinute":2}`}),
+		want: true,
+	}, {
+		name: "not a configmap",
+		old:  &corev1.Secret{},
+		new:  cm(map[string]string{vsphere.SessionStatusKey: `{"relogins":1}`}),
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := adapterStatusChanged(tt.old, tt.new); got != tt.want {
+				t.Errorf("adapterStatusChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
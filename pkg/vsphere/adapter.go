@@ -7,26 +7,30 @@ package vsphere
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
-	"github.com/jpillora/backoff"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/kelseyhightower/envconfig"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/types"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
 	"knative.dev/eventing/pkg/adapter/v2"
 	"knative.dev/pkg/kvstore"
 	"knative.dev/pkg/logging"
 
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
-)
-
-const (
-	// read up to max events per iteration
-	maxEventsBatch = 100
+	"knative.dev/pkg/injection/clients/dynamicclient"
 )
 
 type envConfig struct {
@@ -37,46 +41,409 @@ type envConfig struct {
 
 	// CheckpointConfig configures the checkpoint behavior of this controller
 	CheckpointConfig string `envconfig:"VSPHERE_CHECKPOINT_CONFIG" default:"{}"`
+
+	// PollInterval is the maximum delay between polls of the event history
+	// collector without new events
+	PollInterval time.Duration `envconfig:"VSPHERE_POLL_INTERVAL" default:"5s"`
+
+	// PageSize is the maximum number of events read per poll
+	PageSize int `envconfig:"VSPHERE_PAGE_SIZE" default:"100"`
+
+	// CollectorMode is how new events are awaited, either poll or stream
+	CollectorMode string `envconfig:"VSPHERE_COLLECTOR_MODE" default:"poll"`
+
+	// Scope is the JSON-encoded inventory object whose subtree events and
+	// tasks are collected from, see ScopeConfig. The whole inventory if empty.
+	Scope string `envconfig:"VSPHERE_SCOPE" default:""`
+
+	// IncludeTasks enables sending task lifecycle events
+	IncludeTasks bool `envconfig:"VSPHERE_INCLUDE_TASKS" default:"false"`
+
+	// IncludeContentLibrary enables sending content library change events
+	IncludeContentLibrary bool `envconfig:"VSPHERE_INCLUDE_CONTENT_LIBRARY" default:"false"`
+
+	// IncludeTags enables sending tag association change events
+	IncludeTags bool `envconfig:"VSPHERE_INCLUDE_TAGS" default:"false"`
+
+	// NormalizeAlarms enables sending alarm events with normalized types and
+	// payloads, see newAlarmCloudEvent
+	NormalizeAlarms bool `envconfig:"VSPHERE_NORMALIZE_ALARMS" default:"false"`
+
+	// GroupVSANEvents enables sending vSAN events with the vsan type prefix,
+	// see vsanEventType
+	GroupVSANEvents bool `envconfig:"VSPHERE_GROUP_VSAN_EVENTS" default:"false"`
+
+	// Extensions are the CloudEvents extension attributes set on events
+	Extensions []string `envconfig:"VSPHERE_EXTENSIONS" default:"vsphereeventclass,vmmoref,hostmoref,datacenter,vcenterid"`
+
+	// Enrichment is the JSON-encoded enrichment config for events
+	Enrichment string `envconfig:"VSPHERE_ENRICHMENT" default:""`
+
+	// AttributeMapping is the JSON-encoded mapping of the type and source
+	// attributes of events
+	AttributeMapping string `envconfig:"VSPHERE_ATTRIBUTE_MAPPING" default:""`
+
+	// OutputFormat is the format of events sent to the sink, either
+	// cloudevents or cdevents
+	OutputFormat string `envconfig:"VSPHERE_OUTPUT_FORMAT" default:"cloudevents"`
+
+	// Redaction is the JSON-encoded redaction of payload fields of events
+	Redaction string `envconfig:"VSPHERE_REDACTION" default:""`
+
+	// PayloadEncoding is the encoding of payloads with a data schema, either
+	// json or protobuf
+	PayloadEncoding string `envconfig:"VSPHERE_PAYLOAD_ENCODING" default:"json"`
+
+	// EventFilter is the JSON-encoded filter for events sent to the sink
+	EventFilter string `envconfig:"VSPHERE_EVENT_FILTER" default:""`
+
+	// Sinks is the JSON-encoded list of additional sinks, see Sink
+	Sinks string `envconfig:"VSPHERE_SINKS" default:""`
+
+	// MirrorSink is the URI of the candidate sink of a sink cutover, which
+	// receives a copy of every event sent to the sink, see vAdapter.mirror
+	MirrorSink string `envconfig:"VSPHERE_MIRROR_SINK" default:""`
+
+	// Kafka is the JSON-encoded configuration of the Kafka topic events are
+	// written to instead of the sink, see KafkaConfig
+	Kafka string `envconfig:"VSPHERE_KAFKA" default:""`
+
+	// LogOnly logs the events instead of sending them to the sink
+	LogOnly bool `envconfig:"VSPHERE_LOG_ONLY" default:"false"`
+
+	// Transform is the JSON-encoded transform of the payloads of events
+	Transform string `envconfig:"VSPHERE_TRANSFORM" default:""`
+
+	// SinkContentMode is the HTTP content mode used to send events to the
+	// sink, either binary or structured
+	SinkContentMode string `envconfig:"VSPHERE_SINK_CONTENT_MODE" default:"binary"`
+
+	// SinkCompression is the compression of the HTTP bodies sent to the sink,
+	// either none, gzip or auto
+	SinkCompression string `envconfig:"VSPHERE_SINK_COMPRESSION" default:"none"`
+
+	// SinkHeaders is a JSON-encoded map of static HTTP headers for the sink
+	SinkHeaders string `envconfig:"VSPHERE_SINK_HEADERS" default:""`
+
+	// RateLimit is the JSON-encoded rate limit of events sent to the sink
+	RateLimit string `envconfig:"VSPHERE_RATE_LIMIT" default:""`
+
+	// Sampling is the JSON-encoded Sampling of bursts of events, disabled if
+	// empty
+	Sampling string `envconfig:"VSPHERE_SAMPLING" default:""`
+
+	// SinkMaxAttempts is the number of attempts to send an event before it is
+	// recorded as a dead letter and skipped, disabled if 0
+	SinkMaxAttempts int `envconfig:"VSPHERE_SINK_MAX_ATTEMPTS" default:"0"`
+
+	// DeadLetterSink is the URI of the sink of the dead letters, which are
+	// recorded in the kvstore if empty
+	DeadLetterSink string `envconfig:"VSPHERE_DEAD_LETTER_SINK" default:""`
+
+	// Audit is the JSON-encoded audit log of the dispatched events, see
+	// AuditConfig
+	Audit string `envconfig:"VSPHERE_AUDIT" default:""`
+
+	// SinkHeadersPath is the directory of a mounted secret with additional HTTP
+	// headers for the sink
+	SinkHeadersPath string `envconfig:"VSPHERE_SINK_HEADERS_PATH" default:""`
+
+	// SinkCACertsPath is the directory of a mounted configmap with additional
+	// CA certificates for HTTPS sinks
+	SinkCACertsPath string `envconfig:"VSPHERE_SINK_CA_CERTS_PATH" default:""`
+
+	// SinkCACerts are the PEM-encoded CA certificates advertised by the https
+	// addresses of the sinks
+	SinkCACerts string `envconfig:"VSPHERE_SINK_CA_CERTS" default:""`
+
+	// SinkAudience is the OIDC audience advertised by an authenticated sink
+	SinkAudience string `envconfig:"VSPHERE_SINK_AUDIENCE" default:""`
+
+	// SinkTLSMinVersion and SinkTLSCipherSuites restrict the TLS connections
+	// to HTTPS sinks, see TLSConfig
+	SinkTLSMinVersion   string `envconfig:"VSPHERE_SINK_TLS_MIN_VERSION" default:""`
+	SinkTLSCipherSuites string `envconfig:"VSPHERE_SINK_TLS_CIPHER_SUITES" default:""`
+
+	// ServiceAccount is the service account of the adapter, used to request
+	// OIDC tokens for the sink audience
+	ServiceAccount string `envconfig:"VSPHERE_SERVICE_ACCOUNT" default:""`
+
+	// Addresses is the JSON-encoded list of the configurations of additional
+	// vCenters, see EnvConfig, whose events are merged into the event stream
+	Addresses string `envconfig:"VSPHERE_ADDRESSES" default:""`
+
+	// SourceName is the name of the VSphereSource, whose logging level
+	// annotation is watched if set
+	SourceName string `envconfig:"VSPHERE_SOURCE_NAME" default:""`
+
+	// HealthPort is the port of the readiness and liveness probes, disabled
+	// if 0
+	HealthPort int `envconfig:"VSPHERE_HEALTH_PORT" default:"0"`
+
+	// HeartbeatInterval is the interval of heartbeat events, disabled if 0
+	HeartbeatInterval time.Duration `envconfig:"VSPHERE_HEARTBEAT_INTERVAL" default:"0"`
+
+	// LifecycleEvents enables sending events when the vCenter session of the
+	// adapter changes or it replays events
+	LifecycleEvents bool `envconfig:"VSPHERE_LIFECYCLE_EVENTS" default:"false"`
+
+	// WireTrace enables tracing the vCenter API requests and responses to the
+	// logs or to files in WireTracePath, see WireTraceAnnotation
+	WireTrace     string `envconfig:"VSPHERE_WIRE_TRACE" default:""`
+	WireTracePath string `envconfig:"VSPHERE_WIRE_TRACE_PATH" default:""`
+
+	logger *zap.SugaredLogger
+	level  zap.AtomicLevel
 }
 
 func NewEnvConfig() adapter.EnvConfigAccessor {
 	return &envConfig{}
 }
 
+// GetLogger implements adapter.EnvConfigAccessor. Unlike the default logger,
+// the level of the returned logger can be changed at runtime.
+func (env *envConfig) GetLogger() *zap.SugaredLogger {
+	if env.logger == nil {
+		env.logger, env.level = newLogger(env.LoggingConfigJson, env.Component)
+	}
+	return env.logger
+}
+
 // vAdapter implements the vSphereSource adapter to trigger a Sink.
 type vAdapter struct {
 	Logger    *zap.SugaredLogger
 	Namespace string
 	Source    string
+	VCenterID string
 	VClient   *govmomi.Client
+	RClient   *rest.Client
 	CEClient  cloudevents.Client
 	KVStore   kvstore.Interface
 	CpConfig  CheckpointConfig
+
+	// PollInterval is the maximum delay between polls of the event history
+	// collector without new events, the default if 0
+	PollInterval time.Duration
+	// PageSize is the maximum number of events read per poll, the default if 0
+	PageSize int
+	// CollectorMode is how new events are awaited, polling if empty
+	CollectorMode string
+	// Scope is the inventory object whose subtree events and tasks are
+	// collected from, the whole inventory if nil
+	Scope *ScopeConfig
+
+	IncludeTasks          bool
+	IncludeContentLibrary bool
+	IncludeTags           bool
+	NormalizeAlarms       bool
+	GroupVSANEvents       bool
+	Filter                *EventFilter
+	Sinks                 sinkSet
+	MirrorSink            string
+	Mapper                *attributeMapper
+	Translator            translator
+	Extensions            extensionSet
+	Enricher              *enricher
+	Transformer           *transformer
+	Redactor              *redactor
+	Encoder               *protoEncoder
+	SinkHeaders           http.Header
+	SinkContentMode       string
+	SinkCompression       *sinkCompression
+	SinkTokens            *tokenProvider
+	// SinkLimiter limits the rate of events sent to the sink, nil if
+	// unlimited
+	SinkLimiter *rate.Limiter
+	// Sampler coalesces bursts of events into summary events, nil if all
+	// events are sent
+	Sampler *sampler
+	// DeadLetters gives up on poison events after a number of attempts, nil
+	// if failed events are not retried
+	DeadLetters *deadLetters
+	// Audit records every attempt to send an event, nil if disabled
+	Audit *auditLog
+
+	// Deliveries counts the events acknowledged by the sink for the delivery
+	// status
+	Deliveries deliveryStats
+	// Mirrors counts the events sent to the mirror sink for the delivery
+	// status, nil without mirror sink
+	Mirrors *mirrorStats
+
+	// Login creates new vCenter sessions after the current ones expired or the
+	// credentials changed
+	Login func(ctx context.Context) error
+	// API configures the backoff between the logins
+	API APIConfig
+	// Logout ends the vCenter sessions of the adapter (best effort). It is nil
+	// if the sessions are shared with other adapters.
+	Logout func()
+	// SecretPath is the directory of the mounted secret with the vCenter
+	// credentials, which is watched for rotated credentials
+	SecretPath string
+	// FallbackAddress is the fallback address of the vCenter the adapter is
+	// connected to, empty if connected to the address of the source
+	FallbackAddress string
+	// WatchLoggingLevel applies the logging level of the source until ctx is
+	// done. It is nil if the source is unknown.
+	WatchLoggingLevel func(ctx context.Context)
+
+	// Health records the vCenter session and event polls for the probes
+	Health *health
+	// HealthPort is the port on which Start serves the probes, if not 0
+	HealthPort int
+	// HeartbeatInterval is the interval at which Start sends heartbeat
+	// events, if not 0
+	HeartbeatInterval time.Duration
+	// LifecycleEvents enables the events sent on changes of the vCenter
+	// session and on replays, see Lifecycle
+	LifecycleEvents bool
 }
 
 func NewAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, ceClient cloudevents.Client) adapter.Adapter {
 	env := processed.(*envConfig)
 	logger := logging.FromContext(ctx)
 
-	vClient, err := NewSOAPClient(ctx)
+	addresses, err := newAddresses(env.Addresses)
 	if err != nil {
-		logger.Fatalf("unable to create vSphere client: %v", err)
+		logger.Fatalf("could not read addresses: %v", err)
 	}
 
-	source := vClient.URL().Host
-	if source == "" {
-		logger.Fatal("unable to determine vSphere client source: empty host")
-	}
-
-	// setup checkpointing
+	// setup checkpointing, the kvstore also records the connection status
 	store := kvstore.NewConfigMapKVStore(ctx, env.KVConfigMap, env.Namespace, kubeclient.Get(ctx).CoreV1())
-	if err = store.Init(ctx); err != nil {
+	if len(addresses) > 0 {
+		// shared by the adapters of all vCenters
+		store = &lockedKVStore{store: store}
+	}
+	if err := store.Init(ctx); err != nil {
 		logger.Fatalf("could not initialize kv store: %v", err)
 	}
 
+	var vc EnvConfig
+	if err := envconfig.Process("", &vc); err != nil {
+		logger.Fatalf("could not read vSphere config: %v", err)
+	}
+	// traces the vCenter clients created afterwards
+	if err := configureWireTrace(logger, env.WireTrace, env.WireTracePath); err != nil {
+		logger.Fatalf("could not configure wire trace: %v", err)
+	}
+	vClient, connected, err := soapWithFailover(ctx, vc)
+	if err != nil {
+		recordConnectionStatus(ctx, store, err)
+		logger.Fatalw("unable to create vSphere client", zap.Error(err), errorCategoryField(err))
+	}
+
+	enrichment, err := newEnrichment(env.Enrichment)
+	if err != nil {
+		logger.Fatalf("could not read enrichment config: %v", err)
+	}
+
+	var rClient *rest.Client
+	if env.needsREST(enrichment) {
+		rClient, err = restWithKeepalive(ctx, connected)
+		if err != nil {
+			recordConnectionStatus(ctx, store, err)
+			logger.Fatalw("unable to create vSphere REST client", zap.Error(err), errorCategoryField(err))
+		}
+	}
+	recordConnected(ctx, store, fallbackAddress(vc, connected))
+
+	transport, err := env.newSinkTransport()
+	if err != nil {
+		logger.Fatalf("could not configure sink transport: %v", err)
+	}
+	kafka, err := newKafkaConfig(env.Kafka)
+	if err != nil {
+		logger.Fatalf("could not read kafka config: %v", err)
+	}
+	// replaces the client of the adapter framework sending to the sink, which
+	// uses the default transport
+	switch {
+	case env.LogOnly:
+		if ceClient, err = env.newLogOnlyClient(logger); err != nil {
+			logger.Fatalf("could not create log-only client: %v", err)
+		}
+	case kafka != nil:
+		if ceClient, err = env.newKafkaClient(ctx, kafka); err != nil {
+			logger.Fatalf("could not create kafka client: %v", err)
+		}
+	default:
+		if ceClient, err = env.newSinkClient(transport); err != nil {
+			logger.Fatalf("could not create sink client: %v", err)
+		}
+	}
+
+	secretPath, err := SecretMountPath()
+	if err != nil {
+		logger.Fatalf("could not read vSphere secret path: %v", err)
+	}
+
+	a, err := newVAdapter(ctx, env, enrichment, ceClient, transport, store, vClient, rClient)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	a.Login = func(ctx context.Context) error {
+		return Login(ctx, vClient, rClient)
+	}
+	a.API = vc.API
+	a.Logout = func() {
+		logout(vClient, rClient)
+	}
+	a.SecretPath = secretPath
+	a.FallbackAddress = fallbackAddress(vc, connected)
+	a.WatchLoggingLevel = env.loggingLevelWatcher(ctx)
+	a.HealthPort = env.HealthPort
+	if len(addresses) == 0 {
+		return a
+	}
+
+	adapters := fanInAdapter{a}
+	for _, vc := range addresses {
+		va, err := newAddressAdapter(ctx, env, vc, enrichment, ceClient, transport, store)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		adapters = append(adapters, va)
+	}
+	return adapters
+}
+
+// loggingLevelWatcher returns a function which applies the logging level of
+// the source to the logger of env, or nil if the source is unknown.
+func (env *envConfig) loggingLevelWatcher(ctx context.Context) func(ctx context.Context) {
+	if env.SourceName == "" || env.logger == nil {
+		return nil
+	}
+	sources := dynamicclient.Get(ctx).Resource(sourcesResource).Namespace(env.Namespace)
+	return func(ctx context.Context) {
+		watchLoggingLevel(ctx, sources, env.SourceName, env.level, loggingLevelPollInterval)
+	}
+}
+
+// needsREST returns true if the configured events or the given enrichment
+// require the vCenter REST API. Content library changes, tag association
+// changes and attached tags are only available through the REST API.
+func (env *envConfig) needsREST(enrichment *Enrichment) bool {
+	return env.IncludeContentLibrary || env.IncludeTags || (enrichment != nil && enrichment.Tags)
+}
+
+// newVAdapter returns an adapter for the source configured in env, reading
+// from vCenter with the given clients and recording its state in store. The
+// REST client is optional if not needed by env. The audit records are sent
+// with the given sink transport, see newSinkTransport. Login, Logout and
+// SecretPath are left to the caller.
+func newVAdapter(ctx context.Context, env *envConfig, enrichment *Enrichment, ceClient cloudevents.Client, transport http.RoundTripper,
+	store kvstore.Interface, vClient *govmomi.Client, rClient *rest.Client) (*vAdapter, error) {
+	logger := logging.FromContext(ctx)
+
+	source := vClient.URL().Host
+	if source == "" {
+		return nil, errors.New("unable to determine vSphere client source: empty host")
+	}
+
 	cpconf, err := newCheckpointConfig(env.CheckpointConfig)
 	if err != nil {
-		logger.Fatalf("could not not read checkpoint config: %v", err)
+		return nil, fmt.Errorf("could not not read checkpoint config: %w", err)
 	}
 
 	logger.Infow("configuring checkpointing", zap.String("ReplayWindow", cpconf.MaxAge.String()),
@@ -86,25 +453,240 @@ func NewAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, ceClie
 		logger.Warn("disabling event replay: maxAge set to 0s")
 	}
 
+	if err = validateCollector(env.PollInterval, env.PageSize); err != nil {
+		return nil, fmt.Errorf("could not read event collector config: %w", err)
+	}
+	if err = validateCollectorMode(env.CollectorMode); err != nil {
+		return nil, fmt.Errorf("could not read event collector config: %w", err)
+	}
+
+	filter, err := newEventFilter(env.EventFilter)
+	if err != nil {
+		return nil, fmt.Errorf("could not read event filter: %w", err)
+	}
+
+	sinks, err := newSinkSet(env.Sinks)
+	if err != nil {
+		return nil, fmt.Errorf("could not read sinks: %w", err)
+	}
+
+	var mirrors *mirrorStats
+	if env.MirrorSink != "" {
+		mirrors = &mirrorStats{}
+	}
+
+	mapper, err := newAttributeMapper(env.AttributeMapping)
+	if err != nil {
+		return nil, fmt.Errorf("could not read attribute mapping: %w", err)
+	}
+
+	transformer, err := newTransformer(env.Transform)
+	if err != nil {
+		return nil, fmt.Errorf("could not read transform: %w", err)
+	}
+
+	redactor, err := newRedactor(env.Redaction)
+	if err != nil {
+		return nil, fmt.Errorf("could not read redaction: %w", err)
+	}
+
+	encoder, err := newProtoEncoder(env.PayloadEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("could not read payload encoding: %w", err)
+	}
+	if encoder != nil && transformer != nil {
+		return nil, errors.New("transformed payloads cannot be encoded as protobuf")
+	}
+
+	trans, err := newTranslator(env.OutputFormat)
+	if err != nil {
+		return nil, fmt.Errorf("could not read output format: %w", err)
+	}
+
+	extensions, err := newExtensionSet(env.Extensions)
+	if err != nil {
+		return nil, fmt.Errorf("could not read extension attributes: %w", err)
+	}
+
+	var enr *enricher
+	if enrichment != nil {
+		enr = newEnricher(*enrichment, vClient.Client, rClient)
+	}
+
+	if err = validateContentMode(env.SinkContentMode); err != nil {
+		return nil, fmt.Errorf("could not read sink content mode: %w", err)
+	}
+
+	compression, err := newSinkCompression(env.SinkCompression)
+	if err != nil {
+		return nil, fmt.Errorf("could not read sink compression: %w", err)
+	}
+
+	headers, err := newSinkHeaders(env.SinkHeaders, env.SinkHeadersPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read sink headers: %w", err)
+	}
+
+	limiter, err := newRateLimiter(env.RateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("could not read rate limit: %w", err)
+	}
+
+	sampler, err := newSampler(env.Sampling)
+	if err != nil {
+		return nil, fmt.Errorf("could not read sampling: %w", err)
+	}
+
+	dead, err := newDeadLetters(env.SinkMaxAttempts, env.DeadLetterSink)
+	if err != nil {
+		return nil, fmt.Errorf("could not read dead letter config: %w", err)
+	}
+
+	audit, err := newAuditLog(env.Audit, transport)
+	if err != nil {
+		return nil, fmt.Errorf("could not read audit config: %w", err)
+	}
+
+	scope, err := newScope(env.Scope)
+	if err != nil {
+		return nil, fmt.Errorf("could not read scope config: %w", err)
+	}
+
+	// the Kubernetes client is only needed for authenticated sinks
+	var tokens *tokenProvider
+	if env.SinkAudience != "" {
+		tokens, err = newTokenProvider(kubeclient.Get(ctx).CoreV1(), env.Namespace, env.ServiceAccount, env.SinkAudience)
+		if err != nil {
+			return nil, fmt.Errorf("could not configure sink authentication: %w", err)
+		}
+	}
+
 	return &vAdapter{
 		Logger:    logger,
 		Namespace: env.Namespace,
 		Source:    source,
+		VCenterID: vClient.ServiceContent.About.InstanceUuid,
 		VClient:   vClient,
+		RClient:   rClient,
 		CEClient:  ceClient,
 		KVStore:   store,
 		CpConfig:  *cpconf,
-	}
+
+		PollInterval:  env.PollInterval,
+		PageSize:      env.PageSize,
+		CollectorMode: env.CollectorMode,
+		Scope:         scope,
+
+		IncludeTasks:          env.IncludeTasks,
+		IncludeContentLibrary: env.IncludeContentLibrary,
+		IncludeTags:           env.IncludeTags,
+		NormalizeAlarms:       env.NormalizeAlarms,
+		GroupVSANEvents:       env.GroupVSANEvents,
+		Filter:                filter,
+		Sinks:                 sinks,
+		MirrorSink:            env.MirrorSink,
+		Mapper:                mapper,
+		Translator:            trans,
+		Extensions:            extensions,
+		Enricher:              enr,
+		Transformer:           transformer,
+		Redactor:              redactor,
+		Encoder:               encoder,
+		SinkHeaders:           headers,
+		SinkContentMode:       env.SinkContentMode,
+		SinkCompression:       compression,
+		SinkTokens:            tokens,
+		SinkLimiter:           limiter,
+		Sampler:               sampler,
+		DeadLetters:           dead,
+		Audit:                 audit,
+		Mirrors:               mirrors,
+		HeartbeatInterval:     env.HeartbeatInterval,
+		LifecycleEvents:       env.LifecycleEvents,
+
+		// the clients are logged in
+		Health: &health{started: time.Now(), session: true},
+	}, nil
 }
 
 // Start implements adapter.Adapter
 func (a *vAdapter) Start(ctx context.Context) error {
-	defer func() {
-		// using fresh ctx to avoid canceled error during logout
-		_ = a.VClient.Logout(context.Background()) // best effort, ignoring error
-	}()
+	defer a.logout()
+
+	if a.WatchLoggingLevel != nil {
+		go a.WatchLoggingLevel(ctx)
+	}
+
+	if a.HealthPort != 0 && a.Health != nil {
+		go serveHealth(ctx, a.HealthPort, a.Health)
+	}
+
+	if a.HeartbeatInterval > 0 && a.Health != nil {
+		go a.sendHeartbeats(ctx, a.HeartbeatInterval)
+	}
+
+	a.sendLifecycle(ctx, ConnectedEventType, Lifecycle{})
+
+	logger := logging.FromContext(ctx)
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		changed := watchCredentials(runCtx, a.SecretPath, credentialsPollInterval)
+		go func() {
+			select {
+			case <-changed:
+				cancel()
+			case <-runCtx.Done():
+			}
+		}()
+
+		err := a.run(runCtx)
+		cancel()
+		// until events are polled again with a new session
+		a.Health.markSession(false)
+
+		// collectors are bound to the session, so run again from the last
+		// checkpoint with a new session
+		select {
+		case <-changed:
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.Info("vCenter credentials changed, logging in with new credentials")
+			a.logout()
+			err = a.relogin(ctx, "credentials changed")
+
+		default:
+			if !isNotAuthenticated(err) {
+				a.sendDisconnected(ctx, err)
+				return err
+			}
+			logger.Warnw("vCenter session expired", zap.Error(err))
+			a.sendLifecycle(ctx, DisconnectedEventType, Lifecycle{Reason: "session expired", Error: err.Error()})
+			err = a.relogin(ctx, "session expired")
+		}
+
+		if err != nil {
+			a.sendDisconnected(ctx, err)
+			return err
+		}
+	}
+}
+
+// logout ends the vCenter sessions of the adapter (best effort)
+func (a *vAdapter) logout() {
+	if a.Logout != nil {
+		a.Logout()
+	}
+}
 
-	return a.run(ctx)
+// logout ends the sessions of the given vCenter clients (best effort). The
+// REST client is optional.
+func logout(vClient *govmomi.Client, rClient *rest.Client) {
+	// using fresh ctx to avoid canceled error during logout
+	_ = vClient.Logout(context.Background())
+	if rClient != nil {
+		_ = rClient.Logout(context.Background())
+	}
 }
 
 // run will start reading events from vCenter and send them to the configured
@@ -112,12 +694,12 @@ func (a *vAdapter) Start(ctx context.Context) error {
 // events starting at the current vCenter time or retrieved from a previous
 // checkpoint with additional validation logic to avoid unbounded event replay.
 // A checkpoint will be created periodically to track the position in the
-// vCenter event stream. This allows to implement at-least-once semantics.
+// vCenter event stream. This allows to implement at-least-once semantics. If
+// enabled, task lifecycle events are read concurrently starting at the same
+// begin time, and content library and tag association changes are polled
+// concurrently.
 func (a *vAdapter) run(ctx context.Context) error {
-	var cp checkpoint
-	if err := a.KVStore.Get(ctx, checkpointKey, &cp); err != nil {
-		logging.FromContext(ctx).Warn("get last checkpoint: ", err)
-	}
+	cp := a.lastCheckpoint(ctx)
 
 	// begin of event stream defaults to current vCenter time (UTC)
 	vcTime, err := methods.GetCurrentTime(ctx, a.VClient)
@@ -125,64 +707,248 @@ func (a *vAdapter) run(ctx context.Context) error {
 		return fmt.Errorf("get current time from vCenter: %w", err)
 	}
 
-	begin := getBeginFromCheckpoint(ctx, *vcTime, cp, a.CpConfig.MaxAge)
-	coll, err := newHistoryCollector(ctx, a.VClient.Client, begin)
+	begin := getBegin(ctx, *vcTime, cp, a.CpConfig)
+	var resume *checkpoint
+	if cp.resumedAt(begin, a.VCenterID, a.CpConfig) {
+		logging.FromContext(ctx).Infow("skipping events processed before the checkpoint",
+			zap.Int32("eventKey", cp.LastEventKey), zap.String("windowBegin", cp.WindowBegin.String()))
+		resume = &cp
+	}
+	if begin.Before(*vcTime) {
+		a.sendLifecycle(ctx, ReplayStartedEventType, Lifecycle{ReplayFrom: &begin})
+	}
+	root, err := a.Scope.root(ctx, a.VClient.Client)
+	if err != nil {
+		return fmt.Errorf("resolve scope: %w", err)
+	}
+	coll, err := newHistoryCollector(ctx, a.VClient.Client, root, begin)
 	if err != nil {
 		return fmt.Errorf("create event collector: %w", err)
 	}
 
-	return a.readEvents(ctx, coll)
+	var tasks *taskCollector
+	if a.IncludeTasks {
+		tasks, err = newTaskCollector(ctx, a.VClient.Client, root, begin)
+		if err != nil {
+			return fmt.Errorf("create task collector: %w", err)
+		}
+	}
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		return a.readEvents(egCtx, coll, begin, resume)
+	})
+
+	if tasks != nil {
+		eg.Go(func() error {
+			return a.readTasks(egCtx, tasks)
+		})
+	}
+
+	if a.IncludeContentLibrary {
+		libraries := newLibraryCollector(a.RClient)
+		eg.Go(func() error {
+			return a.readLibraries(egCtx, libraries)
+		})
+	}
+
+	if a.IncludeTags {
+		tagAssociations := newTagCollector(a.RClient)
+		eg.Go(func() error {
+			return a.readTags(egCtx, tagAssociations)
+		})
+	}
+
+	return eg.Wait()
 }
 
-// readEvents polls vCenter for new events starting at the configured begin time
-// in the provided event history collector. A checkpoint will be periodically
+// lastCheckpoint returns the checkpoint in the kvstore, or the imported
+// checkpoint of the same vCenter if it was imported after the checkpoint in
+// the kvstore was created
+func (a *vAdapter) lastCheckpoint(ctx context.Context) checkpoint {
+	logger := logging.FromContext(ctx)
+	var cp checkpoint
+	if err := a.KVStore.Get(ctx, CheckpointKey, &cp); err != nil {
+		logger.Warn("get last checkpoint: ", err)
+	}
+	if a.CpConfig.Import == "" {
+		return cp
+	}
+
+	imported, err := parseCheckpoint([]byte(a.CpConfig.Import))
+	if err != nil {
+		logger.Warnw("ignoring invalid imported checkpoint", zap.Error(err))
+		return cp
+	}
+	if imported.VCenterID != "" && imported.VCenterID != a.VCenterID {
+		logger.Warnw("ignoring imported checkpoint of another vCenter",
+			zap.String("vCenterID", imported.VCenterID), zap.String("checkpointVCenter", imported.VCenter))
+		return cp
+	}
+	if !cp.CreatedTimestamp.Before(imported.CreatedTimestamp) {
+		return cp
+	}
+	logger.Infow("using imported checkpoint", zap.Int32("eventKey", imported.LastEventKey),
+		zap.String("importTimestamp", imported.CreatedTimestamp.String()))
+	return imported
+}
+
+// readEvents polls vCenter for new events starting at the given begin time in
+// the provided event history collector. A checkpoint will be periodically
 // created and stored in Kubernetes to track successfully processed events
-// (ACK-ed by sink).
-func (a *vAdapter) readEvents(ctx context.Context, c *event.HistoryCollector) error {
+// (ACK-ed by sink). If the event stream resumes at the given checkpoint, the
+// events processed before it are skipped.
+func (a *vAdapter) readEvents(ctx context.Context, c *event.HistoryCollector, begin time.Time, resume *checkpoint) error {
 	logger := logging.FromContext(ctx)
 
 	var (
 		lastEvent              types.BaseEvent
+		cp                     checkpoint
 		lastCheckpointEventKey int32
+		lastCheckpointTime     time.Time
+		lastStatus             DeliveryStatus
+		lastStatusTime         = time.Now()
 	)
 
-	bOff := backoff.Backoff{
-		Factor: 2,
-		Jitter: false,
-		Min:    time.Second,
-		Max:    5 * time.Second,
+	bOff := pollBackoff(a.PollInterval)
+
+	// in stream mode vCenter notifies the waiter of new events instead of
+	// polling with backoff
+	var waiter *eventWaiter
+	if a.CollectorMode == CollectorModeStream {
+		w, err := newEventWaiter(ctx, c)
+		if err != nil {
+			logger.Warnw("failed to wait for vCenter event updates, falling back to polling", zap.Error(err))
+		} else {
+			waiter = w
+			defer func() { waiter.destroy() }()
+		}
 	}
 
 	cpTicker := time.NewTicker(a.CpConfig.Period)
 	defer cpTicker.Stop()
 
+	statusTicker := time.NewTicker(deliveryStatusInterval)
+	defer statusTicker.Stop()
+
+	// deliveries in flight are finished when ctx is done, e.g. on SIGTERM,
+	// before the final checkpoint is saved
+	sendCtx, cancelSend := withGracePeriod(ctx, drainTimeout)
+	defer cancelSend()
+
+	// setCheckpoint sets the checkpoint after the last processed event, held
+	// back by the summaries not sent yet
+	setCheckpoint := func() error {
+		if lastEvent == nil {
+			return nil
+		}
+		cp = a.newCheckpoint(lastEvent, begin)
+		if err := a.KVStore.Set(ctx, CheckpointKey, cp); err != nil {
+			return fmt.Errorf("set checkpoint: %w", err)
+		}
+		return nil
+	}
+
+	// sendSummaries sends the summaries of the given closed windows, after
+	// which the checkpoint may pass their coalesced events
+	sendSummaries := func(samples []*sample) error {
+		if len(samples) == 0 {
+			return nil
+		}
+		if err := a.sendSummaries(sendCtx, samples); err != nil {
+			logger.Errorw("failed to send summaries of sampled events", zap.Error(err))
+		}
+		return setCheckpoint()
+	}
+
+	var sent int
+	shutdown := func() error {
+		// the summaries of open windows are sent before the final checkpoint
+		if err := sendSummaries(a.Sampler.drain()); err != nil {
+			logger.Errorw("failed to save final checkpoint", zap.Error(err))
+			return ctx.Err()
+		}
+		if lastEvent != nil && lastCheckpointEventKey != cp.LastEventKey {
+			if err := a.flushCheckpoint(ctx); err != nil {
+				logger.Errorw("failed to save final checkpoint", zap.Error(err))
+				return ctx.Err()
+			}
+			lastCheckpointEventKey = cp.LastEventKey
+			lastCheckpointTime = cp.LastEventKeyTimestamp.UTC()
+		}
+		logger.Infow("stopped reading events", zap.Int("sentEvents", sent),
+			zap.Int32("checkpointEventKey", lastCheckpointEventKey),
+			zap.Time("checkpointTime", lastCheckpointTime))
+		return ctx.Err()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return shutdown()
 
 		// checkpoints
 		case <-cpTicker.C:
 			// avoid unnecessary K8s API calls
-			skip := lastEvent == nil || lastCheckpointEventKey == lastEvent.GetEvent().Key
+			skip := lastEvent == nil || lastCheckpointEventKey == cp.LastEventKey
 			if !skip {
 				logger.Debug("creating checkpoint")
 				if err := a.KVStore.Save(ctx); err != nil {
 					return fmt.Errorf("save checkpoint: %w", err)
 				}
-				lastCheckpointEventKey = lastEvent.GetEvent().Key
+				lastCheckpointEventKey = cp.LastEventKey
+				lastCheckpointTime = cp.LastEventKeyTimestamp.UTC()
 			} else {
 				logger.Debug("skipping checkpoint: no new events since last checkpoint")
 			}
 
+		// delivery status
+		case now := <-statusTicker.C:
+			status := a.Deliveries.newDeliveryStatus(now.Sub(lastStatusTime), lastCheckpointTime)
+			if a.Mirrors != nil {
+				status.Mirror = a.Mirrors.newMirrorStatus(now.Sub(lastStatusTime))
+			}
+			processed := begin
+			if lastEvent != nil {
+				processed = lastEvent.GetEvent().CreatedTime
+			}
+			if lag, err := streamLag(ctx, c, processed); err != nil {
+				logger.Warnw("failed to compute the lag of the event stream", zap.Error(err))
+				status.LagSeconds = lastStatus.LagSeconds
+			} else {
+				status.LagSeconds = int64(lag / time.Second)
+			}
+			a.publishDeliveryStatus(ctx, status, lastStatus)
+			lastStatus, lastStatusTime = status, now
+
 		// poll vCenter events
 		default:
-			events, err := c.ReadNextEvents(ctx, maxEventsBatch)
+			events, err := c.ReadNextEvents(ctx, pageSize(a.PageSize))
 			if err != nil {
+				if ctx.Err() != nil {
+					return shutdown()
+				}
 				return fmt.Errorf("read events from vcenter: %w", err)
 			}
+			a.Health.markPolled(time.Now())
 
 			if len(events) == 0 {
+				// windows are also closed while no new events are read
+				if err = sendSummaries(a.Sampler.expired(time.Time{}, time.Now())); err != nil {
+					return err
+				}
+				if waiter != nil {
+					if _, err = waiter.wait(ctx, bOff.Max); err == nil {
+						continue
+					}
+					if ctx.Err() != nil {
+						return shutdown()
+					}
+					logger.Warnw("failed to wait for vCenter event updates, falling back to polling", zap.Error(err))
+					waiter.destroy()
+					waiter = nil
+				}
+
 				delay := bOff.Duration()
 				logger.Debugw("no new events, backing off", zap.String("delaySeconds", delay.String()))
 				time.Sleep(delay)
@@ -190,8 +956,22 @@ func (a *vAdapter) readEvents(ctx context.Context, c *event.HistoryCollector) er
 			}
 
 			logger.Debugf("got %d events", len(events))
+			a.Health.markEvent(events[len(events)-1].GetEvent().CreatedTime)
 
-			n, err := a.sendEvents(ctx, events)
+			if resume != nil {
+				var skipping bool
+				n := len(events)
+				if events, skipping = skipProcessed(events, *resume); !skipping {
+					resume = nil
+				}
+				logger.Debugf("skipped %d events processed before the checkpoint", n-len(events))
+				if len(events) == 0 {
+					continue
+				}
+			}
+
+			n, err := a.sendEvents(sendCtx, events)
+			sent += n
 			if err != nil {
 				// TODO: return and fail instead?
 				logger.Errorf("send events: success %d (total %d): %v", n, len(events), err)
@@ -208,15 +988,8 @@ func (a *vAdapter) readEvents(ctx context.Context, c *event.HistoryCollector) er
 
 			// last successfully sent event from batch
 			lastEvent = events[n-1]
-			cp := checkpoint{
-				VCenter:               a.Source,
-				LastEventKey:          lastEvent.GetEvent().Key,
-				LastEventType:         getEventDetails(lastEvent).Type,
-				LastEventKeyTimestamp: lastEvent.GetEvent().CreatedTime,
-				CreatedTimestamp:      time.Now().UTC(),
-			}
-			if err = a.KVStore.Set(ctx, checkpointKey, cp); err != nil {
-				return fmt.Errorf("set checkpoint: %w", err)
+			if err = setCheckpoint(); err != nil {
+				return err
 			}
 
 			bOff.Reset()
@@ -224,44 +997,306 @@ func (a *vAdapter) readEvents(ctx context.Context, c *event.HistoryCollector) er
 	}
 }
 
+// newCheckpoint returns the checkpoint after the given last processed event of
+// the event stream beginning at begin. It does not pass the first coalesced
+// event of the sampling windows whose summary was not sent yet, so that the
+// coalesced events are replayed instead of lost if the adapter stops before.
+func (a *vAdapter) newCheckpoint(last types.BaseEvent, begin time.Time) checkpoint {
+	cp := checkpoint{
+		VCenter:               a.Source,
+		LastEventKey:          last.GetEvent().Key,
+		LastEventType:         getEventDetails(last).Type,
+		LastEventKeyTimestamp: last.GetEvent().CreatedTime,
+		CreatedTimestamp:      time.Now().UTC(),
+		VCenterID:             a.VCenterID,
+		WindowBegin:           begin.UTC(),
+	}
+	if pending := a.Sampler.pending(); pending != nil && pending.GetEvent().Key <= cp.LastEventKey {
+		// the events before the pending event are processed, the stream
+		// resumes at its creation time
+		cp.LastEventKey = pending.GetEvent().Key - 1
+		cp.LastEventType = getEventDetails(pending).Type
+		cp.LastEventKeyTimestamp = pending.GetEvent().CreatedTime
+	}
+	return cp
+}
+
 // sendEvents converts all events to cloud events and sends them to the
 // configured sink. It returns the number of successfully processed events,
 // which might 0, partial or all events. sendEvents returns when all events are
-// processed or on the first error.
+// processed or on the first error. If sampling is enabled, the summaries of the
+// windows closed before an event are sent first.
 func (a *vAdapter) sendEvents(ctx context.Context, baseEvents []types.BaseEvent) (int, error) {
 	var success int
 
 	for _, be := range baseEvents {
-		ev := cloudevents.NewEvent(cloudevents.VersionV1)
-		ev.SetSource(a.Source)
+		if err := a.sendSummaries(ctx, a.Sampler.expired(be.GetEvent().CreatedTime, time.Now())); err != nil {
+			return success, err
+		}
+		if err := a.sendEvent(ctx, be, nil); err != nil {
+			return success, err
+		}
+		success++
+	}
+
+	return success, nil
+}
+
+// sendEvent converts the given event to a cloud event and sends it to the
+// configured sink, or coalesces it into its sampling window. Events dropped by
+// the translator, filter or sampler count as sent. If summary is not nil, the
+// event is sent as the summary of the given window.
+func (a *vAdapter) sendEvent(ctx context.Context, be types.BaseEvent, summary *sample) error {
+	ev, err := newEventCloudEvent(a.Source, be, eventOptions{normalizeAlarms: a.NormalizeAlarms, groupVSAN: a.GroupVSANEvents})
+	if err != nil {
+		return a.failed(ctx, withCategory(ErrorCategorySerialization, err))
+	}
+
+	ev, ok, err := a.translate(ev, be)
+	if err != nil {
+		return a.failed(ctx, withCategory(ErrorCategorySerialization, err))
+	}
+
+	// events dropped by the translator or filter count as processed for
+	// checkpointing
+	if !ok || !a.Filter.Match(ev, be) {
+		return nil
+	}
+	if summary != nil {
+		summary.annotate(&ev)
+	} else if !a.Sampler.admit(be, time.Now()) {
+		recordSampled(ctx)
+		return nil
+	}
+
+	sinkCtx := a.Sinks.withMatching(ctx, ev, be)
+	if err = a.Transformer.apply(&ev, be); err != nil {
+		// events which cannot be transformed are dropped like filtered
+		// events instead of blocking the event stream
+		err = withCategory(ErrorCategorySerialization, err)
+		recordError(ctx, err)
+		logging.FromContext(ctx).Warnw("failed to transform cloudevent", zap.String("id", ev.ID()), zap.Error(err),
+			errorCategoryField(err))
+		return nil
+	}
+
+	// TODO: better partial batch failure handling here?
+	if result := a.deliver(sinkCtx, ev, eventExtensionContext(be.GetEvent())); !cloudevents.IsACK(result) {
+		return a.failed(ctx, sinkError(result))
+	}
+	return nil
+}
+
+// deliver prepares the given event about the given context, see prepare, and
+// sends it like sendOrDeadLetter. Once the sink acknowledged the event or it
+// was given up on, it is sent to the mirror sink, if configured, so that every
+// event is mirrored once whatever the attempts to send it to the sink.
+func (a *vAdapter) deliver(ctx context.Context, ev cloudevents.Event, ec extensionContext) protocol.Result {
+	ev, err := a.prepare(ctx, ev, ec)
+	if err != nil {
+		return err
+	}
+	deadLettered, result := a.sendOrDeadLetter(ctx, ev, ec)
+	if a.MirrorSink != "" && cloudevents.IsACK(result) {
+		a.mirror(ctx, ev, ec, !deadLettered)
+	}
+	return result
+}
+
+// failed records the given error of sending an event in the error metric and
+// the delivery status by its category, and logs it. It returns err.
+func (a *vAdapter) failed(ctx context.Context, err error) error {
+	category := recordError(ctx, err)
+	a.Deliveries.recordError(category, err)
+	logging.FromContext(ctx).Errorw("failed to send cloudevent", zap.Error(err), errorCategoryField(err))
+	return err
+}
+
+// eventOptions select how vSphere events are converted into CloudEvents
+type eventOptions struct {
+	// normalizeAlarms converts alarm events with a normalized type and
+	// payload, see newAlarmCloudEvent
+	normalizeAlarms bool
+	// groupVSAN converts vSAN events with the vsan type prefix, see
+	// vsanEventType
+	groupVSAN bool
+}
+
+// newEventCloudEvent converts the given vSphere event into a CloudEvent with
+// the given options.
+func newEventCloudEvent(source string, be types.BaseEvent, opts eventOptions) (cloudevents.Event, error) {
+	if ae, ok := be.(types.BaseAlarmEvent); ok && opts.normalizeAlarms {
+		return newAlarmCloudEvent(source, ae)
+	}
 
-		details := getEventDetails(be)
-		ev.SetType("com.vmware.vsphere." + details.Type)
-		ev.SetExtension("EventClass", details.Class)
+	ev := cloudevents.NewEvent(cloudevents.VersionV1)
+	ev.SetSource(source)
 
-		// TODO: ingestion time?
-		ev.SetTime(be.GetEvent().CreatedTime)
+	details := getEventDetails(be)
+	if opts.groupVSAN && details.Class != "event" {
+		details.Type = vsanEventType(details.Type)
+	}
+	ev.SetType("com.vmware.vsphere." + details.Type)
+	ev.SetExtension("EventClass", details.Class)
+
+	// TODO: ingestion time?
+	ev.SetTime(be.GetEvent().CreatedTime)
+
+	// TODO: UUID?
+	ev.SetID(fmt.Sprintf("%d", be.GetEvent().Key))
 
-		// TODO: UUID?
-		ev.SetID(fmt.Sprintf("%d", be.GetEvent().Key))
+	// TODO(mattmoor): Consider setting the subject
+
+	// TODO: make encoding configurable?
+	if err := ev.SetData(cloudevents.ApplicationXML, be); err != nil {
+		return ev, fmt.Errorf("set data on event: %w", err)
+	}
+	return ev, nil
+}
+
+// translate applies the configured attribute mapping to the given event,
+// created from the given vSphere object, and converts it into the configured
+// output format. Events are not sent if translate returns false.
+func (a *vAdapter) translate(ev cloudevents.Event, obj interface{}) (cloudevents.Event, bool, error) {
+	if err := a.Mapper.apply(&ev); err != nil {
+		return ev, false, err
+	}
+
+	if a.Translator == nil {
+		return ev, true, nil
+	}
+	return a.Translator.translate(ev, obj)
+}
 
-		// TODO(mattmoor): Consider setting the subject
+// send sends the given event to the configured sink, prepared like prepare,
+// adding the configured sink headers and content mode to the request. Once the
+// sink acknowledged the event, it is sent to the additional sinks in ctx, see
+// sinkSet.withMatching. Every attempt is recorded in the audit log, if enabled.
+func (a *vAdapter) send(ctx context.Context, ev cloudevents.Event, ec extensionContext) protocol.Result {
+	ev, err := a.prepare(ctx, ev, ec)
+	if err != nil {
+		return err
+	}
+	return a.sendPrepared(ctx, ev, ec)
+}
+
+// prepare returns a copy of the given event as it is sent to the sink, with the
+// configured extension attributes for the given vSphere context added. If
+// enabled, the event is enriched with information about the affected virtual
+// machine or host, redacted and encoded.
+func (a *vAdapter) prepare(ctx context.Context, ev cloudevents.Event, ec extensionContext) (cloudevents.Event, error) {
+	// the copy shares its context with the event of the caller, which must not
+	// change
+	ev = ev.Clone()
+	a.Extensions.apply(&ev, a.VCenterID, ec)
+
+	entity := ec.VM
+	if entity == nil {
+		entity = ec.Host
+	}
+	if err := a.Enricher.apply(ctx, &ev, entity); err != nil {
+		// best effort, the event is sent without enrichment
+		logging.FromContext(ctx).Warnw("failed to enrich cloudevent", "id", ev.ID(), "error", err)
+	}
+	// events which cannot be redacted are not sent
+	if err := a.Redactor.apply(&ev); err != nil {
+		return ev, withCategory(ErrorCategorySerialization, fmt.Errorf("redact cloudevent: %w", err))
+	}
+	if err := a.Encoder.apply(&ev); err != nil {
+		return ev, withCategory(ErrorCategorySerialization, fmt.Errorf("encode cloudevent: %w", err))
+	}
+	return ev, nil
+}
 
-		// TODO: make encoding configurable?
-		if err := ev.SetData(cloudevents.ApplicationXML, be); err != nil {
-			return success, fmt.Errorf("set data on event: %w", err)
+// sendPrepared sends the given event, returned by prepare, like send.
+func (a *vAdapter) sendPrepared(ctx context.Context, ev cloudevents.Event, ec extensionContext) protocol.Result {
+	// the protocol writes into the header passed, so use a copy per request
+	headers := a.SinkHeaders.Clone()
+	if a.SinkTokens != nil {
+		token, err := a.SinkTokens.get(ctx)
+		if err != nil {
+			return fmt.Errorf("get sink token: %w", err)
 		}
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		headers.Set("Authorization", "Bearer "+token)
+	}
+	sinkCtx := ctx
+	if len(headers) > 0 {
+		sinkCtx = cehttp.WithCustomHeader(ctx, headers)
+	}
+	sinkCtx = withContentMode(sinkCtx, a.SinkContentMode)
+	if err := throttle(sinkCtx, a.SinkLimiter); err != nil {
+		return fmt.Errorf("wait for rate limit: %w", err)
+	}
+	result := a.sendToAudited(sinkCtx, ev, ec)
 
-		// TODO: better partial batch failure handling here?
-		result := a.CEClient.Send(ctx, ev)
+	// the token is only valid for the audience of the sink
+	for _, uri := range matchingSinks(ctx) {
 		if !cloudevents.IsACK(result) {
-			logging.FromContext(ctx).Errorw("failed to send cloudevent", zap.Error(result))
-			return success, result
+			break
 		}
-		success++
+		sinkCtx = cecontext.WithTarget(ctx, uri)
+		if headers := a.SinkHeaders.Clone(); len(headers) > 0 {
+			sinkCtx = cehttp.WithCustomHeader(sinkCtx, headers)
+		}
+		if result = a.sendToAudited(withContentMode(sinkCtx, a.SinkContentMode), ev, ec); !cloudevents.IsACK(result) {
+			result = fmt.Errorf("send to sink %s: %w", uri, result)
+		}
+	}
+	// heartbeats and lifecycle events do not count as delivered events
+	if cloudevents.IsACK(result) && !isAdapterEvent(ev.Type()) {
+		a.Deliveries.record(time.Now().UTC())
 	}
+	return result
+}
 
-	return success, nil
+// sendToAudited sends the given event about the given context like sendTo and
+// records the attempt in the audit log. Heartbeats and lifecycle events are
+// not audited.
+func (a *vAdapter) sendToAudited(ctx context.Context, ev cloudevents.Event, ec extensionContext) protocol.Result {
+	if a.Audit == nil || isAdapterEvent(ev.Type()) {
+		return a.sendTo(ctx, ev)
+	}
+	start := time.Now()
+	result := a.sendTo(ctx, ev)
+	a.Audit.record(ctx, newAuditRecord(ev, ec, targetOf(ctx), result, time.Since(start)))
+	return result
+}
+
+// sendTo sends the given event to the target in ctx, compressed if enabled
+func (a *vAdapter) sendTo(ctx context.Context, ev cloudevents.Event) protocol.Result {
+	if !a.SinkCompression.enabled() {
+		return a.CEClient.Send(ctx, ev)
+	}
+	result := a.CEClient.Send(withGzip(ctx), ev)
+	if a.SinkCompression.retryUncompressed(result) {
+		logging.FromContext(ctx).Warnw("sink does not accept compressed events, sending them uncompressed", "id", ev.ID())
+		result = a.CEClient.Send(ctx, ev)
+	}
+	return result
+}
+
+// getBegin returns the begin time of the event stream. Without an existing
+// checkpoint, a configured replay start time in the past takes precedence over
+// the current vCenter time, allowing historical events to be backfilled
+// independent of maxAge. Otherwise getBeginFromCheckpoint is used. A checkpoint
+// created before the configured reset time counts as nonexistent.
+func getBegin(ctx context.Context, vcTime time.Time, cp checkpoint, config CheckpointConfig) time.Time {
+	if config.ResetAt != nil && !cp.LastEventKeyTimestamp.IsZero() && cp.CreatedTimestamp.Before(*config.ResetAt) {
+		logging.FromContext(ctx).Infow("discarding checkpoint created before the checkpoint reset",
+			zap.String("checkpointTimestamp", cp.CreatedTimestamp.String()), zap.String("resetTimestamp", config.ResetAt.String()))
+		cp = checkpoint{}
+	}
+	if cp.LastEventKeyTimestamp.IsZero() && config.ReplayFrom != nil && config.ReplayFrom.Before(vcTime) {
+		logger := logging.FromContext(ctx)
+		logger.Info("no valid checkpoint found")
+		logger.Infow("setting begin of event stream to configured replay start time",
+			zap.String("beginTimestamp", config.ReplayFrom.String()))
+		return *config.ReplayFrom
+	}
+	return getBeginFromCheckpoint(ctx, vcTime, cp, config.MaxAge)
 }
 
 // getBeginFromCheckpoint returns the valid begin time to start replaying
@@ -78,6 +78,7 @@ func TestSendEvents(t *testing.T) {
 	testCases := map[string]struct {
 		statusCodes []int
 		baseEvents  []types.BaseEvent
+		filter      *EventFilter
 		wantEvents  []*event.Event
 		result      sendResult
 	}{
@@ -126,6 +127,14 @@ func TestSendEvents(t *testing.T) {
 				err:   nil,
 			},
 		},
+		"three events, all filtered": {
+			baseEvents: events.vEvents[:3],
+			filter:     &EventFilter{EventTypes: []string{"com.vmware.vsphere.alarm.*"}},
+			result: sendResult{
+				count: 3, // filtered events are processed but not sent
+				err:   nil,
+			},
+		},
 	}
 	for n, tc := range testCases {
 		ctx := context.Background()
@@ -145,7 +154,7 @@ func TestSendEvents(t *testing.T) {
 			}
 			logger := zaptest.NewLogger(t, zaptest.WrapOptions(zap.AddCaller()))
 
-			adapter := vAdapter{Logger: logger.Sugar(), CEClient: c, Source: source}
+			adapter := vAdapter{Logger: logger.Sugar(), CEClient: c, Source: source, Filter: tc.filter}
 			count, sendResult := adapter.sendEvents(ctx, tc.baseEvents)
 
 			if count != tc.result.count {
@@ -162,6 +171,10 @@ func TestSendEvents(t *testing.T) {
 				}
 			}
 
+			if len(roundTripper.events) != len(tc.wantEvents) {
+				t.Errorf("Unexpected number of sent events, expected %d got %d", len(tc.wantEvents), len(roundTripper.events))
+			}
+
 			for i := range tc.wantEvents {
 				if diff := cmp.Diff(tc.wantEvents[i], roundTripper.events[i]); diff != "" {
 					t.Error("unexpected diff in events", diff)
@@ -278,6 +291,126 @@ func Test_getBeginFromCheckpoint(t *testing.T) {
 	}
 }
 
+func Test_getBegin(t *testing.T) {
+	now := time.Now().UTC()
+	past := now.Add(time.Hour * -24)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		cp     checkpoint
+		config CheckpointConfig
+		want   time.Time
+	}{
+		{
+			name:   "empty checkpoint, no replay start time (use vcTime)",
+			cp:     checkpoint{},
+			config: CheckpointConfig{MaxAge: CheckpointDefaultAge},
+			want:   now,
+		},
+		{
+			name:   "empty checkpoint, replay start time older than maxAge",
+			cp:     checkpoint{},
+			config: CheckpointConfig{MaxAge: CheckpointDefaultAge, ReplayFrom: &past},
+			want:   past,
+		},
+		{
+			name:   "empty checkpoint, replay start time in the future (use vcTime)",
+			cp:     checkpoint{},
+			config: CheckpointConfig{MaxAge: CheckpointDefaultAge, ReplayFrom: &future},
+			want:   now,
+		},
+		{
+			name: "existing checkpoint takes precedence over replay start time",
+			cp: checkpoint{
+				LastEventKey:          1234,
+				LastEventKeyTimestamp: now.Add(time.Minute * -1),
+			},
+			config: CheckpointConfig{MaxAge: CheckpointDefaultAge, ReplayFrom: &past},
+			want:   now.Add(time.Minute * -1),
+		},
+		{
+			name: "checkpoint created before the reset time is discarded",
+			cp: checkpoint{
+				LastEventKey:          1234,
+				LastEventKeyTimestamp: now.Add(time.Minute * -2),
+				CreatedTimestamp:      now.Add(time.Minute * -2),
+			},
+			config: CheckpointConfig{MaxAge: CheckpointDefaultAge, ReplayFrom: &past, ResetAt: timePtr(now.Add(time.Minute * -1))},
+			want:   past,
+		},
+		{
+			name: "checkpoint created after the reset time is kept",
+			cp: checkpoint{
+				LastEventKey:          1234,
+				LastEventKeyTimestamp: now.Add(time.Minute * -1),
+				CreatedTimestamp:      now.Add(time.Minute * -1),
+			},
+			config: CheckpointConfig{MaxAge: CheckpointDefaultAge, ResetAt: timePtr(now.Add(time.Minute * -2))},
+			want:   now.Add(time.Minute * -1),
+		},
+	}
+	for _, tt := range tests {
+		ctx := context.TODO()
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getBegin(ctx, now, tt.cp, tt.config); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getBegin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_lastCheckpoint(t *testing.T) {
+	now := time.Now().UTC()
+	stored := checkpoint{LastEventKey: 1, LastEventKeyTimestamp: now.Add(-time.Hour), CreatedTimestamp: now.Add(-time.Hour), VCenterID: "vcenter-uuid"}
+	imported := checkpoint{LastEventKey: 2, LastEventKeyTimestamp: now.Add(-2 * time.Hour), CreatedTimestamp: now.Add(-time.Minute), VCenterID: "vcenter-uuid"}
+	marshal := func(cp checkpoint) string {
+		b, err := json.Marshal(cp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	tests := []struct {
+		name     string
+		stored   *checkpoint
+		cpImport string
+		want     int32
+	}{
+		{name: "no import", stored: &stored, want: 1},
+		{name: "import without stored checkpoint", cpImport: marshal(imported), want: 2},
+		{name: "import after stored checkpoint", stored: &stored, cpImport: marshal(imported), want: 2},
+		{
+			name:     "stored checkpoint after import",
+			stored:   &checkpoint{LastEventKey: 3, LastEventKeyTimestamp: now, CreatedTimestamp: now, VCenterID: "vcenter-uuid"},
+			cpImport: marshal(imported),
+			want:     3,
+		},
+		{
+			name:     "import of another vCenter",
+			stored:   &stored,
+			cpImport: marshal(checkpoint{LastEventKey: 2, LastEventKeyTimestamp: now, CreatedTimestamp: now, VCenterID: "other-uuid"}),
+			want:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kv := &fakeKVStore{}
+			if tt.stored != nil {
+				if err := kv.Set(context.Background(), CheckpointKey, tt.stored); err != nil {
+					t.Fatal(err)
+				}
+			}
+			a := &vAdapter{Logger: zaptest.NewLogger(t).Sugar(), KVStore: kv, VCenterID: "vcenter-uuid",
+				CpConfig: CheckpointConfig{Import: tt.cpImport}}
+			if got := a.lastCheckpoint(context.Background()); got.LastEventKey != tt.want {
+				t.Errorf("lastCheckpoint() = checkpoint of event %d, want %d", got.LastEventKey, tt.want)
+			}
+		})
+	}
+}
+
 func Test_vAdapter_run(t *testing.T) {
 	const (
 		// number of vcsim events emitted for default VPX model
@@ -319,7 +452,7 @@ func Test_vAdapter_run(t *testing.T) {
 				Source:      source,
 				KVStore: &fakeKVStore{
 					data: map[string]string{
-						checkpointKey: createCheckpoint(t, now.Add(time.Hour*-1)),
+						CheckpointKey: createCheckpoint(t, now.Add(time.Hour*-1)),
 					},
 					dataChan: make(chan string, 1),
 				},
@@ -338,7 +471,7 @@ func Test_vAdapter_run(t *testing.T) {
 				Source:      source,
 				KVStore: &fakeKVStore{
 					data: map[string]string{
-						checkpointKey: createCheckpoint(t, now.Add(time.Hour*-1)),
+						CheckpointKey: createCheckpoint(t, now.Add(time.Hour*-1)),
 					},
 					dataChan: make(chan string, 1),
 				},
@@ -436,7 +569,7 @@ func Test_vAdapter_run(t *testing.T) {
 				}
 
 				if tt.wantCheckpointKey != cp.LastEventKey {
-					t.Errorf("run() checkpointKey = %v, wantEventKey %v", cp.LastEventKey, tt.wantCheckpointKey)
+					t.Errorf("run() CheckpointKey = %v, wantEventKey %v", cp.LastEventKey, tt.wantCheckpointKey)
 				}
 
 				return nil
@@ -504,7 +637,7 @@ func (f *fakeKVStore) Save(ctx context.Context) error {
 		return nil
 	}
 	f.saved = true
-	f.dataChan <- f.data[checkpointKey]
+	f.dataChan <- f.data[CheckpointKey]
 	return nil
 }
 
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	// event class used for alarm events
+	alarmEventClass = "alarm"
+	// type prefix of alarm events, e.g. com.vmware.vsphere.alarm.statuschanged
+	alarmEventTypePrefix = "com.vmware.vsphere.alarm."
+)
+
+// ObjectRef identifies a vSphere managed object in an event payload
+type ObjectRef struct {
+	Name  string `json:"name,omitempty" protobuf:"1"`
+	Type  string `json:"type" protobuf:"2"`
+	Value string `json:"value" protobuf:"3"`
+}
+
+// AlarmEventData is the normalized JSON payload of alarm CloudEvents, so that
+// consumers do not have to decode the various vSphere alarm event classes.
+type AlarmEventData struct {
+	Key         int32      `json:"key" protobuf:"1"`
+	CreatedTime time.Time  `json:"createdTime" protobuf:"2"`
+	UserName    string     `json:"userName,omitempty" protobuf:"3"`
+	Message     string     `json:"message,omitempty" protobuf:"4"`
+	Alarm       ObjectRef  `json:"alarm" protobuf:"5"`
+	Entity      *ObjectRef `json:"entity,omitempty" protobuf:"6"`
+	// From and To contain the alarm status (gray, green, yellow, red) before
+	// and after a status change
+	From string `json:"from,omitempty" protobuf:"7"`
+	To   string `json:"to,omitempty" protobuf:"8"`
+}
+
+// alarmEventType returns the CloudEvent type for the given alarm event class,
+// e.g. com.vmware.vsphere.alarm.statuschanged for AlarmStatusChangedEvent.
+func alarmEventType(class string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(class, "Alarm"), "Event")
+	if name == "" {
+		// generic AlarmEvent
+		name = "event"
+	}
+	return alarmEventTypePrefix + strings.ToLower(name)
+}
+
+// newAlarmCloudEvent converts the given vSphere alarm event into a CloudEvent
+// with a normalized payload.
+func newAlarmCloudEvent(source string, ae types.BaseAlarmEvent) (cloudevents.Event, error) {
+	e := ae.GetAlarmEvent()
+
+	ev := cloudevents.NewEvent(cloudevents.VersionV1)
+	ev.SetSource(source)
+	ev.SetType(alarmEventType(reflect.TypeOf(ae).Elem().Name()))
+	setDataSchema(&ev, schemaAlarm)
+	ev.SetExtension("EventClass", alarmEventClass)
+	ev.SetTime(e.CreatedTime)
+	ev.SetID(fmt.Sprintf("%d", e.Key))
+
+	data := AlarmEventData{
+		Key:         e.Key,
+		CreatedTime: e.CreatedTime,
+		UserName:    e.UserName,
+		Message:     e.FullFormattedMessage,
+		Alarm: ObjectRef{
+			Name:  e.Alarm.Name,
+			Type:  e.Alarm.Alarm.Type,
+			Value: e.Alarm.Alarm.Value,
+		},
+	}
+
+	// the alarm event classes do not share a common accessor for the entity,
+	// so look it up by name
+	if f := reflect.ValueOf(ae).Elem().FieldByName("Entity"); f.IsValid() {
+		if arg, ok := f.Interface().(types.ManagedEntityEventArgument); ok {
+			data.Entity = &ObjectRef{
+				Name:  arg.Name,
+				Type:  arg.Entity.Type,
+				Value: arg.Entity.Value,
+			}
+		}
+	}
+
+	switch e := ae.(type) {
+	case *types.AlarmStatusChangedEvent:
+		data.From = e.From
+		data.To = e.To
+	case *types.AlarmClearedEvent:
+		data.From = e.From
+	}
+
+	if err := ev.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		return ev, fmt.Errorf("set data on event: %w", err)
+	}
+	return ev, nil
+}
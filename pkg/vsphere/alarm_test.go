@@ -0,0 +1,134 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newAlarmCloudEvent(t *testing.T) {
+	created := time.Date(2021, 2, 15, 19, 20, 0, 0, time.UTC)
+
+	alarm := types.AlarmEvent{
+		Event: types.Event{
+			Key:                  42,
+			CreatedTime:          created,
+			FullFormattedMessage: "Alarm 'Host CPU usage' changed from Green to Red",
+		},
+		Alarm: types.AlarmEventArgument{
+			EntityEventArgument: types.EntityEventArgument{Name: "Host CPU usage"},
+			Alarm:               types.ManagedObjectReference{Type: "Alarm", Value: "alarm-1"},
+		},
+	}
+	entity := types.ManagedEntityEventArgument{
+		EntityEventArgument: types.EntityEventArgument{Name: "esx-01"},
+		Entity:              types.ManagedObjectReference{Type: "HostSystem", Value: "host-1"},
+	}
+	wantAlarm := ObjectRef{Name: "Host CPU usage", Type: "Alarm", Value: "alarm-1"}
+	wantEntity := &ObjectRef{Name: "esx-01", Type: "HostSystem", Value: "host-1"}
+
+	tests := []struct {
+		name     string
+		event    types.BaseAlarmEvent
+		wantType string
+		wantData AlarmEventData
+	}{
+		{
+			name:     "status changed",
+			event:    &types.AlarmStatusChangedEvent{AlarmEvent: alarm, Entity: entity, From: "green", To: "red"},
+			wantType: "com.vmware.vsphere.alarm.statuschanged",
+			wantData: AlarmEventData{Alarm: wantAlarm, Entity: wantEntity, From: "green", To: "red"},
+		},
+		{
+			name:     "cleared",
+			event:    &types.AlarmClearedEvent{AlarmEvent: alarm, Entity: entity, From: "red"},
+			wantType: "com.vmware.vsphere.alarm.cleared",
+			wantData: AlarmEventData{Alarm: wantAlarm, Entity: wantEntity, From: "red"},
+		},
+		{
+			name:     "email completed",
+			event:    &types.AlarmEmailCompletedEvent{AlarmEvent: alarm, Entity: entity, To: "ops@example.com"},
+			wantType: "com.vmware.vsphere.alarm.emailcompleted",
+			wantData: AlarmEventData{Alarm: wantAlarm, Entity: wantEntity},
+		},
+		{
+			name:     "generic alarm event",
+			event:    &alarm,
+			wantType: "com.vmware.vsphere.alarm.event",
+			wantData: AlarmEventData{Alarm: wantAlarm},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := newAlarmCloudEvent(source, tt.event)
+			if err != nil {
+				t.Fatalf("newAlarmCloudEvent() error = %v", err)
+			}
+			if ev.Type() != tt.wantType {
+				t.Errorf("Type() = %s, want %s", ev.Type(), tt.wantType)
+			}
+			if ev.ID() != "42" {
+				t.Errorf("ID() = %s, want 42", ev.ID())
+			}
+			if err := ev.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+
+			var got AlarmEventData
+			if err := ev.DataAs(&got); err != nil {
+				t.Fatalf("DataAs() error = %v", err)
+			}
+
+			want := tt.wantData
+			want.Key = 42
+			want.CreatedTime = created
+			want.Message = alarm.FullFormattedMessage
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected event data (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func Test_newEventCloudEventAlarm(t *testing.T) {
+	be := &types.AlarmStatusChangedEvent{AlarmEvent: types.AlarmEvent{Event: types.Event{Key: 42}}, From: "green", To: "red"}
+
+	tests := []struct {
+		name            string
+		normalizeAlarms bool
+		wantType        string
+		wantContentType string
+	}{
+		{
+			name:            "vSphere event",
+			wantType:        "com.vmware.vsphere.AlarmStatusChangedEvent",
+			wantContentType: cloudevents.ApplicationXML,
+		},
+		{
+			name:            "normalized",
+			normalizeAlarms: true,
+			wantType:        "com.vmware.vsphere.alarm.statuschanged",
+			wantContentType: cloudevents.ApplicationJSON,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := newEventCloudEvent(source, be, eventOptions{normalizeAlarms: tt.normalizeAlarms})
+			if err != nil {
+				t.Fatalf("newEventCloudEvent() error = %v", err)
+			}
+			if ev.Type() != tt.wantType || ev.DataContentType() != tt.wantContentType {
+				t.Errorf("newEventCloudEvent() = %s with %s payload, want %s with %s payload",
+					ev.Type(), ev.DataContentType(), tt.wantType, tt.wantContentType)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// APIDefaultRetryBackoff is the default delay before the first retry of a
+	// failed vCenter API call or login
+	APIDefaultRetryBackoff = time.Second
+	// APIDefaultMaxRetryBackoff is the default maximum delay between retries
+	// of a failed vCenter API call or login
+	APIDefaultMaxRetryBackoff = time.Minute
+	// APIMaxRetries is the maximum number of retries of a vCenter API call
+	APIMaxRetries = 10
+)
+
+// APIConfig configures the timeout and the retries of the vCenter SOAP API
+// calls of a client, e.g. logins, reads of events and property lookups. The
+// zero value keeps the behavior of govmomi: calls are neither bounded nor
+// retried.
+type APIConfig struct {
+	// Timeout is the maximum duration of an attempt of a call, unbounded if
+	// 0. The wait time of long polls, e.g. WaitForUpdatesEx, is added.
+	Timeout time.Duration `envconfig:"VC_API_TIMEOUT" default:"0s" json:"timeout,omitempty"`
+	// Retries is the number of retries of a call failing with a network error
+	// or a timeout. Calls failing with a vCenter fault are not retried.
+	Retries int `envconfig:"VC_API_RETRIES" default:"0" json:"retries,omitempty"`
+	// RetryBackoff is the delay before the first retry, doubled with each
+	// retry up to MaxRetryBackoff. It also paces the logins after the session
+	// expired. APIDefaultRetryBackoff if 0.
+	RetryBackoff time.Duration `envconfig:"VC_API_RETRY_BACKOFF" default:"0s" json:"retryBackoff,omitempty"`
+	// MaxRetryBackoff is the maximum delay between retries and logins,
+	// APIDefaultMaxRetryBackoff if 0
+	MaxRetryBackoff time.Duration `envconfig:"VC_API_MAX_RETRY_BACKOFF" default:"0s" json:"maxRetryBackoff,omitempty"`
+}
+
+// backoff returns the backoff between the retries of a call
+func (c APIConfig) backoff() wait.Backoff {
+	b := wait.Backoff{
+		Duration: APIDefaultRetryBackoff,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    c.Retries,
+		Cap:      APIDefaultMaxRetryBackoff,
+	}
+	if c.RetryBackoff > 0 {
+		b.Duration = c.RetryBackoff
+	}
+	if c.MaxRetryBackoff > 0 {
+		b.Cap = c.MaxRetryBackoff
+	}
+	return b
+}
+
+// reloginBackoff returns the backoff between login attempts after the session
+// expired, which is reloginBackoff with the configured delays
+func (c APIConfig) reloginBackoff() wait.Backoff {
+	b := reloginBackoff
+	if c.RetryBackoff > 0 {
+		b.Duration = c.RetryBackoff
+	}
+	if c.MaxRetryBackoff > 0 {
+		b.Cap = c.MaxRetryBackoff
+	}
+	return b
+}
+
+// roundTripper returns the given round tripper with the timeout and retries
+// of the config applied, or rt itself if neither is configured
+func (c APIConfig) roundTripper(rt soap.RoundTripper) soap.RoundTripper {
+	if c.Timeout <= 0 && c.Retries <= 0 {
+		return rt
+	}
+	return &retryRoundTripper{RoundTripper: rt, config: c}
+}
+
+// retryRoundTripper bounds the attempts of vCenter SOAP API calls with the
+// timeout of its config, and retries calls failing with network errors or
+// timeouts with exponential backoff.
+type retryRoundTripper struct {
+	soap.RoundTripper
+	config APIConfig
+}
+
+// RoundTrip implements soap.RoundTripper
+func (rt *retryRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	backoff := rt.config.backoff()
+	for {
+		err := rt.attempt(ctx, req, res)
+		if err == nil || ctx.Err() != nil || backoff.Steps <= 0 || !isRetryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff.Step())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt makes a single attempt of the call, bounded by the timeout
+func (rt *retryRoundTripper) attempt(ctx context.Context, req, res soap.HasFault) error {
+	if rt.config.Timeout <= 0 {
+		return rt.RoundTripper.RoundTrip(ctx, req, res)
+	}
+	ctx, cancel := context.WithTimeout(ctx, rt.config.Timeout+longPollWait(req))
+	defer cancel()
+	return rt.RoundTripper.RoundTrip(ctx, req, res)
+}
+
+// longPollWait returns the maximum time vCenter waits for updates before it
+// responds to the given request, 0 if it responds right away
+func longPollWait(req soap.HasFault) time.Duration {
+	body, ok := req.(*methods.WaitForUpdatesExBody)
+	if !ok || body.Req == nil || body.Req.Options == nil || body.Req.Options.MaxWaitSeconds == nil {
+		return 0
+	}
+	return time.Duration(*body.Req.Options.MaxWaitSeconds) * time.Second
+}
+
+// isRetryable returns true if the given error of an API call is caused by the
+// network or a timeout, not by vCenter
+func isRetryable(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || connectionFailureReason(err) == ConnectionReasonUnreachable
+}
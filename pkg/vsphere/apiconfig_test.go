@@ -0,0 +1,122 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// fakeRoundTripper fails the first calls with the given errors
+type fakeRoundTripper struct {
+	errs  []error
+	calls int
+	// block waits until the context of the call is done instead
+	block bool
+}
+
+func (rt *fakeRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	rt.calls++
+	if rt.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if rt.calls <= len(rt.errs) {
+		return rt.errs[rt.calls-1]
+	}
+	return nil
+}
+
+func Test_retryRoundTripper(t *testing.T) {
+	unreachable := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	fault := soap.WrapVimFault(&types.InvalidLogin{})
+
+	tests := []struct {
+		name      string
+		config    APIConfig
+		rt        *fakeRoundTripper
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "retries network errors",
+			config:    APIConfig{Retries: 3, RetryBackoff: time.Millisecond},
+			rt:        &fakeRoundTripper{errs: []error{unreachable, unreachable}},
+			wantCalls: 3,
+		},
+		{
+			name:      "gives up after the retries",
+			config:    APIConfig{Retries: 2, RetryBackoff: time.Millisecond},
+			rt:        &fakeRoundTripper{errs: []error{unreachable, unreachable, unreachable}},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "does not retry faults",
+			config:    APIConfig{Retries: 3, RetryBackoff: time.Millisecond},
+			rt:        &fakeRoundTripper{errs: []error{fault}},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "retries timeouts",
+			config:    APIConfig{Timeout: time.Millisecond, Retries: 1, RetryBackoff: time.Millisecond},
+			rt:        &fakeRoundTripper{block: true},
+			wantCalls: 2,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := tt.config.roundTripper(tt.rt)
+			err := rt.RoundTrip(context.Background(), &methods.RetrievePropertiesBody{}, &methods.RetrievePropertiesBody{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RoundTrip() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.rt.calls != tt.wantCalls {
+				t.Errorf("RoundTrip() made %d calls, want %d", tt.rt.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func Test_APIConfig_roundTripper(t *testing.T) {
+	rt := &fakeRoundTripper{}
+	if got := (APIConfig{}).roundTripper(rt); got != rt {
+		t.Errorf("roundTripper() = %T, want the round tripper unchanged", got)
+	}
+	if _, ok := (APIConfig{Timeout: time.Second}).roundTripper(rt).(*retryRoundTripper); !ok {
+		t.Error("roundTripper() with a timeout is not a retryRoundTripper")
+	}
+}
+
+func Test_longPollWait(t *testing.T) {
+	wait := int32(5)
+	req := &methods.WaitForUpdatesExBody{Req: &types.WaitForUpdatesEx{Options: &types.WaitOptions{MaxWaitSeconds: &wait}}}
+	if got := longPollWait(req); got != 5*time.Second {
+		t.Errorf("longPollWait() = %v, want 5s", got)
+	}
+	if got := longPollWait(&methods.RetrievePropertiesBody{}); got != 0 {
+		t.Errorf("longPollWait() = %v, want 0", got)
+	}
+}
+
+func Test_APIConfig_reloginBackoff(t *testing.T) {
+	if got := (APIConfig{}).reloginBackoff(); got != reloginBackoff {
+		t.Errorf("reloginBackoff() = %+v, want the default %+v", got, reloginBackoff)
+	}
+	got := APIConfig{RetryBackoff: 5 * time.Second, MaxRetryBackoff: 2 * time.Minute}.reloginBackoff()
+	if got.Duration != 5*time.Second || got.Cap != 2*time.Minute || got.Steps != reloginBackoff.Steps {
+		t.Errorf("reloginBackoff() = %+v, want 5s up to 2m", got)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/pkg/metrics"
+)
+
+const (
+	// apiFaultNone is the fault of the successful vCenter API calls
+	apiFaultNone = "none"
+	// apiFaultError is the fault of the vCenter API calls failing without a
+	// SOAP fault, e.g. on connection errors or timeouts
+	apiFaultError = "error"
+)
+
+var (
+	apiCallCountM = stats.Int64(
+		"vcenter_api_call_count",
+		"Number of vCenter SOAP API calls",
+		stats.UnitDimensionless,
+	)
+	apiLatencyM = stats.Float64(
+		"vcenter_api_call_latencies",
+		"Latency of the vCenter SOAP API calls",
+		stats.UnitMilliseconds,
+	)
+	apiMethodKey = tag.MustNewKey("method")
+	apiFaultKey  = tag.MustNewKey("fault")
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: apiCallCountM.Description(),
+		Measure:     apiCallCountM,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{apiMethodKey, apiFaultKey},
+	}, &view.View{
+		Description: apiLatencyM.Description(),
+		Measure:     apiLatencyM,
+		Aggregation: view.Distribution(metrics.Buckets125(1, 100000)...),
+		TagKeys:     []tag.Key{apiMethodKey},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// metricsRoundTripper records the number, latency and faults of the vCenter
+// SOAP API calls per method, to tell a slow vCenter from a slow sink. The
+// latency of long polls, e.g. WaitForUpdatesEx, includes the wait time.
+type metricsRoundTripper struct {
+	soap.RoundTripper
+}
+
+// RoundTrip implements soap.RoundTripper
+func (rt *metricsRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	start := time.Now()
+	err := rt.RoundTripper.RoundTrip(ctx, req, res)
+	latency := time.Since(start)
+
+	method := apiMethod(req)
+	metrics.Record(ctx, apiCallCountM.M(1), stats.WithTags(
+		tag.Insert(apiMethodKey, method),
+		tag.Insert(apiFaultKey, apiFault(err))))
+	metrics.Record(ctx, apiLatencyM.M(float64(latency)/float64(time.Millisecond)),
+		stats.WithTags(tag.Insert(apiMethodKey, method)))
+	return err
+}
+
+// apiMethod returns the name of the vCenter API method of the given request
+// body, e.g. RetrieveProperties for a *methods.RetrievePropertiesBody
+func apiMethod(req soap.HasFault) string {
+	t := reflect.TypeOf(req)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Name() == "" {
+		return "unknown"
+	}
+	return strings.TrimSuffix(t.Name(), "Body")
+}
+
+// apiFault returns the type of the vCenter fault of the given error of an API
+// call, e.g. NotAuthenticated, apiFaultError if it is not a fault and
+// apiFaultNone if the call succeeded
+func apiFault(err error) string {
+	var fault interface{}
+	switch {
+	case err == nil:
+		return apiFaultNone
+	case soap.IsSoapFault(err):
+		fault = soap.ToSoapFault(err).VimFault()
+	case soap.IsVimFault(err):
+		fault = soap.ToVimFault(err)
+	default:
+		return apiFaultError
+	}
+
+	t := reflect.TypeOf(fault)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Name() == "" {
+		// a SOAP fault without vCenter details
+		return "SoapFault"
+	}
+	return t.Name()
+}
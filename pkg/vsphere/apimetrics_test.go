@@ -0,0 +1,69 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_apiMethod(t *testing.T) {
+	tests := []struct {
+		name string
+		req  soap.HasFault
+		want string
+	}{
+		{name: "request body", req: &methods.RetrievePropertiesBody{}, want: "RetrieveProperties"},
+		{name: "nil request", want: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := apiMethod(tt.req); got != tt.want {
+				t.Errorf("apiMethod() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_metricsRoundTripper(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		vim.RoundTripper = &metricsRoundTripper{RoundTripper: vim.RoundTripper}
+
+		if _, err := methods.GetCurrentTime(ctx, vim); err != nil {
+			t.Fatalf("GetCurrentTime() error = %v", err)
+		}
+		if err := session.NewManager(vim).Logout(ctx); err != nil {
+			t.Fatal(err)
+		}
+		_, expired := methods.GetCurrentTime(ctx, vim)
+
+		tests := []struct {
+			name string
+			err  error
+			want string
+		}{
+			{name: "no error", want: apiFaultNone},
+			{name: "other error", err: errors.New("connection refused"), want: apiFaultError},
+			{name: "soap fault", err: expired, want: "NotAuthenticated"},
+			{name: "vim fault", err: soap.WrapVimFault(&types.InvalidLogin{}), want: "InvalidLogin"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := apiFault(tt.err); got != tt.want {
+					t.Errorf("apiFault(%v) = %s, want %s", tt.err, got, tt.want)
+				}
+			})
+		}
+	})
+}
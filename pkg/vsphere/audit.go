@@ -0,0 +1,223 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/uuid"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+const (
+	// AuditEventType is the type of the events carrying an AuditRecord, which
+	// are sent to the audit sink
+	AuditEventType = "com.vmware.vsphere.audit"
+
+	// AuditResultDelivered is the result of an event acknowledged by a sink
+	AuditResultDelivered = "delivered"
+	// AuditResultFailed is the result of a failed attempt to send an event
+	AuditResultFailed = "failed"
+	// AuditResultDeadLettered is the result of an event given up on and
+	// recorded as a dead letter
+	AuditResultDeadLettered = "deadLettered"
+)
+
+// AuditConfig configures the audit log of the events dispatched by the
+// adapter. At least one of Sink and Path is set.
+type AuditConfig struct {
+	// Sink is the URI the audit records are sent to as events
+	Sink string `json:"sink,omitempty"`
+	// Path is the file the audit records are appended to as JSON lines
+	Path string `json:"path,omitempty"`
+}
+
+// AuditRecord is the metadata of an attempt to send an event to a sink,
+// recorded in the audit log to prove which events were forwarded where.
+type AuditRecord struct {
+	// ID is the id of the event
+	ID string `json:"id"`
+	// Type is the type of the event
+	Type string `json:"type"`
+	// Source is the source of the event
+	Source string `json:"source"`
+	// Entity is the virtual machine or else host the event is about, e.g.
+	// VirtualMachine:vm-42
+	Entity string `json:"entity,omitempty"`
+	// Sink is the URI the event was sent to, empty if it was written to Kafka,
+	// logged or recorded in the kvstore
+	Sink string `json:"sink,omitempty"`
+	// Result is one of delivered, failed or deadLettered
+	Result string `json:"result"`
+	// Error is the error of a failed attempt
+	Error string `json:"error,omitempty"`
+	// LatencyMillis is the duration of the attempt in milliseconds
+	LatencyMillis int64 `json:"latencyMillis"`
+	// Time is when the attempt ended
+	Time time.Time `json:"time"`
+}
+
+var auditFailureCountM = stats.Int64(
+	"audit_failure_count",
+	"Number of audit records which could not be written to the audit log",
+	stats.UnitDimensionless,
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: auditFailureCountM.Description(),
+		Measure:     auditFailureCountM,
+		Aggregation: view.Count(),
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// auditLog appends an AuditRecord for every event dispatched by the adapter
+// to a file and sends it to the audit sink.
+type auditLog struct {
+	sink   string
+	client cloudevents.Client
+
+	// mu serializes the records appended to file
+	mu   sync.Mutex
+	file io.Writer
+}
+
+// newAuditLog returns the audit log for the given JSON-encoded AuditConfig,
+// which is nil if s is empty. The records are sent to the audit sink with the
+// given transport. The audit file is created if it does not exist.
+func newAuditLog(s string, transport http.RoundTripper) (*auditLog, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var ac AuditConfig
+	if err := json.Unmarshal([]byte(s), &ac); err != nil {
+		return nil, fmt.Errorf("unmarshal audit config: %w", err)
+	}
+	if ac.Sink == "" && ac.Path == "" {
+		return nil, errors.New("audit requires a sink or a path")
+	}
+
+	al := &auditLog{sink: ac.Sink}
+	if ac.Path != "" {
+		if !filepath.IsAbs(ac.Path) {
+			return nil, fmt.Errorf("audit path must be absolute, was %q", ac.Path)
+		}
+		f, err := os.OpenFile(ac.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open audit file: %w", err)
+		}
+		al.file = f
+	}
+	if ac.Sink != "" {
+		// independent of the client of the adapter, which might write to Kafka
+		// or only log the events
+		c, err := cloudevents.NewClientHTTP(cehttp.WithClient(http.Client{}), cloudevents.WithRoundTripper(transport))
+		if err != nil {
+			return nil, fmt.Errorf("create audit client: %w", err)
+		}
+		al.client = c
+	}
+	return al, nil
+}
+
+// newAuditRecord returns the record of the given event about the given
+// context, sent to the given sink with the given result after the given
+// latency
+func newAuditRecord(ev cloudevents.Event, ec extensionContext, sink string, result protocol.Result, latency time.Duration) AuditRecord {
+	rec := AuditRecord{
+		ID:            ev.ID(),
+		Type:          ev.Type(),
+		Source:        ev.Source(),
+		Sink:          sink,
+		Result:        AuditResultDelivered,
+		LatencyMillis: latency.Milliseconds(),
+		Time:          time.Now().UTC(),
+	}
+	if ec.VM != nil {
+		rec.Entity = ec.VM.String()
+	} else if ec.Host != nil {
+		rec.Entity = ec.Host.String()
+	}
+	if !cloudevents.IsACK(result) {
+		rec.Result = AuditResultFailed
+		rec.Error = result.Error()
+	}
+	return rec
+}
+
+// targetOf returns the target of the CloudEvents client in ctx, or an empty
+// string if there is none
+func targetOf(ctx context.Context) string {
+	if u := cecontext.TargetFrom(ctx); u != nil {
+		return u.String()
+	}
+	return ""
+}
+
+// record appends the given record to the audit file and sends it to the
+// audit sink. Failures are logged and counted, but do not fail the delivery
+// of the event, which has happened already.
+func (al *auditLog) record(ctx context.Context, rec AuditRecord) {
+	if al == nil {
+		return
+	}
+	logger := logging.FromContext(ctx)
+
+	if al.file != nil {
+		if err := al.append(rec); err != nil {
+			logger.Errorw("failed to append audit record", zap.String("id", rec.ID), zap.Error(err))
+			metrics.Record(ctx, auditFailureCountM.M(1))
+		}
+	}
+	if al.client != nil {
+		ev := cloudevents.NewEvent(cloudevents.VersionV1)
+		ev.SetSource(rec.Source)
+		ev.SetType(AuditEventType)
+		ev.SetID(uuid.New().String())
+		ev.SetTime(rec.Time)
+		if err := ev.SetData(cloudevents.ApplicationJSON, rec); err != nil {
+			logger.Errorw("failed to set data on audit event", zap.String("id", rec.ID), zap.Error(err))
+			metrics.Record(ctx, auditFailureCountM.M(1))
+			return
+		}
+		if result := al.client.Send(cecontext.WithTarget(ctx, al.sink), ev); !cloudevents.IsACK(result) {
+			logger.Errorw("failed to send audit record", zap.String("id", rec.ID), zap.Error(result))
+			metrics.Record(ctx, auditFailureCountM.M(1))
+		}
+	}
+}
+
+// append writes the given record as one JSON line to the audit file
+func (al *auditLog) append(rec AuditRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal audit record: %w", err)
+	}
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	_, err = al.file.Write(append(b, '\n'))
+	return err
+}
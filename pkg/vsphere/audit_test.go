@@ -0,0 +1,164 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func Test_newAuditLog(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		config   string
+		wantNil  bool
+		wantFile bool
+		wantErr  bool
+	}{
+		{name: "disabled", wantNil: true},
+		{name: "sink", config: `{"sink":"http://audit.example.com"}`},
+		{name: "file", config: `{"path":"` + filepath.Join(dir, "events.log") + `"}`, wantFile: true},
+		{name: "invalid JSON", config: `{`, wantErr: true},
+		{name: "neither sink nor path", config: `{}`, wantErr: true},
+		{name: "relative path", config: `{"path":"events.log"}`, wantErr: true},
+		{name: "missing directory", config: `{"path":"` + filepath.Join(dir, "missing", "events.log") + `"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newAuditLog(tt.config, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newAuditLog() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("newAuditLog() = %v, want nil %v", got, tt.wantNil)
+			}
+			if tt.wantFile {
+				if _, err := os.Stat(filepath.Join(dir, "events.log")); err != nil {
+					t.Errorf("audit file not created: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendEventsAudit(t *testing.T) {
+	tests := []struct {
+		name        string
+		failHost    string
+		wantHosts   []string
+		wantResults []string
+	}{{
+		name:        "delivered",
+		wantHosts:   []string{"fake.example.com", "audit.example.com", "fake.example.com", "audit.example.com"},
+		wantResults: []string{AuditResultDelivered, AuditResultDelivered},
+	}, {
+		name:     "dead lettered",
+		failHost: "fake.example.com",
+		wantHosts: []string{
+			"fake.example.com", "audit.example.com", "fake.example.com", "audit.example.com", "audit.example.com",
+			"fake.example.com", "audit.example.com", "fake.example.com", "audit.example.com", "audit.example.com",
+		},
+		wantResults: []string{AuditResultFailed, AuditResultFailed, AuditResultDeadLettered, AuditResultFailed,
+			AuditResultFailed, AuditResultDeadLettered},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &hostRecorder{failHost: tt.failHost}
+			p, err := cehttp.New(cehttp.WithRoundTripper(rt))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := client.New(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dead, err := newDeadLetters(2, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			dead.backoff = wait.Backoff{}
+
+			path := filepath.Join(t.TempDir(), "events.log")
+			audit, err := newAuditLog(`{"sink":"http://audit.example.com","path":"`+path+`"}`, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			audit.client = c
+			a := &vAdapter{Logger: zaptest.NewLogger(t).Sugar(), CEClient: c, Source: source, KVStore: &fakeKVStore{},
+				DeadLetters: dead, Audit: audit}
+
+			events := createTestEvents(2, source, time.Now().UTC())
+			events.vEvents[0].GetEvent().Vm = &types.VmEventArgument{Vm: types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-42"}}
+			ctx := cecontext.WithTarget(context.Background(), "http://fake.example.com")
+			if _, err := a.sendEvents(ctx, events.vEvents); err != nil {
+				t.Fatalf("sendEvents() error = %v", err)
+			}
+			if !cmp.Equal(rt.hosts, tt.wantHosts) {
+				t.Errorf("hosts = %v, want %v", rt.hosts, tt.wantHosts)
+			}
+
+			records := readAuditRecords(t, path)
+			var results []string
+			for _, rec := range records {
+				results = append(results, rec.Result)
+				if (rec.Result == AuditResultDelivered) != (rec.Error == "") {
+					t.Errorf("audit record %+v has result %s with error %q", rec, rec.Result, rec.Error)
+				}
+			}
+			if !cmp.Equal(results, tt.wantResults) {
+				t.Errorf("audit results = %v, want %v", results, tt.wantResults)
+			}
+			first := records[0]
+			if first.ID != "1000" || first.Source != source || first.Entity != "VirtualMachine:vm-42" {
+				t.Errorf("first audit record = %+v, want event 1000 from %s about VirtualMachine:vm-42", first, source)
+			}
+			if first.Sink != "http://fake.example.com" {
+				t.Errorf("first audit record sink = %s, want http://fake.example.com", first.Sink)
+			}
+		})
+	}
+}
+
+func readAuditRecords(t *testing.T, path string) []AuditRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var records []AuditRecord
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var rec AuditRecord
+		if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+			t.Fatalf("unmarshal audit record %q: %v", s.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
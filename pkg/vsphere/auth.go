@@ -0,0 +1,264 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/sts"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// AuthMethodBasic authenticates with the username and password keys of the
+	// secret
+	AuthMethodBasic = "basic"
+	// AuthMethodSAML authenticates with a SAML token of the vSphere SSO STS,
+	// which is either stored in the token key of the secret or issued for the
+	// signing certificate in the tls.crt and tls.key keys of the secret
+	AuthMethodSAML = "saml"
+	// AuthMethodCSP authenticates with the vCenter credentials of a VMware
+	// Cloud on AWS SDDC, which are retrieved with the VMware Cloud Services
+	// API token in the apiToken key of the secret
+	AuthMethodCSP = "csp"
+	// AuthMethodCertificate authenticates as the solution user with the
+	// extension key in the extensionKey key of the secret, using the client
+	// certificate in the tls.crt and tls.key keys of the secret
+	AuthMethodCertificate = "certificate"
+
+	// SAMLTokenKey is the key of a SAML token in the secret
+	SAMLTokenKey = "token"
+	// ExtensionKeyKey is the key of the solution user extension key in the
+	// secret
+	ExtensionKeyKey = "extensionKey"
+)
+
+// credentials authenticate sessions of the vCenter SOAP and REST clients
+type credentials struct {
+	// user is set for basic and CSP authentication
+	user *url.Userinfo
+	// signer is set for SAML token authentication
+	signer *sts.Signer
+	// extension is set for certificate authentication
+	extension string
+	// client is used to issue a SAML token for REST sessions with
+	// certificate authentication
+	client *vim25.Client
+}
+
+// readCredentials reads the credentials for the configured auth method from
+// the configured credential provider.
+func readCredentials(ctx context.Context, c *vim25.Client, env EnvConfig) (*credentials, error) {
+	p, err := newCredentialProvider(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch env.AuthMethod {
+	case "", AuthMethodBasic:
+		user, err := readUserInfo(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		return &credentials{user: user}, nil
+
+	case AuthMethodSAML:
+		signer, err := readSAMLSigner(ctx, c, p)
+		if err != nil {
+			return nil, err
+		}
+		return &credentials{signer: signer}, nil
+
+	case AuthMethodCSP:
+		user, err := readCSPUserInfo(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		return &credentials{user: user}, nil
+
+	case AuthMethodCertificate:
+		extension, err := readExtension(ctx, c, p)
+		if err != nil {
+			return nil, err
+		}
+		return &credentials{extension: extension, client: c}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported auth method %q", env.AuthMethod)
+	}
+}
+
+// ValidateSecretKeys returns an error if the given data of a secret lacks the
+// keys read for the given auth method, e.g. to reject a source before its
+// adapter fails to log in.
+func ValidateSecretKeys(authMethod string, data map[string][]byte) error {
+	var missing []string
+	requires := func(keys ...string) {
+		for _, k := range keys {
+			if _, ok := data[k]; !ok {
+				missing = append(missing, k)
+			}
+		}
+	}
+
+	if authMethod == "" {
+		authMethod = AuthMethodBasic
+	}
+	switch authMethod {
+	case AuthMethodBasic:
+		requires(corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+	case AuthMethodSAML:
+		if _, ok := data[SAMLTokenKey]; !ok {
+			if _, ok = data[corev1.TLSCertKey]; !ok {
+				return fmt.Errorf("secret lacks the keys required by the %s auth method: %s, or %s and %s",
+					authMethod, SAMLTokenKey, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+			}
+			requires(corev1.TLSPrivateKeyKey)
+		}
+	case AuthMethodCertificate:
+		requires(corev1.TLSCertKey, corev1.TLSPrivateKeyKey, ExtensionKeyKey)
+	case AuthMethodCSP:
+		requires(CSPAPITokenKey, VMCOrgKey, VMCSDDCKey)
+	default:
+		return fmt.Errorf("unsupported auth method %q", authMethod)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("secret lacks the keys required by the %s auth method: %s", authMethod, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// readSAMLSigner returns a signer for the SAML token in the secret. A bearer
+// token is used as is, a holder-of-key token is signed with the certificate in
+// the secret. Without a token, a holder-of-key token is issued by the vSphere
+// SSO STS for the certificate.
+func readSAMLSigner(ctx context.Context, c *vim25.Client, p CredentialProvider) (*sts.Signer, error) {
+	cert, err := readCertificate(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if cert != nil {
+		c.SetCertificate(*cert)
+	}
+
+	token, err := p.Key(ctx, SAMLTokenKey)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if token = strings.TrimSpace(token); token != "" {
+		return &sts.Signer{Token: token, Certificate: cert}, nil
+	}
+
+	if cert == nil {
+		return nil, errors.New("SAML token or signing certificate required")
+	}
+	return issueToken(ctx, c, cert)
+}
+
+// readExtension returns the solution user extension key in the secret and
+// configures the given client with its certificate.
+func readExtension(ctx context.Context, c *vim25.Client, p CredentialProvider) (string, error) {
+	cert, err := readCertificate(ctx, p)
+	if err != nil {
+		return "", err
+	}
+	if cert == nil {
+		return "", errors.New("client certificate required")
+	}
+
+	extension, err := p.Key(ctx, ExtensionKeyKey)
+	if err != nil {
+		return "", err
+	}
+	if extension = strings.TrimSpace(extension); extension == "" {
+		return "", errors.New("extension key required")
+	}
+
+	c.SetCertificate(*cert)
+	return extension, nil
+}
+
+// issueToken returns a signer with a holder-of-key token issued by the vSphere
+// SSO STS for the given certificate.
+func issueToken(ctx context.Context, c *vim25.Client, cert *tls.Certificate) (*sts.Signer, error) {
+	stsClient, err := sts.NewClient(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("create STS client: %w", err)
+	}
+
+	signer, err := stsClient.Issue(ctx, sts.TokenRequest{
+		Certificate: cert,
+		Delegatable: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("issue SAML token: %w", err)
+	}
+	return signer, nil
+}
+
+// readCertificate reads the certificate and private key from the credential
+// provider. nil is returned if there is no certificate.
+func readCertificate(ctx context.Context, p CredentialProvider) (*tls.Certificate, error) {
+	certPEM, err := p.Key(ctx, corev1.TLSCertKey)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := p.Key(ctx, corev1.TLSPrivateKeyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("parse certificate: %w", err)
+	}
+	return &cert, nil
+}
+
+// loginSOAP creates a new session for the given SOAP client
+func (cr *credentials) loginSOAP(ctx context.Context, c *govmomi.Client) error {
+	if cr.signer != nil {
+		return c.SessionManager.LoginByToken(c.WithHeader(ctx, soap.Header{Security: cr.signer}))
+	}
+	if cr.extension != "" {
+		return c.SessionManager.LoginExtensionByCertificate(ctx, cr.extension)
+	}
+	return c.SessionManager.Login(ctx, cr.user)
+}
+
+// loginREST creates a new session for the given REST client. The REST API
+// does not support certificate authentication, hence a SAML token is issued
+// for the client certificate instead.
+func (cr *credentials) loginREST(ctx context.Context, c *rest.Client) error {
+	signer := cr.signer
+	if cr.extension != "" {
+		var err error
+		if signer, err = issueToken(ctx, cr.client, cr.client.Certificate()); err != nil {
+			return err
+		}
+	}
+
+	if signer != nil {
+		return c.LoginByToken(c.WithSigner(ctx, signer))
+	}
+	return c.Login(ctx, cr.user)
+}
@@ -0,0 +1,348 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi"
+	_ "github.com/vmware/govmomi/lookup/simulator"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	_ "github.com/vmware/govmomi/sts/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// samlToken is a minimal SAML assertion accepted by the simulator
+const samlToken = `<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion" ID="_1" Version="2.0">` +
+	`<saml2:Subject><saml2:NameID>user@vsphere.local</saml2:NameID></saml2:Subject></saml2:Assertion>`
+
+// setSecret points the vSphere secret path to a new directory with the given
+// keys and restores the environment when the test completes
+func setSecret(t *testing.T, keys map[string]string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "vsphere-secret")
+	if err != nil {
+		t.Fatalf("create temp dir: %v", err)
+	}
+	for k, v := range keys {
+		if err := ioutil.WriteFile(filepath.Join(dir, k), []byte(v), 0o600); err != nil {
+			t.Fatalf("write secret key: %v", err)
+		}
+	}
+
+	setEnv(t, "VC_URL", "https://vcenter.example.com")
+	setEnv(t, "VC_SECRET_PATH", dir)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+// setEnv sets the environment variable and restores it when the test completes
+func setEnv(t *testing.T, key, value string) {
+	t.Helper()
+
+	old, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, old)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
+
+// newCertificate returns a PEM-encoded self-signed certificate and key
+func newCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "vsphere-source"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return string(cert), string(keyPEM)
+}
+
+func Test_readCredentials(t *testing.T) {
+	cert, key := newCertificate(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		secret     map[string]string
+		wantUser   bool
+		wantCert   bool
+		wantSigner bool
+		wantErr    bool
+	}{{
+		name:     "basic",
+		method:   AuthMethodBasic,
+		secret:   map[string]string{corev1.BasicAuthUsernameKey: "user", corev1.BasicAuthPasswordKey: "pass"},
+		wantUser: true,
+	}, {
+		name:    "basic without password",
+		method:  AuthMethodBasic,
+		secret:  map[string]string{corev1.BasicAuthUsernameKey: "user"},
+		wantErr: true,
+	}, {
+		name:       "saml bearer token",
+		method:     AuthMethodSAML,
+		secret:     map[string]string{SAMLTokenKey: samlToken + "\n"},
+		wantSigner: true,
+	}, {
+		name:       "saml holder-of-key token",
+		method:     AuthMethodSAML,
+		secret:     map[string]string{SAMLTokenKey: samlToken, corev1.TLSCertKey: cert, corev1.TLSPrivateKeyKey: key},
+		wantSigner: true,
+		wantCert:   true,
+	}, {
+		name:       "saml token issued for certificate",
+		method:     AuthMethodSAML,
+		secret:     map[string]string{corev1.TLSCertKey: cert, corev1.TLSPrivateKeyKey: key},
+		wantSigner: true,
+		wantCert:   true,
+	}, {
+		name:    "saml without token or certificate",
+		method:  AuthMethodSAML,
+		secret:  map[string]string{corev1.BasicAuthUsernameKey: "user", corev1.BasicAuthPasswordKey: "pass"},
+		wantErr: true,
+	}, {
+		name:    "saml with invalid certificate",
+		method:  AuthMethodSAML,
+		secret:  map[string]string{corev1.TLSCertKey: cert, corev1.TLSPrivateKeyKey: cert},
+		wantErr: true,
+	}, {
+		name:    "certificate without certificate",
+		method:  AuthMethodCertificate,
+		secret:  map[string]string{ExtensionKeyKey: "com.vmware.sources"},
+		wantErr: true,
+	}, {
+		name:    "certificate without extension key",
+		method:  AuthMethodCertificate,
+		secret:  map[string]string{corev1.TLSCertKey: cert, corev1.TLSPrivateKeyKey: key},
+		wantErr: true,
+	}, {
+		name:    "unsupported method",
+		method:  "kerberos",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setSecret(t, tt.secret)
+
+			simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+				c := &govmomi.Client{Client: vim, SessionManager: session.NewManager(vim)}
+				if err := c.SessionManager.Logout(ctx); err != nil {
+					t.Fatal(err)
+				}
+
+				creds, err := readCredentials(ctx, vim, EnvConfig{AuthMethod: tt.method, SecretPath: dir})
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("readCredentials() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if err != nil {
+					return
+				}
+
+				if got := creds.user != nil; got != tt.wantUser {
+					t.Errorf("readCredentials() user = %v, want %v", got, tt.wantUser)
+				}
+				if got := creds.signer != nil; got != tt.wantSigner {
+					t.Fatalf("readCredentials() signer = %v, want %v", got, tt.wantSigner)
+				}
+				if tt.wantSigner && (creds.signer.Certificate != nil) != tt.wantCert {
+					t.Errorf("readCredentials() signer certificate = %v, want %v", creds.signer.Certificate != nil, tt.wantCert)
+				}
+
+				if err = creds.loginSOAP(ctx, c); err != nil {
+					t.Fatalf("loginSOAP() error = %v", err)
+				}
+				if _, err = methods.GetCurrentTime(ctx, vim); err != nil {
+					t.Errorf("GetCurrentTime() after login error = %v", err)
+				}
+			})
+		})
+	}
+}
+
+func TestValidateSecretKeys(t *testing.T) {
+	keys := func(names ...string) map[string][]byte {
+		data := make(map[string][]byte, len(names))
+		for _, n := range names {
+			data[n] = []byte("value")
+		}
+		return data
+	}
+
+	tests := []struct {
+		name       string
+		authMethod string
+		data       map[string][]byte
+		wantErr    string
+	}{
+		{name: "basic by default", data: keys("username", "password")},
+		{name: "basic without password", authMethod: AuthMethodBasic, data: keys("username"),
+			wantErr: "secret lacks the keys required by the basic auth method: password"},
+		{name: "saml token", authMethod: AuthMethodSAML, data: keys(SAMLTokenKey)},
+		{name: "saml certificate", authMethod: AuthMethodSAML, data: keys("tls.crt", "tls.key")},
+		{name: "saml certificate without key", authMethod: AuthMethodSAML, data: keys("tls.crt"),
+			wantErr: "secret lacks the keys required by the saml auth method: tls.key"},
+		{name: "saml without credentials", authMethod: AuthMethodSAML, data: keys("username", "password"),
+			wantErr: "secret lacks the keys required by the saml auth method: token, or tls.crt and tls.key"},
+		{name: "certificate", authMethod: AuthMethodCertificate, data: keys("tls.crt", "tls.key", ExtensionKeyKey)},
+		{name: "certificate without extension key", authMethod: AuthMethodCertificate, data: keys("tls.crt", "tls.key"),
+			wantErr: "secret lacks the keys required by the certificate auth method: extensionKey"},
+		{name: "csp", authMethod: AuthMethodCSP, data: keys(CSPAPITokenKey, VMCOrgKey, VMCSDDCKey)},
+		{name: "csp without SDDC", authMethod: AuthMethodCSP, data: keys(CSPAPITokenKey, VMCOrgKey),
+			wantErr: "secret lacks the keys required by the csp auth method: sddcId"},
+		{name: "unsupported auth method", authMethod: "kerberos", data: keys("username", "password"),
+			wantErr: `unsupported auth method "kerberos"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSecretKeys(tt.authMethod, tt.data)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("ValidateSecretKeys() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_loginExtensionByCertificate(t *testing.T) {
+	cert, key := newCertificate(t)
+	dir := setSecret(t, map[string]string{
+		corev1.TLSCertKey:       cert,
+		corev1.TLSPrivateKeyKey: key,
+		ExtensionKeyKey:         "com.vmware.sources\n",
+	})
+
+	model := simulator.VPX()
+	defer model.Remove()
+	if err := model.Create(); err != nil {
+		t.Fatal(err)
+	}
+	model.Service.TLS = new(tls.Config)
+	model.Service.RegisterEndpoints = true
+
+	s := model.Service.NewServer()
+	defer s.Close()
+	// LoginExtensionByCertificate is tunneled through the vCenter http port
+	if err := s.StartTunnel(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	c, creds, err := soapWithKeepalive(ctx, EnvConfig{
+		Address:    s.URL.String(),
+		Insecure:   true,
+		SecretPath: dir,
+		AuthMethod: AuthMethodCertificate,
+	})
+	if err != nil {
+		t.Fatalf("soapWithKeepalive() error = %v", err)
+	}
+
+	us, err := c.SessionManager.UserSession(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if us == nil || us.UserName != "com.vmware.sources" {
+		t.Errorf("UserSession() = %v, want user %q", us, "com.vmware.sources")
+	}
+
+	restClient := rest.NewClient(c.Client)
+	if err = creds.loginREST(ctx, restClient); err != nil {
+		t.Fatalf("loginREST() error = %v", err)
+	}
+	if rs, err := restClient.Session(ctx); err != nil || rs == nil {
+		t.Errorf("Session() = %v, %v, want active session", rs, err)
+	}
+}
+
+func Test_soapWithKeepaliveCACert(t *testing.T) {
+	model := simulator.VPX()
+	defer model.Remove()
+	if err := model.Create(); err != nil {
+		t.Fatal(err)
+	}
+	model.Service.TLS = new(tls.Config)
+
+	s := model.Service.NewServer()
+	defer s.Close()
+
+	caCert, err := s.CertificateFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(t.TempDir(), "ca.crt")
+	if err = ioutil.WriteFile(invalid, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	password, _ := s.URL.User.Password()
+	dir := setSecret(t, map[string]string{
+		corev1.BasicAuthUsernameKey: s.URL.User.Username(),
+		corev1.BasicAuthPasswordKey: password,
+	})
+
+	tests := []struct {
+		name    string
+		caCert  string
+		wantErr bool
+	}{
+		{name: "trusted CA certificate", caCert: caCert},
+		{name: "system roots", wantErr: true},
+		{name: "invalid CA certificate", caCert: invalid, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, err := soapWithKeepalive(context.Background(), EnvConfig{
+				Address:    s.URL.String(),
+				SecretPath: dir,
+				CACert:     tt.caCert,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("soapWithKeepalive() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if c != nil {
+				_ = c.Logout(context.Background())
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// SinkCACertsVolumeName is the name of the volume holding the configmap
+	// with additional CA certificates for the sink
+	SinkCACertsVolumeName = "sink-ca-certs"
+	// SinkCACertsMountPath is where the configmap with additional CA
+	// certificates for the sink is mounted in the adapter
+	SinkCACertsMountPath = "/var/run/vsphere/sink-ca-certs"
+)
+
+// newSinkCertPool returns the system certificate pool with the PEM-encoded
+// certificates of all files in dir added
+func newSinkCertPool(dir string) (*x509.CertPool, error) {
+	pool := systemCertPool()
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read sink CA certificates directory: %w", err)
+	}
+
+	for _, f := range files {
+		// skip the hidden files and directories of the configmap volume
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		pem, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read sink CA certificate %q: %w", f.Name(), err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM-encoded certificate found in %q", f.Name())
+		}
+	}
+
+	return pool, nil
+}
+
+// systemCertPool returns a copy of the system certificate pool, or an empty
+// pool if it is unavailable
+func systemCertPool() *x509.CertPool {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		return x509.NewCertPool()
+	}
+	return pool
+}
+
+// sinkRootCAs returns the system certificate pool with the CA certificates in
+// dir and the PEM-encoded CA certificates in bundle, e.g. of the https address
+// of a Knative sink, added. It returns nil if dir and bundle are empty, i.e.
+// only the system roots are trusted.
+func sinkRootCAs(dir, bundle string) (*x509.CertPool, error) {
+	if dir == "" && bundle == "" {
+		return nil, nil
+	}
+
+	pool := systemCertPool()
+	if dir != "" {
+		var err error
+		if pool, err = newSinkCertPool(dir); err != nil {
+			return nil, err
+		}
+	}
+	if bundle != "" && !pool.AppendCertsFromPEM([]byte(bundle)) {
+		return nil, errors.New("no PEM-encoded certificate found in the sink CA bundle")
+	}
+	return pool, nil
+}
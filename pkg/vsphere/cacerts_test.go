@@ -0,0 +1,41 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_newSinkCertPool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink-ca-certs")
+	if err != nil {
+		t.Fatalf("create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "ca.crt"), []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("write certificate file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+	}{
+		{name: "invalid certificate", dir: dir},
+		{name: "missing directory", dir: filepath.Join(dir, "missing")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newSinkCertPool(tt.dir); err == nil {
+				t.Error("newSinkCertPool() expected error")
+			}
+		})
+	}
+}
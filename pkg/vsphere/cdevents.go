@@ -0,0 +1,194 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// supported output formats
+const (
+	// OutputFormatCloudEvents sends vSphere events as CloudEvents with the
+	// vSphere payload (default)
+	OutputFormatCloudEvents = "cloudevents"
+	// OutputFormatCDEvents sends selected vSphere events as CDEvents, see
+	// https://cdevents.dev
+	OutputFormatCDEvents = "cdevents"
+)
+
+const (
+	// CDEvents specification version of the emitted events
+	cdEventsSpecVersion = "0.3.0"
+	// type prefix of CDEvents
+	cdEventsTypePrefix = "dev.cdevents."
+)
+
+// translator converts an event before it is sent to the sink. The object is
+// the vSphere object the event was created from, e.g. a types.BaseEvent or
+// types.TaskInfo. Events are not sent if translate returns false.
+type translator interface {
+	translate(ev cloudevents.Event, obj interface{}) (cloudevents.Event, bool, error)
+}
+
+// newTranslator returns the translator for the given output format, which is
+// nil for the default CloudEvents format
+func newTranslator(format string) (translator, error) {
+	switch format {
+	case "", OutputFormatCloudEvents:
+		return nil, nil
+	case OutputFormatCDEvents:
+		return cdEventsTranslator{mappings: cdEventMappings}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// cdEventMapping is the CDEvents subject and predicate a vSphere event is
+// mapped to, e.g. environment and created
+type cdEventMapping struct {
+	Subject   string
+	Predicate string
+	Version   string
+}
+
+func (m cdEventMapping) eventType() string {
+	return cdEventsTypePrefix + m.Subject + "." + m.Predicate + "." + m.Version
+}
+
+// cdEventMappings maps vSphere event types to CDEvents. Virtual machines are
+// treated as environments.
+var cdEventMappings = map[string]cdEventMapping{
+	"VmCreatedEvent":      {Subject: "environment", Predicate: "created", Version: "0.1.1"},
+	"VmClonedEvent":       {Subject: "environment", Predicate: "created", Version: "0.1.1"},
+	"VmDeployedEvent":     {Subject: "environment", Predicate: "created", Version: "0.1.1"},
+	"VmRegisteredEvent":   {Subject: "environment", Predicate: "created", Version: "0.1.1"},
+	"VmReconfiguredEvent": {Subject: "environment", Predicate: "modified", Version: "0.1.1"},
+	"VmRenamedEvent":      {Subject: "environment", Predicate: "modified", Version: "0.1.1"},
+	"VmRemovedEvent":      {Subject: "environment", Predicate: "deleted", Version: "0.1.1"},
+}
+
+// task states mapped to CDEvents taskrun events, queued tasks are not mapped
+var cdTaskMappings = map[types.TaskInfoState]cdEventMapping{
+	types.TaskInfoStateRunning: {Subject: "taskrun", Predicate: "started", Version: "0.1.1"},
+	types.TaskInfoStateSuccess: {Subject: "taskrun", Predicate: "finished", Version: "0.1.1"},
+	types.TaskInfoStateError:   {Subject: "taskrun", Predicate: "finished", Version: "0.1.1"},
+}
+
+// cdEvent is the JSON representation of a CDEvent
+type cdEvent struct {
+	Context cdEventContext `json:"context"`
+	Subject cdEventSubject `json:"subject"`
+}
+
+type cdEventContext struct {
+	Version   string    `json:"version"`
+	ID        string    `json:"id"`
+	Source    string    `json:"source"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type cdEventSubject struct {
+	ID      string      `json:"id"`
+	Source  string      `json:"source"`
+	Type    string      `json:"type"`
+	Content interface{} `json:"content"`
+}
+
+// cdEnvironmentContent is the subject content of environment events
+type cdEnvironmentContent struct {
+	Name string `json:"name,omitempty"`
+}
+
+// cdTaskRunContent is the subject content of taskrun events
+type cdTaskRunContent struct {
+	TaskName string `json:"taskName,omitempty"`
+	Outcome  string `json:"outcome,omitempty"`
+	Errors   string `json:"errors,omitempty"`
+}
+
+// cdEventsTranslator converts vSphere events and tasks into CDEvents. Events
+// without a mapping are not sent.
+type cdEventsTranslator struct {
+	mappings map[string]cdEventMapping
+}
+
+func (t cdEventsTranslator) translate(ev cloudevents.Event, obj interface{}) (cloudevents.Event, bool, error) {
+	var (
+		mapping cdEventMapping
+		subject cdEventSubject
+		ok      bool
+	)
+
+	switch o := obj.(type) {
+	case types.BaseEvent:
+		mapping, ok = t.mappings[getEventDetails(o).Type]
+		if !ok || o.GetEvent().Vm == nil {
+			return ev, false, nil
+		}
+		vm := o.GetEvent().Vm
+		subject = cdEventSubject{
+			ID:      vm.Vm.Value,
+			Type:    mapping.Subject,
+			Content: cdEnvironmentContent{Name: vm.Name},
+		}
+
+	case types.TaskInfo:
+		mapping, ok = cdTaskMappings[o.State]
+		if !ok {
+			return ev, false, nil
+		}
+		content := cdTaskRunContent{TaskName: o.DescriptionId}
+		switch o.State {
+		case types.TaskInfoStateSuccess:
+			content.Outcome = "success"
+		case types.TaskInfoStateError:
+			content.Outcome = "failure"
+			if o.Error != nil {
+				content.Errors = o.Error.LocalizedMessage
+			}
+		}
+		subject = cdEventSubject{
+			ID:      o.Key,
+			Type:    mapping.Subject,
+			Content: content,
+		}
+
+	default:
+		return ev, false, nil
+	}
+
+	subject.Source = ev.Source()
+
+	cd := cloudevents.NewEvent(cloudevents.VersionV1)
+	cd.SetID(ev.ID())
+	cd.SetSource(ev.Source())
+	cd.SetType(mapping.eventType())
+	cd.SetSubject(subject.ID)
+	cd.SetTime(ev.Time())
+	for k, v := range ev.Extensions() {
+		cd.SetExtension(k, v)
+	}
+
+	data := cdEvent{
+		Context: cdEventContext{
+			Version:   cdEventsSpecVersion,
+			ID:        ev.ID(),
+			Source:    ev.Source(),
+			Type:      mapping.eventType(),
+			Timestamp: ev.Time(),
+		},
+		Subject: subject,
+	}
+	if err := cd.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		return cd, false, fmt.Errorf("set data on event: %w", err)
+	}
+	return cd, true, nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newTranslator(t *testing.T) {
+	for _, format := range []string{"", OutputFormatCloudEvents} {
+		if tr, err := newTranslator(format); err != nil || tr != nil {
+			t.Errorf("newTranslator(%q) = %v, %v, want nil", format, tr, err)
+		}
+	}
+
+	if tr, err := newTranslator(OutputFormatCDEvents); err != nil || tr == nil {
+		t.Errorf("newTranslator(%q) = %v, %v", OutputFormatCDEvents, tr, err)
+	}
+
+	if _, err := newTranslator("xml"); err == nil {
+		t.Error("newTranslator() with unsupported format did not fail")
+	}
+}
+
+func Test_cdEventsTranslator_translate(t *testing.T) {
+	created := time.Date(2020, 10, 14, 12, 0, 0, 0, time.UTC)
+	vm := &types.VmEventArgument{
+		EntityEventArgument: types.EntityEventArgument{Name: "web-01"},
+		Vm:                  types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-42"},
+	}
+
+	tests := []struct {
+		name        string
+		obj         interface{}
+		wantOK      bool
+		wantType    string
+		wantSubject string
+		wantContent map[string]interface{}
+	}{
+		{
+			name:        "vm created",
+			obj:         &types.VmCreatedEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 1, CreatedTime: created, Vm: vm}}},
+			wantOK:      true,
+			wantType:    "dev.cdevents.environment.created.0.1.1",
+			wantSubject: "vm-42",
+			wantContent: map[string]interface{}{"name": "web-01"},
+		},
+		{
+			name:        "vm removed",
+			obj:         &types.VmRemovedEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 2, CreatedTime: created, Vm: vm}}},
+			wantOK:      true,
+			wantType:    "dev.cdevents.environment.deleted.0.1.1",
+			wantSubject: "vm-42",
+			wantContent: map[string]interface{}{"name": "web-01"},
+		},
+		{
+			name:   "unmapped event",
+			obj:    &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{Key: 3, CreatedTime: created, Vm: vm}}},
+			wantOK: false,
+		},
+		{
+			name: "task failed",
+			obj: types.TaskInfo{Key: "task-1", DescriptionId: "VirtualMachine.powerOn", State: types.TaskInfoStateError,
+				QueueTime: created, Error: &types.LocalizedMethodFault{LocalizedMessage: "insufficient resources"}},
+			wantOK:      true,
+			wantType:    "dev.cdevents.taskrun.finished.0.1.1",
+			wantSubject: "task-1",
+			wantContent: map[string]interface{}{
+				"taskName": "VirtualMachine.powerOn",
+				"outcome":  "failure",
+				"errors":   "insufficient resources",
+			},
+		},
+		{
+			name:   "task queued",
+			obj:    types.TaskInfo{Key: "task-2", State: types.TaskInfoStateQueued, QueueTime: created},
+			wantOK: false,
+		},
+		{
+			name:   "content library change",
+			obj:    libraryChange{Kind: "item", Op: libraryOpCreated, ID: "item-1"},
+			wantOK: false,
+		},
+	}
+
+	tr := cdEventsTranslator{mappings: cdEventMappings}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				in  = newTestCloudEvent(t, tt.obj)
+				got map[string]interface{}
+			)
+
+			ev, ok, err := tr.translate(in, tt.obj)
+			if err != nil {
+				t.Fatalf("translate() error = %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("translate() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+
+			if ev.Type() != tt.wantType {
+				t.Errorf("Type() = %s, want %s", ev.Type(), tt.wantType)
+			}
+			if ev.Subject() != tt.wantSubject {
+				t.Errorf("Subject() = %s, want %s", ev.Subject(), tt.wantSubject)
+			}
+			if ev.ID() != in.ID() || ev.Source() != in.Source() {
+				t.Errorf("translate() = %s/%s, want %s/%s", ev.Source(), ev.ID(), in.Source(), in.ID())
+			}
+			if err := ev.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+
+			if err := json.Unmarshal(ev.Data(), &got); err != nil {
+				t.Fatal(err)
+			}
+			cdContext := got["context"].(map[string]interface{})
+			if cdContext["type"] != tt.wantType || cdContext["version"] != cdEventsSpecVersion {
+				t.Errorf("context = %v, want type %s and version %s", cdContext, tt.wantType, cdEventsSpecVersion)
+			}
+			subject := got["subject"].(map[string]interface{})
+			if diff := cmp.Diff(tt.wantContent, subject["content"]); diff != "" {
+				t.Errorf("subject content (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
+
+// newTestCloudEvent returns the CloudEvent the adapter creates for the given
+// vSphere object
+func newTestCloudEvent(t *testing.T, obj interface{}) cloudevents.Event {
+	t.Helper()
+
+	var (
+		ev  cloudevents.Event
+		err error
+	)
+	switch o := obj.(type) {
+	case types.BaseEvent:
+		ev, err = newEventCloudEvent(source, o, eventOptions{})
+	case types.TaskInfo:
+		ev, err = newTaskCloudEvent(source, o)
+	case libraryChange:
+		ev, err = newLibraryCloudEvent(source, o)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ev
+}
@@ -8,7 +8,10 @@ package vsphere
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 const (
@@ -16,14 +19,66 @@ const (
 	CheckpointDefaultAge = 5 * time.Minute
 	// create checkpoint every frequency but only on changes
 	CheckpointDefaultPeriod = 10 * time.Second
-	// key name used in KV store for storing the latest checkpoint
-	checkpointKey = "checkpoint"
+	// CheckpointKey is the key of the latest checkpoint in the KV store, the
+	// configmap of the source
+	CheckpointKey = "checkpoint"
 )
 
 var (
 	ErrInvalidInterval = errors.New("invalid checkpoint time interval")
 )
 
+// CheckpointExport is the portable file format of the checkpoint of a source,
+// which is imported into a source of the same vCenter in another cluster to
+// continue the event stream where the exported source left off.
+type CheckpointExport struct {
+	// Source is the namespace/name of the exported source
+	Source string `json:"source"`
+	// ExportedAt is the time (UTC) the checkpoint was exported at
+	ExportedAt time.Time `json:"exportedAt"`
+	// Checkpoint is the checkpoint of the exported source as stored in its
+	// configmap
+	Checkpoint json.RawMessage `json:"checkpoint"`
+}
+
+// NewCheckpointImport returns the value of the CheckpointImportAnnotation
+// importing the checkpoint of the given export at the given time. The
+// imported checkpoint counts as created at this time, so that it takes
+// precedence over the checkpoints the adapter created before, but not over
+// those it creates after the import.
+func NewCheckpointImport(export CheckpointExport, at time.Time) (string, error) {
+	cp, err := parseCheckpoint(export.Checkpoint)
+	if err != nil {
+		return "", err
+	}
+	cp.CreatedTimestamp = at.UTC()
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// ValidateCheckpointImport returns an error if the given value of the
+// CheckpointImportAnnotation is not a valid checkpoint
+func ValidateCheckpointImport(s string) error {
+	_, err := parseCheckpoint([]byte(s))
+	return err
+}
+
+// parseCheckpoint returns the given JSON-encoded checkpoint, which must have
+// a last event timestamp to begin the event stream at
+func parseCheckpoint(b []byte) (checkpoint, error) {
+	var cp checkpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return checkpoint{}, fmt.Errorf("unmarshal checkpoint: %w", err)
+	}
+	if cp.LastEventKeyTimestamp.IsZero() {
+		return checkpoint{}, errors.New("checkpoint has no last event timestamp")
+	}
+	return cp, nil
+}
+
 // checkpoint represents a vCenter checkpoint object
 type checkpoint struct {
 	VCenter string `json:"vCenter"`
@@ -36,6 +91,46 @@ type checkpoint struct {
 	LastEventKeyTimestamp time.Time `json:"lastEventKeyTimestamp"`
 	// timestamp (UTC) when this checkpoint was created
 	CreatedTimestamp time.Time `json:"createdTimestamp"`
+	// instance UUID of the vCenter, event keys are only comparable within the
+	// same vCenter
+	VCenterID string `json:"vCenterID,omitempty"`
+	// begin (UTC) of the event stream which created this checkpoint, the
+	// replay window of the stream ends at LastEventKeyTimestamp
+	WindowBegin time.Time `json:"windowBegin,omitempty"`
+}
+
+// resumedAt returns true if an event stream of the given vCenter beginning at
+// the given time resumes at this checkpoint, so that the events processed
+// before it can be skipped instead of being sent again. Checkpoints without a
+// vCenter instance UUID, discarded by the given config or clamped by its
+// maxAge are never resumed.
+func (cp checkpoint) resumedAt(begin time.Time, vcenterID string, config CheckpointConfig) bool {
+	if vcenterID == "" || cp.VCenterID != vcenterID || cp.LastEventKeyTimestamp.IsZero() {
+		return false
+	}
+	if config.ResetAt != nil && cp.CreatedTimestamp.Before(*config.ResetAt) {
+		return false
+	}
+	return begin.Equal(cp.LastEventKeyTimestamp)
+}
+
+// processed returns true if the given event was processed before this
+// checkpoint was created. Event keys increase within a vCenter, and the
+// replay window of the checkpoint bounds the events compared by key.
+func (cp checkpoint) processed(e *types.Event) bool {
+	return e.Key <= cp.LastEventKey && !e.CreatedTime.After(cp.LastEventKeyTimestamp)
+}
+
+// skipProcessed returns the given events without the leading events processed
+// before the given checkpoint, and whether any remaining events may still
+// have been processed
+func skipProcessed(events []types.BaseEvent, cp checkpoint) ([]types.BaseEvent, bool) {
+	for i, e := range events {
+		if !cp.processed(e.GetEvent()) {
+			return events[i:], false
+		}
+	}
+	return nil, true
 }
 
 // CheckpointConfig influences the checkpoint behavior. It configures the
@@ -46,14 +141,24 @@ type CheckpointConfig struct {
 	MaxAge time.Duration `json:"maxAge"`
 	// create checkpoints at given frequency
 	Period time.Duration `json:"period"`
+	// start the event stream at this time (UTC) if no checkpoint exists
+	ReplayFrom *time.Time `json:"replayFrom,omitempty"`
+	// discard checkpoints created before this time (UTC)
+	ResetAt *time.Time `json:"resetAt,omitempty"`
+	// JSON-encoded checkpoint imported from another cluster, used instead of
+	// the checkpoints created before it
+	Import string `json:"import,omitempty"`
 }
 
 // MarshalJSON defines custom marshalling logic to support human-readable time
 // input on the checkpoint configuration, e.g. "10m" or "1h".
 func (c *CheckpointConfig) MarshalJSON() ([]byte, error) {
 	var out struct {
-		MaxAge string `json:"maxAge"`
-		Period string `json:"period"`
+		MaxAge     string `json:"maxAge"`
+		Period     string `json:"period"`
+		ReplayFrom string `json:"replayFrom,omitempty"`
+		ResetAt    string `json:"resetAt,omitempty"`
+		Import     string `json:"import,omitempty"`
 	}
 
 	if c.MaxAge < time.Duration(0) {
@@ -66,16 +171,27 @@ func (c *CheckpointConfig) MarshalJSON() ([]byte, error) {
 
 	out.MaxAge = c.MaxAge.String()
 	out.Period = c.Period.String()
+	if c.ReplayFrom != nil {
+		out.ReplayFrom = c.ReplayFrom.UTC().Format(time.RFC3339)
+	}
+	if c.ResetAt != nil {
+		out.ResetAt = c.ResetAt.UTC().Format(time.RFC3339)
+	}
+	out.Import = c.Import
 	return json.Marshal(out)
 }
 
 // UnmarshalJSON defines custom marshalling logic to support human-readable time
 // input on the checkpoint configuration, e.g. "10m" or "1h". Using numbers
-// without time suffix as input will fail encoding/decoding.
+// without time suffix as input will fail encoding/decoding. The optional replay
+// start and reset times must be RFC3339-encoded.
 func (c *CheckpointConfig) UnmarshalJSON(b []byte) error {
 	var in struct {
-		MaxAge string `json:"maxAge"`
-		Period string `json:"period"`
+		MaxAge     string `json:"maxAge"`
+		Period     string `json:"period"`
+		ReplayFrom string `json:"replayFrom"`
+		ResetAt    string `json:"resetAt"`
+		Import     string `json:"import"`
 	}
 
 	var (
@@ -114,6 +230,31 @@ func (c *CheckpointConfig) UnmarshalJSON(b []byte) error {
 	}
 	c.Period = v
 
+	if in.ReplayFrom != "" {
+		t, err := time.Parse(time.RFC3339, in.ReplayFrom)
+		if err != nil {
+			return err
+		}
+		t = t.UTC()
+		c.ReplayFrom = &t
+	}
+
+	if in.ResetAt != "" {
+		t, err := time.Parse(time.RFC3339, in.ResetAt)
+		if err != nil {
+			return err
+		}
+		t = t.UTC()
+		c.ResetAt = &t
+	}
+
+	if in.Import != "" {
+		if err := ValidateCheckpointImport(in.Import); err != nil {
+			return err
+		}
+		c.Import = in.Import
+	}
+
 	return nil
 }
 
@@ -9,6 +9,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func Test_checkpointConfig_UnmarshalJSON(t *testing.T) {
@@ -66,6 +68,63 @@ func Test_checkpointConfig_UnmarshalJSON(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid config with replay start time",
+			args: args{b: []byte(`{"maxAge":"1h","period":"10s","replayFrom":"2021-02-15T21:20:35+02:00"}`)},
+			want: &CheckpointConfig{
+				MaxAge:     time.Hour,
+				Period:     10 * time.Second,
+				ReplayFrom: timePtr(time.Date(2021, 2, 15, 19, 20, 35, 0, time.UTC)),
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid config with reset time",
+			args: args{b: []byte(`{"maxAge":"1h","period":"10s","resetAt":"2021-03-01T08:00:00Z"}`)},
+			want: &CheckpointConfig{
+				MaxAge:  time.Hour,
+				Period:  10 * time.Second,
+				ResetAt: timePtr(time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC)),
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid reset time",
+			args: args{b: []byte(`{"maxAge":"1h","period":"10s","resetAt":"now"}`)},
+			want: &CheckpointConfig{
+				MaxAge: time.Hour,
+				Period: 10 * time.Second,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid config with imported checkpoint",
+			args: args{b: []byte(`{"maxAge":"1h","period":"10s","import":"{\"lastEventKey\":42,\"lastEventKeyTimestamp\":\"2021-03-01T08:00:00Z\"}"}`)},
+			want: &CheckpointConfig{
+				MaxAge: time.Hour,
+				Period: 10 * time.Second,
+				Import: `{"lastEventKey":42,"lastEventKeyTimestamp":"2021-03-01T08:00:00Z"}`,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid imported checkpoint",
+			args: args{b: []byte(`{"maxAge":"1h","period":"10s","import":"{}"}`)},
+			want: &CheckpointConfig{
+				MaxAge: time.Hour,
+				Period: 10 * time.Second,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid replay start time",
+			args: args{b: []byte(`{"maxAge":"1h","period":"10s","replayFrom":"yesterday"}`)},
+			want: &CheckpointConfig{
+				MaxAge: time.Hour,
+				Period: 10 * time.Second,
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -82,8 +141,10 @@ func Test_checkpointConfig_UnmarshalJSON(t *testing.T) {
 
 func Test_checkpointConfig_MarshalJSON(t *testing.T) {
 	type fields struct {
-		MaxAge time.Duration
-		Period time.Duration
+		MaxAge     time.Duration
+		Period     time.Duration
+		ReplayFrom *time.Time
+		ResetAt    *time.Time
 	}
 	tests := []struct {
 		name    string
@@ -106,6 +167,26 @@ func Test_checkpointConfig_MarshalJSON(t *testing.T) {
 			want:    []byte(`{"maxAge":"5m0s","period":"10s"}`),
 			wantErr: false,
 		},
+		{
+			name: "config with replay start time",
+			fields: fields{
+				MaxAge:     CheckpointDefaultAge,
+				Period:     CheckpointDefaultPeriod,
+				ReplayFrom: timePtr(time.Date(2021, 2, 15, 21, 20, 35, 0, time.FixedZone("CEST", 2*60*60))),
+			},
+			want:    []byte(`{"maxAge":"5m0s","period":"10s","replayFrom":"2021-02-15T19:20:35Z"}`),
+			wantErr: false,
+		},
+		{
+			name: "config with reset time",
+			fields: fields{
+				MaxAge:  CheckpointDefaultAge,
+				Period:  CheckpointDefaultPeriod,
+				ResetAt: timePtr(time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC)),
+			},
+			want:    []byte(`{"maxAge":"5m0s","period":"10s","resetAt":"2021-03-01T08:00:00Z"}`),
+			wantErr: false,
+		},
 		{
 			name: "invalid values",
 			fields: fields{
@@ -119,8 +200,10 @@ func Test_checkpointConfig_MarshalJSON(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := &CheckpointConfig{
-				MaxAge: tt.fields.MaxAge,
-				Period: tt.fields.Period,
+				MaxAge:     tt.fields.MaxAge,
+				Period:     tt.fields.Period,
+				ReplayFrom: tt.fields.ReplayFrom,
+				ResetAt:    tt.fields.ResetAt,
 			}
 			got, err := c.MarshalJSON()
 			if (err != nil) != tt.wantErr {
@@ -197,3 +280,104 @@ func Test_newCheckpointConfig(t *testing.T) {
 		})
 	}
 }
+
+func Test_checkpoint_resumedAt(t *testing.T) {
+	last := time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)
+	cp := checkpoint{
+		LastEventKey:          42,
+		LastEventKeyTimestamp: last,
+		CreatedTimestamp:      last.Add(time.Second),
+		VCenterID:             "vcenter-uuid",
+		WindowBegin:           last.Add(-time.Hour),
+	}
+	tests := []struct {
+		name      string
+		begin     time.Time
+		vcenterID string
+		config    CheckpointConfig
+		want      bool
+	}{
+		{name: "begins at checkpoint", begin: last, vcenterID: "vcenter-uuid", want: true},
+		{name: "begin clamped by maxAge", begin: last.Add(time.Minute), vcenterID: "vcenter-uuid"},
+		{name: "other vCenter", begin: last, vcenterID: "other-uuid"},
+		{name: "unknown vCenter", begin: last},
+		{
+			name:      "checkpoint reset",
+			begin:     last,
+			vcenterID: "vcenter-uuid",
+			config:    CheckpointConfig{ResetAt: timePtr(last.Add(time.Minute))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cp.resumedAt(tt.begin, tt.vcenterID, tt.config); got != tt.want {
+				t.Errorf("resumedAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCheckpointImport(t *testing.T) {
+	at := time.Date(2021, 4, 2, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		checkpoint string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "valid checkpoint",
+			checkpoint: `{"vCenter":"vcenter.example.com","lastEventKey":42,"lastEventType":"VmPoweredOnEvent","lastEventKeyTimestamp":"2021-04-01T12:00:00Z","createdTimestamp":"2021-04-01T12:00:01Z","vCenterID":"vcenter-uuid"}`,
+			want:       `{"vCenter":"vcenter.example.com","lastEventKey":42,"lastEventType":"VmPoweredOnEvent","lastEventKeyTimestamp":"2021-04-01T12:00:00Z","createdTimestamp":"2021-04-02T08:00:00Z","vCenterID":"vcenter-uuid","windowBegin":"0001-01-01T00:00:00Z"}`,
+		},
+		{name: "no last event timestamp", checkpoint: `{"lastEventKey":42}`, wantErr: true},
+		{name: "invalid JSON", checkpoint: `{`, wantErr: true},
+		{name: "no checkpoint", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCheckpointImport(CheckpointExport{Source: "ns/source", Checkpoint: []byte(tt.checkpoint)}, at)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewCheckpointImport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NewCheckpointImport() = %s, want %s", got, tt.want)
+			}
+			if err == nil {
+				if err := ValidateCheckpointImport(got); err != nil {
+					t.Errorf("ValidateCheckpointImport() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func Test_skipProcessed(t *testing.T) {
+	last := time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)
+	cp := checkpoint{LastEventKey: 42, LastEventKeyTimestamp: last}
+	newEvent := func(key int32, created time.Time) types.BaseEvent {
+		return &types.Event{Key: key, CreatedTime: created}
+	}
+
+	events := []types.BaseEvent{newEvent(41, last), newEvent(42, last), newEvent(43, last), newEvent(44, last.Add(time.Second))}
+	got, skipping := skipProcessed(events, cp)
+	if !reflect.DeepEqual(got, events[2:]) || skipping {
+		t.Errorf("skipProcessed() = %v, %v, want events from key 43 and false", got, skipping)
+	}
+
+	got, skipping = skipProcessed(events[:2], cp)
+	if len(got) != 0 || !skipping {
+		t.Errorf("skipProcessed() = %v, %v, want no events and true", got, skipping)
+	}
+
+	// keys of a vCenter with a new database are not comparable beyond the
+	// replay window
+	events = []types.BaseEvent{newEvent(1, last.Add(time.Minute))}
+	if got, _ = skipProcessed(events, cp); !reflect.DeepEqual(got, events) {
+		t.Errorf("skipProcessed() = %v, want %v", got, events)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -8,10 +8,9 @@ package vsphere
 import (
 	"context"
 	"errors"
-	"io/ioutil"
+	"fmt"
 	"net/http"
 	"net/url"
-	"path/filepath"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
@@ -31,12 +30,50 @@ const (
 	VolumeName        = "vsphere-binding"
 	DefaultMountPath  = "/var/bindings/vsphere" // filepath.Join isn't const.
 	keepaliveInterval = 5 * time.Minute         // vCenter APIs keep-alive
+
+	// CACertsVolumeName is the name of the volume holding the configmap with
+	// the CA certificates of the vSphere API
+	CACertsVolumeName = "vsphere-binding-ca-certs"
+	// CACertsMountPath is where the configmap with the CA certificates of the
+	// vSphere API is mounted in bound containers
+	CACertsMountPath = "/var/bindings/vsphere-ca-certs"
+	// CACertsKey is the key of the PEM-encoded CA certificates in the configmap
+	CACertsKey = "ca.crt"
 )
 
 type EnvConfig struct {
-	Insecure   bool   `envconfig:"VC_INSECURE" default:"false"`
-	Address    string `envconfig:"VC_URL" required:"true"`
-	SecretPath string `envconfig:"VC_SECRET_PATH" default:""`
+	Insecure   bool   `envconfig:"VC_INSECURE" default:"false" json:"insecure,omitempty"`
+	Address    string `envconfig:"VC_URL" required:"true" json:"address"`
+	SecretPath string `envconfig:"VC_SECRET_PATH" default:"" json:"secretPath,omitempty"`
+	AuthMethod string `envconfig:"VC_AUTH_METHOD" default:"basic" json:"authMethod,omitempty"`
+
+	// FallbackAddress is connected to if Address is unreachable, e.g. the
+	// passive node of a vCenter HA cluster. It uses the same credentials.
+	FallbackAddress string `envconfig:"VC_FALLBACK_URL" default:"" json:"fallbackAddress,omitempty"`
+
+	// Locale is the locale of the sessions, in which vCenter formats the
+	// messages of events and tasks, see setLocale
+	Locale string `envconfig:"VC_LOCALE" default:"" json:"locale,omitempty"`
+
+	// CACert is the file of the PEM-encoded CA certificates to verify the
+	// vSphere API with instead of the system roots
+	CACert string `envconfig:"VC_CA_CERT" default:"" json:"caCert,omitempty"`
+
+	// TLSMinVersion and TLSCipherSuites restrict the TLS connections to the
+	// vSphere API, see TLSConfig
+	TLSMinVersion   string `envconfig:"VC_TLS_MIN_VERSION" default:"" json:"tlsMinVersion,omitempty"`
+	TLSCipherSuites string `envconfig:"VC_TLS_CIPHER_SUITES" default:"" json:"tlsCipherSuites,omitempty"`
+
+	// API configures the timeout and the retries of the vCenter API calls
+	API APIConfig `json:"api"`
+
+	CredentialProvider string      `envconfig:"VC_CREDENTIAL_PROVIDER" default:"secret" json:"credentialProvider,omitempty"`
+	Vault              VaultConfig `json:"vault"`
+
+	// SecretName and SecretNamespace identify the secret read through the
+	// Kubernetes API by the kubernetes credential provider
+	SecretName      string `envconfig:"VC_SECRET_NAME" default:"" json:"secretName,omitempty"`
+	SecretNamespace string `envconfig:"VC_SECRET_NAMESPACE" default:"" json:"secretNamespace,omitempty"`
 }
 
 // ReadKey reads the key from the secret.
@@ -46,16 +83,41 @@ func ReadKey(key string) (string, error) {
 		return "", err
 	}
 
-	mountPath := DefaultMountPath
+	return secretProvider(env.secretMountPath()).Key(context.Background(), key)
+}
+
+// SecretMountPath returns the directory of the mounted secret with the vCenter
+// credentials, or an empty string if the credentials are read from an
+// external credential provider.
+func SecretMountPath() (string, error) {
+	var env EnvConfig
+	if err := envconfig.Process("", &env); err != nil {
+		return "", err
+	}
+	if p := env.CredentialProvider; p != "" && p != CredentialProviderSecret {
+		return "", nil
+	}
+	return env.secretMountPath(), nil
+}
+
+func (env EnvConfig) secretMountPath() string {
 	if env.SecretPath != "" {
-		mountPath = env.SecretPath
+		return env.SecretPath
 	}
+	return DefaultMountPath
+}
 
-	data, err := ioutil.ReadFile(filepath.Join(mountPath, key))
+// readUserInfo reads the username and password from the credential provider.
+func readUserInfo(ctx context.Context, p CredentialProvider) (*url.Userinfo, error) {
+	username, err := p.Key(ctx, corev1.BasicAuthUsernameKey)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return string(data), nil
+	password, err := p.Key(ctx, corev1.BasicAuthPasswordKey)
+	if err != nil {
+		return nil, err
+	}
+	return url.UserPassword(username, password), nil
 }
 
 // NewSOAPClient returns a vCenter SOAP API client with active keep-alive. Use
@@ -66,46 +128,56 @@ func NewSOAPClient(ctx context.Context) (*govmomi.Client, error) {
 		return nil, err
 	}
 
+	c, _, err := soapWithKeepalive(ctx, env)
+	return c, err
+}
+
+// soapWithKeepalive returns a SOAP client with active keep-alive, logged in
+// with the credentials of the configured auth method.
+func soapWithKeepalive(ctx context.Context, env EnvConfig) (*govmomi.Client, *credentials, error) {
 	parsedURL, err := soap.ParseURL(env.Address)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Read the username and password from the filesystem.
-	username, err := ReadKey(corev1.BasicAuthUsernameKey)
-	if err != nil {
-		return nil, err
+	soapClient := soap.NewClient(parsedURL, env.Insecure)
+	if env.CACert != "" {
+		// the REST and STS clients share the TLS configuration of the SOAP client
+		if err = soapClient.SetRootCAs(env.CACert); err != nil {
+			return nil, nil, fmt.Errorf("read vSphere CA certificates: %w", err)
+		}
 	}
-	password, err := ReadKey(corev1.BasicAuthPasswordKey)
-	if err != nil {
-		return nil, err
+	tlsConfig := TLSConfig{MinVersion: env.TLSMinVersion, CipherSuites: env.TLSCipherSuites}
+	if err = tlsConfig.apply(soapClient.DefaultTransport().TLSClientConfig); err != nil {
+		return nil, nil, fmt.Errorf("configure vSphere TLS: %w", err)
 	}
-	parsedURL.User = url.UserPassword(username, password)
-
-	return soapWithKeepalive(ctx, parsedURL, env.Insecure)
-}
-
-func soapWithKeepalive(ctx context.Context, url *url.URL, insecure bool) (*govmomi.Client, error) {
-	soapClient := soap.NewClient(url, insecure)
 	vimClient, err := vim25.NewClient(ctx, soapClient)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	vimClient.RoundTripper = &metricsRoundTripper{RoundTripper: vimClient.RoundTripper}
+	vimClient.RoundTripper = env.API.roundTripper(vimClient.RoundTripper)
 	vimClient.RoundTripper = keepalive.NewHandlerSOAP(vimClient.RoundTripper, keepaliveInterval, soapKeepAliveHandler(ctx, vimClient))
 
-	// explicitly create session to activate keep-alive handler via Login
-	m := session.NewManager(vimClient)
-	err = m.Login(ctx, url.User)
+	creds, err := readCredentials(ctx, vimClient, env)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	c := govmomi.Client{
 		Client:         vimClient,
-		SessionManager: m,
+		SessionManager: session.NewManager(vimClient),
 	}
 
-	return &c, nil
+	// explicitly create session to activate keep-alive handler via Login
+	if err = creds.loginSOAP(ctx, &c); err != nil {
+		return nil, nil, err
+	}
+	if err = setLocale(ctx, &c, env.Locale); err != nil {
+		return nil, nil, err
+	}
+
+	return &c, creds, nil
 }
 
 func soapKeepAliveHandler(ctx context.Context, c *vim25.Client) func() error {
@@ -131,23 +203,13 @@ func NewRESTClient(ctx context.Context) (*rest.Client, error) {
 		return nil, err
 	}
 
-	parsedURL, err := soap.ParseURL(env.Address)
-	if err != nil {
-		return nil, err
-	}
-
-	// Read the username and password from the filesystem.
-	username, err := ReadKey(corev1.BasicAuthUsernameKey)
-	if err != nil {
-		return nil, err
-	}
-	password, err := ReadKey(corev1.BasicAuthPasswordKey)
-	if err != nil {
-		return nil, err
-	}
-	parsedURL.User = url.UserPassword(username, password)
+	return restWithKeepalive(ctx, env)
+}
 
-	soapclient, err := soapWithKeepalive(ctx, parsedURL, env.Insecure)
+// restWithKeepalive returns a REST client with active keep-alive, logged in
+// with the credentials of the configured auth method.
+func restWithKeepalive(ctx context.Context, env EnvConfig) (*rest.Client, error) {
+	soapclient, creds, err := soapWithKeepalive(ctx, env)
 	if err != nil {
 		return nil, err
 	}
@@ -156,7 +218,7 @@ func NewRESTClient(ctx context.Context) (*rest.Client, error) {
 	restclient.Transport = keepalive.NewHandlerREST(restclient, keepaliveInterval, restKeepAliveHandler(ctx, restclient))
 
 	// Login activates the keep-alive handler
-	if err := restclient.Login(ctx, parsedURL.User); err != nil {
+	if err := creds.loginREST(ctx, restclient); err != nil {
 		return nil, err
 	}
 	return restclient, nil
@@ -179,3 +241,35 @@ func restKeepAliveHandler(ctx context.Context, restclient *rest.Client) func() e
 		return errors.New(http.StatusText(http.StatusUnauthorized))
 	}
 }
+
+// Login creates new sessions for the given vCenter clients, e.g. after the
+// previous session expired. The credentials are read again from the
+// credential provider to pick up rotated secrets. The REST client is optional.
+func Login(ctx context.Context, soapClient *govmomi.Client, restClient *rest.Client) error {
+	var env EnvConfig
+	if err := envconfig.Process("", &env); err != nil {
+		return err
+	}
+
+	return login(ctx, env, soapClient, restClient)
+}
+
+// login creates new sessions for the given vCenter clients with the
+// credentials of the auth method configured in env.
+func login(ctx context.Context, env EnvConfig, soapClient *govmomi.Client, restClient *rest.Client) error {
+	creds, err := readCredentials(ctx, soapClient.Client, env)
+	if err != nil {
+		return err
+	}
+
+	if err = creds.loginSOAP(ctx, soapClient); err != nil {
+		return err
+	}
+	if err = setLocale(ctx, soapClient, env.Locale); err != nil {
+		return err
+	}
+	if restClient != nil {
+		return creds.loginREST(ctx, restClient)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+// HTTP body compressions for sending events to the sink
+const (
+	// CompressionNone sends uncompressed bodies (default)
+	CompressionNone = "none"
+	// CompressionGzip always sends gzip-compressed bodies
+	CompressionGzip = "gzip"
+	// CompressionAuto sends gzip-compressed bodies until the sink rejects one
+	// with 415 Unsupported Media Type, then uncompressed bodies
+	CompressionAuto = "auto"
+)
+
+// validateCompression returns an error if the given compression is not
+// supported. An empty compression sends uncompressed bodies.
+func validateCompression(compression string) error {
+	switch compression {
+	case "", CompressionNone, CompressionGzip, CompressionAuto:
+		return nil
+	default:
+		return fmt.Errorf("unsupported compression %q", compression)
+	}
+}
+
+type gzipKey struct{}
+
+// withGzip returns a context compressing the bodies of the requests sent with
+// a gzipTransport
+func withGzip(ctx context.Context) context.Context {
+	return context.WithValue(ctx, gzipKey{}, true)
+}
+
+// gzipTransport compresses the bodies of requests with a context created by
+// withGzip and sets their Content-Encoding header
+type gzipTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody || req.Header.Get("Content-Encoding") != "" ||
+		req.Context().Value(gzipKey{}) == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err = zw.Write(body); err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("compress request body: %w", err)
+	}
+
+	// a RoundTripper must not modify the request
+	compressed := buf.Bytes()
+	req = req.Clone(req.Context())
+	req.Header.Set("Content-Encoding", "gzip")
+	req.ContentLength = int64(len(compressed))
+	req.Body = ioutil.NopCloser(bytes.NewReader(compressed))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	return t.base.RoundTrip(req)
+}
+
+// sinkCompression decides whether to compress the events sent to the sink
+type sinkCompression struct {
+	mode string
+	// rejected is set once the sink rejected a compressed event in auto mode
+	rejected int32
+}
+
+// newSinkCompression returns the compression of the given mode, which is nil
+// if bodies are sent uncompressed
+func newSinkCompression(mode string) (*sinkCompression, error) {
+	if err := validateCompression(mode); err != nil {
+		return nil, err
+	}
+	if mode == "" || mode == CompressionNone {
+		return nil, nil
+	}
+	return &sinkCompression{mode: mode}, nil
+}
+
+// enabled returns true if the next event is sent compressed. A nil
+// sinkCompression never compresses.
+func (c *sinkCompression) enabled() bool {
+	return c != nil && atomic.LoadInt32(&c.rejected) == 0
+}
+
+// retryUncompressed returns true if the given result of sending a compressed
+// event is a rejection of the compression in auto mode, which disables
+// compression for the following events.
+func (c *sinkCompression) retryUncompressed(result protocol.Result) bool {
+	if c == nil || c.mode != CompressionAuto {
+		return false
+	}
+	var res *cehttp.Result
+	if !errors.As(result, &res) || res.StatusCode != http.StatusUnsupportedMediaType {
+		return false
+	}
+	atomic.StoreInt32(&c.rejected, 1)
+	return true
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+// encodingRoundTripper records the Content-Encoding and decoded body of all
+// received requests, and rejects compressed requests if configured
+type encodingRoundTripper struct {
+	rejectGzip bool
+	encodings  []string
+	bodies     []string
+}
+
+func (e *encodingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	encoding := req.Header.Get("Content-Encoding")
+	e.encodings = append(e.encodings, encoding)
+
+	body := req.Body
+	if encoding == "gzip" {
+		zr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = zr
+	}
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	e.bodies = append(e.bodies, string(b))
+
+	if encoding == "gzip" && e.rejectGzip {
+		return &http.Response{StatusCode: http.StatusUnsupportedMediaType, Body: http.NoBody}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+func Test_vAdapter_send_compression(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		rejectGzip bool
+		want       []string
+		wantErr    bool
+	}{
+		{name: "uncompressed", want: []string{"", ""}},
+		{name: "none", mode: CompressionNone, want: []string{"", ""}},
+		{name: "gzip", mode: CompressionGzip, want: []string{"gzip", "gzip"}},
+		{name: "gzip rejected", mode: CompressionGzip, rejectGzip: true, want: []string{"gzip"}, wantErr: true},
+		{name: "auto", mode: CompressionAuto, want: []string{"gzip", "gzip"}},
+		// the first event is sent again uncompressed
+		{name: "auto rejected", mode: CompressionAuto, rejectGzip: true, want: []string{"gzip", "", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &encodingRoundTripper{rejectGzip: tt.rejectGzip}
+			p, err := cehttp.New(cehttp.WithRoundTripper(&gzipTransport{base: rt}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := client.New(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			compression, err := newSinkCompression(tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a := &vAdapter{CEClient: c, SinkCompression: compression}
+
+			ev := cloudevents.NewEvent()
+			ev.SetID("1")
+			ev.SetSource(source)
+			ev.SetType("com.vmware.vsphere.VmPoweredOnEvent")
+			if err = ev.SetData(cloudevents.ApplicationJSON, map[string]string{"key": "value"}); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := cecontext.WithTarget(context.Background(), "fake.example.com")
+			for i := 0; i < 2; i++ {
+				result := a.send(ctx, ev, extensionContext{})
+				if !cloudevents.IsACK(result) {
+					if !tt.wantErr {
+						t.Fatalf("send() = %v", result)
+					}
+					break
+				}
+			}
+
+			if len(rt.encodings) != len(tt.want) {
+				t.Fatalf("Content-Encoding = %q, want %q", rt.encodings, tt.want)
+			}
+			for i := range tt.want {
+				if rt.encodings[i] != tt.want[i] {
+					t.Errorf("Content-Encoding = %q, want %q", rt.encodings, tt.want)
+				}
+				if rt.bodies[i] != `{"key":"value"}` {
+					t.Errorf("body = %q, want the event data", rt.bodies[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_validateCompression(t *testing.T) {
+	for _, compression := range []string{"", CompressionNone, CompressionGzip, CompressionAuto} {
+		if err := validateCompression(compression); err != nil {
+			t.Errorf("validateCompression(%q) error = %v", compression, err)
+		}
+	}
+	if err := validateCompression("brotli"); err == nil {
+		t.Error("validateCompression() with unsupported compression did not fail")
+	}
+}
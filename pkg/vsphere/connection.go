@@ -0,0 +1,128 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/kvstore"
+	"knative.dev/pkg/logging"
+)
+
+// ConnectionStatusKey is the key of the vCenter connection status in the
+// kvstore of the adapter
+const ConnectionStatusKey = "connection"
+
+// reasons of a failed vCenter connection
+const (
+	// ConnectionReasonAuthenticationFailed is the reason of rejected credentials
+	ConnectionReasonAuthenticationFailed = "AuthenticationFailed"
+	// ConnectionReasonCertificateInvalid is the reason of an untrusted or
+	// invalid vCenter certificate
+	ConnectionReasonCertificateInvalid = "CertificateInvalid"
+	// ConnectionReasonUnreachable is the reason of a vCenter address which
+	// cannot be resolved or connected to
+	ConnectionReasonUnreachable = "Unreachable"
+	// ConnectionReasonFailed is the reason of other connection failures, e.g.
+	// missing credentials
+	ConnectionReasonFailed = "ConnectionFailed"
+)
+
+// ConnectionStatus records the result of the last attempt of the adapter to
+// connect and log in to vCenter.
+type ConnectionStatus struct {
+	// Connected is true if the adapter logged in to vCenter
+	Connected bool `json:"connected"`
+	// Reason is the reason of a failed connection, e.g. AuthenticationFailed
+	Reason string `json:"reason,omitempty"`
+	// Message is the error of a failed connection
+	Message string `json:"message,omitempty"`
+	// LastAttempt is the time of the connection attempt
+	LastAttempt time.Time `json:"lastAttempt"`
+	// FallbackAddress is the fallback address the adapter connected to
+	// because the address of the source was unreachable, empty if connected
+	// to the address of the source
+	FallbackAddress string `json:"fallbackAddress,omitempty"`
+}
+
+// newConnectionStatus returns the connection status after a connection attempt
+// which failed with the given error, or succeeded if err is nil.
+func newConnectionStatus(err error) ConnectionStatus {
+	status := ConnectionStatus{
+		Connected:   err == nil,
+		LastAttempt: time.Now().UTC(),
+	}
+	if err != nil {
+		status.Reason = connectionFailureReason(err)
+		status.Message = err.Error()
+	}
+	return status
+}
+
+// connectionFailureReason classifies the given error of a failed connection
+func connectionFailureReason(err error) string {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalid          x509.CertificateInvalidError
+		opErr            *net.OpError
+		dnsErr           *net.DNSError
+		netErr           net.Error
+	)
+	switch {
+	case isInvalidLogin(err):
+		return ConnectionReasonAuthenticationFailed
+	case errors.As(err, &unknownAuthority), errors.As(err, &hostname), errors.As(err, &invalid):
+		return ConnectionReasonCertificateInvalid
+	case errors.As(err, &opErr), errors.As(err, &dnsErr), errors.As(err, &netErr) && netErr.Timeout():
+		return ConnectionReasonUnreachable
+	default:
+		return ConnectionReasonFailed
+	}
+}
+
+// isInvalidLogin returns true if err, or any error it wraps, is caused by
+// credentials rejected by vCenter
+func isInvalidLogin(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if soap.IsSoapFault(err) {
+			switch soap.ToSoapFault(err).VimFault().(type) {
+			case types.InvalidLogin, types.NoPermission:
+				return true
+			}
+		}
+		if soap.IsVimFault(err) {
+			switch soap.ToVimFault(err).(type) {
+			case *types.InvalidLogin, *types.NoPermission:
+				return true
+			}
+		}
+	}
+	return isNotAuthenticated(err)
+}
+
+// recordConnectionStatus saves the result of a connection attempt in the
+// kvstore, so that the controller can reflect it in the status of the source.
+func recordConnectionStatus(ctx context.Context, store kvstore.Interface, err error) {
+	saveConnectionStatus(ctx, store, newConnectionStatus(err))
+}
+
+func saveConnectionStatus(ctx context.Context, store kvstore.Interface, status ConnectionStatus) {
+	logger := logging.FromContext(ctx)
+
+	if serr := store.Set(ctx, ConnectionStatusKey, status); serr != nil {
+		logger.Warnw("failed to set connection status", zap.Error(serr))
+	} else if serr = store.Save(ctx); serr != nil {
+		logger.Warnw("failed to save connection status", zap.Error(serr))
+	}
+}
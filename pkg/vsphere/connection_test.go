@@ -0,0 +1,84 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_connectionFailureReason(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		invalidLogin := session.NewManager(vim).Login(ctx, url.UserPassword("user", "wrong"))
+		if invalidLogin == nil {
+			t.Fatal("login with invalid credentials did not fail")
+		}
+
+		tests := []struct {
+			name string
+			err  error
+			want string
+		}{
+			{name: "invalid login", err: invalidLogin, want: ConnectionReasonAuthenticationFailed},
+			{name: "wrapped invalid login", err: fmt.Errorf("login: %w", invalidLogin), want: ConnectionReasonAuthenticationFailed},
+			{name: "vim fault", err: soap.WrapVimFault(&types.InvalidLogin{}), want: ConnectionReasonAuthenticationFailed},
+			{
+				name: "untrusted certificate",
+				err:  &url.Error{Op: "Post", URL: "https://vcenter/sdk", Err: x509.UnknownAuthorityError{}},
+				want: ConnectionReasonCertificateInvalid,
+			},
+			{
+				name: "unknown host",
+				err:  &url.Error{Op: "Post", URL: "https://vcenter/sdk", Err: &net.DNSError{Err: "no such host", Name: "vcenter"}},
+				want: ConnectionReasonUnreachable,
+			},
+			{name: "missing credentials", err: errors.New("open /var/bindings/vsphere/username: no such file or directory"), want: ConnectionReasonFailed},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := connectionFailureReason(tt.err); got != tt.want {
+					t.Errorf("connectionFailureReason(%v) = %v, want %v", tt.err, got, tt.want)
+				}
+			})
+		}
+	})
+}
+
+func Test_recordConnectionStatus(t *testing.T) {
+	ctx := context.Background()
+	kv := &fakeKVStore{dataChan: make(chan string, 2)}
+
+	recordConnectionStatus(ctx, kv, soap.WrapVimFault(&types.InvalidLogin{}))
+
+	var status ConnectionStatus
+	if err := kv.Get(ctx, ConnectionStatusKey, &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Connected || status.Reason != ConnectionReasonAuthenticationFailed || status.Message == "" || !kv.saved {
+		t.Errorf("connection status = %+v, saved %v", status, kv.saved)
+	}
+
+	recordConnectionStatus(ctx, kv, nil)
+
+	status = ConnectionStatus{}
+	if err := kv.Get(ctx, ConnectionStatusKey, &status); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Connected || status.Reason != "" || status.LastAttempt.IsZero() {
+		t.Errorf("connection status = %+v", status)
+	}
+}
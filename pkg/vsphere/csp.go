@@ -0,0 +1,151 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+const (
+	// CSPAPITokenKey is the key of the VMware Cloud Services API token in the
+	// secret
+	CSPAPITokenKey = "apiToken"
+	// VMCOrgKey is the key of the VMware Cloud on AWS organization ID in the
+	// secret
+	VMCOrgKey = "orgId"
+	// VMCSDDCKey is the key of the VMware Cloud on AWS SDDC ID in the secret
+	VMCSDDCKey = "sddcId"
+
+	// path of the CSP API to exchange an API token for an access token
+	cspAuthorizePath = "/csp/gateway/am/api/auth/api-tokens/authorize"
+	// header of VMC API requests with the CSP access token
+	cspAuthHeader = "csp-auth-token"
+	// timeout of CSP and VMC API requests
+	cspTimeout = 30 * time.Second
+)
+
+// cspClient sends CSP and VMC API requests. The http.DefaultClient is not used
+// since the CloudEvents client may replace its transport. The sink CA
+// certificates, TLS and compression only apply to the sink transport, see
+// newSinkTransport.
+var cspClient = &http.Client{Timeout: cspTimeout, Transport: http.DefaultTransport.(*http.Transport).Clone()}
+
+// cspConfig holds the endpoints of VMware Cloud Services and VMware Cloud on
+// AWS, which only need to be changed for testing
+type cspConfig struct {
+	CSPURL string `envconfig:"VC_CSP_URL" default:"https://console.cloud.vmware.com"`
+	VMCURL string `envconfig:"VC_VMC_URL" default:"https://vmc.vmware.com"`
+}
+
+// sddc is the subset of a VMC SDDC with the vCenter cloud admin credentials
+type sddc struct {
+	ResourceConfig struct {
+		CloudUsername string `json:"cloud_username"`
+		CloudPassword string `json:"cloud_password"`
+	} `json:"resource_config"`
+}
+
+// readCSPUserInfo exchanges the CSP API token of the credential provider for
+// an access token and returns the vCenter credentials of the SDDC of the
+// credential provider.
+func readCSPUserInfo(ctx context.Context, p CredentialProvider) (*url.Userinfo, error) {
+	var env cspConfig
+	if err := envconfig.Process("", &env); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]string, 3)
+	for _, k := range []string{CSPAPITokenKey, VMCOrgKey, VMCSDDCKey} {
+		v, err := p.Key(ctx, k)
+		if err != nil {
+			return nil, err
+		}
+		if keys[k] = strings.TrimSpace(v); keys[k] == "" {
+			return nil, fmt.Errorf("credential key %q is empty", k)
+		}
+	}
+
+	token, err := cspAccessToken(ctx, env.CSPURL, keys[CSPAPITokenKey])
+	if err != nil {
+		return nil, fmt.Errorf("exchange CSP API token: %w", err)
+	}
+
+	s, err := getSDDC(ctx, env.VMCURL, token, keys[VMCOrgKey], keys[VMCSDDCKey])
+	if err != nil {
+		return nil, fmt.Errorf("get SDDC credentials: %w", err)
+	}
+
+	rc := s.ResourceConfig
+	if rc.CloudUsername == "" || rc.CloudPassword == "" {
+		return nil, errors.New("SDDC has no vCenter credentials")
+	}
+	return url.UserPassword(rc.CloudUsername, rc.CloudPassword), nil
+}
+
+// cspAccessToken exchanges the given API token for a CSP access token
+func cspAccessToken(ctx context.Context, cspURL, apiToken string) (string, error) {
+	form := url.Values{"refresh_token": {apiToken}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimSuffix(cspURL, "/")+cspAuthorizePath, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err = doJSON(cspClient, req, &res); err != nil {
+		return "", err
+	}
+	if res.AccessToken == "" {
+		return "", errors.New("no access token in response")
+	}
+	return res.AccessToken, nil
+}
+
+// getSDDC returns the given SDDC of the VMC API
+func getSDDC(ctx context.Context, vmcURL, token, org, id string) (*sddc, error) {
+	u := fmt.Sprintf("%s/vmc/api/orgs/%s/sddcs/%s",
+		strings.TrimSuffix(vmcURL, "/"), url.PathEscape(org), url.PathEscape(id))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(cspAuthHeader, token)
+	req.Header.Set("Accept", "application/json")
+
+	var s sddc
+	if err = doJSON(cspClient, req, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// doJSON sends the given request with the client and decodes the JSON response
+// body into v
+func doJSON(c *http.Client, req *http.Request, v interface{}) error {
+	res, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
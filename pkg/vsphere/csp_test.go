@@ -0,0 +1,103 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_readCSPUserInfo(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(cspAuthorizePath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.FormValue("refresh_token") != "api-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "access-token"})
+	})
+	mux.HandleFunc("/vmc/api/orgs/org/sddcs/sddc", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(cspAuthHeader) != "access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var s sddc
+		s.ResourceConfig.CloudUsername = "cloudadmin@vmc.local"
+		s.ResourceConfig.CloudPassword = "secret"
+		_ = json.NewEncoder(w).Encode(s)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		secret  map[string]string
+		wantErr bool
+	}{{
+		name:   "valid",
+		secret: map[string]string{CSPAPITokenKey: "api-token\n", VMCOrgKey: "org", VMCSDDCKey: "sddc"},
+	}, {
+		name:    "invalid api token",
+		secret:  map[string]string{CSPAPITokenKey: "expired", VMCOrgKey: "org", VMCSDDCKey: "sddc"},
+		wantErr: true,
+	}, {
+		name:    "unknown sddc",
+		secret:  map[string]string{CSPAPITokenKey: "api-token", VMCOrgKey: "org", VMCSDDCKey: "other"},
+		wantErr: true,
+	}, {
+		name:    "missing sddc",
+		secret:  map[string]string{CSPAPITokenKey: "api-token", VMCOrgKey: "org"},
+		wantErr: true,
+	}, {
+		name:    "empty api token",
+		secret:  map[string]string{CSPAPITokenKey: "\n", VMCOrgKey: "org", VMCSDDCKey: "sddc"},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setSecret(t, tt.secret)
+			setEnv(t, "VC_CSP_URL", ts.URL)
+			setEnv(t, "VC_VMC_URL", ts.URL+"/")
+
+			user, err := readCSPUserInfo(context.Background(), secretProvider(dir))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readCSPUserInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			password, _ := user.Password()
+			if user.Username() != "cloudadmin@vmc.local" || password != "secret" {
+				t.Errorf("readCSPUserInfo() = %v, want cloudadmin@vmc.local credentials", user)
+			}
+		})
+	}
+}
+
+func Test_cspClientIgnoresSinkCACerts(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if _, err := (&envConfig{SinkCACerts: string(cert)}).newSinkTransport(); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the sink trusts the CA certificates of the sink
+	res, err := cspClient.Get(srv.URL)
+	if err == nil {
+		res.Body.Close()
+		t.Error("cspClient trusts the sink CA certificates")
+	}
+}
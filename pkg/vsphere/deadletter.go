@@ -0,0 +1,190 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+const (
+	// DeadLettersKey is the key of the most recent dead letters in the kvstore
+	// of the adapter, if the source has no dead letter sink
+	DeadLettersKey = "deadLetters"
+
+	// DeadLetterReasonExtension is the extension attribute of the events sent
+	// to the dead letter sink holding the error of the last attempt
+	DeadLetterReasonExtension = "deadletterreason"
+
+	// maxDeadLetters is the number of dead letters kept in the kvstore
+	maxDeadLetters = 10
+)
+
+// DeadLetter is an event the adapter gave up on after the maximum number of
+// attempts to send it.
+type DeadLetter struct {
+	// ID is the id of the event
+	ID string `json:"id"`
+	// Type is the type of the event
+	Type string `json:"type"`
+	// Attempts is the number of failed attempts to send the event
+	Attempts int `json:"attempts"`
+	// Error is the error of the last attempt
+	Error string `json:"error"`
+	// FailedTime is the time of the last attempt
+	FailedTime time.Time `json:"failedTime"`
+	// Event is the JSON-encoded event
+	Event json.RawMessage `json:"event"`
+}
+
+// backoff between the attempts to send an event
+var deadLetterBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Cap:      30 * time.Second,
+}
+
+var deadLetterCountM = stats.Int64(
+	"sink_dead_letter_count",
+	"Number of events given up on after the maximum number of attempts to send them",
+	stats.UnitDimensionless,
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: deadLetterCountM.Description(),
+		Measure:     deadLetterCountM,
+		Aggregation: view.Count(),
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// deadLetters retries failed events and gives up on them after maxAttempts,
+// sending them to the dead letter sink, or else recording them in the
+// kvstore, so that one poison event does not stall the event stream.
+type deadLetters struct {
+	maxAttempts int
+	sink        string
+	backoff     wait.Backoff
+}
+
+// newDeadLetters returns the dead letter handling for the given number of
+// attempts and dead letter sink, which is nil if maxAttempts is 0
+func newDeadLetters(maxAttempts int, sink string) (*deadLetters, error) {
+	if maxAttempts < 0 {
+		return nil, fmt.Errorf("max attempts must not be negative, was %d", maxAttempts)
+	}
+	if maxAttempts == 0 {
+		if sink != "" {
+			return nil, fmt.Errorf("dead letter sink %s requires max attempts", sink)
+		}
+		return nil, nil
+	}
+	return &deadLetters{maxAttempts: maxAttempts, sink: sink, backoff: deadLetterBackoff}, nil
+}
+
+// sendOrDeadLetter sends the given event, returned by prepare, like
+// sendPrepared. If dead letters are enabled, failed attempts are retried with
+// backoff, and after the maximum number of attempts the event is recorded as a
+// dead letter as it was sent and counts as sent, returning deadLettered true.
+// The event is not given up on if ctx is done.
+func (a *vAdapter) sendOrDeadLetter(ctx context.Context, ev cloudevents.Event, ec extensionContext) (deadLettered bool, result protocol.Result) {
+	if a.DeadLetters == nil {
+		return false, a.sendPrepared(ctx, ev, ec)
+	}
+	logger := logging.FromContext(ctx)
+
+	backoff := a.DeadLetters.backoff
+	for attempt := 1; ; attempt++ {
+		if result = a.sendPrepared(ctx, ev, ec); cloudevents.IsACK(result) {
+			return false, result
+		}
+		if attempt == a.DeadLetters.maxAttempts {
+			break
+		}
+		logger.Warnw("failed to send cloudevent, retrying", zap.String("id", ev.ID()), zap.Int("attempt", attempt), zap.Error(result))
+
+		timer := time.NewTimer(backoff.Step())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false, result
+		case <-timer.C:
+		}
+	}
+	if ctx.Err() != nil {
+		return false, result
+	}
+
+	logger.Errorw("giving up on cloudevent, recording dead letter", zap.String("id", ev.ID()),
+		zap.Int("attempts", a.DeadLetters.maxAttempts), zap.Error(result))
+	start := time.Now()
+	if err := a.recordDeadLetter(ctx, ev, result); err != nil {
+		return false, fmt.Errorf("record dead letter: %w", err)
+	}
+	rec := newAuditRecord(ev, ec, a.DeadLetters.sink, nil, time.Since(start))
+	rec.Result, rec.Error = AuditResultDeadLettered, result.Error()
+	a.Audit.record(ctx, rec)
+	metrics.Record(ctx, deadLetterCountM.M(1))
+	return true, nil
+}
+
+// recordDeadLetter sends the given event which failed with the given result
+// to the dead letter sink, or else records it in the kvstore, keeping the most
+// recent dead letters. The kvstore is saved with the next checkpoint. The
+// dead letter sink gets the configured sink headers and content mode like the
+// sink.
+func (a *vAdapter) recordDeadLetter(ctx context.Context, ev cloudevents.Event, result protocol.Result) error {
+	if sink := a.DeadLetters.sink; sink != "" {
+		ev = ev.Clone()
+		ev.SetExtension(DeadLetterReasonExtension, result.Error())
+		// the token of the sink is only valid for its audience
+		sinkCtx := cecontext.WithTarget(ctx, sink)
+		if headers := a.SinkHeaders.Clone(); len(headers) > 0 {
+			sinkCtx = cehttp.WithCustomHeader(sinkCtx, headers)
+		}
+		if result := a.sendTo(withContentMode(sinkCtx, a.SinkContentMode), ev); !cloudevents.IsACK(result) {
+			return fmt.Errorf("send to dead letter sink %s: %w", sink, result)
+		}
+		return nil
+	}
+
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	var letters []DeadLetter
+	if err := a.KVStore.Get(ctx, DeadLettersKey, &letters); err != nil {
+		logging.FromContext(ctx).Debugw("no previous dead letters", zap.Error(err))
+	}
+	letters = append(letters, DeadLetter{
+		ID:         ev.ID(),
+		Type:       ev.Type(),
+		Attempts:   a.DeadLetters.maxAttempts,
+		Error:      result.Error(),
+		FailedTime: time.Now().UTC(),
+		Event:      b,
+	})
+	if len(letters) > maxDeadLetters {
+		letters = letters[len(letters)-maxDeadLetters:]
+	}
+	return a.KVStore.Set(ctx, DeadLettersKey, letters)
+}
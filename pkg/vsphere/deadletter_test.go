@@ -0,0 +1,235 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap/zaptest"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func Test_newDeadLetters(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		sink        string
+		wantNil     bool
+		wantErr     bool
+	}{
+		{name: "disabled", wantNil: true},
+		{name: "kvstore", maxAttempts: 3},
+		{name: "sink", maxAttempts: 3, sink: "http://dead-letters.example.com"},
+		{name: "negative attempts", maxAttempts: -1, wantErr: true},
+		{name: "sink without attempts", sink: "http://dead-letters.example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newDeadLetters(tt.maxAttempts, tt.sink)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newDeadLetters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newDeadLetters() = %v, want nil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendEventsDeadLetters(t *testing.T) {
+	events := createTestEvents(2, source, time.Now().UTC())
+
+	tests := []struct {
+		name       string
+		sink       string
+		failHost   string
+		wantCount  int
+		wantErr    bool
+		wantHosts  []string
+		wantStored []string
+	}{{
+		name:      "dead letter sink",
+		sink:      "http://dead-letters.example.com",
+		failHost:  "fake.example.com",
+		wantCount: 2,
+		wantHosts: []string{
+			"fake.example.com", "fake.example.com", "fake.example.com", "dead-letters.example.com",
+			"fake.example.com", "fake.example.com", "fake.example.com", "dead-letters.example.com",
+		},
+	}, {
+		name:       "kvstore",
+		failHost:   "fake.example.com",
+		wantCount:  2,
+		wantHosts:  []string{"fake.example.com", "fake.example.com", "fake.example.com", "fake.example.com", "fake.example.com", "fake.example.com"},
+		wantStored: []string{"1000", "1001"},
+	}, {
+		name:      "dead letter sink fails",
+		sink:      "http://dead-letters.example.com",
+		failHost:  "dead-letters.example.com",
+		wantCount: 2,
+		wantHosts: []string{"fake.example.com", "fake.example.com"},
+	}, {
+		name:      "dead letter sink fails after attempts",
+		sink:      "http://fake.example.com",
+		failHost:  "fake.example.com",
+		wantErr:   true,
+		wantHosts: []string{"fake.example.com", "fake.example.com", "fake.example.com", "fake.example.com"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &hostRecorder{failHost: tt.failHost}
+			p, err := cehttp.New(cehttp.WithRoundTripper(rt))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := client.New(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dead, err := newDeadLetters(3, tt.sink)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dead.backoff = wait.Backoff{}
+			store := &fakeKVStore{}
+			a := &vAdapter{Logger: zaptest.NewLogger(t).Sugar(), CEClient: c, Source: source, KVStore: store, DeadLetters: dead,
+				SinkHeaders: http.Header{"X-Api-Key": []string{"secret"}}}
+
+			ctx := cecontext.WithTarget(context.Background(), "http://fake.example.com")
+			n, err := a.sendEvents(ctx, events.vEvents)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sendEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n != tt.wantCount {
+				t.Errorf("sendEvents() = %d, want %d", n, tt.wantCount)
+			}
+			if !cmp.Equal(rt.hosts, tt.wantHosts) {
+				t.Errorf("hosts = %v, want %v", rt.hosts, tt.wantHosts)
+			}
+			for i, h := range rt.headers {
+				if got := h.Get("X-Api-Key"); got != "secret" {
+					t.Errorf("request %d to %s has X-Api-Key %q, want the sink headers", i, rt.hosts[i], got)
+				}
+			}
+
+			var letters []DeadLetter
+			_ = store.Get(ctx, DeadLettersKey, &letters)
+			var stored []string
+			for _, l := range letters {
+				if l.Attempts != 3 || !strings.Contains(l.Error, "500") || len(l.Event) == 0 {
+					t.Errorf("dead letter = %+v, want 3 attempts failed with 500", l)
+				}
+				stored = append(stored, l.ID)
+			}
+			if !cmp.Equal(stored, tt.wantStored) {
+				t.Errorf("stored dead letters = %v, want %v", stored, tt.wantStored)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_recordDeadLetterKeepsRecent(t *testing.T) {
+	store := &fakeKVStore{}
+	a := &vAdapter{KVStore: store, DeadLetters: &deadLetters{maxAttempts: 1}}
+	events := createTestEvents(maxDeadLetters+2, source, time.Now().UTC())
+
+	ctx := context.Background()
+	for _, ev := range events.ceEvents {
+		if err := a.recordDeadLetter(ctx, *ev, context.DeadlineExceeded); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var letters []DeadLetter
+	if err := store.Get(ctx, DeadLettersKey, &letters); err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != maxDeadLetters || letters[0].ID != "1002" {
+		t.Errorf("dead letters = %d starting at %s, want %d starting at 1002", len(letters), letters[0].ID, maxDeadLetters)
+	}
+}
+
+func Test_vAdapter_deliverRedactedDeadLetter(t *testing.T) {
+	ev := cloudevents.NewEvent(cloudevents.VersionV1)
+	ev.SetID("42")
+	ev.SetSource(source)
+	ev.SetType("com.vmware.vsphere.UserLoginSessionEvent.v0")
+	if err := ev.SetData(cloudevents.ApplicationJSON, map[string]string{"userName": "admin"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rt := &roundTripperTest{statusCodes: []int{http.StatusInternalServerError, http.StatusInternalServerError}}
+	p, err := cehttp.New(cehttp.WithRoundTripper(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead, err := newDeadLetters(2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead.backoff = wait.Backoff{}
+	redactor, err := newRedactor(`{"fields":["userName"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extensions, err := newExtensionSet([]string{ExtensionVCenterID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &fakeKVStore{}
+	a := &vAdapter{Logger: zaptest.NewLogger(t).Sugar(), CEClient: c, Source: source, KVStore: store,
+		DeadLetters: dead, Redactor: redactor, Extensions: extensions, VCenterID: "vc-1"}
+
+	ctx := cecontext.WithTarget(context.Background(), "http://fake.example.com")
+	if result := a.deliver(ctx, ev, extensionContext{}); !cloudevents.IsACK(result) {
+		t.Fatalf("deliver() = %v", result)
+	}
+
+	// every attempt and the dead letter carry the redacted payload
+	if len(rt.events) != 2 {
+		t.Fatalf("sent %d events, want 2", len(rt.events))
+	}
+	for i, sent := range rt.events {
+		if want := `{"userName":"REDACTED"}`; string(sent.Data()) != want {
+			t.Errorf("attempt %d sent %s, want %s", i+1, sent.Data(), want)
+		}
+		if got := sent.Extensions()[ExtensionVCenterID]; got != "vc-1" {
+			t.Errorf("attempt %d sent %s extension %v, want vc-1", i+1, ExtensionVCenterID, got)
+		}
+	}
+
+	var letters []DeadLetter
+	if err := store.Get(ctx, DeadLettersKey, &letters); err != nil || len(letters) != 1 {
+		t.Fatalf("dead letters = %v, %v, want 1", letters, err)
+	}
+	var got cloudevents.Event
+	if err := json.Unmarshal(letters[0].Event, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"userName":"REDACTED"}`; string(got.Data()) != want {
+		t.Errorf("dead letter payload = %s, want %s", got.Data(), want)
+	}
+	if got := got.Extensions()[ExtensionVCenterID]; got != "vc-1" {
+		t.Errorf("dead letter %s extension = %v, want vc-1 as sent", ExtensionVCenterID, got)
+	}
+	if string(ev.Data()) != `{"userName":"admin"}` || len(ev.Extensions()) != 0 {
+		t.Errorf("deliver() changed the event to %s", ev)
+	}
+}
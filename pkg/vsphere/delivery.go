@@ -0,0 +1,74 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+)
+
+// HTTP content modes for sending events to the sink
+const (
+	// ContentModeBinary sends the event data as HTTP body and the attributes
+	// as ce- headers (default)
+	ContentModeBinary = "binary"
+	// ContentModeStructured sends the whole event as JSON-encoded HTTP body
+	ContentModeStructured = "structured"
+)
+
+// validateContentMode returns an error if the given content mode is not
+// supported. An empty mode uses the default binary mode.
+func validateContentMode(mode string) error {
+	switch mode {
+	case "", ContentModeBinary, ContentModeStructured:
+		return nil
+	default:
+		return fmt.Errorf("unsupported content mode %q", mode)
+	}
+}
+
+// withContentMode returns a context forcing the given content mode when
+// sending events
+func withContentMode(ctx context.Context, mode string) context.Context {
+	switch mode {
+	case ContentModeStructured:
+		return cloudevents.WithEncodingStructured(ctx)
+	case ContentModeBinary:
+		return cloudevents.WithEncodingBinary(ctx)
+	default:
+		return ctx
+	}
+}
+
+// newLogOnlyClient returns a CloudEvents client logging the events with the
+// given logger instead of sending them to the sink, after applying the
+// extensions of the CloudEvents overrides of env like the client of the
+// adapter.
+func (env *envConfig) newLogOnlyClient(logger *zap.SugaredLogger) (cloudevents.Client, error) {
+	overrides, err := env.GetCloudEventOverrides()
+	if err != nil {
+		return nil, fmt.Errorf("read CloudEvents overrides: %w", err)
+	}
+	var extensions map[string]string
+	if overrides != nil {
+		extensions = overrides.Extensions
+	}
+	return &tailClient{
+		send: func(ev cloudevents.Event) error {
+			b, err := json.Marshal(ev)
+			if err != nil {
+				return fmt.Errorf("marshal event: %w", err)
+			}
+			logger.Infow("Not sending event in log-only mode", zap.Reflect("event", json.RawMessage(b)))
+			return nil
+		},
+		extensions: extensions,
+	}, nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// contentTypeRoundTripper records the Content-Type of all received requests
+type contentTypeRoundTripper []string
+
+func (c *contentTypeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	*c = append(*c, req.Header.Get("Content-Type"))
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+func Test_vAdapter_send_contentMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{mode: "", want: cloudevents.ApplicationJSON},
+		{mode: ContentModeBinary, want: cloudevents.ApplicationJSON},
+		{mode: ContentModeStructured, want: cloudevents.ApplicationCloudEventsJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var rt contentTypeRoundTripper
+			p, err := cehttp.New(cehttp.WithRoundTripper(&rt))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := client.New(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a := &vAdapter{CEClient: c, SinkContentMode: tt.mode}
+
+			ev := cloudevents.NewEvent()
+			ev.SetID("1")
+			ev.SetSource(source)
+			ev.SetType("com.vmware.vsphere.VmPoweredOnEvent")
+			if err = ev.SetData(cloudevents.ApplicationJSON, map[string]string{"key": "value"}); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := cecontext.WithTarget(context.Background(), "fake.example.com")
+			if result := a.send(ctx, ev, extensionContext{}); !cloudevents.IsACK(result) {
+				t.Fatalf("send() = %v", result)
+			}
+
+			if len(rt) != 1 || rt[0] != tt.want {
+				t.Errorf("Content-Type = %v, want %s", rt, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateContentMode(t *testing.T) {
+	for _, mode := range []string{"", ContentModeBinary, ContentModeStructured} {
+		if err := validateContentMode(mode); err != nil {
+			t.Errorf("validateContentMode(%q) error = %v", mode, err)
+		}
+	}
+	if err := validateContentMode("json"); err == nil {
+		t.Error("validateContentMode() with unsupported mode did not fail")
+	}
+}
+
+func Test_envConfig_newLogOnlyClient(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	env := &envConfig{}
+	env.CEOverrides = `{"extensions":{"team":"a"}}`
+	c, err := env.newLogOnlyClient(zap.New(core).Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev := cloudevents.NewEvent()
+	ev.SetID("1")
+	ev.SetSource(source)
+	ev.SetType("com.vmware.vsphere.VmPoweredOnEvent")
+	if result := c.Send(context.Background(), ev); !cloudevents.IsACK(result) {
+		t.Fatalf("Send() = %v", result)
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	b, err := json.Marshal(entries[0].ContextMap()["event"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	logged := cloudevents.NewEvent()
+	if err := json.Unmarshal(b, &logged); err != nil {
+		t.Fatalf("logged event %s: %v", b, err)
+	}
+	if logged.ID() != "1" || logged.Extensions()["team"] != "a" {
+		t.Errorf("logged event %s, want ID 1 with the team extension", b)
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/event"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// DeliveryStatusKey is the key of the event delivery status in the kvstore of
+// the adapter
+const DeliveryStatusKey = "delivery"
+
+// deliveryStatusInterval is the period of publishing the delivery status, which
+// is also the window of the events per minute
+var deliveryStatusInterval = time.Minute
+
+// DeliveryStatus records the events delivered by the adapter, so that the
+// controller can show whether events are flowing.
+type DeliveryStatus struct {
+	// LastDeliveredTime is the time the last event was acknowledged by the sink
+	LastDeliveredTime time.Time `json:"lastDeliveredTime,omitempty"`
+	// CheckpointTime is the vCenter time of the last event in the saved
+	// checkpoint
+	CheckpointTime time.Time `json:"checkpointTime,omitempty"`
+	// EventsPerMinute is the number of events delivered in the last interval,
+	// scaled to one minute
+	EventsPerMinute int64 `json:"eventsPerMinute"`
+	// LagSeconds is how far the last event processed by the adapter is behind
+	// the newest vCenter event, in seconds
+	LagSeconds int64 `json:"lagSeconds,omitempty"`
+	// LastErrorCategory is the category of the last error sending an event in
+	// the last interval, empty if all events were sent
+	LastErrorCategory ErrorCategory `json:"lastErrorCategory,omitempty"`
+	// LastError is the last error sending an event in the last interval
+	LastError string `json:"lastError,omitempty"`
+	// Mirror records the events sent to the mirror sink, nil without mirror
+	// sink
+	Mirror *MirrorStatus `json:"mirror,omitempty"`
+}
+
+// deliveryStats counts the events acknowledged by the sink. The zero value
+// is ready to use and safe for concurrent use.
+type deliveryStats struct {
+	mu        sync.Mutex
+	delivered int64
+	last      time.Time
+	// lastErrorCategory and lastError are the last error sending an event
+	// since the last status
+	lastErrorCategory ErrorCategory
+	lastError         string
+}
+
+// record counts an event acknowledged by the sink at the given time
+func (s *deliveryStats) record(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delivered++
+	s.last = t
+}
+
+// recordError records the given error of the given category sending an event
+func (s *deliveryStats) recordError(category ErrorCategory, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErrorCategory = category
+	s.lastError = err.Error()
+}
+
+// take returns the number of events delivered since the last call and the
+// time of the last delivered event
+func (s *deliveryStats) take() (int64, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delivered := s.delivered
+	s.delivered = 0
+	return delivered, s.last
+}
+
+// newDeliveryStatus returns the delivery status with the events delivered since
+// the last status, which was published the given duration ago.
+func (s *deliveryStats) newDeliveryStatus(elapsed time.Duration, checkpointTime time.Time) DeliveryStatus {
+	delivered, last := s.take()
+	status := DeliveryStatus{
+		LastDeliveredTime: last,
+		CheckpointTime:    checkpointTime,
+	}
+	s.mu.Lock()
+	status.LastErrorCategory, status.LastError = s.lastErrorCategory, s.lastError
+	s.lastErrorCategory, s.lastError = "", ""
+	s.mu.Unlock()
+	if elapsed > 0 {
+		status.EventsPerMinute = delivered * int64(time.Minute) / int64(elapsed)
+	}
+	return status
+}
+
+// publishDeliveryStatus saves the given delivery status in the kvstore unless
+// it equals the previous status, to avoid updates of idle sources.
+func (a *vAdapter) publishDeliveryStatus(ctx context.Context, status, previous DeliveryStatus) {
+	if reflect.DeepEqual(status, previous) {
+		return
+	}
+
+	logger := logging.FromContext(ctx)
+	if err := a.KVStore.Set(ctx, DeliveryStatusKey, status); err != nil {
+		logger.Warnw("failed to set delivery status", zap.Error(err))
+	} else if err = a.KVStore.Save(ctx); err != nil {
+		logger.Warnw("failed to save delivery status", zap.Error(err))
+	}
+}
+
+// streamLag returns how far the given vCenter time of the last event
+// processed by the adapter, delivered or filtered, is behind the newest event
+// of the given collector. Events in the latest page of the collector which
+// are older than the processed time do not count as lag.
+func streamLag(ctx context.Context, c *event.HistoryCollector, processed time.Time) (time.Duration, error) {
+	events, err := c.LatestPage(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("read latest vCenter events: %w", err)
+	}
+
+	var lag time.Duration
+	for _, e := range events {
+		if d := e.GetEvent().CreatedTime.Sub(processed); d > lag {
+			lag = d
+		}
+	}
+	return lag, nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Test_deliveryStats_newDeliveryStatus(t *testing.T) {
+	var s deliveryStats
+	checkpoint := time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	if got := s.newDeliveryStatus(time.Minute, time.Time{}); got != (DeliveryStatus{}) {
+		t.Errorf("newDeliveryStatus() without events = %+v, want zero status", got)
+	}
+
+	last := checkpoint.Add(time.Minute)
+	for i := 0; i < 15; i++ {
+		s.record(last)
+	}
+	got := s.newDeliveryStatus(30*time.Second, checkpoint)
+	want := DeliveryStatus{LastDeliveredTime: last, CheckpointTime: checkpoint, EventsPerMinute: 30}
+	if got != want {
+		t.Errorf("newDeliveryStatus() = %+v, want %+v", got, want)
+	}
+
+	// the count restarts, the last delivery is kept
+	got = s.newDeliveryStatus(time.Minute, checkpoint)
+	want.EventsPerMinute = 0
+	if got != want {
+		t.Errorf("newDeliveryStatus() after an idle minute = %+v, want %+v", got, want)
+	}
+
+	// the last error is reported once
+	s.recordError(ErrorCategorySink4xx, errors.New("400: bad request"))
+	got = s.newDeliveryStatus(time.Minute, checkpoint)
+	want.LastErrorCategory, want.LastError = ErrorCategorySink4xx, "400: bad request"
+	if got != want {
+		t.Errorf("newDeliveryStatus() after an error = %+v, want %+v", got, want)
+	}
+	got = s.newDeliveryStatus(time.Minute, checkpoint)
+	want.LastErrorCategory, want.LastError = "", ""
+	if got != want {
+		t.Errorf("newDeliveryStatus() after a reported error = %+v, want %+v", got, want)
+	}
+}
+
+func Test_vAdapter_publishDeliveryStatus(t *testing.T) {
+	ctx := context.Background()
+	kv := &fakeKVStore{dataChan: make(chan string, 2)}
+	a := &vAdapter{KVStore: kv}
+
+	status := DeliveryStatus{LastDeliveredTime: time.Now().UTC(), EventsPerMinute: 5}
+	a.publishDeliveryStatus(ctx, status, DeliveryStatus{})
+
+	var got DeliveryStatus
+	if err := kv.Get(ctx, DeliveryStatusKey, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.EventsPerMinute != 5 || !got.LastDeliveredTime.Equal(status.LastDeliveredTime) || !kv.saved {
+		t.Errorf("delivery status = %+v, saved %v", got, kv.saved)
+	}
+
+	// unchanged status is not saved again
+	kv.saved = false
+	a.publishDeliveryStatus(ctx, status, status)
+	if kv.saved {
+		t.Error("unchanged delivery status was saved")
+	}
+}
+
+func Test_streamLag(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		coll, err := newHistoryCollector(ctx, vim, vim.ServiceContent.RootFolder, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		vm, err := find.NewFinder(vim).VirtualMachine(ctx, "DC0_H0_VM0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		task, err := vm.PowerOff(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = task.Wait(ctx)
+
+		events, err := coll.LatestPage(ctx)
+		if err != nil || len(events) == 0 {
+			t.Fatalf("LatestPage() = %d events, %v", len(events), err)
+		}
+		newest := events[0].GetEvent().CreatedTime
+		for _, e := range events {
+			if e.GetEvent().CreatedTime.After(newest) {
+				newest = e.GetEvent().CreatedTime
+			}
+		}
+
+		if lag, err := streamLag(ctx, coll, newest.Add(-time.Hour)); err != nil || lag != time.Hour {
+			t.Errorf("streamLag() behind = %v, %v, want 1h", lag, err)
+		}
+		if lag, err := streamLag(ctx, coll, newest); err != nil || lag != 0 {
+			t.Errorf("streamLag() caught up = %v, %v, want 0", lag, err)
+		}
+	})
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// CloudEvents extension attributes added by event enrichment
+const (
+	// ExtensionTags is the comma-separated list of tag names attached to the
+	// affected object
+	ExtensionTags = "vspheretags"
+	// ExtensionFolderPath is the inventory path of the folder of the affected
+	// object
+	ExtensionFolderPath = "vspherefolderpath"
+	// ExtensionCustomAttributes is the JSON-encoded map of the custom
+	// attributes of the affected object
+	ExtensionCustomAttributes = "vsphereattributes"
+)
+
+const (
+	// lookups of an object are cached for this duration
+	enrichmentCacheTTL = 5 * time.Minute
+	// the cache is cleared when it exceeds this size
+	enrichmentCacheSize = 1000
+)
+
+// Enrichment selects the information about the affected object of an event
+// which is looked up in vCenter and added to the event
+type Enrichment struct {
+	Tags             bool `json:"tags,omitempty"`
+	FolderPath       bool `json:"folderPath,omitempty"`
+	CustomAttributes bool `json:"customAttributes,omitempty"`
+}
+
+func newEnrichment(s string) (*Enrichment, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var e Enrichment
+	if err := json.Unmarshal([]byte(s), &e); err != nil {
+		return nil, fmt.Errorf("unmarshal enrichment: %w", err)
+	}
+	return &e, nil
+}
+
+// enrichmentData is the information looked up for an object
+type enrichmentData struct {
+	Tags             []string
+	FolderPath       string
+	CustomAttributes map[string]string
+
+	expires time.Time
+}
+
+// enricher looks up information of the affected object of an event and adds it
+// as extension attributes. Lookups are cached per object, so that bursts of
+// events for the same object result in a single lookup.
+type enricher struct {
+	config Enrichment
+	vc     *vim25.Client
+	tags   *tags.Manager
+
+	// the current time, for testing
+	now func() time.Time
+
+	mu    sync.Mutex
+	cache map[types.ManagedObjectReference]enrichmentData
+}
+
+// newEnricher returns an enricher for the given config. The REST client is only
+// required for tag lookups.
+func newEnricher(config Enrichment, vc *vim25.Client, rc *rest.Client) *enricher {
+	e := &enricher{
+		config: config,
+		vc:     vc,
+		now:    time.Now,
+		cache:  make(map[types.ManagedObjectReference]enrichmentData),
+	}
+	if config.Tags && rc != nil {
+		e.tags = tags.NewManager(rc)
+	}
+	return e
+}
+
+// apply adds the enabled extension attributes for the given object to the
+// event. A nil enricher or object is a no-op.
+func (e *enricher) apply(ctx context.Context, ev *cloudevents.Event, ref *types.ManagedObjectReference) error {
+	if e == nil || ref == nil {
+		return nil
+	}
+
+	data, err := e.lookup(ctx, *ref)
+	if err != nil {
+		return err
+	}
+
+	if e.config.Tags && len(data.Tags) > 0 {
+		ev.SetExtension(ExtensionTags, strings.Join(data.Tags, ","))
+	}
+	if e.config.FolderPath && data.FolderPath != "" {
+		ev.SetExtension(ExtensionFolderPath, data.FolderPath)
+	}
+	if e.config.CustomAttributes && len(data.CustomAttributes) > 0 {
+		b, err := json.Marshal(data.CustomAttributes)
+		if err != nil {
+			return fmt.Errorf("marshal custom attributes: %w", err)
+		}
+		ev.SetExtension(ExtensionCustomAttributes, string(b))
+	}
+	return nil
+}
+
+// lookup returns the cached information of the given object or retrieves it
+// from vCenter
+func (e *enricher) lookup(ctx context.Context, ref types.ManagedObjectReference) (enrichmentData, error) {
+	now := e.now()
+
+	e.mu.Lock()
+	data, ok := e.cache[ref]
+	e.mu.Unlock()
+	if ok && now.Before(data.expires) {
+		return data, nil
+	}
+
+	data = enrichmentData{expires: now.Add(enrichmentCacheTTL)}
+
+	if e.tags != nil {
+		attached, err := e.tags.GetAttachedTags(ctx, ref)
+		if err != nil {
+			return data, fmt.Errorf("get attached tags: %w", err)
+		}
+		for _, t := range attached {
+			data.Tags = append(data.Tags, t.Name)
+		}
+		sort.Strings(data.Tags)
+	}
+
+	if e.config.FolderPath {
+		p, err := find.InventoryPath(ctx, e.vc, ref)
+		if err != nil {
+			return data, fmt.Errorf("get inventory path: %w", err)
+		}
+		data.FolderPath = path.Dir(p)
+	}
+
+	if e.config.CustomAttributes {
+		attrs, err := e.customAttributes(ctx, ref)
+		if err != nil {
+			return data, fmt.Errorf("get custom attributes: %w", err)
+		}
+		data.CustomAttributes = attrs
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.cache) >= enrichmentCacheSize {
+		e.cache = make(map[types.ManagedObjectReference]enrichmentData)
+	}
+	e.cache[ref] = data
+
+	return data, nil
+}
+
+// customAttributes returns the custom attributes of the given object by name
+func (e *enricher) customAttributes(ctx context.Context, ref types.ManagedObjectReference) (map[string]string, error) {
+	var entity mo.ManagedEntity
+	err := property.DefaultCollector(e.vc).RetrieveOne(ctx, ref, []string{"customValue"}, &entity)
+	if err != nil {
+		return nil, err
+	}
+	if len(entity.CustomValue) == 0 {
+		return nil, nil
+	}
+
+	m, err := object.GetCustomFieldsManager(e.vc)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := m.Field(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[string]string, len(entity.CustomValue))
+	for _, cv := range entity.CustomValue {
+		v, ok := cv.(*types.CustomFieldStringValue)
+		if !ok {
+			continue
+		}
+		if def := fields.ByKey(v.Key); def != nil {
+			attrs[def.Name] = v.Value
+		}
+	}
+	return attrs, nil
+}
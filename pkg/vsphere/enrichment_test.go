@@ -0,0 +1,107 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+func Test_enricher_apply(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		rc := rest.NewClient(vim)
+		if err := rc.Login(ctx, simulator.DefaultLogin); err != nil {
+			t.Fatal(err)
+		}
+
+		vm, err := find.NewFinder(vim).VirtualMachine(ctx, "DC0_H0_VM0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref := vm.Reference()
+
+		tm := tags.NewManager(rc)
+		catID, err := tm.CreateCategory(ctx, &tags.Category{Name: "env", Cardinality: "MULTIPLE"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"prod", "critical"} {
+			id, err := tm.CreateTag(ctx, &tags.Tag{Name: name, CategoryID: catID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = tm.AttachTag(ctx, id, ref); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		fm, err := object.GetCustomFieldsManager(vim)
+		if err != nil {
+			t.Fatal(err)
+		}
+		field, err := fm.Add(ctx, "owner", "", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = fm.Set(ctx, ref, field.Key, "team-a"); err != nil {
+			t.Fatal(err)
+		}
+
+		now := time.Now()
+		e := newEnricher(Enrichment{Tags: true, FolderPath: true, CustomAttributes: true}, vim, rc)
+		e.now = func() time.Time { return now }
+
+		assertExtensions := func(t *testing.T, want map[string]string) {
+			t.Helper()
+			ev := cloudevents.NewEvent()
+			if err := e.apply(ctx, &ev, &ref); err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			for k, v := range want {
+				if got := ev.Extensions()[k]; got != v {
+					t.Errorf("extension %s = %v, want %v", k, got, v)
+				}
+			}
+		}
+
+		assertExtensions(t, map[string]string{
+			ExtensionTags:             "critical,prod",
+			ExtensionFolderPath:       "/DC0/vm",
+			ExtensionCustomAttributes: `{"owner":"team-a"}`,
+		})
+
+		// cached until the lookup expires
+		if err = fm.Set(ctx, ref, field.Key, "team-b"); err != nil {
+			t.Fatal(err)
+		}
+		assertExtensions(t, map[string]string{ExtensionCustomAttributes: `{"owner":"team-a"}`})
+
+		now = now.Add(enrichmentCacheTTL)
+		assertExtensions(t, map[string]string{ExtensionCustomAttributes: `{"owner":"team-b"}`})
+	})
+}
+
+func Test_enricher_apply_nil(t *testing.T) {
+	var e *enricher
+	ev := cloudevents.NewEvent()
+	if err := e.apply(context.Background(), &ev, nil); err != nil {
+		t.Errorf("apply() error = %v", err)
+	}
+	if len(ev.Extensions()) != 0 {
+		t.Errorf("apply() extensions = %v, want none", ev.Extensions())
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"knative.dev/pkg/metrics"
+)
+
+// ErrorCategory classifies the errors of the adapter by the failing
+// dependency, so that alerts on the logs, metrics and status of a source can
+// be routed to the team owning it.
+type ErrorCategory string
+
+const (
+	// ErrorCategoryAuth is the category of credentials which are missing or
+	// rejected by vCenter
+	ErrorCategoryAuth ErrorCategory = "auth"
+	// ErrorCategoryVCenterNetwork is the category of a vCenter which cannot be
+	// reached or whose certificate is not trusted
+	ErrorCategoryVCenterNetwork ErrorCategory = "vcenter-network"
+	// ErrorCategorySink4xx is the category of events rejected by the sink with
+	// a 4xx status code
+	ErrorCategorySink4xx ErrorCategory = "sink-4xx"
+	// ErrorCategorySink5xx is the category of events the sink failed to accept
+	// with a 5xx status code, or which could not be sent to the sink at all
+	ErrorCategorySink5xx ErrorCategory = "sink-5xx"
+	// ErrorCategorySerialization is the category of events which cannot be
+	// converted, transformed or redacted
+	ErrorCategorySerialization ErrorCategory = "serialization"
+	// ErrorCategoryUnknown is the category of all other errors
+	ErrorCategoryUnknown ErrorCategory = "unknown"
+)
+
+// Reason returns the reason of a condition of a source failing with an error
+// of the category, e.g. SinkRejected.
+func (c ErrorCategory) Reason() string {
+	switch c {
+	case ErrorCategoryAuth:
+		return ConnectionReasonAuthenticationFailed
+	case ErrorCategoryVCenterNetwork:
+		return ConnectionReasonUnreachable
+	case ErrorCategorySink4xx:
+		return "SinkRejected"
+	case ErrorCategorySink5xx:
+		return "SinkFailed"
+	case ErrorCategorySerialization:
+		return "SerializationFailed"
+	default:
+		return "Failed"
+	}
+}
+
+var (
+	errorCountM = stats.Int64(
+		"adapter_error_count",
+		"Number of errors of the adapter by category",
+		stats.UnitDimensionless,
+	)
+	errorCategoryKey = tag.MustNewKey("category")
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: errorCountM.Description(),
+		Measure:     errorCountM,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{errorCategoryKey},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// categorizedError is an error of a known category
+type categorizedError struct {
+	category ErrorCategory
+	err      error
+}
+
+func (e *categorizedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *categorizedError) Unwrap() error {
+	return e.err
+}
+
+// withCategory returns the given error classified as the given category,
+// unless it is classified already. It returns nil if err is nil.
+func withCategory(category ErrorCategory, err error) error {
+	var ce *categorizedError
+	if err == nil || errors.As(err, &ce) {
+		return err
+	}
+	return &categorizedError{category: category, err: err}
+}
+
+// sinkError classifies the given result of a failed attempt to send an event
+// to a sink by its HTTP status code.
+func sinkError(result error) error {
+	var httpResult *cehttp.Result
+	if cloudevents.ResultAs(result, &httpResult) &&
+		httpResult.StatusCode >= http.StatusBadRequest && httpResult.StatusCode < http.StatusInternalServerError {
+		return withCategory(ErrorCategorySink4xx, result)
+	}
+	return withCategory(ErrorCategorySink5xx, result)
+}
+
+// CategoryOf returns the category of the given error. Errors which are not
+// classified explicitly are classified like vCenter connection errors, see
+// connectionFailureReason.
+func CategoryOf(err error) ErrorCategory {
+	var ce *categorizedError
+	if errors.As(err, &ce) {
+		return ce.category
+	}
+
+	switch connectionFailureReason(err) {
+	case ConnectionReasonAuthenticationFailed:
+		return ErrorCategoryAuth
+	case ConnectionReasonCertificateInvalid, ConnectionReasonUnreachable:
+		return ErrorCategoryVCenterNetwork
+	default:
+		return ErrorCategoryUnknown
+	}
+}
+
+// errorCategoryField returns the log field with the category of the given
+// error
+func errorCategoryField(err error) zap.Field {
+	return zap.String("errorCategory", string(CategoryOf(err)))
+}
+
+// recordError counts the given error in the error metric by its category and
+// returns the category
+func recordError(ctx context.Context, err error) ErrorCategory {
+	category := CategoryOf(err)
+	metrics.Record(ctx, errorCountM.M(1), stats.WithTags(tag.Insert(errorCategoryKey, string(category))))
+	return category
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestCategoryOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCategory
+	}{
+		{name: "invalid login", err: soap.WrapVimFault(&types.InvalidLogin{}), want: ErrorCategoryAuth},
+		{
+			name: "unknown host",
+			err:  &url.Error{Op: "Post", URL: "https://vcenter/sdk", Err: &net.DNSError{Err: "no such host", Name: "vcenter"}},
+			want: ErrorCategoryVCenterNetwork,
+		},
+		{name: "sink rejected event", err: sinkError(cehttp.NewResult(400, "bad request")), want: ErrorCategorySink4xx},
+		{name: "sink failed", err: sinkError(cehttp.NewResult(503, "unavailable")), want: ErrorCategorySink5xx},
+		{name: "sink unreachable", err: sinkError(errors.New("dial tcp: connection refused")), want: ErrorCategorySink5xx},
+		{name: "serialization", err: withCategory(ErrorCategorySerialization, errors.New("unsupported event")), want: ErrorCategorySerialization},
+		{
+			name: "wrapped category",
+			err:  fmt.Errorf("send: %w", withCategory(ErrorCategorySerialization, errors.New("unsupported event"))),
+			want: ErrorCategorySerialization,
+		},
+		{
+			name: "category is kept",
+			err:  withCategory(ErrorCategorySink5xx, withCategory(ErrorCategorySerialization, errors.New("unsupported event"))),
+			want: ErrorCategorySerialization,
+		},
+		{name: "other", err: errors.New("boom"), want: ErrorCategoryUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CategoryOf(tt.err); got != tt.want {
+				t.Errorf("CategoryOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorCategory_Reason(t *testing.T) {
+	tests := []struct {
+		category ErrorCategory
+		want     string
+	}{
+		{category: ErrorCategoryAuth, want: ConnectionReasonAuthenticationFailed},
+		{category: ErrorCategoryVCenterNetwork, want: ConnectionReasonUnreachable},
+		{category: ErrorCategorySink4xx, want: "SinkRejected"},
+		{category: ErrorCategorySink5xx, want: "SinkFailed"},
+		{category: ErrorCategorySerialization, want: "SerializationFailed"},
+		{category: ErrorCategoryUnknown, want: "Failed"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.category), func(t *testing.T) {
+			if got := tt.category.Reason(); got != tt.want {
+				t.Errorf("Reason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_sendEvents_errorCategory(t *testing.T) {
+	roundTripper := &roundTripperTest{statusCodes: []int{400}}
+	p, err := cehttp.New(cehttp.WithRoundTripper(roundTripper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := cecontext.WithTarget(context.Background(), "fake.example.com")
+
+	a := &vAdapter{CEClient: c, Source: source}
+	if _, err := a.sendEvents(ctx, []types.BaseEvent{newPoweredOnEvent(1, "vm-1", time.Now())}); err == nil {
+		t.Fatal("sendEvents() did not fail")
+	} else if got := CategoryOf(err); got != ErrorCategorySink4xx {
+		t.Errorf("sendEvents() error category = %q, want %q", got, ErrorCategorySink4xx)
+	}
+
+	if got := a.Deliveries.newDeliveryStatus(time.Minute, time.Time{}).LastErrorCategory; got != ErrorCategorySink4xx {
+		t.Errorf("delivery status lastErrorCategory = %q, want %q", got, ErrorCategorySink4xx)
+	}
+}
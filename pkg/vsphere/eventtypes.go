@@ -0,0 +1,129 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"sort"
+	"strings"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// alarmEventClasses are the vSphere alarm event classes, which are sent with
+// the types returned by alarmEventType if alarms are normalized
+var alarmEventClasses = []string{
+	"AlarmAcknowledgedEvent",
+	"AlarmActionTriggeredEvent",
+	"AlarmClearedEvent",
+	"AlarmCreatedEvent",
+	"AlarmEmailCompletedEvent",
+	"AlarmEmailFailedEvent",
+	"AlarmEvent",
+	"AlarmReconfiguredEvent",
+	"AlarmRemovedEvent",
+	"AlarmScriptCompleteEvent",
+	"AlarmScriptFailedEvent",
+	"AlarmSnmpCompletedEvent",
+	"AlarmSnmpFailedEvent",
+	"AlarmStatusChangedEvent",
+}
+
+// EventTypeOptions are the settings of a source which determine the types of
+// the events it sends
+type EventTypeOptions struct {
+	IncludeTasks          bool
+	IncludeTags           bool
+	IncludeContentLibrary bool
+	NormalizeAlarms       bool
+	OutputFormat          string
+	Filter                EventFilter
+	Mapping               AttributeMapping
+}
+
+// EventTypes returns the sorted CloudEvent types a source with the given
+// options sends, as far as they are known in advance: the types of alarm,
+// task, tag and content library events, the CDEvents types and the types
+// named in the filter without wildcards. The types of the other vSphere events
+// depend on the vCenter and are not returned, unless named in the filter.
+func EventTypes(o EventTypeOptions) ([]string, error) {
+	mapper, err := o.Mapping.mapper()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []cloudevents.Event
+	add := func(class, typ string) {
+		ev := cloudevents.NewEvent(cloudevents.VersionV1)
+		ev.SetType(typ)
+		ev.SetExtension("EventClass", class)
+		candidates = append(candidates, ev)
+	}
+
+	switch o.OutputFormat {
+	case OutputFormatCDEvents:
+		// CDEvents types are not mapped
+		mapper = nil
+		for _, m := range cdEventMappings {
+			add("event", m.eventType())
+		}
+		if o.IncludeTasks {
+			for _, m := range cdTaskMappings {
+				add(taskEventClass, m.eventType())
+			}
+		}
+	default:
+		for _, class := range alarmEventClasses {
+			if o.NormalizeAlarms {
+				add(alarmEventClass, alarmEventType(class))
+			} else {
+				add("event", "com.vmware.vsphere."+class)
+			}
+		}
+		if o.IncludeTasks {
+			for _, state := range []types.TaskInfoState{types.TaskInfoStateQueued,
+				types.TaskInfoStateRunning, types.TaskInfoStateSuccess, types.TaskInfoStateError} {
+				add(taskEventClass, "com.vmware.vsphere.task."+string(state))
+			}
+		}
+		if o.IncludeTags {
+			for _, op := range []string{tagOpAttached, tagOpDetached} {
+				add(tagEventClass, tagEventTypePrefix+op)
+			}
+		}
+		if o.IncludeContentLibrary {
+			for _, kind := range []string{"library", "item"} {
+				for _, op := range []string{libraryOpCreated, libraryOpUpdated, libraryOpDeleted} {
+					add(libraryEventClass, libraryEventTypePrefix+kind+"."+op)
+				}
+			}
+		}
+	}
+
+	set := make(map[string]struct{})
+	for _, ev := range candidates {
+		if err := mapper.apply(&ev); err != nil {
+			return nil, err
+		}
+		if o.Filter.Match(ev, nil) {
+			set[ev.Type()] = struct{}{}
+		}
+	}
+
+	// types named in the filter are sent as soon as vCenter emits them
+	for _, p := range o.Filter.EventTypes {
+		if !strings.ContainsAny(p, `*?[\`) {
+			set[p] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(set))
+	for t := range set {
+		result = append(result, t)
+	}
+	sort.Strings(result)
+	return result, nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEventTypes(t *testing.T) {
+	alarms := []string{
+		"com.vmware.vsphere.AlarmAcknowledgedEvent",
+		"com.vmware.vsphere.AlarmActionTriggeredEvent",
+		"com.vmware.vsphere.AlarmClearedEvent",
+		"com.vmware.vsphere.AlarmCreatedEvent",
+		"com.vmware.vsphere.AlarmEmailCompletedEvent",
+		"com.vmware.vsphere.AlarmEmailFailedEvent",
+		"com.vmware.vsphere.AlarmEvent",
+		"com.vmware.vsphere.AlarmReconfiguredEvent",
+		"com.vmware.vsphere.AlarmRemovedEvent",
+		"com.vmware.vsphere.AlarmScriptCompleteEvent",
+		"com.vmware.vsphere.AlarmScriptFailedEvent",
+		"com.vmware.vsphere.AlarmSnmpCompletedEvent",
+		"com.vmware.vsphere.AlarmSnmpFailedEvent",
+		"com.vmware.vsphere.AlarmStatusChangedEvent",
+	}
+	normalizedAlarms := []string{
+		"com.vmware.vsphere.alarm.acknowledged",
+		"com.vmware.vsphere.alarm.actiontriggered",
+		"com.vmware.vsphere.alarm.cleared",
+		"com.vmware.vsphere.alarm.created",
+		"com.vmware.vsphere.alarm.emailcompleted",
+		"com.vmware.vsphere.alarm.emailfailed",
+		"com.vmware.vsphere.alarm.event",
+		"com.vmware.vsphere.alarm.reconfigured",
+		"com.vmware.vsphere.alarm.removed",
+		"com.vmware.vsphere.alarm.scriptcomplete",
+		"com.vmware.vsphere.alarm.scriptfailed",
+		"com.vmware.vsphere.alarm.snmpcompleted",
+		"com.vmware.vsphere.alarm.snmpfailed",
+		"com.vmware.vsphere.alarm.statuschanged",
+	}
+
+	tests := []struct {
+		name    string
+		opts    EventTypeOptions
+		want    []string
+		wantLen int
+		wantErr bool
+	}{{
+		name: "defaults",
+		opts: EventTypeOptions{},
+		want: alarms,
+	}, {
+		name: "normalized alarms",
+		opts: EventTypeOptions{NormalizeAlarms: true},
+		want: normalizedAlarms,
+	}, {
+		name: "tasks and tags",
+		opts: EventTypeOptions{
+			IncludeTasks: true,
+			IncludeTags:  true,
+			Filter:       EventFilter{EventTypes: []string{"com.vmware.vsphere.task.*", "com.vmware.vsphere.tag.*"}},
+		},
+		want: []string{
+			"com.vmware.vsphere.tag.attached",
+			"com.vmware.vsphere.tag.detached",
+			"com.vmware.vsphere.task.error",
+			"com.vmware.vsphere.task.queued",
+			"com.vmware.vsphere.task.running",
+			"com.vmware.vsphere.task.success",
+		},
+	}, {
+		name:    "content library",
+		opts:    EventTypeOptions{IncludeContentLibrary: true},
+		wantLen: len(alarms) + 6,
+	}, {
+		name: "exact types and globs",
+		opts: EventTypeOptions{
+			Filter: EventFilter{EventTypes: []string{"com.vmware.vsphere.alarm.cleared", "com.vmware.vsphere.Vm*", "com.vmware.vsphere.VmPoweredOnEvent"}},
+		},
+		want: []string{
+			"com.vmware.vsphere.VmPoweredOnEvent",
+			"com.vmware.vsphere.alarm.cleared",
+		},
+	}, {
+		name: "type prefix",
+		opts: EventTypeOptions{
+			NormalizeAlarms: true,
+			Mapping:         AttributeMapping{TypePrefix: "com.example."},
+			Filter:          EventFilter{EventTypes: []string{"com.example.alarm.c*"}},
+		},
+		want: []string{
+			"com.example.alarm.cleared",
+			"com.example.alarm.created",
+		},
+	}, {
+		name: "type template",
+		opts: EventTypeOptions{
+			IncludeTasks: true,
+			Mapping:      AttributeMapping{TypeTemplate: "com.example.{{.Class}}"},
+		},
+		want: []string{
+			"com.example.event",
+			"com.example.task",
+		},
+	}, {
+		name: "cdevents",
+		opts: EventTypeOptions{OutputFormat: OutputFormatCDEvents, IncludeTasks: true},
+		want: []string{
+			"dev.cdevents.environment.created.0.1.1",
+			"dev.cdevents.environment.deleted.0.1.1",
+			"dev.cdevents.environment.modified.0.1.1",
+			"dev.cdevents.taskrun.finished.0.1.1",
+			"dev.cdevents.taskrun.started.0.1.1",
+		},
+	}, {
+		name:    "invalid template",
+		opts:    EventTypeOptions{Mapping: AttributeMapping{TypeTemplate: "{{"}},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EventTypes(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EventTypes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantLen != 0 {
+				if len(got) != tt.wantLen {
+					t.Errorf("EventTypes() got %d types, want %d: %v", len(got), tt.wantLen, got)
+				}
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); !tt.wantErr && diff != "" {
+				t.Errorf("EventTypes() (-want, +got) = %s", diff)
+			}
+		})
+	}
+}
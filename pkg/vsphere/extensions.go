@@ -0,0 +1,126 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// CloudEvents extension attributes describing the vSphere context of an event
+const (
+	// ExtensionEventClass is the class of the event, e.g. event, task or alarm
+	ExtensionEventClass = "vsphereeventclass"
+	// ExtensionVMMoref is the managed object reference value of the affected
+	// virtual machine
+	ExtensionVMMoref = "vmmoref"
+	// ExtensionHostMoref is the managed object reference value of the affected
+	// host
+	ExtensionHostMoref = "hostmoref"
+	// ExtensionDatacenter is the name of the datacenter of the affected object
+	ExtensionDatacenter = "datacenter"
+	// ExtensionVCenterID is the instance UUID of the vCenter
+	ExtensionVCenterID = "vcenterid"
+)
+
+// StandardExtensions are all supported extension attributes
+var StandardExtensions = []string{
+	ExtensionEventClass,
+	ExtensionVMMoref,
+	ExtensionHostMoref,
+	ExtensionDatacenter,
+	ExtensionVCenterID,
+}
+
+// extensionContext is the vSphere context of an event. Unknown fields are not
+// set as extension attributes.
+type extensionContext struct {
+	VM         *types.ManagedObjectReference
+	Host       *types.ManagedObjectReference
+	Datacenter string
+}
+
+// eventExtensionContext returns the context of the given vSphere event
+func eventExtensionContext(e *types.Event) extensionContext {
+	var c extensionContext
+	if e.Vm != nil {
+		c.VM = &e.Vm.Vm
+	}
+	if e.Host != nil {
+		c.Host = &e.Host.Host
+	}
+	if e.Datacenter != nil {
+		c.Datacenter = e.Datacenter.Name
+	}
+	return c
+}
+
+// entityExtensionContext returns the context of an event about the given
+// managed object, e.g. the entity of a task
+func entityExtensionContext(ref *types.ManagedObjectReference) extensionContext {
+	var c extensionContext
+	if ref == nil {
+		return c
+	}
+
+	switch ref.Type {
+	case "VirtualMachine":
+		c.VM = ref
+	case "HostSystem":
+		c.Host = ref
+	}
+	return c
+}
+
+// extensionSet is the set of extension attributes set on events
+type extensionSet map[string]bool
+
+// newExtensionSet returns the set of the given extension attribute names,
+// which must be part of StandardExtensions
+func newExtensionSet(names []string) (extensionSet, error) {
+	s := make(extensionSet, len(names))
+	for _, n := range names {
+		if !isStandardExtension(n) {
+			return nil, fmt.Errorf("unsupported extension attribute %q", n)
+		}
+		s[n] = true
+	}
+	return s, nil
+}
+
+// isStandardExtension returns true if name is part of StandardExtensions
+func isStandardExtension(name string) bool {
+	for _, n := range StandardExtensions {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// apply sets the enabled extension attributes on the given event. The event
+// class is taken from the EventClass extension of the event.
+func (s extensionSet) apply(ev *cloudevents.Event, vcenterID string, c extensionContext) {
+	set := func(name, value string) {
+		if s[name] && value != "" {
+			ev.SetExtension(name, value)
+		}
+	}
+
+	if class, ok := ev.Extensions()["eventclass"].(string); ok {
+		set(ExtensionEventClass, class)
+	}
+	if c.VM != nil {
+		set(ExtensionVMMoref, c.VM.Value)
+	}
+	if c.Host != nil {
+		set(ExtensionHostMoref, c.Host.Value)
+	}
+	set(ExtensionDatacenter, c.Datacenter)
+	set(ExtensionVCenterID, vcenterID)
+}
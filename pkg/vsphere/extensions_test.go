@@ -0,0 +1,98 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newExtensionSet(t *testing.T) {
+	if _, err := newExtensionSet(StandardExtensions); err != nil {
+		t.Errorf("newExtensionSet(%v) error = %v", StandardExtensions, err)
+	}
+
+	if _, err := newExtensionSet([]string{ExtensionVMMoref, "clusterid"}); err == nil {
+		t.Error("newExtensionSet() with unsupported attribute did not fail")
+	}
+}
+
+func Test_extensionSet_apply(t *testing.T) {
+	vm := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+	host := types.ManagedObjectReference{Type: "HostSystem", Value: "host-1"}
+
+	tests := []struct {
+		name       string
+		extensions []string
+		context    extensionContext
+		want       map[string]interface{}
+	}{
+		{
+			name:       "all extensions",
+			extensions: StandardExtensions,
+			context: eventExtensionContext(&types.Event{
+				Vm:         &types.VmEventArgument{Vm: vm},
+				Host:       &types.HostEventArgument{Host: host},
+				Datacenter: &types.DatacenterEventArgument{EntityEventArgument: types.EntityEventArgument{Name: "DC0"}},
+			}),
+			want: map[string]interface{}{
+				"eventclass":        "event",
+				ExtensionEventClass: "event",
+				ExtensionVMMoref:    "vm-1",
+				ExtensionHostMoref:  "host-1",
+				ExtensionDatacenter: "DC0",
+				ExtensionVCenterID:  "vc-uuid",
+			},
+		},
+		{
+			name:       "selected extensions",
+			extensions: []string{ExtensionHostMoref, ExtensionVCenterID},
+			context:    entityExtensionContext(&host),
+			want: map[string]interface{}{
+				"eventclass":       "event",
+				ExtensionHostMoref: "host-1",
+				ExtensionVCenterID: "vc-uuid",
+			},
+		},
+		{
+			name:       "unknown context",
+			extensions: StandardExtensions,
+			context:    extensionContext{},
+			want: map[string]interface{}{
+				"eventclass":        "event",
+				ExtensionEventClass: "event",
+				ExtensionVCenterID:  "vc-uuid",
+			},
+		},
+		{
+			name:       "no extensions",
+			extensions: nil,
+			context:    entityExtensionContext(&vm),
+			want: map[string]interface{}{
+				"eventclass": "event",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newExtensionSet(tt.extensions)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ev := cloudevents.NewEvent()
+			ev.SetExtension("EventClass", "event")
+			s.apply(&ev, "vc-uuid", tt.context)
+
+			if diff := cmp.Diff(tt.want, ev.Extensions()); diff != "" {
+				t.Errorf("apply() (-want, +got) = %v", diff)
+			}
+		})
+	}
+}
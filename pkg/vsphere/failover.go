@@ -0,0 +1,76 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vmware/govmomi"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+	"knative.dev/pkg/kvstore"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+var failoverCountM = stats.Int64(
+	"vcenter_failover_count",
+	"Number of connections to the fallback vCenter address because the address of the source was unreachable",
+	stats.UnitDimensionless,
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: failoverCountM.Description(),
+		Measure:     failoverCountM,
+		Aggregation: view.Count(),
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// soapWithFailover returns a SOAP client like soapWithKeepalive. If the
+// address of env is unreachable, it connects to the fallback address of env
+// instead, if configured. Other failures, e.g. rejected credentials, do not
+// fail over. It also returns the configuration of the connected address.
+func soapWithFailover(ctx context.Context, env EnvConfig) (*govmomi.Client, EnvConfig, error) {
+	c, _, err := soapWithKeepalive(ctx, env)
+	if err == nil || env.FallbackAddress == "" || connectionFailureReason(err) != ConnectionReasonUnreachable {
+		return c, env, err
+	}
+
+	logging.FromContext(ctx).Warnw("vCenter unreachable, failing over to the fallback address",
+		zap.String("fallbackAddress", env.FallbackAddress), zap.Error(err))
+	fallback := env
+	fallback.Address = env.FallbackAddress
+	c, _, ferr := soapWithKeepalive(ctx, fallback)
+	if ferr != nil {
+		return nil, env, fmt.Errorf("%w (fallback address: %v)", err, ferr)
+	}
+	metrics.Record(ctx, failoverCountM.M(1))
+	return c, fallback, nil
+}
+
+// fallbackAddress returns the fallback address of env if connected, the
+// configuration returned by soapWithFailover, is the fallback, otherwise an
+// empty string
+func fallbackAddress(env, connected EnvConfig) string {
+	if connected.Address == env.Address {
+		return ""
+	}
+	return connected.Address
+}
+
+// recordConnected saves a successful connection in the kvstore like
+// recordConnectionStatus, noting the fallback address connected to, if not
+// empty.
+func recordConnected(ctx context.Context, store kvstore.Interface, fallback string) {
+	status := newConnectionStatus(nil)
+	status.FallbackAddress = fallback
+	saveConnectionStatus(ctx, store, status)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_soapWithFailover(t *testing.T) {
+	model := simulator.VPX()
+	defer model.Remove()
+	if err := model.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := model.Service.NewServer()
+	defer s.Close()
+
+	password, _ := s.URL.User.Password()
+	dir := setSecret(t, map[string]string{
+		corev1.BasicAuthUsernameKey: s.URL.User.Username(),
+		corev1.BasicAuthPasswordKey: password,
+	})
+
+	// nothing listens on the address of a closed listener
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unreachable := "https://" + l.Addr().String() + "/sdk"
+	if err = l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		address      string
+		fallback     string
+		wantFallback string
+		wantErr      bool
+	}{
+		{name: "primary reachable", address: s.URL.String(), fallback: unreachable},
+		{name: "primary unreachable", address: unreachable, fallback: s.URL.String(), wantFallback: s.URL.String()},
+		{name: "no fallback", address: unreachable, wantErr: true},
+		{name: "both unreachable", address: unreachable, fallback: unreachable, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := EnvConfig{
+				Address:         tt.address,
+				FallbackAddress: tt.fallback,
+				Insecure:        true,
+				SecretPath:      dir,
+			}
+			c, connected, err := soapWithFailover(context.Background(), env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("soapWithFailover() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer c.Logout(context.Background())
+
+			if got := fallbackAddress(env, connected); got != tt.wantFallback {
+				t.Errorf("fallbackAddress() = %q, want %q", got, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func Test_recordConnected(t *testing.T) {
+	ctx := context.Background()
+	kv := &fakeKVStore{dataChan: make(chan string, 1)}
+
+	recordConnected(ctx, kv, "https://vcenter-2.example.com/sdk")
+
+	var status ConnectionStatus
+	if err := kv.Get(ctx, ConnectionStatusKey, &status); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Connected || status.FallbackAddress != "https://vcenter-2.example.com/sdk" || !kv.saved {
+		t.Errorf("connection status = %+v, saved %v", status, kv.saved)
+	}
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vapi/rest"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/kvstore"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// AddressesMountPath is the directory below which the secrets of the
+	// additional vCenters of a source are mounted in its adapter, see
+	// AddressSecretMountPath
+	AddressesMountPath = "/var/bindings/vsphere-addresses"
+
+	// AddressSecretVolumePrefix is the prefix of the names of the volumes
+	// holding the secrets of the additional vCenters, see
+	// AddressSecretVolumeName
+	AddressSecretVolumePrefix = "vsphere-address-"
+)
+
+// AddressSecretVolumeName returns the name of the volume holding the secret of
+// the additional vCenter with the given index
+func AddressSecretVolumeName(i int) string {
+	return AddressSecretVolumePrefix + strconv.Itoa(i)
+}
+
+// AddressSecretMountPath returns where the secret of the additional vCenter
+// with the given index is mounted in the adapter
+func AddressSecretMountPath(i int) string {
+	return filepath.Join(AddressesMountPath, strconv.Itoa(i))
+}
+
+// newAddresses returns the configurations of the additional vCenters in the
+// given JSON-encoded list, which is empty if s is empty
+func newAddresses(s string) ([]EnvConfig, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var addresses []EnvConfig
+	if err := json.Unmarshal([]byte(s), &addresses); err != nil {
+		return nil, fmt.Errorf("unmarshal addresses: %w", err)
+	}
+	for i, a := range addresses {
+		if a.Address == "" {
+			return nil, fmt.Errorf("missing address of vCenter %d", i)
+		}
+	}
+	return addresses, nil
+}
+
+// lockedKVStore serializes the access of the adapters of all vCenters of a
+// source to its kvstore
+type lockedKVStore struct {
+	mu    sync.Mutex
+	store kvstore.Interface
+}
+
+var _ kvstore.Interface = (*lockedKVStore)(nil)
+
+func (s *lockedKVStore) Init(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Init(ctx)
+}
+
+func (s *lockedKVStore) Load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Load(ctx)
+}
+
+func (s *lockedKVStore) Save(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Save(ctx)
+}
+
+func (s *lockedKVStore) Get(ctx context.Context, key string, value interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Get(ctx, key, value)
+}
+
+func (s *lockedKVStore) Set(ctx context.Context, key string, value interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Set(ctx, key, value)
+}
+
+// prefixedKVStore stores the checkpoint and status of an additional vCenter
+// in the kvstore of the source, prefixing the keys with its host. Only the
+// unprefixed status of the vCenter of the source is reflected in the status of
+// the source.
+type prefixedKVStore struct {
+	kvstore.Interface
+	prefix string
+}
+
+// newPrefixedKVStore returns the kvstore of the vCenter with the given address
+// in the given kvstore of the source
+func newPrefixedKVStore(store kvstore.Interface, address string) (*prefixedKVStore, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("parse address %q: %w", address, err)
+	}
+	// configmap keys consist of alphanumeric characters, '-', '_' or '.'
+	prefix := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, u.Host)
+	return &prefixedKVStore{Interface: store, prefix: prefix + "."}, nil
+}
+
+func (s *prefixedKVStore) Get(ctx context.Context, key string, value interface{}) error {
+	return s.Interface.Get(ctx, s.prefix+key, value)
+}
+
+func (s *prefixedKVStore) Set(ctx context.Context, key string, value interface{}) error {
+	return s.Interface.Set(ctx, s.prefix+key, value)
+}
+
+// newAddressAdapter returns the adapter reading the events of the additional
+// vCenter configured in vc with the configuration of the source in env. It
+// records its state in the given kvstore of the source and sends the events
+// with the CloudEvents client and sink transport of the source.
+func newAddressAdapter(ctx context.Context, env *envConfig, vc EnvConfig, enrichment *Enrichment, ceClient cloudevents.Client,
+	transport http.RoundTripper, store kvstore.Interface) (*vAdapter, error) {
+	vcStore, err := newPrefixedKVStore(store, vc.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	vClient, _, err := soapWithKeepalive(ctx, vc)
+	if err != nil {
+		recordConnectionStatus(ctx, vcStore, err)
+		return nil, fmt.Errorf("unable to create vSphere client for %s: %w", vc.Address, err)
+	}
+
+	var rClient *rest.Client
+	if env.needsREST(enrichment) {
+		rClient, err = restWithKeepalive(ctx, vc)
+		if err != nil {
+			logout(vClient, nil)
+			recordConnectionStatus(ctx, vcStore, err)
+			return nil, fmt.Errorf("unable to create vSphere REST client for %s: %w", vc.Address, err)
+		}
+	}
+	recordConnectionStatus(ctx, vcStore, nil)
+
+	a, err := newVAdapter(ctx, env, enrichment, ceClient, transport, vcStore, vClient, rClient)
+	if err != nil {
+		logout(vClient, rClient)
+		return nil, err
+	}
+	a.Login = func(ctx context.Context) error {
+		return login(ctx, vc, vClient, rClient)
+	}
+	a.API = vc.API
+	a.Logout = func() {
+		logout(vClient, rClient)
+	}
+	if vc.CredentialProvider == "" || vc.CredentialProvider == CredentialProviderSecret {
+		a.SecretPath = vc.secretMountPath()
+	}
+	return a, nil
+}
+
+// fanInAdapter merges the event streams of the vCenters of a source. The
+// first adapter reads the vCenter of the source.
+type fanInAdapter []adapter.Adapter
+
+// Start implements adapter.Adapter. It runs the adapters of all vCenters
+// until ctx is done or the first one fails.
+func (f fanInAdapter) Start(ctx context.Context) error {
+	eg, egCtx := errgroup.WithContext(ctx)
+	for _, a := range f {
+		a := a
+		ctx := egCtx
+		if va, ok := a.(*vAdapter); ok {
+			ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With(zap.String("vcenter", va.Source)))
+		}
+		eg.Go(func() error {
+			return a.Start(ctx)
+		})
+	}
+	return eg.Wait()
+}
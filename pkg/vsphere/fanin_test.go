@@ -0,0 +1,111 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_newAddresses(t *testing.T) {
+	tests := []struct {
+		name      string
+		addresses string
+		want      int
+		wantErr   bool
+	}{
+		{name: "no addresses"},
+		{
+			name:      "addresses",
+			addresses: `[{"address":"https://vcenter-2.example.com/sdk","secretPath":"/var/bindings/vsphere-addresses/0","vault":{}},{"address":"https://vcenter-3.example.com/sdk","vault":{}}]`,
+			want:      2,
+		},
+		{name: "invalid json", addresses: `{"address":"https://vcenter-2.example.com/sdk"}`, wantErr: true},
+		{name: "missing address", addresses: `[{"secretPath":"/var/bindings/vsphere-addresses/0"}]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newAddresses(tt.addresses)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newAddresses() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("newAddresses() = %v, want %d addresses", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_prefixedKVStore(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeKVStore{dataChan: make(chan string, 1)}
+
+	vcStore, err := newPrefixedKVStore(&lockedKVStore{store: store}, "https://vcenter-2.example.com:8443/sdk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	primary := checkpoint{VCenter: "vcenter.example.com", LastEventKey: 1}
+	additional := checkpoint{VCenter: "vcenter-2.example.com:8443", LastEventKey: 2}
+	if err = store.Set(ctx, CheckpointKey, primary); err != nil {
+		t.Fatal(err)
+	}
+	if err = vcStore.Set(ctx, CheckpointKey, additional); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := store.data["vcenter-2.example.com_8443.checkpoint"]; !ok {
+		t.Errorf("kvstore keys = %v, want the checkpoint of the additional vCenter with its host as prefix", store.data)
+	}
+
+	var got checkpoint
+	if err = vcStore.Get(ctx, CheckpointKey, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.LastEventKey != additional.LastEventKey {
+		t.Errorf("Get() = %+v, want %+v", got, additional)
+	}
+	if err = store.Get(ctx, CheckpointKey, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.LastEventKey != primary.LastEventKey {
+		t.Errorf("Get() = %+v, want %+v", got, primary)
+	}
+}
+
+// adapterFunc implements adapter.Adapter
+type adapterFunc func(ctx context.Context) error
+
+func (f adapterFunc) Start(ctx context.Context) error {
+	return f(ctx)
+}
+
+func Test_fanInAdapter_Start(t *testing.T) {
+	failed := errors.New("failed")
+	stopped := make(chan struct{})
+
+	f := fanInAdapter{
+		adapterFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			close(stopped)
+			return ctx.Err()
+		}),
+		adapterFunc(func(ctx context.Context) error {
+			return failed
+		}),
+	}
+
+	if err := f.Start(context.Background()); !errors.Is(err, failed) {
+		t.Errorf("Start() error = %v, want %v", err, failed)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("Start() did not stop the adapters of the other vCenters")
+	}
+}
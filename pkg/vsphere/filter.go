@@ -0,0 +1,181 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker"
+	"github.com/google/cel-go/checker/decls"
+)
+
+// EventFilter selects the CloudEvents sent to the sink
+type EventFilter struct {
+	// EventTypes are glob patterns (see path.Match) matched against the
+	// CloudEvent type, e.g. com.vmware.vsphere.Alarm*. An event is sent if it
+	// matches any of the patterns. No patterns match all events.
+	EventTypes []string `json:"eventTypes,omitempty"`
+
+	// CEL is a CEL expression which must evaluate to true for an event to be
+	// sent, see CompileCELFilter. It is evaluated after the event types.
+	CEL string `json:"cel,omitempty"`
+
+	// program is the compiled CEL expression, nil if none
+	program cel.Program
+}
+
+func newEventFilter(filter string) (*EventFilter, error) {
+	var f EventFilter
+	if filter == "" {
+		return &f, nil
+	}
+
+	if err := json.Unmarshal([]byte(filter), &f); err != nil {
+		return nil, fmt.Errorf("unmarshal event filter: %w", err)
+	}
+	if err := f.compile(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// compile validates the event type patterns of the filter and compiles its
+// CEL expression, if any.
+func (f *EventFilter) compile() error {
+	for _, p := range f.EventTypes {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid event type pattern %q: %w", p, err)
+		}
+	}
+
+	if f.CEL != "" {
+		prg, err := CompileCELFilter(f.CEL)
+		if err != nil {
+			return err
+		}
+		f.program = prg
+	}
+	return nil
+}
+
+// CompileCELFilter compiles the given CEL expression of an event filter. The
+// expression is evaluated with the variables ce, a map of the CloudEvent
+// attributes and extensions by name, e.g. ce.type, and data, the payload
+// decoded as JSON, e.g. data.Vm.Name.startsWith("prod-"). It must return a
+// bool.
+func CompileCELFilter(expr string) (cel.Program, error) {
+	env, err := cel.NewEnv(cel.Declarations(
+		decls.NewVar("ce", decls.NewMapType(decls.String, decls.String)),
+		decls.NewVar("data", decls.Dyn),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create CEL environment: %w", err)
+	}
+
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("invalid CEL expression: %w", iss.Err())
+	}
+	if t := ast.ResultType(); t.GetPrimitive() != decls.Bool.GetPrimitive() && t.GetDyn() == nil {
+		return nil, fmt.Errorf("invalid CEL expression: must return a bool, not %s", checker.FormatCheckedType(t))
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CEL expression: %w", err)
+	}
+	return prg, nil
+}
+
+// Match returns true if the given event, created from the given vSphere
+// object, passes the filter. The object is optional, see matchCEL.
+func (f *EventFilter) Match(ev cloudevents.Event, obj interface{}) bool {
+	if f == nil {
+		return true
+	}
+	return f.matchType(ev) && f.matchCEL(ev, obj)
+}
+
+func (f *EventFilter) matchType(ev cloudevents.Event) bool {
+	if len(f.EventTypes) == 0 {
+		return true
+	}
+
+	for _, p := range f.EventTypes {
+		// patterns are validated when the filter is created
+		if ok, _ := path.Match(p, ev.Type()); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCEL returns true if the CEL expression of the filter, if any, returns
+// true for the given event, created from the given vSphere object, see
+// eventData. Events for which the expression fails, e.g. because of a missing
+// payload field, do not match.
+func (f *EventFilter) matchCEL(ev cloudevents.Event, obj interface{}) bool {
+	if f.program == nil {
+		return true
+	}
+
+	attrs := map[string]string{
+		"specversion": ev.SpecVersion(),
+		"type":        ev.Type(),
+		"source":      ev.Source(),
+		"id":          ev.ID(),
+	}
+	if s := ev.Subject(); s != "" {
+		attrs["subject"] = s
+	}
+	if t := ev.Time(); !t.IsZero() {
+		attrs["time"] = types.FormatTime(t)
+	}
+	if ct := ev.DataContentType(); ct != "" {
+		attrs["datacontenttype"] = ct
+	}
+	if ds := ev.DataSchema(); ds != "" {
+		attrs["dataschema"] = ds
+	}
+	for name, v := range ev.Extensions() {
+		if s, err := types.ToString(v); err == nil {
+			attrs[name] = s
+		}
+	}
+
+	out, _, err := f.program.Eval(map[string]interface{}{"ce": attrs, "data": eventData(ev, obj)})
+	if err != nil {
+		return false
+	}
+	match, ok := out.Value().(bool)
+	return ok && match
+}
+
+// eventData returns the payload of the given event decoded as JSON. Other
+// payloads, e.g. XML, are replaced by the JSON encoding of the given vSphere
+// object the event was created from, which is null if obj is nil.
+func eventData(ev cloudevents.Event, obj interface{}) interface{} {
+	payload := ev.Data()
+	if !isJSON(ev.DataContentType()) {
+		payload, _ = json.Marshal(obj)
+	}
+	var data interface{}
+	_ = json.Unmarshal(payload, &data)
+	return data
+}
+
+// isJSON returns true if the given content type is JSON
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == cloudevents.ApplicationJSON || strings.HasSuffix(mediaType, "+json"))
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"strconv"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newEventFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		want    int
+		wantErr bool
+	}{
+		{name: "empty filter"},
+		{name: "event types", filter: `{"eventTypes":["com.vmware.vsphere.alarm.*","com.vmware.vsphere.VmPoweredOnEvent"]}`, want: 2},
+		{name: "invalid json", filter: `{"eventTypes":"com.vmware.vsphere.alarm.*"}`, wantErr: true},
+		{name: "invalid pattern", filter: `{"eventTypes":["com.vmware.vsphere.[alarm"]}`, wantErr: true},
+		{name: "cel", filter: `{"cel":"data.Vm.Name.startsWith('prod-')"}`},
+		{name: "invalid cel", filter: `{"cel":"data.Vm.Name.startsWith("}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newEventFilter(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newEventFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(got.EventTypes) != tt.want {
+				t.Errorf("newEventFilter() got %d event types, want %d", len(got.EventTypes), tt.want)
+			}
+		})
+	}
+}
+
+func TestEventFilter_Match(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    *EventFilter
+		eventType string
+		want      bool
+	}{
+		{name: "nil filter", eventType: "com.vmware.vsphere.VmPoweredOnEvent", want: true},
+		{name: "no event types", filter: &EventFilter{}, eventType: "com.vmware.vsphere.VmPoweredOnEvent", want: true},
+		{
+			name:      "alarms only, alarm event",
+			filter:    &EventFilter{EventTypes: []string{"com.vmware.vsphere.alarm.*"}},
+			eventType: "com.vmware.vsphere.alarm.statuschanged",
+			want:      true,
+		},
+		{
+			name:      "alarms only, other event",
+			filter:    &EventFilter{EventTypes: []string{"com.vmware.vsphere.alarm.*"}},
+			eventType: "com.vmware.vsphere.VmPoweredOnEvent",
+			want:      false,
+		},
+		{
+			name:      "any pattern matches",
+			filter:    &EventFilter{EventTypes: []string{"com.vmware.vsphere.alarm.*", "com.vmware.vsphere.Vm*"}},
+			eventType: "com.vmware.vsphere.VmPoweredOnEvent",
+			want:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := cloudevents.NewEvent()
+			ev.SetType(tt.eventType)
+			if got := tt.filter.Match(ev, nil); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventFilter_MatchCEL(t *testing.T) {
+	tests := []struct {
+		name string
+		cel  string
+		data interface{}
+		want bool
+	}{
+		{name: "payload matches", cel: `data.Vm.Name.startsWith("prod-")`, data: map[string]interface{}{"Vm": map[string]string{"Name": "prod-db"}}, want: true},
+		{name: "payload does not match", cel: `data.Vm.Name.startsWith("prod-")`, data: map[string]interface{}{"Vm": map[string]string{"Name": "test-db"}}},
+		{name: "missing payload field", cel: `data.Vm.Name.startsWith("prod-")`, data: map[string]interface{}{"Host": map[string]string{"Name": "esx-1"}}},
+		{name: "attributes", cel: `ce.type == "com.vmware.vsphere.VmPoweredOnEvent" && ce.vsphereapiversion == "6.7"`, want: true},
+		{name: "missing attribute", cel: `ce.subject == "vm-1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newEventFilter(`{"eventTypes":["com.vmware.vsphere.Vm*"],"cel":` + strconv.Quote(tt.cel) + `}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ev := cloudevents.NewEvent()
+			ev.SetType("com.vmware.vsphere.VmPoweredOnEvent")
+			ev.SetSource("vcenter.example.com")
+			ev.SetExtension("vsphereapiversion", "6.7")
+			if tt.data != nil {
+				if err = ev.SetData(cloudevents.ApplicationJSON, tt.data); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := f.Match(ev, nil); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+
+			ev.SetType("com.vmware.vsphere.HostConnectedEvent")
+			if f.Match(ev, nil) {
+				t.Error("Match() = true for an event type not matching the filter")
+			}
+		})
+	}
+}
+
+func TestEventFilter_MatchCELObject(t *testing.T) {
+	f, err := newEventFilter(`{"cel":"data.Vm.Name.startsWith('prod-')"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]bool{"prod-db": true, "test-db": false} {
+		be := &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{
+			Vm: &types.VmEventArgument{EntityEventArgument: types.EntityEventArgument{Name: name}},
+		}}}
+		ev, err := newEventCloudEvent("vcenter.example.com", be, eventOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.Match(ev, be); got != want {
+			t.Errorf("Match() of XML event for VM %s = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// SinkHeadersVolumeName is the name of the volume holding the secret with
+	// additional sink headers
+	SinkHeadersVolumeName = "sink-headers"
+	// SinkHeadersMountPath is where the secret with additional sink headers is
+	// mounted in the adapter
+	SinkHeadersMountPath = "/var/run/vsphere/sink-headers"
+)
+
+// newSinkHeaders returns the HTTP headers to add to every request sent to the
+// sink. The given JSON-encoded map of static headers is merged with headers
+// read from the files in dir (file name as header name, content as value),
+// with the latter taking precedence. An empty dir is ignored.
+func newSinkHeaders(headers string, dir string) (http.Header, error) {
+	result := make(http.Header)
+
+	if headers != "" {
+		var static map[string]string
+		if err := json.Unmarshal([]byte(headers), &static); err != nil {
+			return nil, fmt.Errorf("unmarshal sink headers: %w", err)
+		}
+		for k, v := range static {
+			result.Set(k, v)
+		}
+	}
+
+	if dir == "" {
+		return result, nil
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read sink headers directory: %w", err)
+	}
+
+	for _, f := range files {
+		// skip the hidden files and directories of the secret volume
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		v, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read sink header %q: %w", f.Name(), err)
+		}
+		result.Set(f.Name(), strings.TrimSpace(string(v)))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_newSinkHeaders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink-headers")
+	if err != nil {
+		t.Fatalf("create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"Authorization": "Bearer s3cr3t\n",
+		"x-tenant":      "from-secret",
+		".hidden":       "ignored",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("write header file: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "..data"), 0o700); err != nil {
+		t.Fatalf("create data dir: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		headers string
+		dir     string
+		want    http.Header
+		wantErr bool
+	}{
+		{
+			name: "no headers",
+			want: http.Header{},
+		},
+		{
+			name:    "static headers",
+			headers: `{"x-tenant":"acme","X-Source":"vcenter"}`,
+			want:    http.Header{"X-Tenant": {"acme"}, "X-Source": {"vcenter"}},
+		},
+		{
+			name:    "secret headers take precedence",
+			headers: `{"x-tenant":"acme"}`,
+			dir:     dir,
+			want:    http.Header{"X-Tenant": {"from-secret"}, "Authorization": {"Bearer s3cr3t"}},
+		},
+		{
+			name:    "invalid static headers",
+			headers: `["x-tenant"]`,
+			wantErr: true,
+		},
+		{
+			name:    "missing directory",
+			dir:     filepath.Join(dir, "missing"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newSinkHeaders(tt.headers, tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSinkHeaders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newSinkHeaders() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// HealthPort is the port of the probes of the adapter in the Deployments
+	// of the sources
+	HealthPort = 8080
+	// ReadinessPath is the path of the readiness probe of the adapter
+	ReadinessPath = "/readyz"
+	// LivenessPath is the path of the liveness probe of the adapter
+	LivenessPath = "/healthz"
+)
+
+var (
+	// the adapter is not ready if it did not poll vCenter events for longer
+	readinessPollTimeout = 2 * time.Minute
+	// the adapter is considered wedged if it did not poll vCenter events for
+	// longer, e.g. while failing to log in again
+	livenessPollTimeout = 10 * time.Minute
+)
+
+// health records the state of the adapter reported by its probes.
+type health struct {
+	mu sync.Mutex
+	// started is when the adapter started, which counts as the last poll
+	// until the first one
+	started time.Time
+	// session is true while the adapter has a valid vCenter session
+	session bool
+	// lastPoll is when vCenter events were last read successfully
+	lastPoll time.Time
+	// lastEvent is the vCenter time of the last event read, for heartbeats
+	lastEvent time.Time
+}
+
+// markSession records whether the adapter has a valid vCenter session. It is
+// a no-op on a nil health, like markPolled.
+func (h *health) markSession(valid bool) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.session = valid
+}
+
+// markPolled records a successful poll of vCenter events, which requires a
+// valid session.
+func (h *health) markPolled(now time.Time) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.session = true
+	h.lastPoll = now
+}
+
+// markEvent records the vCenter time of the last event read
+func (h *health) markEvent(created time.Time) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastEvent = created
+}
+
+// ready returns an error unless the adapter has a valid vCenter session and
+// polled events recently.
+func (h *health) ready(now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.session {
+		return errors.New("no valid vCenter session")
+	}
+	if h.lastPoll.IsZero() {
+		return errors.New("vCenter events not polled yet")
+	}
+	if d := now.Sub(h.lastPoll); d > readinessPollTimeout {
+		return fmt.Errorf("vCenter events not polled for %v", d.Round(time.Second))
+	}
+	return nil
+}
+
+// alive returns an error if the adapter did not poll events for so long that
+// it is considered wedged.
+func (h *health) alive(now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	last := h.lastPoll
+	if last.IsZero() {
+		last = h.started
+	}
+	if d := now.Sub(last); d > livenessPollTimeout {
+		return fmt.Errorf("vCenter events not polled for %v", d.Round(time.Second))
+	}
+	return nil
+}
+
+// ServeHTTP implements http.Handler for the readiness and liveness probes.
+func (h *health) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var check func(time.Time) error
+	switch r.URL.Path {
+	case ReadinessPath:
+		check = h.ready
+	case LivenessPath:
+		check = h.alive
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := check(time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+// serveHealth serves the probes of the given health on the given port until
+// ctx is done.
+func serveHealth(ctx context.Context, port int, h *health) {
+	logger := logging.FromContext(ctx)
+
+	srv := &http.Server{
+		Addr:    net.JoinHostPort("", strconv.Itoa(port)),
+		Handler: h,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Errorw("health server failed", zap.Error(err))
+	}
+}
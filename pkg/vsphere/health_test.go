@@ -0,0 +1,117 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	start := time.Now()
+
+	tests := []struct {
+		name      string
+		setup     func(h *health)
+		at        time.Duration
+		wantReady bool
+		wantAlive bool
+	}{{
+		name:      "not polled yet",
+		at:        time.Minute,
+		wantReady: false,
+		wantAlive: true,
+	}, {
+		name:      "never polled",
+		at:        livenessPollTimeout + time.Second,
+		wantReady: false,
+		wantAlive: false,
+	}, {
+		name: "polled recently",
+		setup: func(h *health) {
+			h.markPolled(start.Add(time.Minute))
+		},
+		at:        2 * time.Minute,
+		wantReady: true,
+		wantAlive: true,
+	}, {
+		name: "session lost",
+		setup: func(h *health) {
+			h.markPolled(start.Add(time.Minute))
+			h.markSession(false)
+		},
+		at:        2 * time.Minute,
+		wantReady: false,
+		wantAlive: true,
+	}, {
+		name: "poll stale",
+		setup: func(h *health) {
+			h.markPolled(start)
+		},
+		at:        readinessPollTimeout + time.Second,
+		wantReady: false,
+		wantAlive: true,
+	}, {
+		name: "wedged",
+		setup: func(h *health) {
+			h.markPolled(start)
+		},
+		at:        livenessPollTimeout + time.Second,
+		wantReady: false,
+		wantAlive: false,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &health{started: start, session: true}
+			if tt.setup != nil {
+				tt.setup(h)
+			}
+
+			now := start.Add(tt.at)
+			if err := h.ready(now); (err == nil) != tt.wantReady {
+				t.Errorf("ready() = %v, want ready %v", err, tt.wantReady)
+			}
+			if err := h.alive(now); (err == nil) != tt.wantAlive {
+				t.Errorf("alive() = %v, want alive %v", err, tt.wantAlive)
+			}
+		})
+	}
+}
+
+func TestHealthServeHTTP(t *testing.T) {
+	h := &health{started: time.Now(), session: true}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{ReadinessPath, http.StatusServiceUnavailable},
+		{LivenessPath, http.StatusOK},
+		{"/other", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.path, w.Code, tt.want)
+		}
+	}
+
+	h.markPolled(time.Now())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("GET %s after poll = %d, want %d", ReadinessPath, w.Code, http.StatusOK)
+	}
+
+	// nil health is a no-op when marked
+	var nh *health
+	nh.markPolled(time.Now())
+	nh.markSession(false)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// HeartbeatEventType is the type of the heartbeat events of a source
+	HeartbeatEventType = "com.vmware.vsphere.heartbeat"
+
+	// HeartbeatDefaultInterval is the interval of heartbeat events if enabled
+	// without an interval
+	HeartbeatDefaultInterval = time.Minute
+)
+
+// Heartbeat is the payload of the heartbeat events, which the adapter sends
+// periodically independent of vCenter events, so that a dead source can be
+// told apart from an idle vCenter.
+type Heartbeat struct {
+	// Connected is true while the adapter has a valid vCenter session
+	Connected bool `json:"connected"`
+	// LastPollTime is when vCenter events were last read successfully
+	LastPollTime time.Time `json:"lastPollTime,omitempty"`
+	// LastEventTime is the vCenter time of the last event read
+	LastEventTime time.Time `json:"lastEventTime,omitempty"`
+	// PollLagSeconds is the time since the last poll, or since the adapter
+	// started before the first one. It grows while the adapter is wedged.
+	PollLagSeconds int64 `json:"pollLagSeconds"`
+}
+
+// heartbeat returns the heartbeat of the given health at the given time
+func (h *health) heartbeat(now time.Time) Heartbeat {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	last := h.lastPoll
+	if last.IsZero() {
+		last = h.started
+	}
+	return Heartbeat{
+		Connected:      h.session,
+		LastPollTime:   h.lastPoll,
+		LastEventTime:  h.lastEvent,
+		PollLagSeconds: int64(now.Sub(last).Seconds()),
+	}
+}
+
+// newHeartbeatCloudEvent returns the heartbeat event of the given source with
+// the given payload
+func newHeartbeatCloudEvent(source string, hb Heartbeat, now time.Time) (cloudevents.Event, error) {
+	ev := cloudevents.NewEvent(cloudevents.VersionV1)
+	ev.SetSource(source)
+	ev.SetType(HeartbeatEventType)
+	ev.SetID(uuid.New().String())
+	ev.SetTime(now.UTC())
+
+	if err := ev.SetData(cloudevents.ApplicationJSON, hb); err != nil {
+		return ev, fmt.Errorf("set data on event: %w", err)
+	}
+	return ev, nil
+}
+
+// sendHeartbeats sends a heartbeat event at the given interval until ctx is
+// done. Heartbeats are not filtered, transformed or translated into the
+// output format, and failures to send them are only logged.
+func (a *vAdapter) sendHeartbeats(ctx context.Context, interval time.Duration) {
+	logger := logging.FromContext(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case now := <-ticker.C:
+			ev, err := newHeartbeatCloudEvent(a.Source, a.Health.heartbeat(now), now)
+			if err != nil {
+				logger.Errorw("failed to create heartbeat cloudevent", zap.Error(err))
+				continue
+			}
+			if result := a.send(ctx, ev, extensionContext{}); !cloudevents.IsACK(result) {
+				logger.Warnw("failed to send heartbeat cloudevent", zap.Error(result))
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+// eventChanRoundTripper passes the events sent through it into a channel
+type eventChanRoundTripper chan *event.Event
+
+func (c eventChanRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	e, err := binding.ToEvent(context.TODO(), cehttp.NewMessageFromHttpRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case c <- e:
+		return &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody}, nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+func Test_health_heartbeat(t *testing.T) {
+	started := time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)
+	h := &health{started: started, session: true}
+
+	got := h.heartbeat(started.Add(90 * time.Second))
+	want := Heartbeat{Connected: true, PollLagSeconds: 90}
+	if got != want {
+		t.Errorf("heartbeat() before the first poll = %+v, want %+v", got, want)
+	}
+
+	polled, created := started.Add(time.Minute), started.Add(-time.Hour)
+	h.markPolled(polled)
+	h.markEvent(created)
+	h.markSession(false)
+	got = h.heartbeat(polled.Add(10 * time.Second))
+	want = Heartbeat{LastPollTime: polled, LastEventTime: created, PollLagSeconds: 10}
+	if got != want {
+		t.Errorf("heartbeat() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_vAdapter_sendHeartbeats(t *testing.T) {
+	rt := make(eventChanRoundTripper, 1)
+	p, err := cehttp.New(cehttp.WithRoundTripper(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &vAdapter{CEClient: c, Source: source, Health: &health{started: time.Now(), session: true}}
+	ctx, cancel := context.WithCancel(cecontext.WithTarget(context.Background(), "fake.example.com"))
+	defer cancel()
+	go a.sendHeartbeats(ctx, time.Millisecond)
+
+	var ev *event.Event
+	select {
+	case ev = <-rt:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sendHeartbeats() did not send a heartbeat")
+	}
+	cancel()
+
+	if ev.Type() != HeartbeatEventType || ev.Source() != source {
+		t.Errorf("heartbeat type = %s, source = %s, want %s from %s", ev.Type(), ev.Source(), HeartbeatEventType, source)
+	}
+	var hb Heartbeat
+	if err = ev.DataAs(&hb); err != nil {
+		t.Fatal(err)
+	}
+	if !hb.Connected {
+		t.Errorf("heartbeat = %+v, want connected", hb)
+	}
+	if _, last := a.Deliveries.take(); !last.IsZero() {
+		t.Errorf("heartbeat counted as delivered event at %v", last)
+	}
+}
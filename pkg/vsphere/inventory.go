@@ -0,0 +1,272 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// event class used for inventory property change events
+	inventoryEventClass = "inventory"
+	// type prefix of inventory property change events, e.g.
+	// com.vmware.vsphere.inventory.VirtualMachine.changed
+	inventoryEventTypePrefix = "com.vmware.vsphere.inventory."
+)
+
+// inventoryBackoff is the backoff of retrying property change events the sink
+// fails to accept
+var inventoryBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Cap:      30 * time.Second,
+}
+
+type inventoryEnvConfig struct {
+	adapter.EnvConfig
+
+	// Watches is the JSON-encoded list of property watches
+	Watches string `envconfig:"VSPHERE_INVENTORY_WATCHES" required:"true"`
+}
+
+func NewInventoryEnvConfig() adapter.EnvConfigAccessor {
+	return &inventoryEnvConfig{}
+}
+
+// PropertyWatch selects the properties to watch for a managed object type
+type PropertyWatch struct {
+	Type       string   `json:"type"`
+	Properties []string `json:"properties"`
+}
+
+// PropertyChangeEventData is the JSON payload of inventory property change
+// CloudEvents
+type PropertyChangeEventData struct {
+	Object  ObjectRef        `json:"object" protobuf:"1"`
+	Changes []PropertyChange `json:"changes" protobuf:"2"`
+}
+
+// PropertyChange is a single changed property of a managed object
+type PropertyChange struct {
+	Name string `json:"name" protobuf:"1"`
+	// Op is one of add, remove, assign, indirectRemove
+	Op    string      `json:"op" protobuf:"2"`
+	Value interface{} `json:"value,omitempty" protobuf:"3"`
+}
+
+// inventoryAdapter implements the VSphereInventorySource adapter which sends
+// property changes of vSphere inventory objects to a Sink.
+type inventoryAdapter struct {
+	Logger   *zap.SugaredLogger
+	Source   string
+	VClient  *govmomi.Client
+	CEClient cloudevents.Client
+	Watches  []PropertyWatch
+}
+
+func NewInventoryAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, ceClient cloudevents.Client) adapter.Adapter {
+	env := processed.(*inventoryEnvConfig)
+	logger := logging.FromContext(ctx)
+
+	var watches []PropertyWatch
+	if err := json.Unmarshal([]byte(env.Watches), &watches); err != nil {
+		logger.Fatalf("could not read property watches: %v", err)
+	}
+
+	vClient, err := NewSOAPClient(ctx)
+	if err != nil {
+		logger.Fatalf("unable to create vSphere client: %v", err)
+	}
+
+	source := vClient.URL().Host
+	if source == "" {
+		logger.Fatal("unable to determine vSphere client source: empty host")
+	}
+
+	return &inventoryAdapter{
+		Logger:   logger,
+		Source:   source,
+		VClient:  vClient,
+		CEClient: ceClient,
+		Watches:  watches,
+	}
+}
+
+// Start implements adapter.Adapter
+func (a *inventoryAdapter) Start(ctx context.Context) error {
+	defer func() {
+		// using fresh ctx to avoid canceled error during logout
+		_ = a.VClient.Logout(context.Background()) // best effort, ignoring error
+	}()
+
+	return a.run(ctx)
+}
+
+// run waits for changes of the watched properties of all inventory objects of
+// the watched types using the incremental WaitForUpdatesEx API of the property
+// collector and sends a CloudEvent for every modified object. The initial
+// state of the inventory, as well as objects entering or leaving the
+// inventory, are not sent.
+func (a *inventoryAdapter) run(ctx context.Context) error {
+	kinds := make([]string, 0, len(a.Watches))
+	for _, w := range a.Watches {
+		kinds = append(kinds, w.Type)
+	}
+
+	root := a.VClient.ServiceContent.RootFolder
+	v, err := view.NewManager(a.VClient.Client).CreateContainerView(ctx, root, kinds, true)
+	if err != nil {
+		return fmt.Errorf("create container view: %w", err)
+	}
+	defer func() {
+		_ = v.Destroy(context.Background())
+	}()
+
+	pc, err := property.DefaultCollector(a.VClient.Client).Create(ctx)
+	if err != nil {
+		return fmt.Errorf("create property collector: %w", err)
+	}
+	defer func() {
+		_ = pc.Destroy(context.Background())
+	}()
+
+	filter := newInventoryFilter(v.Reference(), a.Watches)
+	if err = pc.CreateFilter(ctx, filter.CreateFilter); err != nil {
+		return fmt.Errorf("create property filter: %w", err)
+	}
+
+	// like property.WaitForUpdates, but the version of the update sets is
+	// needed for the IDs of the events
+	req := types.WaitForUpdatesEx{This: pc.Reference(), Options: filter.Options}
+	for {
+		res, err := methods.WaitForUpdatesEx(ctx, a.VClient.Client, &req)
+		if err != nil {
+			if ctx.Err() == context.Canceled {
+				return pc.CancelWaitForUpdates(context.Background())
+			}
+			return err
+		}
+
+		set := res.Returnval
+		if set == nil {
+			continue
+		}
+		req.Version = set.Version
+
+		for _, fs := range set.FilterSet {
+			for _, u := range fs.ObjectSet {
+				if u.Kind != types.ObjectUpdateKindModify {
+					continue
+				}
+				if err := a.send(ctx, set.Version, u); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// send sends a CloudEvent for the given object update of the given version of
+// the property collector. Since property changes cannot be read again, events
+// the sink fails to accept are retried with backoff until ctx is done, which
+// delays the later updates.
+func (a *inventoryAdapter) send(ctx context.Context, version string, u types.ObjectUpdate) error {
+	logger := logging.FromContext(ctx)
+
+	ev, err := newPropertyChangeCloudEvent(a.Source, version, u)
+	if err != nil {
+		logger.Errorw("failed to create property change cloudevent", "object", u.Obj.Value, "error", err)
+		return nil
+	}
+
+	backoff := inventoryBackoff
+	for {
+		result := a.CEClient.Send(ctx, ev)
+		if cloudevents.IsACK(result) {
+			return nil
+		}
+		logger.Warnw("failed to send property change cloudevent, retrying", "object", u.Obj.Value, "error", result)
+
+		timer := time.NewTimer(backoff.Step())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// newInventoryFilter returns a property collector filter for the given
+// properties of all objects in the given container view
+func newInventoryFilter(view types.ManagedObjectReference, watches []PropertyWatch) *property.WaitFilter {
+	filter := new(property.WaitFilter)
+	filter.Spec.ObjectSet = []types.ObjectSpec{{
+		Obj:  view,
+		Skip: types.NewBool(true),
+		SelectSet: []types.BaseSelectionSpec{
+			&types.TraversalSpec{
+				Type: "ContainerView",
+				Path: "view",
+			},
+		},
+	}}
+
+	for _, w := range watches {
+		filter.Spec.PropSet = append(filter.Spec.PropSet, types.PropertySpec{
+			Type:    w.Type,
+			PathSet: w.Properties,
+		})
+	}
+	return filter
+}
+
+// newPropertyChangeCloudEvent converts the given object update of the given
+// version of the property collector into a CloudEvent, e.g. of type
+// com.vmware.vsphere.inventory.VirtualMachine.changed. Its ID is derived from
+// the object and the version, so that retries of the event keep its ID.
+func newPropertyChangeCloudEvent(source, version string, u types.ObjectUpdate) (cloudevents.Event, error) {
+	ev := cloudevents.NewEvent(cloudevents.VersionV1)
+	ev.SetSource(source)
+	ev.SetType(inventoryEventTypePrefix + u.Obj.Type + ".changed")
+	setDataSchema(&ev, schemaPropertyChange)
+	ev.SetExtension("EventClass", inventoryEventClass)
+	ev.SetSubject(u.Obj.Value)
+	ev.SetID(fmt.Sprintf("%s-%s-%s", u.Obj.Type, u.Obj.Value, version))
+	// property changes are not timestamped by vCenter
+	ev.SetTime(time.Now().UTC())
+
+	data := PropertyChangeEventData{
+		Object: ObjectRef{Type: u.Obj.Type, Value: u.Obj.Value},
+	}
+	for _, c := range u.ChangeSet {
+		data.Changes = append(data.Changes, PropertyChange{
+			Name:  c.Name,
+			Op:    string(c.Op),
+			Value: c.Val,
+		})
+	}
+
+	if err := ev.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		return ev, fmt.Errorf("set data on event: %w", err)
+	}
+	return ev, nil
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// chanRoundTripper passes all received events to a channel
+type chanRoundTripper chan *event.Event
+
+func (c chanRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	e, err := binding.ToEvent(context.TODO(), cehttp.NewMessageFromHttpRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	c <- e
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func Test_inventoryAdapter_run(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		ctx = cecontext.WithTarget(ctx, "fake.example.com")
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		events := make(chanRoundTripper, 10)
+		p, err := cehttp.New(cehttp.WithRoundTripper(events))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := client.New(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a := &inventoryAdapter{
+			Logger:   zaptest.NewLogger(t).Sugar(),
+			Source:   source,
+			VClient:  &govmomi.Client{Client: vim, SessionManager: session.NewManager(vim)},
+			CEClient: c,
+			Watches:  []PropertyWatch{{Type: "VirtualMachine", Properties: []string{"runtime.powerState"}}},
+		}
+
+		runErr := make(chan error, 1)
+		go func() {
+			runErr <- a.run(ctx)
+		}()
+
+		vm, err := find.NewFinder(vim).VirtualMachine(ctx, "DC0_H0_VM0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the initial state is not sent, so retry until the change is observed
+		var got *event.Event
+		timeout := time.After(5 * time.Second)
+		for got == nil {
+			task, err := vm.PowerOff(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = task.Wait(ctx)
+
+			select {
+			case got = <-events:
+			case <-time.After(100 * time.Millisecond):
+				task, err = vm.PowerOn(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_ = task.Wait(ctx)
+			case <-timeout:
+				t.Fatal("timed out waiting for property change event")
+			}
+		}
+
+		if got.Type() != "com.vmware.vsphere.inventory.VirtualMachine.changed" {
+			t.Errorf("Type() = %s", got.Type())
+		}
+		if got.Subject() != vm.Reference().Value {
+			t.Errorf("Subject() = %s, want %s", got.Subject(), vm.Reference().Value)
+		}
+
+		var data PropertyChangeEventData
+		if err := got.DataAs(&data); err != nil {
+			t.Fatal(err)
+		}
+		if len(data.Changes) != 1 || data.Changes[0].Name != "runtime.powerState" {
+			t.Errorf("unexpected changes: %+v", data.Changes)
+		}
+
+		cancel()
+		if err := <-runErr; err != nil && err != context.Canceled {
+			t.Errorf("run() error = %v", err)
+		}
+	})
+}
+
+// failingRoundTripper fails the first requests and then accepts all
+// requests, recording the IDs of the received events
+type failingRoundTripper struct {
+	failures int
+	ids      []string
+}
+
+func (f *failingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	e, err := binding.ToEvent(context.TODO(), cehttp.NewMessageFromHttpRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	f.ids = append(f.ids, e.ID())
+	if len(f.ids) <= f.failures {
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+func Test_inventoryAdapter_send(t *testing.T) {
+	backoff := inventoryBackoff
+	defer func() { inventoryBackoff = backoff }()
+	inventoryBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1}
+
+	u := types.ObjectUpdate{
+		Kind: types.ObjectUpdateKindModify,
+		Obj:  types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"},
+	}
+
+	t.Run("retried until accepted", func(t *testing.T) {
+		rt := &failingRoundTripper{failures: 2}
+		a := newTestInventoryAdapter(t, rt)
+		if err := a.send(cecontext.WithTarget(context.Background(), "http://fake.example.com"), "3", u); err != nil {
+			t.Fatalf("send() error = %v", err)
+		}
+		want := []string{"VirtualMachine-vm-1-3", "VirtualMachine-vm-1-3", "VirtualMachine-vm-1-3"}
+		if diff := cmp.Diff(want, rt.ids); diff != "" {
+			t.Errorf("sent IDs (-want, +got) = %v", diff)
+		}
+	})
+
+	t.Run("given up when canceled", func(t *testing.T) {
+		rt := &failingRoundTripper{failures: 1000}
+		a := newTestInventoryAdapter(t, rt)
+		ctx, cancel := context.WithTimeout(cecontext.WithTarget(context.Background(), "http://fake.example.com"), 50*time.Millisecond)
+		defer cancel()
+		if err := a.send(ctx, "3", u); err == nil {
+			t.Error("send() error = nil, want error of canceled context")
+		}
+	})
+}
+
+func newTestInventoryAdapter(t *testing.T, rt http.RoundTripper) *inventoryAdapter {
+	p, err := cehttp.New(cehttp.WithRoundTripper(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &inventoryAdapter{Logger: zaptest.NewLogger(t).Sugar(), Source: source, CEClient: c}
+}
+
+func Test_newPropertyChangeCloudEvent(t *testing.T) {
+	u := types.ObjectUpdate{
+		Kind: types.ObjectUpdateKindModify,
+		Obj:  types.ManagedObjectReference{Type: "HostSystem", Value: "host-1"},
+		ChangeSet: []types.PropertyChange{{
+			Name: "runtime.connectionState",
+			Op:   types.PropertyChangeOpAssign,
+			Val:  types.HostSystemConnectionStateDisconnected,
+		}},
+	}
+
+	ev, err := newPropertyChangeCloudEvent(source, "7", u)
+	if err != nil {
+		t.Fatalf("newPropertyChangeCloudEvent() error = %v", err)
+	}
+	if err := ev.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if want := "com.vmware.vsphere.inventory.HostSystem.changed"; ev.Type() != want {
+		t.Errorf("Type() = %s, want %s", ev.Type(), want)
+	}
+	if want := "HostSystem-host-1-7"; ev.ID() != want {
+		t.Errorf("ID() = %s, want %s", ev.ID(), want)
+	}
+
+	var data PropertyChangeEventData
+	if err := ev.DataAs(&data); err != nil {
+		t.Fatal(err)
+	}
+	want := PropertyChange{Name: "runtime.connectionState", Op: "assign", Value: "disconnected"}
+	if len(data.Changes) != 1 || data.Changes[0] != want {
+		t.Errorf("Changes = %+v, want [%+v]", data.Changes, want)
+	}
+}
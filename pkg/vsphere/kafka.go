@@ -0,0 +1,220 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+	"github.com/cloudevents/sdk-go/protocol/kafka_sarama/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/xdg-go/scram"
+)
+
+// SASL mechanisms to authenticate with the Kafka brokers
+const (
+	KafkaSASLPlain       = "PLAIN"
+	KafkaSASLSCRAMSHA256 = "SCRAM-SHA-256"
+	KafkaSASLSCRAMSHA512 = "SCRAM-SHA-512"
+)
+
+const (
+	// KafkaSecretVolumeName is the name of the volume holding the secret with
+	// the SASL credentials for the Kafka brokers
+	KafkaSecretVolumeName = "kafka-credentials"
+	// KafkaSecretMountPath is where the secret with the SASL credentials for
+	// the Kafka brokers is mounted in the adapter
+	KafkaSecretMountPath = "/var/bindings/kafka"
+	// KafkaCACertsVolumeName is the name of the volume holding the configmap
+	// with additional CA certificates for the Kafka brokers
+	KafkaCACertsVolumeName = "kafka-ca-certs"
+	// KafkaCACertsMountPath is where the configmap with additional CA
+	// certificates for the Kafka brokers is mounted in the adapter
+	KafkaCACertsMountPath = "/var/run/vsphere/kafka-ca-certs"
+)
+
+// KafkaConfig configures the delivery of events to a Kafka topic instead of
+// the sink.
+type KafkaConfig struct {
+	// BootstrapServers are the host:port addresses of the brokers
+	BootstrapServers []string `json:"bootstrapServers"`
+	// Topic is the topic the events are written to
+	Topic string `json:"topic"`
+	// SASLMechanism is the SASL mechanism to authenticate with, empty to
+	// connect unauthenticated
+	SASLMechanism string `json:"saslMechanism,omitempty"`
+	// SecretPath is the directory of the mounted secret with the username and
+	// password for SASL
+	SecretPath string `json:"secretPath,omitempty"`
+	// TLS enables TLS for the connections to the brokers
+	TLS bool `json:"tls,omitempty"`
+	// CACertsPath is the directory of a mounted configmap with CA certificates
+	// trusted in addition to the system roots, empty for the system roots
+	CACertsPath string `json:"caCertsPath,omitempty"`
+}
+
+// newKafkaConfig returns the Kafka configuration in the given JSON, or nil if
+// s is empty.
+func newKafkaConfig(s string) (*KafkaConfig, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var c KafkaConfig
+	if err := json.Unmarshal([]byte(s), &c); err != nil {
+		return nil, fmt.Errorf("unmarshal kafka config: %w", err)
+	}
+	if len(c.BootstrapServers) == 0 {
+		return nil, errors.New("invalid kafka config: no bootstrap servers")
+	}
+	if c.Topic == "" {
+		return nil, errors.New("invalid kafka config: empty topic")
+	}
+	return &c, nil
+}
+
+// saramaConfig returns the configuration of the Kafka producer, reading the
+// SASL credentials and CA certificates from their mounted directories and
+// restricting TLS with the given configuration.
+func (c *KafkaConfig) saramaConfig(ctx context.Context, tlsConfig TLSConfig) (*sarama.Config, error) {
+	cfg := sarama.NewConfig()
+	// record headers, used for the attributes in binary mode, require 0.11
+	cfg.Version = sarama.V2_0_0_0
+	cfg.Producer.RequiredAcks = sarama.WaitForAll
+	cfg.Producer.Return.Successes = true
+
+	if c.SASLMechanism != "" {
+		user, err := readUserInfo(ctx, secretProvider(c.SecretPath))
+		if err != nil {
+			return nil, fmt.Errorf("read kafka credentials: %w", err)
+		}
+		password, _ := user.Password()
+		cfg.Net.SASL.Enable = true
+		cfg.Net.SASL.User = user.Username()
+		cfg.Net.SASL.Password = password
+
+		switch c.SASLMechanism {
+		case KafkaSASLPlain:
+			cfg.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		case KafkaSASLSCRAMSHA256:
+			cfg.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+			cfg.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return &scramClient{hash: scram.HashGeneratorFcn(sha256.New)}
+			}
+		case KafkaSASLSCRAMSHA512:
+			cfg.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+			cfg.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return &scramClient{hash: scram.HashGeneratorFcn(sha512.New)}
+			}
+		default:
+			return nil, fmt.Errorf("unsupported kafka SASL mechanism %q", c.SASLMechanism)
+		}
+	}
+
+	if c.TLS {
+		t := &tls.Config{}
+		if c.CACertsPath != "" {
+			pool, err := newSinkCertPool(c.CACertsPath)
+			if err != nil {
+				return nil, err
+			}
+			t.RootCAs = pool
+		}
+		if err := tlsConfig.apply(t); err != nil {
+			return nil, err
+		}
+		cfg.Net.TLS.Enable = true
+		cfg.Net.TLS.Config = t
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid kafka config: %w", err)
+	}
+	return cfg, nil
+}
+
+// newKafkaClient returns a CloudEvents client writing the events to the topic
+// of the given configuration, with the CloudEvents overrides and the sink TLS
+// restrictions of env. The producer does not buffer events, so the client needs
+// no closing before the adapter exits.
+func (env *envConfig) newKafkaClient(ctx context.Context, c *KafkaConfig) (cloudevents.Client, error) {
+	cfg, err := c.saramaConfig(ctx, TLSConfig{MinVersion: env.SinkTLSMinVersion, CipherSuites: env.SinkTLSCipherSuites})
+	if err != nil {
+		return nil, err
+	}
+	sender, err := kafka_sarama.NewSender(c.BootstrapServers, cfg, c.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("create kafka producer: %w", err)
+	}
+	overrides, err := env.GetCloudEventOverrides()
+	if err != nil {
+		return nil, fmt.Errorf("read CloudEvents overrides: %w", err)
+	}
+	var extensions map[string]string
+	if overrides != nil {
+		extensions = overrides.Extensions
+	}
+	return newKafkaTopicClient(sender, extensions)
+}
+
+// newKafkaTopicClient returns a CloudEvents client writing the events to the
+// topic with the given sender, after applying the given extensions of the
+// CloudEvents overrides like the client of the adapter. Closing the sender
+// closes the client.
+func newKafkaTopicClient(sender *kafka_sarama.Sender, extensions map[string]string) (cloudevents.Client, error) {
+	c, err := cloudevents.NewClient(sender, cloudevents.WithTimeNow(), cloudevents.WithUUIDs())
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaClient{Client: c, extensions: extensions}, nil
+}
+
+// kafkaClient is a CloudEvents client writing to a Kafka topic instead of the
+// sink
+type kafkaClient struct {
+	cloudevents.Client
+	extensions map[string]string
+}
+
+func (c *kafkaClient) Send(ctx context.Context, ev event.Event) protocol.Result {
+	for k, v := range c.extensions {
+		ev.SetExtension(k, v)
+	}
+	return c.Client.Send(ctx, ev)
+}
+
+// scramClient implements sarama.SCRAMClient with the given hash
+type scramClient struct {
+	hash scram.HashGeneratorFcn
+	conv *scram.ClientConversation
+}
+
+var _ sarama.SCRAMClient = (*scramClient)(nil)
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.hash.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.conv = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conv.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.conv.Done()
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/cloudevents/sdk-go/protocol/kafka_sarama/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_newKafkaConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		kafka   string
+		want    *KafkaConfig
+		wantErr string
+	}{{
+		name: "none",
+	}, {
+		name:  "with SASL and TLS",
+		kafka: `{"bootstrapServers":["kafka.example.com:9093"],"topic":"vsphere.events","saslMechanism":"PLAIN","secretPath":"/var/bindings/kafka","tls":true}`,
+		want: &KafkaConfig{
+			BootstrapServers: []string{"kafka.example.com:9093"},
+			Topic:            "vsphere.events",
+			SASLMechanism:    KafkaSASLPlain,
+			SecretPath:       KafkaSecretMountPath,
+			TLS:              true,
+		},
+	}, {
+		name:    "no bootstrap servers",
+		kafka:   `{"topic":"vsphere.events"}`,
+		wantErr: "no bootstrap servers",
+	}, {
+		name:    "empty topic",
+		kafka:   `{"bootstrapServers":["kafka.example.com:9092"]}`,
+		wantErr: "empty topic",
+	}, {
+		name:    "malformed",
+		kafka:   `["kafka.example.com:9092"]`,
+		wantErr: "unmarshal kafka config",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newKafkaConfig(tt.kafka)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("newKafkaConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("newKafkaConfig() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestKafkaConfig_saramaConfig(t *testing.T) {
+	secretDir := t.TempDir()
+	for k, v := range map[string]string{
+		corev1.BasicAuthUsernameKey: "producer",
+		corev1.BasicAuthPasswordKey: "s3cr3t",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(secretDir, k), []byte(v), 0o600); err != nil {
+			t.Fatalf("write secret key: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		config    KafkaConfig
+		tlsConfig TLSConfig
+		check     func(t *testing.T, cfg *sarama.Config)
+		wantErr   string
+	}{{
+		name: "unauthenticated",
+		check: func(t *testing.T, cfg *sarama.Config) {
+			if cfg.Net.SASL.Enable || cfg.Net.TLS.Enable {
+				t.Errorf("SASL = %v, TLS = %v, want neither", cfg.Net.SASL.Enable, cfg.Net.TLS.Enable)
+			}
+		},
+	}, {
+		name:   "SASL PLAIN",
+		config: KafkaConfig{SASLMechanism: KafkaSASLPlain, SecretPath: secretDir},
+		check: func(t *testing.T, cfg *sarama.Config) {
+			if cfg.Net.SASL.Mechanism != sarama.SASLTypePlaintext || cfg.Net.SASL.User != "producer" || cfg.Net.SASL.Password != "s3cr3t" {
+				t.Errorf("SASL = %s %s:%s, want PLAIN producer:s3cr3t", cfg.Net.SASL.Mechanism, cfg.Net.SASL.User, cfg.Net.SASL.Password)
+			}
+		},
+	}, {
+		name:   "SASL SCRAM-SHA-512 over TLS",
+		config: KafkaConfig{SASLMechanism: KafkaSASLSCRAMSHA512, SecretPath: secretDir, TLS: true},
+		tlsConfig: TLSConfig{
+			MinVersion: "1.2",
+		},
+		check: func(t *testing.T, cfg *sarama.Config) {
+			if cfg.Net.SASL.Mechanism != sarama.SASLTypeSCRAMSHA512 {
+				t.Errorf("SASL mechanism = %s, want %s", cfg.Net.SASL.Mechanism, sarama.SASLTypeSCRAMSHA512)
+			}
+			if c := cfg.Net.SASL.SCRAMClientGeneratorFunc(); c.Begin("producer", "s3cr3t", "") != nil {
+				t.Error("SCRAM client failed to begin")
+			}
+			if !cfg.Net.TLS.Enable || cfg.Net.TLS.Config == nil || cfg.Net.TLS.Config.MinVersion == 0 {
+				t.Errorf("TLS = %v, want enabled with a minimum version", cfg.Net.TLS.Config)
+			}
+		},
+	}, {
+		name:    "missing credentials",
+		config:  KafkaConfig{SASLMechanism: KafkaSASLSCRAMSHA256, SecretPath: t.TempDir()},
+		wantErr: "read kafka credentials",
+	}, {
+		name:    "unsupported mechanism",
+		config:  KafkaConfig{SASLMechanism: "GSSAPI", SecretPath: secretDir},
+		wantErr: `unsupported kafka SASL mechanism "GSSAPI"`,
+	}, {
+		name:    "missing CA certificates",
+		config:  KafkaConfig{TLS: true, CACertsPath: filepath.Join(t.TempDir(), "missing")},
+		wantErr: "read sink CA certificates directory",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := tt.config.saramaConfig(context.Background(), tt.tlsConfig)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("saramaConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func Test_kafkaClient_Send(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithCheckerFunctionAndSucceed(func(val []byte) error {
+		ev := cloudevents.NewEvent()
+		if err := json.Unmarshal(val, &ev); err != nil {
+			return err
+		}
+		if got := ev.Extensions()["cluster"]; got != "prod" {
+			return fmt.Errorf("cluster extension = %v, want prod", got)
+		}
+		return nil
+	})
+	sender, err := kafka_sarama.NewSenderFromSyncProducer("vsphere.events", producer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := newKafkaTopicClient(sender, map[string]string{"cluster": "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev := cloudevents.NewEvent()
+	ev.SetID("1")
+	ev.SetSource(source)
+	ev.SetType("com.vmware.vsphere.VmPoweredOnEvent")
+	if result := c.Send(withContentMode(context.Background(), ContentModeStructured), ev); !cloudevents.IsACK(result) {
+		t.Fatalf("Send() = %v", result)
+	}
+	if err := producer.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/rest"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// poll the vAPI content library endpoints for changes at this interval
+	libraryPollInterval = 30 * time.Second
+	// event class used for content library events
+	libraryEventClass = "contentlibrary"
+	// type prefix of content library events, e.g.
+	// com.vmware.vsphere.contentlibrary.item.created
+	libraryEventTypePrefix = "com.vmware.vsphere.contentlibrary."
+)
+
+// content library change operations
+const (
+	libraryOpCreated = "created"
+	libraryOpUpdated = "updated"
+	libraryOpDeleted = "deleted"
+)
+
+// libraryProtoFields are the protobuf field numbers of the content library
+// payloads by field name, since the vendored types have no protobuf tags. New
+// fields need a new number, numbers must never be reused.
+var libraryProtoFields = map[reflect.Type]map[string]int{
+	reflect.TypeOf(library.Library{}): {
+		"creation_time":      1,
+		"description":        2,
+		"id":                 3,
+		"last_modified_time": 4,
+		"last_sync_time":     5,
+		"name":               6,
+		"storage_backings":   7,
+		"type":               8,
+		"version":            9,
+		"subscription_info":  10,
+		"publish_info":       11,
+	},
+	reflect.TypeOf(library.StorageBackings{}): {
+		"datastore_id": 1,
+		"type":         2,
+	},
+	reflect.TypeOf(library.Subscription{}): {
+		"authentication_method":  1,
+		"automatic_sync_enabled": 2,
+		"on_demand":              3,
+		"password":               4,
+		"ssl_thumbprint":         5,
+		"subscription_url":       6,
+		"user_name":              7,
+	},
+	reflect.TypeOf(library.Publication{}): {
+		"authentication_method": 1,
+		"user_name":             2,
+		"password":              3,
+		"current_password":      4,
+		"persist_json_enabled":  5,
+		"published":             6,
+		"publish_url":           7,
+	},
+	reflect.TypeOf(library.Item{}): {
+		"cached":             1,
+		"content_version":    2,
+		"creation_time":      3,
+		"description":        4,
+		"id":                 5,
+		"last_modified_time": 6,
+		"last_sync_time":     7,
+		"library_id":         8,
+		"metadata_version":   9,
+		"name":               10,
+		"size":               11,
+		"source_id":          12,
+		"type":               13,
+		"version":            14,
+	},
+}
+
+// libraryChange is a change of a content library or library item observed
+// between two polls
+type libraryChange struct {
+	// Kind is either "library" or "item"
+	Kind string
+	// Op is one of created, updated or deleted
+	Op string
+	ID string
+	// Version of the library or item, empty for deleted objects
+	Version string
+	// Time of the change, if known
+	Time *time.Time
+	// Object is the (last known) library.Library or library.Item
+	Object interface{}
+}
+
+// libraryCollector detects changes of content libraries and their items by
+// comparing snapshots retrieved from the vSphere Automation (vAPI) REST API,
+// since these changes are not part of the vCenter event stream.
+type libraryCollector struct {
+	mgr *library.Manager
+
+	// snapshots from the last poll, nil before the first poll
+	libraries map[string]library.Library
+	items     map[string]library.Item
+}
+
+func newLibraryCollector(c *rest.Client) *libraryCollector {
+	return &libraryCollector{mgr: library.NewManager(c)}
+}
+
+// next returns the changes since the last call. The first call only records
+// the current state and does not return any changes.
+func (c *libraryCollector) next(ctx context.Context) ([]libraryChange, error) {
+	libs, err := c.mgr.GetLibraries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get content libraries: %w", err)
+	}
+
+	libraries := make(map[string]library.Library, len(libs))
+	items := make(map[string]library.Item)
+	for _, l := range libs {
+		// never send subscription credentials to the sink
+		if l.Subscription != nil {
+			s := *l.Subscription
+			s.Password = ""
+			l.Subscription = &s
+		}
+		libraries[l.ID] = l
+
+		libItems, err := c.mgr.GetLibraryItems(ctx, l.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get items of content library %q: %w", l.ID, err)
+		}
+		for _, i := range libItems {
+			items[i.ID] = i
+		}
+	}
+
+	var changes []libraryChange
+	if c.libraries != nil {
+		changes = append(changes, diffLibraries(c.libraries, libraries)...)
+		changes = append(changes, diffItems(c.items, items)...)
+	}
+
+	c.libraries = libraries
+	c.items = items
+	return changes, nil
+}
+
+func diffLibraries(old, cur map[string]library.Library) []libraryChange {
+	var changes []libraryChange
+	for _, l := range cur {
+		change := libraryChange{Kind: "library", ID: l.ID, Version: l.Version, Time: l.LastModifiedTime, Object: l}
+
+		prev, ok := old[l.ID]
+		switch {
+		case !ok:
+			change.Op = libraryOpCreated
+		case prev.Version != l.Version:
+			change.Op = libraryOpUpdated
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	for id, l := range old {
+		if _, ok := cur[id]; !ok {
+			changes = append(changes, libraryChange{Kind: "library", Op: libraryOpDeleted, ID: id, Object: l})
+		}
+	}
+	return sortChanges(changes)
+}
+
+func diffItems(old, cur map[string]library.Item) []libraryChange {
+	var changes []libraryChange
+	for _, i := range cur {
+		// metadata and content changes are versioned separately
+		version := i.MetadataVersion + "-" + i.ContentVersion
+		change := libraryChange{Kind: "item", ID: i.ID, Version: version, Time: i.LastModifiedTime, Object: i}
+
+		prev, ok := old[i.ID]
+		switch {
+		case !ok:
+			change.Op = libraryOpCreated
+		case prev.MetadataVersion != i.MetadataVersion || prev.ContentVersion != i.ContentVersion:
+			change.Op = libraryOpUpdated
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	for id, i := range old {
+		if _, ok := cur[id]; !ok {
+			changes = append(changes, libraryChange{Kind: "item", Op: libraryOpDeleted, ID: id, Object: i})
+		}
+	}
+	return sortChanges(changes)
+}
+
+// sortChanges sorts the given changes by ID for a stable event order
+func sortChanges(changes []libraryChange) []libraryChange {
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+	return changes
+}
+
+// readLibraries periodically polls vCenter for content library changes and
+// sends them to the configured sink. Content library events are not
+// checkpointed.
+func (a *vAdapter) readLibraries(ctx context.Context, c *libraryCollector) error {
+	logger := logging.FromContext(ctx)
+
+	// record the initial state
+	if _, err := c.next(ctx); err != nil {
+		return fmt.Errorf("read content libraries from vcenter: %w", err)
+	}
+
+	ticker := time.NewTicker(libraryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-ticker.C:
+			changes, err := c.next(ctx)
+			if err != nil {
+				return fmt.Errorf("read content libraries from vcenter: %w", err)
+			}
+
+			for _, change := range changes {
+				ev, err := newLibraryCloudEvent(a.Source, change)
+				if err != nil {
+					logger.Errorw("failed to create content library cloudevent", "id", change.ID, "error", err)
+					continue
+				}
+
+				ev, ok, err := a.translate(ev, change)
+				if err != nil {
+					logger.Errorw("failed to translate content library cloudevent", "id", change.ID, "error", err)
+					continue
+				}
+
+				if !ok || !a.Filter.Match(ev, change) {
+					continue
+				}
+
+				sinkCtx := a.Sinks.withMatching(ctx, ev, change)
+				if err = a.Transformer.apply(&ev, change); err != nil {
+					logger.Warnw("failed to transform content library cloudevent", "id", change.ID, "error", err)
+					continue
+				}
+
+				// changes are not checkpointed, so events which are neither
+				// sent nor dead lettered are lost
+				if result := a.deliver(sinkCtx, ev, extensionContext{}); !cloudevents.IsACK(result) {
+					_ = a.failed(logging.WithLogger(ctx, logger.With("id", change.ID)), sinkError(result))
+				}
+			}
+		}
+	}
+}
+
+// newLibraryCloudEvent converts the given content library change into a
+// CloudEvent, e.g. of type com.vmware.vsphere.contentlibrary.item.created.
+func newLibraryCloudEvent(source string, change libraryChange) (cloudevents.Event, error) {
+	ev := cloudevents.NewEvent(cloudevents.VersionV1)
+	ev.SetSource(source)
+	ev.SetType(libraryEventTypePrefix + change.Kind + "." + change.Op)
+	if change.Kind == "library" {
+		setDataSchema(&ev, schemaContentLibrary)
+	} else {
+		setDataSchema(&ev, schemaContentLibraryItem)
+	}
+	ev.SetExtension("EventClass", libraryEventClass)
+	ev.SetSubject(change.ID)
+
+	if change.Op == libraryOpDeleted {
+		ev.SetID(fmt.Sprintf("%s-%s", change.ID, change.Op))
+	} else {
+		ev.SetID(fmt.Sprintf("%s-%s-%s", change.ID, change.Op, change.Version))
+	}
+
+	if change.Time != nil && change.Op != libraryOpDeleted {
+		ev.SetTime(*change.Time)
+	} else {
+		ev.SetTime(time.Now().UTC())
+	}
+
+	if err := ev.SetData(cloudevents.ApplicationJSON, change.Object); err != nil {
+		return ev, fmt.Errorf("set data on event: %w", err)
+	}
+	return ev, nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+func Test_libraryCollector_next(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		rc := rest.NewClient(vim)
+		if err := rc.Login(ctx, simulator.DefaultLogin); err != nil {
+			t.Fatal(err)
+		}
+
+		ds, err := find.NewFinder(vim).DefaultDatastore(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mgr := library.NewManager(rc)
+		c := newLibraryCollector(rc)
+
+		assertChanges := func(t *testing.T, want ...string) {
+			t.Helper()
+			changes, err := c.next(ctx)
+			if err != nil {
+				t.Fatalf("next() error = %v", err)
+			}
+
+			var got []string
+			for _, change := range changes {
+				got = append(got, change.Kind+"."+change.Op)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("next() = %v, want %v", got, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("next() = %v, want %v", got, want)
+				}
+			}
+		}
+
+		libID, err := mgr.CreateLibrary(ctx, library.Library{
+			Name: "existing",
+			Type: "LOCAL",
+			Storage: []library.StorageBackings{{
+				DatastoreID: ds.Reference().Value,
+				Type:        "DATASTORE",
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// initial state is not reported
+		assertChanges(t)
+
+		itemID, err := mgr.CreateLibraryItem(ctx, library.Item{Name: "ubuntu", Type: "ovf", LibraryID: libID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertChanges(t, "item.created")
+
+		// no changes
+		assertChanges(t)
+
+		item, err := mgr.GetLibraryItem(ctx, itemID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mgr.DeleteLibraryItem(ctx, item); err != nil {
+			t.Fatal(err)
+		}
+		lib, err := mgr.GetLibraryByID(ctx, libID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mgr.DeleteLibrary(ctx, lib); err != nil {
+			t.Fatal(err)
+		}
+		assertChanges(t, "library.deleted", "item.deleted")
+	})
+}
+
+func Test_newLibraryCloudEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   libraryChange
+		wantType string
+		wantID   string
+	}{
+		{
+			name: "item created",
+			change: libraryChange{Kind: "item", Op: libraryOpCreated, ID: "item-1", Version: "1-1",
+				Object: library.Item{ID: "item-1", Name: "ubuntu"}},
+			wantType: "com.vmware.vsphere.contentlibrary.item.created",
+			wantID:   "item-1-created-1-1",
+		},
+		{
+			name: "library deleted",
+			change: libraryChange{Kind: "library", Op: libraryOpDeleted, ID: "lib-1",
+				Object: library.Library{ID: "lib-1", Name: "templates"}},
+			wantType: "com.vmware.vsphere.contentlibrary.library.deleted",
+			wantID:   "lib-1-deleted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := newLibraryCloudEvent(source, tt.change)
+			if err != nil {
+				t.Fatalf("newLibraryCloudEvent() error = %v", err)
+			}
+			if ev.Type() != tt.wantType {
+				t.Errorf("Type() = %s, want %s", ev.Type(), tt.wantType)
+			}
+			if ev.ID() != tt.wantID {
+				t.Errorf("ID() = %s, want %s", ev.ID(), tt.wantID)
+			}
+			if ev.Subject() != tt.change.ID {
+				t.Errorf("Subject() = %s, want %s", ev.Subject(), tt.change.ID)
+			}
+			if err := ev.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// lifecycleEventTypePrefix is the type prefix of the lifecycle events of
+	// a source
+	lifecycleEventTypePrefix = "com.vmware.vsphere.source."
+
+	// ConnectedEventType is the type of the event sent when the adapter
+	// starts with a vCenter session
+	ConnectedEventType = lifecycleEventTypePrefix + "connected"
+	// DisconnectedEventType is the type of the event sent when the adapter
+	// lost its vCenter session or connection
+	DisconnectedEventType = lifecycleEventTypePrefix + "disconnected"
+	// ReauthenticatedEventType is the type of the event sent when the adapter
+	// logged in to vCenter again after the session expired or the credentials
+	// changed
+	ReauthenticatedEventType = lifecycleEventTypePrefix + "reauthenticated"
+	// ReplayStartedEventType is the type of the event sent when the adapter
+	// starts replaying vCenter events from a checkpoint or the configured
+	// replay start time
+	ReplayStartedEventType = lifecycleEventTypePrefix + "replaystarted"
+)
+
+// Lifecycle is the payload of the lifecycle events, which the adapter sends
+// on changes of its vCenter session and event stream.
+type Lifecycle struct {
+	// Address is the address of the vCenter the adapter is connected to, the
+	// fallback address if connected to it
+	Address string `json:"address"`
+	// Reason is why the session changed, e.g. session expired
+	Reason string `json:"reason,omitempty"`
+	// Error is the error which ended the session or connection
+	Error string `json:"error,omitempty"`
+	// Relogins is the number of logins after the session expired or the
+	// credentials changed
+	Relogins int64 `json:"relogins,omitempty"`
+	// ReplayFrom is the vCenter time of the first event replayed
+	ReplayFrom *time.Time `json:"replayFrom,omitempty"`
+}
+
+// isAdapterEvent returns true if the given event type is sent by the adapter
+// about itself instead of for a vCenter event
+func isAdapterEvent(eventType string) bool {
+	return eventType == HeartbeatEventType || strings.HasPrefix(eventType, lifecycleEventTypePrefix)
+}
+
+// newLifecycleCloudEvent returns the lifecycle event of the given type and
+// source with the given payload
+func newLifecycleCloudEvent(source, eventType string, lc Lifecycle, now time.Time) (cloudevents.Event, error) {
+	ev := cloudevents.NewEvent(cloudevents.VersionV1)
+	ev.SetSource(source)
+	ev.SetType(eventType)
+	ev.SetID(uuid.New().String())
+	ev.SetTime(now.UTC())
+
+	if err := ev.SetData(cloudevents.ApplicationJSON, lc); err != nil {
+		return ev, fmt.Errorf("set data on event: %w", err)
+	}
+	return ev, nil
+}
+
+// sendLifecycle sends a lifecycle event of the given type if enabled. Like
+// heartbeats, lifecycle events are not filtered, transformed or translated
+// into the output format, and failures to send them are only logged.
+func (a *vAdapter) sendLifecycle(ctx context.Context, eventType string, lc Lifecycle) {
+	if !a.LifecycleEvents {
+		return
+	}
+	logger := logging.FromContext(ctx)
+
+	lc.Address = a.Source
+	if a.FallbackAddress != "" {
+		lc.Address = a.FallbackAddress
+	}
+	ev, err := newLifecycleCloudEvent(a.Source, eventType, lc, time.Now())
+	if err != nil {
+		logger.Errorw("failed to create lifecycle cloudevent", zap.String("type", eventType), zap.Error(err))
+		return
+	}
+	if result := a.send(ctx, ev, extensionContext{}); !cloudevents.IsACK(result) {
+		logger.Warnw("failed to send lifecycle cloudevent", zap.String("type", eventType), zap.Error(result))
+	}
+}
+
+// sendDisconnected sends a disconnected event for the given error which stops
+// the adapter, unless it is stopped by ctx
+func (a *vAdapter) sendDisconnected(ctx context.Context, err error) {
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+	a.sendLifecycle(ctx, DisconnectedEventType, Lifecycle{Error: err.Error()})
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+// newLifecycleAdapter returns an adapter sending its events into the returned
+// channel
+func newLifecycleAdapter(t *testing.T, enabled bool) (*vAdapter, eventChanRoundTripper) {
+	t.Helper()
+	rt := make(eventChanRoundTripper, 1)
+	p, err := cehttp.New(cehttp.WithRoundTripper(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &vAdapter{CEClient: c, Source: source, LifecycleEvents: enabled}, rt
+}
+
+func Test_vAdapter_sendLifecycle(t *testing.T) {
+	ctx := cecontext.WithTarget(context.Background(), "fake.example.com")
+	begin := time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("sends the event with the vCenter address", func(t *testing.T) {
+		a, rt := newLifecycleAdapter(t, true)
+		a.FallbackAddress = "https://fallback.example.com"
+		a.sendLifecycle(ctx, ReplayStartedEventType, Lifecycle{ReplayFrom: &begin})
+
+		var ev *event.Event
+		select {
+		case ev = <-rt:
+		default:
+			t.Fatal("sendLifecycle() did not send an event")
+		}
+		if ev.Type() != ReplayStartedEventType || ev.Source() != source {
+			t.Errorf("lifecycle type = %s, source = %s, want %s from %s", ev.Type(), ev.Source(), ReplayStartedEventType, source)
+		}
+		var lc Lifecycle
+		if err := ev.DataAs(&lc); err != nil {
+			t.Fatal(err)
+		}
+		if lc.Address != a.FallbackAddress || lc.ReplayFrom == nil || !lc.ReplayFrom.Equal(begin) {
+			t.Errorf("lifecycle = %+v, want address %s and replay from %v", lc, a.FallbackAddress, begin)
+		}
+		if _, last := a.Deliveries.take(); !last.IsZero() {
+			t.Errorf("lifecycle event counted as delivered event at %v", last)
+		}
+	})
+
+	t.Run("does not send events unless enabled", func(t *testing.T) {
+		a, rt := newLifecycleAdapter(t, false)
+		a.sendLifecycle(ctx, ConnectedEventType, Lifecycle{})
+
+		select {
+		case ev := <-rt:
+			t.Errorf("sendLifecycle() sent %s while disabled", ev.Type())
+		default:
+		}
+	})
+
+	t.Run("does not send disconnected events when stopped", func(t *testing.T) {
+		a, rt := newLifecycleAdapter(t, true)
+		stopped, cancel := context.WithCancel(ctx)
+		cancel()
+		a.sendDisconnected(stopped, errors.New("stopped"))
+
+		select {
+		case ev := <-rt:
+			t.Errorf("sendDisconnected() sent %s while stopped", ev.Type())
+		default:
+		}
+	})
+
+	t.Run("sends disconnected events with the error", func(t *testing.T) {
+		a, rt := newLifecycleAdapter(t, true)
+		a.sendDisconnected(ctx, errors.New("connection refused"))
+
+		ev := <-rt
+		var lc Lifecycle
+		if err := ev.DataAs(&lc); err != nil {
+			t.Fatal(err)
+		}
+		if ev.Type() != DisconnectedEventType || lc.Error != "connection refused" || lc.Address != source {
+			t.Errorf("sendDisconnected() sent %s with %+v", ev.Type(), lc)
+		}
+	})
+}
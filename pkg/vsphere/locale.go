@@ -0,0 +1,28 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vmware/govmomi"
+)
+
+// setLocale sets the locale of the session of the given client, in which
+// vCenter formats the fullFormattedMessage of events and the messages of
+// tasks. The session keeps the locale of its login, en_US unless overridden
+// with GOVMOMI_LOCALE, if locale is empty. The locale must be set again after
+// every login.
+func setLocale(ctx context.Context, c *govmomi.Client, locale string) error {
+	if locale == "" {
+		return nil
+	}
+	if err := c.SessionManager.SetLocale(ctx, locale); err != nil {
+		return fmt.Errorf("set vCenter session locale %q: %w", locale, err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// localeRecorder records the locales set with the SetLocale method, which the
+// simulator does not implement
+type localeRecorder struct {
+	locales []string
+	err     error
+}
+
+func (r *localeRecorder) RoundTrip(_ context.Context, req, _ soap.HasFault) error {
+	if body, ok := req.(*methods.SetLocaleBody); ok {
+		r.locales = append(r.locales, body.Req.Locale)
+	}
+	return r.err
+}
+
+func Test_setLocale(t *testing.T) {
+	tests := []struct {
+		name        string
+		locale      string
+		err         error
+		wantLocales []string
+		wantErr     bool
+	}{
+		{name: "default locale"},
+		{name: "locale", locale: "ja_JP", wantLocales: []string{"ja_JP"}},
+		{name: "unsupported locale", locale: "xx", err: errors.New("InvalidArgument"), wantLocales: []string{"xx"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &localeRecorder{err: tt.err}
+			vim := &vim25.Client{
+				RoundTripper: rt,
+				ServiceContent: types.ServiceContent{
+					SessionManager: &types.ManagedObjectReference{Type: "SessionManager", Value: "SessionManager"},
+				},
+			}
+			c := &govmomi.Client{Client: vim, SessionManager: session.NewManager(vim)}
+
+			if err := setLocale(context.Background(), c, tt.locale); (err != nil) != tt.wantErr {
+				t.Fatalf("setLocale() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !cmp.Equal(rt.locales, tt.wantLocales) {
+				t.Errorf("setLocale() set locales %v, want %v", rt.locales, tt.wantLocales)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"knative.dev/pkg/logging"
+)
+
+// LoggingLevelAnnotation is the annotation of a VSphereSource which sets the
+// logging level of its adapter, e.g. "debug". The adapter picks up changes
+// without a restart.
+const LoggingLevelAnnotation = "vspheresources.sources.tanzu.vmware.com/logging-level"
+
+// interval to read the logging level annotation of the source
+var loggingLevelPollInterval = 10 * time.Second
+
+// sourcesResource is the resource of the VSphereSources, read through the
+// dynamic client to not depend on the API package
+var sourcesResource = schema.GroupVersionResource{
+	Group:    "sources.tanzu.vmware.com",
+	Version:  "v1alpha1",
+	Resource: "vspheresources",
+}
+
+// newLogger returns a logger for the given component configured with the
+// given JSON-encoded logging config, and the level with which its logging
+// level can be changed at runtime. The default logging config is used if the
+// given one is invalid.
+func newLogger(configJSON, component string) (*zap.SugaredLogger, zap.AtomicLevel) {
+	cfg, err := logging.JSONToConfig(configJSON)
+	if err != nil {
+		if cfg, err = logging.NewConfigFromMap(map[string]string{}); err != nil {
+			// the default config is always valid
+			panic(err)
+		}
+	}
+	return logging.NewLoggerFromConfig(cfg, component)
+}
+
+// watchLoggingLevel polls the logging level annotation of the named source
+// and sets the given level accordingly until ctx is done. If the source has
+// no annotation, the level it had when called is restored.
+func watchLoggingLevel(ctx context.Context, sources dynamic.ResourceInterface, name string, level zap.AtomicLevel, interval time.Duration) {
+	logger := logging.FromContext(ctx)
+	defaultLevel := level.Level()
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		src, err := sources.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			logger.Debugw("failed to read the logging level of the source", zap.Error(err))
+			return
+		}
+
+		want := defaultLevel
+		if s, ok := src.GetAnnotations()[LoggingLevelAnnotation]; ok {
+			if err := want.UnmarshalText([]byte(s)); err != nil {
+				logger.Warnw("invalid logging level annotation", zap.String("level", s), zap.Error(err))
+				return
+			}
+		}
+
+		if want != level.Level() {
+			logger.Infow("changing logging level", zap.Stringer("level", want))
+			level.SetLevel(want)
+		}
+	}, interval)
+}
+
+// ValidLoggingLevel returns an error if the given logging level is invalid.
+func ValidLoggingLevel(s string) error {
+	var l zapcore.Level
+	return l.UnmarshalText([]byte(s))
+}
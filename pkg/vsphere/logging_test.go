@@ -0,0 +1,64 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func Test_watchLoggingLevel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src := &unstructured.Unstructured{}
+	src.SetAPIVersion("sources.tanzu.vmware.com/v1alpha1")
+	src.SetKind("VSphereSource")
+	src.SetNamespace("ns")
+	src.SetName("src")
+	src.SetAnnotations(map[string]string{LoggingLevelAnnotation: "debug"})
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), src)
+	sources := client.Resource(sourcesResource).Namespace("ns")
+
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	go watchLoggingLevel(ctx, sources, "src", level, 10*time.Millisecond)
+
+	waitForLevel := func(want zapcore.Level) {
+		t.Helper()
+		for i := 0; i < 100 && level.Level() != want; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := level.Level(); got != want {
+			t.Fatalf("level = %v, want %v", got, want)
+		}
+	}
+	waitForLevel(zapcore.DebugLevel)
+
+	// removing the annotation restores the initial level
+	src.SetAnnotations(nil)
+	if _, err := sources.Update(ctx, src, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForLevel(zapcore.InfoLevel)
+}
+
+func TestValidLoggingLevel(t *testing.T) {
+	if err := ValidLoggingLevel("debug"); err != nil {
+		t.Errorf("ValidLoggingLevel(debug) = %v, want nil", err)
+	}
+	if err := ValidLoggingLevel("chatty"); err == nil {
+		t.Error("ValidLoggingLevel(chatty) = nil, want error")
+	}
+}
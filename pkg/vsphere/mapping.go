@@ -0,0 +1,127 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// defaultTypePrefix is the prefix of the CloudEvent types of all events sent
+// in the default output format
+const defaultTypePrefix = "com.vmware.vsphere."
+
+// AttributeMapping configures how the CloudEvents type and source attributes
+// of events are constructed
+type AttributeMapping struct {
+	// Source replaces the source, which defaults to the vCenter host
+	Source string `json:"source,omitempty"`
+	// TypePrefix replaces the com.vmware.vsphere. type prefix
+	TypePrefix string `json:"typePrefix,omitempty"`
+	// TypeTemplate is a text/template producing the type, see
+	// typeTemplateData for the available fields
+	TypeTemplate string `json:"typeTemplate,omitempty"`
+}
+
+// typeTemplateData are the fields available in a type template
+type typeTemplateData struct {
+	// Class is the event class, e.g. event, task or alarm
+	Class string
+	// Name is the type without the com.vmware.vsphere. prefix, e.g.
+	// VmPoweredOnEvent or task.success
+	Name string
+	// Type is the default type, e.g. com.vmware.vsphere.VmPoweredOnEvent
+	Type string
+}
+
+// NewTypeTemplate parses the given type template and verifies that it can be
+// executed, e.g. does not refer to unknown fields
+func NewTypeTemplate(s string) (*template.Template, error) {
+	tmpl, err := template.New("type").Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := typeTemplateData{Class: "event", Name: "VmPoweredOnEvent", Type: "com.vmware.vsphere.VmPoweredOnEvent"}
+	if err = tmpl.Execute(&strings.Builder{}, sample); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// attributeMapper applies an AttributeMapping to events
+type attributeMapper struct {
+	source string
+	prefix string
+	tmpl   *template.Template
+}
+
+// newAttributeMapper returns the mapper for the given JSON-encoded
+// AttributeMapping, which is nil if s is empty
+func newAttributeMapper(s string) (*attributeMapper, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var m AttributeMapping
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return nil, fmt.Errorf("unmarshal attribute mapping: %w", err)
+	}
+	return m.mapper()
+}
+
+// mapper returns the mapper applying the mapping
+func (m AttributeMapping) mapper() (*attributeMapper, error) {
+	mapper := &attributeMapper{source: m.Source, prefix: m.TypePrefix}
+	if m.TypeTemplate != "" {
+		tmpl, err := NewTypeTemplate(m.TypeTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("parse type template: %w", err)
+		}
+		mapper.tmpl = tmpl
+	}
+	return mapper, nil
+}
+
+// apply sets the mapped source and type on the given event. Only types with
+// the default com.vmware.vsphere. prefix are mapped. A nil mapper is a no-op.
+func (m *attributeMapper) apply(ev *cloudevents.Event) error {
+	if m == nil {
+		return nil
+	}
+
+	if m.source != "" {
+		ev.SetSource(m.source)
+	}
+
+	if !strings.HasPrefix(ev.Type(), defaultTypePrefix) {
+		return nil
+	}
+	name := strings.TrimPrefix(ev.Type(), defaultTypePrefix)
+
+	switch {
+	case m.tmpl != nil:
+		class, _ := ev.Extensions()["eventclass"].(string)
+
+		var b strings.Builder
+		data := typeTemplateData{Class: class, Name: name, Type: ev.Type()}
+		if err := m.tmpl.Execute(&b, data); err != nil {
+			return fmt.Errorf("execute type template: %w", err)
+		}
+		if b.Len() == 0 {
+			return fmt.Errorf("type template returned an empty type for %q", ev.Type())
+		}
+		ev.SetType(b.String())
+
+	case m.prefix != "":
+		ev.SetType(m.prefix + name)
+	}
+	return nil
+}
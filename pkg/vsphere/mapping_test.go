@@ -0,0 +1,91 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func Test_attributeMapper_apply(t *testing.T) {
+	tests := []struct {
+		name       string
+		mapping    string
+		eventType  string
+		wantType   string
+		wantSource string
+		wantErr    bool
+	}{
+		{
+			name:       "no mapping",
+			mapping:    "",
+			eventType:  "com.vmware.vsphere.VmPoweredOnEvent",
+			wantType:   "com.vmware.vsphere.VmPoweredOnEvent",
+			wantSource: source,
+		},
+		{
+			name:       "static source",
+			mapping:    `{"source":"https://vcenter.prod.example.com"}`,
+			eventType:  "com.vmware.vsphere.VmPoweredOnEvent",
+			wantType:   "com.vmware.vsphere.VmPoweredOnEvent",
+			wantSource: "https://vcenter.prod.example.com",
+		},
+		{
+			name:       "type prefix",
+			mapping:    `{"typePrefix":"com.example.vcenter."}`,
+			eventType:  "com.vmware.vsphere.task.success",
+			wantType:   "com.example.vcenter.task.success",
+			wantSource: source,
+		},
+		{
+			name:       "type template",
+			mapping:    `{"typeTemplate":"vsphere.{{ .Class }}.{{ .Name | printf \"%s\" }}"}`,
+			eventType:  "com.vmware.vsphere.VmPoweredOnEvent",
+			wantType:   "vsphere.event.VmPoweredOnEvent",
+			wantSource: source,
+		},
+		{
+			name:       "type without default prefix",
+			mapping:    `{"typePrefix":"com.example.vcenter."}`,
+			eventType:  "dev.cdevents.environment.created.0.1.1",
+			wantType:   "dev.cdevents.environment.created.0.1.1",
+			wantSource: source,
+		},
+		{
+			name:      "unknown template field",
+			mapping:   `{"typeTemplate":"{{ .Unknown }}"}`,
+			eventType: "com.vmware.vsphere.VmPoweredOnEvent",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newAttributeMapper(tt.mapping)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newAttributeMapper() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			ev := cloudevents.NewEvent()
+			ev.SetSource(source)
+			ev.SetType(tt.eventType)
+			ev.SetExtension("EventClass", "event")
+
+			if err = m.apply(&ev); err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			if ev.Type() != tt.wantType {
+				t.Errorf("Type() = %s, want %s", ev.Type(), tt.wantType)
+			}
+			if ev.Source() != tt.wantSource {
+				t.Errorf("Source() = %s, want %s", ev.Source(), tt.wantSource)
+			}
+		})
+	}
+}
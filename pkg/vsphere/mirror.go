@@ -0,0 +1,108 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// mirrorTimeout bounds the attempt to send an event to the mirror sink
+const mirrorTimeout = 5 * time.Second
+
+// MirrorStatus records the events mirrored to the candidate sink of a sink
+// cutover, so that the controller can show whether it keeps up with the sink.
+type MirrorStatus struct {
+	// LastDeliveredTime is the time the last event was acknowledged by the
+	// mirror sink
+	LastDeliveredTime time.Time `json:"lastDeliveredTime,omitempty"`
+	// EventsPerMinute is the number of events delivered to the mirror sink in
+	// the last interval, scaled to one minute
+	EventsPerMinute int64 `json:"eventsPerMinute"`
+	// MirroredEvents is the number of events sent to the mirror sink since the
+	// adapter started
+	MirroredEvents int64 `json:"mirroredEvents"`
+	// DivergentEvents is the number of mirrored events which were acknowledged
+	// by only one of the sink and the mirror sink
+	DivergentEvents int64 `json:"divergentEvents"`
+	// LastErrorCategory is the category of the last error sending an event to
+	// the mirror sink in the last interval
+	LastErrorCategory ErrorCategory `json:"lastErrorCategory,omitempty"`
+	// LastError is the last error sending an event to the mirror sink in the
+	// last interval
+	LastError string `json:"lastError,omitempty"`
+}
+
+// mirrorStats counts the events sent to the mirror sink, independent of the
+// deliveries to the sink. The zero value is ready to use and safe for
+// concurrent use.
+type mirrorStats struct {
+	deliveryStats
+	mirrored  int64
+	divergent int64
+}
+
+// record counts an event sent to the mirror sink at the given time with the
+// given result, which was acknowledged by the sink if acked.
+func (s *mirrorStats) record(t time.Time, result error, acked bool) {
+	mirrored := cloudevents.IsACK(result)
+	if mirrored {
+		s.deliveryStats.record(t)
+	} else {
+		err := sinkError(result)
+		s.recordError(CategoryOf(err), err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mirrored++
+	if mirrored != acked {
+		s.divergent++
+	}
+}
+
+// newMirrorStatus returns the mirror status with the events delivered since
+// the last status, which was published the given duration ago.
+func (s *mirrorStats) newMirrorStatus(elapsed time.Duration) *MirrorStatus {
+	delivery := s.newDeliveryStatus(elapsed, time.Time{})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &MirrorStatus{
+		LastDeliveredTime: delivery.LastDeliveredTime,
+		EventsPerMinute:   delivery.EventsPerMinute,
+		MirroredEvents:    s.mirrored,
+		DivergentEvents:   s.divergent,
+		LastErrorCategory: delivery.LastErrorCategory,
+		LastError:         delivery.LastError,
+	}
+}
+
+// mirror sends the given event to the mirror sink, whatever the result of the
+// sink, which acknowledged the event if acked. Failures are recorded in the
+// mirror status and are not retried, and every attempt is bounded by
+// mirrorTimeout, so that a slow mirror sink holds up the event stream and the
+// checkpoint no longer than that. The headers and the token of the sink are
+// not sent to the mirror sink.
+func (a *vAdapter) mirror(ctx context.Context, ev cloudevents.Event, ec extensionContext, acked bool) {
+	ctx, cancel := context.WithTimeout(ctx, mirrorTimeout)
+	defer cancel()
+
+	sinkCtx := cecontext.WithTarget(ctx, a.MirrorSink)
+	result := a.sendToAudited(withContentMode(sinkCtx, a.SinkContentMode), ev, ec)
+	a.Mirrors.record(time.Now().UTC(), result, acked)
+
+	if !cloudevents.IsACK(result) {
+		err := sinkError(fmt.Errorf("send to mirror sink %s: %w", a.MirrorSink, result))
+		logging.FromContext(ctx).Warnw("failed to mirror cloudevent", zap.String("id", ev.ID()), zap.Error(err),
+			errorCategoryField(err))
+	}
+}
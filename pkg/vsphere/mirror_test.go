@@ -0,0 +1,163 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.uber.org/zap/zaptest"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func Test_vAdapter_sendEventsToMirror(t *testing.T) {
+	events := createTestEvents(2, source, time.Now().UTC())
+
+	tests := []struct {
+		name          string
+		failHost      string
+		maxAttempts   int
+		wantCount     int
+		wantDelivered int
+		wantHosts     []string
+		wantErr       string
+		wantMirrored  int64
+		wantDivergent int64
+		wantMirrorErr ErrorCategory
+	}{{
+		name:          "sends to the sink and the mirror sink",
+		wantCount:     2,
+		wantDelivered: 2,
+		wantHosts:     []string{"sink.example.com", "mirror.example.com", "sink.example.com", "mirror.example.com"},
+		wantMirrored:  2,
+	}, {
+		name:          "does not fail the event if the mirror sink fails",
+		failHost:      "mirror.example.com",
+		wantCount:     2,
+		wantDelivered: 2,
+		wantHosts:     []string{"sink.example.com", "mirror.example.com", "sink.example.com", "mirror.example.com"},
+		wantMirrored:  2,
+		wantDivergent: 2,
+		wantMirrorErr: ErrorCategorySink5xx,
+	}, {
+		name:      "does not mirror events the sink fails before they are retried",
+		failHost:  "sink.example.com",
+		wantHosts: []string{"sink.example.com"},
+		wantErr:   "500",
+	}, {
+		name:        "mirrors events given up on once",
+		failHost:    "sink.example.com",
+		maxAttempts: 2,
+		wantCount:   2,
+		wantHosts: []string{
+			"sink.example.com", "sink.example.com", "mirror.example.com",
+			"sink.example.com", "sink.example.com", "mirror.example.com",
+		},
+		wantMirrored:  2,
+		wantDivergent: 2,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &hostRecorder{failHost: tt.failHost}
+			p, err := cehttp.New(cehttp.WithRoundTripper(recorder))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := client.New(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dead, err := newDeadLetters(tt.maxAttempts, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dead != nil {
+				dead.backoff = wait.Backoff{}
+			}
+
+			a := vAdapter{
+				Logger:      zaptest.NewLogger(t).Sugar(),
+				CEClient:    c,
+				Source:      source,
+				KVStore:     &fakeKVStore{},
+				DeadLetters: dead,
+				SinkHeaders: http.Header{"X-Api-Key": []string{"secret"}},
+				MirrorSink:  "http://mirror.example.com",
+				Mirrors:     &mirrorStats{},
+			}
+			ctx := cecontext.WithTarget(context.Background(), "http://sink.example.com")
+			count, err := a.sendEvents(ctx, events.vEvents)
+
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("sendEvents() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("sendEvents() error = %v, want %q", err, tt.wantErr)
+			}
+			if count != tt.wantCount {
+				t.Errorf("sendEvents() = %d, want %d", count, tt.wantCount)
+			}
+			if !reflect.DeepEqual(recorder.hosts, tt.wantHosts) {
+				t.Errorf("sent to %v, want %v", recorder.hosts, tt.wantHosts)
+			}
+			// the headers of the sink are not sent to the mirror sink
+			for i, h := range recorder.headers {
+				if want := recorder.hosts[i] != "mirror.example.com"; (h.Get("X-Api-Key") != "") != want {
+					t.Errorf("request %d to %s has X-Api-Key %q", i, recorder.hosts[i], h.Get("X-Api-Key"))
+				}
+			}
+
+			status := a.Mirrors.newMirrorStatus(time.Minute)
+			if status.MirroredEvents != tt.wantMirrored || status.DivergentEvents != tt.wantDivergent {
+				t.Errorf("mirror status %d mirrored, %d divergent events, want %d, %d",
+					status.MirroredEvents, status.DivergentEvents, tt.wantMirrored, tt.wantDivergent)
+			}
+			if status.LastErrorCategory != tt.wantMirrorErr {
+				t.Errorf("mirror status error category = %q, want %q", status.LastErrorCategory, tt.wantMirrorErr)
+			}
+			// the mirror sink does not count for the delivery status of the sink
+			if delivery := a.Deliveries.newDeliveryStatus(time.Minute, time.Time{}); delivery.EventsPerMinute != int64(tt.wantDelivered) {
+				t.Errorf("delivery status events per minute = %d, want %d", delivery.EventsPerMinute, tt.wantDelivered)
+			}
+		})
+	}
+}
+
+func Test_mirrorStats_newMirrorStatus(t *testing.T) {
+	var s mirrorStats
+	now := time.Now().UTC()
+	s.record(now, nil, true)
+	s.record(now, nil, false)
+	s.record(now, cehttp.NewResult(404, "not found"), true)
+
+	got := s.newMirrorStatus(30 * time.Second)
+	want := &MirrorStatus{
+		LastDeliveredTime: now,
+		EventsPerMinute:   4,
+		MirroredEvents:    3,
+		DivergentEvents:   2,
+		LastErrorCategory: ErrorCategorySink4xx,
+		LastError:         got.LastError,
+	}
+	if !reflect.DeepEqual(got, want) || got.LastError == "" {
+		t.Errorf("newMirrorStatus() = %+v, want %+v", got, want)
+	}
+
+	// the mirrored and divergent events are counted since the adapter started
+	got = s.newMirrorStatus(time.Minute)
+	want = &MirrorStatus{LastDeliveredTime: now, MirroredEvents: 3, DivergentEvents: 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("newMirrorStatus() = %+v, want %+v", got, want)
+	}
+}
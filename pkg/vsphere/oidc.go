@@ -0,0 +1,83 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	// requested lifetime of OIDC tokens for the sink
+	sinkTokenExpiration = time.Hour
+	// cached tokens are renewed if they expire within this duration
+	sinkTokenRenewBefore = 5 * time.Minute
+)
+
+// tokenProvider mints OIDC tokens for the audience of an authenticated sink
+// from the service account of the adapter. Tokens are cached until shortly
+// before they expire.
+type tokenProvider struct {
+	client         corev1client.ServiceAccountsGetter
+	namespace      string
+	serviceAccount string
+	audience       string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	now    func() time.Time
+}
+
+// newTokenProvider returns a tokenProvider for the given sink audience, or nil
+// if the sink does not advertise an audience.
+func newTokenProvider(client corev1client.ServiceAccountsGetter, namespace, serviceAccount, audience string) (*tokenProvider, error) {
+	if audience == "" {
+		return nil, nil
+	}
+	if serviceAccount == "" {
+		return nil, fmt.Errorf("service account required for sink audience %q", audience)
+	}
+
+	return &tokenProvider{
+		client:         client,
+		namespace:      namespace,
+		serviceAccount: serviceAccount,
+		audience:       audience,
+		now:            time.Now,
+	}, nil
+}
+
+// get returns a valid token for the sink audience
+func (p *tokenProvider) get(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && p.now().Add(sinkTokenRenewBefore).Before(p.expiry) {
+		return p.token, nil
+	}
+
+	expiration := int64(sinkTokenExpiration.Seconds())
+	tr, err := p.client.ServiceAccounts(p.namespace).CreateToken(ctx, p.serviceAccount, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         []string{p.audience},
+			ExpirationSeconds: &expiration,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("request token for audience %q: %w", p.audience, err)
+	}
+
+	p.token = tr.Status.Token
+	p.expiry = tr.Status.ExpirationTimestamp.Time
+	return p.token, nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// authorizationRoundTripper records the Authorization header of all received
+// requests
+type authorizationRoundTripper []string
+
+func (a *authorizationRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	*a = append(*a, req.Header.Get("Authorization"))
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+// newFakeTokenClient returns a fake clientset which issues tokens valid for
+// the requested expiration and counts the token requests
+func newFakeTokenClient(now time.Time, requests *int) *fake.Clientset {
+	c := fake.NewSimpleClientset()
+	c.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		create := action.(k8stesting.CreateAction)
+		if create.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		*requests++
+
+		tr := create.GetObject().(*authenticationv1.TokenRequest)
+		d := time.Duration(*tr.Spec.ExpirationSeconds) * time.Second
+		tr.Status = authenticationv1.TokenRequestStatus{
+			Token:               fmt.Sprintf("token-%d-%s", *requests, tr.Spec.Audiences[0]),
+			ExpirationTimestamp: metav1.NewTime(now.Add(d)),
+		}
+		return true, tr, nil
+	})
+	return c
+}
+
+func Test_newTokenProvider(t *testing.T) {
+	c := fake.NewSimpleClientset().CoreV1()
+
+	p, err := newTokenProvider(c, "default", "adapter", "")
+	if err != nil || p != nil {
+		t.Errorf("newTokenProvider() without audience = %v, %v, want nil", p, err)
+	}
+
+	if _, err = newTokenProvider(c, "default", "", "broker"); err == nil {
+		t.Error("newTokenProvider() without service account did not fail")
+	}
+}
+
+func Test_tokenProvider_get(t *testing.T) {
+	now := time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)
+	var requests int
+	c := newFakeTokenClient(now, &requests)
+
+	p, err := newTokenProvider(c.CoreV1(), "default", "adapter", "broker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    string
+	}{
+		{name: "new token", want: "token-1-broker"},
+		{name: "cached token", elapsed: 30 * time.Minute, want: "token-1-broker"},
+		{name: "renewed token", elapsed: sinkTokenExpiration - time.Minute, want: "token-2-broker"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.now = func() time.Time { return now.Add(tt.elapsed) }
+			got, err := p.get(ctx)
+			if err != nil {
+				t.Fatalf("get() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("get() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vAdapter_send_sinkToken(t *testing.T) {
+	var requests int
+	p, err := newTokenProvider(newFakeTokenClient(time.Now(), &requests).CoreV1(), "default", "adapter", "broker")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rt authorizationRoundTripper
+	ht, err := cehttp.New(cehttp.WithRoundTripper(&rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.New(ht)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &vAdapter{CEClient: c, SinkTokens: p, SinkHeaders: http.Header{"X-Tenant": {"acme"}}}
+
+	ev := cloudevents.NewEvent()
+	ev.SetID("1")
+	ev.SetSource(source)
+	ev.SetType("com.vmware.vsphere.VmPoweredOnEvent")
+
+	ctx := cecontext.WithTarget(context.Background(), "fake.example.com")
+	for i := 0; i < 2; i++ {
+		if result := a.send(ctx, ev, extensionContext{}); !cloudevents.IsACK(result) {
+			t.Fatalf("send() = %v", result)
+		}
+	}
+
+	if len(rt) != 2 || rt[0] != "Bearer token-1-broker" || rt[1] != rt[0] {
+		t.Errorf("Authorization = %v, want cached bearer token", rt)
+	}
+	if len(a.SinkHeaders) != 1 {
+		t.Errorf("SinkHeaders modified: %v", a.SinkHeaders)
+	}
+}
@@ -0,0 +1,630 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// PayloadEncodingJSON sends the payloads of events as they are created,
+	// e.g. JSON or the XML of vSphere events
+	PayloadEncodingJSON = "json"
+	// PayloadEncodingProtobuf sends the JSON payloads with a data schema as
+	// protobuf messages, see ProtoSchema
+	PayloadEncodingProtobuf = "protobuf"
+
+	// ApplicationProtobuf is the content type of protobuf payloads
+	ApplicationProtobuf = "application/protobuf"
+)
+
+// ProtoSchemaURL returns the URL of the protobuf definition of the payload
+// with the given schema name
+func ProtoSchemaURL(name string) string {
+	return DataSchemaBaseURL + name + ".proto"
+}
+
+// dataSchemaName returns the name of the payload schema of the given
+// dataschema attribute, false if it is not one of DataSchemas
+func dataSchemaName(dataSchema string) (string, bool) {
+	name := strings.TrimSuffix(strings.TrimPrefix(dataSchema, DataSchemaBaseURL), ".json")
+	if _, ok := DataSchemas[name]; !ok || DataSchemaURL(name) != dataSchema {
+		return "", false
+	}
+	return name, true
+}
+
+// protoEncoder encodes the JSON payloads of events with a data schema as
+// protobuf messages of the payload type of the schema.
+type protoEncoder struct{}
+
+// newProtoEncoder returns the encoder for the given payload encoding, nil if
+// the payloads are sent as they are.
+func newProtoEncoder(encoding string) (*protoEncoder, error) {
+	switch encoding {
+	case "", PayloadEncodingJSON:
+		return nil, nil
+	case PayloadEncodingProtobuf:
+		return &protoEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported payload encoding %q", encoding)
+	}
+}
+
+// apply encodes the payload of the given event as protobuf message and points
+// its dataschema attribute to the protobuf definition. Payloads without a
+// data schema, e.g. vSphere events, are not changed. A nil encoder is a no-op.
+func (e *protoEncoder) apply(ev *cloudevents.Event) error {
+	if e == nil || len(ev.Data()) == 0 {
+		return nil
+	}
+	name, ok := dataSchemaName(ev.DataSchema())
+	if !ok {
+		return nil
+	}
+	if mediaType, _, err := mime.ParseMediaType(ev.DataContentType()); err != nil || mediaType != cloudevents.ApplicationJSON {
+		return nil
+	}
+
+	v := reflect.New(reflect.TypeOf(DataSchemas[name]))
+	if err := json.Unmarshal(ev.Data(), v.Interface()); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	b, err := marshalProto(v.Elem())
+	if err != nil {
+		return fmt.Errorf("encode payload: %w", err)
+	}
+	if err = ev.SetData(ApplicationProtobuf, b); err != nil {
+		return err
+	}
+	ev.SetDataSchema(ProtoSchemaURL(name))
+	return nil
+}
+
+// protoField is a field of the protobuf message of a struct type. The field
+// numbers are taken from the protobuf struct tags, e.g. `protobuf:"3"`, or
+// libraryProtoFields for the vendored content library types, so that they stay
+// stable when fields are added or reordered.
+type protoField struct {
+	number int
+	name   string
+	index  []int
+	typ    reflect.Type
+}
+
+// protoFields returns the fields of the protobuf message of the given struct
+// type in the order of the JSON properties, including those of embedded
+// structs. Every field must have a unique field number.
+func protoFields(t reflect.Type) ([]protoField, error) {
+	var fields []protoField
+	if err := addProtoFields(t, nil, &fields); err != nil {
+		return nil, err
+	}
+	numbers := make(map[int]string, len(fields))
+	for _, f := range fields {
+		if other, ok := numbers[f.number]; ok {
+			return nil, fmt.Errorf("fields %s and %s of %s have the same number %d", other, f.name, t, f.number)
+		}
+		numbers[f.number] = f.name
+	}
+	return fields, nil
+}
+
+func addProtoFields(t reflect.Type, index []int, fields *[]protoField) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := tag
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			name = tag[:idx]
+		}
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := addProtoFields(ft, fieldIndex, fields); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		name = snakeCase(name)
+
+		number, err := protoFieldNumber(t, f, name)
+		if err != nil {
+			return err
+		}
+		*fields = append(*fields, protoField{number: number, name: name, index: fieldIndex, typ: f.Type})
+	}
+	return nil
+}
+
+// protoFieldNumber returns the protobuf field number of the given field of
+// the struct type t
+func protoFieldNumber(t reflect.Type, f reflect.StructField, name string) (int, error) {
+	if tag, ok := f.Tag.Lookup("protobuf"); ok {
+		number, err := strconv.Atoi(tag)
+		if err != nil || number < 1 {
+			return 0, fmt.Errorf("invalid protobuf field number %q of field %s of %s", tag, f.Name, t)
+		}
+		return number, nil
+	}
+	if number, ok := libraryProtoFields[t][name]; ok {
+		return number, nil
+	}
+	return 0, fmt.Errorf("field %s of %s has no protobuf field number", f.Name, t)
+}
+
+// snakeCase returns the protobuf field name of the given JSON property, e.g.
+// tag_id for tagID
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (!unicode.IsUpper(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1])) && runes[i-1] != '_' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// fieldByIndex returns the field of the given struct value, false if it is in
+// an embedded struct pointer which is nil
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// ProtoSchema returns the protobuf (proto3) definition of the payload of the
+// given type, whose messages are derived from the type and its json struct
+// tags like the JSON schema. The first message is the payload, the field
+// numbers are taken from the protobuf struct tags, see protoField.
+func ProtoSchema(name string, v interface{}) ([]byte, error) {
+	g := &protoGen{names: map[string]reflect.Type{}, messages: map[reflect.Type]string{}, imports: map[string]bool{}}
+	root := reflect.TypeOf(v)
+	if _, err := g.messageName(root); err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	for i := 0; i < len(g.queue); i++ {
+		t := g.queue[i]
+		if i == 0 {
+			fmt.Fprintf(&body, "\n// %s is the payload of the events with the dataschema\n// %s\n", g.messages[t], ProtoSchemaURL(name))
+		} else {
+			body.WriteString("\n")
+		}
+		fields, err := protoFields(t)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&body, "message %s {\n", g.messages[t])
+		for _, f := range fields {
+			typ, err := g.fieldType(f.typ)
+			if err != nil {
+				return nil, fmt.Errorf("field %s of %s: %w", f.name, t, err)
+			}
+			fmt.Fprintf(&body, "  %s %s = %d;\n", typ, f.name, f.number)
+		}
+		body.WriteString("}\n")
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Generated by hack/schemagen, do not edit.\n\n")
+	b.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&b, "package vsphere.%s;\n", strings.ReplaceAll(name, "-", "_"))
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for i := range g.imports {
+			imports = append(imports, i)
+		}
+		sort.Strings(imports)
+		b.WriteString("\n")
+		for _, i := range imports {
+			fmt.Fprintf(&b, "import %q;\n", i)
+		}
+	}
+	b.Write(body.Bytes())
+	return b.Bytes(), nil
+}
+
+// protoGen collects the messages of a protobuf definition
+type protoGen struct {
+	// names are the types of the message names
+	names map[string]reflect.Type
+	// messages are the message names of the struct types
+	messages map[reflect.Type]string
+	// queue are the struct types in the order of the messages
+	queue   []reflect.Type
+	imports map[string]bool
+}
+
+// messageName returns the name of the message of the given struct type,
+// adding the message if it is new
+func (g *protoGen) messageName(t reflect.Type) (string, error) {
+	if name, ok := g.messages[t]; ok {
+		return name, nil
+	}
+	name := t.Name()
+	if name == "" {
+		return "", fmt.Errorf("anonymous struct %s has no message name", t)
+	}
+	if other, ok := g.names[name]; ok {
+		return "", fmt.Errorf("message name %s of %s is taken by %s", name, t, other)
+	}
+	g.names[name] = t
+	g.messages[t] = name
+	g.queue = append(g.queue, t)
+	return name, nil
+}
+
+// fieldType returns the type of a field of the given Go type, including its
+// label
+func (g *protoGen) fieldType(t reflect.Type) (string, error) {
+	switch {
+	case t.Kind() == reflect.Ptr && isProtoScalar(t.Elem()):
+		// presence of the value
+		typ, err := g.valueType(t.Elem())
+		return "optional " + typ, err
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		typ, err := g.valueType(t.Elem())
+		return "repeated " + typ, err
+	case t.Kind() == reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return "", fmt.Errorf("unsupported map key %s", t.Key())
+		}
+		typ, err := g.valueType(t.Elem())
+		return fmt.Sprintf("map<string, %s>", typ), err
+	default:
+		return g.valueType(t)
+	}
+}
+
+// valueType returns the type of a single value of the given Go type
+func (g *protoGen) valueType(t reflect.Type) (string, error) {
+	if t == timeType {
+		g.imports["google/protobuf/timestamp.proto"] = true
+		return "google.protobuf.Timestamp", nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		if t.Elem().Kind() != reflect.Struct {
+			return "", fmt.Errorf("unsupported type %s", t)
+		}
+		return g.valueType(t.Elem())
+	case reflect.Struct:
+		return g.messageName(t)
+	case reflect.Interface:
+		g.imports["google/protobuf/struct.proto"] = true
+		return "google.protobuf.Value", nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes", nil
+		}
+	case reflect.String:
+		return "string", nil
+	case reflect.Bool:
+		return "bool", nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return "int32", nil
+	case reflect.Int, reflect.Int64:
+		return "int64", nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "uint32", nil
+	case reflect.Uint, reflect.Uint64:
+		return "uint64", nil
+	case reflect.Float32:
+		return "float", nil
+	case reflect.Float64:
+		return "double", nil
+	}
+	// e.g. nested lists or maps, which protobuf cannot represent
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// isProtoScalar returns true if values of the given type are encoded as
+// scalar protobuf values
+func isProtoScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// marshalProto encodes the given struct value as the protobuf message defined
+// by ProtoSchema
+func marshalProto(v reflect.Value) ([]byte, error) {
+	return appendMessage(nil, v)
+}
+
+func appendMessage(b []byte, v reflect.Value) ([]byte, error) {
+	fields, err := protoFields(v.Type())
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok {
+			continue
+		}
+		if b, err = appendField(b, protowire.Number(f.number), fv); err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.name, err)
+		}
+	}
+	return b, nil
+}
+
+// appendField appends the given field value, omitting zero scalar values
+// like proto3 does
+func appendField(b []byte, num protowire.Number, v reflect.Value) ([]byte, error) {
+	switch {
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			return b, nil
+		}
+		// an optional scalar is present even if zero
+		return appendValue(b, num, v.Elem())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8:
+		return appendList(b, num, v)
+	case v.Kind() == reflect.Map:
+		return appendMap(b, num, v)
+	case v.Kind() == reflect.Interface:
+		if v.IsNil() {
+			return b, nil
+		}
+		return appendValue(b, num, v)
+	case v.Type() == timeType || v.Kind() != reflect.Struct:
+		if v.IsZero() {
+			return b, nil
+		}
+	}
+	return appendValue(b, num, v)
+}
+
+// appendList appends the elements of a repeated field, packing numbers like
+// proto3 does
+func appendList(b []byte, num protowire.Number, v reflect.Value) ([]byte, error) {
+	if v.Len() == 0 {
+		return b, nil
+	}
+	elem := v.Type().Elem()
+	if isProtoScalar(elem) && elem.Kind() != reflect.String {
+		var packed []byte
+		for i := 0; i < v.Len(); i++ {
+			packed = appendScalar(packed, v.Index(i))
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, packed), nil
+	}
+
+	var err error
+	for i := 0; i < v.Len(); i++ {
+		e := v.Index(i)
+		if e.Kind() == reflect.Ptr {
+			if e.IsNil() {
+				// as empty message
+				e = reflect.New(e.Type().Elem())
+			}
+			e = e.Elem()
+		}
+		if b, err = appendValue(b, num, e); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// appendMap appends the entries of a map field in the order of their keys
+func appendMap(b []byte, num protowire.Number, v reflect.Value) ([]byte, error) {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, k := range keys {
+		entry := protowire.AppendTag(nil, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, k.String())
+
+		e := v.MapIndex(k)
+		if e.Kind() == reflect.Ptr || e.Kind() == reflect.Interface {
+			if e.IsNil() {
+				// as default value
+				e = reflect.Zero(v.Type().Elem())
+				if e.Kind() == reflect.Ptr {
+					e = reflect.New(e.Type().Elem())
+				}
+			}
+			if e.Kind() == reflect.Ptr {
+				e = e.Elem()
+			}
+		}
+		entry, err := appendValue(entry, 2, e)
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	return b, nil
+}
+
+// appendValue appends a single value of a field, even if it is zero
+func appendValue(b []byte, num protowire.Number, v reflect.Value) ([]byte, error) {
+	if v.Type() == timeType {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, appendTimestamp(nil, v)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		return appendValue(b, num, v.Elem())
+	case reflect.Struct:
+		msg, err := appendMessage(nil, v)
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, msg), nil
+	case reflect.Interface:
+		msg, err := appendJSONValue(nil, v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, msg), nil
+	case reflect.String:
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendString(b, v.String()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			return protowire.AppendBytes(b, v.Bytes()), nil
+		}
+	case reflect.Float32:
+		b = protowire.AppendTag(b, num, protowire.Fixed32Type)
+		return appendScalar(b, v), nil
+	case reflect.Float64:
+		b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+		return appendScalar(b, v), nil
+	default:
+		if isProtoScalar(v.Type()) {
+			b = protowire.AppendTag(b, num, protowire.VarintType)
+			return appendScalar(b, v), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// appendScalar appends a number or bool without tag
+func appendScalar(b []byte, v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.Bool:
+		return protowire.AppendVarint(b, protowire.EncodeBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return protowire.AppendVarint(b, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return protowire.AppendVarint(b, v.Uint())
+	case reflect.Float32:
+		return protowire.AppendFixed32(b, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		return protowire.AppendFixed64(b, math.Float64bits(v.Float()))
+	}
+	return b
+}
+
+// appendTimestamp appends the fields of the google.protobuf.Timestamp of the
+// given time
+func appendTimestamp(b []byte, v reflect.Value) []byte {
+	t := v.Interface().(time.Time)
+	if s := t.Unix(); s != 0 {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(s))
+	}
+	if n := t.Nanosecond(); n != 0 {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(n))
+	}
+	return b
+}
+
+// appendJSONValue appends the fields of the google.protobuf.Value of the JSON
+// representation of the given value
+func appendJSONValue(b []byte, v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err = json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+	return appendStructValue(b, decoded), nil
+}
+
+// appendStructValue appends the fields of the google.protobuf.Value of the
+// given decoded JSON value
+func appendStructValue(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		return protowire.AppendVarint(b, 0)
+	case float64:
+		b = protowire.AppendTag(b, 2, protowire.Fixed64Type)
+		return protowire.AppendFixed64(b, math.Float64bits(v))
+	case string:
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		return protowire.AppendString(b, v)
+	case bool:
+		b = protowire.AppendTag(b, 4, protowire.VarintType)
+		return protowire.AppendVarint(b, protowire.EncodeBool(v))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var fields []byte
+		for _, k := range keys {
+			entry := protowire.AppendTag(nil, 1, protowire.BytesType)
+			entry = protowire.AppendString(entry, k)
+			entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+			entry = protowire.AppendBytes(entry, appendStructValue(nil, v[k]))
+			fields = protowire.AppendTag(fields, 1, protowire.BytesType)
+			fields = protowire.AppendBytes(fields, entry)
+		}
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		return protowire.AppendBytes(b, fields)
+	case []interface{}:
+		var values []byte
+		for _, e := range v {
+			values = protowire.AppendTag(values, 1, protowire.BytesType)
+			values = protowire.AppendBytes(values, appendStructValue(nil, e))
+		}
+		b = protowire.AppendTag(b, 6, protowire.BytesType)
+		return protowire.AppendBytes(b, values)
+	}
+	return b
+}
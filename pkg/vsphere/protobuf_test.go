@@ -0,0 +1,381 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestProtoSchema(t *testing.T) {
+	type embedded struct {
+		Embedded string `json:"embedded" protobuf:"1"`
+	}
+	// the field numbers do not depend on the order of the fields
+	type payload struct {
+		embedded
+		Added    string            `json:"added" protobuf:"10"`
+		Name     string            `json:"name" protobuf:"2"`
+		Optional *bool             `json:"optional,omitempty" protobuf:"3"`
+		Time     *time.Time        `json:"time" protobuf:"4"`
+		Labels   map[string]string `json:"labels,omitempty" protobuf:"5"`
+		Refs     []ObjectRef       `json:"refs,omitempty" protobuf:"6"`
+		Sizes    []int64           `json:"sizes,omitempty" protobuf:"7"`
+		Value    interface{}       `json:"value,omitempty" protobuf:"8"`
+		Ignored  string            `json:"-"`
+		TagID    string            `json:"tagID" protobuf:"9"`
+	}
+
+	got, err := ProtoSchema("test-payload", payload{})
+	if err != nil {
+		t.Fatalf("ProtoSchema() error = %v", err)
+	}
+
+	want := `// Generated by hack/schemagen, do not edit.
+
+syntax = "proto3";
+
+package vsphere.test_payload;
+
+import "google/protobuf/struct.proto";
+import "google/protobuf/timestamp.proto";
+
+// payload is the payload of the events with the dataschema
+// ` + ProtoSchemaURL("test-payload") + `
+message payload {
+  string embedded = 1;
+  string added = 10;
+  string name = 2;
+  optional bool optional = 3;
+  google.protobuf.Timestamp time = 4;
+  map<string, string> labels = 5;
+  repeated ObjectRef refs = 6;
+  repeated int64 sizes = 7;
+  google.protobuf.Value value = 8;
+  string tag_id = 9;
+}
+
+message ObjectRef {
+  string name = 1;
+  string type = 2;
+  string value = 3;
+}
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("ProtoSchema() (-want, +got) = %v", diff)
+	}
+
+	type nested struct {
+		Lists [][]string `json:"lists" protobuf:"1"`
+	}
+	if _, err = ProtoSchema("test", nested{}); err == nil {
+		t.Error("ProtoSchema() of nested lists succeeded, want error")
+	}
+
+	type unnumbered struct {
+		Name string `json:"name"`
+	}
+	if _, err = ProtoSchema("test", unnumbered{}); err == nil {
+		t.Error("ProtoSchema() of field without number succeeded, want error")
+	}
+
+	type duplicate struct {
+		Name  string `json:"name" protobuf:"1"`
+		Value string `json:"value" protobuf:"1"`
+	}
+	if _, err = ProtoSchema("test", duplicate{}); err == nil {
+		t.Error("ProtoSchema() of duplicate field numbers succeeded, want error")
+	}
+}
+
+// TestPublishedProtoSchemas verifies that the protobuf definitions in the
+// schemas directory are up to date, run hack/update-codegen.sh otherwise
+func TestPublishedProtoSchemas(t *testing.T) {
+	for name, v := range DataSchemas {
+		t.Run(name, func(t *testing.T) {
+			want, err := ProtoSchema(name, v)
+			if err != nil {
+				t.Fatalf("ProtoSchema() error = %v", err)
+			}
+
+			got, err := ioutil.ReadFile(filepath.Join("..", "..", "schemas", name+".proto"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(string(want), string(got)); diff != "" {
+				t.Errorf("protobuf definition %s is outdated (-want, +got) = %v", name, diff)
+			}
+		})
+	}
+}
+
+func Test_newProtoEncoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		wantNil  bool
+		wantErr  bool
+	}{
+		{name: "default", wantNil: true},
+		{name: "json", encoding: PayloadEncodingJSON, wantNil: true},
+		{name: "protobuf", encoding: PayloadEncodingProtobuf},
+		{name: "unsupported", encoding: "avro", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newProtoEncoder(tt.encoding)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newProtoEncoder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newProtoEncoder() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_marshalProto(t *testing.T) {
+	created := time.Date(2021, 4, 1, 12, 0, 0, 500, time.UTC)
+	data := AlarmEventData{
+		Key:         42,
+		CreatedTime: created,
+		Alarm:       ObjectRef{Name: "cpu", Type: "Alarm", Value: "alarm-1"},
+		To:          "red",
+	}
+
+	var ts []byte
+	ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+	ts = protowire.AppendVarint(ts, uint64(created.Unix()))
+	ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+	ts = protowire.AppendVarint(ts, 500)
+	var alarm []byte
+	alarm = protowire.AppendTag(alarm, 1, protowire.BytesType)
+	alarm = protowire.AppendString(alarm, "cpu")
+	alarm = protowire.AppendTag(alarm, 2, protowire.BytesType)
+	alarm = protowire.AppendString(alarm, "Alarm")
+	alarm = protowire.AppendTag(alarm, 3, protowire.BytesType)
+	alarm = protowire.AppendString(alarm, "alarm-1")
+
+	// the unset user name, message, entity and from are omitted
+	var want []byte
+	want = protowire.AppendTag(want, 1, protowire.VarintType)
+	want = protowire.AppendVarint(want, 42)
+	want = protowire.AppendTag(want, 2, protowire.BytesType)
+	want = protowire.AppendBytes(want, ts)
+	want = protowire.AppendTag(want, 5, protowire.BytesType)
+	want = protowire.AppendBytes(want, alarm)
+	want = protowire.AppendTag(want, 8, protowire.BytesType)
+	want = protowire.AppendString(want, "red")
+
+	got, err := marshalProto(reflect.ValueOf(data))
+	if err != nil {
+		t.Fatalf("marshalProto() error = %v", err)
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("marshalProto() = %x, want %x", got, want)
+	}
+
+	// well-known types are decoded by the protobuf runtime
+	var gotTime timestamppb.Timestamp
+	if err = proto.Unmarshal(ts, &gotTime); err != nil || !gotTime.AsTime().Equal(created) {
+		t.Errorf("Timestamp = %v, %v, want %v", gotTime.AsTime(), err, created)
+	}
+
+	change := PropertyChange{Name: "runtime", Op: "assign", Value: map[string]interface{}{
+		"powerState": "poweredOn",
+		"numCpu":     2,
+		"devices":    []interface{}{"disk", nil, true},
+	}}
+	b, err := marshalProto(reflect.ValueOf(change))
+	if err != nil {
+		t.Fatalf("marshalProto() error = %v", err)
+	}
+	value := lastField(t, b, 3)
+	var gotValue structpb.Value
+	if err = proto.Unmarshal(value, &gotValue); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	wantValue := map[string]interface{}{
+		"powerState": "poweredOn",
+		"numCpu":     float64(2),
+		"devices":    []interface{}{"disk", nil, true},
+	}
+	if diff := cmp.Diff(wantValue, gotValue.AsInterface()); diff != "" {
+		t.Errorf("Value (-want, +got) = %v", diff)
+	}
+}
+
+func Test_protoEncoder_apply(t *testing.T) {
+	alarmEvent := &types.AlarmStatusChangedEvent{
+		AlarmEvent: types.AlarmEvent{
+			Event: types.Event{Key: 42, CreatedTime: time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)},
+			Alarm: types.AlarmEventArgument{
+				EntityEventArgument: types.EntityEventArgument{Name: "cpu"},
+				Alarm:               types.ManagedObjectReference{Type: "Alarm", Value: "alarm-1"},
+			},
+		},
+		To: "red",
+	}
+	alarm, err := newAlarmCloudEvent("https://vcenter/sdk", alarmEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xml := cloudevents.NewEvent(cloudevents.VersionV1)
+	if err = xml.SetData(cloudevents.ApplicationXML, &types.VmPoweredOnEvent{}); err != nil {
+		t.Fatal(err)
+	}
+	foreign := cloudevents.NewEvent(cloudevents.VersionV1)
+	foreign.SetDataSchema("https://example.com/alarm.json")
+	if err = foreign.SetData(cloudevents.ApplicationJSON, map[string]string{"key": "value"}); err != nil {
+		t.Fatal(err)
+	}
+	invalid := alarm.Clone()
+	invalid.DataEncoded = []byte(`{"key":"42"}`)
+
+	t.Run("nil encoder", func(t *testing.T) {
+		ev := alarm.Clone()
+		var e *protoEncoder
+		if err := e.apply(&ev); err != nil || ev.DataContentType() != cloudevents.ApplicationJSON {
+			t.Errorf("apply() = %v, content type %s, want unchanged", err, ev.DataContentType())
+		}
+	})
+
+	t.Run("payload with schema", func(t *testing.T) {
+		ev := alarm.Clone()
+		if err := (&protoEncoder{}).apply(&ev); err != nil {
+			t.Fatalf("apply() error = %v", err)
+		}
+		if ev.DataContentType() != ApplicationProtobuf || ev.DataSchema() != ProtoSchemaURL(schemaAlarm) {
+			t.Errorf("apply() content type %s, dataschema %s, want %s, %s",
+				ev.DataContentType(), ev.DataSchema(), ApplicationProtobuf, ProtoSchemaURL(schemaAlarm))
+		}
+		want, err := marshalProto(reflect.ValueOf(AlarmEventData{
+			Key:         42,
+			CreatedTime: alarmEvent.CreatedTime,
+			Alarm:       ObjectRef{Name: "cpu", Type: "Alarm", Value: "alarm-1"},
+			// the entity without a reference is present as empty message
+			Entity: &ObjectRef{},
+			To:     "red",
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(want, ev.Data()) {
+			t.Errorf("apply() data = %x, want %x", ev.Data(), want)
+		}
+	})
+
+	for name, ev := range map[string]cloudevents.Event{"xml payload": xml, "foreign schema": foreign} {
+		t.Run(name, func(t *testing.T) {
+			got := ev.Clone()
+			if err := (&protoEncoder{}).apply(&got); err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			if !cmp.Equal(ev.Data(), got.Data()) || got.DataContentType() != ev.DataContentType() {
+				t.Errorf("apply() changed %s payload", ev.DataContentType())
+			}
+		})
+	}
+
+	t.Run("invalid payload", func(t *testing.T) {
+		ev := invalid.Clone()
+		if err := (&protoEncoder{}).apply(&ev); err == nil {
+			t.Error("apply() succeeded, want error")
+		}
+	})
+}
+
+// lastField returns the value of the last occurrence of the given
+// length-delimited field of a message
+func lastField(t *testing.T, b []byte, num protowire.Number) []byte {
+	t.Helper()
+	var value []byte
+	for len(b) > 0 {
+		n, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			t.Fatalf("ConsumeTag() error = %v", protowire.ParseError(l))
+		}
+		b = b[l:]
+		if n == num && typ == protowire.BytesType {
+			v, l := protowire.ConsumeBytes(b)
+			if l < 0 {
+				t.Fatalf("ConsumeBytes() error = %v", protowire.ParseError(l))
+			}
+			value = v
+		}
+		l = protowire.ConsumeFieldValue(n, typ, b)
+		if l < 0 {
+			t.Fatalf("ConsumeFieldValue() error = %v", protowire.ParseError(l))
+		}
+		b = b[l:]
+	}
+	if value == nil {
+		t.Fatalf("field %d not found", num)
+	}
+	return value
+}
+
+func Test_vAdapter_sendProtobufRetried(t *testing.T) {
+	alarm, err := newAlarmCloudEvent(source, &types.AlarmStatusChangedEvent{
+		AlarmEvent: types.AlarmEvent{Event: types.Event{Key: 42}},
+		To:         "red",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := &roundTripperTest{statusCodes: []int{http.StatusInternalServerError, http.StatusOK}}
+	p, err := cehttp.New(cehttp.WithRoundTripper(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead, err := newDeadLetters(2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead.backoff = wait.Backoff{}
+	a := &vAdapter{Logger: zaptest.NewLogger(t).Sugar(), CEClient: c, Source: source, Encoder: &protoEncoder{}, DeadLetters: dead}
+
+	ctx := cecontext.WithTarget(context.Background(), "http://fake.example.com")
+	if result := a.deliver(ctx, alarm, extensionContext{}); !cloudevents.IsACK(result) {
+		t.Fatalf("deliver() = %v", result)
+	}
+	if len(rt.events) != 2 {
+		t.Fatalf("sent %d events, want 2", len(rt.events))
+	}
+	// every attempt encodes the original JSON payload
+	for i, ev := range rt.events {
+		if ev.DataContentType() != ApplicationProtobuf || !cmp.Equal(ev.Data(), rt.events[0].Data()) {
+			t.Errorf("attempt %d sent %s payload %x, want the protobuf payload %x", i+1, ev.DataContentType(),
+				ev.Data(), rt.events[0].Data())
+		}
+	}
+	if alarm.DataContentType() != cloudevents.ApplicationJSON || alarm.DataSchema() != DataSchemaURL(schemaAlarm) {
+		t.Errorf("send() changed the event to %s with dataschema %s", alarm.DataContentType(), alarm.DataSchema())
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+)
+
+const (
+	// CredentialProviderSecret reads the credentials from the mounted secret
+	CredentialProviderSecret = "secret"
+	// CredentialProviderVault reads the credentials from a HashiCorp Vault
+	// secret, authenticating with the Kubernetes service account of the pod
+	CredentialProviderVault = "vault"
+	// CredentialProviderKubernetes reads the credentials from a secret through
+	// the Kubernetes API, e.g. in the shared adapter which cannot mount the
+	// secrets of all its sources
+	CredentialProviderKubernetes = "kubernetes"
+)
+
+// CredentialProvider provides the keys of the vCenter credentials of an auth
+// method, e.g. the username and password keys for basic authentication.
+type CredentialProvider interface {
+	// Key returns the value of the given key. The returned error wraps
+	// os.ErrNotExist if the key does not exist.
+	Key(ctx context.Context, key string) (string, error)
+}
+
+// newCredentialProvider returns the credential provider configured in env. The
+// kubernetes provider uses the Kubernetes client injected into ctx.
+func newCredentialProvider(ctx context.Context, env EnvConfig) (CredentialProvider, error) {
+	switch env.CredentialProvider {
+	case "", CredentialProviderSecret:
+		return secretProvider(env.secretMountPath()), nil
+	case CredentialProviderVault:
+		return newVaultProvider(env.Vault)
+	case CredentialProviderKubernetes:
+		if env.SecretName == "" {
+			return nil, errors.New("secret name required")
+		}
+		return &kubeSecretProvider{
+			secrets: kubeclient.Get(ctx).CoreV1().Secrets(env.SecretNamespace),
+			name:    env.SecretName,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported credential provider %q", env.CredentialProvider)
+	}
+}
+
+// secretProvider reads the keys of the secret mounted at the given directory
+type secretProvider string
+
+// Key implements CredentialProvider
+func (p secretProvider) Key(_ context.Context, key string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(string(p), key))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// kubeSecretProvider reads the keys of a secret through the Kubernetes API.
+// The secret is read on every call to pick up rotated credentials.
+type kubeSecretProvider struct {
+	secrets corev1client.SecretInterface
+	name    string
+}
+
+// Key implements CredentialProvider
+func (p *kubeSecretProvider) Key(ctx context.Context, key string) (string, error) {
+	secret, err := p.secrets.Get(ctx, p.name, metav1.GetOptions{})
+	if apierrs.IsNotFound(err) {
+		return "", fmt.Errorf("secret %q not found: %w", p.name, os.ErrNotExist)
+	} else if err != nil {
+		return "", fmt.Errorf("read secret %q: %w", p.name, err)
+	}
+
+	data, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %q has no key %q: %w", p.name, key, os.ErrNotExist)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+)
+
+func Test_kubeSecretProvider(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vsphere-credentials", Namespace: "ns"},
+		Data:       map[string][]byte{corev1.BasicAuthUsernameKey: []byte("user")},
+	})
+	ctx := context.WithValue(context.Background(), kubeclient.Key{}, client)
+
+	p, err := newCredentialProvider(ctx, EnvConfig{
+		CredentialProvider: CredentialProviderKubernetes,
+		SecretName:         "vsphere-credentials",
+		SecretNamespace:    "ns",
+	})
+	if err != nil {
+		t.Fatalf("newCredentialProvider() error = %v", err)
+	}
+
+	if got, err := p.Key(ctx, corev1.BasicAuthUsernameKey); err != nil || got != "user" {
+		t.Errorf("Key() = %q, %v, want %q", got, err, "user")
+	}
+	if _, err := p.Key(ctx, corev1.BasicAuthPasswordKey); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Key() of missing key error = %v, want %v", err, os.ErrNotExist)
+	}
+
+	missing, err := newCredentialProvider(ctx, EnvConfig{CredentialProvider: CredentialProviderKubernetes, SecretName: "missing"})
+	if err != nil {
+		t.Fatalf("newCredentialProvider() error = %v", err)
+	}
+	if _, err := missing.Key(ctx, corev1.BasicAuthUsernameKey); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Key() of missing secret error = %v, want %v", err, os.ErrNotExist)
+	}
+
+	if _, err := newCredentialProvider(ctx, EnvConfig{CredentialProvider: CredentialProviderKubernetes}); err == nil {
+		t.Error("newCredentialProvider() without secret name succeeded, want error")
+	}
+}
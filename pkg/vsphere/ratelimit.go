@@ -0,0 +1,97 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"golang.org/x/time/rate"
+	"knative.dev/pkg/metrics"
+)
+
+// RateLimit limits the events sent to the sink. Events exceeding the limit
+// are delayed, not dropped, which also delays reading further events from
+// vCenter.
+type RateLimit struct {
+	// EventsPerSecond is the sustained rate of events sent to the sink
+	EventsPerSecond int32 `json:"eventsPerSecond"`
+	// Burst is the number of events which may be sent at once, defaults to
+	// EventsPerSecond
+	Burst int32 `json:"burst,omitempty"`
+}
+
+var throttledCountM = stats.Int64(
+	"sink_throttled_event_count",
+	"Number of events delayed by the rate limit of the sink",
+	stats.UnitDimensionless,
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: throttledCountM.Description(),
+		Measure:     throttledCountM,
+		Aggregation: view.Count(),
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// newRateLimiter returns the limiter for the given JSON-encoded RateLimit,
+// which is nil if s is empty
+func newRateLimiter(s string) (*rate.Limiter, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var rl RateLimit
+	if err := json.Unmarshal([]byte(s), &rl); err != nil {
+		return nil, fmt.Errorf("unmarshal rate limit: %w", err)
+	}
+	if rl.EventsPerSecond < 1 {
+		return nil, fmt.Errorf("events per second must be positive, was %d", rl.EventsPerSecond)
+	}
+	if rl.Burst < 0 {
+		return nil, fmt.Errorf("burst must not be negative, was %d", rl.Burst)
+	}
+
+	burst := rl.Burst
+	if burst == 0 {
+		burst = rl.EventsPerSecond
+	}
+	return rate.NewLimiter(rate.Limit(rl.EventsPerSecond), int(burst)), nil
+}
+
+// throttle waits until the given limiter allows sending an event and records
+// the events which had to wait in the throttled metric. A nil limiter is a
+// no-op.
+func throttle(ctx context.Context, limiter *rate.Limiter) error {
+	if limiter == nil {
+		return nil
+	}
+
+	r := limiter.Reserve()
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	metrics.Record(ctx, throttledCountM.M(1))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give the token back for the next attempt
+		r.Cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func Test_newRateLimiter(t *testing.T) {
+	tests := []struct {
+		name      string
+		rateLimit string
+		wantNil   bool
+		wantLimit rate.Limit
+		wantBurst int
+		wantErr   bool
+	}{
+		{name: "unlimited", wantNil: true},
+		{name: "rate and burst", rateLimit: `{"eventsPerSecond":10,"burst":50}`, wantLimit: 10, wantBurst: 50},
+		{name: "burst defaults to rate", rateLimit: `{"eventsPerSecond":10}`, wantLimit: 10, wantBurst: 10},
+		{name: "invalid json", rateLimit: `{"eventsPerSecond":"10"}`, wantErr: true},
+		{name: "zero rate", rateLimit: `{"eventsPerSecond":0}`, wantErr: true},
+		{name: "negative burst", rateLimit: `{"eventsPerSecond":10,"burst":-1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newRateLimiter(tt.rateLimit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newRateLimiter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("newRateLimiter() = %v, wantNil %v", got, tt.wantNil)
+			}
+			if got == nil {
+				return
+			}
+			if got.Limit() != tt.wantLimit || got.Burst() != tt.wantBurst {
+				t.Errorf("newRateLimiter() limit = %v, burst = %d, want %v, %d", got.Limit(), got.Burst(), tt.wantLimit, tt.wantBurst)
+			}
+		})
+	}
+}
+
+func Test_throttle(t *testing.T) {
+	t.Run("nil limiter", func(t *testing.T) {
+		if err := throttle(context.Background(), nil); err != nil {
+			t.Errorf("throttle() error = %v", err)
+		}
+	})
+
+	t.Run("burst then delay", func(t *testing.T) {
+		limiter := rate.NewLimiter(20, 2)
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			if err := throttle(context.Background(), limiter); err != nil {
+				t.Fatalf("throttle() error = %v", err)
+			}
+		}
+		// the third event waits for a token at 20 events per second
+		if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+			t.Errorf("throttle() returned after %v, want the third event delayed", elapsed)
+		}
+	})
+
+	t.Run("context done while waiting", func(t *testing.T) {
+		limiter := rate.NewLimiter(0.1, 1)
+		if err := throttle(context.Background(), limiter); err != nil {
+			t.Fatalf("throttle() error = %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := throttle(ctx, limiter); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("throttle() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// Redaction modes of payload fields
+const (
+	// RedactionMask replaces the values of the fields with RedactedValue
+	// (default)
+	RedactionMask = "mask"
+	// RedactionRemove removes the fields
+	RedactionRemove = "remove"
+)
+
+// RedactedValue replaces the values of masked payload fields
+const RedactedValue = "REDACTED"
+
+// Redaction configures the payload fields masked or removed before events are
+// sent to the sink
+type Redaction struct {
+	// Fields are field names matched at any depth, e.g. userName, or
+	// dot-separated paths from the payload root, e.g. $.vm.name, see
+	// ParseRedactionField
+	Fields []string `json:"fields"`
+	// Mode is either mask or remove
+	Mode string `json:"mode,omitempty"`
+}
+
+// ParseRedactionField returns the path segments of a redacted field, which is
+// a field name, e.g. userName, or a dot-separated path from the root of the
+// payload with an optional $. prefix, e.g. $.vm.name. For XML payloads, the
+// root is the top-level element.
+func ParseRedactionField(field string) ([]string, error) {
+	path := strings.Split(strings.TrimPrefix(field, "$."), ".")
+	for _, s := range path {
+		if s == "" {
+			return nil, fmt.Errorf("empty field name in %q", field)
+		}
+		if strings.ContainsAny(s, "$*[]@ ") {
+			return nil, fmt.Errorf("unsupported field name %q in %q, only names and dot-separated paths are supported", s, field)
+		}
+	}
+	return path, nil
+}
+
+// redactor masks or removes fields of JSON and XML event payloads
+type redactor struct {
+	// names are matched at any depth
+	names []string
+	// paths are matched from the payload root
+	paths  [][]string
+	remove bool
+}
+
+// newRedactor returns the redactor for the given JSON-encoded Redaction,
+// which is nil if s is empty
+func newRedactor(s string) (*redactor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var rd Redaction
+	if err := json.Unmarshal([]byte(s), &rd); err != nil {
+		return nil, fmt.Errorf("unmarshal redaction: %w", err)
+	}
+
+	r := &redactor{}
+	switch rd.Mode {
+	case "", RedactionMask:
+	case RedactionRemove:
+		r.remove = true
+	default:
+		return nil, fmt.Errorf("unsupported redaction mode %q", rd.Mode)
+	}
+
+	for _, f := range rd.Fields {
+		path, err := ParseRedactionField(f)
+		if err != nil {
+			return nil, err
+		}
+		// a field with the $. prefix is a path from the root
+		if len(path) == 1 && !strings.HasPrefix(f, "$.") {
+			r.names = append(r.names, path[0])
+		} else {
+			r.paths = append(r.paths, path)
+		}
+	}
+	return r, nil
+}
+
+// match returns true if the field at the given path from the payload root is
+// redacted. Names are matched case-insensitively.
+func (r *redactor) match(path []string) bool {
+	name := path[len(path)-1]
+	for _, n := range r.names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	for _, p := range r.paths {
+		if len(p) != len(path) {
+			continue
+		}
+		matched := true
+		for i := range p {
+			matched = matched && strings.EqualFold(p[i], path[i])
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// apply redacts the payload of the given event. Payloads which are neither
+// JSON nor XML cannot be redacted and fail, so that they are not sent. A nil
+// redactor is a no-op.
+func (r *redactor) apply(ev *cloudevents.Event) error {
+	if r == nil || len(ev.Data()) == 0 {
+		return nil
+	}
+
+	contentType := ev.DataContentType()
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("parse content type %q: %w", contentType, err)
+	}
+
+	var data []byte
+	switch {
+	case mediaType == cloudevents.ApplicationJSON || strings.HasSuffix(mediaType, "+json"):
+		data, err = r.redactJSON(ev.Data())
+	case mediaType == cloudevents.ApplicationXML || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		data, err = r.redactXML(ev.Data())
+	default:
+		return fmt.Errorf("cannot redact payload of content type %q", contentType)
+	}
+	if err != nil {
+		return err
+	}
+	return ev.SetData(contentType, data)
+}
+
+func (r *redactor) redactJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// keep numbers as is
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("decode JSON payload: %w", err)
+	}
+	return json.Marshal(r.redactValue(v, nil))
+}
+
+// redactValue redacts the fields of the given JSON value at the given path
+func (r *redactor) redactValue(v interface{}, path []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			p := append(path[:len(path):len(path)], k)
+			switch {
+			case !r.match(p):
+				v[k] = r.redactValue(child, p)
+			case r.remove:
+				delete(v, k)
+			default:
+				v[k] = RedactedValue
+			}
+		}
+	case []interface{}:
+		// the elements of an array share its path
+		for i, child := range v {
+			v[i] = r.redactValue(child, path)
+		}
+	}
+	return v
+}
+
+func (r *redactor) redactXML(data []byte) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+
+	// the names of the open elements, starting with the root
+	var open []string
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode XML payload: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			// paths start below the root element
+			if len(open) > 0 && r.match(append(open[1:len(open):len(open)], t.Name.Local)) {
+				if err = dec.Skip(); err != nil {
+					return nil, fmt.Errorf("decode XML payload: %w", err)
+				}
+				if !r.remove {
+					err = enc.EncodeElement(RedactedValue, xml.StartElement{Name: t.Name})
+				}
+				break
+			}
+			open = append(open, t.Name.Local)
+			err = enc.EncodeToken(t)
+		case xml.EndElement:
+			open = open[:len(open)-1]
+			err = enc.EncodeToken(t)
+		default:
+			err = enc.EncodeToken(xml.CopyToken(tok))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encode XML payload: %w", err)
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		return nil, fmt.Errorf("encode XML payload: %w", err)
+	}
+	return buf.Bytes(), nil
+}
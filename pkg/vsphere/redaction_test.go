@@ -0,0 +1,142 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newRedactor(t *testing.T) {
+	tests := []struct {
+		name      string
+		redaction string
+		wantNil   bool
+		wantErr   bool
+	}{
+		{name: "no redaction", wantNil: true},
+		{name: "names and paths", redaction: `{"fields":["userName","$.vm.name","$.key"],"mode":"remove"}`},
+		{name: "invalid json", redaction: `{"fields":"userName"}`, wantErr: true},
+		{name: "invalid mode", redaction: `{"fields":["userName"],"mode":"hash"}`, wantErr: true},
+		{name: "invalid field", redaction: `{"fields":["$.vm[0].name"]}`, wantErr: true},
+		{name: "empty field", redaction: `{"fields":["vm..name"]}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newRedactor(tt.redaction)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newRedactor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newRedactor() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_redactor_apply(t *testing.T) {
+	vmEvent := &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{
+		Key:      42,
+		UserName: `VSPHERE.LOCAL\admin`,
+		Vm: &types.VmEventArgument{
+			EntityEventArgument: types.EntityEventArgument{Name: "vm-name"},
+			Vm:                  types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"},
+		},
+	}}}
+	jsonData := map[string]interface{}{
+		"userName": `VSPHERE.LOCAL\admin`,
+		"count":    12345678901234567,
+		"vm":       map[string]interface{}{"name": "vm-name", "ipAddress": "10.0.0.1"},
+		"hosts":    []interface{}{map[string]interface{}{"name": "esx-1", "ipAddress": "10.0.0.2"}},
+	}
+
+	tests := []struct {
+		name        string
+		redaction   string
+		contentType string
+		data        interface{}
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "mask xml names",
+			redaction:   `{"fields":["USERNAME"]}`,
+			contentType: cloudevents.ApplicationXML,
+			data:        vmEvent,
+			want:        `<VmPoweredOnEvent><key>42</key><chainId>0</chainId><createdTime>0001-01-01T00:00:00Z</createdTime><userName>REDACTED</userName><vm><name>vm-name</name><vm type="VirtualMachine">vm-1</vm></vm><template>false</template></VmPoweredOnEvent>`,
+		},
+		{
+			name:        "remove xml paths",
+			redaction:   `{"fields":["$.vm.name","$.key"],"mode":"remove"}`,
+			contentType: cloudevents.ApplicationXML,
+			data:        vmEvent,
+			want:        `<VmPoweredOnEvent><chainId>0</chainId><createdTime>0001-01-01T00:00:00Z</createdTime><userName>VSPHERE.LOCAL\admin</userName><vm><vm type="VirtualMachine">vm-1</vm></vm><template>false</template></VmPoweredOnEvent>`,
+		},
+		{
+			name:        "mask xml element with children",
+			redaction:   `{"fields":["$.vm"]}`,
+			contentType: cloudevents.ApplicationXML,
+			data:        vmEvent,
+			want:        `<VmPoweredOnEvent><key>42</key><chainId>0</chainId><createdTime>0001-01-01T00:00:00Z</createdTime><userName>VSPHERE.LOCAL\admin</userName><vm>REDACTED</vm><template>false</template></VmPoweredOnEvent>`,
+		},
+		{
+			name:        "mask json names in objects and arrays",
+			redaction:   `{"fields":["userName","ipAddress"]}`,
+			contentType: cloudevents.ApplicationJSON,
+			data:        jsonData,
+			want:        `{"count":12345678901234567,"hosts":[{"ipAddress":"REDACTED","name":"esx-1"}],"userName":"REDACTED","vm":{"ipAddress":"REDACTED","name":"vm-name"}}`,
+		},
+		{
+			name:        "remove json paths",
+			redaction:   `{"fields":["$.vm.ipAddress","$.hosts.name"],"mode":"remove"}`,
+			contentType: cloudevents.ApplicationJSON,
+			data:        jsonData,
+			want:        `{"count":12345678901234567,"hosts":[{"ipAddress":"10.0.0.2"}],"userName":"VSPHERE.LOCAL\\admin","vm":{"name":"vm-name"}}`,
+		},
+		{
+			name:        "unsupported content type",
+			redaction:   `{"fields":["userName"]}`,
+			contentType: cloudevents.TextPlain,
+			data:        "userName=admin",
+			wantErr:     true,
+		},
+		{
+			name:        "no redaction",
+			contentType: cloudevents.TextPlain,
+			data:        "userName=admin",
+			want:        "userName=admin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newRedactor(tt.redaction)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ev := cloudevents.NewEvent()
+			if err = ev.SetData(tt.contentType, tt.data); err != nil {
+				t.Fatal(err)
+			}
+
+			err = r.apply(&ev)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := string(ev.Data()); got != tt.want {
+				t.Errorf("apply() data = %s, want %s", got, tt.want)
+			}
+			if ev.DataContentType() != tt.contentType {
+				t.Errorf("apply() content type = %s, want %s", ev.DataContentType(), tt.contentType)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+const (
+	// RestartedAtAnnotation is the annotation of a VSphereSource with the
+	// RFC3339 time its adapter was last restarted at. Changing it restarts the
+	// adapter.
+	RestartedAtAnnotation = "vspheresources.sources.tanzu.vmware.com/restarted-at"
+
+	// CheckpointResetAnnotation is the annotation of a VSphereSource with an
+	// RFC3339 time before which the checkpoints of its adapter are discarded,
+	// so that it starts the event stream as without a checkpoint. Changing it
+	// restarts the adapter.
+	CheckpointResetAnnotation = "vspheresources.sources.tanzu.vmware.com/checkpoint-reset"
+
+	// CheckpointImportAnnotation is the annotation of a VSphereSource with a
+	// JSON-encoded checkpoint imported from another cluster, which its adapter
+	// uses instead of the checkpoints created before the import. Changing it
+	// restarts the adapter.
+	CheckpointImportAnnotation = "vspheresources.sources.tanzu.vmware.com/checkpoint-import"
+)
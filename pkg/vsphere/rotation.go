@@ -0,0 +1,88 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+// interval to check the mounted secret for rotated credentials
+var credentialsPollInterval = 10 * time.Second
+
+// secretVersion returns a digest of all keys of the secret mounted at dir.
+// Hidden entries, i.e. the data directories of the kubelet, are skipped.
+func secretVersion(dir string) (string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") || e.IsDir() {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(h, "%s:%d:", e.Name(), len(data))
+		_, _ = h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// watchCredentials polls the secret mounted at dir and closes the returned
+// channel once its keys changed, e.g. after the credentials were rotated. The
+// returned channel is nil if dir is empty.
+func watchCredentials(ctx context.Context, dir string, interval time.Duration) <-chan struct{} {
+	if dir == "" {
+		return nil
+	}
+	logger := logging.FromContext(ctx)
+
+	last, err := secretVersion(dir)
+	if err != nil {
+		logger.Warnw("not watching vCenter credentials for changes", zap.Error(err))
+		return nil
+	}
+
+	changed := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+				v, err := secretVersion(dir)
+				if err != nil {
+					logger.Debugw("failed to read vCenter credentials", zap.Error(err))
+					continue
+				}
+				if v != last {
+					close(changed)
+					return
+				}
+			}
+		}
+	}()
+	return changed
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// writeSecret writes the given keys to dir
+func writeSecret(t *testing.T, dir string, keys map[string]string) {
+	t.Helper()
+	for k, v := range keys {
+		if err := ioutil.WriteFile(filepath.Join(dir, k), []byte(v), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_secretVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vsphere-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeSecret(t, dir, map[string]string{corev1.BasicAuthUsernameKey: "user", corev1.BasicAuthPasswordKey: "pass"})
+	v1, err := secretVersion(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// kubelet data directories and files are hidden
+	if err = os.Mkdir(filepath.Join(dir, "..2020_01_01"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeSecret(t, dir, map[string]string{"..data": "ignored"})
+	if v, _ := secretVersion(dir); v != v1 {
+		t.Errorf("secretVersion() changed with hidden entries")
+	}
+
+	writeSecret(t, dir, map[string]string{corev1.BasicAuthPasswordKey: "rotated"})
+	if v, _ := secretVersion(dir); v == v1 {
+		t.Errorf("secretVersion() did not change with rotated password")
+	}
+
+	if _, err = secretVersion(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("secretVersion() of missing directory did not fail")
+	}
+}
+
+func Test_watchCredentials(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if watchCredentials(ctx, "", time.Millisecond) != nil {
+		t.Errorf("watchCredentials() without directory returned channel")
+	}
+	if watchCredentials(ctx, "/does/not/exist", time.Millisecond) != nil {
+		t.Errorf("watchCredentials() with missing directory returned channel")
+	}
+
+	dir, err := ioutil.TempDir("", "vsphere-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeSecret(t, dir, map[string]string{corev1.BasicAuthPasswordKey: "pass"})
+
+	changed := watchCredentials(ctx, dir, time.Millisecond)
+	select {
+	case <-changed:
+		t.Fatal("watchCredentials() reported change before credentials changed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	writeSecret(t, dir, map[string]string{corev1.BasicAuthPasswordKey: "rotated"})
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchCredentials() did not report changed credentials")
+	}
+}
+
+func Test_vAdapter_StartCredentialsChanged(t *testing.T) {
+	defaultInterval := credentialsPollInterval
+	defer func() { credentialsPollInterval = defaultInterval }()
+	credentialsPollInterval = 10 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "vsphere-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeSecret(t, dir, map[string]string{corev1.BasicAuthPasswordKey: "pass"})
+
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		vcClient := &govmomi.Client{Client: vim, SessionManager: session.NewManager(vim)}
+		kv := &fakeKVStore{dataChan: make(chan string, 10)}
+
+		var logins int
+		a := &vAdapter{
+			Source:     source,
+			VClient:    vcClient,
+			KVStore:    kv,
+			CpConfig:   CheckpointConfig{MaxAge: CheckpointDefaultAge, Period: time.Second},
+			SecretPath: dir,
+			Login: func(ctx context.Context) error {
+				logins++
+				defer time.AfterFunc(100*time.Millisecond, cancel)
+				return vcClient.SessionManager.Login(ctx, simulator.DefaultLogin)
+			},
+			Logout: func() {
+				logout(vcClient, nil)
+			},
+		}
+
+		time.AfterFunc(50*time.Millisecond, func() {
+			if err := ioutil.WriteFile(filepath.Join(dir, corev1.BasicAuthPasswordKey), []byte("rotated"), 0o600); err != nil {
+				t.Errorf("rotate password: %v", err)
+			}
+		})
+
+		if err := a.Start(ctx); err == nil || !strings.Contains(err.Error(), "context canceled") {
+			t.Errorf("Start() error = %v, want context canceled", err)
+		}
+		if logins != 1 {
+			t.Errorf("logins = %d, want 1", logins)
+		}
+
+		var status SessionStatus
+		if err := kv.Get(ctx, SessionStatusKey, &status); err != nil {
+			t.Fatal(err)
+		}
+		if status.Relogins != 1 || status.LastError != "" {
+			t.Errorf("session status = %+v", status)
+		}
+	})
+}
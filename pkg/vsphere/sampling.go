@@ -0,0 +1,272 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+const (
+	// SampledCountExtension is the extension attribute of a summary event with
+	// the number of events it stands for
+	SampledCountExtension = "sampledcount"
+	// SampledSinceExtension is the extension attribute of a summary event with
+	// the creation time of the first event it stands for
+	SampledSinceExtension = "sampledsince"
+)
+
+// Sampling coalesces bursts of events, e.g. on mass power operations. Within a
+// window, the first Threshold events of the same type about the same entity
+// are sent as usual. The remaining events are coalesced into a single summary
+// event when the window closes, which is the last of these events with the
+// number of events it stands for in the sampledcount extension attribute.
+type Sampling struct {
+	// Window is the duration of the window opened by the first event of a type
+	// about an entity
+	Window time.Duration `json:"window"`
+	// Threshold is the number of events sent individually per window,
+	// defaults to 1
+	Threshold int32 `json:"threshold,omitempty"`
+}
+
+var sampledCountM = stats.Int64(
+	"sampled_event_count",
+	"Number of events coalesced into summary events by sampling",
+	stats.UnitDimensionless,
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: sampledCountM.Description(),
+		Measure:     sampledCountM,
+		Aggregation: view.Count(),
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// sampleKey identifies the events coalesced by sampling
+type sampleKey struct {
+	eventType string
+	entity    string
+}
+
+// sample is the window of the events of a sampleKey
+type sample struct {
+	key sampleKey
+	// first is the creation time of the first event of the window
+	first time.Time
+	// opened is when the window was opened, which closes windows of an idle
+	// event stream
+	opened time.Time
+	// sent is the number of events of the window sent individually
+	sent int32
+	// coalesced is the number of events of the window not sent individually
+	coalesced int32
+	// firstCoalesced is the first coalesced event
+	firstCoalesced types.BaseEvent
+	// last is the last coalesced event, which is sent as summary
+	last types.BaseEvent
+}
+
+// sampler keeps the open sampling windows. It is not safe for concurrent use.
+type sampler struct {
+	window    time.Duration
+	threshold int32
+	samples   map[sampleKey]*sample
+	// order are the open windows in the order they were opened, in which
+	// they close
+	order []*sample
+}
+
+// newSampler returns the sampler for the given JSON-encoded Sampling, which is
+// nil if s is empty
+func newSampler(s string) (*sampler, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var sm Sampling
+	if err := json.Unmarshal([]byte(s), &sm); err != nil {
+		return nil, fmt.Errorf("unmarshal sampling: %w", err)
+	}
+	if sm.Window <= 0 {
+		return nil, fmt.Errorf("window must be positive, was %s", sm.Window)
+	}
+	if sm.Threshold < 0 {
+		return nil, fmt.Errorf("threshold must not be negative, was %d", sm.Threshold)
+	}
+
+	threshold := sm.Threshold
+	if threshold == 0 {
+		threshold = 1
+	}
+	return &sampler{window: sm.Window, threshold: threshold, samples: make(map[sampleKey]*sample)}, nil
+}
+
+// newSampleKey returns the key of the given event, whose entity is the most
+// specific managed object the event is about
+func newSampleKey(be types.BaseEvent) sampleKey {
+	e := be.GetEvent()
+	key := sampleKey{eventType: getEventDetails(be).Type}
+	switch {
+	case e.Vm != nil:
+		key.entity = e.Vm.Vm.String()
+	case e.Host != nil:
+		key.entity = e.Host.Host.String()
+	case e.ComputeResource != nil:
+		key.entity = e.ComputeResource.ComputeResource.String()
+	case e.Ds != nil:
+		key.entity = e.Ds.Datastore.String()
+	case e.Net != nil:
+		key.entity = e.Net.Network.String()
+	case e.Dvs != nil:
+		key.entity = e.Dvs.Dvs.String()
+	case e.Datacenter != nil:
+		key.entity = e.Datacenter.Datacenter.String()
+	}
+	return key
+}
+
+// admit returns whether the given event, read at now, is sent individually.
+// Otherwise it is coalesced into the summary of its window. A nil sampler
+// admits all events.
+func (s *sampler) admit(be types.BaseEvent, now time.Time) bool {
+	if s == nil {
+		return true
+	}
+
+	key := newSampleKey(be)
+	sm, ok := s.samples[key]
+	if !ok {
+		sm = &sample{key: key, first: be.GetEvent().CreatedTime, opened: now}
+		s.samples[key] = sm
+		s.order = append(s.order, sm)
+	}
+	if sm.sent < s.threshold {
+		sm.sent++
+		return true
+	}
+	if sm.coalesced == 0 {
+		sm.firstCoalesced = be
+	}
+	sm.coalesced++
+	sm.last = be
+	return false
+}
+
+// expired returns the windows closed before an event created at the given
+// time is read at now which have coalesced events, and removes the others. A
+// zero created time only closes the windows opened a window before now, e.g.
+// when no new events are read. The returned windows are kept until their
+// summary is sent, so that they are returned again if it fails.
+func (s *sampler) expired(created, now time.Time) []*sample {
+	if s == nil {
+		return nil
+	}
+
+	return s.close(func(sm *sample) bool {
+		return (!created.IsZero() && created.Sub(sm.first) >= s.window) || now.Sub(sm.opened) >= s.window
+	})
+}
+
+// drain closes all windows and returns those with coalesced events, e.g. on
+// shutdown. Like expired, the returned windows are kept until their summary
+// is sent.
+func (s *sampler) drain() []*sample {
+	if s == nil {
+		return nil
+	}
+
+	return s.close(func(*sample) bool { return true })
+}
+
+// close closes the leading windows for which closed returns true, in the
+// order they were opened. Closed windows without coalesced events are
+// removed, the others are returned.
+func (s *sampler) close(closed func(sm *sample) bool) []*sample {
+	var pending []*sample
+	i := 0
+	for ; i < len(s.order) && closed(s.order[i]); i++ {
+		sm := s.order[i]
+		if sm.coalesced > 0 {
+			pending = append(pending, sm)
+		} else {
+			delete(s.samples, sm.key)
+		}
+	}
+	s.order = append(append([]*sample{}, pending...), s.order[i:]...)
+	return pending
+}
+
+// done removes the given window after its summary was sent
+func (s *sampler) done(sm *sample) {
+	if s == nil {
+		return
+	}
+
+	delete(s.samples, sm.key)
+	for i, o := range s.order {
+		if o == sm {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// pending returns the first coalesced event of the windows whose summary was
+// not sent yet, or nil if there is none. The checkpoint must not pass this
+// event, so that the coalesced events are replayed if the summary is lost.
+func (s *sampler) pending() types.BaseEvent {
+	if s == nil {
+		return nil
+	}
+
+	var first types.BaseEvent
+	for _, sm := range s.order {
+		if sm.coalesced > 0 && (first == nil || sm.firstCoalesced.GetEvent().Key < first.GetEvent().Key) {
+			first = sm.firstCoalesced
+		}
+	}
+	return first
+}
+
+// annotate adds the number and the creation time of the first of the coalesced
+// events of the window to the given summary event
+func (sm *sample) annotate(ev *cloudevents.Event) {
+	ev.SetExtension(SampledCountExtension, sm.coalesced)
+	ev.SetExtension(SampledSinceExtension, sm.first.UTC())
+}
+
+// sendSummaries sends the summary events of the given closed windows, which
+// are removed from the sampler once their summary is sent. It returns on the
+// first error.
+func (a *vAdapter) sendSummaries(ctx context.Context, samples []*sample) error {
+	for _, sm := range samples {
+		logging.FromContext(ctx).Debugw("sending summary of sampled events", "type", sm.key.eventType,
+			"entity", sm.key.entity, "count", sm.coalesced)
+		if err := a.sendEvent(ctx, sm.last, sm); err != nil {
+			return err
+		}
+		a.Sampler.done(sm)
+	}
+	return nil
+}
+
+// recordSampled records a coalesced event in the sampled metric
+func recordSampled(ctx context.Context) {
+	metrics.Record(ctx, sampledCountM.M(1))
+}
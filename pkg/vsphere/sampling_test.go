@@ -0,0 +1,262 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newSampler(t *testing.T) {
+	tests := []struct {
+		name          string
+		sampling      string
+		wantNil       bool
+		wantWindow    time.Duration
+		wantThreshold int32
+		wantErr       bool
+	}{
+		{name: "disabled", wantNil: true},
+		{name: "window and threshold", sampling: `{"window":60000000000,"threshold":3}`, wantWindow: time.Minute, wantThreshold: 3},
+		{name: "threshold defaults to 1", sampling: `{"window":60000000000}`, wantWindow: time.Minute, wantThreshold: 1},
+		{name: "invalid json", sampling: `{"window":"1m"}`, wantErr: true},
+		{name: "zero window", sampling: `{"window":0}`, wantErr: true},
+		{name: "negative threshold", sampling: `{"window":60000000000,"threshold":-1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newSampler(tt.sampling)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSampler() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("newSampler() = %v, wantNil %v", got, tt.wantNil)
+			}
+			if got == nil {
+				return
+			}
+			if got.window != tt.wantWindow || got.threshold != tt.wantThreshold {
+				t.Errorf("newSampler() window = %v, threshold = %d, want %v, %d", got.window, got.threshold, tt.wantWindow, tt.wantThreshold)
+			}
+		})
+	}
+}
+
+// newPoweredOnEvent returns a VmPoweredOnEvent about the given virtual machine
+func newPoweredOnEvent(key int32, vm string, created time.Time) types.BaseEvent {
+	return &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{
+		Key:         key,
+		CreatedTime: created,
+		Vm:          &types.VmEventArgument{Vm: types.ManagedObjectReference{Type: "VirtualMachine", Value: vm}},
+	}}}
+}
+
+func Test_sampler(t *testing.T) {
+	begin := time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	t.Run("nil sampler admits all events", func(t *testing.T) {
+		var s *sampler
+		if !s.admit(newPoweredOnEvent(1, "vm-1", begin), now) {
+			t.Error("admit() = false, want true")
+		}
+		if got := s.expired(begin, now); got != nil {
+			t.Errorf("expired() = %v, want nil", got)
+		}
+	})
+
+	t.Run("coalesces events above the threshold per type and entity", func(t *testing.T) {
+		s := &sampler{window: time.Minute, threshold: 2, samples: make(map[sampleKey]*sample)}
+		var admitted []int32
+		for i, vm := range []string{"vm-1", "vm-1", "vm-2", "vm-1", "vm-1"} {
+			be := newPoweredOnEvent(int32(i), vm, begin.Add(time.Duration(i)*time.Second))
+			if s.admit(be, now) {
+				admitted = append(admitted, be.GetEvent().Key)
+			}
+		}
+		if want := []int32{0, 1, 2}; len(admitted) != len(want) || admitted[0] != 0 || admitted[1] != 1 || admitted[2] != 2 {
+			t.Errorf("admit() admitted %v, want %v", admitted, want)
+		}
+
+		if got := s.expired(begin.Add(30*time.Second), now); len(got) != 0 {
+			t.Errorf("expired() within the window = %v, want none", got)
+		}
+		got := s.expired(begin.Add(time.Minute), now)
+		if len(got) != 1 {
+			t.Fatalf("expired() after the window = %v, want the window of vm-1", got)
+		}
+		if got[0].coalesced != 2 || got[0].last.GetEvent().Key != 4 || !got[0].first.Equal(begin) {
+			t.Errorf("expired() = %+v, want 2 coalesced events up to key 4 since %v", got[0], begin)
+		}
+		// the closed window is kept until its summary is sent
+		if len(s.samples) != 2 || len(s.order) != 2 {
+			t.Errorf("expired() kept %d windows, want the windows of vm-1 and vm-2", len(s.samples))
+		}
+		if pending := s.pending(); pending == nil || pending.GetEvent().Key != 3 {
+			t.Errorf("pending() = %v, want the first coalesced event 3", pending)
+		}
+		if again := s.expired(begin.Add(time.Minute), now); len(again) != 1 || again[0] != got[0] {
+			t.Errorf("expired() before the summary is sent = %v, want the window of vm-1 again", again)
+		}
+		s.done(got[0])
+		if len(s.samples) != 1 || len(s.order) != 1 || s.pending() != nil {
+			t.Errorf("done() kept %d windows, want the window of vm-2", len(s.samples))
+		}
+
+		// a new window is opened after the summary was sent
+		if !s.admit(newPoweredOnEvent(5, "vm-1", begin.Add(time.Minute)), now) {
+			t.Error("admit() in a new window = false, want true")
+		}
+	})
+
+	t.Run("closes windows of an idle event stream", func(t *testing.T) {
+		s := &sampler{window: time.Minute, threshold: 1, samples: make(map[sampleKey]*sample)}
+		s.admit(newPoweredOnEvent(1, "vm-1", begin), now)
+		s.admit(newPoweredOnEvent(2, "vm-1", begin), now)
+
+		if got := s.expired(time.Time{}, now.Add(30*time.Second)); len(got) != 0 {
+			t.Errorf("expired() within the window = %v, want none", got)
+		}
+		if got := s.expired(time.Time{}, now.Add(time.Minute)); len(got) != 1 || got[0].coalesced != 1 {
+			t.Errorf("expired() after the window = %v, want one coalesced event", got)
+		}
+	})
+
+	t.Run("drains open windows", func(t *testing.T) {
+		s := &sampler{window: time.Minute, threshold: 1, samples: make(map[sampleKey]*sample)}
+		s.admit(newPoweredOnEvent(1, "vm-1", begin), now)
+		s.admit(newPoweredOnEvent(2, "vm-2", begin), now)
+		s.admit(newPoweredOnEvent(3, "vm-2", begin), now)
+
+		got := s.drain()
+		if len(got) != 1 || got[0].last.GetEvent().Key != 3 {
+			t.Fatalf("drain() = %v, want the window of vm-2", got)
+		}
+		if len(s.samples) != 1 || len(s.order) != 1 {
+			t.Errorf("drain() kept %d windows, want the window of vm-2", len(s.samples))
+		}
+		s.done(got[0])
+		if len(s.samples) != 0 || len(s.order) != 0 {
+			t.Errorf("done() kept %d windows, want none", len(s.samples))
+		}
+	})
+}
+
+func Test_vAdapter_sendEvents_sampling(t *testing.T) {
+	roundTripper := &roundTripperTest{statusCodes: createStatusCodes(3, failNever)}
+	p, err := cehttp.New(cehttp.WithRoundTripper(roundTripper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := cecontext.WithTarget(context.Background(), "fake.example.com")
+
+	begin := time.Now().UTC().Add(-time.Minute)
+	storm := []types.BaseEvent{
+		newPoweredOnEvent(1, "vm-1", begin),
+		newPoweredOnEvent(2, "vm-1", begin.Add(time.Second)),
+		newPoweredOnEvent(3, "vm-1", begin.Add(2*time.Second)),
+		newPoweredOnEvent(4, "vm-1", begin.Add(3*time.Second)),
+	}
+	a := &vAdapter{CEClient: c, Source: source,
+		Sampler: &sampler{window: 10 * time.Second, threshold: 1, samples: make(map[sampleKey]*sample)}}
+
+	n, err := a.sendEvents(ctx, storm)
+	if err != nil || n != len(storm) {
+		t.Fatalf("sendEvents() = %d, %v, want %d events processed", n, err, len(storm))
+	}
+	if len(roundTripper.events) != 1 || roundTripper.events[0].ID() != "1" {
+		t.Fatalf("sendEvents() sent %d events, want the first event of the window", len(roundTripper.events))
+	}
+
+	// the next event closes the window, whose summary is sent first
+	n, err = a.sendEvents(ctx, []types.BaseEvent{newPoweredOnEvent(5, "vm-1", begin.Add(10*time.Second))})
+	if err != nil || n != 1 {
+		t.Fatalf("sendEvents() = %d, %v, want 1 event processed", n, err)
+	}
+	if len(roundTripper.events) != 3 {
+		t.Fatalf("sendEvents() sent %d events, want the summary and the next event", len(roundTripper.events))
+	}
+	summary := roundTripper.events[1]
+	if summary.ID() != "4" || summary.Type() != "com.vmware.vsphere.VmPoweredOnEvent" {
+		t.Errorf("summary id = %s, type = %s, want the last coalesced event", summary.ID(), summary.Type())
+	}
+	// extension attributes are received as strings
+	if got := summary.Extensions()[SampledCountExtension]; got != "3" {
+		t.Errorf("summary %s = %v, want 3", SampledCountExtension, got)
+	}
+	if roundTripper.events[2].ID() != "5" {
+		t.Errorf("sendEvents() sent %s after the summary, want 5", roundTripper.events[2].ID())
+	}
+}
+
+func Test_vAdapter_sendEvents_samplingSummaryFails(t *testing.T) {
+	roundTripper := &roundTripperTest{statusCodes: []int{200, 500, 200, 200}}
+	p, err := cehttp.New(cehttp.WithRoundTripper(roundTripper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.New(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := cecontext.WithTarget(context.Background(), "fake.example.com")
+
+	begin := time.Now().UTC().Add(-time.Minute)
+	storm := []types.BaseEvent{
+		newPoweredOnEvent(1, "vm-1", begin),
+		newPoweredOnEvent(2, "vm-1", begin.Add(time.Second)),
+		newPoweredOnEvent(3, "vm-1", begin.Add(2*time.Second)),
+	}
+	a := &vAdapter{CEClient: c, Source: source,
+		Sampler: &sampler{window: 10 * time.Second, threshold: 1, samples: make(map[sampleKey]*sample)}}
+
+	if n, err := a.sendEvents(ctx, storm); err != nil || n != len(storm) {
+		t.Fatalf("sendEvents() = %d, %v, want %d events processed", n, err, len(storm))
+	}
+
+	// the checkpoint does not pass the coalesced events before their summary
+	// is sent
+	cp := a.newCheckpoint(storm[2], begin)
+	if cp.LastEventKey != 1 || !cp.LastEventKeyTimestamp.Equal(begin.Add(time.Second)) {
+		t.Errorf("newCheckpoint() = key %d at %v, want key 1 at the first coalesced event", cp.LastEventKey, cp.LastEventKeyTimestamp)
+	}
+	next := newPoweredOnEvent(4, "vm-1", begin.Add(10*time.Second))
+	if n, err := a.sendEvents(ctx, []types.BaseEvent{next}); err == nil || n != 0 {
+		t.Fatalf("sendEvents() with failing summary = %d, %v, want an error", n, err)
+	}
+
+	// the window is kept and its summary sent with the next event
+	if n, err := a.sendEvents(ctx, []types.BaseEvent{next}); err != nil || n != 1 {
+		t.Fatalf("sendEvents() = %d, %v, want 1 event processed", n, err)
+	}
+	var ids []string
+	for _, ev := range roundTripper.events {
+		ids = append(ids, ev.ID())
+	}
+	if want := []string{"1", "3", "3", "4"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("sendEvents() sent %v, want the first event, the failed and the sent summary and the next event", ids)
+	}
+	if got := roundTripper.events[2].Extensions()[SampledCountExtension]; got != "2" {
+		t.Errorf("summary %s = %v, want 2", SampledCountExtension, got)
+	}
+	if cp = a.newCheckpoint(next, begin); cp.LastEventKey != 4 {
+		t.Errorf("newCheckpoint() = key %d, want 4 after the summary was sent", cp.LastEventKey)
+	}
+}
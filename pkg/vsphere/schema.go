@@ -0,0 +1,146 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vapi/library"
+)
+
+// DataSchemaBaseURL is the base URL of the published JSON schemas of the event
+// payloads, see hack/update-codegen.sh
+const DataSchemaBaseURL = "https://raw.githubusercontent.com/vmware-tanzu/sources-for-knative/main/schemas/"
+
+// names of the published JSON schemas
+const (
+	schemaAlarm              = "alarm"
+	schemaPropertyChange     = "propertychange"
+	schemaTagAssociation     = "tagassociation"
+	schemaContentLibrary     = "contentlibrary-library"
+	schemaContentLibraryItem = "contentlibrary-item"
+)
+
+// DataSchemas are the JSON payload types of the emitted events by schema name.
+// vSphere events and tasks are sent as XML and have no JSON schema.
+var DataSchemas = map[string]interface{}{
+	schemaAlarm:              AlarmEventData{},
+	schemaPropertyChange:     PropertyChangeEventData{},
+	schemaTagAssociation:     TagAssociationEventData{},
+	schemaContentLibrary:     library.Library{},
+	schemaContentLibraryItem: library.Item{},
+}
+
+// DataSchemaURL returns the URL of the JSON schema with the given name
+func DataSchemaURL(name string) string {
+	return DataSchemaBaseURL + name + ".json"
+}
+
+// setDataSchema sets the dataschema attribute of the given event to the JSON
+// schema with the given name
+func setDataSchema(ev *cloudevents.Event, name string) {
+	ev.SetDataSchema(DataSchemaURL(name))
+}
+
+// JSONSchema returns the JSON schema (draft-07) of the JSON representation of
+// v, derived from its type and json struct tags
+func JSONSchema(name string, v interface{}) ([]byte, error) {
+	s := schemaOf(reflect.TypeOf(v))
+	s["$schema"] = "http://json-schema.org/draft-07/schema#"
+	s["$id"] = DataSchemaURL(name)
+	s["title"] = name
+	return json.MarshalIndent(s, "", "  ")
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func schemaOf(t reflect.Type) map[string]interface{} {
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem())
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		var required []string
+		addProperties(t, properties, &required)
+
+		s := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		// any value, e.g. interface{}
+		return map[string]interface{}{}
+	}
+}
+
+// addProperties adds the JSON properties of the given struct type, including
+// those of embedded structs, following the rules of encoding/json
+func addProperties(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addProperties(ft, properties, required)
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		properties[name] = schemaOf(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJSONSchema(t *testing.T) {
+	type embedded struct {
+		Embedded string `json:"embedded"`
+	}
+	type payload struct {
+		embedded
+		Name     string            `json:"name"`
+		Optional int32             `json:"optional,omitempty"`
+		Time     *time.Time        `json:"time"`
+		Labels   map[string]string `json:"labels,omitempty"`
+		Refs     []ObjectRef       `json:"refs,omitempty"`
+		Value    interface{}       `json:"value,omitempty"`
+		Ignored  string            `json:"-"`
+	}
+
+	b, err := JSONSchema("test", payload{})
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+
+	var got map[string]interface{}
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"$id":     DataSchemaURL("test"),
+		"title":   "test",
+		"type":    "object",
+		"properties": map[string]interface{}{
+			"embedded": map[string]interface{}{"type": "string"},
+			"name":     map[string]interface{}{"type": "string"},
+			"optional": map[string]interface{}{"type": "integer"},
+			"time":     map[string]interface{}{"type": "string", "format": "date-time"},
+			"labels": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+			"refs": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name":  map[string]interface{}{"type": "string"},
+						"type":  map[string]interface{}{"type": "string"},
+						"value": map[string]interface{}{"type": "string"},
+					},
+					"required": []interface{}{"type", "value"},
+				},
+			},
+			"value": map[string]interface{}{},
+		},
+		"required": []interface{}{"embedded", "name"},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("JSONSchema() (-want, +got) = %v", diff)
+	}
+}
+
+// TestPublishedSchemas verifies that the schemas in the schemas directory are
+// up to date, run hack/update-codegen.sh otherwise
+func TestPublishedSchemas(t *testing.T) {
+	for name, v := range DataSchemas {
+		t.Run(name, func(t *testing.T) {
+			want, err := JSONSchema(name, v)
+			if err != nil {
+				t.Fatalf("JSONSchema() error = %v", err)
+			}
+
+			got, err := ioutil.ReadFile(filepath.Join("..", "..", "schemas", name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(string(want)+"\n", string(got)); diff != "" {
+				t.Errorf("schema %s is outdated (-want, +got) = %v", name, diff)
+			}
+		})
+	}
+}
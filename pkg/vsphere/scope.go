@@ -0,0 +1,110 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ScopeConfig identifies the vSphere inventory object whose subtree the
+// events and tasks are collected from, by inventory path or managed object
+// reference. Exactly one of Path and ID is set.
+type ScopeConfig struct {
+	// Path is the inventory path of the object, e.g. /dc1/host/cluster1
+	Path string `json:"path,omitempty"`
+	// ID is the managed object reference of the object as type:value, e.g.
+	// ClusterComputeResource:domain-c7
+	ID string `json:"id,omitempty"`
+}
+
+// scopeTypes are the types of the inventory objects a source can be scoped
+// to, all of which have a subtree of entities
+var scopeTypes = []string{
+	"Datacenter",
+	"Folder",
+	"ClusterComputeResource",
+	"ComputeResource",
+	"ResourcePool",
+	"VirtualApp",
+}
+
+// ValidScopeType returns an error if a source cannot be scoped to an
+// inventory object of the given type.
+func ValidScopeType(t string) error {
+	for _, st := range scopeTypes {
+		if t == st {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported scope type %q, must be one of %s", t, strings.Join(scopeTypes, ", "))
+}
+
+// ParseScopeID returns the managed object reference of the given scope ID,
+// e.g. ClusterComputeResource:domain-c7.
+func ParseScopeID(id string) (types.ManagedObjectReference, error) {
+	var ref types.ManagedObjectReference
+	if !ref.FromString(id) {
+		return ref, fmt.Errorf("invalid managed object reference %q, expected type:value, e.g. ClusterComputeResource:domain-c7", id)
+	}
+	return ref, ValidScopeType(ref.Type)
+}
+
+// newScope returns the scope for the given JSON-encoded ScopeConfig, which is
+// nil if s is empty.
+func newScope(s string) (*ScopeConfig, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var sc ScopeConfig
+	if err := json.Unmarshal([]byte(s), &sc); err != nil {
+		return nil, fmt.Errorf("unmarshal scope: %w", err)
+	}
+	switch {
+	case sc.Path == "" && sc.ID == "":
+		return nil, errors.New("scope requires a path or an id")
+	case sc.Path != "" && sc.ID != "":
+		return nil, errors.New("scope requires either a path or an id, not both")
+	case sc.ID != "":
+		if _, err := ParseScopeID(sc.ID); err != nil {
+			return nil, err
+		}
+	}
+	return &sc, nil
+}
+
+// root returns the managed object reference of the inventory object of the
+// scope, which is looked up by its path in vCenter, or the root folder of the
+// inventory if sc is nil.
+func (sc *ScopeConfig) root(ctx context.Context, client *vim25.Client) (types.ManagedObjectReference, error) {
+	if sc == nil {
+		return client.ServiceContent.RootFolder, nil
+	}
+	if sc.ID != "" {
+		return ParseScopeID(sc.ID)
+	}
+
+	obj, err := object.NewSearchIndex(client).FindByInventoryPath(ctx, sc.Path)
+	if err != nil {
+		return types.ManagedObjectReference{}, fmt.Errorf("find scope %q: %w", sc.Path, err)
+	}
+	if obj == nil {
+		return types.ManagedObjectReference{}, fmt.Errorf("scope %q not found", sc.Path)
+	}
+	ref := obj.Reference()
+	if err := ValidScopeType(ref.Type); err != nil {
+		return types.ManagedObjectReference{}, fmt.Errorf("scope %q: %w", sc.Path, err)
+	}
+	return ref, nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    *ScopeConfig
+		wantErr bool
+	}{
+		{name: "whole inventory"},
+		{name: "path", config: `{"path":"/dc1/host/cluster1"}`, want: &ScopeConfig{Path: "/dc1/host/cluster1"}},
+		{name: "id", config: `{"id":"ClusterComputeResource:domain-c7"}`, want: &ScopeConfig{ID: "ClusterComputeResource:domain-c7"}},
+		{name: "invalid JSON", config: `{`, wantErr: true},
+		{name: "neither path nor id", config: `{}`, wantErr: true},
+		{name: "path and id", config: `{"path":"/dc1","id":"Datacenter:datacenter-2"}`, wantErr: true},
+		{name: "invalid id", config: `{"id":"domain-c7"}`, wantErr: true},
+		{name: "id of a virtual machine", config: `{"id":"VirtualMachine:vm-42"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newScope(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newScope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("newScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ScopeConfig_root(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		cluster, err := find.NewFinder(vim).ClusterComputeResource(ctx, "/DC0/host/DC0_C0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name    string
+			scope   *ScopeConfig
+			want    types.ManagedObjectReference
+			wantErr bool
+		}{
+			{name: "whole inventory", want: vim.ServiceContent.RootFolder},
+			{name: "path", scope: &ScopeConfig{Path: "/DC0/host/DC0_C0"}, want: cluster.Reference()},
+			{name: "id", scope: &ScopeConfig{ID: cluster.Reference().String()}, want: cluster.Reference()},
+			{name: "path not found", scope: &ScopeConfig{Path: "/DC0/host/missing"}, wantErr: true},
+			{name: "path of a virtual machine", scope: &ScopeConfig{Path: "/DC0/vm/DC0_H0_VM0"}, wantErr: true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := tt.scope.root(ctx, vim)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("root() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if err == nil && got != tt.want {
+					t.Errorf("root() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+}
+
+func Test_newHistoryCollectorScoped(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		finder := find.NewFinder(vim)
+		cluster, err := finder.ClusterComputeResource(ctx, "/DC0/host/DC0_C0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		coll, err := newHistoryCollector(ctx, vim, cluster.Reference(), time.Now().Add(-time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, name := range []string{"DC0_H0_VM0", "DC0_C0_RP0_VM0"} {
+			vm, err := finder.VirtualMachine(ctx, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			task, err := vm.PowerOff(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = task.Wait(ctx)
+		}
+
+		events, err := coll.ReadNextEvents(ctx, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var poweredOff bool
+		for _, e := range events {
+			vm := e.GetEvent().Vm
+			if vm != nil && vm.Name == "DC0_H0_VM0" {
+				t.Errorf("ReadNextEvents() = %T about the standalone host, want only events of the cluster", e)
+			}
+			if _, ok := e.(*types.VmPoweredOffEvent); ok && vm != nil && vm.Name == "DC0_C0_RP0_VM0" {
+				poweredOff = true
+			}
+		}
+		if !poweredOff {
+			t.Error("ReadNextEvents() has no VmPoweredOffEvent about DC0_C0_RP0_VM0")
+		}
+	})
+}
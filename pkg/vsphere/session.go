@@ -0,0 +1,162 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+// SessionStatusKey is the key of the vCenter session status in the kvstore of
+// the adapter
+const SessionStatusKey = "session"
+
+// SessionStatus records the logins of the adapter after its vCenter session
+// expired or its credentials changed.
+type SessionStatus struct {
+	// Relogins is the number of successful logins after the session expired or
+	// the credentials changed
+	Relogins int64 `json:"relogins"`
+	// LastRelogin is the time of the last successful login after the session
+	// expired or the credentials changed
+	LastRelogin time.Time `json:"lastRelogin,omitempty"`
+	// LastError is the error of the last failed login, cleared with the next
+	// successful login
+	LastError string `json:"lastError,omitempty"`
+}
+
+// backoff between login attempts after the session expired or the credentials
+// changed, unless configured otherwise, see APIConfig
+var reloginBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    8,
+	Cap:      time.Minute,
+}
+
+var (
+	reloginCountM = stats.Int64(
+		"vcenter_relogin_count",
+		"Number of logins to vCenter after the session expired or the credentials changed",
+		stats.UnitDimensionless,
+	)
+	reloginResultKey = tag.MustNewKey("result")
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: reloginCountM.Description(),
+		Measure:     reloginCountM,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{reloginResultKey},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// isNotAuthenticated returns true if err, or any error it wraps, is caused by
+// an expired or invalidated vCenter session of the SOAP or REST client
+func isNotAuthenticated(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if soap.IsSoapFault(err) {
+			if _, ok := soap.ToSoapFault(err).VimFault().(types.NotAuthenticated); ok {
+				return true
+			}
+		}
+		if soap.IsVimFault(err) {
+			if _, ok := soap.ToVimFault(err).(*types.NotAuthenticated); ok {
+				return true
+			}
+		}
+		// the REST client does not export its status error
+		if strings.HasSuffix(err.Error(), fmt.Sprintf("%d %s", http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))) {
+			return true
+		}
+	}
+	return false
+}
+
+// relogin logs in to vCenter again with backoff after the session expired or
+// the credentials changed, as given by reason, and records the result in the
+// session status and the relogin metric.
+func (a *vAdapter) relogin(ctx context.Context, reason string) error {
+	logger := logging.FromContext(ctx)
+
+	var status SessionStatus
+	if err := a.KVStore.Get(ctx, SessionStatusKey, &status); err != nil {
+		logger.Debugw("no previous session status", zap.Error(err))
+	}
+
+	var loginErr error
+	err := wait.ExponentialBackoff(a.API.reloginBackoff(), func() (bool, error) {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		if loginErr = a.Login(ctx); loginErr != nil {
+			logger.Warnw("failed to login to vCenter", zap.Error(loginErr), errorCategoryField(loginErr))
+			return false, nil
+		}
+		return true, nil
+	})
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+		if loginErr == nil {
+			loginErr = err
+		}
+		status.LastError = loginErr.Error()
+		recordError(ctx, loginErr)
+	} else {
+		status.Relogins++
+		status.LastRelogin = time.Now().UTC()
+		status.LastError = ""
+	}
+	metrics.Record(ctx, reloginCountM.M(1), stats.WithTags(tag.Insert(reloginResultKey, result)))
+
+	var connErr error
+	if err != nil {
+		connErr = loginErr
+	}
+	connStatus := newConnectionStatus(connErr)
+	if connErr == nil {
+		connStatus.FallbackAddress = a.FallbackAddress
+	}
+	if serr := a.KVStore.Set(ctx, ConnectionStatusKey, connStatus); serr != nil {
+		logger.Warnw("failed to set connection status", zap.Error(serr))
+	}
+	if serr := a.KVStore.Set(ctx, SessionStatusKey, status); serr != nil {
+		logger.Warnw("failed to set session status", zap.Error(serr))
+	} else if serr = a.KVStore.Save(ctx); serr != nil {
+		logger.Warnw("failed to save session status", zap.Error(serr))
+	}
+
+	if err != nil {
+		return fmt.Errorf("login to vCenter after %s: %w", reason, loginErr)
+	}
+	logger.Infow("logged in to vCenter after "+reason, zap.Int64("relogins", status.Relogins))
+	a.sendLifecycle(ctx, ReauthenticatedEventType, Lifecycle{Reason: reason, Relogins: status.Relogins})
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func Test_isNotAuthenticated(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		if err := session.NewManager(vim).Logout(ctx); err != nil {
+			t.Fatal(err)
+		}
+		_, expired := methods.GetCurrentTime(ctx, vim)
+
+		tests := []struct {
+			name string
+			err  error
+			want bool
+		}{
+			{name: "no error"},
+			{name: "other error", err: errors.New("connection refused")},
+			{name: "other fault", err: soap.WrapVimFault(&types.InvalidLogin{})},
+			{name: "soap fault", err: expired, want: true},
+			{name: "wrapped soap fault", err: fmt.Errorf("read events from vcenter: %w", expired), want: true},
+			{name: "vim fault", err: soap.WrapVimFault(&types.NotAuthenticated{}), want: true},
+			{name: "rest status", err: errors.New("GET https://vcenter/rest/com/vmware/cis/tagging/tag: 401 Unauthorized"), want: true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := isNotAuthenticated(tt.err); got != tt.want {
+					t.Errorf("isNotAuthenticated(%v) = %v, want %v", tt.err, got, tt.want)
+				}
+			})
+		}
+	})
+}
+
+func Test_vAdapter_relogin(t *testing.T) {
+	defaultBackoff := reloginBackoff
+	defer func() { reloginBackoff = defaultBackoff }()
+	reloginBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+
+	t.Run("failed login", func(t *testing.T) {
+		var attempts int
+		kv := &fakeKVStore{dataChan: make(chan string, 1)}
+		a := &vAdapter{
+			KVStore: kv,
+			Login: func(ctx context.Context) error {
+				attempts++
+				return fmt.Errorf("invalid login %d", attempts)
+			},
+		}
+
+		if err := a.relogin(context.Background(), "session expired"); err == nil {
+			t.Fatal("relogin() did not fail")
+		}
+		if attempts != reloginBackoff.Steps {
+			t.Errorf("login attempts = %d, want %d", attempts, reloginBackoff.Steps)
+		}
+
+		var status SessionStatus
+		if err := kv.Get(context.Background(), SessionStatusKey, &status); err != nil {
+			t.Fatal(err)
+		}
+		if status.Relogins != 0 || status.LastError != "invalid login 3" || !kv.saved {
+			t.Errorf("session status = %+v, saved %v", status, kv.saved)
+		}
+	})
+
+	t.Run("session expired while running", func(t *testing.T) {
+		simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			vcClient := &govmomi.Client{Client: vim, SessionManager: session.NewManager(vim)}
+			if err := vcClient.SessionManager.Logout(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			kv := &fakeKVStore{
+				data:     map[string]string{SessionStatusKey: `{"relogins":1,"lastError":"invalid login"}`},
+				dataChan: make(chan string, 10),
+			}
+			a := &vAdapter{
+				Source:   source,
+				VClient:  vcClient,
+				KVStore:  kv,
+				CpConfig: CheckpointConfig{MaxAge: CheckpointDefaultAge, Period: time.Second},
+				Login: func(ctx context.Context) error {
+					defer time.AfterFunc(100*time.Millisecond, cancel)
+					return vcClient.SessionManager.Login(ctx, simulator.DefaultLogin)
+				},
+			}
+
+			if err := a.Start(ctx); err == nil || !strings.Contains(err.Error(), "context canceled") {
+				t.Errorf("Start() error = %v, want context canceled", err)
+			}
+
+			var status SessionStatus
+			if err := kv.Get(ctx, SessionStatusKey, &status); err != nil {
+				t.Fatal(err)
+			}
+			if status.Relogins != 2 || status.LastError != "" || status.LastRelogin.IsZero() {
+				t.Errorf("session status = %+v", status)
+			}
+		})
+	})
+}
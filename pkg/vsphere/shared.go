@@ -0,0 +1,479 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vapi/rest"
+	"go.uber.org/zap"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"knative.dev/eventing/pkg/adapter/v2"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/kvstore"
+	"knative.dev/pkg/logging"
+)
+
+// sharedAdapterComponent is the logging component of the sources served by
+// the shared adapter, the same as of dedicated adapters
+const sharedAdapterComponent = "vspheresource"
+
+// interval to read the configurations of the sources served by the shared
+// adapter
+var tenantPollInterval = 10 * time.Second
+
+// SourceConfig is the configuration of a VSphereSource served by the shared
+// adapter. The controller stores the configurations of all sources in a
+// namespace in the configmap of the shared adapter, keyed by source name. The
+// fields correspond to the environment variables of a dedicated adapter.
+type SourceConfig struct {
+	// VCenter configures the connection to vCenter. Sources with the same
+	// configuration share their vCenter sessions.
+	VCenter EnvConfig `json:"vcenter"`
+
+	// KVConfigMap is the name of the configmap to use as kvstore of the source
+	KVConfigMap string `json:"kvConfigMap"`
+	// Sink is the URI of the sink
+	Sink string `json:"sink"`
+	// CEOverrides is the JSON-encoded CloudEvents overrides of the source
+	CEOverrides string `json:"ceOverrides,omitempty"`
+
+	CheckpointConfig      string        `json:"checkpointConfig,omitempty"`
+	PollInterval          time.Duration `json:"pollInterval,omitempty"`
+	PageSize              int           `json:"pageSize,omitempty"`
+	CollectorMode         string        `json:"collectorMode,omitempty"`
+	Scope                 string        `json:"scope,omitempty"`
+	IncludeTasks          bool          `json:"includeTasks,omitempty"`
+	IncludeContentLibrary bool          `json:"includeContentLibrary,omitempty"`
+	IncludeTags           bool          `json:"includeTags,omitempty"`
+	NormalizeAlarms       bool          `json:"normalizeAlarms,omitempty"`
+	GroupVSANEvents       bool          `json:"groupVsanEvents,omitempty"`
+	Extensions            []string      `json:"extensions,omitempty"`
+	Enrichment            string        `json:"enrichment,omitempty"`
+	AttributeMapping      string        `json:"attributeMapping,omitempty"`
+	OutputFormat          string        `json:"outputFormat,omitempty"`
+	Redaction             string        `json:"redaction,omitempty"`
+	PayloadEncoding       string        `json:"payloadEncoding,omitempty"`
+	EventFilter           string        `json:"eventFilter,omitempty"`
+	Sinks                 string        `json:"sinks,omitempty"`
+	MirrorSink            string        `json:"mirrorSink,omitempty"`
+	Kafka                 string        `json:"kafka,omitempty"`
+	LogOnly               bool          `json:"logOnly,omitempty"`
+	Transform             string        `json:"transform,omitempty"`
+	SinkContentMode       string        `json:"sinkContentMode,omitempty"`
+	SinkCompression       string        `json:"sinkCompression,omitempty"`
+	SinkHeaders           string        `json:"sinkHeaders,omitempty"`
+	SinkAudience          string        `json:"sinkAudience,omitempty"`
+	SinkCACerts           string        `json:"sinkCACerts,omitempty"`
+	RateLimit             string        `json:"rateLimit,omitempty"`
+	Sampling              string        `json:"sampling,omitempty"`
+	SinkMaxAttempts       int           `json:"sinkMaxAttempts,omitempty"`
+	DeadLetterSink        string        `json:"deadLetterSink,omitempty"`
+	Audit                 string        `json:"audit,omitempty"`
+	HeartbeatInterval     time.Duration `json:"heartbeatInterval,omitempty"`
+	LifecycleEvents       bool          `json:"lifecycleEvents,omitempty"`
+	// LoggingConfig is the JSON-encoded logging config of the source
+	LoggingConfig string `json:"loggingConfig,omitempty"`
+	// RestartedAt is the time the source was last restarted at. It is not
+	// used by the adapter, but changing it restarts the source.
+	RestartedAt string `json:"restartedAt,omitempty"`
+}
+
+// envConfig returns the adapter configuration of the source in the given
+// namespace, sending events with the given service account.
+func (c *SourceConfig) envConfig(namespace, serviceAccount string) *envConfig {
+	env := &envConfig{
+		KVConfigMap:           c.KVConfigMap,
+		CheckpointConfig:      c.CheckpointConfig,
+		PollInterval:          c.PollInterval,
+		PageSize:              c.PageSize,
+		CollectorMode:         c.CollectorMode,
+		Scope:                 c.Scope,
+		IncludeTasks:          c.IncludeTasks,
+		IncludeContentLibrary: c.IncludeContentLibrary,
+		IncludeTags:           c.IncludeTags,
+		NormalizeAlarms:       c.NormalizeAlarms,
+		GroupVSANEvents:       c.GroupVSANEvents,
+		Extensions:            c.Extensions,
+		Enrichment:            c.Enrichment,
+		AttributeMapping:      c.AttributeMapping,
+		OutputFormat:          c.OutputFormat,
+		Redaction:             c.Redaction,
+		PayloadEncoding:       c.PayloadEncoding,
+		EventFilter:           c.EventFilter,
+		Sinks:                 c.Sinks,
+		MirrorSink:            c.MirrorSink,
+		Kafka:                 c.Kafka,
+		LogOnly:               c.LogOnly,
+		Transform:             c.Transform,
+		SinkContentMode:       c.SinkContentMode,
+		SinkCompression:       c.SinkCompression,
+		SinkHeaders:           c.SinkHeaders,
+		SinkAudience:          c.SinkAudience,
+		SinkCACerts:           c.SinkCACerts,
+		RateLimit:             c.RateLimit,
+		Sampling:              c.Sampling,
+		SinkMaxAttempts:       c.SinkMaxAttempts,
+		DeadLetterSink:        c.DeadLetterSink,
+		Audit:                 c.Audit,
+		HeartbeatInterval:     c.HeartbeatInterval,
+		LifecycleEvents:       c.LifecycleEvents,
+		ServiceAccount:        serviceAccount,
+	}
+	env.Namespace = namespace
+	env.Component = sharedAdapterComponent
+	env.LoggingConfigJson = c.LoggingConfig
+	env.Sink = c.Sink
+	env.CEOverrides = c.CEOverrides
+
+	// defaults of the environment variables
+	if env.CheckpointConfig == "" {
+		env.CheckpointConfig = "{}"
+	}
+	if env.PollInterval == 0 {
+		env.PollInterval = CollectorDefaultPollInterval
+	}
+	if env.PageSize == 0 {
+		env.PageSize = CollectorDefaultPageSize
+	}
+	if env.CollectorMode == "" {
+		env.CollectorMode = CollectorModePoll
+	}
+	if env.OutputFormat == "" {
+		env.OutputFormat = OutputFormatCloudEvents
+	}
+	if env.PayloadEncoding == "" {
+		env.PayloadEncoding = PayloadEncodingJSON
+	}
+	if env.SinkContentMode == "" {
+		env.SinkContentMode = ContentModeBinary
+	}
+	if env.SinkCompression == "" {
+		env.SinkCompression = CompressionNone
+	}
+	if env.LoggingConfigJson == "" {
+		env.LoggingConfigJson = "{}"
+	}
+	return env
+}
+
+type sharedEnvConfig struct {
+	adapter.EnvConfig
+
+	// TenantConfigMap is the name of the configmap with the configurations of
+	// the sources served by this adapter, keyed by source name.
+	TenantConfigMap string `envconfig:"VSPHERE_SHARED_ADAPTER_CONFIGMAP" required:"true"`
+
+	// ServiceAccount is the service account of the adapter, used to request
+	// OIDC tokens for the sink audiences
+	ServiceAccount string `envconfig:"VSPHERE_SERVICE_ACCOUNT" default:""`
+
+	// SinkTLSMinVersion and SinkTLSCipherSuites restrict the TLS connections
+	// to the HTTPS sinks of all sources, see TLSConfig
+	SinkTLSMinVersion   string `envconfig:"VSPHERE_SINK_TLS_MIN_VERSION" default:""`
+	SinkTLSCipherSuites string `envconfig:"VSPHERE_SINK_TLS_CIPHER_SUITES" default:""`
+}
+
+func NewSharedEnvConfig() adapter.EnvConfigAccessor {
+	return &sharedEnvConfig{}
+}
+
+// sharedAdapter serves many VSphereSources of a namespace in one process. It
+// runs a vAdapter per source and multiplexes the vCenter sessions of sources
+// with the same vCenter configuration.
+type sharedAdapter struct {
+	Logger          *zap.SugaredLogger
+	Namespace       string
+	TenantConfigMap string
+	ServiceAccount  string
+	ConfigMaps      corev1client.ConfigMapInterface
+
+	// startTenant runs the adapter of the given source until ctx is done
+	startTenant func(ctx context.Context, name string, config SourceConfig) error
+}
+
+// NewSharedAdapter returns the adapter serving the sources configured in the
+// tenant configmap. The given CloudEvents client is not used, every source
+// sends events to its own sink.
+func NewSharedAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, _ cloudevents.Client) adapter.Adapter {
+	env := processed.(*sharedEnvConfig)
+	sinkTLS := TLSConfig{MinVersion: env.SinkTLSMinVersion, CipherSuites: env.SinkTLSCipherSuites}
+	if err := sinkTLS.Validate(); err != nil {
+		logging.FromContext(ctx).Fatalf("could not configure sink TLS: %v", err)
+	}
+
+	a := &sharedAdapter{
+		Logger:          logging.FromContext(ctx),
+		Namespace:       env.Namespace,
+		TenantConfigMap: env.TenantConfigMap,
+		ServiceAccount:  env.ServiceAccount,
+		ConfigMaps:      kubeclient.Get(ctx).CoreV1().ConfigMaps(env.Namespace),
+	}
+	a.startTenant = newSessionPool(ctx).runTenant(a.Namespace, a.ServiceAccount, sinkTLS)
+	return a
+}
+
+// tenant is a source run by the shared adapter
+type tenant struct {
+	// config is the raw configuration the source was started with
+	config string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Start implements adapter.Adapter. It polls the tenant configmap and starts,
+// restarts and stops the adapters of the configured sources. Sources whose
+// adapter failed are restarted with the next poll.
+func (a *sharedAdapter) Start(ctx context.Context) error {
+	tenants := make(map[string]*tenant)
+	defer func() {
+		for _, t := range tenants {
+			t.cancel()
+			<-t.done
+		}
+	}()
+
+	ticker := time.NewTicker(tenantPollInterval)
+	defer ticker.Stop()
+
+	for {
+		a.sync(ctx, tenants)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync reconciles the running tenants with the tenant configmap
+func (a *sharedAdapter) sync(ctx context.Context, tenants map[string]*tenant) {
+	desired, err := a.readTenants(ctx)
+	if err != nil {
+		a.Logger.Warnw("failed to read tenant configmap", zap.Error(err))
+		return
+	}
+
+	// forget failed tenants so that they are started again
+	for name, t := range tenants {
+		select {
+		case <-t.done:
+			delete(tenants, name)
+		default:
+		}
+	}
+
+	running := make(map[string]string, len(tenants))
+	for name, t := range tenants {
+		running[name] = t.config
+	}
+
+	start, stop := diffTenants(running, desired)
+	for _, name := range stop {
+		a.Logger.Infow("stopping source", zap.String("source", name))
+		tenants[name].cancel()
+		<-tenants[name].done
+		delete(tenants, name)
+	}
+
+	for _, name := range start {
+		var config SourceConfig
+		if err := json.Unmarshal([]byte(desired[name]), &config); err != nil {
+			a.Logger.Errorw("invalid source configuration", zap.String("source", name), zap.Error(err))
+			continue
+		}
+
+		a.Logger.Infow("starting source", zap.String("source", name))
+		tenantCtx, cancel := context.WithCancel(ctx)
+		t := &tenant{config: desired[name], cancel: cancel, done: make(chan struct{})}
+		tenants[name] = t
+
+		go func(name string) {
+			defer close(t.done)
+			if err := a.startTenant(tenantCtx, name, config); err != nil && tenantCtx.Err() == nil {
+				a.Logger.Errorw("source failed", zap.String("source", name), zap.Error(err))
+			}
+		}(name)
+	}
+}
+
+// readTenants returns the raw configurations of the sources in the tenant
+// configmap, keyed by source name
+func (a *sharedAdapter) readTenants(ctx context.Context) (map[string]string, error) {
+	cm, err := a.ConfigMaps.Get(ctx, a.TenantConfigMap, metav1.GetOptions{})
+	if apierrs.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return cm.Data, nil
+}
+
+// diffTenants returns the names of the desired sources which are not running
+// or run with a different configuration, and of the running sources which are
+// not desired or run with a different configuration.
+func diffTenants(running, desired map[string]string) (start, stop []string) {
+	for name, config := range running {
+		if d, ok := desired[name]; !ok || d != config {
+			stop = append(stop, name)
+		}
+	}
+	for name, config := range desired {
+		if r, ok := running[name]; !ok || r != config {
+			start = append(start, name)
+		}
+	}
+	return start, stop
+}
+
+// sessionPool shares the vCenter sessions of the sources served by the shared
+// adapter by vCenter configuration
+type sessionPool struct {
+	// ctx outlives the sources, it is used for the keep-alive of sessions
+	ctx context.Context
+
+	mu       sync.Mutex
+	sessions map[string]*sharedSession
+}
+
+type sharedSession struct {
+	refs    int
+	env     EnvConfig
+	vClient *govmomi.Client
+	rClient *rest.Client
+}
+
+func newSessionPool(ctx context.Context) *sessionPool {
+	return &sessionPool{ctx: ctx, sessions: make(map[string]*sharedSession)}
+}
+
+// acquire returns the session for the given vCenter configuration, logging in
+// if no source uses it yet. The REST client is only created if requested.
+func (p *sessionPool) acquire(env EnvConfig, withREST bool) (*sharedSession, error) {
+	key, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.sessions[string(key)]
+	if !ok {
+		vClient, connected, err := soapWithFailover(p.ctx, env)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create vSphere client: %w", err)
+		}
+		s = &sharedSession{env: connected, vClient: vClient}
+		p.sessions[string(key)] = s
+	}
+
+	if withREST && s.rClient == nil {
+		rClient, err := restWithKeepalive(p.ctx, s.env)
+		if err != nil {
+			if s.refs == 0 {
+				logout(s.vClient, nil)
+				delete(p.sessions, string(key))
+			}
+			return nil, fmt.Errorf("unable to create vSphere REST client: %w", err)
+		}
+		s.rClient = rClient
+	}
+
+	s.refs++
+	return s, nil
+}
+
+// release logs out of the given session if no other source uses it
+func (p *sessionPool) release(s *sharedSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s.refs--
+	if s.refs > 0 {
+		return
+	}
+	for key, session := range p.sessions {
+		if session == s {
+			delete(p.sessions, key)
+		}
+	}
+	logout(s.vClient, s.rClient)
+}
+
+// runTenant returns a function running the adapter of a source of the shared
+// adapter in the given namespace with sessions of the pool. The connections
+// to the sinks of all sources are restricted by the given TLS configuration.
+func (p *sessionPool) runTenant(namespace, serviceAccount string, sinkTLS TLSConfig) func(ctx context.Context, name string, config SourceConfig) error {
+	return func(ctx context.Context, name string, config SourceConfig) error {
+		env := config.envConfig(namespace, serviceAccount)
+		env.SourceName = name
+		env.SinkTLSMinVersion = sinkTLS.MinVersion
+		env.SinkTLSCipherSuites = sinkTLS.CipherSuites
+		// every source logs with its own level
+		ctx = logging.WithLogger(ctx, env.GetLogger().With(zap.String("source", name)))
+		ctx = adapter.ContextWithMetricTag(ctx, &adapter.MetricTag{
+			Name:          name,
+			Namespace:     namespace,
+			ResourceGroup: "vspheresources.sources.tanzu.vmware.com",
+		})
+
+		store := kvstore.NewConfigMapKVStore(ctx, env.KVConfigMap, namespace, kubeclient.Get(ctx).CoreV1())
+		if err := store.Init(ctx); err != nil {
+			return fmt.Errorf("could not initialize kv store: %w", err)
+		}
+
+		enrichment, err := newEnrichment(env.Enrichment)
+		if err != nil {
+			return fmt.Errorf("could not read enrichment config: %w", err)
+		}
+
+		s, err := p.acquire(config.VCenter, env.needsREST(enrichment))
+		if err != nil {
+			recordConnectionStatus(ctx, store, err)
+			return err
+		}
+		defer p.release(s)
+		recordConnected(ctx, store, fallbackAddress(config.VCenter, s.env))
+
+		transport, err := env.newSinkTransport()
+		if err != nil {
+			return fmt.Errorf("could not configure sink transport: %w", err)
+		}
+		var ceClient cloudevents.Client
+		if env.LogOnly {
+			ceClient, err = env.newLogOnlyClient(logging.FromContext(ctx))
+		} else {
+			ceClient, err = env.newSinkClient(transport)
+		}
+		if err != nil {
+			return fmt.Errorf("could not create CloudEvents client: %w", err)
+		}
+
+		a, err := newVAdapter(ctx, env, enrichment, ceClient, transport, store, s.vClient, s.rClient)
+		if err != nil {
+			return err
+		}
+		// the session is shared, so it is logged in again but never out by
+		// the adapter of a single source
+		a.Login = func(ctx context.Context) error {
+			return login(ctx, s.env, s.vClient, s.rClient)
+		}
+		a.API = s.env.API
+		a.FallbackAddress = fallbackAddress(config.VCenter, s.env)
+		a.WatchLoggingLevel = env.loggingLevelWatcher(ctx)
+		return a.Start(ctx)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_diffTenants(t *testing.T) {
+	running := map[string]string{"same": "a", "changed": "b", "removed": "c"}
+	desired := map[string]string{"same": "a", "changed": "b2", "added": "d"}
+
+	start, stop := diffTenants(running, desired)
+	sort.Strings(start)
+	sort.Strings(stop)
+
+	if want := []string{"added", "changed"}; !cmp.Equal(start, want) {
+		t.Errorf("diffTenants() start = %v, want %v", start, want)
+	}
+	if want := []string{"changed", "removed"}; !cmp.Equal(stop, want) {
+		t.Errorf("diffTenants() stop = %v, want %v", stop, want)
+	}
+}
+
+func TestSourceConfig_envConfig(t *testing.T) {
+	c := SourceConfig{
+		KVConfigMap: "src-configmap",
+		Sink:        "http://sink.example.com",
+		Extensions:  []string{"vmmoref"},
+	}
+
+	env := c.envConfig("ns", "shared-adapter")
+	if env.Namespace != "ns" || env.ServiceAccount != "shared-adapter" || env.Sink != c.Sink || env.KVConfigMap != c.KVConfigMap {
+		t.Errorf("envConfig() = %+v, want namespace, service account, sink and kvstore set", env)
+	}
+	if env.CheckpointConfig != "{}" || env.OutputFormat != OutputFormatCloudEvents || env.SinkContentMode != ContentModeBinary ||
+		env.PollInterval != CollectorDefaultPollInterval || env.PageSize != CollectorDefaultPageSize {
+		t.Errorf("envConfig() = %+v, want defaults of the environment variables", env)
+	}
+	if !cmp.Equal(env.Extensions, c.Extensions) {
+		t.Errorf("envConfig() extensions = %v, want %v", env.Extensions, c.Extensions)
+	}
+}
+
+func Test_sharedAdapter_sync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "ns"},
+		Data: map[string]string{
+			"src1": `{"sink":"http://one.example.com"}`,
+			"src2": `{"sink":"http://two.example.com"}`,
+			"bad":  `{`,
+			"ca":   `{"sink":"https://three.example.com","sinkCACerts":"-----BEGIN CERTIFICATE-----"}`,
+		},
+	}
+	client := fake.NewSimpleClientset(cm)
+
+	var mu sync.Mutex
+	started := make(map[string]string)
+	a := &sharedAdapter{
+		Logger:          zap.NewNop().Sugar(),
+		Namespace:       "ns",
+		TenantConfigMap: "shared",
+		ConfigMaps:      client.CoreV1().ConfigMaps("ns"),
+		startTenant: func(ctx context.Context, name string, config SourceConfig) error {
+			mu.Lock()
+			started[name] = config.Sink
+			mu.Unlock()
+			<-ctx.Done()
+			return nil
+		},
+	}
+
+	tenants := make(map[string]*tenant)
+	a.sync(ctx, tenants)
+	if len(tenants) != 3 || tenants["src1"] == nil || tenants["src2"] == nil || tenants["ca"] == nil {
+		t.Fatalf("sync() tenants = %v, want src1, src2 and ca", tenants)
+	}
+	src2 := tenants["src2"]
+
+	// remove src1 and change src2
+	cm.Data = map[string]string{"src2": `{"sink":"http://new.example.com"}`}
+	if _, err := client.CoreV1().ConfigMaps("ns").Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	a.sync(ctx, tenants)
+
+	if len(tenants) != 1 || tenants["src2"] == nil || tenants["src2"] == src2 {
+		t.Fatalf("sync() tenants = %v, want restarted src2", tenants)
+	}
+	select {
+	case <-src2.done:
+	default:
+		t.Error("sync() did not stop src2 with the previous configuration")
+	}
+
+	tenants["src2"].cancel()
+	<-tenants["src2"].done
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := map[string]string{"src1": "http://one.example.com", "src2": "http://new.example.com", "ca": "https://three.example.com"}
+	if !cmp.Equal(started, want) {
+		t.Errorf("started sources = %v, want %v", started, want)
+	}
+}
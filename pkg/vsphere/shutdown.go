@@ -0,0 +1,64 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+var (
+	// time to finish the deliveries in flight after the adapter is stopped
+	drainTimeout = 15 * time.Second
+	// time to save the final checkpoint after the deliveries are drained, both
+	// within the default termination grace period of 30s
+	flushTimeout = 10 * time.Second
+)
+
+// valuesOnly is a context with the values of its parent, which is never done.
+type valuesOnly struct {
+	context.Context
+}
+
+func (valuesOnly) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (valuesOnly) Done() <-chan struct{}       { return nil }
+func (valuesOnly) Err() error                  { return nil }
+
+// withGracePeriod returns a context with the values of ctx which is done the
+// given grace period after ctx is done, to finish work in flight when ctx is
+// canceled, e.g. on SIGTERM.
+func withGracePeriod(ctx context.Context, grace time.Duration) (context.Context, context.CancelFunc) {
+	gctx, cancel := context.WithCancel(valuesOnly{ctx})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-gctx.Done():
+			return
+		}
+
+		t := time.NewTimer(grace)
+		defer t.Stop()
+		select {
+		case <-t.C:
+			cancel()
+		case <-gctx.Done():
+		}
+	}()
+	return gctx, cancel
+}
+
+// flushCheckpoint saves the checkpoint set in the kvstore, which is not saved
+// periodically anymore once ctx is done.
+func (a *vAdapter) flushCheckpoint(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(valuesOnly{ctx}, flushTimeout)
+	defer cancel()
+
+	if err := a.KVStore.Save(ctx); err != nil {
+		return fmt.Errorf("save final checkpoint: %w", err)
+	}
+	return nil
+}
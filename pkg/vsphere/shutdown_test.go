@@ -0,0 +1,56 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+func TestWithGracePeriod(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "value"))
+
+	gctx, gcancel := withGracePeriod(ctx, 50*time.Millisecond)
+	defer gcancel()
+
+	if got := gctx.Value(ctxKey{}); got != "value" {
+		t.Errorf("Value() = %v, want value", got)
+	}
+
+	cancel()
+	select {
+	case <-gctx.Done():
+		t.Fatal("context done without grace period")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	select {
+	case <-gctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context not done after grace period")
+	}
+}
+
+func TestFlushCheckpoint(t *testing.T) {
+	kv := &fakeKVStore{dataChan: make(chan string, 1)}
+	a := &vAdapter{KVStore: kv}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := kv.Set(ctx, CheckpointKey, checkpoint{LastEventKey: 42}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.flushCheckpoint(ctx); err != nil {
+		t.Fatalf("flushCheckpoint() = %v", err)
+	}
+	if !kv.saved {
+		t.Error("flushCheckpoint() did not save the checkpoint")
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// Sink is an additional sink of a source, which receives the events sent to
+// the sink of the source that match its filter.
+type Sink struct {
+	// URI is the resolved URI of the sink
+	URI string `json:"uri"`
+
+	// Filter selects the events sent to the sink, all events if nil
+	Filter *EventFilter `json:"filter,omitempty"`
+}
+
+// sinkSet is the list of the additional sinks of a source
+type sinkSet []Sink
+
+func newSinkSet(sinks string) (sinkSet, error) {
+	if sinks == "" {
+		return nil, nil
+	}
+
+	var s sinkSet
+	if err := json.Unmarshal([]byte(sinks), &s); err != nil {
+		return nil, fmt.Errorf("unmarshal sinks: %w", err)
+	}
+
+	for i := range s {
+		if s[i].URI == "" {
+			return nil, errors.New("invalid sink: empty uri")
+		}
+		if s[i].Filter == nil {
+			continue
+		}
+		if err := s[i].Filter.compile(); err != nil {
+			return nil, fmt.Errorf("invalid filter of sink %q: %w", s[i].URI, err)
+		}
+	}
+	return s, nil
+}
+
+type sinksKey struct{}
+
+// withMatching returns ctx with the URIs of the sinks whose filter matches the
+// given event, created from the given vSphere object, which send also sends
+// the event to. The event is matched before it is transformed, like by the
+// filter of the source.
+func (s sinkSet) withMatching(ctx context.Context, ev cloudevents.Event, obj interface{}) context.Context {
+	if len(s) == 0 {
+		return ctx
+	}
+
+	uris := make([]string, 0, len(s))
+	for _, sink := range s {
+		if sink.Filter.Match(ev, obj) {
+			uris = append(uris, sink.URI)
+		}
+	}
+	return context.WithValue(ctx, sinksKey{}, uris)
+}
+
+// matchingSinks returns the URIs of the additional sinks set by withMatching
+func matchingSinks(ctx context.Context) []string {
+	uris, _ := ctx.Value(sinksKey{}).([]string)
+	return uris
+}
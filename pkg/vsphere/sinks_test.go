@@ -0,0 +1,147 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/client"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_newSinkSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		sinks   string
+		want    int
+		wantErr string
+	}{{
+		name: "none",
+	}, {
+		name:  "sinks with and without filter",
+		sinks: `[{"uri":"http://audit.example.com"},{"uri":"http://metrics.example.com","filter":{"cel":"ce.type != \"\""}}]`,
+		want:  2,
+	}, {
+		name:    "empty uri",
+		sinks:   `[{"uri":""}]`,
+		wantErr: "invalid sink: empty uri",
+	}, {
+		name:    "invalid filter",
+		sinks:   `[{"uri":"http://audit.example.com","filter":{"eventTypes":["[alarm"]}}]`,
+		wantErr: `invalid filter of sink "http://audit.example.com"`,
+	}, {
+		name:    "malformed",
+		sinks:   `{"uri":"http://audit.example.com"}`,
+		wantErr: "unmarshal sinks",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newSinkSet(tt.sinks)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("newSinkSet() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("newSinkSet() = %d sinks, want %d", len(got), tt.want)
+			}
+			for _, s := range got {
+				if s.Filter != nil && s.Filter.CEL != "" && s.Filter.program == nil {
+					t.Errorf("newSinkSet() did not compile the filter of sink %q", s.URI)
+				}
+			}
+		})
+	}
+}
+
+// hostRecorder records the hosts of the requests and fails those to failHost
+type hostRecorder struct {
+	hosts    []string
+	headers  []http.Header
+	failHost string
+}
+
+func (r *hostRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.hosts = append(r.hosts, req.URL.Host)
+	r.headers = append(r.headers, req.Header)
+	if req.URL.Host == r.failHost {
+		return &http.Response{StatusCode: http.StatusInternalServerError, Body: http.NoBody}, nil
+	}
+	return &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody}, nil
+}
+
+func Test_vAdapter_sendEventsToSinks(t *testing.T) {
+	events := createTestEvents(2, source, time.Now().UTC())
+	sinks := sinkSet{{
+		URI: "http://audit.example.com",
+	}, {
+		URI:    "http://metrics.example.com",
+		Filter: &EventFilter{EventTypes: []string{"com.vmware.vsphere.alarm.*"}},
+	}}
+
+	tests := []struct {
+		name      string
+		failHost  string
+		wantCount int
+		wantHosts []string
+		wantErr   string
+	}{{
+		name:      "sends to the matching sinks",
+		wantCount: 2,
+		wantHosts: []string{"sink.example.com", "audit.example.com", "sink.example.com", "audit.example.com"},
+	}, {
+		name:      "fails the event if a sink fails",
+		failHost:  "audit.example.com",
+		wantHosts: []string{"sink.example.com", "audit.example.com"},
+		wantErr:   "send to sink http://audit.example.com",
+	}, {
+		name:      "does not send to the sinks if the sink fails",
+		failHost:  "sink.example.com",
+		wantHosts: []string{"sink.example.com"},
+		wantErr:   "500",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &hostRecorder{failHost: tt.failHost}
+			p, err := cehttp.New(cehttp.WithRoundTripper(recorder))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := client.New(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a := vAdapter{Logger: zaptest.NewLogger(t).Sugar(), CEClient: c, Source: source, Sinks: sinks}
+			ctx := cecontext.WithTarget(context.Background(), "http://sink.example.com")
+			count, err := a.sendEvents(ctx, events.vEvents)
+
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("sendEvents() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("sendEvents() error = %v, want %q", err, tt.wantErr)
+			}
+			if count != tt.wantCount {
+				t.Errorf("sendEvents() = %d, want %d", count, tt.wantCount)
+			}
+			if !reflect.DeepEqual(recorder.hosts, tt.wantHosts) {
+				t.Errorf("sent to %v, want %v", recorder.hosts, tt.wantHosts)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.opencensus.io/plugin/ochttp"
+	"knative.dev/eventing/pkg/adapter/v2"
+	knsource "knative.dev/pkg/source"
+	"knative.dev/pkg/tracing/propagation/tracecontextb3"
+)
+
+// newSinkTransport returns the transport of the clients sending events to the
+// sinks of the source: a clone of the default transport, which trusts the
+// sink CA certificates in addition to the system roots, restricts the TLS
+// versions and cipher suites and compresses the requests with a context
+// created by withGzip. The default transport is not changed, so that the
+// other clients of the adapter, e.g. of Vault, do not trust the sink CA.
+func (env *envConfig) newSinkTransport() (http.RoundTripper, error) {
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("unsupported default HTTP transport")
+	}
+	t = t.Clone()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+
+	pool, err := sinkRootCAs(env.SinkCACertsPath, env.SinkCACerts)
+	if err != nil {
+		return nil, err
+	}
+	if pool != nil {
+		t.TLSClientConfig.RootCAs = pool
+	}
+	if err = (TLSConfig{MinVersion: env.SinkTLSMinVersion, CipherSuites: env.SinkTLSCipherSuites}).apply(t.TLSClientConfig); err != nil {
+		return nil, err
+	}
+
+	if env.SinkCompression == "" || env.SinkCompression == CompressionNone {
+		return t, nil
+	}
+	return &gzipTransport{base: t}, nil
+}
+
+// newSinkClient returns the CloudEvents client sending events to the sink
+// with the given transport. Like the client of the adapter framework, which
+// always uses the default transport, it propagates the trace context, applies
+// the extensions of the CloudEvents overrides and reports the sent events.
+func (env *envConfig) newSinkClient(transport http.RoundTripper) (cloudevents.Client, error) {
+	// the protocol sets the round tripper of the default client otherwise
+	client := http.Client{}
+	if timeout := env.GetSinktimeout(); timeout > 0 {
+		client.Timeout = time.Duration(timeout) * time.Second
+	}
+	opts := []cehttp.Option{
+		cehttp.WithClient(client),
+		cloudevents.WithRoundTripper(&ochttp.Transport{
+			Base:        transport,
+			Propagation: tracecontextb3.TraceContextEgress,
+		}),
+	}
+	if env.Sink != "" {
+		opts = append(opts, cloudevents.WithTarget(env.Sink))
+	}
+	p, err := cloudevents.NewHTTP(opts...)
+	if err != nil {
+		return nil, err
+	}
+	c, err := cloudevents.NewClient(p, cloudevents.WithTimeNow(), cloudevents.WithUUIDs())
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := env.GetCloudEventOverrides()
+	if err != nil {
+		return nil, fmt.Errorf("read CloudEvents overrides: %w", err)
+	}
+	reporter, err := knsource.NewStatsReporter()
+	if err != nil {
+		return nil, fmt.Errorf("create stats reporter: %w", err)
+	}
+	return &sinkClient{Client: c, extensions: overrides.Extensions, reporter: reporter}, nil
+}
+
+// sinkClient is a CloudEvents client sending events to the sink over HTTP
+type sinkClient struct {
+	cloudevents.Client
+	extensions map[string]string
+	reporter   knsource.StatsReporter
+}
+
+func (c *sinkClient) Send(ctx context.Context, ev event.Event) protocol.Result {
+	for k, v := range c.extensions {
+		ev.SetExtension(k, v)
+	}
+	result := c.Client.Send(ctx, ev)
+	c.report(ctx, ev, result)
+	return result
+}
+
+// report records the result of sending the given event in the metrics of the
+// source, like the client of the adapter framework
+func (c *sinkClient) report(ctx context.Context, ev event.Event, result protocol.Result) {
+	tags := adapter.MetricTagFromContext(ctx)
+	args := &knsource.ReportArgs{
+		Namespace:     tags.Namespace,
+		EventSource:   ev.Source(),
+		EventType:     ev.Type(),
+		Name:          tags.Name,
+		ResourceGroup: tags.ResourceGroup,
+	}
+
+	var retries *cehttp.RetriesResult
+	if cloudevents.ResultAs(result, &retries) {
+		result = retries.Result
+	}
+	var res *cehttp.Result
+	if cloudevents.ResultAs(result, &res) {
+		_ = c.reporter.ReportEventCount(args, res.StatusCode)
+		return
+	}
+
+	if result != nil {
+		args.Error = result.Error()
+		var urlErr *url.Error
+		args.Timeout = errors.As(result, &urlErr) && urlErr.Timeout()
+	}
+	_ = c.reporter.ReportEventCount(args, 0)
+}
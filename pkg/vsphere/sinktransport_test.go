@@ -0,0 +1,127 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"knative.dev/eventing/pkg/adapter/v2"
+)
+
+func Test_newSinkTransport(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	dir, err := ioutil.TempDir("", "sink-ca-certs")
+	if err != nil {
+		t.Fatalf("create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "ca.crt"), cert, 0o600); err != nil {
+		t.Fatalf("write certificate file: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte("ignored"), 0o600); err != nil {
+		t.Fatalf("write hidden file: %v", err)
+	}
+	if err = os.Mkdir(filepath.Join(dir, "..data"), 0o700); err != nil {
+		t.Fatalf("create data dir: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		env     envConfig
+		wantErr bool
+		// wantGetErr is true if the sink is not trusted
+		wantGetErr bool
+	}{
+		{name: "system roots", wantGetErr: true},
+		{name: "CA certificates directory", env: envConfig{SinkCACertsPath: dir}},
+		{name: "CA certificates bundle", env: envConfig{SinkCACerts: string(cert)}},
+		{name: "invalid bundle", env: envConfig{SinkCACerts: "not a certificate"}, wantErr: true},
+		{name: "missing directory", env: envConfig{SinkCACertsPath: filepath.Join(dir, "missing")}, wantErr: true},
+		{name: "min version satisfied", env: envConfig{SinkCACerts: string(cert), SinkTLSMinVersion: "1.2"}},
+		{name: "min version not satisfied", env: envConfig{SinkCACerts: string(cert), SinkTLSMinVersion: "1.3"}, wantGetErr: true},
+		{name: "unsupported min version", env: envConfig{SinkTLSMinVersion: "2.0"}, wantErr: true},
+		{name: "compressed", env: envConfig{SinkCACerts: string(cert), SinkCompression: CompressionGzip}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := tt.env.newSinkTransport()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSinkTransport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			res, err := (&http.Client{Transport: transport}).Get(srv.URL)
+			if err == nil {
+				err = res.Body.Close()
+			}
+			if (err != nil) != tt.wantGetErr {
+				t.Errorf("GET error = %v, wantErr %v", err, tt.wantGetErr)
+			}
+
+			// the other clients of the adapter do not trust the sink CA
+			if res, err := (&http.Client{}).Get(srv.URL); err == nil {
+				res.Body.Close()
+				t.Error("default client trusts the sink CA certificates")
+			}
+		})
+	}
+}
+
+func Test_newSinkClient(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	env := &envConfig{EnvConfig: adapter.EnvConfig{
+		Sink:           srv.URL,
+		CEOverrides:    `{"extensions":{"cluster":"prod"}}`,
+		EnvSinkTimeout: "5",
+	}}
+	transport, err := env.newSinkTransport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultTransport := http.DefaultClient.Transport
+	c, err := env.newSinkClient(transport)
+	if err != nil {
+		t.Fatalf("newSinkClient() error = %v", err)
+	}
+
+	ev := cloudevents.NewEvent()
+	ev.SetID("1")
+	ev.SetSource(source)
+	ev.SetType("com.vmware.vsphere.VmPoweredOnEvent")
+	if result := c.Send(context.Background(), ev); !cloudevents.IsACK(result) {
+		t.Fatalf("Send() = %v", result)
+	}
+	if got == nil || got.Header.Get("Ce-Cluster") != "prod" {
+		t.Errorf("Send() request = %v, want the cluster extension of the overrides", got)
+	}
+	if http.DefaultClient.Transport != defaultTransport {
+		t.Error("newSinkClient() changed the transport of the default client")
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	// CollectorModePoll polls the event history collector, backing off while
+	// vCenter has no new events
+	CollectorModePoll = "poll"
+	// CollectorModeStream waits for vCenter to push changes of the latest page
+	// of the event history collector, falling back to polling if vCenter does
+	// not support it
+	CollectorModeStream = "stream"
+)
+
+// validateCollectorMode returns an error if the given mode of the event
+// history collector is unsupported. The empty mode polls.
+func validateCollectorMode(mode string) error {
+	switch mode {
+	case "", CollectorModePoll, CollectorModeStream:
+		return nil
+	default:
+		return fmt.Errorf("unsupported event collector mode %q", mode)
+	}
+}
+
+// eventWaiter waits for new events of an event history collector with a
+// dedicated property collector, which vCenter notifies when the latest page of
+// the history collector changes.
+type eventWaiter struct {
+	client  *vim25.Client
+	pc      *property.Collector
+	version string
+}
+
+// newEventWaiter returns a waiter for new events of the given history
+// collector. The waiter must be destroyed.
+func newEventWaiter(ctx context.Context, c *event.HistoryCollector) (*eventWaiter, error) {
+	pc, err := property.DefaultCollector(c.Client()).Create(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create property collector: %w", err)
+	}
+	w := &eventWaiter{client: c.Client(), pc: pc}
+
+	err = pc.CreateFilter(ctx, types.CreateFilter{
+		Spec: types.PropertyFilterSpec{
+			ObjectSet: []types.ObjectSpec{{Obj: c.Reference()}},
+			PropSet: []types.PropertySpec{{
+				Type:    c.Reference().Type,
+				PathSet: []string{"latestPage"},
+			}},
+		},
+	})
+	if err != nil {
+		w.destroy()
+		return nil, fmt.Errorf("create property filter: %w", err)
+	}
+
+	// the first update reports the current latest page, which is read anyway
+	if err = w.sync(ctx); err != nil {
+		w.destroy()
+		return nil, err
+	}
+	return w, nil
+}
+
+// sync reads the current version of the latest page without waiting
+func (w *eventWaiter) sync(ctx context.Context) error {
+	_, err := w.update(ctx, 0)
+	return err
+}
+
+// wait blocks until the latest page of the history collector changed or the
+// given timeout passed, at least one second, and returns whether it changed.
+func (w *eventWaiter) wait(ctx context.Context, timeout time.Duration) (bool, error) {
+	if timeout < time.Second {
+		timeout = time.Second
+	}
+	return w.update(ctx, int32(timeout/time.Second))
+}
+
+// update waits up to the given seconds for a new version of the latest page
+func (w *eventWaiter) update(ctx context.Context, maxWait int32) (bool, error) {
+	req := types.WaitForUpdatesEx{
+		This:    w.pc.Reference(),
+		Version: w.version,
+		Options: &types.WaitOptions{MaxWaitSeconds: &maxWait},
+	}
+	res, err := methods.WaitForUpdatesEx(ctx, w.client, &req)
+	if err != nil {
+		return false, fmt.Errorf("wait for updates: %w", err)
+	}
+	if res.Returnval == nil {
+		return false, nil
+	}
+	w.version = res.Returnval.Version
+	return true, nil
+}
+
+// destroy destroys the property collector of the waiter (best effort). It is
+// a no-op on a nil waiter.
+func (w *eventWaiter) destroy() {
+	if w == nil {
+		return
+	}
+	// using fresh ctx to avoid canceled error
+	_ = w.pc.Destroy(context.Background())
+}
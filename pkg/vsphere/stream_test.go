@@ -0,0 +1,62 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+)
+
+func Test_validateCollectorMode(t *testing.T) {
+	for _, mode := range []string{"", CollectorModePoll, CollectorModeStream} {
+		if err := validateCollectorMode(mode); err != nil {
+			t.Errorf("validateCollectorMode(%q) error = %v", mode, err)
+		}
+	}
+	if err := validateCollectorMode("push"); err == nil {
+		t.Error("validateCollectorMode(push) error = nil, want error")
+	}
+}
+
+func Test_eventWaiter(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		vcTime := time.Now()
+		coll, err := newHistoryCollector(ctx, vim, vim.ServiceContent.RootFolder, vcTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w, err := newEventWaiter(ctx, coll)
+		if err != nil {
+			t.Fatalf("newEventWaiter() error = %v", err)
+		}
+		defer w.destroy()
+
+		changed, err := w.wait(ctx, time.Second)
+		if err != nil || changed {
+			t.Fatalf("wait() without events = %v, %v, want false", changed, err)
+		}
+
+		vm, err := find.NewFinder(vim).VirtualMachine(ctx, "DC0_H0_VM0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		task, err := vm.PowerOff(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = task.Wait(ctx)
+
+		if changed, err = w.wait(ctx, 5*time.Second); err != nil || !changed {
+			t.Errorf("wait() after an event = %v, %v, want true", changed, err)
+		}
+	})
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/types"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// poll the vAPI tagging endpoints for association changes at this interval
+	tagPollInterval = 30 * time.Second
+	// event class used for tag association events
+	tagEventClass = "tag"
+	// type prefix of tag association events, e.g. com.vmware.vsphere.tag.attached
+	tagEventTypePrefix = "com.vmware.vsphere.tag."
+)
+
+// tag association change operations
+const (
+	tagOpAttached = "attached"
+	tagOpDetached = "detached"
+)
+
+// tagAssociation is a tag attached to a managed object
+type tagAssociation struct {
+	TagID  string
+	Object ObjectRef
+}
+
+// TagAssociationEventData is the data of a tag association CloudEvent
+type TagAssociationEventData struct {
+	TagID      string    `json:"tagID" protobuf:"1"`
+	TagName    string    `json:"tagName,omitempty" protobuf:"2"`
+	CategoryID string    `json:"categoryID,omitempty" protobuf:"3"`
+	Object     ObjectRef `json:"object" protobuf:"4"`
+}
+
+// tagChange is a tag association change observed between two polls
+type tagChange struct {
+	// Op is either attached or detached
+	Op   string
+	Data TagAssociationEventData
+}
+
+// tagCollector detects tag association changes by comparing snapshots retrieved
+// from the vSphere Automation (vAPI) tagging REST API, since attaching or
+// detaching a tag is not part of the vCenter event stream.
+type tagCollector struct {
+	mgr *tags.Manager
+
+	// snapshot from the last poll, nil before the first poll
+	associations map[tagAssociation]tags.Tag
+}
+
+func newTagCollector(c *rest.Client) *tagCollector {
+	return &tagCollector{mgr: tags.NewManager(c)}
+}
+
+// next returns the changes since the last call. The first call only records
+// the current state and does not return any changes.
+func (c *tagCollector) next(ctx context.Context) ([]tagChange, error) {
+	all, err := c.mgr.GetTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get tags: %w", err)
+	}
+
+	associations := make(map[tagAssociation]tags.Tag)
+	if len(all) > 0 {
+		ids := make([]string, 0, len(all))
+		byID := make(map[string]tags.Tag, len(all))
+		for _, t := range all {
+			ids = append(ids, t.ID)
+			byID[t.ID] = t
+		}
+
+		attached, err := c.mgr.ListAttachedObjectsOnTags(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("list attached objects: %w", err)
+		}
+
+		for _, a := range attached {
+			for _, obj := range a.ObjectIDs {
+				ref := obj.Reference()
+				assoc := tagAssociation{
+					TagID:  a.TagID,
+					Object: ObjectRef{Type: ref.Type, Value: ref.Value},
+				}
+				associations[assoc] = byID[a.TagID]
+			}
+		}
+	}
+
+	var changes []tagChange
+	if c.associations != nil {
+		changes = diffTagAssociations(c.associations, associations)
+	}
+
+	c.associations = associations
+	return changes, nil
+}
+
+func diffTagAssociations(old, cur map[tagAssociation]tags.Tag) []tagChange {
+	var changes []tagChange
+	for assoc, t := range cur {
+		if _, ok := old[assoc]; !ok {
+			changes = append(changes, newTagChange(tagOpAttached, assoc, t))
+		}
+	}
+
+	for assoc, t := range old {
+		if _, ok := cur[assoc]; !ok {
+			changes = append(changes, newTagChange(tagOpDetached, assoc, t))
+		}
+	}
+
+	// stable event order
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i].Data, changes[j].Data
+		if a.Object.Value != b.Object.Value {
+			return a.Object.Value < b.Object.Value
+		}
+		return a.TagID < b.TagID
+	})
+	return changes
+}
+
+func newTagChange(op string, assoc tagAssociation, t tags.Tag) tagChange {
+	return tagChange{
+		Op: op,
+		Data: TagAssociationEventData{
+			TagID:      assoc.TagID,
+			TagName:    t.Name,
+			CategoryID: t.CategoryID,
+			Object:     assoc.Object,
+		},
+	}
+}
+
+// readTags periodically polls vCenter for tag association changes and sends
+// them to the configured sink. Tag association events are not checkpointed.
+func (a *vAdapter) readTags(ctx context.Context, c *tagCollector) error {
+	logger := logging.FromContext(ctx)
+
+	// record the initial state
+	if _, err := c.next(ctx); err != nil {
+		return fmt.Errorf("read tag associations from vcenter: %w", err)
+	}
+
+	ticker := time.NewTicker(tagPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-ticker.C:
+			changes, err := c.next(ctx)
+			if err != nil {
+				return fmt.Errorf("read tag associations from vcenter: %w", err)
+			}
+
+			for _, change := range changes {
+				ev, err := newTagCloudEvent(a.Source, change)
+				if err != nil {
+					logger.Errorw("failed to create tag cloudevent", "tag", change.Data.TagID, "error", err)
+					continue
+				}
+
+				ev, ok, err := a.translate(ev, change)
+				if err != nil {
+					logger.Errorw("failed to translate tag cloudevent", "tag", change.Data.TagID, "error", err)
+					continue
+				}
+
+				if !ok || !a.Filter.Match(ev, change) {
+					continue
+				}
+
+				sinkCtx := a.Sinks.withMatching(ctx, ev, change)
+				if err = a.Transformer.apply(&ev, change); err != nil {
+					logger.Warnw("failed to transform tag cloudevent", "tag", change.Data.TagID, "error", err)
+					continue
+				}
+
+				// changes are not checkpointed, so events which are neither
+				// sent nor dead lettered are lost
+				if result := a.deliver(sinkCtx, ev, entityExtensionContext(&types.ManagedObjectReference{
+					Type:  change.Data.Object.Type,
+					Value: change.Data.Object.Value,
+				})); !cloudevents.IsACK(result) {
+					_ = a.failed(logging.WithLogger(ctx, logger.With("tag", change.Data.TagID)), sinkError(result))
+				}
+			}
+		}
+	}
+}
+
+// newTagCloudEvent converts the given tag association change into a
+// CloudEvent, e.g. of type com.vmware.vsphere.tag.attached. The subject is the
+// managed object the tag is attached to or detached from.
+func newTagCloudEvent(source string, change tagChange) (cloudevents.Event, error) {
+	ev := cloudevents.NewEvent(cloudevents.VersionV1)
+	ev.SetSource(source)
+	ev.SetType(tagEventTypePrefix + change.Op)
+	setDataSchema(&ev, schemaTagAssociation)
+	ev.SetExtension("EventClass", tagEventClass)
+	ev.SetSubject(change.Data.Object.Value)
+	// the same association can be attached and detached repeatedly
+	ev.SetID(uuid.New().String())
+	// association changes are not timestamped by vCenter
+	ev.SetTime(time.Now().UTC())
+
+	if err := ev.SetData(cloudevents.ApplicationJSON, change.Data); err != nil {
+		return ev, fmt.Errorf("set data on event: %w", err)
+	}
+	return ev, nil
+}
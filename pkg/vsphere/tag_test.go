@@ -0,0 +1,123 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+func Test_tagCollector_next(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		rc := rest.NewClient(vim)
+		if err := rc.Login(ctx, simulator.DefaultLogin); err != nil {
+			t.Fatal(err)
+		}
+
+		vm, err := find.NewFinder(vim).VirtualMachine(ctx, "DC0_H0_VM0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mgr := tags.NewManager(rc)
+		c := newTagCollector(rc)
+
+		assertChanges := func(t *testing.T, want ...string) {
+			t.Helper()
+			changes, err := c.next(ctx)
+			if err != nil {
+				t.Fatalf("next() error = %v", err)
+			}
+
+			var got []string
+			for _, change := range changes {
+				if change.Data.Object.Value != vm.Reference().Value {
+					t.Errorf("next() object = %v, want %v", change.Data.Object, vm.Reference())
+				}
+				got = append(got, change.Data.TagName+"."+change.Op)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("next() = %v, want %v", got, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("next() = %v, want %v", got, want)
+				}
+			}
+		}
+
+		// no tags yet
+		assertChanges(t)
+
+		catID, err := mgr.CreateCategory(ctx, &tags.Category{Name: "env", Cardinality: "MULTIPLE"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		prodID, err := mgr.CreateTag(ctx, &tags.Tag{Name: "prod", CategoryID: catID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		devID, err := mgr.CreateTag(ctx, &tags.Tag{Name: "dev", CategoryID: catID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertChanges(t)
+
+		if err = mgr.AttachTag(ctx, prodID, vm); err != nil {
+			t.Fatal(err)
+		}
+		assertChanges(t, "prod.attached")
+
+		// no changes
+		assertChanges(t)
+
+		if err = mgr.AttachTag(ctx, devID, vm); err != nil {
+			t.Fatal(err)
+		}
+		assertChanges(t, "dev.attached")
+
+		if err = mgr.DetachTag(ctx, prodID, vm); err != nil {
+			t.Fatal(err)
+		}
+		assertChanges(t, "prod.detached")
+	})
+}
+
+func Test_newTagCloudEvent(t *testing.T) {
+	change := tagChange{
+		Op: tagOpDetached,
+		Data: TagAssociationEventData{
+			TagID:   "urn:vmomi:InventoryServiceTag:1:GLOBAL",
+			TagName: "prod",
+			Object:  ObjectRef{Type: "VirtualMachine", Value: "vm-1"},
+		},
+	}
+
+	ev, err := newTagCloudEvent(source, change)
+	if err != nil {
+		t.Fatalf("newTagCloudEvent() error = %v", err)
+	}
+	if want := "com.vmware.vsphere.tag.detached"; ev.Type() != want {
+		t.Errorf("Type() = %s, want %s", ev.Type(), want)
+	}
+	if want := DataSchemaURL(schemaTagAssociation); ev.DataSchema() != want {
+		t.Errorf("DataSchema() = %s, want %s", ev.DataSchema(), want)
+	}
+	if ev.Subject() != "vm-1" {
+		t.Errorf("Subject() = %s, want %s", ev.Subject(), "vm-1")
+	}
+	if err := ev.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
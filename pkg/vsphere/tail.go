@@ -0,0 +1,153 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25/soap"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+// TailEvents reads the events of a source with the given configuration from
+// vCenter, logging in with the given credentials, and calls send with every
+// event the adapter of the source would send to its sink, until ctx is done or
+// reading fails. Events are read from the current vCenter time on, nothing is
+// checkpointed.
+func TailEvents(ctx context.Context, config SourceConfig, username, password string, send func(cloudevents.Event) error) error {
+	env := config.envConfig("", "")
+	// the tail shows the events once, as sent to the sink of the source
+	env.Sinks = ""
+	env.Kafka = ""
+
+	// never replay, the tail starts at the current vCenter time
+	cpconf, err := newCheckpointConfig(env.CheckpointConfig)
+	if err != nil {
+		return fmt.Errorf("could not not read checkpoint config: %w", err)
+	}
+	cpconf.ReplayFrom = nil
+	b, err := json.Marshal(cpconf)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint config: %w", err)
+	}
+	env.CheckpointConfig = string(b)
+
+	enrichment, err := newEnrichment(env.Enrichment)
+	if err != nil {
+		return fmt.Errorf("could not read enrichment config: %w", err)
+	}
+
+	u, err := soap.ParseURL(config.VCenter.Address)
+	if err != nil {
+		return fmt.Errorf("failed to parse vCenter URL: %w", err)
+	}
+	u.User = url.UserPassword(username, password)
+
+	vClient, err := govmomi.NewClient(ctx, u, config.VCenter.Insecure)
+	if err != nil {
+		return fmt.Errorf("unable to create vSphere client: %w", err)
+	}
+	var rClient *rest.Client
+	if env.needsREST(enrichment) {
+		rClient = rest.NewClient(vClient.Client)
+		if err := rClient.Login(ctx, u.User); err != nil {
+			logout(vClient, nil)
+			return fmt.Errorf("unable to create vSphere REST client: %w", err)
+		}
+	}
+	defer logout(vClient, rClient)
+
+	var overrides duckv1.CloudEventOverrides
+	if env.CEOverrides != "" {
+		if err := json.Unmarshal([]byte(env.CEOverrides), &overrides); err != nil {
+			return fmt.Errorf("could not read CloudEvents overrides: %w", err)
+		}
+	}
+
+	transport, err := env.newSinkTransport()
+	if err != nil {
+		return fmt.Errorf("could not configure sink transport: %w", err)
+	}
+	ceClient := &tailClient{send: send, extensions: overrides.Extensions}
+	a, err := newVAdapter(ctx, env, enrichment, ceClient, transport, &memoryKVStore{}, vClient, rClient)
+	if err != nil {
+		return err
+	}
+	return a.run(ctx)
+}
+
+// tailClient is a CloudEvents client passing the events to a function
+// instead of a sink, after applying the extensions of the CloudEvents
+// overrides like the client of the adapter.
+type tailClient struct {
+	send       func(cloudevents.Event) error
+	extensions map[string]string
+}
+
+var _ cloudevents.Client = (*tailClient)(nil)
+
+func (c *tailClient) Send(_ context.Context, ev event.Event) protocol.Result {
+	for k, v := range c.extensions {
+		ev.SetExtension(k, v)
+	}
+	if err := c.send(ev); err != nil {
+		return err
+	}
+	return protocol.ResultACK
+}
+
+func (c *tailClient) Request(ctx context.Context, ev event.Event) (*event.Event, protocol.Result) {
+	return nil, c.Send(ctx, ev)
+}
+
+func (c *tailClient) StartReceiver(context.Context, interface{}) error {
+	return fmt.Errorf("receiving events is not supported")
+}
+
+// memoryKVStore is a kvstore which is not backed by a configmap.
+type memoryKVStore struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (s *memoryKVStore) Init(context.Context) error { return nil }
+func (s *memoryKVStore) Load(context.Context) error { return nil }
+func (s *memoryKVStore) Save(context.Context) error { return nil }
+
+func (s *memoryKVStore) Get(_ context.Context, key string, value interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.data[key]
+	if !ok {
+		return fmt.Errorf("key %s does not exist", key)
+	}
+	return json.Unmarshal([]byte(v), value)
+}
+
+func (s *memoryKVStore) Set(_ context.Context, key string, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to Marshal: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		s.data = make(map[string]string)
+	}
+	s.data[key] = string(b)
+	return nil
+}
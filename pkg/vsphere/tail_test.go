@@ -0,0 +1,92 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"go.uber.org/zap/zaptest"
+	"knative.dev/pkg/logging"
+)
+
+func TestTailEvents(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vim *vim25.Client) {
+		ctx = logging.WithLogger(ctx, zaptest.NewLogger(t).Sugar())
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		config := SourceConfig{
+			VCenter:     EnvConfig{Address: vim.URL().String(), Insecure: true},
+			EventFilter: `{"eventTypes":["*VmPoweredOffEvent*"]}`,
+			CEOverrides: `{"extensions":{"team":"a"}}`,
+		}
+
+		events := make(chan cloudevents.Event, 10)
+		tailErr := make(chan error, 1)
+		go func() {
+			tailErr <- TailEvents(ctx, config, "user", "pass", func(ev cloudevents.Event) error {
+				select {
+				case events <- ev:
+				default:
+				}
+				return nil
+			})
+		}()
+
+		vm, err := find.NewFinder(vim).VirtualMachine(ctx, "DC0_H0_VM0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the tail starts at the current vCenter time, so retry until an event
+		// is observed
+		var got *cloudevents.Event
+		timeout := time.After(10 * time.Second)
+		for got == nil {
+			task, err := vm.PowerOff(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = task.Wait(ctx)
+
+			select {
+			case ev := <-events:
+				got = &ev
+			case <-time.After(100 * time.Millisecond):
+				task, err = vm.PowerOn(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_ = task.Wait(ctx)
+			case err := <-tailErr:
+				t.Fatalf("TailEvents() = %v", err)
+			case <-timeout:
+				t.Fatal("timed out waiting for event")
+			}
+		}
+
+		if !strings.Contains(got.Type(), "VmPoweredOffEvent") {
+			t.Errorf("Type() = %s, want only filtered power off events", got.Type())
+		}
+		if ext := got.Extensions()["team"]; ext != "a" {
+			t.Errorf("extension team = %v, want a", ext)
+		}
+
+		cancel()
+		select {
+		case <-tailErr:
+		case <-time.After(5 * time.Second):
+			t.Fatal("TailEvents() did not return after cancel")
+		}
+	})
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// read up to max tasks per iteration
+	maxTasksBatch = 100
+	// poll vCenter for new tasks and task state changes at this interval
+	taskPollInterval = 2 * time.Second
+	// event class used for task events
+	taskEventClass = "task"
+)
+
+// taskCollector reads new tasks from a vCenter TaskHistoryCollector and tracks
+// the state of tasks which did not complete yet, so that every task lifecycle
+// transition (queued, running, success, error) is observed.
+type taskCollector struct {
+	client *vim25.Client
+	ref    types.ManagedObjectReference
+
+	// last observed state of tasks which did not complete yet
+	pending map[types.ManagedObjectReference]types.TaskInfoState
+}
+
+func newTaskCollector(ctx context.Context, client *vim25.Client, root types.ManagedObjectReference, begin time.Time) (*taskCollector, error) {
+	req := types.CreateCollectorForTasks{
+		This: *client.ServiceContent.TaskManager,
+		Filter: types.TaskFilterSpec{
+			Entity: &types.TaskFilterSpecByEntity{
+				Entity:    root,
+				Recursion: types.TaskFilterSpecRecursionOptionAll,
+			},
+			Time: &types.TaskFilterSpecByTime{
+				TimeType:  types.TaskFilterSpecTimeOptionQueuedTime,
+				BeginTime: types.NewTime(begin),
+			},
+		},
+	}
+
+	res, err := methods.CreateCollectorForTasks(ctx, client, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &taskCollector{
+		client:  client,
+		ref:     res.Returnval,
+		pending: make(map[types.ManagedObjectReference]types.TaskInfoState),
+	}, nil
+}
+
+// next returns the state changes of pending tasks followed by new tasks read
+// from the history collector since the last call.
+func (c *taskCollector) next(ctx context.Context) ([]types.TaskInfo, error) {
+	var result []types.TaskInfo
+
+	updates, err := c.refresh(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("refresh pending tasks: %w", err)
+	}
+	result = append(result, updates...)
+
+	res, err := methods.ReadNextTasks(ctx, c.client, &types.ReadNextTasks{
+		This:     c.ref,
+		MaxCount: maxTasksBatch,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read next tasks: %w", err)
+	}
+
+	for _, info := range res.Returnval {
+		if c.track(info) {
+			result = append(result, info)
+		}
+	}
+
+	return result, nil
+}
+
+// refresh retrieves the current info of all pending tasks and returns those
+// with a changed state. Tasks which no longer exist in vCenter are dropped.
+func (c *taskCollector) refresh(ctx context.Context) ([]types.TaskInfo, error) {
+	if len(c.pending) == 0 {
+		return nil, nil
+	}
+
+	refs := make([]types.ManagedObjectReference, 0, len(c.pending))
+	for ref := range c.pending {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Value < refs[j].Value })
+
+	var tasks []mo.Task
+	err := property.DefaultCollector(c.client).Retrieve(ctx, refs, []string{"info"}, &tasks)
+	if err != nil {
+		if soap.IsSoapFault(err) {
+			if f, ok := soap.ToSoapFault(err).VimFault().(types.ManagedObjectNotFound); ok {
+				// task expired: stop tracking it and retry with the next poll
+				delete(c.pending, f.Obj)
+				return nil, nil
+			}
+		}
+		return nil, err
+	}
+
+	var result []types.TaskInfo
+	for _, t := range tasks {
+		if c.track(t.Info) {
+			result = append(result, t.Info)
+		}
+	}
+	return result, nil
+}
+
+// track records the state of the given task and returns true if the task is
+// new or its state changed since it was last observed.
+func (c *taskCollector) track(info types.TaskInfo) bool {
+	last, known := c.pending[info.Task]
+	if known && last == info.State {
+		return false
+	}
+
+	switch info.State {
+	case types.TaskInfoStateSuccess, types.TaskInfoStateError:
+		delete(c.pending, info.Task)
+	default:
+		c.pending[info.Task] = info.State
+	}
+	return true
+}
+
+// readTasks periodically polls vCenter for task lifecycle changes and sends
+// them to the configured sink. Task events are not checkpointed.
+func (a *vAdapter) readTasks(ctx context.Context, c *taskCollector) error {
+	logger := logging.FromContext(ctx)
+
+	ticker := time.NewTicker(taskPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-ticker.C:
+			tasks, err := c.next(ctx)
+			if err != nil {
+				return fmt.Errorf("read tasks from vcenter: %w", err)
+			}
+
+			for _, info := range tasks {
+				ev, err := newTaskCloudEvent(a.Source, info)
+				if err != nil {
+					logger.Errorw("failed to create task cloudevent", "task", info.Key, "error", err)
+					continue
+				}
+
+				ev, ok, err := a.translate(ev, info)
+				if err != nil {
+					logger.Errorw("failed to translate task cloudevent", "task", info.Key, "error", err)
+					continue
+				}
+
+				if !ok || !a.Filter.Match(ev, info) {
+					continue
+				}
+
+				sinkCtx := a.Sinks.withMatching(ctx, ev, info)
+				if err = a.Transformer.apply(&ev, info); err != nil {
+					logger.Warnw("failed to transform task cloudevent", "task", info.Key, "error", err)
+					continue
+				}
+
+				// tasks are not checkpointed, so events which are neither sent
+				// nor dead lettered are lost
+				if result := a.deliver(sinkCtx, ev, entityExtensionContext(info.Entity)); !cloudevents.IsACK(result) {
+					_ = a.failed(logging.WithLogger(ctx, logger.With("task", info.Key)), sinkError(result))
+				}
+			}
+		}
+	}
+}
+
+// newTaskCloudEvent converts the given task info into a CloudEvent with a type
+// reflecting the task state, e.g. com.vmware.vsphere.task.success.
+func newTaskCloudEvent(source string, info types.TaskInfo) (cloudevents.Event, error) {
+	ev := cloudevents.NewEvent(cloudevents.VersionV1)
+	ev.SetSource(source)
+	ev.SetType("com.vmware.vsphere.task." + string(info.State))
+	ev.SetExtension("EventClass", taskEventClass)
+	ev.SetID(fmt.Sprintf("%s-%s", info.Key, info.State))
+	ev.SetTime(getTaskTime(info))
+
+	if err := ev.SetData(cloudevents.ApplicationXML, info); err != nil {
+		return ev, fmt.Errorf("set data on event: %w", err)
+	}
+	return ev, nil
+}
+
+// getTaskTime returns the time of the last state transition of the given task
+func getTaskTime(info types.TaskInfo) time.Time {
+	switch info.State {
+	case types.TaskInfoStateSuccess, types.TaskInfoStateError:
+		if info.CompleteTime != nil {
+			return *info.CompleteTime
+		}
+	case types.TaskInfoStateRunning:
+		if info.StartTime != nil {
+			return *info.StartTime
+		}
+	}
+	return info.QueueTime
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_taskCollector_track(t *testing.T) {
+	task := types.ManagedObjectReference{Type: "Task", Value: "task-1"}
+	info := func(state types.TaskInfoState) types.TaskInfo {
+		return types.TaskInfo{Key: "task-1", Task: task, State: state}
+	}
+
+	c := &taskCollector{pending: make(map[types.ManagedObjectReference]types.TaskInfoState)}
+
+	steps := []struct {
+		state       types.TaskInfoState
+		wantChanged bool
+		wantPending bool
+	}{
+		{state: types.TaskInfoStateQueued, wantChanged: true, wantPending: true},
+		{state: types.TaskInfoStateQueued, wantChanged: false, wantPending: true},
+		{state: types.TaskInfoStateRunning, wantChanged: true, wantPending: true},
+		{state: types.TaskInfoStateRunning, wantChanged: false, wantPending: true},
+		{state: types.TaskInfoStateSuccess, wantChanged: true, wantPending: false},
+	}
+
+	for i, s := range steps {
+		if got := c.track(info(s.state)); got != s.wantChanged {
+			t.Errorf("step %d: track(%s) = %v, want %v", i, s.state, got, s.wantChanged)
+		}
+		if _, got := c.pending[task]; got != s.wantPending {
+			t.Errorf("step %d: task pending = %v, want %v", i, got, s.wantPending)
+		}
+	}
+}
+
+func Test_newTaskCloudEvent(t *testing.T) {
+	queued := time.Date(2021, 2, 15, 19, 20, 0, 0, time.UTC)
+	started := queued.Add(time.Second)
+	completed := started.Add(time.Second)
+
+	tests := []struct {
+		name     string
+		info     types.TaskInfo
+		wantType string
+		wantID   string
+		wantTime time.Time
+	}{
+		{
+			name:     "queued task",
+			info:     types.TaskInfo{Key: "task-1", State: types.TaskInfoStateQueued, QueueTime: queued},
+			wantType: "com.vmware.vsphere.task.queued",
+			wantID:   "task-1-queued",
+			wantTime: queued,
+		},
+		{
+			name: "running task",
+			info: types.TaskInfo{Key: "task-1", State: types.TaskInfoStateRunning, QueueTime: queued,
+				StartTime: &started},
+			wantType: "com.vmware.vsphere.task.running",
+			wantID:   "task-1-running",
+			wantTime: started,
+		},
+		{
+			name: "failed task",
+			info: types.TaskInfo{Key: "task-1", State: types.TaskInfoStateError, QueueTime: queued,
+				StartTime: &started, CompleteTime: &completed},
+			wantType: "com.vmware.vsphere.task.error",
+			wantID:   "task-1-error",
+			wantTime: completed,
+		},
+		{
+			name:     "completed task without complete time",
+			info:     types.TaskInfo{Key: "task-1", State: types.TaskInfoStateSuccess, QueueTime: queued},
+			wantType: "com.vmware.vsphere.task.success",
+			wantID:   "task-1-success",
+			wantTime: queued,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := newTaskCloudEvent(source, tt.info)
+			if err != nil {
+				t.Fatalf("newTaskCloudEvent() error = %v", err)
+			}
+			if ev.Type() != tt.wantType {
+				t.Errorf("Type() = %s, want %s", ev.Type(), tt.wantType)
+			}
+			if ev.ID() != tt.wantID {
+				t.Errorf("ID() = %s, want %s", ev.ID(), tt.wantID)
+			}
+			if !ev.Time().Equal(tt.wantTime) {
+				t.Errorf("Time() = %v, want %v", ev.Time(), tt.wantTime)
+			}
+			if ev.Source() != source {
+				t.Errorf("Source() = %s, want %s", ev.Source(), source)
+			}
+			if err := ev.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		})
+	}
+}
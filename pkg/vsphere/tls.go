@@ -0,0 +1,107 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TLSConfig restricts the TLS versions and cipher suites of the connections
+// to vCenter and the sink, e.g. for FIPS or STIG compliance
+type TLSConfig struct {
+	// MinVersion is the minimum TLS version, one of 1.0, 1.1, 1.2 or 1.3. The
+	// Go default is used if empty.
+	MinVersion string
+	// CipherSuites is a comma-separated list of the names of the allowed
+	// cipher suites, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, see
+	// ParseCipherSuites. The Go defaults are used if empty. The cipher suites
+	// of TLS 1.3 are not configurable.
+	CipherSuites string
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion returns the TLS version of the given name, one of 1.0, 1.1,
+// 1.2 or 1.3
+func ParseTLSVersion(version string) (uint16, error) {
+	v, ok := tlsVersions[version]
+	if !ok {
+		return 0, fmt.Errorf("unsupported TLS version %q, must be one of 1.0, 1.1, 1.2 or 1.3", version)
+	}
+	return v, nil
+}
+
+// ParseCipherSuites returns the IDs of the cipher suites in the given
+// comma-separated list of cipher suite names as defined by the crypto/tls
+// package, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Insecure cipher suites
+// are supported, but must be allowed explicitly.
+func ParseCipherSuites(names string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		known[s.Name] = s.ID
+	}
+
+	var ids []uint16
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no cipher suite given")
+	}
+	return ids, nil
+}
+
+// Validate returns an error if the minimum TLS version or a cipher suite is
+// not supported
+func (c TLSConfig) Validate() error {
+	if c.MinVersion != "" {
+		if _, err := ParseTLSVersion(c.MinVersion); err != nil {
+			return err
+		}
+	}
+	if c.CipherSuites != "" {
+		if _, err := ParseCipherSuites(c.CipherSuites); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply sets the minimum TLS version and cipher suites of the given TLS
+// configuration, if configured
+func (c TLSConfig) apply(t *tls.Config) error {
+	if c.MinVersion != "" {
+		v, err := ParseTLSVersion(c.MinVersion)
+		if err != nil {
+			return err
+		}
+		t.MinVersion = v
+	}
+	if c.CipherSuites != "" {
+		ids, err := ParseCipherSuites(c.CipherSuites)
+		if err != nil {
+			return err
+		}
+		t.CipherSuites = ids
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"crypto/tls"
+	"reflect"
+	"testing"
+)
+
+func TestParseCipherSuites(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   string
+		want    []uint16
+		wantErr bool
+	}{
+		{
+			name:  "secure and insecure suites",
+			names: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_RSA_WITH_RC4_128_SHA",
+			want:  []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_RSA_WITH_RC4_128_SHA},
+		},
+		{name: "unknown suite", names: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_RSA_WITH_NULL", wantErr: true},
+		{name: "no suite", names: " , ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCipherSuites(tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCipherSuites() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCipherSuites() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTLSConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  TLSConfig
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "valid", config: TLSConfig{MinVersion: "1.2", CipherSuites: "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"}},
+		{name: "unsupported version", config: TLSConfig{MinVersion: "1.2.1"}, wantErr: true},
+		{name: "unsupported cipher suite", config: TLSConfig{CipherSuites: "AES256"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
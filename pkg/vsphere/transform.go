@@ -0,0 +1,81 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/template"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// Transform reshapes the payload of events before they are sent to the sink,
+// e.g. to send a stable minimal schema instead of the vSphere API objects
+type Transform struct {
+	// Template is a text/template producing the JSON payload, see
+	// NewTransformTemplate
+	Template string `json:"template"`
+}
+
+// NewTransformTemplate parses the given payload template. The template is
+// executed with the payload decoded as JSON, e.g. {{ .Vm.Name }} for vSphere
+// events, and must produce JSON. The json function encodes a value as JSON,
+// e.g. {"vm": {{ json .Vm.Name }}}. Missing keys are null.
+func NewTransformTemplate(s string) (*template.Template, error) {
+	return template.New("transform").Option("missingkey=zero").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(s)
+}
+
+// transformer applies a Transform to events
+type transformer struct {
+	tmpl *template.Template
+}
+
+// newTransformer returns the transformer for the given JSON-encoded Transform,
+// which is nil if s is empty
+func newTransformer(s string) (*transformer, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var t Transform
+	if err := json.Unmarshal([]byte(s), &t); err != nil {
+		return nil, fmt.Errorf("unmarshal transform: %w", err)
+	}
+	if t.Template == "" {
+		return nil, errors.New("missing transform template")
+	}
+	tmpl, err := NewTransformTemplate(t.Template)
+	if err != nil {
+		return nil, fmt.Errorf("parse transform template: %w", err)
+	}
+	return &transformer{tmpl: tmpl}, nil
+}
+
+// apply replaces the payload of the given event, created from the given
+// vSphere object, with the JSON produced by the template, see eventData. A nil
+// transformer is a no-op.
+func (t *transformer) apply(ev *cloudevents.Event, obj interface{}) error {
+	if t == nil {
+		return nil
+	}
+
+	var b bytes.Buffer
+	if err := t.tmpl.Execute(&b, eventData(*ev, obj)); err != nil {
+		return fmt.Errorf("execute transform template: %w", err)
+	}
+	if !json.Valid(b.Bytes()) {
+		return fmt.Errorf("transform template returned invalid JSON: %q", b.String())
+	}
+	return ev.SetData(cloudevents.ApplicationJSON, b.Bytes())
+}
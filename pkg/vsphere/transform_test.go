@@ -0,0 +1,115 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"strconv"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func Test_newTransformer(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform string
+		wantNil   bool
+		wantErr   bool
+	}{
+		{name: "no transform", wantNil: true},
+		{name: "template", transform: `{"template":"{\"vm\": {{ json .Vm.Name }}}"}`},
+		{name: "invalid json", transform: `{"template":1}`, wantErr: true},
+		{name: "missing template", transform: `{}`, wantErr: true},
+		{name: "invalid template", transform: `{"template":"{{ .Vm.Name "}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTransformer(tt.transform)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newTransformer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("newTransformer() = %v, want nil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_transformer_apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "pick and rename fields",
+			template: `{"vm": {{ json .Vm.Name }}, "moref": {{ json .Vm.Vm.Value }}, "datastore": {{ json .Ds }}}`,
+			want:     `{"vm": "prod-db", "moref": "vm-42", "datastore": null}`,
+		},
+		{name: "missing parent field", template: `{"host": {{ json .Host.Name }}}`, wantErr: true},
+		{name: "invalid json", template: `vm={{ .Vm.Name }}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, err := newTransformer(`{"template":` + strconv.Quote(tt.template) + `}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			be := &types.VmPoweredOnEvent{VmEvent: types.VmEvent{Event: types.Event{
+				Vm: &types.VmEventArgument{
+					EntityEventArgument: types.EntityEventArgument{Name: "prod-db"},
+					Vm:                  types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-42"},
+				},
+			}}}
+			ev, err := newEventCloudEvent("vcenter.example.com", be, eventOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = tr.apply(&ev, be)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := string(ev.Data()); got != tt.want {
+				t.Errorf("apply() data = %s, want %s", got, tt.want)
+			}
+			if ev.DataContentType() != cloudevents.ApplicationJSON {
+				t.Errorf("apply() content type = %s, want %s", ev.DataContentType(), cloudevents.ApplicationJSON)
+			}
+		})
+	}
+
+	t.Run("json payload", func(t *testing.T) {
+		tr, err := newTransformer(`{"template":"{\"name\": {{ json .vm.name }}}"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev := cloudevents.NewEvent()
+		if err = ev.SetData(cloudevents.ApplicationJSON, map[string]interface{}{"vm": map[string]string{"name": "prod-db"}}); err != nil {
+			t.Fatal(err)
+		}
+		if err = tr.apply(&ev, nil); err != nil {
+			t.Fatalf("apply() error = %v", err)
+		}
+		if got, want := string(ev.Data()), `{"name": "prod-db"}`; got != want {
+			t.Errorf("apply() data = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("nil transformer", func(t *testing.T) {
+		var tr *transformer
+		ev := cloudevents.NewEvent()
+		if err := tr.apply(&ev, nil); err != nil {
+			t.Errorf("apply() error = %v", err)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// token of the Kubernetes service account of the pod
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	// header of Vault API requests with the client token
+	vaultTokenHeader = "X-Vault-Token"
+	// timeout of Vault API requests
+	vaultTimeout = 30 * time.Second
+)
+
+// vaultClient sends Vault API requests. The http.DefaultClient is not used
+// since the CloudEvents client may replace its transport. The sink CA
+// certificates only apply to the sink transport, see newSinkTransport.
+var vaultClient = &http.Client{Timeout: vaultTimeout, Transport: http.DefaultTransport.(*http.Transport).Clone()}
+
+// VaultConfig configures the HashiCorp Vault credential provider
+type VaultConfig struct {
+	// Address is the URL of the Vault server
+	Address string `envconfig:"VC_VAULT_ADDRESS" default:"" json:"address,omitempty"`
+	// Path is the path of the secret with the credentials, e.g.
+	// secret/data/vcenter for version 2 of the KV secrets engine
+	Path string `envconfig:"VC_VAULT_PATH" default:"" json:"path,omitempty"`
+	// Role is the role of the Kubernetes auth method to log in with
+	Role string `envconfig:"VC_VAULT_ROLE" default:"" json:"role,omitempty"`
+	// AuthPath is the mount path of the Kubernetes auth method
+	AuthPath string `envconfig:"VC_VAULT_AUTH_PATH" default:"kubernetes" json:"authPath,omitempty"`
+	// TokenPath is the file with the service account token to log in with
+	TokenPath string `envconfig:"VC_VAULT_TOKEN_PATH" default:"" json:"tokenPath,omitempty"`
+}
+
+// vaultProvider reads the keys of a Vault secret. The secret is read once and
+// all keys are served from memory, i.e. a new provider must be created to
+// pick up changed credentials.
+type vaultProvider struct {
+	config VaultConfig
+	data   map[string]string
+}
+
+func newVaultProvider(config VaultConfig) (*vaultProvider, error) {
+	if config.Address == "" || config.Path == "" || config.Role == "" {
+		return nil, errors.New("vault address, path and role required")
+	}
+	if config.TokenPath == "" {
+		config.TokenPath = serviceAccountTokenPath
+	}
+	return &vaultProvider{config: config}, nil
+}
+
+// Key implements CredentialProvider
+func (p *vaultProvider) Key(ctx context.Context, key string) (string, error) {
+	if p.data == nil {
+		data, err := p.read(ctx)
+		if err != nil {
+			return "", fmt.Errorf("read vault secret %q: %w", p.config.Path, err)
+		}
+		p.data = data
+	}
+
+	v, ok := p.data[key]
+	if !ok {
+		return "", fmt.Errorf("vault secret %q has no key %q: %w", p.config.Path, key, os.ErrNotExist)
+	}
+	return v, nil
+}
+
+// read logs in to Vault and returns the data of the secret
+func (p *vaultProvider) read(ctx context.Context) (map[string]string, error) {
+	token, err := p.login(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("login: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url(p.config.Path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(vaultTokenHeader, token)
+
+	var res struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err = doJSON(vaultClient, req, &res); err != nil {
+		return nil, err
+	}
+
+	// the data of version 2 of the KV secrets engine is nested with its
+	// metadata
+	raw := res.Data
+	if d, ok := res.Data["data"]; ok {
+		if _, ok = res.Data["metadata"]; ok {
+			raw = nil
+			if err = json.Unmarshal(d, &raw); err != nil {
+				return nil, fmt.Errorf("decode secret data: %w", err)
+			}
+		}
+	}
+
+	data := make(map[string]string, len(raw))
+	for k, v := range raw {
+		var s string
+		if err = json.Unmarshal(v, &s); err != nil {
+			return nil, fmt.Errorf("key %q is not a string", k)
+		}
+		data[k] = s
+	}
+	return data, nil
+}
+
+// login returns a client token for the service account token of the pod
+func (p *vaultProvider) login(ctx context.Context) (string, error) {
+	jwt, err := ioutil.ReadFile(p.config.TokenPath)
+	if err != nil {
+		return "", fmt.Errorf("read service account token: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"role": p.config.Role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	path := "auth/" + strings.Trim(p.config.AuthPath, "/") + "/login"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url(path), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var res struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err = doJSON(vaultClient, req, &res); err != nil {
+		return "", err
+	}
+	if res.Auth.ClientToken == "" {
+		return "", errors.New("no client token in response")
+	}
+	return res.Auth.ClientToken, nil
+}
+
+// url returns the URL of the given path of the Vault API
+func (p *vaultProvider) url(path string) string {
+	return strings.TrimSuffix(p.config.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_vaultProvider(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["role"] != "vsphere" || req["jwt"] != "sa-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"auth":{"client_token":"vault-token"}}`))
+	})
+	mux.HandleFunc("/v1/secret/data/vcenter", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(vaultTokenHeader) != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"username":"user","password":"pass"},"metadata":{"version":1}}}`))
+	})
+	mux.HandleFunc("/v1/kv/vcenter", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(vaultTokenHeader) != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"username":"user","password":"pass"}}`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "vault")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenPath := filepath.Join(dir, "token")
+	if err = ioutil.WriteFile(tokenPath, []byte("sa-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  VaultConfig
+		wantErr bool
+	}{{
+		name:   "kv version 2",
+		config: VaultConfig{Address: ts.URL, Path: "secret/data/vcenter", Role: "vsphere", AuthPath: "kubernetes", TokenPath: tokenPath},
+	}, {
+		name:   "kv version 1",
+		config: VaultConfig{Address: ts.URL + "/", Path: "/kv/vcenter", Role: "vsphere", AuthPath: "/kubernetes/", TokenPath: tokenPath},
+	}, {
+		name:    "invalid role",
+		config:  VaultConfig{Address: ts.URL, Path: "secret/data/vcenter", Role: "other", AuthPath: "kubernetes", TokenPath: tokenPath},
+		wantErr: true,
+	}, {
+		name:    "unknown secret",
+		config:  VaultConfig{Address: ts.URL, Path: "secret/data/other", Role: "vsphere", AuthPath: "kubernetes", TokenPath: tokenPath},
+		wantErr: true,
+	}, {
+		name:    "missing token",
+		config:  VaultConfig{Address: ts.URL, Path: "secret/data/vcenter", Role: "vsphere", AuthPath: "kubernetes", TokenPath: filepath.Join(dir, "missing")},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newVaultProvider(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			user, err := readUserInfo(context.Background(), p)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readUserInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if password, _ := user.Password(); user.Username() != "user" || password != "pass" {
+				t.Errorf("readUserInfo() = %v, want user credentials", user)
+			}
+
+			if _, err = p.Key(context.Background(), corev1.TLSCertKey); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("Key() of missing key error = %v, want %v", err, os.ErrNotExist)
+			}
+		})
+	}
+}
+
+func Test_newCredentialProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     EnvConfig
+		want    CredentialProvider
+		wantErr bool
+	}{{
+		name: "default",
+		env:  EnvConfig{},
+		want: secretProvider(DefaultMountPath),
+	}, {
+		name: "secret",
+		env:  EnvConfig{CredentialProvider: CredentialProviderSecret, SecretPath: "/secret"},
+		want: secretProvider("/secret"),
+	}, {
+		name:    "vault without address",
+		env:     EnvConfig{CredentialProvider: CredentialProviderVault, Vault: VaultConfig{Path: "secret/data/vcenter", Role: "vsphere"}},
+		wantErr: true,
+	}, {
+		name:    "unsupported",
+		env:     EnvConfig{CredentialProvider: "keyring"},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newCredentialProvider(context.Background(), tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newCredentialProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("newCredentialProvider() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_vaultClientIgnoresSinkCACerts(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if _, err := (&envConfig{SinkCACerts: string(cert)}).newSinkTransport(); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the sink trusts the CA certificates of the sink
+	res, err := vaultClient.Get(srv.URL)
+	if err == nil {
+		res.Body.Close()
+		t.Error("vaultClient trusts the sink CA certificates")
+	}
+}
@@ -7,20 +7,75 @@ package vsphere
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
+	"github.com/jpillora/backoff"
 	"github.com/vmware/govmomi/event"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
-func newHistoryCollector(ctx context.Context, client *vim25.Client, begin time.Time) (*event.HistoryCollector, error) {
+const (
+	// CollectorDefaultPollInterval is the maximum delay between polls of the
+	// event history collector without new events
+	CollectorDefaultPollInterval = 5 * time.Second
+	// CollectorDefaultPageSize is the maximum number of events read per poll
+	CollectorDefaultPageSize = 100
+	// CollectorMaxPageSize is the maximum number of events vCenter returns per
+	// read of the event history collector
+	CollectorMaxPageSize = 1000
+	// minimum delay between polls without new events
+	collectorMinPollInterval = time.Second
+)
+
+// validateCollector returns an error if the given poll interval or page size
+// of the event history collector is invalid. Zero values use the defaults.
+func validateCollector(pollInterval time.Duration, pageSize int) error {
+	if pollInterval < 0 {
+		return fmt.Errorf("poll interval must not be negative, was %v", pollInterval)
+	}
+	if pageSize < 0 || pageSize > CollectorMaxPageSize {
+		return fmt.Errorf("page size must be between 1 and %d, was %d", CollectorMaxPageSize, pageSize)
+	}
+	return nil
+}
+
+// pollBackoff returns the backoff between polls of the event history
+// collector without new events, up to the given poll interval
+func pollBackoff(pollInterval time.Duration) backoff.Backoff {
+	if pollInterval == 0 {
+		pollInterval = CollectorDefaultPollInterval
+	}
+	min := collectorMinPollInterval
+	if pollInterval < min {
+		min = pollInterval
+	}
+	return backoff.Backoff{
+		Factor: 2,
+		Jitter: false,
+		Min:    min,
+		Max:    pollInterval,
+	}
+}
+
+// pageSize returns the number of events to read per poll of the event history
+// collector
+func pageSize(size int) int32 {
+	if size == 0 {
+		return CollectorDefaultPageSize
+	}
+	return int32(size)
+}
+
+// newHistoryCollector returns an event history collector for the events of
+// the entities in the subtree of the given root, beginning at the given time
+func newHistoryCollector(ctx context.Context, client *vim25.Client, root types.ManagedObjectReference, begin time.Time) (*event.HistoryCollector, error) {
 	mgr := event.NewManager(client)
-	root := client.ServiceContent.RootFolder
 
 	filter := types.EventFilterSpec{
-		// everything
 		Entity: &types.EventFilterSpecByEntity{
 			Entity:    root,
 			Recursion: types.EventFilterSpecRecursionOptionAll,
@@ -65,3 +120,27 @@ func getEventDetails(event types.BaseEvent) eventDetails {
 
 	return details
 }
+
+// vsanEventPrefixes are the EventTypeId prefixes of vSAN health and performance
+// events published by vCenter
+var vsanEventPrefixes = []string{"com.vmware.vsan.", "vsan."}
+
+// vsanEventType returns the event type for the given EventTypeId of an EventEx
+// or ExtendedEvent, if vSAN events are grouped. vSAN event ids are mapped to a common vsan prefix so they can be selected as a group,
+// e.g. com.vmware.vsan.perf.stats.collected becomes vsan.perf.stats.collected
+// and esx.problem.vob.vsan.lsom.diskerror becomes
+// vsan.esx.problem.vob.vsan.lsom.diskerror. Other ids are returned unchanged.
+func vsanEventType(id string) string {
+	for _, p := range vsanEventPrefixes {
+		if strings.HasPrefix(id, p) {
+			return "vsan." + strings.TrimPrefix(id, p)
+		}
+	}
+
+	// vSAN events raised by ESXi hosts, e.g. esx.audit.vsan.clustering.enabled
+	if strings.HasPrefix(id, "esx.") && strings.Contains(id, ".vsan.") {
+		return "vsan." + id
+	}
+
+	return id
+}
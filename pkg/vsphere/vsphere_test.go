@@ -8,6 +8,7 @@ package vsphere
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/vmware/govmomi/vim25/types"
 )
@@ -49,6 +50,36 @@ func Test_getEventDetails(t *testing.T) {
 				Type:  "tokeninvalid.com.auth.provider.foo",
 			},
 		},
+		{
+			name: "vSAN health EventEx",
+			args: args{&types.EventEx{
+				EventTypeId: "vsan.health.test.cluster.clusterstatus.event",
+			}},
+			want: eventDetails{
+				Class: "eventex",
+				Type:  "vsan.health.test.cluster.clusterstatus.event",
+			},
+		},
+		{
+			name: "vSAN performance EventEx",
+			args: args{&types.EventEx{
+				EventTypeId: "com.vmware.vsan.perfsvc.statsdisabled",
+			}},
+			want: eventDetails{
+				Class: "eventex",
+				Type:  "com.vmware.vsan.perfsvc.statsdisabled",
+			},
+		},
+		{
+			name: "vSAN host EventEx",
+			args: args{&types.EventEx{
+				EventTypeId: "esx.problem.vob.vsan.lsom.diskerror",
+			}},
+			want: eventDetails{
+				Class: "eventex",
+				Type:  "esx.problem.vob.vsan.lsom.diskerror",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -58,3 +89,99 @@ func Test_getEventDetails(t *testing.T) {
 		})
 	}
 }
+
+func Test_newEventCloudEventVSAN(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    types.BaseEvent
+		group    bool
+		wantType string
+	}{
+		{
+			name:     "vSAN EventEx",
+			event:    &types.EventEx{EventTypeId: "com.vmware.vsan.perfsvc.statsdisabled"},
+			wantType: "com.vmware.vsphere.com.vmware.vsan.perfsvc.statsdisabled",
+		},
+		{
+			name:     "grouped vSAN EventEx",
+			event:    &types.EventEx{EventTypeId: "com.vmware.vsan.perfsvc.statsdisabled"},
+			group:    true,
+			wantType: "com.vmware.vsphere.vsan.perfsvc.statsdisabled",
+		},
+		{
+			name:     "grouped vSAN host ExtendedEvent",
+			event:    &types.ExtendedEvent{EventTypeId: "esx.problem.vob.vsan.lsom.diskerror"},
+			group:    true,
+			wantType: "com.vmware.vsphere.vsan.esx.problem.vob.vsan.lsom.diskerror",
+		},
+		{
+			name:     "grouped other EventEx",
+			event:    &types.EventEx{EventTypeId: "snapshotcreated.com.backup.provider.foo"},
+			group:    true,
+			wantType: "com.vmware.vsphere.snapshotcreated.com.backup.provider.foo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := newEventCloudEvent(source, tt.event, eventOptions{groupVSAN: tt.group})
+			if err != nil {
+				t.Fatalf("newEventCloudEvent() error = %v", err)
+			}
+			if ev.Type() != tt.wantType {
+				t.Errorf("Type() = %s, want %s", ev.Type(), tt.wantType)
+			}
+		})
+	}
+}
+
+func Test_validateCollector(t *testing.T) {
+	tests := []struct {
+		name         string
+		pollInterval time.Duration
+		pageSize     int
+		wantErr      bool
+	}{
+		{name: "defaults"},
+		{name: "custom", pollInterval: 30 * time.Second, pageSize: CollectorMaxPageSize},
+		{name: "negative poll interval", pollInterval: -time.Second, wantErr: true},
+		{name: "negative page size", pageSize: -1, wantErr: true},
+		{name: "page size too large", pageSize: CollectorMaxPageSize + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateCollector(tt.pollInterval, tt.pageSize); (err != nil) != tt.wantErr {
+				t.Errorf("validateCollector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_pollBackoff(t *testing.T) {
+	tests := []struct {
+		name         string
+		pollInterval time.Duration
+		wantMin      time.Duration
+		wantMax      time.Duration
+	}{
+		{name: "default", wantMin: time.Second, wantMax: CollectorDefaultPollInterval},
+		{name: "longer interval", pollInterval: time.Minute, wantMin: time.Second, wantMax: time.Minute},
+		{name: "shorter interval", pollInterval: 500 * time.Millisecond, wantMin: 500 * time.Millisecond, wantMax: 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pollBackoff(tt.pollInterval)
+			if got.Min != tt.wantMin || got.Max != tt.wantMax {
+				t.Errorf("pollBackoff() min = %v, max = %v, want %v, %v", got.Min, got.Max, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func Test_pageSize(t *testing.T) {
+	if got := pageSize(0); got != CollectorDefaultPageSize {
+		t.Errorf("pageSize(0) = %d, want %d", got, CollectorDefaultPageSize)
+	}
+	if got := pageSize(500); got != 500 {
+		t.Errorf("pageSize(500) = %d, want 500", got)
+	}
+}
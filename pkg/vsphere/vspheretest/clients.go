@@ -0,0 +1,44 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheretest
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	kubeclientfake "knative.dev/pkg/client/injection/kube/client/fake"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	sourcesfake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	sourcesclientfake "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/client/fake"
+)
+
+// Clients are the fake clients injected by WithClients.
+type Clients struct {
+	Kube    *kubefake.Clientset
+	Sources *sourcesfake.Clientset
+}
+
+// WithClients returns ctx with fake Kubernetes and sources clients injected,
+// as used by the reconcilers, which hold the given objects. The VSphereSource,
+// VSphereBinding and VSphereInventorySource objects are held by the sources
+// client and all others by the Kubernetes client.
+func WithClients(ctx context.Context, objects ...runtime.Object) (context.Context, *Clients) {
+	var kubeObjects, sourcesObjects []runtime.Object
+	for _, o := range objects {
+		switch o.(type) {
+		case *v1alpha1.VSphereSource, *v1alpha1.VSphereBinding, *v1alpha1.VSphereInventorySource:
+			sourcesObjects = append(sourcesObjects, o)
+		default:
+			kubeObjects = append(kubeObjects, o)
+		}
+	}
+
+	ctx, kube := kubeclientfake.With(ctx, kubeObjects...)
+	ctx, sources := sourcesclientfake.With(ctx, sourcesObjects...)
+	return ctx, &Clients{Kube: kube, Sources: sources}
+}
@@ -0,0 +1,16 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package vspheretest holds a test harness for the adapter and the controller
+// of the VSphereSource without a live vCenter. It runs a simulated vCenter
+// (vcsim), generates canned event scenarios and tails the events the adapter
+// of a source would send to its sink:
+//    sim := vspheretest.NewSimulator(t)
+//    tail := sim.Tail(ctx, vsphere.SourceConfig{EventFilter: `{"eventTypes":["*VmPoweredOffEvent*"]}`})
+//    ev := tail.NextEvent(t, vspheretest.PowerOps, 10*time.Second)
+//
+// WithClients injects fake clients for the reconcilers into a context, e.g.
+// with the secret and the source returned by Secret and Source.
+package vspheretest
@@ -0,0 +1,73 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheretest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Scenario is a canned vCenter operation generating events.
+type Scenario string
+
+const (
+	// PowerOps toggles the power state of a VM
+	PowerOps Scenario = "power"
+	// TaskFailures powers on a VM which is already powered on
+	TaskFailures Scenario = "task-failure"
+)
+
+// Scenarios are all the supported scenarios.
+var Scenarios = []Scenario{PowerOps, TaskFailures}
+
+// GenerateEvent generates the events of the given scenario with the given VM
+// and returns a description of the operation.
+func GenerateEvent(ctx context.Context, vm *object.VirtualMachine, scenario Scenario) (string, error) {
+	state, err := vm.PowerState(ctx)
+	if err != nil {
+		return "", err
+	}
+	poweredOn := state == types.VirtualMachinePowerStatePoweredOn
+
+	switch scenario {
+	case PowerOps:
+		if poweredOn {
+			return fmt.Sprintf("powered off VM %s", vm.Name()), runTask(ctx, vm.PowerOff)
+		}
+		return fmt.Sprintf("powered on VM %s", vm.Name()), runTask(ctx, vm.PowerOn)
+
+	case TaskFailures:
+		// power on first so that the task of interest fails
+		if !poweredOn {
+			if err := runTask(ctx, vm.PowerOn); err != nil {
+				return "", err
+			}
+		}
+		task, err := vm.PowerOn(ctx)
+		if err != nil {
+			return "", err
+		}
+		if err := task.Wait(ctx); err != nil {
+			return fmt.Sprintf("failed task %s powering on VM %s: %v", task.Reference().Value, vm.Name(), err), nil
+		}
+		return "", fmt.Errorf("powering on VM %s did not fail", vm.Name())
+
+	default:
+		return "", fmt.Errorf("unsupported scenario %q", scenario)
+	}
+}
+
+// runTask starts a task and waits for it to complete
+func runTask(ctx context.Context, start func(context.Context) (*object.Task, error)) error {
+	task, err := start(ctx)
+	if err != nil {
+		return err
+	}
+	return task.Wait(ctx)
+}
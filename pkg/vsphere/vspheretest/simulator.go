@@ -0,0 +1,191 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheretest
+
+import (
+	"context"
+	"crypto/tls"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+
+	// serve the vCenter REST API for tag and content library events
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+// Credentials of the simulated vCenter
+const (
+	Username = "user"
+	Password = "pass"
+)
+
+// Simulator is a simulated vCenter with the inventory of a default vcsim VPX
+// model, serving the SOAP and the REST API over TLS with a self-signed
+// certificate.
+type Simulator struct {
+	// URL is the address of the simulated vCenter, without credentials
+	URL *url.URL
+	// Client is logged in to the simulated vCenter
+	Client *govmomi.Client
+
+	mu   sync.Mutex
+	vms  []*object.VirtualMachine
+	next int
+}
+
+// NewSimulator starts a simulated vCenter, which is stopped when the test
+// completes.
+func NewSimulator(t testing.TB) *Simulator {
+	t.Helper()
+
+	model := simulator.VPX()
+	if err := model.Create(); err != nil {
+		t.Fatalf("create simulated vCenter: %v", err)
+	}
+	t.Cleanup(model.Remove)
+	model.Service.Listen = &url.URL{User: url.UserPassword(Username, Password)}
+	model.Service.TLS = new(tls.Config)
+	model.Service.RegisterEndpoints = true
+
+	server := model.Service.NewServer()
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	client, err := govmomi.NewClient(ctx, server.URL, true)
+	if err != nil {
+		t.Fatalf("connect to simulated vCenter: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Logout(context.Background()) })
+
+	vms, err := find.NewFinder(client.Client).VirtualMachineList(ctx, "*")
+	if err != nil {
+		t.Fatalf("find simulated VMs: %v", err)
+	}
+
+	return &Simulator{
+		URL:    &url.URL{Scheme: server.URL.Scheme, Host: server.URL.Host, Path: server.URL.Path},
+		Client: client,
+		vms:    vms,
+	}
+}
+
+// Generate generates the events of the given scenario with the VMs of the
+// simulated vCenter in turn and returns a description of the operation.
+func (s *Simulator) Generate(ctx context.Context, scenario Scenario) (string, error) {
+	s.mu.Lock()
+	vm := s.vms[s.next%len(s.vms)]
+	s.next++
+	s.mu.Unlock()
+	return GenerateEvent(ctx, vm, scenario)
+}
+
+// Secret returns the basic-auth secret with the credentials of the simulated
+// vCenter.
+func (s *Simulator) Secret(namespace, name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Type: corev1.SecretTypeBasicAuth,
+		StringData: map[string]string{
+			corev1.BasicAuthUsernameKey: Username,
+			corev1.BasicAuthPasswordKey: Password,
+		},
+	}
+}
+
+// Source returns a source of the simulated vCenter, authenticating with the
+// secret of the given name and sending its events to the given sink.
+func (s *Simulator) Source(namespace, name, secretName string, sink *apis.URL) *v1alpha1.VSphereSource {
+	return &v1alpha1.VSphereSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: v1alpha1.VSphereSourceSpec{
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{URI: sink},
+			},
+			VAuthSpec: v1alpha1.VAuthSpec{
+				Address:       apis.URL(*s.URL),
+				SkipTLSVerify: true,
+				SecretRef:     corev1.LocalObjectReference{Name: secretName},
+			},
+		},
+	}
+}
+
+// Tail is the tail of the events the adapter of a source sends to its sink.
+type Tail struct {
+	// Events receives the events the adapter sends
+	Events <-chan cloudevents.Event
+
+	sim  *Simulator
+	errs <-chan error
+}
+
+// Tail runs the adapter of a source with the given configuration against the
+// simulated vCenter, like `kn vsphere events tail`, until ctx is done. The
+// vCenter of the configuration is ignored. Events are read from the current
+// vCenter time on and nothing is checkpointed.
+func (s *Simulator) Tail(ctx context.Context, config vsphere.SourceConfig) *Tail {
+	config.VCenter = vsphere.EnvConfig{Address: s.URL.String(), Insecure: true}
+
+	events := make(chan cloudevents.Event, 100)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- vsphere.TailEvents(ctx, config, Username, Password, func(ev cloudevents.Event) error {
+			select {
+			case events <- ev:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return &Tail{Events: events, sim: s, errs: errs}
+}
+
+// NextEvent generates the events of the given scenario until the adapter
+// sends an event, and returns it. As the tail starts at the current vCenter
+// time, the events generated before the adapter is ready are not sent. The
+// test fails if the adapter fails or sends no event within the timeout.
+func (tl *Tail) NextEvent(t testing.TB, scenario Scenario, timeout time.Duration) cloudevents.Event {
+	t.Helper()
+
+	ctx := context.Background()
+	deadline := time.After(timeout)
+	for {
+		if _, err := tl.sim.Generate(ctx, scenario); err != nil {
+			t.Fatalf("generate %s events: %v", scenario, err)
+		}
+
+		select {
+		case ev := <-tl.Events:
+			return ev
+		case err := <-tl.errs:
+			t.Fatalf("tail events: %v", err)
+		case <-deadline:
+			t.Fatalf("timed out after %s waiting for an event of scenario %s", timeout, scenario)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
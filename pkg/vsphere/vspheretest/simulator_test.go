@@ -0,0 +1,83 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vspheretest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/logging"
+
+	sourcesclient "github.com/vmware-tanzu/sources-for-knative/pkg/client/injection/client"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere/vspheretest"
+)
+
+func TestSimulator_Tail(t *testing.T) {
+	sim := vspheretest.NewSimulator(t)
+	ctx, cancel := context.WithCancel(logging.WithLogger(context.Background(), zaptest.NewLogger(t).Sugar()))
+	defer cancel()
+
+	tail := sim.Tail(ctx, vsphere.SourceConfig{
+		EventFilter: `{"eventTypes":["*VmPoweredOffEvent*"]}`,
+		CEOverrides: `{"extensions":{"team":"a"}}`,
+	})
+	ev := tail.NextEvent(t, vspheretest.PowerOps, 10*time.Second)
+
+	if !strings.Contains(ev.Type(), "VmPoweredOffEvent") {
+		t.Errorf("Type() = %s, want only filtered power off events", ev.Type())
+	}
+	if ext := ev.Extensions()["team"]; ext != "a" {
+		t.Errorf("extension team = %v, want a", ext)
+	}
+}
+
+func TestSimulator_Generate(t *testing.T) {
+	sim := vspheretest.NewSimulator(t)
+
+	for _, scenario := range vspheretest.Scenarios {
+		msg, err := sim.Generate(context.Background(), scenario)
+		if err != nil {
+			t.Fatalf("Generate(%s) error = %v", scenario, err)
+		}
+		if msg == "" {
+			t.Errorf("Generate(%s) returned no description", scenario)
+		}
+	}
+	if _, err := sim.Generate(context.Background(), "host-failure"); err == nil {
+		t.Error("Generate() with unsupported scenario did not fail")
+	}
+}
+
+func TestWithClients(t *testing.T) {
+	sim := vspheretest.NewSimulator(t)
+	ctx, clients := vspheretest.WithClients(context.Background(),
+		sim.Secret("ns", "vcsim-credentials"),
+		sim.Source("ns", "vcsim", "vcsim-credentials", apis.HTTP("sink.example.com")))
+
+	if kubeclient.Get(ctx) != clients.Kube || sourcesclient.Get(ctx) != clients.Sources {
+		t.Fatal("WithClients() did not inject the returned clients")
+	}
+	if _, err := clients.Kube.CoreV1().Secrets("ns").Get(ctx, "vcsim-credentials", metav1.GetOptions{}); err != nil {
+		t.Errorf("get secret: %v", err)
+	}
+	source, err := clients.Sources.SourcesV1alpha1().VSphereSources("ns").Get(ctx, "vcsim", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get source: %v", err)
+	}
+	if err := source.Validate(ctx); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	if got := source.Spec.Address.String(); got != sim.URL.String() {
+		t.Errorf("source address = %s, want %s", got, sim.URL)
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/vmware/govmomi/vim25/debug"
+	"go.uber.org/zap"
+)
+
+const (
+	// WireTraceAnnotation is the annotation of a VSphereSource which enables
+	// tracing the vCenter API requests and responses of its adapter, either to
+	// its logs or to a volume. Changing it restarts the adapter.
+	WireTraceAnnotation = "vspheresources.sources.tanzu.vmware.com/wire-trace"
+
+	// WireTraceLogs writes the wire trace to the adapter logs
+	WireTraceLogs = "logs"
+	// WireTraceVolume writes the wire trace to files in an ephemeral volume
+	// of the adapter
+	WireTraceVolume = "volume"
+
+	// WireTraceVolumeName is the name of the ephemeral volume holding the wire
+	// trace files
+	WireTraceVolumeName = "wire-trace"
+	// WireTraceMountPath is where the wire trace volume is mounted in the
+	// adapter
+	WireTraceMountPath = "/var/run/vsphere/wire-trace"
+)
+
+// wireTraceScrubs replace the credentials, session cookies and tokens in the
+// wire trace
+var wireTraceScrubs = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(?s)<password>.*?</password>`), `<password>********</password>`},
+	{regexp.MustCompile(`(?s)<([\w-]+:)?Assertion\b.*?</([\w-]+:)?Assertion>`), `<Assertion>********</Assertion>`},
+	{regexp.MustCompile(`(?s)<([\w-]+:)?BinarySecurityToken\b.*?</([\w-]+:)?BinarySecurityToken>`), `<BinarySecurityToken>********</BinarySecurityToken>`},
+	{regexp.MustCompile(`(?im)^(Authorization|Cookie|Set-Cookie|Vmware-Api-Session-Id):[^\r\n]*`), `$1: ********`},
+	// the session id returned by the login of the REST API
+	{regexp.MustCompile(`"value"\s*:\s*"[0-9a-f]{32}"`), `"value":"********"`},
+}
+
+// ValidWireTrace returns an error if the given wire trace mode is not
+// supported. The empty mode disables the wire trace.
+func ValidWireTrace(mode string) error {
+	switch mode {
+	case "", WireTraceLogs, WireTraceVolume:
+		return nil
+	default:
+		return fmt.Errorf("unsupported wire trace %q, must be %s or %s", mode, WireTraceLogs, WireTraceVolume)
+	}
+}
+
+// scrubWireTrace returns the given trace without credentials, session cookies
+// and tokens
+func scrubWireTrace(b []byte) []byte {
+	for _, s := range wireTraceScrubs {
+		b = s.re.ReplaceAll(b, []byte(s.repl))
+	}
+	return b
+}
+
+// configureWireTrace enables the wire trace of the vCenter clients created
+// afterwards in the given mode, writing trace files into dir in volume mode.
+// The trace is global to the process and a no-op for the empty mode.
+func configureWireTrace(logger *zap.SugaredLogger, mode, dir string) error {
+	if err := ValidWireTrace(mode); err != nil {
+		return err
+	}
+
+	switch mode {
+	case WireTraceLogs:
+		debug.SetProvider(&wireTraceProvider{logger: logger})
+	case WireTraceVolume:
+		if dir == "" {
+			return fmt.Errorf("no directory for the %s wire trace", mode)
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("create wire trace directory: %w", err)
+		}
+		debug.SetProvider(&wireTraceProvider{logger: logger, dir: dir})
+	default:
+		return nil
+	}
+	logger.Warnw("tracing vCenter API requests and responses, disable when done", zap.String("wireTrace", mode))
+	return nil
+}
+
+// wireTraceProvider implements the debug.Provider of govmomi, which writes the
+// headers and bodies of every vCenter request and response to a file named
+// after the client and request. The trace is written scrubbed into dir, or to
+// the logger if dir is empty. Failures to write are logged but do not fail the
+// traced requests.
+type wireTraceProvider struct {
+	logger *zap.SugaredLogger
+	dir    string
+
+	mu    sync.Mutex
+	files []io.Closer
+}
+
+// NewFile implements debug.Provider
+func (p *wireTraceProvider) NewFile(name string) io.WriteCloser {
+	// the request logs of the clients are written per line and never closed
+	w := &wireTraceWriter{lines: strings.HasSuffix(name, ".log")}
+	if p.dir == "" {
+		w.write = func(b []byte) {
+			p.logger.Infow("vCenter wire trace", zap.String("file", name), zap.String("trace", strings.TrimSpace(string(b))))
+		}
+		return w
+	}
+
+	f, err := os.Create(filepath.Join(p.dir, filepath.Base(name)))
+	if err != nil {
+		p.logger.Warnw("failed to create wire trace file", zap.String("file", name), zap.Error(err))
+		w.write = func([]byte) {}
+		return w
+	}
+	var failed bool
+	w.write = func(b []byte) {
+		if _, err := f.Write(b); err != nil && !failed {
+			failed = true
+			p.logger.Warnw("failed to write wire trace file", zap.String("file", name), zap.Error(err))
+		}
+	}
+	w.close = f.Close
+	if w.lines {
+		p.mu.Lock()
+		p.files = append(p.files, f)
+		p.mu.Unlock()
+	}
+	return w
+}
+
+// Flush implements debug.Provider
+func (p *wireTraceProvider) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, f := range p.files {
+		_ = f.Close()
+	}
+	p.files = nil
+}
+
+// wireTraceWriter buffers a trace file until it is closed, so that the
+// credentials in it are scrubbed as a whole, and then writes it scrubbed. The
+// lines of request logs are written immediately.
+type wireTraceWriter struct {
+	lines bool
+	write func(b []byte)
+	close func() error
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write implements io.Writer. It never fails, as the trace tees the bodies of
+// the traced requests.
+func (w *wireTraceWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.lines {
+		w.write(scrubWireTrace(b))
+		return len(b), nil
+	}
+	return w.buf.Write(b)
+}
+
+// Close implements io.Closer
+func (w *wireTraceWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() > 0 {
+		w.write(scrubWireTrace(w.buf.Bytes()))
+		w.buf.Reset()
+	}
+	if w.close == nil {
+		return nil
+	}
+	return w.close()
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vsphere
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_scrubWireTrace(t *testing.T) {
+	tests := []struct {
+		name  string
+		trace string
+		want  string
+	}{{
+		name:  "password",
+		trace: "<Login><userName>user</userName><password>s3cr3t</password></Login>",
+		want:  "<Login><userName>user</userName><password>********</password></Login>",
+	}, {
+		name:  "session cookie",
+		trace: "POST /sdk HTTP/1.1\r\nCookie: vmware_soap_session=\"abc\"\r\nSoapaction: urn:vim25/6.7\r\n",
+		want:  "POST /sdk HTTP/1.1\r\nCookie: ********\r\nSoapaction: urn:vim25/6.7\r\n",
+	}, {
+		name:  "REST session",
+		trace: "Vmware-Api-Session-Id: 0123\r\n\r\n{\"value\":\"0123456789abcdef0123456789abcdef\"}",
+		want:  "Vmware-Api-Session-Id: ********\r\n\r\n{\"value\":\"********\"}",
+	}, {
+		name:  "SAML token",
+		trace: "<wsse:Security><saml2:Assertion ID=\"a\">\n<token/>\n</saml2:Assertion></wsse:Security>",
+		want:  "<wsse:Security><Assertion>********</Assertion></wsse:Security>",
+	}, {
+		name:  "events",
+		trace: "<returnval><key>42</key><fullFormattedMessage>VM powered off</fullFormattedMessage></returnval>",
+		want:  "<returnval><key>42</key><fullFormattedMessage>VM powered off</fullFormattedMessage></returnval>",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(scrubWireTrace([]byte(tt.trace))); got != tt.want {
+				t.Errorf("scrubWireTrace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_wireTraceProvider(t *testing.T) {
+	trace := []byte("<password>s3cr3t</password>")
+
+	t.Run("writes scrubbed files", func(t *testing.T) {
+		dir := t.TempDir()
+		p := &wireTraceProvider{logger: zap.NewNop().Sugar(), dir: dir}
+
+		// the password is scrubbed even if split across writes
+		f := p.NewFile("1-0001.req.xml")
+		for _, b := range [][]byte{trace[:15], trace[15:]} {
+			if _, err := f.Write(b); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		p.Flush()
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "1-0001.req.xml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(b); got != "<password>********</password>" {
+			t.Errorf("wire trace file = %q, want the password scrubbed", got)
+		}
+	})
+
+	t.Run("logs scrubbed files when closed", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+		p := &wireTraceProvider{logger: zap.New(core).Sugar()}
+
+		f := p.NewFile("1-0001.req.xml")
+		_, _ = f.Write(trace[:10])
+		_, _ = f.Write(trace[10:])
+		if logs.Len() != 0 {
+			t.Fatalf("logged %d wire trace entries before the file was closed", logs.Len())
+		}
+		_ = f.Close()
+
+		entries := logs.All()
+		if len(entries) != 1 {
+			t.Fatalf("logged %d wire trace entries, want 1", len(entries))
+		}
+		got := entries[0].ContextMap()["trace"].(string)
+		if strings.Contains(got, "s3cr3t") || entries[0].ContextMap()["file"] != "1-0001.req.xml" {
+			t.Errorf("logged wire trace %v", entries[0].ContextMap())
+		}
+	})
+}
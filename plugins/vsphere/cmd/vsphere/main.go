@@ -6,8 +6,11 @@ SPDX-License-Identifier: Apache-2.0
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
 	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
@@ -24,7 +27,17 @@ func main() {
 		fmt.Printf("ERROR: %v\n", err)
 		os.Exit(1)
 	}
-	if err := command.NewRootCommand(clients).Execute(); err != nil {
+
+	// long running commands stop on interrupt
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	if err := command.NewRootCommand(clients).ExecuteContext(ctx); err != nil {
 		fmt.Printf("ERROR: %v\n", err)
 		os.Exit(1)
 	}
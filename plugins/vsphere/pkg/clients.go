@@ -12,6 +12,7 @@ import (
 
 	vsphere "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -34,10 +35,15 @@ func NewClients(kubeConfigPath string) (*Clients, error) {
 		fmt.Println("failed to create Clients:", err)
 		os.Exit(1)
 	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
 	return &Clients{
 		ClientSet:        clientSet,
 		ClientConfig:     clientConfig,
 		VSphereClientSet: vSphereConfig,
+		DynamicClient:    dynamicClient,
 	}, nil
 }
 
@@ -45,6 +51,7 @@ type Clients struct {
 	ClientConfig     clientcmd.ClientConfig
 	ClientSet        kubernetes.Interface
 	VSphereClientSet vsphere.Interface
+	DynamicClient    dynamic.Interface
 }
 
 func (c *Clients) GetExplicitOrDefaultNamespace(ns string) (string, error) {
@@ -0,0 +1,195 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+type RotateOptions struct {
+	Namespace     string
+	SecretName    string
+	Username      string
+	Password      string
+	PasswordStdIn bool
+	PasswordFile  string
+	VerifyURL     string
+	Insecure      bool
+
+	SkipRestart bool
+
+	OutputOptions
+}
+
+func NewAuthCommand(clients *pkg.Clients) *cobra.Command {
+	result := cobra.Command{
+		Use:   "auth",
+		Short: "Manage vSphere credentials",
+		Long:  "Manage vSphere credentials",
+	}
+	result.AddCommand(NewAuthRotateCommand(clients))
+	return &result
+}
+
+func NewAuthRotateCommand(clients *pkg.Clients) *cobra.Command {
+	options := RotateOptions{}
+	result := cobra.Command{
+		Use:   "rotate",
+		Short: "Rotate the password of existing vSphere credentials",
+		Long: "Rotate the password of existing vSphere credentials created with login, and make the sources and bindings using them pick it up.\n" +
+			"The adapters of the sources using the credentials are restarted, and the controller rolls the subjects of the bindings using them.",
+		Example: `# Rotate the password of the credentials in the default namespace, prompted for via standard input
+kn vsphere auth rotate --secret-name vsphere-credentials --password-stdin
+# Rotate the password of the credentials in the default namespace, read from a file
+kn vsphere auth rotate --secret-name vsphere-credentials --password-file ./vc-password
+# Rotate the password of the credentials in the specified namespace and validate it against vCenter before
+kn vsphere auth rotate --namespace ns --secret-name vsphere-credentials --password s3cr3t --verify-url https://myvc.corp.local
+# Rotate the username and password of the credentials, leaving the sources to reload them on their own
+kn vsphere auth rotate --secret-name vsphere-credentials --username jane-doe --password s3cr3t --skip-restart
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validateOutput(); err != nil {
+				return err
+			}
+			if options.SecretName == "" {
+				return fmt.Errorf("'secret-name' requires a nonempty secret name provided with the --secret-name option")
+			}
+			// the password is prompted for when none is set on a terminal
+			if options.Password == "" && !options.PasswordStdIn && options.PasswordFile == "" && !stdinIsTerminal() {
+				return fmt.Errorf("'password' requires a nonempty password provided with the --password option or prompted later via the --password-stdin option, or read from the file of the --password-file option")
+			}
+			return validatePasswordSource(options.Password, options.PasswordStdIn, options.PasswordFile)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %+v", err)
+			}
+
+			secrets := clients.ClientSet.CoreV1().Secrets(namespace)
+			secret, err := secrets.Get(cmd.Context(), options.SecretName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get Secret: %+v", err)
+			}
+			if _, ok := secret.Data[corev1.BasicAuthPasswordKey]; !ok {
+				return fmt.Errorf("secret %q does not hold a password in the %q key", secret.Name, corev1.BasicAuthPasswordKey)
+			}
+			username := string(secret.Data[corev1.BasicAuthUsernameKey])
+			if options.Username != "" {
+				username = options.Username
+			}
+
+			password, err := readPassword(cmd, options.Password, options.PasswordFile)
+			if err != nil {
+				return fmt.Errorf("failed to get password: %+v", err)
+			}
+			if options.VerifyURL != "" {
+				// validate the new credentials before rotating them
+				if err := verifyCredentials(cmd.Context(), options.VerifyURL, username, password, options.Insecure); err != nil {
+					return err
+				}
+			}
+
+			secret.Data[corev1.BasicAuthUsernameKey] = []byte(username)
+			secret.Data[corev1.BasicAuthPasswordKey] = []byte(password)
+			updated, err := secrets.Update(cmd.Context(), secret, metav1.UpdateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to update Secret: %+v", err)
+			}
+
+			restarted, bindings, err := options.triggerDependents(cmd.Context(), clients, namespace)
+			if err != nil {
+				return err
+			}
+			updated.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+			message := fmt.Sprintf("Rotated vSphere credentials, restarted %d source(s), rolling the subjects of %d binding(s)",
+				restarted, bindings)
+			return options.printObject(cmd.OutOrStdout(), updated, message)
+		},
+	}
+
+	flags := result.Flags()
+	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the credentials to rotate (default namespace if omitted)")
+	flags.StringVarP(&options.SecretName, "secret-name", "s", "", "name of the Secret of the credentials to rotate")
+	flags.StringVarP(&options.Username, "username", "u", "", "new username (unchanged if omitted)")
+	flags.StringVarP(&options.Password, "password", "p", "", "new password (same as VC_PASSWORD)")
+	flags.BoolVarP(&options.PasswordStdIn, "password-stdin", "i", false, "read the new password from standard input")
+	flags.StringVar(&options.PasswordFile, "password-file", "", "read the new password from the given file")
+	flags.StringVar(&options.VerifyURL, "verify-url", "", "vCenter URL to verify the new credentials before rotating them (optional)")
+	flags.BoolVar(&options.Insecure, "verify-insecure", false, "Ignore certificate errors during credential verification")
+	flags.BoolVar(&options.SkipRestart, "skip-restart", false,
+		"do not restart the adapters of the sources using the credentials, which reload them within a few minutes")
+	// the secret is not printed as JSON or YAML, which would reveal the password
+	options.addOutputFlag(&result, "", outputName)
+	options.addQuietFlag(&result)
+	return &result
+}
+
+// triggerDependents restarts the adapters of the sources in the namespace
+// using the rotated credentials, unless skipped, and returns their number and
+// the number of bindings using them, whose subjects the controller rolls.
+func (ro *RotateOptions) triggerDependents(ctx context.Context, clients *pkg.Clients, namespace string) (int, int, error) {
+	client := clients.VSphereClientSet.SourcesV1alpha1()
+	sources, err := client.VSphereSources(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list sources: %+v", err)
+	}
+	bindings, err := client.VSphereBindings(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list bindings: %+v", err)
+	}
+
+	var restarted, bound int
+	for i := range bindings.Items {
+		if bindings.Items[i].Spec.SecretRef.Name == ro.SecretName {
+			bound++
+		}
+	}
+	if ro.SkipRestart {
+		return 0, bound, nil
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for i := range sources.Items {
+		source := &sources.Items[i]
+		if !usesSecret(source, ro.SecretName) {
+			continue
+		}
+		if source.Annotations == nil {
+			source.Annotations = map[string]string{}
+		}
+		source.Annotations[vsphere.RestartedAtAnnotation] = now
+		if _, err := client.VSphereSources(namespace).Update(ctx, source, metav1.UpdateOptions{}); err != nil {
+			return restarted, bound, fmt.Errorf("failed to restart source %q: %+v", source.Name, err)
+		}
+		restarted++
+	}
+	return restarted, bound, nil
+}
+
+// usesSecret returns whether the source reads the credentials of any of its
+// vCenters from the named secret
+func usesSecret(source *v1alpha1.VSphereSource, name string) bool {
+	if source.Spec.SecretRef.Name == name {
+		return true
+	}
+	for _, a := range source.Spec.Addresses {
+		if a.SecretRef.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	vspherefake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+)
+
+func TestNewAuthRotateCommand(t *testing.T) {
+	const secretName = "creds"
+	const username = "fbiville"
+	const password = "n3w-s3cr3t"
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		authCommand, _, _ := authCommand(regularClientConfig())
+		rotateCommand, _, err := authCommand.Find([]string{"rotate"})
+		assert.NilError(t, err)
+
+		assert.Equal(t, rotateCommand.Use, "rotate")
+		assert.Check(t, len(rotateCommand.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(rotateCommand.Long) > 0,
+			"command should have a nonempty long description")
+		checkFlag(t, rotateCommand, "namespace")
+		checkFlag(t, rotateCommand, "secret-name")
+		checkFlag(t, rotateCommand, "username")
+		checkFlag(t, rotateCommand, "password")
+		checkFlag(t, rotateCommand, "password-stdin")
+		checkFlag(t, rotateCommand, "verify-url")
+		checkFlag(t, rotateCommand, "verify-insecure")
+		checkFlag(t, rotateCommand, "skip-restart")
+		checkFlag(t, rotateCommand, "output")
+		checkFlag(t, rotateCommand, "quiet")
+		assert.Assert(t, rotateCommand.RunE != nil)
+	})
+
+	t.Run("rotates the password and restarts the sources using the credentials", func(t *testing.T) {
+		authCommand, client, vSphereClientSet := authCommand(regularClientConfig(),
+			existingCredentials("ns", secretName),
+			newSource(t, "ns", "src", "https://sink.example.com", secretName, "https://vcenter.example.com"),
+			newSource(t, "ns", "other-src", "https://sink.example.com", "other-creds", "https://vcenter.example.com"),
+			newBinding(t, "ns", "binding", "https://vcenter.example.com", secretName, "apps/v1", "Deployment", "my-simple-app"))
+		output := bytes.Buffer{}
+		authCommand.SetOut(&output)
+		authCommand.SetArgs([]string{"rotate", "--namespace", "ns", "--secret-name", secretName, "--password", password})
+
+		err := authCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, output.String(), "Rotated vSphere credentials, restarted 1 source(s), rolling the subjects of 1 binding(s)\n")
+		assertRotatedSecret(t, client, "ns", secretName, "old-user", password)
+		assert.Check(t, retrieveSource(t, vSphereClientSet, "ns", "src").Annotations[vsphere.RestartedAtAnnotation] != "")
+		assert.Check(t, retrieveSource(t, vSphereClientSet, "ns", "other-src").Annotations[vsphere.RestartedAtAnnotation] == "")
+	})
+
+	t.Run("restarts the sources using the credentials for an additional vCenter", func(t *testing.T) {
+		source := newSource(t, "ns", "src", "https://sink.example.com", "other-creds", "https://vcenter.example.com").(*v1alpha1.VSphereSource)
+		source.Spec.Addresses = []v1alpha1.VAddressSpec{{
+			Address:   parseURI(t, "https://other-vcenter.example.com"),
+			SecretRef: corev1.LocalObjectReference{Name: secretName},
+		}}
+		authCommand, _, vSphereClientSet := authCommand(regularClientConfig(), existingCredentials("ns", secretName), source)
+		authCommand.SetArgs([]string{"rotate", "--namespace", "ns", "--secret-name", secretName, "--password", password})
+
+		err := authCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Check(t, retrieveSource(t, vSphereClientSet, "ns", "src").Annotations[vsphere.RestartedAtAnnotation] != "")
+	})
+
+	t.Run("rotates the username and password from stdin without restarting the sources", func(t *testing.T) {
+		authCommand, client, vSphereClientSet := authCommand(regularClientConfig(),
+			existingCredentials(defaultNamespace, secretName),
+			newSource(t, defaultNamespace, "src", "https://sink.example.com", secretName, "https://vcenter.example.com"))
+		output := bytes.Buffer{}
+		authCommand.SetOut(&output)
+		authCommand.SetIn(strings.NewReader(password))
+		authCommand.SetArgs([]string{"rotate", "--secret-name", secretName, "--username", username, "--password-stdin", "--skip-restart"})
+
+		err := authCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Check(t, strings.HasSuffix(output.String(), "Rotated vSphere credentials, restarted 0 source(s), rolling the subjects of 0 binding(s)\n"))
+		assertRotatedSecret(t, client, defaultNamespace, secretName, username, password)
+		assert.Check(t, retrieveSource(t, vSphereClientSet, defaultNamespace, "src").Annotations[vsphere.RestartedAtAnnotation] == "")
+	})
+
+	t.Run("passes verification against vCenter before rotating", func(t *testing.T) {
+		simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+			authCommand, client, _ := authCommand(regularClientConfig(), existingCredentials(defaultNamespace, secretName))
+			authCommand.SetArgs([]string{"rotate", "--secret-name", secretName, "--password", password,
+				"--verify-url", vc.URL().String(), "--verify-insecure"})
+
+			err := authCommand.Execute()
+
+			assert.NilError(t, err)
+			assertRotatedSecret(t, client, defaultNamespace, secretName, "old-user", password)
+			return nil
+		})
+	})
+
+	t.Run("fails verification against vCenter and leaves the credentials untouched", func(t *testing.T) {
+		model := simulator.VPX()
+		defer model.Remove()
+		assert.NilError(t, model.Create())
+		model.Service.Listen = &url.URL{
+			User: url.UserPassword("old-user", "old-s3cr3t"),
+		}
+
+		simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+			authCommand, client, _ := authCommand(regularClientConfig(), existingCredentials(defaultNamespace, secretName))
+			authCommand.SetArgs([]string{"rotate", "--secret-name", secretName, "--password", password,
+				"--verify-url", vc.URL().String(), "--verify-insecure"})
+
+			err := authCommand.Execute()
+
+			assert.ErrorContains(t, err, "failed to authenticate with vCenter: ServerFaultCode: Login failure")
+			assertRotatedSecret(t, client, defaultNamespace, secretName, "old-user", "old-s3cr3t")
+			return nil
+		}, model)
+	})
+
+	t.Run("fails to execute without secret name", func(t *testing.T) {
+		authCommand, _, _ := authCommand(regularClientConfig())
+		authCommand.SetArgs([]string{"rotate", "--password", password})
+
+		err := authCommand.Execute()
+
+		assert.ErrorContains(t, err, "'secret-name' requires a nonempty secret name provided with the --secret-name option")
+	})
+
+	t.Run("rotates the password read from a file", func(t *testing.T) {
+		passwordFile := filepath.Join(t.TempDir(), "password")
+		assert.NilError(t, ioutil.WriteFile(passwordFile, []byte(password+"\n"), 0600))
+		authCommand, client, _ := authCommand(regularClientConfig(), existingCredentials(defaultNamespace, secretName))
+		authCommand.SetArgs([]string{"rotate", "--secret-name", secretName, "--password-file", passwordFile, "--skip-restart"})
+
+		err := authCommand.Execute()
+
+		assert.NilError(t, err)
+		assertRotatedSecret(t, client, defaultNamespace, secretName, "old-user", password)
+	})
+
+	t.Run("fails to execute with both password and password-file", func(t *testing.T) {
+		authCommand, _, _ := authCommand(regularClientConfig())
+		authCommand.SetArgs([]string{"rotate", "--secret-name", secretName, "--password", password, "--password-file", "password"})
+
+		err := authCommand.Execute()
+
+		assert.ErrorContains(t, err, "the --password-file option cannot be combined with the --password or --password-stdin options")
+	})
+
+	t.Run("fails to execute with both password and password-stdin", func(t *testing.T) {
+		authCommand, _, _ := authCommand(regularClientConfig())
+		authCommand.SetArgs([]string{"rotate", "--secret-name", secretName, "--password", password, "--password-stdin"})
+
+		err := authCommand.Execute()
+
+		assert.ErrorContains(t, err, "either set an explicit password with the --password option or set the --password-stdin option to get prompted for one, do not set both")
+	})
+
+	t.Run("fails to execute with an output revealing the password", func(t *testing.T) {
+		authCommand, _, _ := authCommand(regularClientConfig())
+		authCommand.SetArgs([]string{"rotate", "--secret-name", secretName, "--password", password, "--output", "yaml"})
+
+		err := authCommand.Execute()
+
+		assert.ErrorContains(t, err, "yaml")
+	})
+
+	t.Run("fails to execute when the secret does not exist", func(t *testing.T) {
+		authCommand, _, _ := authCommand(regularClientConfig())
+		authCommand.SetArgs([]string{"rotate", "--secret-name", secretName, "--password", password})
+
+		err := authCommand.Execute()
+
+		assert.ErrorContains(t, err, fmt.Sprintf(`failed to get Secret: secrets "%s" not found`, secretName))
+	})
+
+	t.Run("fails to execute when the secret holds no password", func(t *testing.T) {
+		secret := existingCredentials(defaultNamespace, secretName)
+		delete(secret.Data, corev1.BasicAuthPasswordKey)
+		authCommand, _, _ := authCommand(regularClientConfig(), secret)
+		authCommand.SetArgs([]string{"rotate", "--secret-name", secretName, "--password", password})
+
+		err := authCommand.Execute()
+
+		assert.ErrorContains(t, err, fmt.Sprintf(`secret "%s" does not hold a password`, secretName))
+	})
+}
+
+func authCommand(clientConfig clientcmd.ClientConfig, objects ...runtime.Object) (*cobra.Command, *k8sfake.Clientset, *vspherefake.Clientset) {
+	var secrets, resources []runtime.Object
+	for _, o := range objects {
+		if _, ok := o.(*corev1.Secret); ok {
+			secrets = append(secrets, o)
+		} else {
+			resources = append(resources, o)
+		}
+	}
+	client := k8sfake.NewSimpleClientset(secrets...)
+	vSphereClientSet := vspherefake.NewSimpleClientset(resources...)
+	authCommand := command.NewAuthCommand(&pkg.Clients{
+		ClientSet:        client,
+		ClientConfig:     clientConfig,
+		VSphereClientSet: vSphereClientSet,
+	})
+	authCommand.SetErr(ioutil.Discard)
+	authCommand.SetOut(ioutil.Discard)
+	return authCommand, client, vSphereClientSet
+}
+
+// existingCredentials returns credentials as stored by the API server, with
+// the keys in the data of the secret
+func existingCredentials(namespace, name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Type: corev1.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			corev1.BasicAuthUsernameKey: []byte("old-user"),
+			corev1.BasicAuthPasswordKey: []byte("old-s3cr3t"),
+		},
+	}
+}
+
+func assertRotatedSecret(t *testing.T, client *k8sfake.Clientset, namespace, name, username, password string) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, string(secret.Data[corev1.BasicAuthUsernameKey]), username)
+	assert.Equal(t, string(secret.Data[corev1.BasicAuthPasswordKey]), password)
+}
+
+func retrieveSource(t *testing.T, vSphereClientSet *vspherefake.Clientset, namespace, name string) *v1alpha1.VSphereSource {
+	return retrieveCreatedSource(t, nil, vSphereClientSet, namespace, name)
+}
@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package command
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 
@@ -14,6 +15,9 @@ import (
 	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"knative.dev/pkg/apis"
 	duckv1alpha1 "knative.dev/pkg/apis/duck/v1alpha1"
 	"knative.dev/pkg/tracker"
@@ -25,11 +29,21 @@ type BindingOptions struct {
 	Address       string
 	SkipTLSVerify bool
 	SecretRef     string
+	GovcEnv       bool
 
 	SubjectAPIVersion string
 	SubjectKind       string
 	SubjectName       string
 	SubjectSelector   string
+
+	SkipSubjectVerification bool
+
+	Labels      []string
+	Annotations []string
+
+	Filename string
+
+	OutputOptions
 }
 
 func NewBindingCommand(clients *pkg.Clients) *cobra.Command {
@@ -40,11 +54,30 @@ func NewBindingCommand(clients *pkg.Clients) *cobra.Command {
 		Short: "Create a vSphere binding to call into the vSphere API",
 		Long:  "Create a vSphere binding to call into the vSphere API",
 		Example: `# Create the binding in the default namespace, targeting a Deployment subject
-kn vsphere binding --name binding --address https://my-vsphere-endpoint.local --skip-tls-verify --secret-ref vsphere-credentials --subject-api-version app/v1 --subject-kind Deployment --subject-name my-simple-app
+kn vsphere binding --name binding --address https://my-vsphere-endpoint.local --skip-tls-verify --secret-ref vsphere-credentials --subject-api-version apps/v1 --subject-kind Deployment --subject-name my-simple-app
 # Create the binding in the specified namespace, targeting a selection of Job subjects
 kn vsphere binding --namespace ns --name source --address https://my-vsphere-endpoint.local --skip-tls-verify --secret-ref vsphere-credentials --subject-api-version batch/v1 --subject-kind Job --subject-selector foo=bar
+# Create the binding targeting a Knative Service subject
+kn vsphere binding --name binding --address https://my-vsphere-endpoint.local --secret-ref vsphere-credentials --subject-api-version serving.knative.dev/v1 --subject-kind Service --subject-name my-service
+# Create the binding targeting a Deployment subject, labeled with its cost center
+kn vsphere binding --name binding --address https://my-vsphere-endpoint.local --secret-ref vsphere-credentials --subject-api-version apps/v1 --subject-kind Deployment --subject-name my-simple-app --label cost-center=1234
+# Create the binding of a manifest, targeting another Deployment subject
+kn vsphere binding --filename binding.yaml --subject-name my-other-app
 `,
+		// accept stray arguments as before the subcommands were added
+		Args: cobra.ArbitraryArgs,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validateOutput(); err != nil {
+				return err
+			}
+			if options.Filename != "" {
+				// the manifest with the flags applied is validated once read
+				if !MutuallyExclusiveStringFlags(options.SubjectName, options.SubjectSelector) {
+					return fmt.Errorf("subject can optionally be configured with one of the following flags (but several were set):\n\t" +
+						"--subject-name, --subject-selector")
+				}
+				return nil
+			}
 			if options.Name == "" {
 				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
 			}
@@ -57,6 +90,9 @@ kn vsphere binding --namespace ns --name source --address https://my-vsphere-end
 			if options.SubjectAPIVersion == "" {
 				return fmt.Errorf("'subject-api-version' requires a nonempty subject API version provided with the --subject-api-version option")
 			}
+			if _, err := schema.ParseGroupVersion(options.SubjectAPIVersion); err != nil {
+				return fmt.Errorf("'subject-api-version' requires a valid API version such as apps/v1: %+v", err)
+			}
 			if options.SubjectKind == "" {
 				return fmt.Errorf("'subject-kind' requires a nonempty subject kind provided with the --subject-kind option")
 			}
@@ -73,44 +109,70 @@ kn vsphere binding --namespace ns --name source --address https://my-vsphere-end
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
-			if err != nil {
-				return fmt.Errorf("failed to get namespace: %+v", err)
+			var binding *v1alpha1.VSphereBinding
+			if options.Filename != "" {
+				var err error
+				if binding, err = options.manifestBinding(cmd, clients); err != nil {
+					return err
+				}
+			} else {
+				namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+				if err != nil {
+					return fmt.Errorf("failed to get namespace: %+v", err)
+				}
+				address, err := url.Parse(options.Address)
+				if err != nil {
+					return fmt.Errorf("failed to parse binding address: %+v", err)
+				}
+				var selector *metav1.LabelSelector
+				if options.SubjectSelector != "" {
+					if selector, err = metav1.ParseToLabelSelector(options.SubjectSelector); err != nil {
+						return fmt.Errorf("failed to parse subject selector: %+v", err)
+					}
+				}
+				binding = newBinding(namespace, address, selector, options)
 			}
-			address, err := url.Parse(options.Address)
-			if err != nil {
-				return fmt.Errorf("failed to parse binding address: %+v", err)
+			if err := applyMetadata(&binding.ObjectMeta, options.Labels, options.Annotations); err != nil {
+				return err
 			}
-			selector, err := metav1.ParseToLabelSelector(options.SubjectSelector)
-			if err != nil {
-				return fmt.Errorf("failed to parse subject selector: %+v", err)
+			if !options.SkipSubjectVerification {
+				if err := verifySubject(cmd.Context(), clients.DynamicClient, binding.Spec.Subject); err != nil {
+					return fmt.Errorf("failed to verify subject: %+v", err)
+				}
 			}
-			if _, err := clients.VSphereClientSet.
+			created, err := clients.VSphereClientSet.
 				SourcesV1alpha1().
-				VSphereBindings(namespace).
-				Create(cmd.Context(), newBinding(namespace, address, selector, options), metav1.CreateOptions{}); err != nil {
+				VSphereBindings(binding.Namespace).
+				Create(cmd.Context(), binding, metav1.CreateOptions{})
+			if err != nil {
 				return fmt.Errorf("failed to create Binding: %+v", err)
 			}
-			fmt.Fprintln(cmd.OutOrStdout(), "Created binding")
-			return nil
+			created.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("VSphereBinding"))
+			return options.printObject(cmd.OutOrStdout(), created, "Created binding")
 		},
 	}
 
 	flags := result.Flags()
 	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the binding to create (default namespace if omitted)")
+	flags.StringVarP(&options.Filename, "filename", "f", "",
+		"manifest of the binding to create, or - for stdin, with the other flags overriding its fields")
 	flags.StringVar(&options.Name, "name", "", "name of the binding to create")
-	_ = result.MarkFlagRequired("name")
+	flags.StringArrayVarP(&options.Labels, "label", "l", nil, "label to set on the binding as key=value (can be repeated)")
+	flags.StringArrayVar(&options.Annotations, "annotation", nil, "annotation to set on the binding as key=value (can be repeated)")
 	flags.StringVarP(&options.Address, "address", "a", "", "URL of the events to fetch")
-	_ = result.MarkFlagRequired("address")
 	flags.BoolVarP(&options.SkipTLSVerify, "skip-tls-verify", "k", false, "disables certificate verification for the source address (same as VC_INSECURE)")
 	flags.StringVarP(&options.SecretRef, "secret-ref", "s", "", "reference to the Kubernetes secret for the vSphere credentials needed for the source address")
-	_ = result.MarkFlagRequired("secret-ref")
-	flags.StringVar(&options.SubjectAPIVersion, "subject-api-version", "", "subject API version")
-	_ = result.MarkFlagRequired("subject-api-version")
-	flags.StringVar(&options.SubjectKind, "subject-kind", "", "subject kind")
-	_ = result.MarkFlagRequired("subject-kind")
+	flags.BoolVar(&options.GovcEnv, "govc-env", false, "additionally injects the environment variables of the govc CLI (GOVC_URL, GOVC_USERNAME, ...) into the subject")
+	flags.StringVar(&options.SubjectAPIVersion, "subject-api-version", "", "subject API version, e.g. apps/v1 or serving.knative.dev/v1")
+	flags.StringVar(&options.SubjectKind, "subject-kind", "", "subject kind of any resource embedding a PodSpec in spec.template, e.g. Deployment or Service, or a CronJob")
 	flags.StringVar(&options.SubjectName, "subject-name", "", "subject name (cannot be used with --subject-selector)")
 	flags.StringVar(&options.SubjectSelector, "subject-selector", "", "subject selector (cannot be used with --subject-name)")
+	flags.BoolVar(&options.SkipSubjectVerification, "skip-subject-verification", false, "skips verifying that the subject exists in the cluster and can be bound")
+	options.addOutputFlag(&result, "", outputJSON, outputYAML, outputName)
+	options.addQuietFlag(&result)
+	result.AddCommand(NewBindingUpdateCommand(clients))
+	result.AddCommand(NewBindingListCommand(clients))
+	result.AddCommand(NewBindingDescribeCommand(clients))
 	return &result
 }
 
@@ -137,6 +199,138 @@ func newBinding(namespace string, address *url.URL, selector *metav1.LabelSelect
 					Name: options.SecretRef,
 				},
 			},
+			GovcEnv: options.GovcEnv,
 		},
 	}
 }
+
+// manifestBinding reads the binding of the --filename manifest, applies the
+// explicitly set flags on top of it and validates the result.
+func (bo *BindingOptions) manifestBinding(cmd *cobra.Command, clients *pkg.Clients) (*v1alpha1.VSphereBinding, error) {
+	binding := &v1alpha1.VSphereBinding{}
+	if err := readManifest(cmd.InOrStdin(), bo.Filename, "VSphereBinding", binding); err != nil {
+		return nil, err
+	}
+	meta, err := manifestMeta(clients, bo.Namespace, binding.ObjectMeta)
+	if err != nil {
+		return nil, err
+	}
+	binding.ObjectMeta = meta
+	binding.Status = v1alpha1.VSphereBindingStatus{}
+
+	changed := cmd.Flags().Changed
+	if changed("name") {
+		binding.Name = bo.Name
+	}
+	if err := bo.applyFlags(changed, binding); err != nil {
+		return nil, err
+	}
+
+	if binding.Name == "" {
+		return nil, fmt.Errorf("'name' requires a nonempty name provided in the manifest or with the --name option")
+	}
+	if err := validateBinding(cmd.Context(), binding); err != nil {
+		return nil, err
+	}
+	return binding, nil
+}
+
+// applyFlags applies the explicitly set flags of the spec to the given binding.
+func (bo *BindingOptions) applyFlags(changed func(name string) bool, binding *v1alpha1.VSphereBinding) error {
+	if changed("address") {
+		address, err := url.Parse(bo.Address)
+		if err != nil {
+			return fmt.Errorf("failed to parse binding address: %+v", err)
+		}
+		binding.Spec.Address = apis.URL(*address)
+	}
+	if changed("skip-tls-verify") {
+		binding.Spec.SkipTLSVerify = bo.SkipTLSVerify
+	}
+	if changed("secret-ref") {
+		binding.Spec.SecretRef.Name = bo.SecretRef
+	}
+	if changed("govc-env") {
+		binding.Spec.GovcEnv = bo.GovcEnv
+	}
+	subject := &binding.Spec.Subject
+	subject.Namespace = binding.Namespace
+	if changed("subject-api-version") {
+		subject.APIVersion = bo.SubjectAPIVersion
+	}
+	if changed("subject-kind") {
+		subject.Kind = bo.SubjectKind
+	}
+	if changed("subject-name") {
+		subject.Name, subject.Selector = bo.SubjectName, nil
+	}
+	if changed("subject-selector") {
+		selector, err := metav1.ParseToLabelSelector(bo.SubjectSelector)
+		if err != nil {
+			return fmt.Errorf("failed to parse subject selector: %+v", err)
+		}
+		subject.Name, subject.Selector = "", selector
+	}
+	return nil
+}
+
+// validateBinding validates what the webhook would, after defaulting.
+func validateBinding(ctx context.Context, binding *v1alpha1.VSphereBinding) error {
+	defaulted := binding.DeepCopy()
+	defaulted.SetDefaults(ctx)
+	if err := defaulted.Validate(ctx); err != nil {
+		return fmt.Errorf("invalid binding: %+v", err)
+	}
+	return nil
+}
+
+// verifySubject checks that the subject exists in the cluster and embeds a
+// PodSpec in spec.template, or in spec.jobTemplate.spec.template like a
+// CronJob, which is required to bind it. Subjects matched by a
+// selector may not exist yet, but those which do must be bindable.
+func verifySubject(ctx context.Context, client dynamic.Interface, subject tracker.Reference) error {
+	gv, err := schema.ParseGroupVersion(subject.APIVersion)
+	if err != nil {
+		return err
+	}
+	resource := client.Resource(apis.KindToResource(gv.WithKind(subject.Kind))).Namespace(subject.Namespace)
+
+	if subject.Name != "" {
+		obj, err := resource.Get(ctx, subject.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		return verifyPodSpecable(obj)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(subject.Selector)
+	if err != nil {
+		return err
+	}
+	list, err := resource.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return err
+	}
+	for i := range list.Items {
+		if err := verifyPodSpecable(&list.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// podSpecPaths are the fields embedding the PodSpec of a bindable subject
+var podSpecPaths = [][]string{
+	{"spec", "template", "spec", "containers"},
+	{"spec", "jobTemplate", "spec", "template", "spec", "containers"},
+}
+
+func verifyPodSpecable(obj *unstructured.Unstructured) error {
+	for _, path := range podSpecPaths {
+		if _, found, err := unstructured.NestedSlice(obj.Object, path...); err == nil && found {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %q cannot be bound, because it does not embed a PodSpec in spec.template or spec.jobTemplate.spec.template",
+		obj.GetKind(), obj.GetName())
+}
@@ -6,11 +6,13 @@ SPDX-License-Identifier: Apache-2.0
 package command_test
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io/ioutil"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -22,12 +24,15 @@ import (
 	"gotest.tools/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clientcmd"
 	duckv1alpha1 "knative.dev/pkg/apis/duck/v1alpha1"
 	"knative.dev/pkg/tracker"
+	"sigs.k8s.io/yaml"
 )
 
 func TestNewBindingCommand(t *testing.T) {
@@ -49,10 +54,17 @@ func TestNewBindingCommand(t *testing.T) {
 		checkFlag(t, bindingCommand, "address")
 		checkFlag(t, bindingCommand, "skip-tls-verify")
 		checkFlag(t, bindingCommand, "secret-ref")
+		checkFlag(t, bindingCommand, "govc-env")
 		checkFlag(t, bindingCommand, "subject-api-version")
 		checkFlag(t, bindingCommand, "subject-kind")
 		checkFlag(t, bindingCommand, "subject-name")
 		checkFlag(t, bindingCommand, "subject-selector")
+		checkFlag(t, bindingCommand, "skip-subject-verification")
+		checkFlag(t, bindingCommand, "filename")
+		checkFlag(t, bindingCommand, "label")
+		checkFlag(t, bindingCommand, "annotation")
+		checkFlag(t, bindingCommand, "output")
+		checkFlag(t, bindingCommand, "quiet")
 		assert.Assert(t, bindingCommand.RunE != nil)
 	})
 
@@ -116,6 +128,22 @@ func TestNewBindingCommand(t *testing.T) {
 		assert.ErrorContains(t, err, "requires a nonempty subject API version provided with the --subject-api-version option")
 	})
 
+	t.Run("fails to execute with an invalid subject API version", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{
+			"--name", bindingName,
+			"--address", bindingAddress,
+			"--secret-ref", secretRef,
+			"--subject-api-version", "apps/v1/deployments",
+			"--subject-kind", "Deployment",
+			"--subject-name", "my-simple-app",
+		})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, "requires a valid API version such as apps/v1")
+	})
+
 	t.Run("fails to execute with an empty subject kind", func(t *testing.T) {
 		bindingCommand, _ := bindingCommand(regularClientConfig())
 		bindingCommand.SetArgs([]string{
@@ -184,7 +212,7 @@ or a nonempty subject selector with the --subject-selector option`)
 		binding := retrieveCreatedBinding(t, err, vSphereClientSet, defaultNamespace, bindingName)
 		assertBasicBinding(t, &binding.Spec, bindingAddress, secretRef, false)
 		assertSubject(t, &binding.Spec.Subject,
-			subjectAPIVersion, subjectKind, defaultNamespace, subjectName, defaultSelector())
+			subjectAPIVersion, subjectKind, defaultNamespace, subjectName, nil)
 	})
 
 	t.Run("creates insecure binding in explicit namespace", func(t *testing.T) {
@@ -210,7 +238,7 @@ or a nonempty subject selector with the --subject-selector option`)
 		binding := retrieveCreatedBinding(t, err, vSphereClientSet, namespace, bindingName)
 		assertBasicBinding(t, &binding.Spec, bindingAddress, secretRef, skipTLSVerify)
 		assertSubject(t, &binding.Spec.Subject,
-			subjectAPIVersion, subjectKind, namespace, subjectName, defaultSelector())
+			subjectAPIVersion, subjectKind, namespace, subjectName, nil)
 	})
 
 	t.Run("creates binding with subject label selector in default namespace", func(t *testing.T) {
@@ -243,6 +271,306 @@ or a nonempty subject selector with the --subject-selector option`)
 			})
 	})
 
+	t.Run("creates binding with govc environment variables", func(t *testing.T) {
+		bindingCommand, vSphereClientSet := bindingCommand(regularClientConfig())
+		subjectAPIVersion := "batch/v1"
+		subjectKind := "Job"
+		subjectName := "govc-job"
+		bindingCommand.SetArgs([]string{
+			"--name", bindingName,
+			"--address", bindingAddress,
+			"--secret-ref", secretRef,
+			"--govc-env",
+			"--subject-api-version", subjectAPIVersion,
+			"--subject-kind", subjectKind,
+			"--subject-name", subjectName,
+		})
+
+		err := bindingCommand.Execute()
+
+		binding := retrieveCreatedBinding(t, err, vSphereClientSet, defaultNamespace, bindingName)
+		assertBasicBinding(t, &binding.Spec, bindingAddress, secretRef, false)
+		assert.Check(t, binding.Spec.GovcEnv)
+	})
+
+	t.Run("creates binding targeting a Knative Service", func(t *testing.T) {
+		bindingCommand, vSphereClientSet := bindingCommand(regularClientConfig())
+		subjectAPIVersion := "serving.knative.dev/v1"
+		subjectKind := "Service"
+		subjectName := "my-service"
+		bindingCommand.SetArgs([]string{
+			"--name", bindingName,
+			"--address", bindingAddress,
+			"--secret-ref", secretRef,
+			"--subject-api-version", subjectAPIVersion,
+			"--subject-kind", subjectKind,
+			"--subject-name", subjectName,
+		})
+
+		err := bindingCommand.Execute()
+
+		binding := retrieveCreatedBinding(t, err, vSphereClientSet, defaultNamespace, bindingName)
+		assertSubject(t, &binding.Spec.Subject,
+			subjectAPIVersion, subjectKind, defaultNamespace, subjectName, nil)
+	})
+
+	t.Run("creates binding with labels and annotations", func(t *testing.T) {
+		bindingCommand, vSphereClientSet := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{
+			"--name", bindingName,
+			"--address", bindingAddress,
+			"--secret-ref", secretRef,
+			"--subject-api-version", "apps/v1",
+			"--subject-kind", "Deployment",
+			"--subject-name", "my-simple-app",
+			"--label", "cost-center=1234",
+			"--annotation", "policy.example.com/audit=true",
+		})
+
+		err := bindingCommand.Execute()
+
+		binding := retrieveCreatedBinding(t, err, vSphereClientSet, defaultNamespace, bindingName)
+		assert.DeepEqual(t, binding.Labels, map[string]string{"cost-center": "1234"})
+		assert.DeepEqual(t, binding.Annotations, map[string]string{"policy.example.com/audit": "true"})
+	})
+
+	t.Run("prints the created binding as YAML", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		out := &bytes.Buffer{}
+		bindingCommand.SetOut(out)
+		bindingCommand.SetArgs([]string{
+			"--name", bindingName,
+			"--address", bindingAddress,
+			"--secret-ref", secretRef,
+			"--subject-api-version", "apps/v1",
+			"--subject-kind", "Deployment",
+			"--subject-name", "my-simple-app",
+			"-o", "yaml",
+		})
+
+		err := bindingCommand.Execute()
+
+		assert.NilError(t, err)
+		var binding v1alpha1.VSphereBinding
+		assert.NilError(t, yaml.Unmarshal(out.Bytes(), &binding), out.String())
+		assert.Equal(t, binding.Kind, "VSphereBinding")
+		assert.Equal(t, binding.Name, bindingName)
+		assertSubject(t, &binding.Spec.Subject, "apps/v1", "Deployment", defaultNamespace, "my-simple-app", nil)
+	})
+
+	t.Run("prints the name of the created binding", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		out := &bytes.Buffer{}
+		bindingCommand.SetOut(out)
+		bindingCommand.SetArgs([]string{
+			"--name", bindingName,
+			"--address", bindingAddress,
+			"--secret-ref", secretRef,
+			"--subject-api-version", "apps/v1",
+			"--subject-kind", "Deployment",
+			"--subject-name", "my-simple-app",
+			"-o", "name",
+		})
+
+		err := bindingCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, out.String(), "vspherebinding.sources.tanzu.vmware.com/"+bindingName+"\n")
+	})
+
+	t.Run("fails to execute with an invalid annotation", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{
+			"--name", bindingName,
+			"--address", bindingAddress,
+			"--secret-ref", secretRef,
+			"--subject-api-version", "apps/v1",
+			"--subject-kind", "Deployment",
+			"--subject-name", "my-simple-app",
+			"--annotation", "audit",
+		})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, "--annotation requires a key=value pair")
+	})
+
+	t.Run("fails to execute when the subject does not exist", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{
+			"--name", bindingName,
+			"--address", bindingAddress,
+			"--secret-ref", secretRef,
+			"--subject-api-version", "apps/v1",
+			"--subject-kind", "StatefulSet",
+			"--subject-name", "missing",
+		})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, `failed to verify subject: statefulsets.apps "missing" not found`)
+	})
+
+	t.Run("creates binding with a cronjob subject", func(t *testing.T) {
+		bindingCommand, vSphereClientSet := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{
+			"--name", bindingName,
+			"--address", bindingAddress,
+			"--secret-ref", secretRef,
+			"--subject-api-version", "batch/v1beta1",
+			"--subject-kind", "CronJob",
+			"--subject-name", "nightly",
+		})
+
+		err := bindingCommand.Execute()
+
+		binding := retrieveCreatedBinding(t, err, vSphereClientSet, defaultNamespace, bindingName)
+		assertSubject(t, &binding.Spec.Subject, "batch/v1beta1", "CronJob", defaultNamespace, "nightly", nil)
+	})
+
+	t.Run("fails to execute when the subject cannot be bound", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{
+			"--name", bindingName,
+			"--address", bindingAddress,
+			"--secret-ref", secretRef,
+			"--subject-api-version", "v1",
+			"--subject-kind", "ConfigMap",
+			"--subject-name", "settings",
+		})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err,
+			`ConfigMap "settings" cannot be bound, because it does not embed a PodSpec in spec.template or spec.jobTemplate.spec.template`)
+	})
+
+	t.Run("fails to execute when a selected subject cannot be bound", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{
+			"--name", bindingName,
+			"--address", bindingAddress,
+			"--secret-ref", secretRef,
+			"--subject-api-version", "v1",
+			"--subject-kind", "ConfigMap",
+			"--subject-selector", "app=settings",
+		})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, `ConfigMap "settings" cannot be bound`)
+	})
+
+	t.Run("creates binding without verifying the subject", func(t *testing.T) {
+		bindingCommand, vSphereClientSet := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{
+			"--name", bindingName,
+			"--address", bindingAddress,
+			"--secret-ref", secretRef,
+			"--subject-api-version", "argoproj.io/v1alpha1",
+			"--subject-kind", "Rollout",
+			"--subject-name", "not-yet-created",
+			"--skip-subject-verification",
+		})
+
+		err := bindingCommand.Execute()
+
+		binding := retrieveCreatedBinding(t, err, vSphereClientSet, defaultNamespace, bindingName)
+		assertSubject(t, &binding.Spec.Subject,
+			"argoproj.io/v1alpha1", "Rollout", defaultNamespace, "not-yet-created", nil)
+	})
+
+	const bindingManifest = `apiVersion: sources.tanzu.vmware.com/v1alpha1
+kind: VSphereBinding
+metadata:
+  name: manifest-binding
+  namespace: ns
+spec:
+  address: https://manifest-vsphere-endpoint.example.com
+  secretRef:
+    name: manifest-creds
+  govcEnv: true
+  subject:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: my-simple-app
+`
+
+	t.Run("creates the binding of a manifest file", func(t *testing.T) {
+		bindingCommand, vSphereClientSet := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{
+			"--filename", writeManifest(t, bindingManifest),
+		})
+
+		err := bindingCommand.Execute()
+
+		binding := retrieveCreatedBinding(t, err, vSphereClientSet, "ns", "manifest-binding")
+		assertBasicBinding(t, &binding.Spec, "https://manifest-vsphere-endpoint.example.com", "manifest-creds", false)
+		assertSubject(t, &binding.Spec.Subject, "apps/v1", "Deployment", "ns", "my-simple-app", nil)
+		assert.Check(t, binding.Spec.GovcEnv)
+	})
+
+	t.Run("creates the binding of a manifest from stdin with the flags applied on top", func(t *testing.T) {
+		bindingCommand, vSphereClientSet := bindingCommand(regularClientConfig())
+		bindingCommand.SetIn(strings.NewReader(bindingManifest))
+		bindingCommand.SetArgs([]string{
+			"-f", "-",
+			"--name", bindingName,
+			"--skip-tls-verify",
+			"--subject-selector", "foo=bar",
+		})
+
+		err := bindingCommand.Execute()
+
+		binding := retrieveCreatedBinding(t, err, vSphereClientSet, "ns", bindingName)
+		assertBasicBinding(t, &binding.Spec, "https://manifest-vsphere-endpoint.example.com", "manifest-creds", true)
+		assertSubject(t, &binding.Spec.Subject, "apps/v1", "Deployment", "ns", "",
+			&metav1.LabelSelector{
+				MatchLabels:      map[string]string{"foo": "bar"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{},
+			})
+	})
+
+	t.Run("fails to execute when the subject of a manifest does not exist", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		bindingCommand.SetIn(strings.NewReader(bindingManifest))
+		bindingCommand.SetArgs([]string{
+			"-f", "-",
+			"--subject-name", "does-not-exist",
+		})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, "failed to verify subject")
+	})
+
+	t.Run("fails to execute when the manifest with the flags applied is invalid", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		bindingCommand.SetIn(strings.NewReader(bindingManifest))
+		bindingCommand.SetArgs([]string{
+			"-f", "-",
+			"--secret-ref", "",
+		})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, "invalid binding")
+	})
+
+	t.Run("fails to execute with a manifest and both subject name and selector set", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		bindingCommand.SetIn(strings.NewReader(bindingManifest))
+		bindingCommand.SetArgs([]string{
+			"-f", "-",
+			"--subject-name", "my-simple-app",
+			"--subject-selector", "foo=bar",
+		})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, "subject can optionally be configured with one of the following flags")
+	})
+
 	t.Run("fails to execute when default namespace retrieval fails", func(t *testing.T) {
 		namespaceError := fmt.Errorf("no default namespace, oops")
 		bindingCommand, _ := bindingCommand(failingClientConfig(namespaceError))
@@ -341,12 +669,76 @@ func bindingCommand(clientConfig clientcmd.ClientConfig, objects ...runtime.Obje
 		ClientSet:        k8sfake.NewSimpleClientset(),
 		ClientConfig:     clientConfig,
 		VSphereClientSet: vSphereSourcesClient,
+		DynamicClient:    dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), bindingSubjects()...),
 	})
 	bindingCommand.SetErr(ioutil.Discard)
 	bindingCommand.SetOut(ioutil.Discard)
 	return bindingCommand, vSphereSourcesClient
 }
 
+// bindingSubjects returns the resources in the cluster which the tests bind
+func bindingSubjects() []runtime.Object {
+	podSpecable := func(apiVersion, kind, namespace, name string, labels map[string]interface{}) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"namespace": namespace,
+				"name":      name,
+				"labels":    labels,
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "app", "image": "example.com/app"},
+						},
+					},
+				},
+			},
+		}}
+	}
+
+	return []runtime.Object{
+		podSpecable("apps/v1", "Deployment", defaultNamespace, "my-simple-app", nil),
+		podSpecable("apps/v1", "Deployment", "ns", "my-simple-app", nil),
+		podSpecable("apps/v1", "Deployment", defaultNamespace, "labeled-app", map[string]interface{}{"foo": "bar"}),
+		podSpecable("batch/v1", "Job", defaultNamespace, "govc-job", nil),
+		podSpecable("serving.knative.dev/v1", "Service", defaultNamespace, "my-service", nil),
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "batch/v1beta1",
+			"kind":       "CronJob",
+			"metadata": map[string]interface{}{
+				"namespace": defaultNamespace,
+				"name":      "nightly",
+			},
+			"spec": map[string]interface{}{
+				"jobTemplate": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"template": map[string]interface{}{
+							"spec": map[string]interface{}{
+								"containers": []interface{}{
+									map[string]interface{}{"name": "backup", "image": "example.com/backup"},
+								},
+							},
+						},
+					},
+				},
+			},
+		}},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"namespace": defaultNamespace,
+				"name":      "settings",
+				"labels":    map[string]interface{}{"app": "settings"},
+			},
+			"data": map[string]interface{}{"key": "value"},
+		}},
+	}
+}
+
 func retrieveCreatedBinding(t *testing.T, err error, vSphereClientSet vsphere.Interface, namespace, sourceName string) *v1alpha1.VSphereBinding {
 	assert.NilError(t, err)
 	source, err := vSphereClientSet.SourcesV1alpha1().
@@ -362,13 +754,6 @@ func assertBasicBinding(t *testing.T, bindingSpec *v1alpha1.VSphereBindingSpec,
 	assert.Check(t, bindingSpec.SkipTLSVerify == skipTLSVerify)
 }
 
-func defaultSelector() *metav1.LabelSelector {
-	return &metav1.LabelSelector{
-		MatchLabels:      map[string]string{},
-		MatchExpressions: []metav1.LabelSelectorRequirement{},
-	}
-}
-
 func assertSubject(t *testing.T, subject *tracker.Reference, apiVersion, kind, namespace, name string, selector *metav1.LabelSelector) {
 	assert.Equal(t, subject.APIVersion, apiVersion)
 	assert.Equal(t, subject.Kind, kind)
@@ -0,0 +1,175 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"knative.dev/pkg/apis"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+// bindingPollInterval is the delay between the reads of the binding while
+// waiting for its update to be reconciled
+const bindingPollInterval = time.Second
+
+// bindingUpdateFlags are the flags of the fields of a binding which can be
+// updated
+var bindingUpdateFlags = []string{
+	"address", "skip-tls-verify", "secret-ref", "govc-env",
+	"subject-api-version", "subject-kind", "subject-name", "subject-selector",
+	"label", "annotation",
+}
+
+type BindingUpdateOptions struct {
+	BindingOptions
+
+	Wait    bool
+	Timeout time.Duration
+}
+
+func NewBindingUpdateCommand(clients *pkg.Clients) *cobra.Command {
+	options := BindingUpdateOptions{}
+	result := cobra.Command{
+		Use:   "update",
+		Short: "Update an existing vSphere binding",
+		Long: "Update an existing vSphere binding in place, leaving the fields of its spec without flags untouched.\n" +
+			"Unlike deleting and creating the binding again, the bound workloads keep their credentials during the update.",
+		Example: `# Change the secret of the binding in the default namespace
+kn vsphere binding update --name binding --secret-ref other-credentials
+# Change the address and TLS verification of the binding in the specified namespace
+kn vsphere binding update --namespace ns --name binding --address https://other-vsphere-endpoint.local --skip-tls-verify=false
+# Bind a selection of Deployment subjects instead, waiting until the controller bound them
+kn vsphere binding update --name binding --subject-kind Deployment --subject-selector foo=bar --wait
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validateOutput(); err != nil {
+				return err
+			}
+			if options.Name == "" {
+				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+			}
+			if !changedAny(cmd, bindingUpdateFlags) {
+				return fmt.Errorf("nothing to update, provide at least one of the following flags:\n\t--%s",
+					strings.Join(bindingUpdateFlags, ", --"))
+			}
+			if !MutuallyExclusiveStringFlags(options.SubjectName, options.SubjectSelector) {
+				return fmt.Errorf("subject can optionally be configured with one of the following flags (but several were set):\n\t" +
+					"--subject-name, --subject-selector")
+			}
+			if cmd.Flags().Changed("subject-api-version") {
+				if _, err := schema.ParseGroupVersion(options.SubjectAPIVersion); err != nil {
+					return fmt.Errorf("'subject-api-version' requires a valid API version such as apps/v1: %+v", err)
+				}
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %+v", err)
+			}
+
+			bindings := clients.VSphereClientSet.SourcesV1alpha1().VSphereBindings(namespace)
+			binding, err := bindings.Get(cmd.Context(), options.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get binding: %+v", err)
+			}
+			previousSubject := binding.Spec.Subject
+			if err := options.applyFlags(cmd.Flags().Changed, binding); err != nil {
+				return err
+			}
+			if err := applyMetadata(&binding.ObjectMeta, options.Labels, options.Annotations); err != nil {
+				return err
+			}
+			if err := validateBinding(cmd.Context(), binding); err != nil {
+				return err
+			}
+			subjectChanged := !equality.Semantic.DeepEqual(previousSubject, binding.Spec.Subject)
+			if subjectChanged && !options.SkipSubjectVerification {
+				if err := verifySubject(cmd.Context(), clients.DynamicClient, binding.Spec.Subject); err != nil {
+					return fmt.Errorf("failed to verify subject: %+v", err)
+				}
+			}
+
+			updated, err := bindings.Update(cmd.Context(), binding, metav1.UpdateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to update binding: %+v", err)
+			}
+			message := "Updated binding"
+			if options.Wait {
+				if updated, err = waitForBinding(cmd.Context(), clients, updated, options.Timeout); err != nil {
+					return err
+				}
+				message = fmt.Sprintf("Updated binding, bound to %d subject(s)", updated.Status.BoundSubjects)
+			}
+			updated.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("VSphereBinding"))
+			return options.printObject(cmd.OutOrStdout(), updated, message)
+		},
+	}
+	flags := result.Flags()
+	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the binding (default namespace if omitted)")
+	flags.StringVar(&options.Name, "name", "", "name of the binding to update")
+	flags.StringArrayVarP(&options.Labels, "label", "l", nil, "label to set on the binding as key=value (can be repeated)")
+	flags.StringArrayVar(&options.Annotations, "annotation", nil, "annotation to set on the binding as key=value (can be repeated)")
+	flags.StringVarP(&options.Address, "address", "a", "", "new URL of the vSphere endpoint")
+	flags.BoolVarP(&options.SkipTLSVerify, "skip-tls-verify", "k", false, "disables certificate verification for the address (same as VC_INSECURE)")
+	flags.StringVarP(&options.SecretRef, "secret-ref", "s", "", "new reference to the Kubernetes secret for the vSphere credentials")
+	flags.BoolVar(&options.GovcEnv, "govc-env", false, "additionally injects the environment variables of the govc CLI (GOVC_URL, GOVC_USERNAME, ...) into the subject")
+	flags.StringVar(&options.SubjectAPIVersion, "subject-api-version", "", "new subject API version, e.g. apps/v1 or serving.knative.dev/v1")
+	flags.StringVar(&options.SubjectKind, "subject-kind", "", "new subject kind of any resource embedding a PodSpec in spec.template, or a CronJob")
+	flags.StringVar(&options.SubjectName, "subject-name", "", "new subject name, replacing the subject selector (cannot be used with --subject-selector)")
+	flags.StringVar(&options.SubjectSelector, "subject-selector", "", "new subject selector, replacing the subject name (cannot be used with --subject-name)")
+	flags.BoolVar(&options.SkipSubjectVerification, "skip-subject-verification", false, "skips verifying that a changed subject exists in the cluster and can be bound")
+	flags.BoolVar(&options.Wait, "wait", false, "wait until the controller reconciled the updated binding")
+	flags.DurationVar(&options.Timeout, "timeout", time.Minute, "maximum time to wait for the updated binding to be reconciled")
+	options.addOutputFlag(&result, "", outputJSON, outputYAML, outputName)
+	options.addQuietFlag(&result)
+	return &result
+}
+
+// changedAny returns whether any of the given flags of the command was set
+func changedAny(cmd *cobra.Command, names []string) bool {
+	for _, name := range names {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForBinding polls the given updated binding until the controller
+// reconciled it and it is ready, and returns the reconciled binding.
+func waitForBinding(ctx context.Context, clients *pkg.Clients, updated *v1alpha1.VSphereBinding,
+	timeout time.Duration) (*v1alpha1.VSphereBinding, error) {
+	bindings := clients.VSphereClientSet.SourcesV1alpha1().VSphereBindings(updated.Namespace)
+	binding := updated
+	err := wait.PollImmediate(bindingPollInterval, timeout, func() (bool, error) {
+		var err error
+		if binding, err = bindings.Get(ctx, updated.Name, metav1.GetOptions{}); err != nil {
+			return false, fmt.Errorf("failed to get binding: %+v", err)
+		}
+		ready := binding.Status.GetCondition(apis.ConditionReady)
+		return binding.Status.ObservedGeneration >= updated.Generation && ready.IsTrue(), nil
+	})
+	if err == wait.ErrWaitTimeout {
+		err = fmt.Errorf("timed out after %s waiting for binding %q to be reconciled", timeout, updated.Name)
+		if ready := binding.Status.GetCondition(apis.ConditionReady); ready != nil && ready.Message != "" {
+			err = fmt.Errorf("%w: %s", err, ready.Message)
+		}
+	}
+	return binding, err
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+)
+
+func TestNewBindingUpdateCommand(t *testing.T) {
+
+	const bindingName = "spring"
+	const secretRef = "street-creds"
+	const bindingAddress = "https://my-vsphere-endpoint.example.com"
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		updateCommand, _, err := bindingCommand.Find([]string{"update"})
+		assert.NilError(t, err)
+
+		assert.Equal(t, updateCommand.Use, "update")
+		assert.Check(t, len(updateCommand.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(updateCommand.Long) > 0,
+			"command should have a nonempty long description")
+		checkFlag(t, updateCommand, "namespace")
+		checkFlag(t, updateCommand, "name")
+		checkFlag(t, updateCommand, "address")
+		checkFlag(t, updateCommand, "skip-tls-verify")
+		checkFlag(t, updateCommand, "secret-ref")
+		checkFlag(t, updateCommand, "subject-api-version")
+		checkFlag(t, updateCommand, "subject-kind")
+		checkFlag(t, updateCommand, "subject-name")
+		checkFlag(t, updateCommand, "subject-selector")
+		checkFlag(t, updateCommand, "wait")
+		checkFlag(t, updateCommand, "timeout")
+		checkFlag(t, updateCommand, "output")
+		checkFlag(t, updateCommand, "quiet")
+		assert.Assert(t, updateCommand.RunE != nil)
+	})
+
+	t.Run("changes the secret and TLS settings only", func(t *testing.T) {
+		existingBinding := newBinding(t, "ns", bindingName, bindingAddress, secretRef, "apps/v1", "Deployment", "my-simple-app")
+		bindingCommand, vSphereClientSet := bindingCommand(regularClientConfig(), existingBinding)
+		bindingCommand.SetArgs([]string{"update", "--namespace", "ns", "--name", bindingName, "--secret-ref", "other-creds", "--skip-tls-verify"})
+
+		err := bindingCommand.Execute()
+
+		binding := retrieveCreatedBinding(t, err, vSphereClientSet, "ns", bindingName)
+		assertBasicBinding(t, &binding.Spec, bindingAddress, "other-creds", true)
+		assertSubject(t, &binding.Spec.Subject, "apps/v1", "Deployment", "ns", "my-simple-app", nil)
+	})
+
+	t.Run("changes the address and the subject in the specified namespace", func(t *testing.T) {
+		const newAddress = "https://other-vsphere-endpoint.example.com"
+		existingBinding := newBinding(t, "ns", bindingName, bindingAddress, secretRef, "batch/v1", "Job", "my-job")
+		bindingCommand, vSphereClientSet := bindingCommand(regularClientConfig(), existingBinding)
+		bindingCommand.SetArgs([]string{"update", "--namespace", "ns", "--name", bindingName, "--address", newAddress,
+			"--subject-api-version", "apps/v1", "--subject-kind", "Deployment", "--subject-name", "my-simple-app"})
+
+		err := bindingCommand.Execute()
+
+		binding := retrieveCreatedBinding(t, err, vSphereClientSet, "ns", bindingName)
+		assertBasicBinding(t, &binding.Spec, newAddress, secretRef, false)
+		assertSubject(t, &binding.Spec.Subject, "apps/v1", "Deployment", "ns", "my-simple-app", nil)
+	})
+
+	t.Run("replaces the subject name with a selector", func(t *testing.T) {
+		existingBinding := newBinding(t, "ns", bindingName, bindingAddress, secretRef, "apps/v1", "Deployment", "my-simple-app")
+		bindingCommand, vSphereClientSet := bindingCommand(regularClientConfig(), existingBinding)
+		bindingCommand.SetArgs([]string{"update", "--namespace", "ns", "--name", bindingName, "--subject-selector", "foo=bar"})
+
+		err := bindingCommand.Execute()
+
+		binding := retrieveCreatedBinding(t, err, vSphereClientSet, "ns", bindingName)
+		assertSubject(t, &binding.Spec.Subject, "apps/v1", "Deployment", "ns", "",
+			&metav1.LabelSelector{
+				MatchLabels:      map[string]string{"foo": "bar"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{},
+			})
+	})
+
+	t.Run("waits for the updated binding to be ready", func(t *testing.T) {
+		existingBinding := newBinding(t, "ns", bindingName, bindingAddress, secretRef, "apps/v1", "Deployment", "my-simple-app").(*v1alpha1.VSphereBinding)
+		existingBinding.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionTrue}}
+		existingBinding.Status.BoundSubjects = 1
+		bindingCommand, _ := bindingCommand(regularClientConfig(), existingBinding)
+		output := bytes.Buffer{}
+		bindingCommand.SetOut(&output)
+		bindingCommand.SetArgs([]string{"update", "--namespace", "ns", "--name", bindingName, "--secret-ref", "other-creds", "--wait"})
+
+		err := bindingCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, output.String(), "Updated binding, bound to 1 subject(s)\n")
+	})
+
+	t.Run("fails to wait when the binding is not ready in time", func(t *testing.T) {
+		existingBinding := newBinding(t, "ns", bindingName, bindingAddress, secretRef, "apps/v1", "Deployment", "my-simple-app").(*v1alpha1.VSphereBinding)
+		existingBinding.Status.Conditions = duckv1.Conditions{{
+			Type:    apis.ConditionReady,
+			Status:  corev1.ConditionFalse,
+			Message: `secrets "other-creds" not found`,
+		}}
+		bindingCommand, _ := bindingCommand(regularClientConfig(), existingBinding)
+		bindingCommand.SetArgs([]string{"update", "--namespace", "ns", "--name", bindingName, "--secret-ref", "other-creds", "--wait", "--timeout", "1ms"})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, fmt.Sprintf(`timed out after 1ms waiting for binding %q to be reconciled: `+
+			`secrets "other-creds" not found`, bindingName))
+	})
+
+	t.Run("fails to execute with an empty name", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{"update", "--secret-ref", "other-creds"})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, "'name' requires a nonempty name provided with the --name option")
+	})
+
+	t.Run("fails to execute without changes", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{"update", "--name", bindingName})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, "nothing to update")
+	})
+
+	t.Run("fails to execute with both subject name and selector", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{"update", "--name", bindingName, "--subject-name", "my-simple-app", "--subject-selector", "foo=bar"})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, "subject can optionally be configured with one of the following flags (but several were set)")
+	})
+
+	t.Run("fails to execute with an invalid address", func(t *testing.T) {
+		existingBinding := newBinding(t, "ns", bindingName, bindingAddress, secretRef, "apps/v1", "Deployment", "my-simple-app")
+		bindingCommand, _ := bindingCommand(regularClientConfig(), existingBinding)
+		bindingCommand.SetArgs([]string{"update", "--namespace", "ns", "--name", bindingName, "--address", "vcenter.example.com"})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, "invalid binding")
+	})
+
+	t.Run("fails to execute when the new subject does not exist", func(t *testing.T) {
+		existingBinding := newBinding(t, "ns", bindingName, bindingAddress, secretRef, "apps/v1", "Deployment", "my-simple-app")
+		bindingCommand, _ := bindingCommand(regularClientConfig(), existingBinding)
+		bindingCommand.SetArgs([]string{"update", "--namespace", "ns", "--name", bindingName, "--subject-name", "missing-app"})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, "failed to verify subject")
+	})
+
+	t.Run("fails to execute when the binding does not exist", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		bindingCommand.SetArgs([]string{"update", "--name", bindingName, "--secret-ref", "other-creds"})
+
+		err := bindingCommand.Execute()
+
+		assert.ErrorContains(t, err, fmt.Sprintf(`failed to get binding: vspherebindings.sources.tanzu.vmware.com %q not found`, bindingName))
+	})
+}
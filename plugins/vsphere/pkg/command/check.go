@@ -0,0 +1,363 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/spf13/cobra"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vim25/soap"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+const (
+	// name of the deployment running the controller and the webhook
+	controllerDeploymentName = "webhook"
+	// service account of the controller
+	controllerServiceAccountName = "controller"
+)
+
+type CheckOptions struct {
+	SourceOptions
+
+	SystemNamespace string
+}
+
+// checkResult is the outcome of a single check of the report
+type checkResult struct {
+	Name string
+	// Message describes a passed or skipped check
+	Message string
+	// Err is set if the check failed
+	Err error
+	// Skipped is true if the check could not run, e.g. without credentials
+	Skipped bool
+}
+
+// MarshalJSON implements json.Marshaler for the --output of the report
+func (r checkResult) MarshalJSON() ([]byte, error) {
+	status, message := "PASS", r.Message
+	switch {
+	case r.Err != nil:
+		status, message = "FAIL", r.Err.Error()
+	case r.Skipped:
+		status = "SKIP"
+	}
+	return json.Marshal(struct {
+		Name    string `json:"name"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}{r.Name, status, message})
+}
+
+func (r checkResult) String() string {
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("FAIL  %s: %v", r.Name, r.Err)
+	case r.Skipped:
+		return fmt.Sprintf("SKIP  %s: %s", r.Name, r.Message)
+	default:
+		return fmt.Sprintf("PASS  %s: %s", r.Name, r.Message)
+	}
+}
+
+// controllerPermissions are the verbs the controller needs in the namespace
+// of a source to run its adapter
+var controllerPermissions = []authorizationv1.ResourceAttributes{
+	{Verb: "create", Resource: "configmaps"},
+	{Verb: "create", Resource: "serviceaccounts"},
+	{Verb: "create", Group: "rbac.authorization.k8s.io", Resource: "rolebindings"},
+	{Verb: "create", Group: "apps", Resource: "deployments"},
+	{Verb: "create", Group: "sources.tanzu.vmware.com", Resource: "vspherebindings"},
+}
+
+func NewCheckCommand(clients *pkg.Clients) *cobra.Command {
+	options := CheckOptions{}
+	result := cobra.Command{
+		Use:   "check",
+		Short: "Check the setup of an existing or prospective vSphere source",
+		Long: "Check the setup of an existing or prospective vSphere source: the credentials, the vCenter connection,\n" +
+			"the sink, the controller and its permissions in the namespace of the source",
+		Example: `# Check an existing source in the default namespace
+kn vsphere check --name source
+# Check a prospective source in the specified namespace before creating it
+kn vsphere check --namespace ns --address https://my-vsphere-endpoint.local --secret-ref vsphere-credentials --sink broker:default
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validateOutput(); err != nil {
+				return err
+			}
+			if options.Name == "" && (options.Address == "" || options.SecretRef == "") {
+				return fmt.Errorf("'check' requires the name of an existing source provided with the --name option," +
+					"\nor the --address and --secret-ref options of a prospective source")
+			}
+			return options.applySinkShorthand()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %+v", err)
+			}
+
+			spec, err := options.checkedSpec(cmd.Context(), clients, namespace)
+			if err != nil {
+				return err
+			}
+
+			results := runChecks(cmd.Context(), clients, namespace, options.SystemNamespace, spec)
+			failed := 0
+			for _, r := range results {
+				if options.Output == "" && !options.Quiet {
+					fmt.Fprintln(cmd.OutOrStdout(), r)
+				}
+				if r.Err != nil {
+					failed++
+				}
+			}
+			if options.Output != "" {
+				if err := options.printValue(cmd.OutOrStdout(), results); err != nil {
+					return err
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d checks failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+	flags := result.Flags()
+	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the source to check (default namespace if omitted)")
+	flags.StringVar(&options.Name, "name", "", "name of an existing source to check")
+	flags.StringVarP(&options.Address, "address", "a", "", "URL of ESXi or vCenter instance of a prospective source")
+	flags.BoolVarP(&options.SkipTLSVerify, "skip-tls-verify", "k", false, "disables certificate verification for the source address")
+	flags.StringVarP(&options.SecretRef, "secret-ref", "s", "", "reference to the Kubernetes secret for the vSphere credentials of a prospective source")
+	flags.StringVar(&options.Sink, "sink", "",
+		"sink as broker:<name>, channel:<name>, ksvc:<name>, svc:<name>, the name of a Knative Service or an http(s) URL")
+	flags.StringVarP(&options.SinkURI, "sink-uri", "u", "", "sink URI (can be absolute, or relative to the referred sink resource)")
+	flags.StringVar(&options.SinkAPIVersion, "sink-api-version", "", "sink API version")
+	flags.StringVar(&options.SinkKind, "sink-kind", "", "sink kind")
+	flags.StringVar(&options.SinkName, "sink-name", "", "sink name")
+	flags.StringVar(&options.SystemNamespace, "system-namespace", "vmware-sources", "namespace of the controller")
+	options.addOutputFlag(&result, "", outputJSON, outputYAML)
+	options.addQuietFlag(&result)
+	return &result
+}
+
+// checkedSpec returns the address, credentials and sink to check, either of
+// the existing source or the flags of a prospective one. The sink is nil if
+// not set, or if the source does not send its events to a sink.
+func (co *CheckOptions) checkedSpec(ctx context.Context, clients *pkg.Clients, namespace string) (*checkedSpec, error) {
+	if co.Address == "" {
+		src, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace).Get(ctx, co.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get source: %+v", err)
+		}
+		address := url.URL(src.Spec.Address)
+		spec := &checkedSpec{
+			Address:       &address,
+			SkipTLSVerify: src.Spec.SkipTLSVerify,
+			SecretRef:     src.Spec.SecretRef.Name,
+		}
+		if !src.Spec.LogOnly && src.Spec.Kafka == nil {
+			sink := src.Spec.Sink
+			spec.Sink = &sink
+		}
+		return spec, nil
+	}
+
+	address, err := url.Parse(co.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source address: %+v", err)
+	}
+	spec := &checkedSpec{
+		Address:       address,
+		SkipTLSVerify: co.SkipTLSVerify,
+		SecretRef:     co.SecretRef,
+	}
+	if co.SinkURI != "" || co.SinkAPIVersion != "" {
+		if spec.Sink, err = co.AsSinkDestination(namespace); err != nil {
+			return nil, fmt.Errorf("failed to parse sink address: %+v", err)
+		}
+	}
+	return spec, nil
+}
+
+// checkedSpec is the part of a source which is checked
+type checkedSpec struct {
+	Address       *url.URL
+	SkipTLSVerify bool
+	SecretRef     string
+	Sink          *duckv1.Destination
+}
+
+func runChecks(ctx context.Context, clients *pkg.Clients, namespace, systemNamespace string, spec *checkedSpec) []checkResult {
+	secret, secretResult := checkSecret(ctx, clients, namespace, spec.SecretRef)
+	return []checkResult{
+		secretResult,
+		checkVCenter(ctx, spec, secret),
+		checkSink(ctx, clients, spec.Sink),
+		checkController(ctx, clients, systemNamespace),
+		checkControllerPermissions(ctx, clients, namespace, systemNamespace),
+	}
+}
+
+// checkSecret checks that the secret has the keys of basic auth credentials,
+// and returns it if so.
+func checkSecret(ctx context.Context, clients *pkg.Clients, namespace, name string) (*corev1.Secret, checkResult) {
+	r := checkResult{Name: "secret"}
+	secret, err := clients.ClientSet.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		r.Err = fmt.Errorf("failed to get secret %q: %v", name, err)
+		return nil, r
+	}
+	for _, key := range []string{corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey} {
+		if len(secret.Data[key]) == 0 {
+			r.Err = fmt.Errorf("secret %q has no %q key", name, key)
+			return nil, r
+		}
+	}
+	r.Message = fmt.Sprintf("secret %q has the %q and %q keys", name, corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+	return secret, r
+}
+
+// checkVCenter checks that the vCenter address resolves and that the
+// credentials of the given secret authenticate, unless the secret is nil.
+func checkVCenter(ctx context.Context, spec *checkedSpec, secret *corev1.Secret) checkResult {
+	r := checkResult{Name: "vcenter"}
+	if _, err := net.DefaultResolver.LookupHost(ctx, spec.Address.Hostname()); err != nil {
+		r.Err = fmt.Errorf("failed to resolve %q: %v", spec.Address.Hostname(), err)
+		return r
+	}
+	if secret == nil {
+		r.Skipped = true
+		r.Message = fmt.Sprintf("%q resolves, authentication not checked without valid credentials", spec.Address.Hostname())
+		return r
+	}
+
+	u, err := soap.ParseURL(spec.Address.String())
+	if err != nil {
+		r.Err = fmt.Errorf("failed to parse vCenter URL: %v", err)
+		return r
+	}
+	u.User = url.UserPassword(string(secret.Data[corev1.BasicAuthUsernameKey]), string(secret.Data[corev1.BasicAuthPasswordKey]))
+	client, err := govmomi.NewClient(ctx, u, spec.SkipTLSVerify)
+	if err != nil {
+		r.Err = fmt.Errorf("failed to authenticate with vCenter: %v", err)
+		return r
+	}
+	_ = client.Logout(ctx)
+
+	r.Message = fmt.Sprintf("authenticated with %s", spec.Address.Host)
+	return r
+}
+
+// checkSink checks that the sink is an absolute URI or refers to an
+// addressable resource.
+func checkSink(ctx context.Context, clients *pkg.Clients, sink *duckv1.Destination) checkResult {
+	r := checkResult{Name: "sink"}
+	switch {
+	case sink == nil:
+		r.Skipped = true
+		r.Message = "no sink specified"
+		return r
+
+	case sink.Ref == nil:
+		if sink.URI == nil || !sink.URI.URL().IsAbs() {
+			r.Err = errors.New("sink URI must be absolute without a sink reference")
+			return r
+		}
+		r.Message = fmt.Sprintf("sink URI %s is absolute", sink.URI)
+		return r
+	}
+
+	ref := sink.Ref
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		r.Err = fmt.Errorf("invalid sink API version %q: %v", ref.APIVersion, err)
+		return r
+	}
+	obj, err := clients.DynamicClient.Resource(apis.KindToResource(gv.WithKind(ref.Kind))).Namespace(ref.Namespace).
+		Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		r.Err = fmt.Errorf("failed to get sink %s %q: %v", ref.Kind, ref.Name, err)
+		return r
+	}
+
+	// Kubernetes Services are addressed by their DNS name
+	if gv.Group == "" && ref.Kind == "Service" {
+		r.Message = fmt.Sprintf("sink %s %q exists", ref.Kind, ref.Name)
+		return r
+	}
+	address, _, _ := unstructured.NestedString(obj.Object, "status", "address", "url")
+	if address == "" {
+		r.Err = fmt.Errorf("sink %s %q is not addressable", ref.Kind, ref.Name)
+		return r
+	}
+	r.Message = fmt.Sprintf("sink %s %q is addressable at %s", ref.Kind, ref.Name, address)
+	return r
+}
+
+// checkController checks that the controller and webhook are available.
+func checkController(ctx context.Context, clients *pkg.Clients, systemNamespace string) checkResult {
+	r := checkResult{Name: "controller"}
+	d, err := clients.ClientSet.AppsV1().Deployments(systemNamespace).Get(ctx, controllerDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		r.Err = fmt.Errorf("failed to get deployment %s/%s: %v", systemNamespace, controllerDeploymentName, err)
+		return r
+	}
+	if d.Status.AvailableReplicas < 1 {
+		r.Err = fmt.Errorf("deployment %s/%s has no available replicas", systemNamespace, controllerDeploymentName)
+		return r
+	}
+	r.Message = fmt.Sprintf("deployment %s/%s has %d available replicas", systemNamespace, controllerDeploymentName, d.Status.AvailableReplicas)
+	return r
+}
+
+// checkControllerPermissions checks that RBAC permits the controller to create
+// the resources of the adapter in the namespace.
+func checkControllerPermissions(ctx context.Context, clients *pkg.Clients, namespace, systemNamespace string) checkResult {
+	r := checkResult{Name: "rbac"}
+	user := fmt.Sprintf("system:serviceaccount:%s:%s", systemNamespace, controllerServiceAccountName)
+	for _, attrs := range controllerPermissions {
+		attrs := attrs
+		attrs.Namespace = namespace
+		review, err := clients.ClientSet.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:               user,
+				ResourceAttributes: &attrs,
+			},
+		}, metav1.CreateOptions{})
+		if apierrs.IsForbidden(err) {
+			r.Skipped = true
+			r.Message = "not permitted to review the permissions of the controller"
+			return r
+		} else if err != nil {
+			r.Err = fmt.Errorf("failed to review the permissions of the controller: %v", err)
+			return r
+		}
+		if !review.Status.Allowed {
+			r.Err = fmt.Errorf("controller cannot %s %s in namespace %q", attrs.Verb, attrs.Resource, namespace)
+			return r
+		}
+	}
+	r.Message = fmt.Sprintf("controller can manage adapters in namespace %q", namespace)
+	return r
+}
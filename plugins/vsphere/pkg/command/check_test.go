@@ -0,0 +1,242 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	vspherefake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+)
+
+func TestNewCheckCommand(t *testing.T) {
+	const secretName = "vsphere-credentials"
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		checkCommand, _ := checkCommand(checkClients(true))
+
+		assert.Equal(t, checkCommand.Use, "check")
+		assert.Check(t, len(checkCommand.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(checkCommand.Long) > 0,
+			"command should have a nonempty long description")
+		checkFlag(t, checkCommand, "namespace")
+		checkFlag(t, checkCommand, "name")
+		checkFlag(t, checkCommand, "address")
+		checkFlag(t, checkCommand, "secret-ref")
+		checkFlag(t, checkCommand, "sink")
+		checkFlag(t, checkCommand, "system-namespace")
+		assert.Assert(t, checkCommand.RunE != nil)
+	})
+
+	t.Run("fails to execute without a name or an address", func(t *testing.T) {
+		checkCommand, _ := checkCommand(checkClients(true))
+		checkCommand.SetArgs([]string{"--secret-ref", secretName})
+
+		err := checkCommand.Execute()
+
+		assert.ErrorContains(t, err, "requires the name of an existing source")
+	})
+
+	t.Run("fails to execute when the source does not exist", func(t *testing.T) {
+		checkCommand, _ := checkCommand(checkClients(true))
+		checkCommand.SetArgs([]string{"--name", "unknown"})
+
+		err := checkCommand.Execute()
+
+		assert.ErrorContains(t, err, "failed to get source")
+	})
+
+	t.Run("passes all checks of an existing source", func(t *testing.T) {
+		simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+			clients := checkClients(true, newCredentials(secretName), newCheckedSource(vc.URL().String(), secretName))
+			checkCommand, out := checkCommand(clients)
+			checkCommand.SetArgs([]string{"--name", "source"})
+
+			err := checkCommand.Execute()
+
+			assert.NilError(t, err, out.String())
+			assert.Check(t, !bytes.Contains(out.Bytes(), []byte("FAIL")), out.String())
+			return nil
+		})
+	})
+
+	t.Run("reports a missing secret and a sink which is not addressable", func(t *testing.T) {
+		simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+			checkCommand, out := checkCommand(checkClients(true))
+			checkCommand.SetArgs([]string{
+				"--address", vc.URL().String(),
+				"--secret-ref", secretName,
+				"--sink", "broker:unready",
+			})
+
+			err := checkCommand.Execute()
+
+			assert.ErrorContains(t, err, "2 of 5 checks failed")
+			assert.Check(t, bytes.Contains(out.Bytes(), []byte("FAIL  secret")), out.String())
+			assert.Check(t, bytes.Contains(out.Bytes(), []byte("SKIP  vcenter")), out.String())
+			assert.Check(t, bytes.Contains(out.Bytes(), []byte(`FAIL  sink: sink Broker "unready" is not addressable`)), out.String())
+			return nil
+		})
+	})
+
+	t.Run("reports the checks as JSON", func(t *testing.T) {
+		simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+			checkCommand, out := checkCommand(checkClients(true))
+			checkCommand.SetArgs([]string{
+				"--address", vc.URL().String(),
+				"--secret-ref", secretName,
+				"--sink", "broker:default",
+				"-o", "json",
+			})
+
+			err := checkCommand.Execute()
+
+			assert.ErrorContains(t, err, "1 of 5 checks failed")
+			var results []struct{ Name, Status, Message string }
+			// the usage of the failed command follows the report
+			assert.NilError(t, json.NewDecoder(out).Decode(&results))
+			assert.Equal(t, len(results), 5)
+			assert.Equal(t, results[0].Name, "secret")
+			assert.Equal(t, results[0].Status, "FAIL")
+			assert.Equal(t, results[1].Status, "SKIP")
+			return nil
+		})
+	})
+
+	t.Run("reports missing permissions of the controller", func(t *testing.T) {
+		simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+			checkCommand, out := checkCommand(checkClients(false, newCredentials(secretName)))
+			checkCommand.SetArgs([]string{
+				"--address", vc.URL().String(),
+				"--skip-tls-verify",
+				"--secret-ref", secretName,
+				"--sink", "broker:default",
+			})
+
+			err := checkCommand.Execute()
+
+			assert.ErrorContains(t, err, "1 of 5 checks failed")
+			assert.Check(t, bytes.Contains(out.Bytes(), []byte("FAIL  rbac: controller cannot create configmaps")), out.String())
+			return nil
+		})
+	})
+}
+
+func checkCommand(clients *pkg.Clients) (*cobra.Command, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	checkCommand := command.NewCheckCommand(clients)
+	checkCommand.SetErr(ioutil.Discard)
+	checkCommand.SetOut(out)
+	return checkCommand, out
+}
+
+// checkClients returns clients of a cluster with a running controller, whose
+// permissions are allowed or denied, and two brokers of which only the default
+// one is addressable
+func checkClients(allowed bool, objects ...runtime.Object) *pkg.Clients {
+	var k8sObjects, vsphereObjects []runtime.Object
+	for _, o := range objects {
+		if _, ok := o.(*v1alpha1.VSphereSource); ok {
+			vsphereObjects = append(vsphereObjects, o)
+		} else {
+			k8sObjects = append(k8sObjects, o)
+		}
+	}
+	k8sObjects = append(k8sObjects, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "vmware-sources", Name: "webhook"},
+		Status:     appsv1.DeploymentStatus{AvailableReplicas: 1},
+	})
+
+	clientSet := k8sfake.NewSimpleClientset(k8sObjects...)
+	clientSet.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = allowed
+		return true, review, nil
+	})
+
+	broker := func(name, address string) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "eventing.knative.dev/v1",
+			"kind":       "Broker",
+			"metadata": map[string]interface{}{
+				"namespace": defaultNamespace,
+				"name":      name,
+			},
+			"status": map[string]interface{}{
+				"address": map[string]interface{}{
+					"url": address,
+				},
+			},
+		}}
+	}
+
+	return &pkg.Clients{
+		ClientSet:        clientSet,
+		ClientConfig:     regularClientConfig(),
+		VSphereClientSet: vspherefake.NewSimpleClientset(vsphereObjects...),
+		DynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+			broker("default", "http://broker-ingress.knative-eventing.svc.cluster.local/default/default"),
+			broker("unready", "")),
+	}
+}
+
+func newCredentials(name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: defaultNamespace,
+			Name:      name,
+		},
+		Type: corev1.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			corev1.BasicAuthUsernameKey: []byte("user"),
+			corev1.BasicAuthPasswordKey: []byte("pass"),
+		},
+	}
+}
+
+func newCheckedSource(address, secretName string) *v1alpha1.VSphereSource {
+	u, _ := apis.ParseURL(address)
+	sink, _ := apis.ParseURL("http://where.to.send.stuff")
+	return &v1alpha1.VSphereSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: defaultNamespace,
+			Name:      "source",
+		},
+		Spec: v1alpha1.VSphereSourceSpec{
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{URI: sink},
+			},
+			VAuthSpec: v1alpha1.VAuthSpec{
+				Address:       *u,
+				SkipTLSVerify: true,
+				SecretRef:     corev1.LocalObjectReference{Name: secretName},
+			},
+		},
+	}
+}
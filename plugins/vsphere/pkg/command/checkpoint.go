@@ -0,0 +1,168 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources/names"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+type CheckpointOptions struct {
+	Namespace string
+	Name      string
+	Filename  string
+
+	OutputOptions
+}
+
+func NewSourceExportCheckpointCommand(clients *pkg.Clients) *cobra.Command {
+	options := CheckpointOptions{}
+	result := cobra.Command{
+		Use:   "export-checkpoint",
+		Short: "Export the checkpoint of an existing vSphere source",
+		Long: "Export the checkpoint of an existing vSphere source to a portable file, which can be imported into a source of the same vCenter in another cluster.\n" +
+			"Pause the source before exporting its checkpoint, so that it does not send the events after the checkpoint as well.",
+		Example: `# Export the checkpoint of the source in the default namespace
+kn vsphere source export-checkpoint --name source > checkpoint.json
+# Export the checkpoint of the source in the specified namespace to the specified file
+kn vsphere source export-checkpoint --namespace ns --name source --filename checkpoint.json
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if options.Name == "" {
+				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %+v", err)
+			}
+
+			source, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace).Get(cmd.Context(), options.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get source: %+v", err)
+			}
+			cm, err := clients.ClientSet.CoreV1().ConfigMaps(namespace).Get(cmd.Context(), names.ConfigMap(source), metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get checkpoint: %+v", err)
+			}
+			cp, ok := cm.Data[vsphere.CheckpointKey]
+			if !ok {
+				return fmt.Errorf("source %s/%s has no checkpoint yet", namespace, options.Name)
+			}
+
+			b, err := json.MarshalIndent(vsphere.CheckpointExport{
+				Source:     namespace + "/" + options.Name,
+				ExportedAt: time.Now().UTC(),
+				Checkpoint: json.RawMessage(cp),
+			}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode checkpoint: %+v", err)
+			}
+			b = append(b, '\n')
+			if options.Filename == "" || options.Filename == "-" {
+				_, err = cmd.OutOrStdout().Write(b)
+			} else {
+				err = ioutil.WriteFile(options.Filename, b, 0o600)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write checkpoint: %+v", err)
+			}
+			return nil
+		},
+	}
+	flags := result.Flags()
+	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the source (default namespace if omitted)")
+	flags.StringVar(&options.Name, "name", "", "name of the source to export the checkpoint of")
+	flags.StringVarP(&options.Filename, "filename", "f", "", "file to write the checkpoint to (stdout if omitted or -)")
+	return &result
+}
+
+func NewSourceImportCheckpointCommand(clients *pkg.Clients) *cobra.Command {
+	options := CheckpointOptions{}
+	result := cobra.Command{
+		Use:   "import-checkpoint",
+		Short: "Import an exported checkpoint into an existing vSphere source",
+		Long: "Import a checkpoint exported with export-checkpoint into an existing vSphere source, e.g. in another cluster.\n" +
+			"The source is annotated with the checkpoint, which makes the controller roll out its adapter again, " +
+			"continuing the event stream of the vCenter where the exported source left off.",
+		Example: `# Import the checkpoint into the source in the default namespace
+kn vsphere source import-checkpoint --name source --filename checkpoint.json
+# Migrate the checkpoint of a source to the source in another cluster
+kn vsphere source export-checkpoint --name source | KUBECONFIG=other.kubeconfig kn vsphere source import-checkpoint --name source -f -
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validateOutput(); err != nil {
+				return err
+			}
+			if options.Name == "" {
+				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+			}
+			if options.Filename == "" {
+				return fmt.Errorf("'filename' requires a nonempty file name provided with the --filename option")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var b []byte
+			var err error
+			if options.Filename == "-" {
+				b, err = ioutil.ReadAll(cmd.InOrStdin())
+			} else {
+				b, err = ioutil.ReadFile(options.Filename)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read checkpoint: %+v", err)
+			}
+			var export vsphere.CheckpointExport
+			if err := json.Unmarshal(b, &export); err != nil {
+				return fmt.Errorf("failed to parse checkpoint: %+v", err)
+			}
+			imported, err := vsphere.NewCheckpointImport(export, time.Now())
+			if err != nil {
+				return fmt.Errorf("invalid checkpoint: %+v", err)
+			}
+
+			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %+v", err)
+			}
+			sources := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace)
+			source, err := sources.Get(cmd.Context(), options.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get source: %+v", err)
+			}
+			if source.Annotations == nil {
+				source.Annotations = map[string]string{}
+			}
+			source.Annotations[vsphere.CheckpointImportAnnotation] = imported
+			updated, err := sources.Update(cmd.Context(), source, metav1.UpdateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to update source: %+v", err)
+			}
+			updated.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("VSphereSource"))
+			return options.printObject(cmd.OutOrStdout(), updated, fmt.Sprintf("Imported checkpoint of source %s", export.Source))
+		},
+	}
+	flags := result.Flags()
+	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the source (default namespace if omitted)")
+	flags.StringVar(&options.Name, "name", "", "name of the source to import the checkpoint into")
+	flags.StringVarP(&options.Filename, "filename", "f", "", "file to read the exported checkpoint from (stdin if -)")
+	options.addOutputFlag(&result, "", outputJSON, outputYAML, outputName)
+	options.addQuietFlag(&result)
+	return &result
+}
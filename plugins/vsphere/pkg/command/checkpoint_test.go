@@ -0,0 +1,146 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	vspherefake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+)
+
+func TestSourceCheckpointCommands(t *testing.T) {
+
+	const sourceName = "spring"
+	const secretRef = "street-creds"
+	const sourceAddress = "https://my-vsphere-endpoint.example.com"
+	const sinkURI = "https://sink.example.com"
+	const checkpoint = `{"vCenter":"my-vsphere-endpoint.example.com","lastEventKey":42,"lastEventType":"VmPoweredOnEvent","lastEventKeyTimestamp":"2021-04-01T12:00:00Z","createdTimestamp":"2021-04-01T12:00:01Z","vCenterID":"vcenter-uuid"}`
+
+	newCommand := func(objects []runtime.Object, configMaps ...runtime.Object) (*cobra.Command, *vspherefake.Clientset, *bytes.Buffer) {
+		vSphereClientSet := vspherefake.NewSimpleClientset(objects...)
+		sourceCommand := command.NewSourceCommand(&pkg.Clients{
+			ClientSet:        k8sfake.NewSimpleClientset(configMaps...),
+			ClientConfig:     regularClientConfig(),
+			VSphereClientSet: vSphereClientSet,
+		})
+		out := &bytes.Buffer{}
+		sourceCommand.SetErr(ioutil.Discard)
+		sourceCommand.SetOut(out)
+		return sourceCommand, vSphereClientSet, out
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: defaultNamespace, Name: sourceName + "-configmap"},
+		Data:       map[string]string{vsphere.CheckpointKey: checkpoint},
+	}
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		for _, use := range []string{"export-checkpoint", "import-checkpoint"} {
+			sourceCommand, _, _ := newCommand(nil)
+			checkpointCommand, _, err := sourceCommand.Find([]string{use})
+			assert.NilError(t, err)
+
+			assert.Equal(t, checkpointCommand.Use, use)
+			assert.Check(t, len(checkpointCommand.Short) > 0,
+				"command should have a nonempty short description")
+			assert.Check(t, len(checkpointCommand.Long) > 0,
+				"command should have a nonempty long description")
+			checkFlag(t, checkpointCommand, "namespace")
+			checkFlag(t, checkpointCommand, "name")
+			checkFlag(t, checkpointCommand, "filename")
+			assert.Assert(t, checkpointCommand.RunE != nil)
+		}
+	})
+
+	t.Run("exports the checkpoint of the source", func(t *testing.T) {
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, _, out := newCommand([]runtime.Object{existingSource}, configMap)
+		sourceCommand.SetArgs([]string{"export-checkpoint", "--name", sourceName})
+
+		err := sourceCommand.Execute()
+
+		assert.NilError(t, err)
+		var export vsphere.CheckpointExport
+		assert.NilError(t, json.Unmarshal(out.Bytes(), &export))
+		assert.Equal(t, export.Source, defaultNamespace+"/"+sourceName)
+		var compact bytes.Buffer
+		assert.NilError(t, json.Compact(&compact, export.Checkpoint))
+		assert.Equal(t, compact.String(), checkpoint)
+		assert.Check(t, time.Since(export.ExportedAt) < time.Minute)
+	})
+
+	t.Run("imports the exported checkpoint into the source", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "checkpoint.json")
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, _, _ := newCommand([]runtime.Object{existingSource}, configMap)
+		sourceCommand.SetArgs([]string{"export-checkpoint", "--name", sourceName, "--filename", filename})
+		assert.NilError(t, sourceCommand.Execute())
+
+		otherSource := newSource(t, "ns", sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, vSphereClientSet, _ := newCommand([]runtime.Object{otherSource})
+		sourceCommand.SetArgs([]string{"import-checkpoint", "--namespace", "ns", "--name", sourceName, "--filename", filename})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, "ns", sourceName)
+		imported := source.Annotations[vsphere.CheckpointImportAnnotation]
+		assert.NilError(t, vsphere.ValidateCheckpointImport(imported))
+		var cp struct {
+			LastEventKey     int32     `json:"lastEventKey"`
+			CreatedTimestamp time.Time `json:"createdTimestamp"`
+		}
+		assert.NilError(t, json.Unmarshal([]byte(imported), &cp))
+		assert.Equal(t, cp.LastEventKey, int32(42))
+		assert.Check(t, time.Since(cp.CreatedTimestamp) < time.Minute)
+	})
+
+	t.Run("fails to export the checkpoint of a source without checkpoint", func(t *testing.T) {
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, _, _ := newCommand([]runtime.Object{existingSource}, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: defaultNamespace, Name: sourceName + "-configmap"},
+		})
+		sourceCommand.SetArgs([]string{"export-checkpoint", "--name", sourceName})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "source "+defaultNamespace+"/spring has no checkpoint yet")
+	})
+
+	t.Run("fails to import an invalid checkpoint", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "checkpoint.json")
+		assert.NilError(t, ioutil.WriteFile(filename, []byte(`{"source":"default/spring","checkpoint":{"lastEventKey":42}}`), 0o600))
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, _, _ := newCommand([]runtime.Object{existingSource})
+		sourceCommand.SetArgs([]string{"import-checkpoint", "--name", sourceName, "--filename", filename})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "invalid checkpoint: checkpoint has no last event timestamp")
+	})
+
+	t.Run("fails to import without a file name", func(t *testing.T) {
+		sourceCommand, _, _ := newCommand(nil)
+		sourceCommand.SetArgs([]string{"import-checkpoint", "--name", sourceName})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "'filename' requires a nonempty file name provided with the --filename option")
+	})
+}
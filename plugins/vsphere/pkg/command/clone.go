@@ -0,0 +1,159 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+type CloneOptions struct {
+	SourceOptions
+
+	ToName      string
+	ToNamespace string
+}
+
+func NewSourceCloneCommand(clients *pkg.Clients) *cobra.Command {
+	options := CloneOptions{}
+	result := cobra.Command{
+		Use:   "clone",
+		Short: "Create a vSphere source with the spec of an existing one",
+		Long: "Create a vSphere source with the spec of an existing one, e.g. to stamp out a variant of a known-good source per environment.\n" +
+			"The labels and annotations of the source are copied, its status and checkpoint are not.",
+		Example: `# Clone the source in the default namespace, sending the events of the clone to another broker
+kn vsphere source clone --name source --to-name other-source --sink broker:other
+# Clone the source of the specified namespace into another namespace, watching another vCenter
+kn vsphere source clone --namespace dev --name source --to-namespace prod --to-name source --address https://prod-vcenter.local --secret-ref prod-credentials
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validateOutput(); err != nil {
+				return err
+			}
+			if options.Name == "" {
+				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+			}
+			if options.ToName == "" {
+				return fmt.Errorf("'to-name' requires a nonempty name provided with the --to-name option")
+			}
+			if options.Sink == "" && options.SinkURI == "" && options.SinkAPIVersion == "" && options.SinkKind == "" && options.SinkName == "" {
+				// the sink of the source is kept
+				return nil
+			}
+			if err := options.applySinkShorthand(); err != nil {
+				return err
+			}
+			return options.validateSink()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %+v", err)
+			}
+			toNamespace := namespace
+			if options.ToNamespace != "" {
+				toNamespace = options.ToNamespace
+			}
+
+			original, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace).
+				Get(cmd.Context(), options.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get source: %+v", err)
+			}
+			source, err := options.cloneSource(cmd, original, toNamespace)
+			if err != nil {
+				return err
+			}
+			created, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(toNamespace).
+				Create(cmd.Context(), source, metav1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to create source: %+v", err)
+			}
+			created.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("VSphereSource"))
+			return options.printObject(cmd.OutOrStdout(), created,
+				fmt.Sprintf("Created source %s/%s as clone of %s/%s", toNamespace, options.ToName, namespace, options.Name))
+		},
+	}
+	flags := result.Flags()
+	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the source to clone (default namespace if omitted)")
+	flags.StringVar(&options.Name, "name", "", "name of the source to clone")
+	flags.StringVar(&options.ToNamespace, "to-namespace", "", "namespace of the source to create (namespace of the cloned source if omitted)")
+	flags.StringVar(&options.ToName, "to-name", "", "name of the source to create")
+	flags.StringVarP(&options.Address, "address", "a", "", "URL of ESXi or vCenter instance to connect to instead (optional)")
+	flags.StringVarP(&options.SecretRef, "secret-ref", "s", "",
+		"reference to the Kubernetes secret for the vSphere credentials to use instead (optional)")
+	flags.StringVar(&options.Sink, "sink", "",
+		"sink as broker:<name>, channel:<name>, ksvc:<name>, svc:<name>, the name of a Knative Service, an http(s) URL or none to log the events")
+	flags.StringVarP(&options.SinkURI, "sink-uri", "u", "", "sink URI (can be absolute, or relative to the referred sink resource)")
+	flags.StringVar(&options.SinkAPIVersion, "sink-api-version", "", "sink API version")
+	flags.StringVar(&options.SinkKind, "sink-kind", "", "sink kind")
+	flags.StringVar(&options.SinkName, "sink-name", "", "sink name")
+	options.addOutputFlag(&result, "", outputJSON, outputYAML, outputName)
+	options.addQuietFlag(&result)
+	return &result
+}
+
+// cloneSource returns the source to create in the given namespace with the
+// spec of the given original source, with the flags which are set applied on
+// top of it, and validates it.
+func (co *CloneOptions) cloneSource(cmd *cobra.Command, original *v1alpha1.VSphereSource, namespace string) (*v1alpha1.VSphereSource, error) {
+	source := &v1alpha1.VSphereSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        co.ToName,
+			Labels:      original.Labels,
+			Annotations: original.Annotations,
+		},
+		Spec: *original.Spec.DeepCopy(),
+	}
+	if _, ok := source.Annotations[lastAppliedAnnotation]; ok {
+		source.Annotations = make(map[string]string, len(original.Annotations))
+		for k, v := range original.Annotations {
+			if k != lastAppliedAnnotation {
+				source.Annotations[k] = v
+			}
+		}
+	}
+
+	changed := cmd.Flags().Changed
+	if changed("address") {
+		address, err := url.Parse(co.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse source address: %+v", err)
+		}
+		source.Spec.Address = apis.URL(*address)
+	}
+	if changed("secret-ref") {
+		source.Spec.SecretRef.Name = co.SecretRef
+	}
+	if changed("sink") || changed("sink-uri") || changed("sink-api-version") || changed("sink-kind") || changed("sink-name") {
+		sinkDestination, err := co.AsSinkDestination(namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sink address: %+v", err)
+		}
+		source.Spec.Sink = *sinkDestination
+		source.Spec.LogOnly = co.LogOnly
+	} else if ref := source.Spec.Sink.Ref; ref != nil && ref.Namespace == original.Namespace {
+		// a sink of the namespace of the cloned source refers to the sink of
+		// the same name in the namespace of the clone
+		ref.Namespace = namespace
+	}
+
+	// validate what the webhook would, after defaulting
+	defaulted := source.DeepCopy()
+	defaulted.SetDefaults(cmd.Context())
+	if err := defaulted.Validate(cmd.Context()); err != nil {
+		return nil, fmt.Errorf("invalid source: %+v", err)
+	}
+	return source, nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+)
+
+func TestNewSourceCloneCommand(t *testing.T) {
+
+	const sourceName = "spring"
+	const cloneName = "summer"
+	const secretRef = "street-creds"
+	const sourceAddress = "https://my-vsphere-endpoint.example.com"
+
+	existingSource := func(namespace string) *v1alpha1.VSphereSource {
+		return &v1alpha1.VSphereSource{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      sourceName,
+				Labels:    map[string]string{"team": "infra"},
+				Annotations: map[string]string{
+					"owner": "infra@example.com",
+					"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				},
+			},
+			Spec: v1alpha1.VSphereSourceSpec{
+				SourceSpec: duckv1.SourceSpec{Sink: duckv1.Destination{Ref: &duckv1.KReference{
+					APIVersion: "eventing.knative.dev/v1",
+					Kind:       "Broker",
+					Namespace:  namespace,
+					Name:       "default",
+				}}},
+				VAuthSpec: v1alpha1.VAuthSpec{
+					Address:   parseURI(t, sourceAddress),
+					SecretRef: corev1.LocalObjectReference{Name: secretRef},
+				},
+				IncludeTasks: true,
+				Filter:       &v1alpha1.VFilterSpec{EventTypes: []string{"com.vmware.vsphere.alarm.*"}},
+			},
+		}
+	}
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		cloneCommand, _, err := sourceCommand.Find([]string{"clone"})
+		assert.NilError(t, err)
+
+		assert.Equal(t, cloneCommand.Use, "clone")
+		assert.Check(t, len(cloneCommand.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(cloneCommand.Long) > 0,
+			"command should have a nonempty long description")
+		checkFlag(t, cloneCommand, "namespace")
+		checkFlag(t, cloneCommand, "name")
+		checkFlag(t, cloneCommand, "to-namespace")
+		checkFlag(t, cloneCommand, "to-name")
+		checkFlag(t, cloneCommand, "address")
+		checkFlag(t, cloneCommand, "secret-ref")
+		checkFlag(t, cloneCommand, "sink")
+		checkFlag(t, cloneCommand, "sink-uri")
+		checkFlag(t, cloneCommand, "output")
+		checkFlag(t, cloneCommand, "quiet")
+		assert.Assert(t, cloneCommand.RunE != nil)
+	})
+
+	t.Run("clones the source in the same namespace", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource(defaultNamespace))
+		output := bytes.Buffer{}
+		sourceCommand.SetOut(&output)
+		sourceCommand.SetArgs([]string{"clone", "--name", sourceName, "--to-name", cloneName})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, cloneName)
+		assertBasicSource(t, &source.Spec, sourceAddress, secretRef, false)
+		assertSinkReference(t, source.Spec.Sink.Ref, "eventing.knative.dev/v1", "Broker", defaultNamespace, "default")
+		assert.Check(t, source.Spec.IncludeTasks)
+		assert.DeepEqual(t, source.Spec.Filter.EventTypes, []string{"com.vmware.vsphere.alarm.*"})
+		assert.DeepEqual(t, source.Labels, map[string]string{"team": "infra"})
+		assert.DeepEqual(t, source.Annotations, map[string]string{"owner": "infra@example.com"})
+		assert.Equal(t, output.String(),
+			fmt.Sprintf("Created source %s/%s as clone of %s/%s\n", defaultNamespace, cloneName, defaultNamespace, sourceName))
+	})
+
+	t.Run("clones the source into another namespace with overrides", func(t *testing.T) {
+		const otherAddress = "https://other-vsphere-endpoint.example.com"
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource("dev"))
+		sourceCommand.SetArgs([]string{"clone", "--namespace", "dev", "--name", sourceName, "--to-namespace", "prod",
+			"--to-name", cloneName, "--address", otherAddress, "--secret-ref", "prod-creds"})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, "prod", cloneName)
+		assertBasicSource(t, &source.Spec, otherAddress, "prod-creds", false)
+		// the broker of the namespace of the clone is used
+		assertSinkReference(t, source.Spec.Sink.Ref, "eventing.knative.dev/v1", "Broker", "prod", "default")
+	})
+
+	t.Run("clones the source with another sink", func(t *testing.T) {
+		const sinkURI = "https://sink.example.com"
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource(defaultNamespace))
+		sourceCommand.SetArgs([]string{"clone", "--name", sourceName, "--to-name", cloneName, "--sink", sinkURI})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, cloneName)
+		assert.Check(t, source.Spec.Sink.Ref == nil)
+		assert.Equal(t, source.Spec.Sink.URI.String(), sinkURI)
+	})
+
+	t.Run("fails to execute without a target name", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"clone", "--name", sourceName})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "'to-name' requires a nonempty name provided with the --to-name option")
+	})
+
+	t.Run("fails to execute with an incomplete sink", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"clone", "--name", sourceName, "--to-name", cloneName, "--sink-kind", "Broker"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "sink requires a --sink option")
+	})
+
+	t.Run("fails to execute when the source does not exist", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"clone", "--name", sourceName, "--to-name", cloneName})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, fmt.Sprintf(`failed to get source: vspheresources.sources.tanzu.vmware.com %q not found`, sourceName))
+	})
+
+	t.Run("fails to execute with an invalid override", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig(), existingSource(defaultNamespace))
+		sourceCommand.SetArgs([]string{"clone", "--name", sourceName, "--to-name", cloneName, "--address", "http://insecure.example.com"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "invalid source: ")
+	})
+}
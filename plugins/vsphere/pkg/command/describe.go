@@ -0,0 +1,257 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+type DescribeOptions struct {
+	Namespace string
+	Name      string
+
+	Events bool
+}
+
+// describeField is a line of the header printed by the describe commands
+type describeField struct {
+	name  string
+	value string
+}
+
+func NewSourceDescribeCommand(clients *pkg.Clients) *cobra.Command {
+	options := DescribeOptions{}
+	result := cobra.Command{
+		Use:   "describe",
+		Short: "Describe an existing vSphere source",
+		Long: "Describe an existing vSphere source, with its vCenter, sink and conditions.\n" +
+			"With --events, the Kubernetes events the controller and the webhook emitted about the source are described too.",
+		Example: `# Describe the source in the default namespace
+kn vsphere source describe --name source
+# Describe the source in the specified namespace with its Kubernetes events, e.g. to see why it is not ready
+kn vsphere source describe --namespace ns --name source --events
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return options.validate()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %+v", err)
+			}
+			source, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace).Get(cmd.Context(), options.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get source: %+v", err)
+			}
+
+			hosts := []string{source.Spec.Address.Host}
+			for _, a := range source.Spec.Addresses {
+				hosts = append(hosts, a.Address.Host)
+			}
+			sink := "-"
+			if source.Status.SinkURI != nil {
+				sink = source.Status.SinkURI.String()
+			}
+			describedFields := []describeField{
+				{"Name", source.Name},
+				{"Namespace", source.Namespace},
+				{"vCenter", strings.Join(hosts, ",")},
+				{"Secret", source.Spec.SecretRef.Name},
+				{"Sink", sink},
+			}
+			return options.describe(cmd.Context(), cmd.OutOrStdout(), clients, "VSphereSource", &source.ObjectMeta, describedFields, &source.Status.Status)
+		},
+	}
+	options.addFlags(&result, "source")
+	return &result
+}
+
+func NewBindingDescribeCommand(clients *pkg.Clients) *cobra.Command {
+	options := DescribeOptions{}
+	result := cobra.Command{
+		Use:   "describe",
+		Short: "Describe an existing vSphere binding",
+		Long: "Describe an existing vSphere binding, with its vCenter, subject and conditions.\n" +
+			"With --events, the Kubernetes events the controller and the webhook emitted about the binding are described too.",
+		Example: `# Describe the binding in the default namespace
+kn vsphere binding describe --name binding
+# Describe the binding in the specified namespace with its Kubernetes events, e.g. to see why it is not ready
+kn vsphere binding describe --namespace ns --name binding --events
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return options.validate()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %+v", err)
+			}
+			binding, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereBindings(namespace).Get(cmd.Context(), options.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get binding: %+v", err)
+			}
+
+			subject := binding.Spec.Subject
+			target := subject.Name
+			if subject.Selector != nil {
+				target = metav1.FormatLabelSelector(subject.Selector)
+			}
+			describedFields := []describeField{
+				{"Name", binding.Name},
+				{"Namespace", binding.Namespace},
+				{"vCenter", binding.Spec.Address.Host},
+				{"Secret", binding.Spec.SecretRef.Name},
+				{"Subject", fmt.Sprintf("%s %s %s", subject.APIVersion, subject.Kind, target)},
+			}
+			return options.describe(cmd.Context(), cmd.OutOrStdout(), clients, "VSphereBinding", &binding.ObjectMeta, describedFields, &binding.Status.Status)
+		},
+	}
+	options.addFlags(&result, "binding")
+	return &result
+}
+
+func (do *DescribeOptions) addFlags(cmd *cobra.Command, resource string) {
+	flags := cmd.Flags()
+	flags.StringVarP(&do.Namespace, "namespace", "n", "", fmt.Sprintf("namespace of the %s (default namespace if omitted)", resource))
+	flags.StringVar(&do.Name, "name", "", fmt.Sprintf("name of the %s to describe", resource))
+	flags.BoolVarP(&do.Events, "events", "e", false, fmt.Sprintf("also describe the Kubernetes events about the %s", resource))
+}
+
+func (do *DescribeOptions) validate() error {
+	if do.Name == "" {
+		return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+	}
+	return nil
+}
+
+// describe prints the given fields and the conditions of the given status,
+// followed by the Kubernetes events about the resource if requested
+func (do *DescribeOptions) describe(ctx context.Context, out io.Writer, clients *pkg.Clients, kind string, meta *metav1.ObjectMeta,
+	describedFields []describeField, status *duckv1.Status) error {
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	for _, f := range describedFields {
+		fmt.Fprintf(w, "%s:\t%s\n", f.name, f.value)
+	}
+	fmt.Fprintln(w, "Conditions:")
+	if len(status.Conditions) == 0 {
+		fmt.Fprintln(w, "  <none>")
+	} else {
+		fmt.Fprintln(w, "  TYPE\tSTATUS\tREASON\tMESSAGE")
+		for _, c := range status.Conditions {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", c.Type, c.Status, orDash(c.Reason), orDash(c.Message))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !do.Events {
+		return nil
+	}
+
+	events, err := relatedEvents(ctx, clients, kind, meta)
+	if err != nil {
+		return err
+	}
+	return printEvents(out, events, time.Now())
+}
+
+// relatedEvents returns the Kubernetes events about the given resource, the
+// least recent first. Events about a deleted resource of the same name are
+// left out.
+func relatedEvents(ctx context.Context, clients *pkg.Clients, kind string, meta *metav1.ObjectMeta) ([]corev1.Event, error) {
+	selector := fields.Set{
+		"involvedObject.kind": kind,
+		"involvedObject.name": meta.Name,
+	}.AsSelector().String()
+	list, err := clients.ClientSet.CoreV1().Events(meta.Namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %+v", err)
+	}
+
+	events := make([]corev1.Event, 0, len(list.Items))
+	for _, e := range list.Items {
+		o := e.InvolvedObject
+		if o.Kind != kind || o.Name != meta.Name || (o.UID != "" && meta.UID != "" && o.UID != meta.UID) {
+			continue
+		}
+		events = append(events, e)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(&events[i]).Before(eventTime(&events[j]))
+	})
+	return events, nil
+}
+
+// printEvents prints the given events as a table with their age at now
+func printEvents(out io.Writer, events []corev1.Event, now time.Time) error {
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "Events:")
+	if len(events) == 0 {
+		fmt.Fprintln(w, "  <none>")
+		return w.Flush()
+	}
+	fmt.Fprintln(w, "  LAST SEEN\tTYPE\tREASON\tFROM\tMESSAGE")
+	for i := range events {
+		e := &events[i]
+		lastSeen := age(now.Sub(eventTime(e)))
+		if e.Count > 1 {
+			lastSeen = fmt.Sprintf("%s (x%d)", lastSeen, e.Count)
+		}
+		from := e.Source.Component
+		if from == "" {
+			from = e.ReportingController
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", lastSeen, e.Type, e.Reason, orDash(from), strings.TrimSpace(e.Message))
+	}
+	return w.Flush()
+}
+
+// eventTime returns the time the given event was last seen
+func eventTime(e *corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
+
+// age formats the given duration in its largest unit like kubectl, e.g. 5m
+func age(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	vspherefake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+)
+
+func TestNewSourceDescribeCommand(t *testing.T) {
+	source := &v1alpha1.VSphereSource{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "spring", UID: "spring-uid"},
+		Spec: v1alpha1.VSphereSourceSpec{
+			VAuthSpec: v1alpha1.VAuthSpec{
+				Address:   apis.URL{Scheme: "https", Host: "vcenter.example.com"},
+				SecretRef: corev1.LocalObjectReference{Name: "street-creds"},
+			},
+		},
+	}
+	source.Status.Conditions = duckv1.Conditions{{
+		Type:    apis.ConditionReady,
+		Status:  corev1.ConditionFalse,
+		Reason:  "SecretNotFound",
+		Message: `secret "street-creds" not found`,
+	}}
+	k8sObjects := []runtime.Object{
+		describedEvent("spring-1", "VSphereSource", "spring", "spring-uid", "SecretNotFound", `secret "street-creds" not found`, 10*time.Minute, 3),
+		describedEvent("spring-2", "VSphereSource", "spring", "spring-uid", "InternalError", "failed to create deployment", time.Hour, 1),
+		// about a deleted source of the same name
+		describedEvent("spring-3", "VSphereSource", "spring", "old-uid", "DeploymentCreated", "created deployment", 24*time.Hour, 1),
+		describedEvent("summer-1", "VSphereSource", "summer", "summer-uid", "SecretNotFound", "summer event", time.Minute, 1),
+	}
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		describeCommand, _, err := sourceCommand.Find([]string{"describe"})
+		assert.NilError(t, err)
+
+		assert.Equal(t, describeCommand.Use, "describe")
+		assert.Check(t, len(describeCommand.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(describeCommand.Long) > 0,
+			"command should have a nonempty long description")
+		checkFlag(t, describeCommand, "namespace")
+		checkFlag(t, describeCommand, "name")
+		checkFlag(t, describeCommand, "events")
+		assert.Assert(t, describeCommand.RunE != nil)
+	})
+
+	t.Run("describes the source without events", func(t *testing.T) {
+		describeCommand, out := describeCommand(command.NewSourceCommand, []runtime.Object{source}, k8sObjects)
+		describeCommand.SetArgs([]string{"describe", "--namespace", "ns", "--name", "spring"})
+
+		err := describeCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, out.String(), `Name:        spring
+Namespace:   ns
+vCenter:     vcenter.example.com
+Secret:      street-creds
+Sink:        -
+Conditions:
+  TYPE    STATUS   REASON           MESSAGE
+  Ready   False    SecretNotFound   secret "street-creds" not found
+`)
+	})
+
+	t.Run("describes the events of the source", func(t *testing.T) {
+		describeCommand, out := describeCommand(command.NewSourceCommand, []runtime.Object{source}, k8sObjects)
+		describeCommand.SetArgs([]string{"describe", "--namespace", "ns", "--name", "spring", "-e"})
+
+		err := describeCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Check(t, bytes.HasSuffix(out.Bytes(), []byte(`Events:
+  LAST SEEN   TYPE      REASON           FROM                       MESSAGE
+  1h          Warning   InternalError    vspheresource-controller   failed to create deployment
+  10m (x3)    Warning   SecretNotFound   vspheresource-controller   secret "street-creds" not found
+`)), out.String())
+	})
+
+	t.Run("fails to execute with an empty name", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"describe"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "'name' requires a nonempty name provided with the --name option")
+	})
+
+	t.Run("fails to execute with a missing source", func(t *testing.T) {
+		describeCommand, _ := describeCommand(command.NewSourceCommand, nil, nil)
+		describeCommand.SetArgs([]string{"describe", "--name", "spring"})
+
+		err := describeCommand.Execute()
+
+		assert.ErrorContains(t, err, "failed to get source")
+	})
+}
+
+func TestNewBindingDescribeCommand(t *testing.T) {
+	binding := newBinding(t, "ns", "spring", "https://vcenter.example.com", "street-creds", "apps/v1", "Deployment", "app")
+
+	t.Run("describes the binding without events", func(t *testing.T) {
+		describeCommand, out := describeCommand(command.NewBindingCommand, []runtime.Object{binding}, nil)
+		describeCommand.SetArgs([]string{"describe", "--namespace", "ns", "--name", "spring", "--events"})
+
+		err := describeCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, out.String(), `Name:        spring
+Namespace:   ns
+vCenter:     vcenter.example.com
+Secret:      street-creds
+Subject:     apps/v1 Deployment app
+Conditions:
+  <none>
+Events:
+  <none>
+`)
+	})
+}
+
+func describeCommand(newCommand func(*pkg.Clients) *cobra.Command, vsphereObjects, k8sObjects []runtime.Object) (*cobra.Command, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	result := newCommand(&pkg.Clients{
+		ClientSet:        k8sfake.NewSimpleClientset(k8sObjects...),
+		ClientConfig:     regularClientConfig(),
+		VSphereClientSet: vspherefake.NewSimpleClientset(vsphereObjects...),
+	})
+	result.SetErr(ioutil.Discard)
+	result.SetOut(out)
+	return result, out
+}
+
+// describedEvent returns a warning event of the controller about the given
+// resource, last seen the given duration ago
+func describedEvent(name, kind, objectName, uid, reason, message string, ago time.Duration, count int32) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      kind,
+			Namespace: "ns",
+			Name:      objectName,
+			UID:       types.UID(uid),
+		},
+		Type:          corev1.EventTypeWarning,
+		Reason:        reason,
+		Message:       message,
+		Source:        corev1.EventSource{Component: "vspheresource-controller"},
+		LastTimestamp: metav1.NewTime(time.Now().Add(-ago)),
+		Count:         count,
+	}
+}
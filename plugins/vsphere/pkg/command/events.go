@@ -0,0 +1,148 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/logging"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/reconciler/vspheresource/resources"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+func NewEventsCommand(clients *pkg.Clients) *cobra.Command {
+	result := cobra.Command{
+		Use:   "events",
+		Short: "Inspect the events of vSphere sources",
+		Long:  "Inspect the events of vSphere sources",
+	}
+	result.AddCommand(NewEventsTailCommand(clients))
+	return &result
+}
+
+func NewEventsTailCommand(clients *pkg.Clients) *cobra.Command {
+	options := SourceOptions{}
+	result := cobra.Command{
+		Use:   "tail",
+		Short: "Stream the events a vSphere source would send",
+		Long: "Stream the events a vSphere source would send to its sink, as CloudEvents JSON one per line.\n" +
+			"The events are read from vCenter by the CLI, starting at the current vCenter time, with the filters of the source.",
+		Example: `# Stream the events of an existing source in the default namespace
+kn vsphere events tail --name source
+# Stream the alarm events of a prospective source in the specified namespace
+kn vsphere events tail --namespace ns --address https://my-vsphere-endpoint.local --secret-ref vsphere-credentials --event-type 'com.vmware.vsphere.Alarm*'
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if options.Name == "" && (options.Address == "" || options.SecretRef == "") {
+				return fmt.Errorf("'events tail' requires the name of an existing source provided with the --name option," +
+					"\nor the --address and --secret-ref options of a prospective source")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %+v", err)
+			}
+
+			src, err := options.tailedSource(cmd.Context(), clients, namespace)
+			if err != nil {
+				return err
+			}
+			if p := src.Spec.CredentialProvider; p != nil && p.Vault != nil {
+				return errors.New("sources with Vault credentials cannot be tailed, only sources with a secret")
+			}
+
+			secret, err := clients.ClientSet.CoreV1().Secrets(namespace).Get(cmd.Context(), src.Spec.SecretRef.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get secret: %+v", err)
+			}
+
+			// the same configuration as of the adapter of the source, except
+			// for the TLS restrictions of the cluster
+			config, err := resources.MakeSourceConfig(cmd.Context(), src, vsphere.TLSConfig{})
+			if err != nil {
+				return fmt.Errorf("failed to configure source: %+v", err)
+			}
+
+			// only problems are logged, the events are the output
+			logger := zap.New(zapcore.NewCore(
+				zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()),
+				zapcore.AddSync(cmd.ErrOrStderr()),
+				zap.WarnLevel,
+			))
+			ctx := logging.WithLogger(cmd.Context(), logger.Sugar())
+
+			out := cmd.OutOrStdout()
+			err = vsphere.TailEvents(ctx, *config,
+				string(secret.Data[corev1.BasicAuthUsernameKey]), string(secret.Data[corev1.BasicAuthPasswordKey]),
+				func(ev cloudevents.Event) error {
+					b, err := json.Marshal(ev)
+					if err != nil {
+						return err
+					}
+					_, err = fmt.Fprintln(out, string(b))
+					return err
+				})
+			if err != nil && ctx.Err() == nil {
+				return fmt.Errorf("failed to read events: %+v", err)
+			}
+			return nil
+		},
+	}
+	flags := result.Flags()
+	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the source (default namespace if omitted)")
+	flags.StringVar(&options.Name, "name", "", "name of an existing source to tail")
+	flags.StringVarP(&options.Address, "address", "a", "", "URL of ESXi or vCenter instance of a prospective source")
+	flags.BoolVarP(&options.SkipTLSVerify, "skip-tls-verify", "k", false, "disables certificate verification for the source address")
+	flags.StringVarP(&options.SecretRef, "secret-ref", "s", "", "reference to the Kubernetes secret for the vSphere credentials of a prospective source")
+	flags.BoolVar(&options.IncludeTasks, "include-tasks", false, "also stream events for vSphere task lifecycle changes")
+	flags.BoolVar(&options.IncludeContentLibrary, "include-content-library", false,
+		"also stream events for content library and library item changes")
+	flags.BoolVar(&options.IncludeTags, "include-tags", false,
+		"also stream events when tags are attached to or detached from objects")
+	flags.BoolVar(&options.NormalizeAlarms, "normalize-alarms", false,
+		"stream alarm events with a type per alarm event class and a normalized JSON payload")
+	flags.BoolVar(&options.GroupVSANEvents, "group-vsan-events", false,
+		"stream vSAN events with the common type prefix com.vmware.vsphere.vsan.")
+	flags.StringSliceVar(&options.EventTypes, "event-type", nil,
+		"only stream events with a type matching one of these glob patterns, e.g. com.vmware.vsphere.Alarm* (optional)")
+	return &result
+}
+
+// tailedSource returns the existing source, or the prospective source of the
+// flags if an address is given.
+func (so *SourceOptions) tailedSource(ctx context.Context, clients *pkg.Clients, namespace string) (*v1alpha1.VSphereSource, error) {
+	if so.Address == "" {
+		src, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace).Get(ctx, so.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get source: %+v", err)
+		}
+		return src, nil
+	}
+
+	address, err := url.Parse(so.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source address: %+v", err)
+	}
+	src := newSource(namespace, &duckv1.Destination{}, address, nil, *so)
+	src.SetDefaults(ctx)
+	return src, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	vspherefake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+)
+
+func TestNewEventsTailCommand(t *testing.T) {
+	const secretName = "vsphere-credentials"
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		tailCommand := tailCommand()
+
+		assert.Equal(t, tailCommand.Use, "tail")
+		assert.Check(t, len(tailCommand.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(tailCommand.Long) > 0,
+			"command should have a nonempty long description")
+		checkFlag(t, tailCommand, "namespace")
+		checkFlag(t, tailCommand, "name")
+		checkFlag(t, tailCommand, "address")
+		checkFlag(t, tailCommand, "secret-ref")
+		checkFlag(t, tailCommand, "event-type")
+		assert.Assert(t, tailCommand.RunE != nil)
+	})
+
+	t.Run("fails to execute without a name or an address", func(t *testing.T) {
+		tailCommand := tailCommand()
+		tailCommand.SetArgs([]string{"--address", "https://my-vsphere-endpoint.example.com"})
+
+		err := tailCommand.Execute()
+
+		assert.ErrorContains(t, err, "requires the name of an existing source")
+	})
+
+	t.Run("fails to execute when the secret does not exist", func(t *testing.T) {
+		tailCommand := tailCommand()
+		tailCommand.SetArgs([]string{
+			"--address", "https://my-vsphere-endpoint.example.com",
+			"--secret-ref", secretName,
+		})
+
+		err := tailCommand.Execute()
+
+		assert.ErrorContains(t, err, "failed to get secret")
+	})
+
+	t.Run("fails to execute for a source with Vault credentials", func(t *testing.T) {
+		src := newCheckedSource("https://my-vsphere-endpoint.example.com", secretName)
+		src.Spec.CredentialProvider = &v1alpha1.VCredentialProviderSpec{Vault: &v1alpha1.VVaultSpec{}}
+		tailCommand := tailCommand(src)
+		tailCommand.SetArgs([]string{"--name", "source"})
+
+		err := tailCommand.Execute()
+
+		assert.ErrorContains(t, err, "cannot be tailed")
+	})
+
+	t.Run("streams the events of an existing source until stopped", func(t *testing.T) {
+		simulator.Run(func(ctx context.Context, vc *vim25.Client) error {
+			tailCommand := tailCommand(newCredentials(secretName), newCheckedSource(vc.URL().String(), secretName))
+			tailCommand.SetArgs([]string{"--name", "source"})
+
+			ctx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			err := tailCommand.ExecuteContext(ctx)
+
+			assert.NilError(t, err)
+			return nil
+		})
+	})
+}
+
+func tailCommand(objects ...runtime.Object) *cobra.Command {
+	var k8sObjects, vsphereObjects []runtime.Object
+	for _, o := range objects {
+		if _, ok := o.(*v1alpha1.VSphereSource); ok {
+			vsphereObjects = append(vsphereObjects, o)
+		} else {
+			k8sObjects = append(k8sObjects, o)
+		}
+	}
+
+	tailCommand := command.NewEventsTailCommand(&pkg.Clients{
+		ClientSet:        k8sfake.NewSimpleClientset(k8sObjects...),
+		ClientConfig:     regularClientConfig(),
+		VSphereClientSet: vspherefake.NewSimpleClientset(vsphereObjects...),
+	})
+	tailCommand.SetErr(ioutil.Discard)
+	tailCommand.SetOut(ioutil.Discard)
+	return tailCommand
+}
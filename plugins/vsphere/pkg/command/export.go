@@ -0,0 +1,133 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+// exportedMetadata are the metadata fields kept in exported resources, the
+// others are set by the cluster
+var exportedMetadata = map[string]bool{
+	"name":        true,
+	"namespace":   true,
+	"labels":      true,
+	"annotations": true,
+}
+
+// lastAppliedAnnotation is set by kubectl apply and set again when the
+// exported resources are applied
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+type ExportOptions struct {
+	Namespace string
+
+	OutputOptions
+}
+
+func NewExportCommand(clients *pkg.Clients) *cobra.Command {
+	options := ExportOptions{}
+	result := cobra.Command{
+		Use:   "export",
+		Short: "Export the vSphere sources and bindings of a namespace as YAML",
+		Long: "Export the vSphere sources and bindings of a namespace as YAML which can be applied again, e.g. to another cluster.\n" +
+			"The status and the metadata set by the cluster are not exported, nor the bindings the sources create themselves.",
+		Example: `# Export the sources and bindings of the default namespace
+kn vsphere export > vsphere.yaml
+# Export the sources and bindings of the specified namespace and apply them to another cluster
+kn vsphere export --namespace ns | kubectl --context other apply -f -
+# List the names of the sources and bindings of the default namespace
+kn vsphere export -o name
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return options.validateOutput()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %+v", err)
+			}
+
+			sources, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace).List(cmd.Context(), metav1.ListOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to list sources: %+v", err)
+			}
+			bindings, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereBindings(namespace).List(cmd.Context(), metav1.ListOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to list bindings: %+v", err)
+			}
+
+			var objects []runtime.Object
+			for i := range sources.Items {
+				src := &sources.Items[i]
+				src.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("VSphereSource"))
+				objects = append(objects, src)
+			}
+			for i := range bindings.Items {
+				binding := &bindings.Items[i]
+				// the bindings of the adapters are created by the sources
+				if metav1.GetControllerOf(binding) != nil {
+					continue
+				}
+				binding.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("VSphereBinding"))
+				objects = append(objects, binding)
+			}
+
+			exported, err := exportObjects(objects)
+			if err != nil {
+				return err
+			}
+			return options.printObjects(cmd.OutOrStdout(), exported)
+		},
+	}
+	flags := result.Flags()
+	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the sources and bindings to export (default namespace if omitted)")
+	options.addOutputFlag(&result, outputYAML, outputYAML, outputJSON, outputName)
+	return &result
+}
+
+// exportObjects returns the given objects without their status and the
+// metadata set by the cluster.
+func exportObjects(objects []runtime.Object) ([]runtime.Object, error) {
+	result := make([]runtime.Object, 0, len(objects))
+	for _, obj := range objects {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert resource: %+v", err)
+		}
+		cleanExported(u)
+		result = append(result, &unstructured.Unstructured{Object: u})
+	}
+	return result, nil
+}
+
+// cleanExported removes the status and the metadata set by the cluster from
+// the given unstructured resource.
+func cleanExported(u map[string]interface{}) {
+	delete(u, "status")
+
+	metadata, _, _ := unstructured.NestedMap(u, "metadata")
+	for k := range metadata {
+		if !exportedMetadata[k] {
+			delete(metadata, k)
+		}
+	}
+	if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+		delete(annotations, lastAppliedAnnotation)
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+	u["metadata"] = metadata
+}
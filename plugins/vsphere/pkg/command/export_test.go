@@ -0,0 +1,160 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/kmeta"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	vspherefake "github.com/vmware-tanzu/sources-for-knative/pkg/client/clientset/versioned/fake"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+)
+
+func TestNewExportCommand(t *testing.T) {
+	t.Run("defines basic metadata", func(t *testing.T) {
+		exportCommand, _ := exportCommand()
+
+		assert.Equal(t, exportCommand.Use, "export")
+		assert.Check(t, len(exportCommand.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(exportCommand.Long) > 0,
+			"command should have a nonempty long description")
+		checkFlag(t, exportCommand, "namespace")
+		checkFlag(t, exportCommand, "output")
+		assert.Assert(t, exportCommand.RunE != nil)
+	})
+
+	t.Run("exports nothing without sources and bindings", func(t *testing.T) {
+		exportCommand, out := exportCommand()
+
+		err := exportCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, out.String(), "")
+	})
+
+	t.Run("exports sources and bindings without cluster state", func(t *testing.T) {
+		source := newCheckedSource("https://my-vsphere-endpoint.example.com", "vsphere-credentials")
+		source.UID = "source-uid"
+		source.ResourceVersion = "42"
+		source.Annotations = map[string]string{
+			"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			"team": "a",
+		}
+		source.Status.SinkURI = apis.HTTP("where.to.send.stuff")
+
+		binding := &v1alpha1.VSphereBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  defaultNamespace,
+				Name:       "binding",
+				UID:        "binding-uid",
+				Generation: 3,
+			},
+		}
+		adapterBinding := &v1alpha1.VSphereBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       defaultNamespace,
+				Name:            "source-vspherebinding",
+				OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(source)},
+			},
+		}
+		otherNamespace := &v1alpha1.VSphereBinding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "other"},
+		}
+		exportCommand, out := exportCommand(source, binding, adapterBinding, otherNamespace)
+
+		err := exportCommand.Execute()
+
+		assert.NilError(t, err)
+		docs := strings.Split(out.String(), "---\n")
+		assert.Equal(t, len(docs), 2, out.String())
+		for _, unwanted := range []string{"status", "uid", "resourceVersion", "generation", "creationTimestamp", "last-applied-configuration"} {
+			assert.Check(t, !strings.Contains(out.String(), unwanted+":"), "exported %s:\n%s", unwanted, out.String())
+		}
+
+		var gotSource v1alpha1.VSphereSource
+		assert.NilError(t, yaml.Unmarshal([]byte(docs[0]), &gotSource))
+		assert.Equal(t, gotSource.APIVersion, "sources.tanzu.vmware.com/v1alpha1")
+		assert.Equal(t, gotSource.Kind, "VSphereSource")
+		assert.Equal(t, gotSource.Name, "source")
+		assert.DeepEqual(t, gotSource.Annotations, map[string]string{"team": "a"})
+		assert.DeepEqual(t, gotSource.Spec, source.Spec)
+
+		var gotBinding v1alpha1.VSphereBinding
+		assert.NilError(t, yaml.Unmarshal([]byte(docs[1]), &gotBinding))
+		assert.Equal(t, gotBinding.Kind, "VSphereBinding")
+		assert.Equal(t, gotBinding.Name, "binding")
+	})
+
+	t.Run("exports sources and bindings as a JSON list", func(t *testing.T) {
+		source := newCheckedSource("https://my-vsphere-endpoint.example.com", "vsphere-credentials")
+		binding := &v1alpha1.VSphereBinding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: defaultNamespace, Name: "binding"},
+		}
+		exportCommand, out := exportCommand(source, binding)
+		exportCommand.SetArgs([]string{"-o", "json"})
+
+		err := exportCommand.Execute()
+
+		assert.NilError(t, err)
+		var list unstructured.UnstructuredList
+		assert.NilError(t, list.UnmarshalJSON(out.Bytes()), out.String())
+		assert.Equal(t, len(list.Items), 2)
+		assert.Equal(t, list.Items[0].GetKind(), "VSphereSource")
+		assert.Equal(t, list.Items[1].GetKind(), "VSphereBinding")
+	})
+
+	t.Run("exports an empty JSON list without sources and bindings", func(t *testing.T) {
+		exportCommand, out := exportCommand()
+		exportCommand.SetArgs([]string{"-o", "json"})
+
+		err := exportCommand.Execute()
+
+		assert.NilError(t, err)
+		var list unstructured.UnstructuredList
+		assert.NilError(t, list.UnmarshalJSON(out.Bytes()), out.String())
+		assert.Equal(t, len(list.Items), 0)
+	})
+
+	t.Run("exports the names of sources and bindings", func(t *testing.T) {
+		source := newCheckedSource("https://my-vsphere-endpoint.example.com", "vsphere-credentials")
+		binding := &v1alpha1.VSphereBinding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: defaultNamespace, Name: "binding"},
+		}
+		exportCommand, out := exportCommand(source, binding)
+		exportCommand.SetArgs([]string{"-o", "name"})
+
+		err := exportCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, out.String(), "vspheresource.sources.tanzu.vmware.com/source\n"+
+			"vspherebinding.sources.tanzu.vmware.com/binding\n")
+	})
+}
+
+func exportCommand(objects ...runtime.Object) (*cobra.Command, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	exportCommand := command.NewExportCommand(&pkg.Clients{
+		ClientConfig:     regularClientConfig(),
+		VSphereClientSet: vspherefake.NewSimpleClientset(objects...),
+	})
+	exportCommand.SetErr(ioutil.Discard)
+	exportCommand.SetOut(out)
+	return exportCommand, out
+}
@@ -5,6 +5,14 @@ SPDX-License-Identifier: Apache-2.0
 
 package command
 
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
 func MutuallyExclusiveStringFlags(value1, value2 string, rest ...string) bool {
 	nonEmpty := 0
 	incrementIfNonEmpty(value1, &nonEmpty)
@@ -26,3 +34,58 @@ func incrementIfNonEmpty(value string, nonEmpty *int) {
 		*nonEmpty++
 	}
 }
+
+// ParseKeyValues parses the key=value pairs of a repeatable flag, e.g.
+// --label, the last value of a key wins.
+func ParseKeyValues(flag string, values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string, len(values))
+	for _, kv := range values {
+		i := strings.Index(kv, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("--%s requires a key=value pair, was %q", flag, kv)
+		}
+		key := kv[:i]
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("--%s has an invalid key %q: %s", flag, key, strings.Join(errs, "; "))
+		}
+		result[key] = kv[i+1:]
+	}
+	return result, nil
+}
+
+// applyMetadata sets the labels and annotations of the --label and
+// --annotation flags on the given metadata, overriding existing keys.
+func applyMetadata(meta *metav1.ObjectMeta, labels, annotations []string) error {
+	parsedLabels, err := ParseKeyValues("label", labels)
+	if err != nil {
+		return err
+	}
+	for key, value := range parsedLabels {
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("--label has an invalid value %q for %q: %s", value, key, strings.Join(errs, "; "))
+		}
+	}
+	parsedAnnotations, err := ParseKeyValues("annotation", annotations)
+	if err != nil {
+		return err
+	}
+	meta.Labels = mergeKeyValues(meta.Labels, parsedLabels)
+	meta.Annotations = mergeKeyValues(meta.Annotations, parsedAnnotations)
+	return nil
+}
+
+func mergeKeyValues(existing, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return existing
+	}
+	if existing == nil {
+		existing = make(map[string]string, len(overrides))
+	}
+	for k, v := range overrides {
+		existing[k] = v
+	}
+	return existing
+}
@@ -28,3 +28,42 @@ func TestMutuallyExclusiveStringFlags(t *testing.T) {
 		assert.Check(t, !command.MutuallyExclusiveStringFlags("set", "set", "set"))
 	})
 }
+
+func TestParseKeyValues(t *testing.T) {
+
+	t.Run("parses key=value pairs", func(t *testing.T) {
+		result, err := command.ParseKeyValues("label", []string{"team=a", "example.com/cost-center=", "team=b", "url=http://x?y=z"})
+
+		assert.NilError(t, err)
+		assert.DeepEqual(t, result, map[string]string{
+			"team":                    "b",
+			"example.com/cost-center": "",
+			"url":                     "http://x?y=z",
+		})
+	})
+
+	t.Run("parses no values", func(t *testing.T) {
+		result, err := command.ParseKeyValues("label", nil)
+
+		assert.NilError(t, err)
+		assert.Check(t, result == nil)
+	})
+
+	t.Run("fails without a key", func(t *testing.T) {
+		_, err := command.ParseKeyValues("label", []string{"=a"})
+
+		assert.ErrorContains(t, err, "--label requires a key=value pair")
+	})
+
+	t.Run("fails without a value", func(t *testing.T) {
+		_, err := command.ParseKeyValues("annotation", []string{"team"})
+
+		assert.ErrorContains(t, err, "--annotation requires a key=value pair")
+	})
+
+	t.Run("fails with an invalid key", func(t *testing.T) {
+		_, err := command.ParseKeyValues("label", []string{"team a=b"})
+
+		assert.ErrorContains(t, err, `--label has an invalid key "team a"`)
+	})
+}
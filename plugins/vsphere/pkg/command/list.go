@@ -0,0 +1,223 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+// defaultOwnerLabel is the label holding the team owning a resource, as set
+// in the examples of source and binding
+const defaultOwnerLabel = "team"
+
+type ListOptions struct {
+	Namespace     string
+	AllNamespaces bool
+	OwnerLabel    string
+
+	OutputOptions
+}
+
+// listRow is a line of the table printed by the list commands
+type listRow struct {
+	namespace string
+	name      string
+	owner     string
+	vCenter   string
+	ready     string
+}
+
+func NewSourceListCommand(clients *pkg.Clients) *cobra.Command {
+	options := ListOptions{}
+	result := cobra.Command{
+		Use:   "list",
+		Short: "List the vSphere sources of a namespace or of all namespaces",
+		Long: "List the vSphere sources of a namespace or of all namespaces, with their owner, vCenter and readiness.\n" +
+			"The owner is the value of the owner label of the source, team by default.",
+		Example: `# List the sources of the default namespace
+kn vsphere source list
+# List the sources of all namespaces, e.g. for an inventory of the teams talking to each vCenter
+kn vsphere source list --all-namespaces
+# List the sources of all namespaces, owned as per the specified label
+kn vsphere source list -A --owner-label app.kubernetes.io/part-of
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return options.validate()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := options.listNamespace(clients)
+			if err != nil {
+				return err
+			}
+			sources, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace).List(cmd.Context(), metav1.ListOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to list sources: %+v", err)
+			}
+			sort.Slice(sources.Items, func(i, j int) bool {
+				return lessObject(&sources.Items[i].ObjectMeta, &sources.Items[j].ObjectMeta)
+			})
+
+			objects := make([]runtime.Object, 0, len(sources.Items))
+			rows := make([]listRow, 0, len(sources.Items))
+			for i := range sources.Items {
+				source := &sources.Items[i]
+				source.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("VSphereSource"))
+				objects = append(objects, source)
+
+				hosts := []string{source.Spec.Address.Host}
+				for _, a := range source.Spec.Addresses {
+					hosts = append(hosts, a.Address.Host)
+				}
+				rows = append(rows, options.row(&source.ObjectMeta, hosts, &source.Status.Status))
+			}
+			if options.Output != "" {
+				return options.printObjects(cmd.OutOrStdout(), objects)
+			}
+			return options.printRows(cmd.OutOrStdout(), rows, "sources")
+		},
+	}
+	options.addFlags(&result, "sources")
+	return &result
+}
+
+func NewBindingListCommand(clients *pkg.Clients) *cobra.Command {
+	options := ListOptions{}
+	result := cobra.Command{
+		Use:   "list",
+		Short: "List the vSphere bindings of a namespace or of all namespaces",
+		Long: "List the vSphere bindings of a namespace or of all namespaces, with their owner, vCenter and readiness.\n" +
+			"The owner is the value of the owner label of the binding, team by default.",
+		Example: `# List the bindings of the default namespace
+kn vsphere binding list
+# List the bindings of all namespaces, e.g. for an inventory of the teams talking to each vCenter
+kn vsphere binding list --all-namespaces
+# List the names of the bindings of the specified namespace
+kn vsphere binding list --namespace ns -o name
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return options.validate()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := options.listNamespace(clients)
+			if err != nil {
+				return err
+			}
+			bindings, err := clients.VSphereClientSet.SourcesV1alpha1().VSphereBindings(namespace).List(cmd.Context(), metav1.ListOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to list bindings: %+v", err)
+			}
+			sort.Slice(bindings.Items, func(i, j int) bool {
+				return lessObject(&bindings.Items[i].ObjectMeta, &bindings.Items[j].ObjectMeta)
+			})
+
+			objects := make([]runtime.Object, 0, len(bindings.Items))
+			rows := make([]listRow, 0, len(bindings.Items))
+			for i := range bindings.Items {
+				binding := &bindings.Items[i]
+				binding.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("VSphereBinding"))
+				objects = append(objects, binding)
+				rows = append(rows, options.row(&binding.ObjectMeta, []string{binding.Spec.Address.Host}, &binding.Status.Status))
+			}
+			if options.Output != "" {
+				return options.printObjects(cmd.OutOrStdout(), objects)
+			}
+			return options.printRows(cmd.OutOrStdout(), rows, "bindings")
+		},
+	}
+	options.addFlags(&result, "bindings")
+	return &result
+}
+
+func (lo *ListOptions) addFlags(cmd *cobra.Command, resources string) {
+	flags := cmd.Flags()
+	flags.StringVarP(&lo.Namespace, "namespace", "n", "", fmt.Sprintf("namespace of the %s to list (default namespace if omitted)", resources))
+	flags.BoolVarP(&lo.AllNamespaces, "all-namespaces", "A", false, fmt.Sprintf("list the %s of all namespaces", resources))
+	flags.StringVar(&lo.OwnerLabel, "owner-label", defaultOwnerLabel, fmt.Sprintf("label of the %s holding their owner", resources))
+	// the default output is a table
+	lo.addOutputFlag(cmd, "", outputJSON, outputYAML, outputName)
+}
+
+func (lo *ListOptions) validate() error {
+	if err := lo.validateOutput(); err != nil {
+		return err
+	}
+	if lo.AllNamespaces && lo.Namespace != "" {
+		return fmt.Errorf("--namespace cannot be combined with --all-namespaces")
+	}
+	return nil
+}
+
+// listNamespace returns the namespace to list, empty for all namespaces
+func (lo *ListOptions) listNamespace(clients *pkg.Clients) (string, error) {
+	if lo.AllNamespaces {
+		return metav1.NamespaceAll, nil
+	}
+	namespace, err := clients.GetExplicitOrDefaultNamespace(lo.Namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to get namespace: %+v", err)
+	}
+	return namespace, nil
+}
+
+func (lo *ListOptions) row(meta *metav1.ObjectMeta, hosts []string, status *duckv1.Status) listRow {
+	row := listRow{
+		namespace: meta.Namespace,
+		name:      meta.Name,
+		owner:     "-",
+		vCenter:   strings.Join(hosts, ","),
+		ready:     string(corev1.ConditionUnknown),
+	}
+	if owner := meta.Labels[lo.OwnerLabel]; owner != "" {
+		row.owner = owner
+	}
+	if c := status.GetCondition(apis.ConditionReady); c != nil {
+		row.ready = string(c.Status)
+	}
+	return row
+}
+
+// printRows prints the given rows as a table, with their namespace when
+// listing all namespaces
+func (lo *ListOptions) printRows(out io.Writer, rows []listRow, resources string) error {
+	if len(rows) == 0 {
+		_, err := fmt.Fprintf(out, "No %s found\n", resources)
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	if lo.AllNamespaces {
+		fmt.Fprint(w, "NAMESPACE\t")
+	}
+	fmt.Fprintln(w, "NAME\tOWNER\tVCENTER\tREADY")
+	for _, r := range rows {
+		if lo.AllNamespaces {
+			fmt.Fprintf(w, "%s\t", r.namespace)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.name, r.owner, r.vCenter, r.ready)
+	}
+	return w.Flush()
+}
+
+// lessObject orders resources by namespace and name like the API server
+func lessObject(a, b *metav1.ObjectMeta) bool {
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+)
+
+func TestNewSourceListCommand(t *testing.T) {
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		listCommand, _, err := sourceCommand.Find([]string{"list"})
+		assert.NilError(t, err)
+
+		assert.Equal(t, listCommand.Use, "list")
+		assert.Check(t, len(listCommand.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(listCommand.Long) > 0,
+			"command should have a nonempty long description")
+		checkFlag(t, listCommand, "namespace")
+		checkFlag(t, listCommand, "all-namespaces")
+		checkFlag(t, listCommand, "owner-label")
+		checkFlag(t, listCommand, "output")
+		assert.Assert(t, listCommand.RunE != nil)
+	})
+
+	t.Run("lists the sources of the default namespace", func(t *testing.T) {
+		ready := newSource(t, defaultNamespace, "src", "https://sink.example.com", "creds", "https://vcenter.example.com").(*v1alpha1.VSphereSource)
+		ready.Labels = map[string]string{"team": "infra"}
+		ready.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionTrue}}
+		sourceCommand, _ := sourceCommand(regularClientConfig(),
+			ready,
+			newSource(t, "other", "other-src", "https://sink.example.com", "creds", "https://other-vcenter.example.com"))
+		output := bytes.Buffer{}
+		sourceCommand.SetOut(&output)
+		sourceCommand.SetArgs([]string{"list"})
+
+		err := sourceCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, output.String(), ""+
+			"NAME   OWNER   VCENTER               READY\n"+
+			"src    infra   vcenter.example.com   True\n")
+	})
+
+	t.Run("lists the sources of all namespaces with their additional vCenters", func(t *testing.T) {
+		source := newSource(t, "ns", "src", "https://sink.example.com", "creds", "https://vcenter.example.com").(*v1alpha1.VSphereSource)
+		source.Labels = map[string]string{"app.kubernetes.io/part-of": "monitoring"}
+		source.Spec.Addresses = []v1alpha1.VAddressSpec{{Address: parseURI(t, "https://other-vcenter.example.com")}}
+		sourceCommand, _ := sourceCommand(regularClientConfig(),
+			source,
+			newSource(t, "another-ns", "src", "https://sink.example.com", "creds", "https://vcenter.example.com"))
+		output := bytes.Buffer{}
+		sourceCommand.SetOut(&output)
+		sourceCommand.SetArgs([]string{"list", "-A", "--owner-label", "app.kubernetes.io/part-of"})
+
+		err := sourceCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, output.String(), ""+
+			"NAMESPACE    NAME   OWNER        VCENTER                                         READY\n"+
+			"another-ns   src    -            vcenter.example.com                             Unknown\n"+
+			"ns           src    monitoring   vcenter.example.com,other-vcenter.example.com   Unknown\n")
+	})
+
+	t.Run("lists the names of the sources of all namespaces", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig(),
+			newSource(t, "ns", "src", "https://sink.example.com", "creds", "https://vcenter.example.com"),
+			newSource(t, "another-ns", "other-src", "https://sink.example.com", "creds", "https://vcenter.example.com"))
+		output := bytes.Buffer{}
+		sourceCommand.SetOut(&output)
+		sourceCommand.SetArgs([]string{"list", "--all-namespaces", "-o", "name"})
+
+		err := sourceCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, output.String(), ""+
+			"vspheresource.sources.tanzu.vmware.com/other-src\n"+
+			"vspheresource.sources.tanzu.vmware.com/src\n")
+	})
+
+	t.Run("reports when there is no source", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		output := bytes.Buffer{}
+		sourceCommand.SetOut(&output)
+		sourceCommand.SetArgs([]string{"list", "--namespace", "ns"})
+
+		err := sourceCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, output.String(), "No sources found\n")
+	})
+
+	t.Run("fails to execute with both namespace and all namespaces", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"list", "--namespace", "ns", "--all-namespaces"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "--namespace cannot be combined with --all-namespaces")
+	})
+}
+
+func TestNewBindingListCommand(t *testing.T) {
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig())
+		listCommand, _, err := bindingCommand.Find([]string{"list"})
+		assert.NilError(t, err)
+
+		assert.Equal(t, listCommand.Use, "list")
+		assert.Check(t, len(listCommand.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(listCommand.Long) > 0,
+			"command should have a nonempty long description")
+		checkFlag(t, listCommand, "namespace")
+		checkFlag(t, listCommand, "all-namespaces")
+		checkFlag(t, listCommand, "owner-label")
+		checkFlag(t, listCommand, "output")
+		assert.Assert(t, listCommand.RunE != nil)
+	})
+
+	t.Run("lists the bindings of all namespaces", func(t *testing.T) {
+		binding := newBinding(t, "ns", "binding", "https://vcenter.example.com", "creds", "apps/v1", "Deployment", "my-simple-app").(*v1alpha1.VSphereBinding)
+		binding.Labels = map[string]string{"team": "infra"}
+		binding.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionFalse}}
+		bindingCommand, _ := bindingCommand(regularClientConfig(),
+			binding,
+			newBinding(t, defaultNamespace, "other-binding", "https://other-vcenter.example.com", "creds", "apps/v1", "Deployment", "my-simple-app"))
+		output := bytes.Buffer{}
+		bindingCommand.SetOut(&output)
+		bindingCommand.SetArgs([]string{"list", "--all-namespaces"})
+
+		err := bindingCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, output.String(), ""+
+			"NAMESPACE           NAME            OWNER   VCENTER                     READY\n"+
+			"configuredDefault   other-binding   -       other-vcenter.example.com   Unknown\n"+
+			"ns                  binding         infra   vcenter.example.com         False\n")
+	})
+
+	t.Run("lists the bindings of the specified namespace as JSON", func(t *testing.T) {
+		bindingCommand, _ := bindingCommand(regularClientConfig(),
+			newBinding(t, "ns", "binding", "https://vcenter.example.com", "creds", "apps/v1", "Deployment", "my-simple-app"))
+		output := bytes.Buffer{}
+		bindingCommand.SetOut(&output)
+		bindingCommand.SetArgs([]string{"list", "--namespace", "ns", "-o", "json"})
+
+		err := bindingCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Check(t, bytes.Contains(output.Bytes(), []byte(`"kind": "List"`)))
+		assert.Check(t, bytes.Contains(output.Bytes(), []byte(`"kind": "VSphereBinding"`)))
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/url"
+	"strings"
 	"syscall"
 
 	"github.com/vmware/govmomi"
@@ -29,8 +30,11 @@ type LoginOptions struct {
 	Password      string
 	SecretName    string
 	PasswordStdIn bool
+	PasswordFile  string
 	VerifyURL     string
 	Insecure      bool
+
+	OutputOptions
 }
 
 func NewLoginCommand(clients *pkg.Clients) *cobra.Command {
@@ -48,8 +52,16 @@ kn vsphere login --username jane-doe --password s3cr3t --secret-name vsphere-cre
 kn vsphere login --namespace ns --username john-doe --password s3cr3t --secret-name vsphere-credentials
 # Create login credentials in the specified namespace with the password retrieved via standard input
 kn vsphere login --namespace ns --username john-doe --password-stdin --secret-name vsphere-credentials
+# Create login credentials in the default namespace with the password read from a file
+kn vsphere login --username jane-doe --password-file ./vc-password --secret-name vsphere-credentials
+# Create login credentials in the default namespace with the password prompted for on the terminal
+kn vsphere login --username jane-doe --secret-name vsphere-credentials
 `,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validateOutput(); err != nil {
+				return err
+			}
+
 			username := options.Username
 			if username == "" {
 				return fmt.Errorf("'login' requires a nonempty username provided with the --username option")
@@ -57,12 +69,13 @@ kn vsphere login --namespace ns --username john-doe --password-stdin --secret-na
 
 			password := options.Password
 			passwordViaStdIn := options.PasswordStdIn
-			if password == "" && !passwordViaStdIn {
-				return fmt.Errorf("'password' requires a nonempty password provided with the --password option or prompted later via the --password-std-in option")
+			// the password is prompted for when none is set on a terminal
+			if password == "" && !passwordViaStdIn && options.PasswordFile == "" && !stdinIsTerminal() {
+				return fmt.Errorf("'password' requires a nonempty password provided with the --password option or prompted later via the --password-std-in option, or read from the file of the --password-file option")
 			}
 
-			if password != "" && passwordViaStdIn {
-				return fmt.Errorf("either set an explicit password with the --password option or set the --password-stdin option to get prompted for one, do not set both")
+			if err := validatePasswordSource(password, passwordViaStdIn, options.PasswordFile); err != nil {
+				return err
 			}
 
 			secretName := options.SecretName
@@ -78,33 +91,26 @@ kn vsphere login --namespace ns --username john-doe --password-stdin --secret-na
 				return fmt.Errorf("failed to get namespace: %+v", err)
 			}
 
-			password, err := readPassword(cmd, options)
+			password, err := readPassword(cmd, options.Password, options.PasswordFile)
 			if err != nil {
 				return fmt.Errorf("failed to get password: %+v", err)
 			}
 
-			vcURL := options.VerifyURL
-			if vcURL != "" {
+			if options.VerifyURL != "" {
 				// validate credentials before creating secret
-				parsedURL, err := soap.ParseURL(vcURL)
-				if err != nil {
-					return fmt.Errorf("failed to parse vCenter URL: %+v", err)
-				}
-
-				parsedURL.User = url.UserPassword(options.Username, options.Password)
-				_, err = govmomi.NewClient(context.TODO(), parsedURL, options.Insecure)
-				if err != nil {
-					return fmt.Errorf("failed to authenticate with vCenter: %+v", err)
+				if err := verifyCredentials(cmd.Context(), options.VerifyURL, options.Username, password, options.Insecure); err != nil {
+					return err
 				}
 			}
 
 			credentials := newSecret(namespace, password, options)
-			if _, err := clients.ClientSet.CoreV1().Secrets(namespace).Create(cmd.Context(), credentials, metav1.CreateOptions{}); err != nil {
+			created, err := clients.ClientSet.CoreV1().Secrets(namespace).Create(cmd.Context(), credentials, metav1.CreateOptions{})
+			if err != nil {
 				return fmt.Errorf("failed to create Secret: %+v", err)
 			}
 
-			fmt.Fprintln(cmd.OutOrStdout(), "Created vSphere credentials")
-			return nil
+			created.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+			return options.printObject(cmd.OutOrStdout(), created, "Created vSphere credentials")
 		},
 	}
 
@@ -114,10 +120,14 @@ kn vsphere login --namespace ns --username john-doe --password-stdin --secret-na
 	_ = result.MarkFlagRequired("username")
 	flags.StringVarP(&options.Password, "password", "p", "", "password (same as VC_PASSWORD)")
 	flags.BoolVarP(&options.PasswordStdIn, "password-stdin", "i", false, "read password from standard input")
+	flags.StringVar(&options.PasswordFile, "password-file", "", "read password from the given file")
 	flags.StringVarP(&options.SecretName, "secret-name", "s", "", "name of the Secret created for the credentials")
 	flags.StringVar(&options.VerifyURL, "verify-url", "", "vCenter URL to verify specified credentials (optional)")
 	flags.BoolVar(&options.Insecure, "verify-insecure", false, "Ignore certificate errors during credential verification")
 	_ = result.MarkFlagRequired("secret-name")
+	// the secret is not printed as JSON or YAML, which would reveal the password
+	options.addOutputFlag(result, "", outputName)
+	options.addQuietFlag(result)
 	return result
 }
 
@@ -135,22 +145,77 @@ func newSecret(namespace string, password string, options *LoginOptions) *corev1
 	}
 }
 
-func readPassword(cmd *cobra.Command, options *LoginOptions) (string, error) {
-	if !options.PasswordStdIn {
-		return options.Password, nil
+// verifyCredentials logs in to the vCenter at the given URL with the given
+// credentials, and logs out again.
+func verifyCredentials(ctx context.Context, vcURL, username, password string, insecure bool) error {
+	parsedURL, err := soap.ParseURL(vcURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse vCenter URL: %+v", err)
+	}
+
+	parsedURL.User = url.UserPassword(username, password)
+	client, err := govmomi.NewClient(ctx, parsedURL, insecure)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate with vCenter: %+v", err)
+	}
+	_ = client.Logout(ctx)
+	return nil
+}
+
+// validatePasswordSource fails if more than one of the explicit password, the
+// standard input and the password file are set.
+func validatePasswordSource(password string, stdin bool, file string) error {
+	if password != "" && stdin {
+		return fmt.Errorf("either set an explicit password with the --password option or set the --password-stdin option to get prompted for one, do not set both")
+	}
+	if file != "" && (password != "" || stdin) {
+		return fmt.Errorf("the --password-file option cannot be combined with the --password or --password-stdin options")
+	}
+	return nil
+}
+
+// stdinIsTerminal returns whether the password can be prompted for on the
+// terminal without echoing it.
+func stdinIsTerminal() bool {
+	return terminal.IsTerminal(syscall.Stdin)
+}
+
+// readPassword returns the given password, or else reads it from the given
+// file or standard input, hiding it on a terminal. A trailing newline read
+// from the file or a pipe is not part of the password.
+func readPassword(cmd *cobra.Command, password string, file string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+	if file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return nonEmptyPassword(b)
 	}
 	cmd.Println("Password:")
-	if terminal.IsTerminal(syscall.Stdin) {
-		password, err := terminal.ReadPassword(syscall.Stdin)
+	if stdinIsTerminal() {
+		b, err := terminal.ReadPassword(syscall.Stdin)
 		cmd.Println()
 		if err != nil {
 			return "", err
 		}
-		return string(password), nil
+		return nonEmptyPassword(b)
 	}
-	password, err := ioutil.ReadAll(cmd.InOrStdin())
+	b, err := ioutil.ReadAll(cmd.InOrStdin())
 	if err != nil {
 		return "", err
 	}
-	return string(password), nil
+	return nonEmptyPassword(b)
+}
+
+// nonEmptyPassword strips a trailing newline from the read password and fails
+// if nothing remains.
+func nonEmptyPassword(b []byte) (string, error) {
+	password := strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r")
+	if password == "" {
+		return "", fmt.Errorf("the password is empty")
+	}
+	return password, nil
 }
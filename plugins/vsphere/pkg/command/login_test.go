@@ -6,11 +6,13 @@ SPDX-License-Identifier: Apache-2.0
 package command_test
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/url"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -101,6 +103,38 @@ func TestNewLoginCommand(t *testing.T) {
 		assertSecret(t, secret, username, password)
 	})
 
+	t.Run("prints the name of the created secret", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		loginCommand := loginCommand(&pkg.Clients{ClientSet: client, ClientConfig: regularClientConfig()})
+		out := &bytes.Buffer{}
+		loginCommand.SetOut(out)
+		loginCommand.SetArgs([]string{
+			"--username", username,
+			"--password", password,
+			"--secret-name", secretName,
+			"-o", "name",
+		})
+
+		err := loginCommand.Execute()
+
+		retrieveCreatedSecret(t, err, client, defaultNamespace, secretName)
+		assert.Equal(t, out.String(), "secret/"+secretName+"\n")
+	})
+
+	t.Run("fails to execute when printing the secret as JSON", func(t *testing.T) {
+		loginCommand := loginCommand(&pkg.Clients{ClientSet: fake.NewSimpleClientset(), ClientConfig: regularClientConfig()})
+		loginCommand.SetArgs([]string{
+			"--username", username,
+			"--password", password,
+			"--secret-name", secretName,
+			"-o", "json",
+		})
+
+		err := loginCommand.Execute()
+
+		assert.ErrorContains(t, err, `unsupported output format "json", use one of name`)
+	})
+
 	t.Run("logs in default namespace with prompted password", func(t *testing.T) {
 		client := fake.NewSimpleClientset()
 		loginCommand := loginCommand(&pkg.Clients{ClientSet: client, ClientConfig: regularClientConfig()})
@@ -117,6 +151,63 @@ func TestNewLoginCommand(t *testing.T) {
 		assertSecret(t, secret, username, password)
 	})
 
+	t.Run("logs in with the password read from standard input without the trailing newline", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		loginCommand := loginCommand(&pkg.Clients{ClientSet: client, ClientConfig: regularClientConfig()})
+		loginCommand.SetIn(strings.NewReader(password + "\n"))
+		loginCommand.SetArgs([]string{
+			"--username", username,
+			"--password-stdin",
+			"--secret-name", secretName,
+		})
+
+		err := loginCommand.Execute()
+
+		secret := retrieveCreatedSecret(t, err, client, defaultNamespace, secretName)
+		assertSecret(t, secret, username, password)
+	})
+
+	t.Run("logs in with the password read from a file", func(t *testing.T) {
+		passwordFile := filepath.Join(t.TempDir(), "password")
+		assert.NilError(t, ioutil.WriteFile(passwordFile, []byte(password+"\n"), 0600))
+		client := fake.NewSimpleClientset()
+		loginCommand := loginCommand(&pkg.Clients{ClientSet: client, ClientConfig: regularClientConfig()})
+		loginCommand.SetArgs([]string{
+			"--username", username,
+			"--password-file", passwordFile,
+			"--secret-name", secretName,
+		})
+
+		err := loginCommand.Execute()
+
+		secret := retrieveCreatedSecret(t, err, client, defaultNamespace, secretName)
+		assertSecret(t, secret, username, password)
+	})
+
+	t.Run("fails to execute with an empty password file", func(t *testing.T) {
+		passwordFile := filepath.Join(t.TempDir(), "password")
+		assert.NilError(t, ioutil.WriteFile(passwordFile, []byte("\n"), 0600))
+		loginCommand := loginCommand(&pkg.Clients{ClientSet: fake.NewSimpleClientset(), ClientConfig: regularClientConfig()})
+		loginCommand.SetArgs([]string{
+			"--username", username,
+			"--password-file", passwordFile,
+			"--secret-name", secretName,
+		})
+
+		err := loginCommand.Execute()
+
+		assert.ErrorContains(t, err, "failed to get password: the password is empty")
+	})
+
+	t.Run("fails to execute with a password file and a stdin flag set", func(t *testing.T) {
+		loginCommand := loginCommand(&pkg.Clients{})
+		loginCommand.SetArgs([]string{"--username", username, "--secret-name", secretName, "--password-file", "password", "--password-stdin"})
+
+		err := loginCommand.Execute()
+
+		assert.ErrorContains(t, err, "the --password-file option cannot be combined with the --password or --password-stdin options")
+	})
+
 	t.Run("fails to execute if password cannot be retrieved from standard input", func(t *testing.T) {
 		stdInError := "oops"
 		loginCommand := loginCommand(&pkg.Clients{ClientSet: fake.NewSimpleClientset(), ClientConfig: regularClientConfig()})
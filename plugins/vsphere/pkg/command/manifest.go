@@ -0,0 +1,62 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+// readManifest decodes the YAML or JSON manifest of the given file, or of
+// stdin if the file is "-", into obj, which must be of the given kind.
+func readManifest(stdin io.Reader, filename string, kind string, obj runtime.Object) error {
+	var b []byte
+	var err error
+	if filename == "-" {
+		b, err = ioutil.ReadAll(stdin)
+	} else {
+		b, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %+v", err)
+	}
+	if err := yaml.UnmarshalStrict(b, obj); err != nil {
+		return fmt.Errorf("failed to parse manifest: %+v", err)
+	}
+	want := v1alpha1.SchemeGroupVersion.WithKind(kind)
+	if got := obj.GetObjectKind().GroupVersionKind(); got != want {
+		return fmt.Errorf("manifest must define a %s of %s, was kind %q of %q",
+			kind, want.GroupVersion().String(), got.Kind, got.GroupVersion().String())
+	}
+	return nil
+}
+
+// manifestMeta returns the metadata of a resource to create from a manifest,
+// without the metadata set by the cluster, in the namespace of the flag, or of
+// the manifest, or else the default namespace.
+func manifestMeta(clients *pkg.Clients, namespace string, meta metav1.ObjectMeta) (metav1.ObjectMeta, error) {
+	if namespace == "" && meta.Namespace != "" {
+		namespace = meta.Namespace
+	}
+	namespace, err := clients.GetExplicitOrDefaultNamespace(namespace)
+	if err != nil {
+		return metav1.ObjectMeta{}, fmt.Errorf("failed to get namespace: %+v", err)
+	}
+	return metav1.ObjectMeta{
+		Namespace:   namespace,
+		Name:        meta.Name,
+		Labels:      meta.Labels,
+		Annotations: meta.Annotations,
+	}, nil
+}
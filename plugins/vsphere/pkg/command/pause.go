@@ -0,0 +1,107 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+type PauseOptions struct {
+	Namespace string
+	Name      string
+
+	OutputOptions
+}
+
+func NewSourcePauseCommand(clients *pkg.Clients) *cobra.Command {
+	return newPauseCommand(clients, true, cobra.Command{
+		Use:   "pause",
+		Short: "Pause an existing vSphere source",
+		Long: "Pause an existing vSphere source, e.g. during a maintenance window of its sink.\n" +
+			"The adapter of the source is scaled to zero, and its checkpoint is kept until the source is resumed.",
+		Example: `# Pause the source in the default namespace
+kn vsphere source pause --name source
+# Pause the source in the specified namespace
+kn vsphere source pause --namespace ns --name source
+`,
+	})
+}
+
+func NewSourceResumeCommand(clients *pkg.Clients) *cobra.Command {
+	return newPauseCommand(clients, false, cobra.Command{
+		Use:   "resume",
+		Short: "Resume a paused vSphere source",
+		Long: "Resume a paused vSphere source.\n" +
+			"The adapter of the source is scaled up again and replays the events since its checkpoint, at most as old as the maximum checkpoint age.",
+		Example: `# Resume the source in the default namespace
+kn vsphere source resume --name source
+# Resume the source in the specified namespace
+kn vsphere source resume --namespace ns --name source
+`,
+	})
+}
+
+// newPauseCommand returns the given command, which sets the paused field of
+// the source to the given value
+func newPauseCommand(clients *pkg.Clients, paused bool, result cobra.Command) *cobra.Command {
+	options := PauseOptions{}
+	action := "resume"
+	if paused {
+		action = "pause"
+	}
+	result.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := options.validateOutput(); err != nil {
+			return err
+		}
+		if options.Name == "" {
+			return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+		}
+		return nil
+	}
+	result.RunE = func(cmd *cobra.Command, args []string) error {
+		namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+		if err != nil {
+			return fmt.Errorf("failed to get namespace: %+v", err)
+		}
+
+		sources := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace)
+		source, err := sources.Get(cmd.Context(), options.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get source: %+v", err)
+		}
+		var message string
+		switch {
+		case source.Spec.Paused == paused && paused:
+			message = "Source is already paused"
+		case source.Spec.Paused == paused:
+			message = "Source is not paused"
+		default:
+			source.Spec.Paused = paused
+			if source, err = sources.Update(cmd.Context(), source, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("failed to update source: %+v", err)
+			}
+			message = "Resumed source"
+			if paused {
+				message = "Paused source"
+			}
+		}
+		source.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("VSphereSource"))
+		return options.printObject(cmd.OutOrStdout(), source, message)
+	}
+
+	flags := result.Flags()
+	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the source (default namespace if omitted)")
+	flags.StringVar(&options.Name, "name", "", fmt.Sprintf("name of the source to %s", action))
+	options.addOutputFlag(&result, "", outputJSON, outputYAML, outputName)
+	options.addQuietFlag(&result)
+	return &result
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+)
+
+func TestNewSourcePauseCommand(t *testing.T) {
+
+	const sourceName = "spring"
+	const secretRef = "street-creds"
+	const sourceAddress = "https://my-vsphere-endpoint.example.com"
+	const sinkURI = "https://sink.example.com"
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		for _, use := range []string{"pause", "resume"} {
+			sourceCommand, _ := sourceCommand(regularClientConfig())
+			pauseCommand, _, err := sourceCommand.Find([]string{use})
+			assert.NilError(t, err)
+
+			assert.Equal(t, pauseCommand.Use, use)
+			assert.Check(t, len(pauseCommand.Short) > 0,
+				"command should have a nonempty short description")
+			assert.Check(t, len(pauseCommand.Long) > 0,
+				"command should have a nonempty long description")
+			checkFlag(t, pauseCommand, "namespace")
+			checkFlag(t, pauseCommand, "name")
+			checkFlag(t, pauseCommand, "output")
+			checkFlag(t, pauseCommand, "quiet")
+			assert.Assert(t, pauseCommand.RunE != nil)
+		}
+	})
+
+	t.Run("pauses and resumes the source", func(t *testing.T) {
+		existingSource := newSource(t, "ns", sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource)
+		out := &bytes.Buffer{}
+		sourceCommand.SetOut(out)
+		sourceCommand.SetArgs([]string{"pause", "--namespace", "ns", "--name", sourceName})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, "ns", sourceName)
+		assert.Check(t, source.Spec.Paused)
+		assert.Equal(t, out.String(), "Paused source\n")
+
+		out.Reset()
+		sourceCommand.SetArgs([]string{"resume", "--namespace", "ns", "--name", sourceName})
+
+		err = sourceCommand.Execute()
+
+		source = retrieveCreatedSource(t, err, vSphereClientSet, "ns", sourceName)
+		assert.Check(t, !source.Spec.Paused)
+		assert.Equal(t, out.String(), "Resumed source\n")
+	})
+
+	t.Run("does not update a paused source", func(t *testing.T) {
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		existingSource.(*v1alpha1.VSphereSource).Spec.Paused = true
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource)
+		vSphereClientSet.PrependReactor("update", "vspheresources", func(a k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, fmt.Errorf("unexpected update")
+		})
+		out := &bytes.Buffer{}
+		sourceCommand.SetOut(out)
+		sourceCommand.SetArgs([]string{"pause", "--name", sourceName})
+
+		err := sourceCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, out.String(), "Source is already paused\n")
+	})
+
+	t.Run("fails to execute with an empty name", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"resume"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "'name' requires a nonempty name provided with the --name option")
+	})
+
+	t.Run("fails to execute when the source does not exist", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"pause", "--name", sourceName})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, fmt.Sprintf(`failed to get source: vspheresources.sources.tanzu.vmware.com %q not found`, sourceName))
+	})
+
+	t.Run("fails to execute when the source update fails", func(t *testing.T) {
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource)
+		vSphereClientSet.PrependReactor("update", "vspheresources", func(a k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, fmt.Errorf("cannot update source")
+		})
+		sourceCommand.SetArgs([]string{"pause", "--name", sourceName})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "failed to update source: cannot update source")
+	})
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	outputJSON = "json"
+	outputYAML = "yaml"
+	outputName = "name"
+)
+
+// OutputOptions select how a command prints its result: a message for humans
+// by default, nothing if quiet, or the machine-readable format of --output.
+type OutputOptions struct {
+	Output string
+	Quiet  bool
+
+	// formats are the output formats supported by the command
+	formats []string
+}
+
+// addOutputFlag adds the --output flag supporting the given formats.
+func (oo *OutputOptions) addOutputFlag(cmd *cobra.Command, defaultFormat string, formats ...string) {
+	oo.formats = formats
+	cmd.Flags().StringVarP(&oo.Output, "output", "o", defaultFormat,
+		fmt.Sprintf("output format, one of %s", strings.Join(formats, "|")))
+}
+
+// addQuietFlag adds the --quiet flag.
+func (oo *OutputOptions) addQuietFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&oo.Quiet, "quiet", "q", false, "only print errors")
+}
+
+// validateOutput checks that the output format is supported by the command.
+func (oo *OutputOptions) validateOutput() error {
+	if oo.Output == "" {
+		return nil
+	}
+	if oo.Quiet {
+		return fmt.Errorf("--quiet cannot be combined with --output")
+	}
+	for _, f := range oo.formats {
+		if oo.Output == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q, use one of %s", oo.Output, strings.Join(oo.formats, "|"))
+}
+
+// printObject prints the given resource, which must have its kind set, in the
+// output format, or else the given message unless quiet.
+func (oo *OutputOptions) printObject(out io.Writer, obj runtime.Object, message string) error {
+	switch oo.Output {
+	case outputJSON, outputYAML:
+		return oo.printValue(out, obj)
+	case outputName:
+		name, err := objectName(obj)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, name)
+		return err
+	}
+	if !oo.Quiet {
+		_, err := fmt.Fprintln(out, message)
+		return err
+	}
+	return nil
+}
+
+// printObjects prints the given resources, which must have their kind set, in
+// the output format: a List in JSON, a multi-document stream in YAML or a name
+// per line.
+func (oo *OutputOptions) printObjects(out io.Writer, objects []runtime.Object) error {
+	switch oo.Output {
+	case outputJSON:
+		list := map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "List",
+			"items":      objects,
+		}
+		if len(objects) == 0 {
+			list["items"] = []interface{}{}
+		}
+		return oo.printValue(out, list)
+	case outputYAML:
+		for i, obj := range objects {
+			if i > 0 {
+				fmt.Fprintln(out, "---")
+			}
+			if err := oo.printValue(out, obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputName:
+		for _, obj := range objects {
+			if err := oo.printObject(out, obj, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported output format %q", oo.Output)
+}
+
+// printValue prints the given value as JSON or YAML.
+func (oo *OutputOptions) printValue(out io.Writer, v interface{}) error {
+	var b []byte
+	var err error
+	if oo.Output == outputYAML {
+		b, err = yaml.Marshal(v)
+	} else {
+		b, err = json.MarshalIndent(v, "", "  ")
+		b = append(b, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %+v", err)
+	}
+	_, err = out.Write(b)
+	return err
+}
+
+// objectName returns the <kind>.<group>/<name> of a resource like kubectl.
+func objectName(obj runtime.Object) (string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", err
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	kind := strings.ToLower(gvk.Kind)
+	if gvk.Group != "" {
+		kind += "." + gvk.Group
+	}
+	return kind + "/" + accessor.GetName(), nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+type RestartOptions struct {
+	Namespace string
+	Name      string
+
+	ResetCheckpoint bool
+
+	OutputOptions
+}
+
+func NewSourceRestartCommand(clients *pkg.Clients) *cobra.Command {
+	options := RestartOptions{}
+	result := cobra.Command{
+		Use:   "restart",
+		Short: "Restart the adapter of an existing vSphere source",
+		Long: "Restart the adapter of an existing vSphere source, e.g. to recover a wedged source.\n" +
+			"The source is annotated with the restart time, which makes the controller roll out its adapter again.",
+		Example: `# Restart the adapter of the source in the default namespace
+kn vsphere source restart --name source
+# Restart the adapter of the source in the specified namespace, discarding its checkpoint
+kn vsphere source restart --namespace ns --name source --reset-checkpoint
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validateOutput(); err != nil {
+				return err
+			}
+			if options.Name == "" {
+				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %+v", err)
+			}
+
+			sources := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace)
+			source, err := sources.Get(cmd.Context(), options.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get source: %+v", err)
+			}
+			now := time.Now().UTC().Format(time.RFC3339)
+			if source.Annotations == nil {
+				source.Annotations = map[string]string{}
+			}
+			source.Annotations[vsphere.RestartedAtAnnotation] = now
+			message := "Restarted source"
+			if options.ResetCheckpoint {
+				source.Annotations[vsphere.CheckpointResetAnnotation] = now
+				message = "Restarted source, discarding its checkpoint"
+			}
+			updated, err := sources.Update(cmd.Context(), source, metav1.UpdateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to update source: %+v", err)
+			}
+			updated.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("VSphereSource"))
+			return options.printObject(cmd.OutOrStdout(), updated, message)
+		},
+	}
+	flags := result.Flags()
+	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the source (default namespace if omitted)")
+	flags.StringVar(&options.Name, "name", "", "name of the source to restart")
+	flags.BoolVar(&options.ResetCheckpoint, "reset-checkpoint", false,
+		"discard the checkpoint of the source, so that its adapter starts as if the source was new")
+	options.addOutputFlag(&result, "", outputJSON, outputYAML, outputName)
+	options.addQuietFlag(&result)
+	return &result
+}
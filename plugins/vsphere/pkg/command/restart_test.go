@@ -0,0 +1,102 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere"
+)
+
+func TestNewSourceRestartCommand(t *testing.T) {
+
+	const sourceName = "spring"
+	const secretRef = "street-creds"
+	const sourceAddress = "https://my-vsphere-endpoint.example.com"
+	const sinkURI = "https://sink.example.com"
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		restartCommand, _, err := sourceCommand.Find([]string{"restart"})
+		assert.NilError(t, err)
+
+		assert.Equal(t, restartCommand.Use, "restart")
+		assert.Check(t, len(restartCommand.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(restartCommand.Long) > 0,
+			"command should have a nonempty long description")
+		checkFlag(t, restartCommand, "namespace")
+		checkFlag(t, restartCommand, "name")
+		checkFlag(t, restartCommand, "reset-checkpoint")
+		checkFlag(t, restartCommand, "output")
+		checkFlag(t, restartCommand, "quiet")
+		assert.Assert(t, restartCommand.RunE != nil)
+	})
+
+	t.Run("annotates the source with the restart time", func(t *testing.T) {
+		existingSource := newSource(t, "ns", sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource)
+		sourceCommand.SetArgs([]string{"restart", "--namespace", "ns", "--name", sourceName})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, "ns", sourceName)
+		restartedAt, err := time.Parse(time.RFC3339, source.Annotations[vsphere.RestartedAtAnnotation])
+		assert.NilError(t, err)
+		assert.Check(t, time.Since(restartedAt) < time.Minute)
+		_, reset := source.Annotations[vsphere.CheckpointResetAnnotation]
+		assert.Check(t, !reset)
+	})
+
+	t.Run("resets the checkpoint of the source", func(t *testing.T) {
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource)
+		sourceCommand.SetArgs([]string{"restart", "--name", sourceName, "--reset-checkpoint"})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Check(t, source.Annotations[vsphere.RestartedAtAnnotation] != "")
+		assert.Equal(t, source.Annotations[vsphere.CheckpointResetAnnotation], source.Annotations[vsphere.RestartedAtAnnotation])
+	})
+
+	t.Run("fails to execute with an empty name", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"restart"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "'name' requires a nonempty name provided with the --name option")
+	})
+
+	t.Run("fails to execute when the source does not exist", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"restart", "--name", sourceName})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, fmt.Sprintf(`failed to get source: vspheresources.sources.tanzu.vmware.com %q not found`, sourceName))
+	})
+
+	t.Run("fails to execute when the source update fails", func(t *testing.T) {
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource)
+		vSphereClientSet.PrependReactor("update", "vspheresources", func(a k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, fmt.Errorf("cannot update source")
+		})
+		sourceCommand.SetArgs([]string{"restart", "--name", sourceName})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "failed to update source: cannot update source")
+	})
+}
@@ -17,8 +17,13 @@ func NewRootCommand(clients *pkg.Clients) *cobra.Command {
 		Short: "Knative plugin to create Knative compatible Event Sources for VSphere events,\nand Bindings to access the vSphere API",
 	}
 	result.AddCommand(NewLoginCommand(clients))
+	result.AddCommand(NewAuthCommand(clients))
 	result.AddCommand(NewSourceCommand(clients))
 	result.AddCommand(NewBindingCommand(clients))
-	result.AddCommand(NewVersionCommand())
+	result.AddCommand(NewCheckCommand(clients))
+	result.AddCommand(NewEventsCommand(clients))
+	result.AddCommand(NewExportCommand(clients))
+	result.AddCommand(NewSimulateCommand())
+	result.AddCommand(NewVersionCommand(clients))
 	return &result
 }
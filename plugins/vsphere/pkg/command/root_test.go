@@ -20,13 +20,23 @@ func TestNewRootCommand(t *testing.T) {
 	assert.Equal(t, "kn-vsphere", rootCommand.Name())
 	assert.Check(t, len(rootCommand.Short) > 0,
 		"command should have a nonempty description")
-	assert.Check(t, len(rootCommand.Commands()) == 4, "unexpected number of subcommands")
+	assert.Check(t, len(rootCommand.Commands()) == 9, "unexpected number of subcommands")
 	assert.Check(t, HasLeafCommand(rootCommand, "login"),
 		"command should have subcommand login")
+	assert.Check(t, HasLeafCommand(rootCommand, "auth"),
+		"command should have subcommand auth")
 	assert.Check(t, HasLeafCommand(rootCommand, "source"),
 		"command should have subcommand source")
 	assert.Check(t, HasLeafCommand(rootCommand, "binding"),
 		"command should have subcommand binding")
+	assert.Check(t, HasLeafCommand(rootCommand, "check"),
+		"command should have subcommand check")
+	assert.Check(t, HasLeafCommand(rootCommand, "events"),
+		"command should have subcommand events")
+	assert.Check(t, HasLeafCommand(rootCommand, "export"),
+		"command should have subcommand export")
+	assert.Check(t, HasLeafCommand(rootCommand, "simulate"),
+		"command should have subcommand simulate")
 	assert.Check(t, HasLeafCommand(rootCommand, "version"),
 		"command should have subcommand version")
 }
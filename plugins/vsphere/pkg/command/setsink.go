@@ -0,0 +1,160 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+)
+
+// sinkPollInterval is the delay between the reads of the source while waiting
+// for its new sink to be resolved
+const sinkPollInterval = time.Second
+
+type SetSinkOptions struct {
+	SourceOptions
+
+	Wait    bool
+	Timeout time.Duration
+}
+
+func NewSourceSetSinkCommand(clients *pkg.Clients) *cobra.Command {
+	options := SetSinkOptions{}
+	result := cobra.Command{
+		Use:   "set-sink",
+		Short: "Change the sink of an existing vSphere source",
+		Long:  "Change the sink of an existing vSphere source, leaving the rest of its spec untouched",
+		Example: `# Send the events of the source in the default namespace to the default broker
+kn vsphere source set-sink --name source --sink broker:default
+# Send the events of the source in the specified namespace to the specified sink URI
+kn vsphere source set-sink --namespace ns --name source --sink-uri http://where.to.send.stuff
+# Send the events of the source to another broker, waiting until the controller resolved it
+kn vsphere source set-sink --name source --sink broker:other --wait
+# Log the events of the source instead of sending them, e.g. while changing its filter
+kn vsphere source set-sink --name source --sink none
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validateOutput(); err != nil {
+				return err
+			}
+			if options.Name == "" {
+				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
+			}
+			if err := options.applySinkShorthand(); err != nil {
+				return err
+			}
+			return options.validateSink()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %+v", err)
+			}
+			sinkDestination, err := options.AsSinkDestination(namespace)
+			if err != nil {
+				return fmt.Errorf("failed to parse sink address: %+v", err)
+			}
+
+			sources := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(namespace)
+			source, err := sources.Get(cmd.Context(), options.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get source: %+v", err)
+			}
+			// the sink URI resolved for the previous sink, which must change
+			// unless the sink does not
+			previous := source.Status.SinkURI
+			if equality.Semantic.DeepEqual(source.Spec.Sink, *sinkDestination) {
+				previous = nil
+			}
+			source.Spec.Sink = *sinkDestination
+			source.Spec.LogOnly = options.LogOnly
+			updated, err := sources.Update(cmd.Context(), source, metav1.UpdateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to update source: %+v", err)
+			}
+
+			if options.Wait {
+				updated, err = waitForSink(cmd.Context(), clients, updated, previous, options.Timeout)
+				if err != nil {
+					return err
+				}
+			}
+			updated.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("VSphereSource"))
+			message := "Updated sink of source"
+			if sinkURI := updated.Status.SinkURI; options.Wait && sinkURI != nil {
+				message = fmt.Sprintf("Updated sink of source, sending events to %s", sinkURI)
+			}
+			return options.printObject(cmd.OutOrStdout(), updated, message)
+		},
+	}
+	flags := result.Flags()
+	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the source (default namespace if omitted)")
+	flags.StringVar(&options.Name, "name", "", "name of the source to update")
+	flags.StringVar(&options.Sink, "sink", "",
+		"sink as broker:<name>, channel:<name>, ksvc:<name>, svc:<name>, the name of a Knative Service, an http(s) URL or none to log the events")
+	flags.StringVarP(&options.SinkURI, "sink-uri", "u", "", "sink URI (can be absolute, or relative to the referred sink resource)")
+	flags.StringVar(&options.SinkAPIVersion, "sink-api-version", "", "sink API version")
+	flags.StringVar(&options.SinkKind, "sink-kind", "", "sink kind")
+	flags.StringVar(&options.SinkName, "sink-name", "", "sink name")
+	flags.BoolVar(&options.Wait, "wait", false, "wait until the status of the source reflects the resolved new sink")
+	flags.DurationVar(&options.Timeout, "timeout", time.Minute, "maximum time to wait for the new sink to be resolved")
+	options.addOutputFlag(&result, "", outputJSON, outputYAML, outputName)
+	options.addQuietFlag(&result)
+	return &result
+}
+
+// waitForSink polls the given updated source until the controller reconciled
+// its new sink, and returns the reconciled source. previous is the sink URI
+// which must have changed, nil if any resolved URI will do.
+func waitForSink(ctx context.Context, clients *pkg.Clients, updated *v1alpha1.VSphereSource, previous *apis.URL,
+	timeout time.Duration) (*v1alpha1.VSphereSource, error) {
+	sources := clients.VSphereClientSet.SourcesV1alpha1().VSphereSources(updated.Namespace)
+	source := updated
+	err := wait.PollImmediate(sinkPollInterval, timeout, func() (bool, error) {
+		var err error
+		if source, err = sources.Get(ctx, updated.Name, metav1.GetOptions{}); err != nil {
+			return false, fmt.Errorf("failed to get source: %+v", err)
+		}
+		if updated.Spec.LogOnly {
+			// there is no sink to resolve
+			return source.Status.ObservedGeneration >= updated.Generation, nil
+		}
+		return sinkResolved(source, updated.Generation, updated.Spec.Sink, previous), nil
+	})
+	if err == wait.ErrWaitTimeout {
+		err = fmt.Errorf("timed out after %s waiting for the sink of source %q to be resolved", timeout, updated.Name)
+		if ready := source.Status.GetCondition(apis.ConditionReady); ready != nil && ready.Message != "" {
+			err = fmt.Errorf("%w: %s", err, ready.Message)
+		}
+	}
+	return source, err
+}
+
+// sinkResolved returns whether the status of the source reflects the given sink
+// of the given generation
+func sinkResolved(source *v1alpha1.VSphereSource, generation int64, sink duckv1.Destination, previous *apis.URL) bool {
+	sinkURI := source.Status.SinkURI
+	if source.Status.ObservedGeneration < generation || sinkURI == nil {
+		return false
+	}
+	if sink.Ref == nil && sink.URI != nil {
+		// the sink path of the delivery spec may be appended
+		return strings.HasPrefix(sinkURI.String(), sink.URI.String())
+	}
+	return previous == nil || sinkURI.String() != previous.String()
+}
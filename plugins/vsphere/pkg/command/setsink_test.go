@@ -0,0 +1,166 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+)
+
+func TestNewSourceSetSinkCommand(t *testing.T) {
+
+	const sourceName = "spring"
+	const secretRef = "street-creds"
+	const sourceAddress = "https://my-vsphere-endpoint.example.com"
+	const sinkURI = "https://sink.example.com"
+
+	t.Run("defines basic metadata", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		setSinkCommand, _, err := sourceCommand.Find([]string{"set-sink"})
+		assert.NilError(t, err)
+
+		assert.Equal(t, setSinkCommand.Use, "set-sink")
+		assert.Check(t, len(setSinkCommand.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(setSinkCommand.Long) > 0,
+			"command should have a nonempty long description")
+		checkFlag(t, setSinkCommand, "namespace")
+		checkFlag(t, setSinkCommand, "name")
+		checkFlag(t, setSinkCommand, "sink")
+		checkFlag(t, setSinkCommand, "sink-uri")
+		checkFlag(t, setSinkCommand, "sink-api-version")
+		checkFlag(t, setSinkCommand, "sink-kind")
+		checkFlag(t, setSinkCommand, "sink-name")
+		checkFlag(t, setSinkCommand, "wait")
+		checkFlag(t, setSinkCommand, "timeout")
+		checkFlag(t, setSinkCommand, "output")
+		checkFlag(t, setSinkCommand, "quiet")
+		assert.Assert(t, setSinkCommand.RunE != nil)
+	})
+
+	t.Run("changes the sink to a broker with the shorthand", func(t *testing.T) {
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource)
+		sourceCommand.SetArgs([]string{"set-sink", "--name", sourceName, "--sink", "broker:default"})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assertBasicSource(t, &source.Spec, sinkURI, secretRef, false)
+		assert.Check(t, source.Spec.Sink.URI == nil)
+		assertSinkReference(t, source.Spec.Sink.Ref, "eventing.knative.dev/v1", "Broker", defaultNamespace, "default")
+	})
+
+	t.Run("changes the sink to a URI in the specified namespace", func(t *testing.T) {
+		const newSinkURI = "https://other-sink.example.com"
+		existingSource := newSource(t, "ns", sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource)
+		sourceCommand.SetArgs([]string{"set-sink", "--namespace", "ns", "--name", sourceName, "--sink-uri", newSinkURI})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, "ns", sourceName)
+		assert.Check(t, source.Spec.Sink.Ref == nil)
+		assert.Equal(t, source.Spec.Sink.URI.String(), newSinkURI)
+	})
+
+	t.Run("changes the source to log its events", func(t *testing.T) {
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource)
+		sourceCommand.SetArgs([]string{"set-sink", "--name", sourceName, "--sink", "none", "--wait"})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Check(t, source.Spec.LogOnly)
+		assert.Check(t, source.Spec.Sink.URI == nil)
+		assert.Check(t, source.Spec.Sink.Ref == nil)
+	})
+
+	t.Run("waits for the status to reflect the new sink", func(t *testing.T) {
+		const newSinkURI = "https://other-sink.example.com"
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI).(*v1alpha1.VSphereSource)
+		resolved := parseURI(t, newSinkURI+"/events")
+		existingSource.Status.SinkURI = &resolved
+		sourceCommand, _ := sourceCommand(regularClientConfig(), existingSource)
+		output := bytes.Buffer{}
+		sourceCommand.SetOut(&output)
+		sourceCommand.SetArgs([]string{"set-sink", "--name", sourceName, "--sink-uri", newSinkURI, "--wait"})
+
+		err := sourceCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, output.String(), fmt.Sprintf("Updated sink of source, sending events to %s/events\n", newSinkURI))
+	})
+
+	t.Run("fails to wait when the new sink is not resolved in time", func(t *testing.T) {
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI).(*v1alpha1.VSphereSource)
+		previous := parseURI(t, sinkURI)
+		existingSource.Status.SinkURI = &previous
+		existingSource.Status.Conditions = duckv1.Conditions{{
+			Type:    apis.ConditionReady,
+			Status:  corev1.ConditionFalse,
+			Message: `brokers.eventing.knative.dev "other" not found`,
+		}}
+		sourceCommand, _ := sourceCommand(regularClientConfig(), existingSource)
+		sourceCommand.SetArgs([]string{"set-sink", "--name", sourceName, "--sink", "broker:other", "--wait", "--timeout", "1ms"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, fmt.Sprintf(`timed out after 1ms waiting for the sink of source %q to be resolved: `+
+			`brokers.eventing.knative.dev "other" not found`, sourceName))
+	})
+
+	t.Run("fails to execute with an empty name", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"set-sink", "--sink", "broker:default"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "'name' requires a nonempty name provided with the --name option")
+	})
+
+	t.Run("fails to execute without a sink", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"set-sink", "--name", sourceName})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "sink requires a --sink option")
+	})
+
+	t.Run("fails to execute when the source does not exist", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"set-sink", "--name", sourceName, "--sink", "broker:default"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, fmt.Sprintf(`failed to get source: vspheresources.sources.tanzu.vmware.com %q not found`, sourceName))
+	})
+
+	t.Run("fails to execute when the source update fails", func(t *testing.T) {
+		existingSource := newSource(t, defaultNamespace, sourceName, sourceAddress, secretRef, sinkURI)
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), existingSource)
+		vSphereClientSet.PrependReactor("update", "vspheresources", func(a k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, fmt.Errorf("cannot update source")
+		})
+		sourceCommand.SetArgs([]string{"set-sink", "--name", sourceName, "--sink", "broker:default"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "failed to update source: cannot update source")
+	})
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/vsphere/vspheretest"
+
+	// serve the vCenter REST API for tag and content library events
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+const (
+	simulatedPowerOps     = string(vspheretest.PowerOps)
+	simulatedTaskFailures = string(vspheretest.TaskFailures)
+)
+
+type SimulateOptions struct {
+	Listen   string
+	Username string
+	Password string
+	Interval time.Duration
+	Count    int
+	Events   []string
+}
+
+func NewSimulateCommand() *cobra.Command {
+	options := SimulateOptions{}
+	result := cobra.Command{
+		Use:   "simulate",
+		Short: "Run a simulated vCenter generating events",
+		Long: "Run a simulated vCenter (vcsim) which generates events on a schedule, e.g. VM power operations and failed tasks,\n" +
+			"to exercise event pipelines without a real vCenter. It runs until interrupted or the given number of events is generated.",
+		Example: `# Run a simulated vCenter on the default address, generating power operations and task failures every 10s
+kn vsphere simulate
+# Run a simulated vCenter reachable from a local cluster, generating 5 power operations, one per minute
+kn vsphere simulate --listen 0.0.0.0:8989 --event power --interval 1m --count 5
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if options.Interval <= 0 {
+				return fmt.Errorf("'interval' must be positive, was %v", options.Interval)
+			}
+			if len(options.Events) == 0 {
+				return fmt.Errorf("'event' requires at least one of %s or %s", simulatedPowerOps, simulatedTaskFailures)
+			}
+			for _, e := range options.Events {
+				if e != simulatedPowerOps && e != simulatedTaskFailures {
+					return fmt.Errorf("unsupported event %q, use %s or %s", e, simulatedPowerOps, simulatedTaskFailures)
+				}
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			model := simulator.VPX()
+			defer model.Remove()
+			if err := model.Create(); err != nil {
+				return fmt.Errorf("failed to create simulated vCenter: %+v", err)
+			}
+			model.Service.Listen = &url.URL{
+				Host: options.Listen,
+				User: url.UserPassword(options.Username, options.Password),
+			}
+			model.Service.TLS = new(tls.Config)
+			model.Service.RegisterEndpoints = true
+
+			server := model.Service.NewServer()
+			defer server.Close()
+
+			address := url.URL{Scheme: server.URL.Scheme, Host: server.URL.Host}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Simulated vCenter running at %s with username %q and password %q\n",
+				address.String(), options.Username, options.Password)
+			fmt.Fprintf(out, "Create a source for it with a self-signed certificate, e.g.:\n"+
+				"  kn vsphere login --username %s --password %s --secret-name vcsim-credentials\n"+
+				"  kn vsphere source --name vcsim --address %s --skip-tls-verify --secret-ref vcsim-credentials --sink <sink>\n",
+				options.Username, options.Password, address.String())
+
+			client, err := govmomi.NewClient(cmd.Context(), server.URL, true)
+			if err != nil {
+				return fmt.Errorf("failed to connect to simulated vCenter: %+v", err)
+			}
+			defer func() { _ = client.Logout(context.Background()) }()
+
+			vms, err := find.NewFinder(client.Client).VirtualMachineList(cmd.Context(), "*")
+			if err != nil {
+				return fmt.Errorf("failed to find simulated VMs: %+v", err)
+			}
+
+			return simulateEvents(cmd.Context(), out, vms, options)
+		},
+	}
+	flags := result.Flags()
+	flags.StringVar(&options.Listen, "listen", "127.0.0.1:8989", "address of the simulated vCenter")
+	flags.StringVarP(&options.Username, "username", "u", "user", "username of the simulated vCenter")
+	flags.StringVarP(&options.Password, "password", "p", "pass", "password of the simulated vCenter")
+	flags.DurationVar(&options.Interval, "interval", 10*time.Second, "interval between generated events")
+	flags.IntVar(&options.Count, "count", 0, "number of events to generate, unlimited if 0")
+	flags.StringSliceVar(&options.Events, "event", []string{simulatedPowerOps, simulatedTaskFailures},
+		fmt.Sprintf("events to generate in turn, %s and/or %s", simulatedPowerOps, simulatedTaskFailures))
+	return &result
+}
+
+// simulateEvents generates the configured events in turn on the given VMs
+// until ctx is done or the configured number of events is generated.
+func simulateEvents(ctx context.Context, out io.Writer, vms []*object.VirtualMachine, options SimulateOptions) error {
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+
+	for i := 0; options.Count == 0 || i < options.Count; i++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		vm := vms[i%len(vms)]
+		msg, err := vspheretest.GenerateEvent(ctx, vm, vspheretest.Scenario(options.Events[i%len(options.Events)]))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to generate event: %+v", err)
+		}
+		fmt.Fprintf(out, "%s %s\n", time.Now().Format(time.RFC3339), msg)
+	}
+	return nil
+}
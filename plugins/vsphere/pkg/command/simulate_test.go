@@ -0,0 +1,80 @@
+/*
+Copyright 2020 VMware, Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/assert"
+
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
+)
+
+func TestNewSimulateCommand(t *testing.T) {
+	t.Run("defines basic metadata", func(t *testing.T) {
+		simulateCommand, _ := simulateCommand()
+
+		assert.Equal(t, simulateCommand.Use, "simulate")
+		assert.Check(t, len(simulateCommand.Short) > 0,
+			"command should have a nonempty short description")
+		assert.Check(t, len(simulateCommand.Long) > 0,
+			"command should have a nonempty long description")
+		checkFlag(t, simulateCommand, "listen")
+		checkFlag(t, simulateCommand, "username")
+		checkFlag(t, simulateCommand, "password")
+		checkFlag(t, simulateCommand, "interval")
+		checkFlag(t, simulateCommand, "count")
+		checkFlag(t, simulateCommand, "event")
+		assert.Assert(t, simulateCommand.RunE != nil)
+	})
+
+	t.Run("fails to execute with an unsupported event", func(t *testing.T) {
+		simulateCommand, _ := simulateCommand()
+		simulateCommand.SetArgs([]string{"--event", "host-failure"})
+
+		err := simulateCommand.Execute()
+
+		assert.ErrorContains(t, err, `unsupported event "host-failure"`)
+	})
+
+	t.Run("fails to execute with a non-positive interval", func(t *testing.T) {
+		simulateCommand, _ := simulateCommand()
+		simulateCommand.SetArgs([]string{"--interval", "0s"})
+
+		err := simulateCommand.Execute()
+
+		assert.ErrorContains(t, err, "'interval' must be positive")
+	})
+
+	t.Run("generates the given number of events", func(t *testing.T) {
+		simulateCommand, out := simulateCommand()
+		simulateCommand.SetArgs([]string{
+			"--listen", "127.0.0.1:0",
+			"--interval", "10ms",
+			"--count", "4",
+		})
+
+		err := simulateCommand.Execute()
+
+		assert.NilError(t, err)
+		output := out.String()
+		assert.Check(t, strings.Contains(output, "Simulated vCenter running at https://127.0.0.1:"), output)
+		assert.Check(t, strings.Contains(output, "powered off VM"), output)
+		assert.Check(t, strings.Contains(output, "failed task"), output)
+	})
+}
+
+func simulateCommand() (*cobra.Command, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	simulateCommand := command.NewSimulateCommand()
+	simulateCommand.SetErr(ioutil.Discard)
+	simulateCommand.SetOut(out)
+	return simulateCommand, out
+}
@@ -6,8 +6,10 @@ SPDX-License-Identifier: Apache-2.0
 package command
 
 import (
+	"context"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -27,13 +29,113 @@ type SourceOptions struct {
 	SkipTLSVerify bool
 	SecretRef     string
 
+	AllowInsecureAddress bool
+
+	// Template is the name of the VSphereSourceTemplate of the source
+	Template string
+
+	Sink           string
 	SinkURI        string
 	SinkAPIVersion string
 	SinkKind       string
 	SinkName       string
+	// LogOnly is set by --sink none, the source then logs its events
+	LogOnly bool
 
 	CheckpointMaxAge time.Duration
 	CheckpointPeriod time.Duration
+	ReplayFrom       string
+
+	PollInterval time.Duration
+	PageSize     int32
+
+	IncludeTasks          bool
+	IncludeContentLibrary bool
+	IncludeTags           bool
+	NormalizeAlarms       bool
+	GroupVSANEvents       bool
+	EventTypes            []string
+	CELFilter             string
+
+	Labels      []string
+	Annotations []string
+
+	Filename string
+
+	OutputOptions
+}
+
+// sinkNone is the value of the --sink flag for a source which logs its events
+// instead of sending them
+const sinkNone = "none"
+
+// sinkPrefixes are the kinds which can be referenced with the "<prefix>:<name>"
+// shorthand of the --sink flag, as in the kn CLI
+var sinkPrefixes = map[string]struct{ apiVersion, kind string }{
+	"broker":  {"eventing.knative.dev/v1", "Broker"},
+	"channel": {"messaging.knative.dev/v1", "Channel"},
+	"ksvc":    {"serving.knative.dev/v1", "Service"},
+	"svc":     {"v1", "Service"},
+}
+
+// applySinkShorthand sets the sink URI or reference from the --sink flag, which
+// is either an http(s) URL, "<prefix>:<name>" or the name of a Knative Service,
+// or sets LogOnly for "none".
+func (so *SourceOptions) applySinkShorthand() error {
+	if so.Sink == "" {
+		return nil
+	}
+	if so.SinkAPIVersion != "" || so.SinkKind != "" || so.SinkName != "" {
+		return fmt.Errorf("--sink cannot be combined with --sink-api-version, --sink-kind or --sink-name")
+	}
+	if so.Sink == sinkNone {
+		if so.SinkURI != "" {
+			return fmt.Errorf("--sink none cannot be combined with --sink-uri")
+		}
+		so.LogOnly = true
+		return nil
+	}
+
+	prefix, name := "ksvc", so.Sink
+	if i := strings.Index(so.Sink, ":"); i >= 0 {
+		prefix, name = so.Sink[:i], so.Sink[i+1:]
+	}
+	if prefix == "http" || prefix == "https" {
+		if so.SinkURI != "" {
+			return fmt.Errorf("--sink with a URL cannot be combined with --sink-uri")
+		}
+		so.SinkURI = so.Sink
+		return nil
+	}
+
+	kind, ok := sinkPrefixes[prefix]
+	if !ok {
+		return fmt.Errorf("unsupported sink prefix %q, use one of broker, channel, ksvc or svc, or an http(s) URL", prefix)
+	}
+	if name == "" {
+		return fmt.Errorf("sink %q requires a name after the %q prefix", so.Sink, prefix)
+	}
+	so.SinkAPIVersion, so.SinkKind, so.SinkName = kind.apiVersion, kind.kind, name
+	return nil
+}
+
+// validateSink checks that the sink flags, after applying the --sink
+// shorthand, set a URI and/or a complete reference, unless the source logs its
+// events
+func (so *SourceOptions) validateSink() error {
+	if so.LogOnly {
+		return nil
+	}
+	sinkCoordinatesAllEmpty := so.SinkAPIVersion == "" && so.SinkKind == "" && so.SinkName == ""
+	sinkCoordinatesAllSet := so.SinkAPIVersion != "" && so.SinkKind != "" && so.SinkName != ""
+	if so.SinkURI == "" && sinkCoordinatesAllEmpty ||
+		(!sinkCoordinatesAllEmpty && !sinkCoordinatesAllSet) {
+		return fmt.Errorf("sink requires a --sink option, an URI" +
+			"\nand/or a nonempty API version --sink-api-version option," +
+			"\nwith a nonempty kind --sink-kind option," +
+			"\nand with a nonempty name with the --sink-name")
+	}
+	return nil
 }
 
 func (so *SourceOptions) AsSinkDestination(namespace string) (*duckv1.Destination, error) {
@@ -47,6 +149,18 @@ func (so *SourceOptions) AsSinkDestination(namespace string) (*duckv1.Destinatio
 	}, nil
 }
 
+func (so *SourceOptions) replayFromTime() (*metav1.Time, error) {
+	if so.ReplayFrom == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, so.ReplayFrom)
+	if err != nil {
+		return nil, err
+	}
+	result := metav1.NewTime(t)
+	return &result, nil
+}
+
 func (so *SourceOptions) sinkURL() (*apis.URL, error) {
 	if so.SinkURI == "" {
 		return nil, nil
@@ -79,64 +193,114 @@ func NewSourceCommand(clients *pkg.Clients) *cobra.Command {
 		Long:  "Create a vSphere source to react to vSphere events",
 		Example: `# Create the source in the default namespace, sending events to the specified sink URI
 kn vsphere source --name source --address https://my-vsphere-endpoint.local --skip-tls-verify --secret-ref vsphere-credentials --sink-uri http://where.to.send.stuff
+# Create the source in the default namespace, sending events to the default broker
+kn vsphere source --name source --address https://my-vsphere-endpoint.local --secret-ref vsphere-credentials --sink broker:default
 # Create the source in the specified namespace, sending events to the specified service
 kn vsphere source --namespace ns --name source --address https://my-vsphere-endpoint.local --skip-tls-verify --secret-ref vsphere-credentials --sink-api-version v1 --sink-kind Service --sink-name the-service-name
 # Create the source in the specified namespace, sending events to the specified service with custom checkpoint behavior
 kn vsphere source --namespace ns --name source --address https://my-vsphere-endpoint.local --skip-tls-verify --secret-ref vsphere-credentials --sink-api-version v1 --sink-kind Service --sink-name the-service-name --checkpoint-age 1h --checkpoint-period 30s
+# Create the source in the default namespace, replaying events starting at the specified time
+kn vsphere source --name source --address https://my-vsphere-endpoint.local --secret-ref vsphere-credentials --sink-uri http://where.to.send.stuff --replay-from 2021-02-15T19:00:00Z
+# Create the source in the default namespace, only sending alarm events
+kn vsphere source --name source --address https://my-vsphere-endpoint.local --secret-ref vsphere-credentials --sink-uri http://where.to.send.stuff --event-type 'com.vmware.vsphere.Alarm*'
+# Create the source in the default namespace, only sending events of production VMs
+kn vsphere source --name source --address https://my-vsphere-endpoint.local --secret-ref vsphere-credentials --sink-uri http://where.to.send.stuff --cel-filter 'data.Vm.Name.startsWith("prod-")'
+# Create the source in the default namespace, polling a large vCenter less often for more events at once
+kn vsphere source --name source --address https://my-vsphere-endpoint.local --secret-ref vsphere-credentials --sink broker:default --poll-interval 30s --page-size 1000
+# Create the source in the default namespace, labeled and annotated with its owning team
+kn vsphere source --name source --address https://my-vsphere-endpoint.local --secret-ref vsphere-credentials --sink broker:default --label team=infra --annotation owner=infra@example.com
+# Create the source in the default namespace with the vCenter and credentials of the specified template
+kn vsphere source --name source --template prod-vcenter --sink broker:default
+# Create the source of a manifest, with another name and sink
+kn vsphere source --filename source.yaml --name other-source --sink broker:other
+# Create the source of a manifest, logging its events instead of sending them to validate its filter and transform
+kn vsphere source --filename source.yaml --sink none
 `,
+		// accept stray arguments as before the subcommands were added
+		Args: cobra.ArbitraryArgs,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := options.validateOutput(); err != nil {
+				return err
+			}
+			if options.CELFilter != "" {
+				if _, err := vsphere.CompileCELFilter(options.CELFilter); err != nil {
+					return fmt.Errorf("--cel-filter has an invalid value: %w", err)
+				}
+			}
+			if options.Filename != "" {
+				// the manifest with the flags applied is validated once read
+				return options.applySinkShorthand()
+			}
 			if options.Name == "" {
 				return fmt.Errorf("'name' requires a nonempty name provided with the --name option")
 			}
-			if options.Address == "" {
+			if options.Address == "" && options.Template == "" {
 				return fmt.Errorf("'address' requires a nonempty address provided with the --address option")
 			}
-			if options.SecretRef == "" {
+			if options.SecretRef == "" && options.Template == "" {
 				return fmt.Errorf("'secret-ref' requires a nonempty secret reference provided with the --secret-ref option")
 			}
-			sinkCoordinatesAllEmpty := options.SinkAPIVersion == "" && options.SinkKind == "" && options.SinkName == ""
-			sinkCoordinatesAllSet := options.SinkAPIVersion != "" && options.SinkKind != "" && options.SinkName != ""
-			if options.SinkURI == "" && sinkCoordinatesAllEmpty ||
-				(!sinkCoordinatesAllEmpty && !sinkCoordinatesAllSet) {
-				return fmt.Errorf("sink requires an URI" +
-					"\nand/or a nonempty API version --sink-api-version option," +
-					"\nwith a nonempty kind --sink-kind option," +
-					"\nand with a nonempty name with the --sink-name")
+			if err := options.applySinkShorthand(); err != nil {
+				return err
 			}
-			return nil
+			return options.validateSink()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
-			if err != nil {
-				return fmt.Errorf("failed to get namespace: %+v", err)
+			var source *v1alpha1.VSphereSource
+			if options.Filename != "" {
+				var err error
+				if source, err = options.manifestSource(cmd, clients); err != nil {
+					return err
+				}
+			} else {
+				namespace, err := clients.GetExplicitOrDefaultNamespace(options.Namespace)
+				if err != nil {
+					return fmt.Errorf("failed to get namespace: %+v", err)
+				}
+				address, err := url.Parse(options.Address)
+				if err != nil {
+					return fmt.Errorf("failed to parse source address: %+v", err)
+				}
+				sinkDestination, err := options.AsSinkDestination(namespace)
+				if err != nil {
+					return fmt.Errorf("failed to parse sink address: %+v", err)
+				}
+				replayFrom, err := options.replayFromTime()
+				if err != nil {
+					return fmt.Errorf("failed to parse replay start time: %+v", err)
+				}
+				source = newSource(namespace, sinkDestination, address, replayFrom, options)
 			}
-			address, err := url.Parse(options.Address)
-			if err != nil {
-				return fmt.Errorf("failed to parse source address: %+v", err)
-			}
-			sinkDestination, err := options.AsSinkDestination(namespace)
-			if err != nil {
-				return fmt.Errorf("failed to parse sink address: %+v", err)
+			if err := applyMetadata(&source.ObjectMeta, options.Labels, options.Annotations); err != nil {
+				return err
 			}
-			if _, err = clients.VSphereClientSet.
+			created, err := clients.VSphereClientSet.
 				SourcesV1alpha1().
-				VSphereSources(namespace).
-				Create(cmd.Context(), newSource(namespace, sinkDestination, address, options), metav1.CreateOptions{}); err != nil {
+				VSphereSources(source.Namespace).
+				Create(cmd.Context(), source, metav1.CreateOptions{})
+			if err != nil {
 				return fmt.Errorf("failed to create source: %+v", err)
 			}
-			fmt.Fprintln(cmd.OutOrStdout(), "Created source")
-			return nil
+			created.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("VSphereSource"))
+			return options.printObject(cmd.OutOrStdout(), created, "Created source")
 		},
 	}
 	flags := result.Flags()
 	flags.StringVarP(&options.Namespace, "namespace", "n", "", "namespace of the source to create (default namespace if omitted)")
+	flags.StringVarP(&options.Filename, "filename", "f", "",
+		"manifest of the source to create, or - for stdin, with the other flags overriding its fields")
 	flags.StringVar(&options.Name, "name", "", "name of the source to create")
-	_ = result.MarkFlagRequired("name")
+	flags.StringArrayVarP(&options.Labels, "label", "l", nil, "label to set on the source as key=value (can be repeated)")
+	flags.StringArrayVar(&options.Annotations, "annotation", nil, "annotation to set on the source as key=value (can be repeated)")
 	flags.StringVarP(&options.Address, "address", "a", "", "URL of ESXi or vCenter instance to connect to (same as VC_URL)")
-	_ = result.MarkFlagRequired("address")
 	flags.BoolVarP(&options.SkipTLSVerify, "skip-tls-verify", "k", false, "disables certificate verification for the source address (same as VC_INSECURE)")
 	flags.StringVarP(&options.SecretRef, "secret-ref", "s", "", "reference to the Kubernetes secret for the vSphere credentials needed for the source address")
-	_ = result.MarkFlagRequired("secret-ref")
+	flags.BoolVar(&options.AllowInsecureAddress, "allow-insecure-address", false,
+		"allows a source address without TLS, e.g. http://, which sends the credentials in clear text")
+	flags.StringVar(&options.Template, "template", "",
+		"name of the VSphereSourceTemplate whose settings, e.g. the address and secret, override those of the source (optional)")
+	flags.StringVar(&options.Sink, "sink", "",
+		"sink as broker:<name>, channel:<name>, ksvc:<name>, svc:<name>, the name of a Knative Service, an http(s) URL or none to log the events")
 	flags.StringVarP(&options.SinkURI, "sink-uri", "u", "", "sink URI (can be absolute, or relative to the referred sink resource)")
 	flags.StringVar(&options.SinkAPIVersion, "sink-api-version", "", "sink API version")
 	flags.StringVar(&options.SinkKind, "sink-kind", "", "sink kind")
@@ -145,10 +309,40 @@ kn vsphere source --namespace ns --name source --address https://my-vsphere-endp
 		"maximum allowed age for replaying events determined by last successful event in checkpoint")
 	flags.DurationVar(&options.CheckpointPeriod, "checkpoint-period", vsphere.CheckpointDefaultPeriod,
 		"period between saving checkpoints")
+	flags.StringVar(&options.ReplayFrom, "replay-from", "",
+		"RFC3339 timestamp to start replaying events from when no checkpoint exists (optional)")
+	flags.DurationVar(&options.PollInterval, "poll-interval", vsphere.CollectorDefaultPollInterval,
+		"maximum delay between polls of vCenter events while there are no new events")
+	flags.Int32Var(&options.PageSize, "page-size", vsphere.CollectorDefaultPageSize,
+		fmt.Sprintf("maximum number of vCenter events read per poll, at most %d", vsphere.CollectorMaxPageSize))
+	flags.BoolVar(&options.IncludeTasks, "include-tasks", false, "also send events for vSphere task lifecycle changes")
+	flags.BoolVar(&options.IncludeContentLibrary, "include-content-library", false,
+		"also send events for content library and library item changes")
+	flags.BoolVar(&options.IncludeTags, "include-tags", false,
+		"also send events when tags are attached to or detached from objects")
+	flags.BoolVar(&options.NormalizeAlarms, "normalize-alarms", false,
+		"send alarm events with a type per alarm event class and a normalized JSON payload")
+	flags.BoolVar(&options.GroupVSANEvents, "group-vsan-events", false,
+		"send vSAN events with the common type prefix com.vmware.vsphere.vsan.")
+	flags.StringSliceVar(&options.EventTypes, "event-type", nil,
+		"only send events with a type matching one of these glob patterns, e.g. com.vmware.vsphere.Alarm* (optional)")
+	flags.StringVar(&options.CELFilter, "cel-filter", "",
+		`only send events for which this CEL expression returns true, e.g. data.Vm.Name.startsWith("prod-") (optional)`)
+	options.addOutputFlag(&result, "", outputJSON, outputYAML, outputName)
+	options.addQuietFlag(&result)
+	result.AddCommand(NewSourceSetSinkCommand(clients))
+	result.AddCommand(NewSourceRestartCommand(clients))
+	result.AddCommand(NewSourcePauseCommand(clients))
+	result.AddCommand(NewSourceResumeCommand(clients))
+	result.AddCommand(NewSourceExportCheckpointCommand(clients))
+	result.AddCommand(NewSourceImportCheckpointCommand(clients))
+	result.AddCommand(NewSourceListCommand(clients))
+	result.AddCommand(NewSourceDescribeCommand(clients))
+	result.AddCommand(NewSourceCloneCommand(clients))
 	return &result
 }
 
-func newSource(namespace string, sinkDestination *duckv1.Destination, address *url.URL, options SourceOptions) *v1alpha1.VSphereSource {
+func newSource(namespace string, sinkDestination *duckv1.Destination, address *url.URL, replayFrom *metav1.Time, options SourceOptions) *v1alpha1.VSphereSource {
 	return &v1alpha1.VSphereSource{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: namespace,
@@ -169,7 +363,154 @@ func newSource(namespace string, sinkDestination *duckv1.Destination, address *u
 				// rounding errors are ok here
 				MaxAgeSeconds: int64(options.CheckpointMaxAge.Seconds()),
 				PeriodSeconds: int64(options.CheckpointPeriod.Seconds()),
+				ReplayFrom:    replayFrom,
 			},
+			TemplateRef:           options.Template,
+			EventCollector:        options.eventCollector(),
+			AllowInsecureAddress:  options.AllowInsecureAddress,
+			IncludeTasks:          options.IncludeTasks,
+			IncludeContentLibrary: options.IncludeContentLibrary,
+			IncludeTags:           options.IncludeTags,
+			NormalizeAlarms:       options.NormalizeAlarms,
+			GroupVSANEvents:       options.GroupVSANEvents,
+			Filter:                options.eventFilter(),
+			LogOnly:               options.LogOnly,
 		},
 	}
 }
+
+// manifestSource reads the source of the --filename manifest, applies the
+// explicitly set flags on top of it and validates the result.
+func (so *SourceOptions) manifestSource(cmd *cobra.Command, clients *pkg.Clients) (*v1alpha1.VSphereSource, error) {
+	source := &v1alpha1.VSphereSource{}
+	if err := readManifest(cmd.InOrStdin(), so.Filename, "VSphereSource", source); err != nil {
+		return nil, err
+	}
+	meta, err := manifestMeta(clients, so.Namespace, source.ObjectMeta)
+	if err != nil {
+		return nil, err
+	}
+	source.ObjectMeta = meta
+	source.Status = v1alpha1.VSphereSourceStatus{}
+
+	changed := cmd.Flags().Changed
+	if changed("name") {
+		source.Name = so.Name
+	}
+	if changed("address") {
+		address, err := url.Parse(so.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse source address: %+v", err)
+		}
+		source.Spec.Address = apis.URL(*address)
+	}
+	if changed("skip-tls-verify") {
+		source.Spec.SkipTLSVerify = so.SkipTLSVerify
+	}
+	if changed("secret-ref") {
+		source.Spec.SecretRef.Name = so.SecretRef
+	}
+	if changed("allow-insecure-address") {
+		source.Spec.AllowInsecureAddress = so.AllowInsecureAddress
+	}
+	if changed("template") {
+		source.Spec.TemplateRef = so.Template
+	}
+	if changed("sink") || changed("sink-uri") || changed("sink-api-version") || changed("sink-kind") || changed("sink-name") {
+		sinkDestination, err := so.AsSinkDestination(source.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sink address: %+v", err)
+		}
+		source.Spec.Sink = *sinkDestination
+		source.Spec.LogOnly = so.LogOnly
+	}
+	if changed("checkpoint-age") {
+		source.Spec.CheckpointConfig.MaxAgeSeconds = int64(so.CheckpointMaxAge.Seconds())
+	}
+	if changed("checkpoint-period") {
+		source.Spec.CheckpointConfig.PeriodSeconds = int64(so.CheckpointPeriod.Seconds())
+	}
+	if changed("replay-from") {
+		replayFrom, err := so.replayFromTime()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse replay start time: %+v", err)
+		}
+		source.Spec.CheckpointConfig.ReplayFrom = replayFrom
+	}
+	if changed("poll-interval") || changed("page-size") {
+		if source.Spec.EventCollector == nil {
+			source.Spec.EventCollector = &v1alpha1.VEventCollectorSpec{}
+		}
+		if changed("poll-interval") {
+			source.Spec.EventCollector.PollIntervalSeconds = int64(so.PollInterval.Seconds())
+		}
+		if changed("page-size") {
+			source.Spec.EventCollector.PageSize = so.PageSize
+		}
+	}
+	if changed("include-tasks") {
+		source.Spec.IncludeTasks = so.IncludeTasks
+	}
+	if changed("include-content-library") {
+		source.Spec.IncludeContentLibrary = so.IncludeContentLibrary
+	}
+	if changed("include-tags") {
+		source.Spec.IncludeTags = so.IncludeTags
+	}
+	if changed("normalize-alarms") {
+		source.Spec.NormalizeAlarms = so.NormalizeAlarms
+	}
+	if changed("group-vsan-events") {
+		source.Spec.GroupVSANEvents = so.GroupVSANEvents
+	}
+	if changed("event-type") || changed("cel-filter") {
+		filter := &v1alpha1.VFilterSpec{}
+		if source.Spec.Filter != nil {
+			filter = source.Spec.Filter
+		}
+		if changed("event-type") {
+			filter.EventTypes = so.EventTypes
+		}
+		if changed("cel-filter") {
+			filter.CEL = so.CELFilter
+		}
+		source.Spec.Filter = filter
+		if len(filter.EventTypes) == 0 && filter.CEL == "" {
+			source.Spec.Filter = nil
+		}
+	}
+
+	if source.Name == "" {
+		return nil, fmt.Errorf("'name' requires a nonempty name provided in the manifest or with the --name option")
+	}
+	// validate what the webhook would, after defaulting with the template
+	ctx := v1alpha1.WithTemplates(cmd.Context(), func(ctx context.Context, name string) (*v1alpha1.VSphereSourceTemplate, error) {
+		return clients.VSphereClientSet.SourcesV1alpha1().VSphereSourceTemplates().Get(ctx, name, metav1.GetOptions{})
+	})
+	defaulted := source.DeepCopy()
+	defaulted.SetDefaults(ctx)
+	if err := defaulted.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("invalid source: %+v", err)
+	}
+	return source, nil
+}
+
+// eventCollector returns the event collector config of the flags, nil if the
+// defaults are used
+func (so *SourceOptions) eventCollector() *v1alpha1.VEventCollectorSpec {
+	if so.PollInterval == vsphere.CollectorDefaultPollInterval && so.PageSize == vsphere.CollectorDefaultPageSize {
+		return nil
+	}
+	return &v1alpha1.VEventCollectorSpec{
+		// rounding errors are ok here
+		PollIntervalSeconds: int64(so.PollInterval.Seconds()),
+		PageSize:            so.PageSize,
+	}
+}
+
+func (so *SourceOptions) eventFilter() *v1alpha1.VFilterSpec {
+	if len(so.EventTypes) == 0 && so.CELFilter == "" {
+		return nil
+	}
+	return &v1alpha1.VFilterSpec{EventTypes: so.EventTypes, CEL: so.CELFilter}
+}
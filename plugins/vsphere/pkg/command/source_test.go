@@ -6,12 +6,17 @@ SPDX-License-Identifier: Apache-2.0
 package command_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/url"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
@@ -50,10 +55,28 @@ func TestNewSourceCommand(t *testing.T) {
 		checkFlag(t, sourceCommand, "address")
 		checkFlag(t, sourceCommand, "skip-tls-verify")
 		checkFlag(t, sourceCommand, "secret-ref")
+		checkFlag(t, sourceCommand, "allow-insecure-address")
+		checkFlag(t, sourceCommand, "template")
+		checkFlag(t, sourceCommand, "sink")
 		checkFlag(t, sourceCommand, "sink-uri")
 		checkFlag(t, sourceCommand, "sink-api-version")
 		checkFlag(t, sourceCommand, "sink-kind")
 		checkFlag(t, sourceCommand, "sink-name")
+		checkFlag(t, sourceCommand, "replay-from")
+		checkFlag(t, sourceCommand, "poll-interval")
+		checkFlag(t, sourceCommand, "page-size")
+		checkFlag(t, sourceCommand, "include-tasks")
+		checkFlag(t, sourceCommand, "include-content-library")
+		checkFlag(t, sourceCommand, "include-tags")
+		checkFlag(t, sourceCommand, "normalize-alarms")
+		checkFlag(t, sourceCommand, "group-vsan-events")
+		checkFlag(t, sourceCommand, "event-type")
+		checkFlag(t, sourceCommand, "cel-filter")
+		checkFlag(t, sourceCommand, "filename")
+		checkFlag(t, sourceCommand, "label")
+		checkFlag(t, sourceCommand, "annotation")
+		checkFlag(t, sourceCommand, "output")
+		checkFlag(t, sourceCommand, "quiet")
 		assert.Assert(t, sourceCommand.RunE != nil)
 	})
 
@@ -151,7 +174,7 @@ func TestNewSourceCommand(t *testing.T) {
 
 			err := sourceCommand.Execute()
 
-			assert.ErrorContains(t, err, `sink requires an URI
+			assert.ErrorContains(t, err, `sink requires a --sink option, an URI
 and/or a nonempty API version --sink-api-version option,
 with a nonempty kind --sink-kind option,
 and with a nonempty name with the --sink-name`)
@@ -203,6 +226,632 @@ and with a nonempty name with the --sink-name`)
 		assertSinkReference(t, source.Spec.Sink.Ref, sinkAPIVersion, sinkKind, namespace, sinkName)
 	})
 
+	sinkShorthandMatrix := []struct {
+		sink             string
+		apiVersion, kind string
+		name             string
+	}{
+		{"broker:default", "eventing.knative.dev/v1", "Broker", "default"},
+		{"channel:events", "messaging.knative.dev/v1", "Channel", "events"},
+		{"ksvc:event-display", "serving.knative.dev/v1", "Service", "event-display"},
+		{"svc:event-display", "v1", "Service", "event-display"},
+		{"event-display", "serving.knative.dev/v1", "Service", "event-display"},
+	}
+	for _, sinkTestCase := range sinkShorthandMatrix {
+		t.Run(fmt.Sprintf("creates source with %s sink shorthand", sinkTestCase.sink), func(t *testing.T) {
+			namespace := "ns"
+			sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+			sourceCommand.SetArgs([]string{
+				"--namespace", namespace,
+				"--name", sourceName,
+				"--address", sourceAddress,
+				"--secret-ref", secretRef,
+				"--sink", sinkTestCase.sink,
+			})
+
+			err := sourceCommand.Execute()
+
+			source := retrieveCreatedSource(t, err, vSphereClientSet, namespace, sourceName)
+			assert.Check(t, source.Spec.Sink.URI == nil)
+			assertSinkReference(t, source.Spec.Sink.Ref, sinkTestCase.apiVersion, sinkTestCase.kind, namespace, sinkTestCase.name)
+		})
+	}
+
+	t.Run("creates source with sink URL shorthand", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink", sinkURI,
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Equal(t, source.Spec.Sink.URI.String(), sinkURI)
+		assert.Check(t, source.Spec.Sink.Ref == nil)
+	})
+
+	t.Run("creates source with sink shorthand and relative sink URI", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink", "broker:default",
+			"--sink-uri", "/relative/uri",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Equal(t, source.Spec.Sink.URI.String(), "/relative/uri")
+		assertSinkReference(t, source.Spec.Sink.Ref, "eventing.knative.dev/v1", "Broker", defaultNamespace, "default")
+	})
+
+	t.Run("creates log-only source with none sink", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink", "none",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Check(t, source.Spec.LogOnly)
+		assert.Check(t, source.Spec.Sink.URI == nil)
+		assert.Check(t, source.Spec.Sink.Ref == nil)
+	})
+
+	invalidSinkShorthandMatrix := []struct {
+		description string
+		args        []string
+		err         string
+	}{
+		{"unknown prefix", []string{"--sink", "gateway:gw"}, `unsupported sink prefix "gateway"`},
+		{"missing name", []string{"--sink", "broker:"}, `sink "broker:" requires a name after the "broker" prefix`},
+		{"URL and sink URI", []string{"--sink", sinkURI, "--sink-uri", sinkURI}, "--sink with a URL cannot be combined with --sink-uri"},
+		{"none and sink URI", []string{"--sink", "none", "--sink-uri", sinkURI}, "--sink none cannot be combined with --sink-uri"},
+		{"sink coordinates", []string{"--sink", "broker:default", "--sink-kind", "Service"},
+			"--sink cannot be combined with --sink-api-version, --sink-kind or --sink-name"},
+	}
+	for _, sinkTestCase := range invalidSinkShorthandMatrix {
+		t.Run(fmt.Sprintf("fails to execute with %s in the sink shorthand", sinkTestCase.description), func(t *testing.T) {
+			sourceCommand, _ := sourceCommand(regularClientConfig())
+			sourceCommand.SetArgs(append([]string{
+				"--name", sourceName,
+				"--address", sourceAddress,
+				"--secret-ref", secretRef,
+			}, sinkTestCase.args...))
+
+			err := sourceCommand.Execute()
+
+			assert.ErrorContains(t, err, sinkTestCase.err)
+		})
+	}
+
+	t.Run("creates source with replay start time", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--replay-from", "2021-02-15T19:20:35Z",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assertBasicSource(t, &source.Spec, sourceAddress, secretRef, false)
+		assert.Assert(t, source.Spec.CheckpointConfig.ReplayFrom != nil)
+		assert.Check(t, source.Spec.CheckpointConfig.ReplayFrom.Equal(&metav1.Time{Time: time.Date(2021, 2, 15, 19, 20, 35, 0, time.UTC)}))
+	})
+
+	t.Run("creates source with the default event collector", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Check(t, source.Spec.EventCollector == nil)
+	})
+
+	t.Run("creates source with custom poll interval and page size", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--poll-interval", "30s",
+			"--page-size", "1000",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.DeepEqual(t, source.Spec.EventCollector, &v1alpha1.VEventCollectorSpec{
+			PollIntervalSeconds: 30,
+			PageSize:            1000,
+		})
+	})
+
+	t.Run("creates source including task events", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--include-tasks",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Check(t, source.Spec.IncludeTasks)
+	})
+
+	t.Run("defines a source including content library events", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--include-content-library",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Check(t, source.Spec.IncludeContentLibrary)
+	})
+
+	t.Run("defines a source including tag association events", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--include-tags",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Check(t, source.Spec.IncludeTags)
+	})
+
+	t.Run("defines a source with normalized alarm events", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--normalize-alarms",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Check(t, source.Spec.NormalizeAlarms)
+	})
+
+	t.Run("defines a source with grouped vSAN events", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--group-vsan-events",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Check(t, source.Spec.GroupVSANEvents)
+	})
+
+	t.Run("creates source with an insecure address", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", "http://my-vsphere-endpoint.example.com",
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--allow-insecure-address",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Check(t, source.Spec.AllowInsecureAddress)
+	})
+
+	t.Run("creates source with a template instead of an address and secret reference", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--template", "prod",
+			"--sink-uri", sinkURI,
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Equal(t, source.Spec.TemplateRef, "prod")
+		assert.Equal(t, source.Spec.Address.String(), "")
+	})
+
+	t.Run("defines an event filter", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--event-type", "com.vmware.vsphere.alarm.*",
+			"--event-type", "com.vmware.vsphere.VmPoweredOnEvent",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.DeepEqual(t, source.Spec.Filter, &v1alpha1.VFilterSpec{
+			EventTypes: []string{"com.vmware.vsphere.alarm.*", "com.vmware.vsphere.VmPoweredOnEvent"},
+		})
+	})
+
+	t.Run("defines a CEL event filter", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--event-type", "com.vmware.vsphere.VmPoweredOnEvent",
+			"--cel-filter", `data.Vm.Name.startsWith("prod-")`,
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.DeepEqual(t, source.Spec.Filter, &v1alpha1.VFilterSpec{
+			EventTypes: []string{"com.vmware.vsphere.VmPoweredOnEvent"},
+			CEL:        `data.Vm.Name.startsWith("prod-")`,
+		})
+	})
+
+	t.Run("fails to execute with an invalid CEL event filter", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--cel-filter", `data.Vm.Name.startsWith(`,
+		})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "--cel-filter has an invalid value")
+	})
+
+	t.Run("defines labels and annotations", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--label", "team=infra",
+			"-l", "example.com/cost-center=1234",
+			"--annotation", "owner=infra@example.com",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.DeepEqual(t, source.Labels, map[string]string{"team": "infra", "example.com/cost-center": "1234"})
+		assert.DeepEqual(t, source.Annotations, map[string]string{"owner": "infra@example.com"})
+	})
+
+	t.Run("fails to execute with an invalid label", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--label", "team=infra team",
+		})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, `--label has an invalid value "infra team"`)
+	})
+
+	t.Run("prints the created source as JSON", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		out := &bytes.Buffer{}
+		sourceCommand.SetOut(out)
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"-o", "json",
+		})
+
+		err := sourceCommand.Execute()
+
+		assert.NilError(t, err)
+		var source v1alpha1.VSphereSource
+		assert.NilError(t, json.Unmarshal(out.Bytes(), &source), out.String())
+		assert.Equal(t, source.Kind, "VSphereSource")
+		assert.Equal(t, source.APIVersion, "sources.tanzu.vmware.com/v1alpha1")
+		assert.Equal(t, source.Name, sourceName)
+		assertBasicSource(t, &source.Spec, sourceAddress, secretRef, false)
+	})
+
+	t.Run("prints the name of the created source", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		out := &bytes.Buffer{}
+		sourceCommand.SetOut(out)
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"-o", "name",
+		})
+
+		err := sourceCommand.Execute()
+
+		assert.NilError(t, err)
+		assert.Equal(t, out.String(), "vspheresource.sources.tanzu.vmware.com/"+sourceName+"\n")
+	})
+
+	t.Run("prints nothing when quiet", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		out := &bytes.Buffer{}
+		sourceCommand.SetOut(out)
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--quiet",
+		})
+
+		err := sourceCommand.Execute()
+
+		retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, sourceName)
+		assert.Equal(t, out.String(), "")
+	})
+
+	t.Run("fails to execute with an unsupported output format", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"-o", "wide",
+		})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, `unsupported output format "wide", use one of json|yaml|name`)
+	})
+
+	t.Run("fails to execute when quiet with an output format", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"-o", "name",
+			"-q",
+		})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "--quiet cannot be combined with --output")
+	})
+
+	t.Run("fails to execute with an invalid replay start time", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--name", sourceName,
+			"--address", sourceAddress,
+			"--secret-ref", secretRef,
+			"--sink-uri", sinkURI,
+			"--replay-from", "yesterday",
+		})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "failed to parse replay start time")
+	})
+
+	const sourceManifest = `apiVersion: sources.tanzu.vmware.com/v1alpha1
+kind: VSphereSource
+metadata:
+  name: manifest-source
+  namespace: manifest-ns
+  labels:
+    team: a
+  resourceVersion: "42"
+spec:
+  address: https://manifest-vsphere-endpoint.example.com
+  secretRef:
+    name: manifest-creds
+  sink:
+    uri: https://manifest-sink.example.com
+  includeTasks: true
+status:
+  observedGeneration: 1
+`
+
+	t.Run("creates the source of a manifest file", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{
+			"--filename", writeManifest(t, sourceManifest),
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, "manifest-ns", "manifest-source")
+		assertBasicSource(t, &source.Spec, "https://manifest-vsphere-endpoint.example.com", "manifest-creds", false)
+		assert.Equal(t, source.Spec.Sink.URI.String(), "https://manifest-sink.example.com")
+		assert.Check(t, source.Spec.IncludeTasks)
+		assert.Equal(t, source.Labels["team"], "a")
+		assert.Equal(t, source.ResourceVersion, "")
+		assert.Equal(t, source.Status.ObservedGeneration, int64(0))
+	})
+
+	t.Run("creates the source of a manifest from stdin with the flags applied on top", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetIn(strings.NewReader(sourceManifest))
+		sourceCommand.SetArgs([]string{
+			"-f", "-",
+			"--namespace", "ns",
+			"--name", sourceName,
+			"--secret-ref", secretRef,
+			"--sink", "broker:default",
+			"--event-type", "com.vmware.vsphere.alarm.*",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, "ns", sourceName)
+		assertBasicSource(t, &source.Spec, "https://manifest-vsphere-endpoint.example.com", secretRef, false)
+		assertSinkReference(t, source.Spec.Sink.Ref, "eventing.knative.dev/v1", "Broker", "ns", "default")
+		assert.Check(t, source.Spec.Sink.URI == nil)
+		assert.Check(t, source.Spec.IncludeTasks)
+		assert.DeepEqual(t, source.Spec.Filter, &v1alpha1.VFilterSpec{EventTypes: []string{"com.vmware.vsphere.alarm.*"}})
+	})
+
+	t.Run("creates the source of a manifest with the labels and annotations of the flags merged", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetIn(strings.NewReader(sourceManifest))
+		sourceCommand.SetArgs([]string{
+			"-f", "-",
+			"--label", "team=b",
+			"--label", "tier=prod",
+			"--annotation", "owner=b@example.com",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, "manifest-ns", "manifest-source")
+		assert.DeepEqual(t, source.Labels, map[string]string{"team": "b", "tier": "prod"})
+		assert.DeepEqual(t, source.Annotations, map[string]string{"owner": "b@example.com"})
+	})
+
+	t.Run("creates the source of a manifest without namespace in the default namespace", func(t *testing.T) {
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig())
+		sourceCommand.SetIn(strings.NewReader(strings.Replace(sourceManifest, "  namespace: manifest-ns\n", "", 1)))
+		sourceCommand.SetArgs([]string{"-f", "-"})
+
+		err := sourceCommand.Execute()
+
+		retrieveCreatedSource(t, err, vSphereClientSet, defaultNamespace, "manifest-source")
+	})
+
+	t.Run("fails to execute with an invalid manifest", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetIn(strings.NewReader(strings.Replace(sourceManifest, "  secretRef:", "  secretReference:", 1)))
+		sourceCommand.SetArgs([]string{"-f", "-"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "failed to parse manifest")
+	})
+
+	t.Run("fails to execute with a manifest of another kind", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetIn(strings.NewReader(strings.Replace(sourceManifest, "kind: VSphereSource", "kind: VSphereBinding", 1)))
+		sourceCommand.SetArgs([]string{"-f", "-"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "manifest must define a VSphereSource")
+	})
+
+	t.Run("fails to execute when the manifest with the flags applied is invalid", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetIn(strings.NewReader(sourceManifest))
+		sourceCommand.SetArgs([]string{
+			"-f", "-",
+			"--address", "http://insecure-vsphere-endpoint.example.com",
+		})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "invalid source")
+	})
+
+	t.Run("creates the source of a manifest with the settings of its template", func(t *testing.T) {
+		template := &v1alpha1.VSphereSourceTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+			Spec: v1alpha1.VSphereSourceTemplateSpec{
+				Address: &apis.URL{Scheme: "https", Host: "prod-vsphere-endpoint.example.com"},
+			},
+		}
+		sourceCommand, vSphereClientSet := sourceCommand(regularClientConfig(), template)
+		sourceCommand.SetIn(strings.NewReader(sourceManifest))
+		sourceCommand.SetArgs([]string{
+			"-f", "-",
+			"--address", "http://insecure-vsphere-endpoint.example.com",
+			"--template", "prod",
+		})
+
+		err := sourceCommand.Execute()
+
+		source := retrieveCreatedSource(t, err, vSphereClientSet, "manifest-ns", "manifest-source")
+		assert.Equal(t, source.Spec.TemplateRef, "prod")
+	})
+
+	t.Run("fails to execute when the template of the manifest does not exist", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetIn(strings.NewReader(sourceManifest))
+		sourceCommand.SetArgs([]string{
+			"-f", "-",
+			"--template", "prod",
+		})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "VSphereSourceTemplate prod not found")
+	})
+
+	t.Run("fails to execute with a missing manifest file", func(t *testing.T) {
+		sourceCommand, _ := sourceCommand(regularClientConfig())
+		sourceCommand.SetArgs([]string{"-f", "does-not-exist.yaml"})
+
+		err := sourceCommand.Execute()
+
+		assert.ErrorContains(t, err, "failed to read manifest")
+	})
+
 	t.Run("fails to execute when default namespace retrieval fails", func(t *testing.T) {
 		namespaceError := fmt.Errorf("no default namespace, oops")
 		sourceCommand, _ := sourceCommand(failingClientConfig(namespaceError))
@@ -340,6 +989,14 @@ func newSource(t *testing.T, namespace, name, address, secretRef, sinkURI string
 	}
 }
 
+// writeManifest writes the given manifest to a temporary file and returns its
+// path
+func writeManifest(t *testing.T, manifest string) string {
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	assert.NilError(t, ioutil.WriteFile(path, []byte(manifest), 0600))
+	return path
+}
+
 func parseURI(t *testing.T, uri string) apis.URL {
 	result, err := url.Parse(uri)
 	assert.NilError(t, err)
@@ -7,25 +7,142 @@ package command
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/discovery"
+
+	"github.com/vmware-tanzu/sources-for-knative/pkg/apis/sources/v1alpha1"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
 )
 
 var Version string
 var BuildDate string
 var GitRevision string
 
+// expectedResources are the resources of the sources API used by the plugin
+var expectedResources = []string{"vspheresources", "vspherebindings"}
+
+// versionInfo is the --output of the 'kn version' command
+type versionInfo struct {
+	Version     string `json:"version"`
+	BuildDate   string `json:"buildDate"`
+	GitRevision string `json:"gitRevision"`
+	// APIVersion is the version of the sources API the plugin expects
+	APIVersion string `json:"apiVersion"`
+	// ClusterAPIVersions are the versions of the sources API served by the
+	// cluster, unset if the cluster is not checked
+	ClusterAPIVersions []string `json:"clusterAPIVersions,omitempty"`
+}
+
+type VersionOptions struct {
+	Client bool
+
+	OutputOptions
+}
+
 // NewVersionCommand implements 'kn version' command
-func NewVersionCommand() *cobra.Command {
-	return &cobra.Command{
+func NewVersionCommand(clients *pkg.Clients) *cobra.Command {
+	options := VersionOptions{}
+	result := cobra.Command{
 		Use:   "version",
 		Short: "Prints the plugin version",
+		Long: "Prints the plugin version, its build metadata and the version of the sources API it expects,\n" +
+			"and warns if the cluster does not serve that version, e.g. after upgrading only the plugin or the controller.",
+		Example: `# Print the version of the plugin and check the sources API of the cluster
+kn vsphere version
+# Print the version of the plugin only
+kn vsphere version --client
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return options.validateOutput()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			info := versionInfo{
+				Version:     Version,
+				BuildDate:   BuildDate,
+				GitRevision: GitRevision,
+				APIVersion:  v1alpha1.SchemeGroupVersion.String(),
+			}
+			var warnings []string
+			if !options.Client {
+				info.ClusterAPIVersions, warnings = checkAPIVersions(clients.ClientSet.Discovery())
+			}
+
 			out := cmd.OutOrStdout()
-			fmt.Fprintf(out, "Version:      %s\n", Version)
-			fmt.Fprintf(out, "Build Date:   %s\n", BuildDate)
-			fmt.Fprintf(out, "Git Revision: %s\n", GitRevision)
+			if options.Output != "" {
+				if err := options.printValue(out, info); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintf(out, "Version:      %s\n", info.Version)
+				fmt.Fprintf(out, "Build Date:   %s\n", info.BuildDate)
+				fmt.Fprintf(out, "Git Revision: %s\n", info.GitRevision)
+				fmt.Fprintf(out, "API Version:  %s\n", info.APIVersion)
+				if !options.Client {
+					clusterVersions := strings.Join(info.ClusterAPIVersions, ", ")
+					if clusterVersions == "" {
+						clusterVersions = "none"
+					}
+					fmt.Fprintf(out, "Cluster APIs: %s\n", clusterVersions)
+				}
+			}
+			for _, w := range warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "WARNING: %s\n", w)
+			}
 			return nil
 		},
 	}
+	flags := result.Flags()
+	flags.BoolVar(&options.Client, "client", false, "only print the version of the plugin, without checking the cluster")
+	options.addOutputFlag(&result, "", outputJSON, outputYAML)
+	return &result
+}
+
+// checkAPIVersions returns the versions of the sources API served by the
+// cluster, and warnings if they do not match the version the plugin expects.
+func checkAPIVersions(client discovery.DiscoveryInterface) ([]string, []string) {
+	expected := v1alpha1.SchemeGroupVersion
+
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return nil, []string{fmt.Sprintf("could not check the sources API of the cluster: %v", err)}
+	}
+	var served []string
+	for _, g := range groups.Groups {
+		if g.Name != expected.Group {
+			continue
+		}
+		for _, v := range g.Versions {
+			served = append(served, v.GroupVersion)
+		}
+	}
+	if len(served) == 0 {
+		return nil, []string{fmt.Sprintf("the cluster does not serve the %s API, the controller may not be installed", expected.Group)}
+	}
+
+	found := false
+	for _, v := range served {
+		found = found || v == expected.String()
+	}
+	if !found {
+		return served, []string{fmt.Sprintf("the cluster serves %s but the plugin expects %s, upgrade the plugin or the controller",
+			strings.Join(served, ", "), expected.String())}
+	}
+
+	resources, err := client.ServerResourcesForGroupVersion(expected.String())
+	if err != nil {
+		return served, []string{fmt.Sprintf("could not check the resources of %s: %v", expected.String(), err)}
+	}
+	var warnings []string
+	for _, name := range expectedResources {
+		found := false
+		for _, r := range resources.APIResources {
+			found = found || r.Name == name
+		}
+		if !found {
+			warnings = append(warnings, fmt.Sprintf("the cluster does not serve %s of %s, upgrade the controller", name, expected.String()))
+		}
+	}
+	return served, warnings
 }
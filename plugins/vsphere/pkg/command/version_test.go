@@ -10,9 +10,13 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
-
 	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg"
+	"github.com/vmware-tanzu/sources-for-knative/plugins/vsphere/pkg/command"
 )
 
 const (
@@ -22,7 +26,7 @@ const (
 )
 
 func TestVersionSetup(t *testing.T) {
-	versionCommand := command.NewVersionCommand()
+	versionCommand := command.NewVersionCommand(&pkg.Clients{})
 
 	assert.Equal(t, versionCommand.Use, "version")
 	assert.Equal(t, versionCommand.Short, "Prints the plugin version")
@@ -33,22 +37,103 @@ func TestVersionOutput(t *testing.T) {
 	command.Version = fakeVersion
 	command.BuildDate = fakeBuildDate
 	command.GitRevision = fakeGitRevision
-	expectedOutput := fmt.Sprintf(`Version:      %s
+
+	t.Run("prints the versions of the plugin and the cluster", func(t *testing.T) {
+		expectedOutput := fmt.Sprintf(`Version:      %s
+Build Date:   %s
+Git Revision: %s
+API Version:  sources.tanzu.vmware.com/v1alpha1
+Cluster APIs: sources.tanzu.vmware.com/v1alpha1
+`, fakeVersion, fakeBuildDate, fakeGitRevision)
+
+		output, warnings, err := runVersionCmd(sourcesAPI("v1alpha1", "vspheresources", "vspherebindings"))
+
+		assert.NilError(t, err)
+		assert.Equal(t, output, expectedOutput)
+		assert.Equal(t, warnings, "")
+	})
+
+	t.Run("prints the version of the plugin only", func(t *testing.T) {
+		expectedOutput := fmt.Sprintf(`Version:      %s
 Build Date:   %s
 Git Revision: %s
+API Version:  sources.tanzu.vmware.com/v1alpha1
+`, fakeVersion, fakeBuildDate, fakeGitRevision)
+
+		output, warnings, err := runVersionCmd(nil, "--client")
+
+		assert.NilError(t, err)
+		assert.Equal(t, output, expectedOutput)
+		assert.Equal(t, warnings, "")
+	})
+
+	t.Run("warns when the cluster does not serve the sources API", func(t *testing.T) {
+		output, warnings, err := runVersionCmd(nil)
+
+		assert.NilError(t, err)
+		assert.Check(t, bytes.Contains([]byte(output), []byte("Cluster APIs: none\n")), output)
+		assert.Equal(t, warnings, "WARNING: the cluster does not serve the sources.tanzu.vmware.com API, the controller may not be installed\n")
+	})
+
+	t.Run("warns when the cluster serves another version of the sources API", func(t *testing.T) {
+		_, warnings, err := runVersionCmd(sourcesAPI("v1beta1", "vspheresources", "vspherebindings"))
+
+		assert.NilError(t, err)
+		assert.Equal(t, warnings, "WARNING: the cluster serves sources.tanzu.vmware.com/v1beta1 but the plugin expects "+
+			"sources.tanzu.vmware.com/v1alpha1, upgrade the plugin or the controller\n")
+	})
+
+	t.Run("warns when the cluster does not serve a resource of the sources API", func(t *testing.T) {
+		_, warnings, err := runVersionCmd(sourcesAPI("v1alpha1", "vspheresources"))
+
+		assert.NilError(t, err)
+		assert.Equal(t, warnings, "WARNING: the cluster does not serve vspherebindings of sources.tanzu.vmware.com/v1alpha1, upgrade the controller\n")
+	})
+}
+
+func TestVersionJSONOutput(t *testing.T) {
+	command.Version = fakeVersion
+	command.BuildDate = fakeBuildDate
+	command.GitRevision = fakeGitRevision
+	expectedOutput := fmt.Sprintf(`{
+  "version": "%s",
+  "buildDate": "%s",
+  "gitRevision": "%s",
+  "apiVersion": "sources.tanzu.vmware.com/v1alpha1",
+  "clusterAPIVersions": [
+    "sources.tanzu.vmware.com/v1alpha1"
+  ]
+}
 `, fakeVersion, fakeBuildDate, fakeGitRevision)
 
-	output, err := runVersionCmd()
+	output, _, err := runVersionCmd(sourcesAPI("v1alpha1", "vspheresources", "vspherebindings"), "-o", "json")
 
 	assert.NilError(t, err)
 	assert.Equal(t, output, expectedOutput)
 }
 
-func runVersionCmd() (string, error) {
-	versionCmd := command.NewVersionCommand()
+// sourcesAPI returns the discovery of the given version and resources of the
+// sources API
+func sourcesAPI(version string, resources ...string) *metav1.APIResourceList {
+	result := &metav1.APIResourceList{GroupVersion: "sources.tanzu.vmware.com/" + version}
+	for _, r := range resources {
+		result.APIResources = append(result.APIResources, metav1.APIResource{Name: r})
+	}
+	return result
+}
+
+func runVersionCmd(api *metav1.APIResourceList, args ...string) (string, string, error) {
+	client := k8sfake.NewSimpleClientset()
+	if api != nil {
+		client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{api}
+	}
+	versionCmd := command.NewVersionCommand(&pkg.Clients{ClientSet: client})
+	versionCmd.SetArgs(args)
 
 	output := new(bytes.Buffer)
+	warnings := new(bytes.Buffer)
 	versionCmd.SetOut(output)
+	versionCmd.SetErr(warnings)
 	err := versionCmd.Execute()
-	return output.String(), err
+	return output.String(), warnings.String(), err
 }